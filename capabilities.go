@@ -0,0 +1,84 @@
+package sleet
+
+import "strings"
+
+// Major 3DS versions listed in Capabilities.ThreeDSVersions
+const (
+	ThreeDSVersion1 = "1"
+	ThreeDSVersion2 = "2"
+)
+
+// Capabilities describes the optional features supported by a client, so callers can choose a gateway for a request
+type Capabilities struct {
+	ContextSupport           bool     // the client implements ClientWithContext
+	IncrementalAuthorization bool     // IncrementAuthorization can raise the amount of an existing authorization
+	Sale                     bool     // Sale authorizes and captures in a single request
+	Vault                    bool     // payment methods can be stored, retrieved and deleted
+	TransactionQuery         bool     // QueryTransaction looks up a transaction by its TransactionReference
+	FindByReference          bool     // FindByClientReference searches transactions by the references they were sent with
+	TimeoutReversal          bool     // ReverseTimedOutAuthorization reverses an authorization by its references
+	PartialAuthorization     bool     // AuthorizationRequest.AllowPartialAuth is sent to the PsP
+	PartialCapture           bool     // an authorization can be captured for less than its amount
+	MultipleCapture          bool     // an authorization can be captured more than once
+	PartialRefund            bool     // a capture can be refunded for less than its amount
+	Level2                   bool     // the tax amount and customer reference of AuthorizationRequest.Level3Data are sent
+	Level3                   bool     // the line items of AuthorizationRequest.Level3Data are sent
+	ThreeDSVersions          []string // major versions of AuthorizationRequest.ThreeDS results sent to the PsP
+	NetworkTokens            bool     // a network token can be authorized with its Cryptogram and ECI
+	ApplePay                 bool
+	GooglePay                bool
+	Currencies               []string // ISO 4217 currency codes accepted by the client, nil if any currency is accepted
+}
+
+// SupportsCurrency returns true if the client accepts amounts in the given currency
+func (c Capabilities) SupportsCurrency(currency string) bool {
+	if c.Currencies == nil {
+		return true
+	}
+	for _, supported := range c.Currencies {
+		if strings.EqualFold(supported, currency) {
+			return true
+		}
+	}
+	return false
+}
+
+// SupportsThreeDS returns true if 3DS results of the given version, such as "2.1.0", are sent to the PsP
+func (c Capabilities) SupportsThreeDS(version string) bool {
+	major := strings.SplitN(version, ".", 2)[0]
+	for _, supported := range c.ThreeDSVersions {
+		if supported == major {
+			return true
+		}
+	}
+	return false
+}
+
+// CapabilitiesReporter is implemented by clients which describe their Capabilities
+type CapabilitiesReporter interface {
+	Capabilities() Capabilities
+}
+
+// CapabilitiesOf returns the Capabilities the client reports. For clients which don't report them, only the optional
+// operations are described, by the interfaces the client implements.
+func CapabilitiesOf(client interface{}) Capabilities {
+	if reporter, ok := client.(CapabilitiesReporter); ok {
+		return reporter.Capabilities()
+	}
+	_, contextSupport := client.(ClientWithContext)
+	_, incremental := client.(IncrementAuthorizerWithContext)
+	_, sale := client.(SaleWithContext)
+	_, vault := client.(VaultWithContext)
+	_, query := client.(TransactionQuerierWithContext)
+	_, finder := client.(TransactionFinderWithContext)
+	_, reverser := client.(TimeoutReverserWithContext)
+	return Capabilities{
+		ContextSupport:           contextSupport,
+		IncrementalAuthorization: incremental,
+		Sale:                     sale,
+		Vault:                    vault,
+		TransactionQuery:         query,
+		FindByReference:          finder,
+		TimeoutReversal:          reverser,
+	}
+}
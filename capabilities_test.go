@@ -0,0 +1,72 @@
+package sleet
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCapabilitiesSupportsCurrency(t *testing.T) {
+	t.Run("Any Currency", func(t *testing.T) {
+		if !(Capabilities{}).SupportsCurrency("JPY") {
+			t.Error("expected any currency to be supported when Currencies is nil")
+		}
+	})
+
+	t.Run("Listed Currencies", func(t *testing.T) {
+		capabilities := Capabilities{Currencies: []string{"USD", "EUR"}}
+		if !capabilities.SupportsCurrency("usd") {
+			t.Error("expected USD to be supported")
+		}
+		if capabilities.SupportsCurrency("JPY") {
+			t.Error("expected JPY not to be supported")
+		}
+	})
+}
+
+func TestCapabilitiesSupportsThreeDS(t *testing.T) {
+	capabilities := Capabilities{ThreeDSVersions: []string{ThreeDSVersion2}}
+	if !capabilities.SupportsThreeDS("2.1.0") {
+		t.Error("expected 3DS 2.1.0 to be supported")
+	}
+	if capabilities.SupportsThreeDS("1.0.2") {
+		t.Error("expected 3DS 1.0.2 not to be supported")
+	}
+	if (Capabilities{}).SupportsThreeDS("2.2.0") {
+		t.Error("expected 3DS not to be supported without ThreeDSVersions")
+	}
+}
+
+// saleClient implements the client and Sale interfaces without reporting its Capabilities
+type saleClient struct {
+	ClientWithContext
+}
+
+func (saleClient) Sale(*SaleRequest) (*SaleResponse, error) {
+	return nil, nil
+}
+
+func (saleClient) SaleWithContext(context.Context, *SaleRequest) (*SaleResponse, error) {
+	return nil, nil
+}
+
+func TestCapabilitiesOf(t *testing.T) {
+	t.Run("Reported Capabilities", func(t *testing.T) {
+		reported := Capabilities{PartialCapture: true}
+		if got := CapabilitiesOf(reporterFunc(func() Capabilities { return reported })); !got.PartialCapture || got.Sale {
+			t.Errorf("expected the reported capabilities, got %+v", got)
+		}
+	})
+
+	t.Run("Implemented Interfaces", func(t *testing.T) {
+		got := CapabilitiesOf(saleClient{})
+		if !got.ContextSupport || !got.Sale || got.Vault || got.TimeoutReversal {
+			t.Errorf("expected context and sale support only, got %+v", got)
+		}
+	})
+}
+
+type reporterFunc func() Capabilities
+
+func (f reporterFunc) Capabilities() Capabilities {
+	return f()
+}
@@ -0,0 +1,30 @@
+package common
+
+import (
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// AmountFromDecimalString converts a decimal amount returned by a PsP, such as "10.50", to minor units of the currency.
+// Currencies missing from CURRENCIES are assumed to have 2 decimal places.
+func AmountFromDecimalString(amount string, currency string) (int64, error) {
+	value, err := decimal.NewFromString(amount)
+	if err != nil {
+		return 0, err
+	}
+	return value.Shift(currencyPrecision(currency)).Round(0).IntPart(), nil
+}
+
+// AmountFromFloat converts a decimal amount returned by a PsP as a JSON number to minor units of the currency.
+// Currencies missing from CURRENCIES are assumed to have 2 decimal places.
+func AmountFromFloat(amount float64, currency string) int64 {
+	return decimal.NewFromFloat(amount).Shift(currencyPrecision(currency)).Round(0).IntPart()
+}
+
+func currencyPrecision(currency string) int32 {
+	if currency, ok := CURRENCIES[Code(strings.ToUpper(currency))]; ok {
+		return int32(currency.Precision)
+	}
+	return 2
+}
@@ -0,0 +1,19 @@
+package common
+
+import "github.com/BoltApp/sleet"
+
+// TranslateDeclineReason looks up the code a PsP returned for a failed operation in the PsP's decline reasons. Payment
+// errors with a code missing from reasons are declined for an unknown reason, while other failures only have a decline
+// reason when their code is listed, e.g. when the issuer is unavailable.
+func TranslateDeclineReason(reasons map[string]sleet.DeclineReason, code string, resultType sleet.ResultType) sleet.DeclineReason {
+	if resultType == sleet.ResultTypeSuccess {
+		return ""
+	}
+	if reason, ok := reasons[code]; ok {
+		return reason
+	}
+	if resultType == sleet.ResultTypePaymentError {
+		return sleet.DeclineReasonUnknown
+	}
+	return ""
+}
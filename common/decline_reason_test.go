@@ -0,0 +1,33 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/BoltApp/sleet"
+)
+
+func TestTranslateDeclineReason(t *testing.T) {
+	reasons := map[string]sleet.DeclineReason{
+		"51": sleet.DeclineReasonInsufficientFunds,
+		"91": sleet.DeclineReasonIssuerUnavailable,
+	}
+	cases := []struct {
+		label      string
+		code       string
+		resultType sleet.ResultType
+		want       sleet.DeclineReason
+	}{
+		{"Approved", "00", sleet.ResultTypeSuccess, ""},
+		{"Known Decline", "51", sleet.ResultTypePaymentError, sleet.DeclineReasonInsufficientFunds},
+		{"Unknown Decline", "05", sleet.ResultTypePaymentError, sleet.DeclineReasonUnknown},
+		{"Known Server Error", "91", sleet.ResultTypeServerError, sleet.DeclineReasonIssuerUnavailable},
+		{"Validation Error", "E00003", sleet.ResultTypeAPIError, ""},
+	}
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			if got := TranslateDeclineReason(reasons, c.code, c.resultType); got != c.want {
+				t.Errorf("expected %q, got %q", c.want, got)
+			}
+		})
+	}
+}
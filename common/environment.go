@@ -1,12 +1,41 @@
 package common
 
+import (
+	"net/url"
+	"strings"
+)
+
 // Environment provides a common way of interacting with Sleet's PsP
 // Sandbox refers to non-live, typically test accounts and Production to live accounts
 // Done at the Sleet level to avoid clients having to import Payment specific data
 type Environment string
 
-// Only sandbox and production environments are supported
 const (
 	Sandbox    Environment = "sandbox"
 	Production Environment = "production"
+	// Custom is for endpoints of neither, such as a local stand-in for the PsP. Its URL is given with
+	// sleet.WithBaseURL, and the PsP is otherwise treated as in Sandbox, with test modes enabled.
+	Custom Environment = "custom"
 )
+
+// OverrideBaseURL points a PsP URL at baseURL: the scheme and host are replaced by those of baseURL, and the path of
+// baseURL, if any, is prefixed to the PsP's path. The PsP URL is kept when baseURL is empty. An invalid baseURL is
+// returned as it is, so requests fail rather than reach the PsP.
+func OverrideBaseURL(pspURL string, baseURL string) string {
+	if baseURL == "" {
+		return pspURL
+	}
+	base, err := url.Parse(baseURL)
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return baseURL
+	}
+	psp, err := url.Parse(pspURL)
+	if err != nil {
+		return baseURL
+	}
+	psp.Scheme = base.Scheme
+	psp.Host = base.Host
+	psp.Path = strings.TrimSuffix(base.Path, "/") + psp.Path
+	psp.RawPath = ""
+	return psp.String()
+}
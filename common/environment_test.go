@@ -0,0 +1,25 @@
+package common
+
+import "testing"
+
+func TestOverrideBaseURL(t *testing.T) {
+	cases := []struct {
+		label   string
+		pspURL  string
+		baseURL string
+		want    string
+	}{
+		{"No Base URL", "https://cert.api.firstdata.com/gateway/v2/payments", "", "https://cert.api.firstdata.com/gateway/v2/payments"},
+		{"Host", "https://cert.api.firstdata.com/gateway/v2/payments", "http://127.0.0.1:8080", "http://127.0.0.1:8080/gateway/v2/payments"},
+		{"Path Prefix", "https://apitest.authorize.net/xml/v1/request.api", "https://proxy.example.com/authorizenet/", "https://proxy.example.com/authorizenet/xml/v1/request.api"},
+		{"Query Kept", "https://secure.nmi.com/api/query.php?report_type=transaction", "http://localhost:9000", "http://localhost:9000/api/query.php?report_type=transaction"},
+		{"Invalid Base URL", "https://secure.nmi.com/api/transact.php", "localhost:9000", "localhost:9000"},
+	}
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			if got := OverrideBaseURL(c.pspURL, c.baseURL); got != c.want {
+				t.Errorf("Got %q, want %q", got, c.want)
+			}
+		})
+	}
+}
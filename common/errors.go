@@ -0,0 +1,107 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+
+	"github.com/BoltApp/sleet"
+)
+
+// TransportError wraps an error returned while sending a request or reading its response as a timeout or network
+// error. Both are retryable.
+func TransportError(gateway string, err error) *sleet.Error {
+	kind := sleet.ErrorKindNetwork
+	if isTimeout(err) {
+		kind = sleet.ErrorKindTimeout
+	}
+	return &sleet.Error{Kind: kind, Gateway: gateway, Retryable: true, Err: err}
+}
+
+// ResponseError wraps an error decoding a PsP response. A response with an error status is an authentication error
+// for 401 and 403 and a PsP API error otherwise, retryable on 429 and 5xx. A successful status is a decode error.
+func ResponseError(gateway string, statusCode int, err error) *sleet.Error {
+	kind := sleet.ErrorKindDecode
+	switch {
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		kind = sleet.ErrorKindAuthentication
+	case statusCode >= http.StatusBadRequest:
+		kind = sleet.ErrorKindPSPAPI
+	}
+	return &sleet.Error{
+		Kind:       kind,
+		Gateway:    gateway,
+		Retryable:  statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError,
+		StatusCode: statusCode,
+		Err:        err,
+	}
+}
+
+// DecodeError wraps an error translating a PsP response whose status is not known
+func DecodeError(gateway string, err error) *sleet.Error {
+	return &sleet.Error{Kind: sleet.ErrorKindDecode, Gateway: gateway, Err: err}
+}
+
+// ValidationError wraps an error building a request, which was not sent
+func ValidationError(gateway string, err error) *sleet.Error {
+	return &sleet.Error{Kind: sleet.ErrorKindValidation, Gateway: gateway, Err: err}
+}
+
+// ClassifyError wraps an error returned by a PsP SDK, which hides whether the request was sent. Timeouts, connection
+// failures and undecodable responses are recognized by their type, any other error is a PsP API error.
+func ClassifyError(gateway string, err error) *sleet.Error {
+	if isTimeout(err) || isNetwork(err) {
+		return TransportError(gateway, err)
+	}
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var xmlErr *xml.SyntaxError
+	if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) || errors.As(err, &xmlErr) {
+		return DecodeError(gateway, err)
+	}
+	return &sleet.Error{Kind: sleet.ErrorKindPSPAPI, Gateway: gateway, Err: err}
+}
+
+// OperationError returns err as a *sleet.Error of operation, for client methods to return. Errors which were not
+// classified when the request was sent are validation errors found while building it, and
+// sleet.ErrTransactionQueryNotSupported is returned as is.
+func OperationError(gateway string, operation string, err error) error {
+	if err == nil || errors.Is(err, sleet.ErrTransactionQueryNotSupported) {
+		return err
+	}
+	var sleetErr *sleet.Error
+	if !errors.As(err, &sleetErr) {
+		sleetErr = ValidationError(gateway, err)
+		err = sleetErr
+	}
+	if sleetErr.Gateway == "" {
+		sleetErr.Gateway = gateway
+	}
+	if sleetErr.Operation == "" {
+		sleetErr.Operation = operation
+	}
+	return err
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, context.Canceled) ||
+		(errors.As(err, &netErr) && netErr.Timeout())
+}
+
+func isNetwork(err error) bool {
+	var netErr net.Error
+	var urlErr *url.Error
+	return errors.As(err, &netErr) ||
+		errors.As(err, &urlErr) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET)
+}
@@ -0,0 +1,113 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/BoltApp/sleet"
+)
+
+func TestTransportError(t *testing.T) {
+	cases := []struct {
+		label string
+		err   error
+		want  sleet.ErrorKind
+	}{
+		{"Deadline", &url.Error{Op: "Post", URL: "https://psp.test", Err: context.DeadlineExceeded}, sleet.ErrorKindTimeout},
+		{"Cancelled", context.Canceled, sleet.ErrorKindTimeout},
+		{"Connection Reset", &url.Error{Op: "Post", URL: "https://psp.test", Err: errors.New("connection reset by peer")}, sleet.ErrorKindNetwork},
+		{"Truncated Body", io.ErrUnexpectedEOF, sleet.ErrorKindNetwork},
+	}
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			got := TransportError("psp", c.err)
+			if got.Kind != c.want || !got.Retryable || got.Gateway != "psp" {
+				t.Errorf("expected a retryable %s error, got %+v", c.want, got)
+			}
+			if !errors.Is(got, c.err) {
+				t.Errorf("expected the cause to be wrapped, got %v", got)
+			}
+		})
+	}
+}
+
+func TestResponseError(t *testing.T) {
+	cases := []struct {
+		statusCode    int
+		wantKind      sleet.ErrorKind
+		wantRetryable bool
+	}{
+		{http.StatusOK, sleet.ErrorKindDecode, false},
+		{http.StatusBadRequest, sleet.ErrorKindPSPAPI, false},
+		{http.StatusUnauthorized, sleet.ErrorKindAuthentication, false},
+		{http.StatusForbidden, sleet.ErrorKindAuthentication, false},
+		{http.StatusTooManyRequests, sleet.ErrorKindPSPAPI, true},
+		{http.StatusBadGateway, sleet.ErrorKindPSPAPI, true},
+	}
+	for _, c := range cases {
+		t.Run(strconv.Itoa(c.statusCode), func(t *testing.T) {
+			got := ResponseError("psp", c.statusCode, errors.New("unexpected body"))
+			if got.Kind != c.wantKind || got.Retryable != c.wantRetryable || got.StatusCode != c.statusCode {
+				t.Errorf("expected %s retryable %t, got %+v", c.wantKind, c.wantRetryable, got)
+			}
+		})
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	var syntaxErr error = &json.SyntaxError{Offset: 1}
+	cases := []struct {
+		label string
+		err   error
+		want  sleet.ErrorKind
+	}{
+		{"Timeout", fmt.Errorf("request failed: %w", context.DeadlineExceeded), sleet.ErrorKindTimeout},
+		{"Network", &url.Error{Op: "Get", URL: "https://psp.test", Err: io.EOF}, sleet.ErrorKindNetwork},
+		{"Decode", fmt.Errorf("couldn't deserialize: %w", syntaxErr), sleet.ErrorKindDecode},
+		{"PsP API", errors.New("500 Internal Server Error"), sleet.ErrorKindPSPAPI},
+	}
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			if got := ClassifyError("psp", c.err); got.Kind != c.want {
+				t.Errorf("expected %s, got %s", c.want, got.Kind)
+			}
+		})
+	}
+}
+
+func TestOperationError(t *testing.T) {
+	t.Run("Validation", func(t *testing.T) {
+		err := OperationError("psp", sleet.OperationCapture, sleet.ErrClientReferenceRequired)
+		var sleetErr *sleet.Error
+		if !errors.As(err, &sleetErr) || sleetErr.Kind != sleet.ErrorKindValidation || sleetErr.Operation != sleet.OperationCapture {
+			t.Fatalf("expected a capture validation error, got %v", err)
+		}
+		if !errors.Is(err, sleet.ErrClientReferenceRequired) {
+			t.Errorf("expected the sentinel to match, got %v", err)
+		}
+	})
+
+	t.Run("Classified", func(t *testing.T) {
+		err := OperationError("psp", sleet.OperationRefund, ResponseError("psp", http.StatusServiceUnavailable, errors.New("<html>")))
+		want := "sleet: psp refund psp_api error (http status 503): <html>"
+		if err.Error() != want {
+			t.Errorf("expected %q, got %q", want, err.Error())
+		}
+	})
+
+	t.Run("Passed Through", func(t *testing.T) {
+		if err := OperationError("psp", sleet.OperationQueryTransaction, sleet.ErrTransactionQueryNotSupported); err != sleet.ErrTransactionQueryNotSupported {
+			t.Errorf("expected ErrTransactionQueryNotSupported, got %v", err)
+		}
+		if err := OperationError("psp", sleet.OperationVoid, nil); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+}
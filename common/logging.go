@@ -0,0 +1,75 @@
+package common
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/BoltApp/sleet"
+)
+
+// Messages of the events logged by every gateway, so they can be filtered on across PsPs
+const (
+	LogRequestStarted   = "sleet: request started"
+	LogResponseReceived = "sleet: response received"
+	LogRequestFailed    = "sleet: request failed"
+	LogPSPResult        = "sleet: psp result"
+	LogCloseFailed      = "sleet: closing response body failed"
+	LogInvalidField     = "sleet: response field could not be parsed"
+)
+
+// Logger returns the logger set on the context, or the logger the client was built with
+func Logger(ctx context.Context, clientLogger sleet.Logger) sleet.Logger {
+	if logger := sleet.LoggerFromContext(ctx); logger != nil {
+		return logger
+	}
+	if clientLogger == nil {
+		return sleet.NopLogger{}
+	}
+	return clientLogger
+}
+
+// LogResult logs the result code the PsP returned for a request, followed by any other keys and values
+func LogResult(ctx context.Context, clientLogger sleet.Logger, gateway string, resultCode string, keysAndValues ...interface{}) {
+	fields := append([]interface{}{"gateway", gateway, "result_code", resultCode}, keysAndValues...)
+	Logger(ctx, clientLogger).Info(LogPSPResult, fields...)
+}
+
+// loggingTransport logs the start, HTTP status and latency of each request sent through next
+type loggingTransport struct {
+	next    http.RoundTripper
+	gateway string
+	logger  sleet.Logger
+}
+
+// LoggingHttpClient returns a copy of httpClient logging each request of the gateway. Only the method, host and path
+// are logged, never bodies or headers, so card data can't reach the logger.
+func LoggingHttpClient(httpClient *http.Client, gateway string, logger sleet.Logger) *http.Client {
+	logging := &http.Client{}
+	if httpClient != nil {
+		*logging = *httpClient
+	}
+	logging.Transport = &loggingTransport{next: logging.Transport, gateway: gateway, logger: logger}
+	return logging
+}
+
+func (t *loggingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	// http.DefaultTransport is read on each request so that it can be replaced in tests
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	logger := Logger(request.Context(), t.logger)
+	logger.Debug(LogRequestStarted, "gateway", t.gateway, "method", request.Method, "host", request.URL.Host, "path", request.URL.Path)
+
+	start := time.Now()
+	response, err := next.RoundTrip(request)
+	latency := time.Since(start)
+	if err != nil {
+		logger.Error(LogRequestFailed, "gateway", t.gateway, "path", request.URL.Path, "latency_ms", latency.Milliseconds(), "error", err)
+		return response, err
+	}
+	logger.Info(LogResponseReceived, "gateway", t.gateway, "path", request.URL.Path, "http_status", response.StatusCode, "latency_ms", latency.Milliseconds())
+	return response, nil
+}
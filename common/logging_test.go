@@ -0,0 +1,106 @@
+package common
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/BoltApp/sleet"
+)
+
+type event struct {
+	level         string
+	message       string
+	keysAndValues []interface{}
+}
+
+type recordingLogger struct {
+	events []event
+}
+
+func (l *recordingLogger) Debug(message string, keysAndValues ...interface{}) {
+	l.events = append(l.events, event{"debug", message, keysAndValues})
+}
+
+func (l *recordingLogger) Info(message string, keysAndValues ...interface{}) {
+	l.events = append(l.events, event{"info", message, keysAndValues})
+}
+
+func (l *recordingLogger) Warn(message string, keysAndValues ...interface{}) {
+	l.events = append(l.events, event{"warn", message, keysAndValues})
+}
+
+func (l *recordingLogger) Error(message string, keysAndValues ...interface{}) {
+	l.events = append(l.events, event{"error", message, keysAndValues})
+}
+
+func (e event) value(key string) interface{} {
+	for i := 0; i+1 < len(e.keysAndValues); i += 2 {
+		if e.keysAndValues[i] == key {
+			return e.keysAndValues[i+1]
+		}
+	}
+	return nil
+}
+
+type stubTransport struct{}
+
+func (stubTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: http.StatusCreated,
+		Body:       ioutil.NopCloser(strings.NewReader(`{}`)),
+		Request:    request,
+	}, nil
+}
+
+func TestLoggingHttpClient(t *testing.T) {
+	clientLogger := &recordingLogger{}
+	httpClient := LoggingHttpClient(&http.Client{Transport: stubTransport{}}, "gateway", clientLogger)
+
+	response, err := httpClient.Post("https://example.com/payments?card=4111111111111111", "application/json", strings.NewReader(`{"number":"4111111111111111"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+
+	if len(clientLogger.events) != 2 {
+		t.Fatalf("expected 2 events, got %+v", clientLogger.events)
+	}
+	started, received := clientLogger.events[0], clientLogger.events[1]
+	if started.message != LogRequestStarted || started.value("path") != "/payments" {
+		t.Errorf("unexpected start event %+v", started)
+	}
+	if received.message != LogResponseReceived || received.value("http_status") != http.StatusCreated || received.value("gateway") != "gateway" {
+		t.Errorf("unexpected response event %+v", received)
+	}
+	for _, e := range clientLogger.events {
+		for _, v := range e.keysAndValues {
+			if s, ok := v.(string); ok && strings.Contains(s, "4111111111111111") {
+				t.Errorf("card number logged in %+v", e)
+			}
+		}
+	}
+}
+
+func TestLogger(t *testing.T) {
+	clientLogger := &recordingLogger{}
+	contextLogger := &recordingLogger{}
+
+	if _, ok := Logger(context.Background(), nil).(sleet.NopLogger); !ok {
+		t.Error("expected a NopLogger when no logger is set")
+	}
+	if Logger(context.Background(), clientLogger) != clientLogger {
+		t.Error("expected the client logger")
+	}
+
+	ctx := sleet.ContextWithLogger(context.Background(), contextLogger)
+	LogResult(ctx, clientLogger, "gateway", "00", "reason", "approved")
+	if len(clientLogger.events) != 0 || len(contextLogger.events) != 1 {
+		t.Fatal("expected the context logger to be used over the client logger")
+	}
+	if got := contextLogger.events[0]; got.message != LogPSPResult || got.value("result_code") != "00" || got.value("reason") != "approved" {
+		t.Errorf("unexpected result event %+v", got)
+	}
+}
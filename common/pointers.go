@@ -18,3 +18,8 @@ func SPtr(s string) *string {
 func BPtr(b bool) *bool {
 	return &b
 }
+
+// I64Ptr returns a pointer to the int64 value i
+func I64Ptr(i int64) *int64 {
+	return &i
+}
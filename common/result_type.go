@@ -0,0 +1,19 @@
+package common
+
+import (
+	"net/http"
+
+	"github.com/BoltApp/sleet"
+)
+
+// ResultTypeFromHTTPStatus classifies a failed response by its HTTP status, for PsP error codes which are not known:
+// 5xx statuses and rate limiting are server errors, other 4xx statuses are API errors
+func ResultTypeFromHTTPStatus(statusCode int) sleet.ResultType {
+	switch {
+	case statusCode >= http.StatusInternalServerError, statusCode == http.StatusTooManyRequests, statusCode == http.StatusRequestTimeout:
+		return sleet.ResultTypeServerError
+	case statusCode >= http.StatusBadRequest:
+		return sleet.ResultTypeAPIError
+	}
+	return sleet.ResultTypeUnknownError
+}
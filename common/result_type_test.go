@@ -0,0 +1,33 @@
+package common
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/BoltApp/sleet"
+)
+
+func TestResultTypeFromHTTPStatus(t *testing.T) {
+	cases := []struct {
+		statusCode int
+		want       sleet.ResultType
+	}{
+		{http.StatusOK, sleet.ResultTypeUnknownError},
+		{http.StatusBadRequest, sleet.ResultTypeAPIError},
+		{http.StatusUnauthorized, sleet.ResultTypeAPIError},
+		{http.StatusUnprocessableEntity, sleet.ResultTypeAPIError},
+		{http.StatusRequestTimeout, sleet.ResultTypeServerError},
+		{http.StatusTooManyRequests, sleet.ResultTypeServerError},
+		{http.StatusInternalServerError, sleet.ResultTypeServerError},
+		{http.StatusServiceUnavailable, sleet.ResultTypeServerError},
+		{0, sleet.ResultTypeUnknownError},
+	}
+	for _, c := range cases {
+		t.Run(strconv.Itoa(c.statusCode), func(t *testing.T) {
+			if got := ResultTypeFromHTTPStatus(c.statusCode); got != c.want {
+				t.Errorf("expected %s, got %s", c.want, got)
+			}
+		})
+	}
+}
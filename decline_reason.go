@@ -0,0 +1,25 @@
+package sleet
+
+// DeclineReason is the reason a PsP or issuer declined a payment, translated from the PsP's raw code so declines can be
+// compared across PsPs. It is empty for approvals and for failures which are not declines, such as validation errors.
+type DeclineReason string
+
+const (
+	DeclineReasonUnknown                DeclineReason = "Unknown"                // declined for a reason which isn't translated
+	DeclineReasonDoNotHonor             DeclineReason = "DoNotHonor"             // generic decline from the issuer
+	DeclineReasonInsufficientFunds      DeclineReason = "InsufficientFunds"      // not enough funds or credit available
+	DeclineReasonLimitExceeded          DeclineReason = "LimitExceeded"          // amount or frequency limit of the card exceeded
+	DeclineReasonExpiredCard            DeclineReason = "ExpiredCard"            // the card has expired
+	DeclineReasonInvalidExpirationDate  DeclineReason = "InvalidExpirationDate"  // the expiration date doesn't match the card
+	DeclineReasonInvalidCardNumber      DeclineReason = "InvalidCardNumber"      // no such card or issuer
+	DeclineReasonLostOrStolenCard       DeclineReason = "LostOrStolenCard"       // the card was reported lost or stolen
+	DeclineReasonRestrictedCard         DeclineReason = "RestrictedCard"         // the card is blocked or should be picked up
+	DeclineReasonSuspectedFraud         DeclineReason = "SuspectedFraud"         // declined by the issuer's or PsP's fraud checks
+	DeclineReasonCVVFailure             DeclineReason = "CVVFailure"             // the security code is incorrect or missing
+	DeclineReasonAVSFailure             DeclineReason = "AVSFailure"             // the billing address doesn't match
+	DeclineReasonTransactionNotAllowed  DeclineReason = "TransactionNotAllowed"  // the card can't be used for this kind of transaction
+	DeclineReasonAuthenticationRequired DeclineReason = "AuthenticationRequired" // the cardholder must authenticate, e.g. with 3DS
+	DeclineReasonCallIssuer             DeclineReason = "CallIssuer"             // the issuer asks for a voice authorization
+	DeclineReasonIssuerUnavailable      DeclineReason = "IssuerUnavailable"      // the issuer or the network couldn't be reached
+	DeclineReasonTryAgainLater          DeclineReason = "TryAgainLater"          // a temporary failure, the payment can be retried
+)
@@ -0,0 +1,86 @@
+package sleet
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ErrorKind classifies why an operation failed without a response
+type ErrorKind string
+
+const (
+	// ErrorKindNetwork is a request which could not be sent, or whose response could not be read
+	ErrorKindNetwork ErrorKind = "network"
+	// ErrorKindTimeout is a request which timed out or whose context was cancelled
+	ErrorKindTimeout ErrorKind = "timeout"
+	// ErrorKindValidation is a request which is invalid and was not sent to the PsP
+	ErrorKindValidation ErrorKind = "validation"
+	// ErrorKindAuthentication is a request whose credentials the PsP rejected without a response body
+	ErrorKindAuthentication ErrorKind = "authentication"
+	// ErrorKindPSPAPI is an error status returned by the PsP without a body the client understands
+	ErrorKindPSPAPI ErrorKind = "psp_api"
+	// ErrorKindDecode is a PsP response which could not be decoded
+	ErrorKindDecode ErrorKind = "decode"
+)
+
+// Names of the client operations, as found in Error.Operation
+const (
+	OperationAuthorize                    = "authorize"
+	OperationCapture                      = "capture"
+	OperationVoid                         = "void"
+	OperationRefund                       = "refund"
+	OperationSale                         = "sale"
+	OperationVerify                       = "verify"
+	OperationIncrementAuthorization       = "increment_authorization"
+	OperationQueryTransaction             = "query_transaction"
+	OperationFindByClientReference        = "find_by_client_reference"
+	OperationReverseTimedOutAuthorization = "reverse_timed_out_authorization"
+	OperationStorePaymentMethod           = "store_payment_method"
+	OperationGetPaymentMethod             = "get_payment_method"
+	OperationDeletePaymentMethod          = "delete_payment_method"
+	OperationGetTransactionDetails        = "get_transaction_details"
+)
+
+// Error is returned by client operations which failed without a response. The cause is kept, so sentinel errors
+// such as ErrClientReferenceRequired and context.DeadlineExceeded still match with errors.Is.
+//
+// Every client follows the same contract for errors. Whenever the PsP returns an answer the client can translate,
+// whether the payment was approved, declined or the request rejected, the operation returns a response with Success
+// and ResultType set and a nil error. An operation returns a nil response and a non-nil *Error only when there is no
+// such answer: the request was invalid and never sent, it could not be sent or its response read, the response could
+// not be decoded, or the PsP failed with an error status and a body the client does not understand.
+//
+// ErrTransactionQueryNotSupported is returned as is. Verifications return their response along with an error when
+// the authorization or customer profile created to verify the card could not be voided or deleted.
+type Error struct {
+	Kind       ErrorKind
+	Gateway    string
+	Operation  string
+	Retryable  bool // whether the same request can be sent again, the PsP may still have processed the original
+	StatusCode int  // the status code of the PsP http response, if there was one
+	Err        error
+}
+
+// Error describes the failure with the gateway and operation it happened in
+func (e *Error) Error() string {
+	var message strings.Builder
+	message.WriteString("sleet:")
+	for _, part := range []string{e.Gateway, e.Operation, string(e.Kind)} {
+		if part != "" {
+			message.WriteString(" " + part)
+		}
+	}
+	message.WriteString(" error")
+	if e.StatusCode != 0 {
+		fmt.Fprintf(&message, " (http status %d)", e.StatusCode)
+	}
+	if e.Err != nil {
+		message.WriteString(": " + e.Err.Error())
+	}
+	return message.String()
+}
+
+// Unwrap returns the cause of the error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
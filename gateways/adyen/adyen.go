@@ -2,6 +2,7 @@ package adyen
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
 	"github.com/adyen/adyen-go-api-library/v4/src/adyen"
@@ -11,9 +12,17 @@ import (
 	"github.com/BoltApp/sleet/common"
 )
 
+const gatewayName = "adyen"
+
 var (
 	// assert client interface
-	_ sleet.ClientWithContext = &AdyenClient{}
+	_ sleet.ClientWithContext              = &AdyenClient{}
+	_ sleet.TransactionQuerierWithContext  = &AdyenClient{}
+	_ sleet.TransactionFinderWithContext   = &AdyenClient{}
+	_ sleet.TimeoutReverserWithContext     = &AdyenClient{}
+	_ sleet.VerifierWithContext            = &AdyenClient{}
+	_ sleet.IncrementAuthorizerWithContext = &AdyenClient{}
+	_ sleet.CapabilitiesReporter           = &AdyenClient{}
 )
 
 // AdyenClient represents the authentication fields needed to make API Requests for a given environment
@@ -23,23 +32,72 @@ type AdyenClient struct {
 	merchantAccount string
 	apiKey          string
 	liveURLPrefix   string
+	baseURL         string
 	environment     common.Environment
 	httpClient      *http.Client
+	referenceIndex  ReferenceIndex
+	logger          sleet.Logger
 }
 
 // NewClient creates an Adyen client with creds and default http client
-func NewClient(merchantAccount string, apiKey string, liveURLPrefix string, env common.Environment) *AdyenClient {
-	return NewWithHTTPClient(merchantAccount, apiKey, liveURLPrefix, env, common.DefaultHttpClient())
+func NewClient(merchantAccount string, apiKey string, liveURLPrefix string, env common.Environment, options ...sleet.ClientOption) *AdyenClient {
+	return NewWithHTTPClient(merchantAccount, apiKey, liveURLPrefix, env, common.DefaultHttpClient(), options...)
 }
 
 // NewWithHTTPClient creates an Adyen client with creds and user specified http client for custom behavior
-func NewWithHTTPClient(merchantAccount string, apiKey string, liveURLPrefix string, env common.Environment, httpClient *http.Client) *AdyenClient {
+func NewWithHTTPClient(merchantAccount string, apiKey string, liveURLPrefix string, env common.Environment, httpClient *http.Client, options ...sleet.ClientOption) *AdyenClient {
+	clientOptions := sleet.NewClientOptions(options...)
 	return &AdyenClient{
 		environment:     env,
 		apiKey:          apiKey,
 		liveURLPrefix:   liveURLPrefix,
+		baseURL:         clientOptions.BaseURL,
 		merchantAccount: merchantAccount,
-		httpClient:      httpClient,
+		httpClient:      common.LoggingHttpClient(httpClient, gatewayName, clientOptions.Logger),
+		logger:          clientOptions.Logger,
+	}
+}
+
+// newAPIClient builds a client of Adyen's library for the environment, pointing its Checkout and Payments endpoints at
+// the base URL when one is set
+func (client *AdyenClient) newAPIClient() *adyen.APIClient {
+	adyenClient := adyen.NewClient(&adyen_common.Config{
+		ApiKey:                client.apiKey,
+		LiveEndpointURLPrefix: client.liveURLPrefix,
+		MerchantAccount:       client.merchantAccount,
+		Environment:           Environment(client.environment),
+		HTTPClient:            client.httpClient,
+	})
+	if client.baseURL != "" {
+		config := adyenClient.GetConfig()
+		config.Endpoint = common.OverrideBaseURL(config.Endpoint, client.baseURL)
+		config.CheckoutEndpoint = common.OverrideBaseURL(config.CheckoutEndpoint, client.baseURL)
+	}
+	return adyenClient
+}
+
+// SetReferenceIndex sets the index used by FindByClientReference, which should be kept up to date with IndexWebhookEvents
+func (client *AdyenClient) SetReferenceIndex(index ReferenceIndex) {
+	client.referenceIndex = index
+}
+
+// Capabilities describes the optional operations supported by Adyen
+func (client *AdyenClient) Capabilities() sleet.Capabilities {
+	return sleet.Capabilities{
+		ContextSupport:           true,
+		TransactionQuery:         true,
+		FindByReference:          true,
+		TimeoutReversal:          true,
+		IncrementalAuthorization: true,
+		PartialAuthorization:     true,
+		PartialCapture:           true,
+		PartialRefund:            true,
+		Level2:                   true,
+		Level3:                   true,
+		ThreeDSVersions:          []string{sleet.ThreeDSVersion1, sleet.ThreeDSVersion2},
+		NetworkTokens:            true,
+		ApplePay:                 true,
+		GooglePay:                true,
 	}
 }
 
@@ -53,47 +111,29 @@ func (client *AdyenClient) Authorize(request *sleet.AuthorizationRequest) (*slee
 // Note: In order to be compliant, a credit card CVV is required for all transactions where a customer did not agree
 // to have their card information saved or where a customer does not have a previous transaction with the caller.
 func (client *AdyenClient) AuthorizeWithContext(ctx context.Context, request *sleet.AuthorizationRequest) (*sleet.AuthorizationResponse, error) {
-	adyenClient := adyen.NewClient(&adyen_common.Config{
-		ApiKey:                client.apiKey,
-		LiveEndpointURLPrefix: client.liveURLPrefix,
-		MerchantAccount:       client.merchantAccount,
-		Environment:           Environment(client.environment),
-		HTTPClient:            client.httpClient,
-	})
+	adyenClient := client.newAPIClient()
 
 	result, httpResp, err := adyenClient.Checkout.Payments(buildAuthRequest(request, client.merchantAccount), ctx)
-	var (
-		statusCode     int
-		responseHeader http.Header
-	)
-	if httpResp != nil {
-		statusCode = httpResp.StatusCode
-		responseHeader = sleet.GetHTTPResponseHeader(request.Options, *httpResp)
-	}
+	statusCode, responseHeader := httpResponseDetails(httpResp, request.Options)
 	if err != nil {
-		if adyenError, ok := err.(adyen_common.APIError); ok {
+		if adyenError, ok := apiError(httpResp, err); ok {
+			common.LogResult(ctx, client.logger, gatewayName, adyenError.Code, "error_type", adyenError.Type)
 			return &sleet.AuthorizationResponse{
 				Success:    false,
 				StatusCode: statusCode,
 				Header:     responseHeader,
 				ErrorCode:  adyenError.Code,
 				Message:    adyenError.Message,
-				ResultType: sleet.ResultTypeAPIError,
+				ResultType: translateErrorResultType(adyenError),
 			}, nil
 		}
-		return &sleet.AuthorizationResponse{
-			Success:              false,
-			TransactionReference: "",
-			AvsResult:            sleet.AVSResponseUnknown,
-			CvvResult:            sleet.CVVResponseUnknown,
-			StatusCode:           statusCode,
-			Header:               responseHeader,
-			ResultType:           sleet.ResultTypeServerError,
-		}, err
+		return nil, common.OperationError(gatewayName, sleet.OperationAuthorize, classifyError(httpResp, err))
 	}
 
+	common.LogResult(ctx, client.logger, gatewayName, result.ResultCode.String(), "refusal_reason_code", result.RefusalReasonCode)
 	response := &sleet.AuthorizationResponse{
 		TransactionReference: result.PspReference,
+		ResultType:           translateResultType(result.ResultCode, result.RefusalReasonCode),
 		StatusCode:           statusCode,
 		Header:               responseHeader,
 	}
@@ -101,8 +141,14 @@ func (client *AdyenClient) AuthorizeWithContext(ctx context.Context, request *sl
 		values, ok := result.AdditionalData.(map[string]interface{})
 		if ok {
 			if err = addAdditionalDataFields(values, response); err != nil {
-				return nil, err
+				return nil, common.OperationError(gatewayName, sleet.OperationAuthorize, common.ResponseError(gatewayName, statusCode, err))
+			}
+			// the partial approval stands even if its amount can't be parsed, so the response is kept without it
+			approvedAmount, err := translateApprovedAmount(values)
+			if err != nil {
+				common.Logger(ctx, client.logger).Warn(common.LogInvalidField, "gateway", gatewayName, "field", "authorisedAmountValue", "error", err)
 			}
+			response.ApprovedAmount = approvedAmount
 		}
 	}
 
@@ -111,11 +157,27 @@ func (client *AdyenClient) AuthorizeWithContext(ctx context.Context, request *sl
 		response.Success = false
 		response.ErrorCode = result.RefusalReasonCode
 		response.Response = result.RefusalReason
-		response.ResultType = sleet.ResultTypePaymentError
+		response.DeclineReason = common.TranslateDeclineReason(declineReasonMap, result.RefusalReasonCode, response.ResultType)
 	}
 	return response, nil
 }
 
+// Verify validates a card with a zero amount authorization -- this wraps VerifyWithContext
+func (client *AdyenClient) Verify(request *sleet.VerificationRequest) (*sleet.VerificationResponse, error) {
+	return client.VerifyWithContext(context.TODO(), request)
+}
+
+// VerifyWithContext validates a card with a zero amount authorization, which Adyen does not hold funds for
+func (client *AdyenClient) VerifyWithContext(ctx context.Context, request *sleet.VerificationRequest) (*sleet.VerificationResponse, error) {
+	authRequest := *request
+	authRequest.Amount.Amount = 0
+	resp, err := client.AuthorizeWithContext(ctx, &authRequest)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationVerify, err)
+	}
+	return &sleet.VerificationResponse{AuthorizationResponse: *resp}, nil
+}
+
 // Capture an existing transaction by reference -- this wraps CaptureWithContext
 func (client *AdyenClient) Capture(request *sleet.CaptureRequest) (*sleet.CaptureResponse, error) {
 	return client.CaptureWithContext(context.TODO(), request)
@@ -123,21 +185,61 @@ func (client *AdyenClient) Capture(request *sleet.CaptureRequest) (*sleet.Captur
 
 // CaptureWithContext captures an existing transaction by reference
 func (client *AdyenClient) CaptureWithContext(ctx context.Context, request *sleet.CaptureRequest) (*sleet.CaptureResponse, error) {
-	adyenClient := adyen.NewClient(&adyen_common.Config{
-		ApiKey:                client.apiKey,
-		LiveEndpointURLPrefix: client.liveURLPrefix,
-		MerchantAccount:       client.merchantAccount,
-		Environment:           Environment(client.environment),
-		HTTPClient:            client.httpClient,
-	})
+	adyenClient := client.newAPIClient()
 
-	capture, _, err := adyenClient.Payments.Capture(buildCaptureRequest(request, client.merchantAccount), ctx)
+	capture, httpResp, err := adyenClient.Payments.Capture(buildCaptureRequest(request, client.merchantAccount), ctx)
+	statusCode, responseHeader := httpResponseDetails(httpResp, request.Options)
 	if err != nil {
-		return &sleet.CaptureResponse{Success: false, TransactionReference: ""}, err
+		adyenError, ok := apiError(httpResp, err)
+		if !ok {
+			return nil, common.OperationError(gatewayName, sleet.OperationCapture, classifyError(httpResp, err))
+		}
+		return &sleet.CaptureResponse{
+			Success:    false,
+			ResultType: translateErrorResultType(adyenError),
+			StatusCode: statusCode,
+			Header:     responseHeader,
+			ErrorCode:  &adyenError.Code,
+			Message:    adyenError.Message,
+		}, nil
 	}
+	common.LogResult(ctx, client.logger, gatewayName, capture.Response)
 	return &sleet.CaptureResponse{
 		Success:              true,
 		TransactionReference: capture.PspReference,
+		ResultType:           sleet.ResultTypeSuccess,
+		Amount:               request.Amount,
+		StatusCode:           statusCode,
+		Header:               responseHeader,
+	}, nil
+}
+
+// IncrementAuthorization raises the amount of an authorised payment -- this wraps IncrementAuthorizationWithContext
+func (client *AdyenClient) IncrementAuthorization(request *sleet.IncrementAuthorizationRequest) (*sleet.IncrementAuthorizationResponse, error) {
+	return client.IncrementAuthorizationWithContext(context.TODO(), request)
+}
+
+// IncrementAuthorizationWithContext raises the amount of an authorised payment with an asynchronous authorisation
+// adjustment. Adyen takes the new total, so the request must have the AuthorizedAmount. The outcome is confirmed by an
+// AUTHORISATION_ADJUSTMENT notification.
+func (client *AdyenClient) IncrementAuthorizationWithContext(ctx context.Context, request *sleet.IncrementAuthorizationRequest) (*sleet.IncrementAuthorizationResponse, error) {
+	if request.AuthorizedAmount == 0 {
+		return nil, common.OperationError(gatewayName, sleet.OperationIncrementAuthorization, sleet.ErrAuthorizedAmountRequired)
+	}
+	adyenClient := client.newAPIClient()
+
+	adjustment, httpResp, err := adyenClient.Payments.AdjustAuthorisation(buildIncrementAuthorizationRequest(request, client.merchantAccount), ctx)
+	if err != nil {
+		if adyenError, ok := apiError(httpResp, err); ok {
+			return &sleet.IncrementAuthorizationResponse{Success: false, ErrorCode: &adyenError.Code}, nil
+		}
+		return nil, common.OperationError(gatewayName, sleet.OperationIncrementAuthorization, classifyError(httpResp, err))
+	}
+	common.LogResult(ctx, client.logger, gatewayName, adjustment.Response)
+	return &sleet.IncrementAuthorizationResponse{
+		Success:              true,
+		TransactionReference: adjustment.PspReference,
+		AuthorizedAmount:     request.NewAuthorizedAmount(),
 	}, nil
 }
 
@@ -148,21 +250,32 @@ func (client *AdyenClient) Refund(request *sleet.RefundRequest) (*sleet.RefundRe
 
 // Refund a captured transaction by reference with specified amount
 func (client *AdyenClient) RefundWithContext(ctx context.Context, request *sleet.RefundRequest) (*sleet.RefundResponse, error) {
-	adyenClient := adyen.NewClient(&adyen_common.Config{
-		ApiKey:                client.apiKey,
-		LiveEndpointURLPrefix: client.liveURLPrefix,
-		MerchantAccount:       client.merchantAccount,
-		Environment:           Environment(client.environment),
-		HTTPClient:            client.httpClient,
-	})
+	adyenClient := client.newAPIClient()
 
-	refund, _, err := adyenClient.Payments.Refund(buildRefundRequest(request, client.merchantAccount), ctx)
+	refund, httpResp, err := adyenClient.Payments.Refund(buildRefundRequest(request, client.merchantAccount), ctx)
+	statusCode, responseHeader := httpResponseDetails(httpResp, request.Options)
 	if err != nil {
-		return &sleet.RefundResponse{Success: false, TransactionReference: ""}, err
+		adyenError, ok := apiError(httpResp, err)
+		if !ok {
+			return nil, common.OperationError(gatewayName, sleet.OperationRefund, classifyError(httpResp, err))
+		}
+		return &sleet.RefundResponse{
+			Success:    false,
+			ResultType: translateErrorResultType(adyenError),
+			StatusCode: statusCode,
+			Header:     responseHeader,
+			ErrorCode:  &adyenError.Code,
+			Message:    adyenError.Message,
+		}, nil
 	}
+	common.LogResult(ctx, client.logger, gatewayName, refund.Response)
 	return &sleet.RefundResponse{
 		Success:              true,
 		TransactionReference: refund.PspReference,
+		ResultType:           sleet.ResultTypeSuccess,
+		Amount:               request.Amount,
+		StatusCode:           statusCode,
+		Header:               responseHeader,
 	}, nil
 }
 
@@ -173,21 +286,115 @@ func (client *AdyenClient) Void(request *sleet.VoidRequest) (*sleet.VoidResponse
 
 // VoidWithContext voids an authorized transaction (cancels the authorization)
 func (client *AdyenClient) VoidWithContext(ctx context.Context, request *sleet.VoidRequest) (*sleet.VoidResponse, error) {
-	adyenClient := adyen.NewClient(&adyen_common.Config{
-		ApiKey:                client.apiKey,
-		LiveEndpointURLPrefix: client.liveURLPrefix,
-		MerchantAccount:       client.merchantAccount,
-		Environment:           Environment(client.environment),
-		HTTPClient:            client.httpClient,
-	})
+	adyenClient := client.newAPIClient()
 
-	void, _, err := adyenClient.Payments.Cancel(buildVoidRequest(request, client.merchantAccount), ctx)
+	void, httpResp, err := adyenClient.Payments.Cancel(buildVoidRequest(request, client.merchantAccount), ctx)
+	statusCode, responseHeader := httpResponseDetails(httpResp, request.Options)
 	if err != nil {
-		return &sleet.VoidResponse{Success: false, TransactionReference: ""}, err
+		adyenError, ok := apiError(httpResp, err)
+		if !ok {
+			return nil, common.OperationError(gatewayName, sleet.OperationVoid, classifyError(httpResp, err))
+		}
+		return &sleet.VoidResponse{
+			Success:    false,
+			ResultType: translateErrorResultType(adyenError),
+			StatusCode: statusCode,
+			Header:     responseHeader,
+			ErrorCode:  &adyenError.Code,
+			Message:    adyenError.Message,
+		}, nil
 	}
+	common.LogResult(ctx, client.logger, gatewayName, void.Response)
 	return &sleet.VoidResponse{
 		Success:              true,
 		TransactionReference: void.PspReference,
+		ResultType:           sleet.ResultTypeSuccess,
+		StatusCode:           statusCode,
+		Header:               responseHeader,
+	}, nil
+}
+
+// ReverseTimedOutAuthorization cancels a payment whose outcome is unknown by its reference -- this wraps
+// ReverseTimedOutAuthorizationWithContext
+func (client *AdyenClient) ReverseTimedOutAuthorization(request *sleet.TimeoutReversalRequest) (*sleet.TimeoutReversalResponse, error) {
+	return client.ReverseTimedOutAuthorizationWithContext(context.TODO(), request)
+}
+
+// ReverseTimedOutAuthorizationWithContext cancels a payment whose outcome is unknown with a technical cancel, which
+// identifies the payment by the ClientTransactionReference it was sent with as its reference
+func (client *AdyenClient) ReverseTimedOutAuthorizationWithContext(ctx context.Context, request *sleet.TimeoutReversalRequest) (*sleet.TimeoutReversalResponse, error) {
+	if request.ClientTransactionReference == nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationReverseTimedOutAuthorization, sleet.ErrClientReferenceRequired)
+	}
+	adyenClient := client.newAPIClient()
+
+	cancel, httpResp, err := adyenClient.Payments.TechnicalCancel(buildTimeoutReversalRequest(request, client.merchantAccount), ctx)
+	if err != nil {
+		if adyenError, ok := apiError(httpResp, err); ok {
+			return &sleet.TimeoutReversalResponse{Success: false, ErrorCode: &adyenError.Code}, nil
+		}
+		return nil, common.OperationError(gatewayName, sleet.OperationReverseTimedOutAuthorization, classifyError(httpResp, err))
+	}
+	common.LogResult(ctx, client.logger, gatewayName, cancel.Response)
+	return &sleet.TimeoutReversalResponse{
+		Success:              true,
+		TransactionReference: cancel.PspReference,
+	}, nil
+}
+
+// QueryTransaction returns the payment with the PSP reference from the client's ReferenceIndex. Adyen has no API to
+// look up a single payment, so its state is the one given by the notifications indexed with IndexWebhookEvents.
+func (client *AdyenClient) QueryTransaction(request *sleet.TransactionQueryRequest) (*sleet.TransactionQueryResponse, error) {
+	return client.QueryTransactionWithContext(context.TODO(), request)
+}
+
+// QueryTransactionWithContext returns the payment with the PSP reference from the client's ReferenceIndex. The context
+// is unused as the index is local.
+func (client *AdyenClient) QueryTransactionWithContext(_ context.Context, request *sleet.TransactionQueryRequest) (*sleet.TransactionQueryResponse, error) {
+	if client.referenceIndex == nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationQueryTransaction, ErrReferenceIndexNotConfigured)
+	}
+	if request.TransactionReference == "" {
+		return nil, common.OperationError(gatewayName, sleet.OperationQueryTransaction, errors.New("TransactionReference given to query request is empty"))
+	}
+
+	transaction, err := lookUpTransaction(client.referenceIndex, request.TransactionReference)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationQueryTransaction, err)
+	}
+	if transaction == nil {
+		return &sleet.TransactionQueryResponse{
+			Success:              false,
+			TransactionReference: request.TransactionReference,
+			ErrorCode:            common.SPtr(errorCodeNotIndexed),
+		}, nil
+	}
+	return transaction, nil
+}
+
+// FindByClientReference returns the payments with the ClientTransactionReference, sent to Adyen as the payment
+// reference, from the client's ReferenceIndex. Adyen has no API to search payments by reference.
+func (client *AdyenClient) FindByClientReference(request *sleet.FindByClientReferenceRequest) (*sleet.FindByClientReferenceResponse, error) {
+	return client.FindByClientReferenceWithContext(context.TODO(), request)
+}
+
+// FindByClientReferenceWithContext returns the payments with the ClientTransactionReference from the client's
+// ReferenceIndex. The context is unused as the index is local.
+func (client *AdyenClient) FindByClientReferenceWithContext(_ context.Context, request *sleet.FindByClientReferenceRequest) (*sleet.FindByClientReferenceResponse, error) {
+	if client.referenceIndex == nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationFindByClientReference, ErrReferenceIndexNotConfigured)
+	}
+	if request.ClientTransactionReference == nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationFindByClientReference, sleet.ErrClientReferenceRequired)
+	}
+
+	transactions, err := client.referenceIndex.Get(*request.ClientTransactionReference)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationFindByClientReference, err)
+	}
+	return &sleet.FindByClientReferenceResponse{
+		Success:      true,
+		Transactions: transactions,
 	}, nil
 }
 
@@ -230,3 +437,35 @@ func getAdyenAdditionalData(additionalData map[string]interface{}) map[string]st
 	}
 	return adyenMap
 }
+
+// httpResponseDetails returns the status code and the requested headers of the response returned by the Adyen library,
+// which has no response when the request failed before reaching Adyen.
+func httpResponseDetails(httpResp *http.Response, options map[string]interface{}) (int, http.Header) {
+	if httpResp == nil {
+		return 0, nil
+	}
+	return httpResp.StatusCode, sleet.GetHTTPResponseHeader(options, *httpResp)
+}
+
+// apiError returns the error the Adyen library returned for an error response of Adyen. The library also returns an
+// APIError when it can't decode a successful response, which isn't a refusal, so those are not API errors.
+func apiError(httpResp *http.Response, err error) (adyen_common.APIError, bool) {
+	adyenError, ok := err.(adyen_common.APIError)
+	if !ok || isSuccessfulResponse(httpResp) {
+		return adyen_common.APIError{}, false
+	}
+	return adyenError, true
+}
+
+// classifyError wraps an error returned by the Adyen library which is not an API error. An error with a successful
+// response is a response which couldn't be decoded.
+func classifyError(httpResp *http.Response, err error) *sleet.Error {
+	if isSuccessfulResponse(httpResp) {
+		return common.ResponseError(gatewayName, httpResp.StatusCode, err)
+	}
+	return common.ClassifyError(gatewayName, err)
+}
+
+func isSuccessfulResponse(httpResp *http.Response) bool {
+	return httpResp != nil && httpResp.StatusCode < http.StatusMultipleChoices
+}
@@ -0,0 +1,34 @@
+//go:build unit
+// +build unit
+
+package adyen
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+	"github.com/BoltApp/sleet/testing/conformance"
+	"github.com/BoltApp/sleet/testing/fakes"
+)
+
+func TestConformance(t *testing.T) {
+	fake := fakes.NewAdyen()
+	defer fake.Close()
+
+	conformance.Run(t, conformance.Suite{
+		Gateway:   "adyen",
+		Transport: fake.Client().Transport,
+		NewClient: func(httpClient *http.Client) sleet.ClientWithContext {
+			return NewWithHTTPClient(fakes.AdyenMerchantAccount, fakes.AdyenAPIKey, "", common.Sandbox, httpClient)
+		},
+		// Adyen's test platform refuses a payment with the refusal reason given as the card holder's name
+		Decline: func(request *sleet.AuthorizationRequest) {
+			request.CreditCard.FirstName, request.CreditCard.LastName = "Not enough", "balance"
+		},
+		// the test card has no billing address on file
+		AVS: sleet.AVSResponseNoMatch,
+		CVV: sleet.CVVResponseMatch,
+	})
+}
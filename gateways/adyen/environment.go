@@ -8,7 +8,7 @@ import (
 
 // Environment translates a Sleet common environment into the adyen specific environment for the library
 func Environment(environment common.Environment) adyen_common.Environment {
-	if environment == common.Sandbox {
+	if environment == common.Sandbox || environment == common.Custom {
 		return adyen_common.TestEnv
 	}
 	return adyen_common.LiveEnv
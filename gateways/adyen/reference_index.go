@@ -0,0 +1,170 @@
+package adyen
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/webhooks"
+)
+
+// ErrReferenceIndexNotConfigured is returned by FindByClientReference and QueryTransaction when the client has no
+// ReferenceIndex
+var ErrReferenceIndexNotConfigured = errors.New("adyen: no reference index configured to find payments by reference")
+
+// errorCodeNotIndexed is the error code of a query for a PSP reference which no indexed notification has mentioned
+const errorCodeNotIndexed = "not_indexed"
+
+// ReferenceIndex stores the payments seen for each merchant reference. Adyen has no API to search payments, so
+// FindByClientReference is answered from an index built from notifications with IndexWebhookEvents.
+type ReferenceIndex interface {
+	// Put stores the transaction under the merchant reference, replacing a transaction with the same TransactionReference
+	Put(merchantReference string, transaction sleet.TransactionQueryResponse) error
+	// Get returns the transactions stored under the merchant reference
+	Get(merchantReference string) ([]sleet.TransactionQueryResponse, error)
+	// MerchantReference returns the merchant reference a PSP reference is stored under, or "" if it is not stored
+	MerchantReference(pspReference string) (string, error)
+}
+
+// MemoryReferenceIndex is a ReferenceIndex kept in memory, suitable for a single process and for tests
+type MemoryReferenceIndex struct {
+	mu                 sync.RWMutex
+	transactions       map[string][]sleet.TransactionQueryResponse
+	merchantReferences map[string]string
+}
+
+// NewMemoryReferenceIndex creates an empty in memory ReferenceIndex
+func NewMemoryReferenceIndex() *MemoryReferenceIndex {
+	return &MemoryReferenceIndex{
+		transactions:       make(map[string][]sleet.TransactionQueryResponse),
+		merchantReferences: make(map[string]string),
+	}
+}
+
+// Put stores the transaction under the merchant reference
+func (index *MemoryReferenceIndex) Put(merchantReference string, transaction sleet.TransactionQueryResponse) error {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+
+	transactions := index.transactions[merchantReference]
+	for i := range transactions {
+		if transactions[i].TransactionReference == transaction.TransactionReference {
+			transactions[i] = transaction
+			return nil
+		}
+	}
+	index.transactions[merchantReference] = append(transactions, transaction)
+	index.merchantReferences[transaction.TransactionReference] = merchantReference
+	return nil
+}
+
+// Get returns a copy of the transactions stored under the merchant reference
+func (index *MemoryReferenceIndex) Get(merchantReference string) ([]sleet.TransactionQueryResponse, error) {
+	index.mu.RLock()
+	defer index.mu.RUnlock()
+
+	return append([]sleet.TransactionQueryResponse{}, index.transactions[merchantReference]...), nil
+}
+
+// MerchantReference returns the merchant reference the PSP reference is stored under
+func (index *MemoryReferenceIndex) MerchantReference(pspReference string) (string, error) {
+	index.mu.RLock()
+	defer index.mu.RUnlock()
+
+	return index.merchantReferences[pspReference], nil
+}
+
+// IndexWebhookEvents updates the index with events parsed by the WebhookParser. Authorizations are stored under their
+// merchant reference, and modifications update the state of the authorization they reference.
+// Adyen delivers notifications at least once, so events should be de-duplicated before they are indexed.
+func IndexWebhookEvents(index ReferenceIndex, events []webhooks.WebhookEvent) error {
+	for _, event := range events {
+		if event.Kind == webhooks.EventKindAuthorization {
+			if event.MerchantReference == "" {
+				continue
+			}
+			transaction := sleet.TransactionQueryResponse{
+				Success:              true,
+				TransactionReference: event.TransactionReference,
+				State:                sleet.TransactionStateDeclined,
+				StateRaw:             event.PsPEventType,
+			}
+			if event.Success {
+				transaction.State = sleet.TransactionStateAuthorized
+				if event.Amount != nil {
+					transaction.AuthorizedAmount = event.Amount.Amount
+				}
+			}
+			if event.Amount != nil {
+				transaction.Currency = event.Amount.Currency
+			}
+			if err := index.Put(event.MerchantReference, transaction); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := indexModification(index, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// indexModification applies a successful capture, void or refund to the authorization it references
+func indexModification(index ReferenceIndex, event webhooks.WebhookEvent) error {
+	if !event.Success || event.OriginalTransactionReference == "" {
+		return nil
+	}
+	merchantReference, err := index.MerchantReference(event.OriginalTransactionReference)
+	if err != nil || merchantReference == "" {
+		return err
+	}
+	transactions, err := index.Get(merchantReference)
+	if err != nil {
+		return err
+	}
+
+	var amount int64
+	if event.Amount != nil {
+		amount = event.Amount.Amount
+	}
+	for _, transaction := range transactions {
+		if transaction.TransactionReference != event.OriginalTransactionReference {
+			continue
+		}
+		switch event.Kind {
+		case webhooks.EventKindCapture:
+			transaction.State = sleet.TransactionStateCaptured
+			transaction.CapturedAmount += amount
+		case webhooks.EventKindVoid:
+			transaction.State = sleet.TransactionStateVoided
+		case webhooks.EventKindRefund:
+			transaction.State = sleet.TransactionStateRefunded
+			transaction.RefundedAmount += amount
+		default:
+			return nil
+		}
+		transaction.StateRaw = event.PsPEventType
+		return index.Put(merchantReference, transaction)
+	}
+	return nil
+}
+
+// lookUpTransaction returns the indexed transaction with the PSP reference, or nil if it is not indexed
+func lookUpTransaction(index ReferenceIndex, pspReference string) (*sleet.TransactionQueryResponse, error) {
+	merchantReference, err := index.MerchantReference(pspReference)
+	if err != nil || merchantReference == "" {
+		return nil, err
+	}
+	transactions, err := index.Get(merchantReference)
+	if err != nil {
+		return nil, err
+	}
+	for _, transaction := range transactions {
+		if transaction.TransactionReference == pspReference {
+			return &transaction, nil
+		}
+	}
+	return nil, nil
+}
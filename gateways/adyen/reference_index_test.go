@@ -0,0 +1,152 @@
+//go:build unit
+// +build unit
+
+package adyen
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-test/deep"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+	"github.com/BoltApp/sleet/webhooks"
+)
+
+func TestFindByClientReference(t *testing.T) {
+	client := NewClient("merchant", "key", "", common.Sandbox)
+
+	t.Run("Without Reference Index", func(t *testing.T) {
+		_, err := client.FindByClientReference(&sleet.FindByClientReferenceRequest{
+			ClientTransactionReference: common.SPtr("order-1"),
+		})
+		if !errors.Is(err, ErrReferenceIndexNotConfigured) {
+			t.Errorf("expected ErrReferenceIndexNotConfigured, got %v", err)
+		}
+	})
+
+	t.Run("With Indexed Webhook Events", func(t *testing.T) {
+		index := NewMemoryReferenceIndex()
+		client.SetReferenceIndex(index)
+
+		events := []webhooks.WebhookEvent{
+			{
+				Kind:                 webhooks.EventKindAuthorization,
+				PsPEventType:         "AUTHORISATION",
+				TransactionReference: "8815000000000001",
+				MerchantReference:    "order-1",
+				Amount:               &sleet.Amount{Amount: 1000, Currency: "USD"},
+				Success:              true,
+			},
+			{
+				Kind:                 webhooks.EventKindAuthorization,
+				PsPEventType:         "AUTHORISATION",
+				TransactionReference: "8815000000000002",
+				MerchantReference:    "order-2",
+				Amount:               &sleet.Amount{Amount: 500, Currency: "USD"},
+				Success:              false,
+			},
+			{
+				Kind:                         webhooks.EventKindCapture,
+				PsPEventType:                 "CAPTURE",
+				TransactionReference:         "8815000000000003",
+				OriginalTransactionReference: "8815000000000001",
+				Amount:                       &sleet.Amount{Amount: 1000, Currency: "USD"},
+				Success:                      true,
+			},
+		}
+		if err := IndexWebhookEvents(index, events); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+
+		got, err := client.FindByClientReference(&sleet.FindByClientReferenceRequest{
+			ClientTransactionReference: common.SPtr("order-1"),
+		})
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+
+		want := &sleet.FindByClientReferenceResponse{
+			Success: true,
+			Transactions: []sleet.TransactionQueryResponse{
+				{
+					Success:              true,
+					TransactionReference: "8815000000000001",
+					State:                sleet.TransactionStateCaptured,
+					StateRaw:             "CAPTURE",
+					Currency:             "USD",
+					AuthorizedAmount:     1000,
+					CapturedAmount:       1000,
+				},
+			},
+		}
+		if diff := deep.Equal(got, want); diff != nil {
+			t.Error(diff)
+		}
+	})
+}
+
+func TestQueryTransaction(t *testing.T) {
+	client := NewClient("merchant", "key", "", common.Sandbox)
+
+	t.Run("Without Reference Index", func(t *testing.T) {
+		_, err := client.QueryTransaction(&sleet.TransactionQueryRequest{TransactionReference: "8815000000000001"})
+		if !errors.Is(err, ErrReferenceIndexNotConfigured) {
+			t.Errorf("expected ErrReferenceIndexNotConfigured, got %v", err)
+		}
+	})
+
+	index := NewMemoryReferenceIndex()
+	client.SetReferenceIndex(index)
+	err := IndexWebhookEvents(index, []webhooks.WebhookEvent{
+		{
+			Kind:                 webhooks.EventKindAuthorization,
+			PsPEventType:         "AUTHORISATION",
+			TransactionReference: "8815000000000001",
+			MerchantReference:    "order-1",
+			Amount:               &sleet.Amount{Amount: 1000, Currency: "USD"},
+			Success:              true,
+		},
+		{
+			Kind:                         webhooks.EventKindRefund,
+			PsPEventType:                 "REFUND",
+			TransactionReference:         "8815000000000004",
+			OriginalTransactionReference: "8815000000000001",
+			Amount:                       &sleet.Amount{Amount: 400, Currency: "USD"},
+			Success:                      true,
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	t.Run("Indexed Payment", func(t *testing.T) {
+		got, err := client.QueryTransaction(&sleet.TransactionQueryRequest{TransactionReference: "8815000000000001"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := &sleet.TransactionQueryResponse{
+			Success:              true,
+			TransactionReference: "8815000000000001",
+			State:                sleet.TransactionStateRefunded,
+			StateRaw:             "REFUND",
+			Currency:             "USD",
+			AuthorizedAmount:     1000,
+			RefundedAmount:       400,
+		}
+		if diff := deep.Equal(got, want); diff != nil {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("Payment Not Indexed", func(t *testing.T) {
+		got, err := client.QueryTransaction(&sleet.TransactionQueryRequest{TransactionReference: "8815000000000009"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Success || common.SafeStr(got.ErrorCode) != errorCodeNotIndexed {
+			t.Errorf("expected an unsuccessful response for a payment which isn't indexed, got %+v", got)
+		}
+	})
+}
@@ -0,0 +1,32 @@
+package adyen
+
+import (
+	"net/http"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/registry"
+)
+
+// Adyen credential keys of a registry.Config, the live URL prefix is only needed in production
+const (
+	credentialMerchantAccount = "merchant_account"
+	credentialAPIKey          = "api_key"
+	credentialLiveURLPrefix   = "live_url_prefix"
+)
+
+func init() {
+	registry.Register("adyen", newRegistryClient)
+}
+
+// newRegistryClient builds an AdyenClient from a registry config
+func newRegistryClient(config *registry.Config, httpClient *http.Client, options ...sleet.ClientOption) (sleet.ClientWithContext, error) {
+	credentials, err := config.RequiredCredentials(credentialMerchantAccount, credentialAPIKey)
+	if err != nil {
+		return nil, err
+	}
+	liveURLPrefix := config.OptionalCredential(credentialLiveURLPrefix)
+	if liveURLPrefix == nil {
+		liveURLPrefix = new(string)
+	}
+	return NewWithHTTPClient(credentials[0], credentials[1], *liveURLPrefix, config.Environment, httpClient, options...), nil
+}
@@ -90,9 +90,15 @@ func buildAuthRequest(authRequest *sleet.AuthorizationRequest, merchantAccount s
 		request.ShopperInteraction = shopperInteractionEcommerce
 	}
 
-	level3 := authRequest.Level3Data
-	if level3 != nil {
-		request.AdditionalData = buildLevel3Data(level3)
+	additionalData := map[string]string{}
+	if level3 := authRequest.Level3Data; level3 != nil {
+		additionalData = buildLevel3Data(level3)
+	}
+	if authRequest.AllowPartialAuth {
+		additionalData["allowPartialAuth"] = "true"
+	}
+	if len(additionalData) > 0 {
+		request.AdditionalData = additionalData
 	}
 
 	// Attach results of 3DS verification if performed (and not "R"ejected)
@@ -252,6 +258,21 @@ func buildCaptureRequest(captureRequest *sleet.CaptureRequest, merchantAccount s
 	return request
 }
 
+// buildIncrementAuthorizationRequest adjusts the authorisation to its new total, which is what Adyen takes as the
+// modification amount
+func buildIncrementAuthorizationRequest(incrementRequest *sleet.IncrementAuthorizationRequest, merchantAccount string) *payments.ModificationRequest {
+	total := incrementRequest.NewAuthorizedAmount()
+	request := &payments.ModificationRequest{
+		OriginalReference: incrementRequest.TransactionReference,
+		ModificationAmount: &payments.Amount{
+			Value:    total.Amount,
+			Currency: total.Currency,
+		},
+		MerchantAccount: merchantAccount,
+	}
+	return request
+}
+
 func buildRefundRequest(refundRequest *sleet.RefundRequest, merchantAccount string) *payments.ModificationRequest {
 	request := &payments.ModificationRequest{
 		OriginalReference: refundRequest.TransactionReference,
@@ -271,6 +292,15 @@ func buildVoidRequest(voidRequest *sleet.VoidRequest, merchantAccount string) *p
 	return request
 }
 
+// buildTimeoutReversalRequest cancels the payment with the ClientTransactionReference, sent to Adyen as its reference
+func buildTimeoutReversalRequest(reversalRequest *sleet.TimeoutReversalRequest, merchantAccount string) *payments.ModificationRequest {
+	request := &payments.ModificationRequest{
+		OriginalMerchantReference: *reversalRequest.ClientTransactionReference,
+		MerchantAccount:           merchantAccount,
+	}
+	return request
+}
+
 func addIfNonEmpty(value string, key string, data *map[string]string) {
 	if value != "" {
 		(*data)[key] = value
@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/adyen/adyen-go-api-library/v4/src/checkout"
+	"github.com/adyen/adyen-go-api-library/v4/src/payments"
 	"github.com/go-test/deep"
 
 	"github.com/BoltApp/sleet"
@@ -343,6 +344,23 @@ func TestBuild3DSAuthRequest(t *testing.T) {
 	}
 }
 
+func TestBuildIncrementAuthorizationRequest(t *testing.T) {
+	base := sleet_testing.BaseIncrementAuthorizationRequest()
+	want := &payments.ModificationRequest{
+		OriginalReference: "111111",
+		ModificationAmount: &payments.Amount{
+			Value:    150,
+			Currency: "USD",
+		},
+		MerchantAccount: "merchant",
+	}
+
+	got := buildIncrementAuthorizationRequest(base, "merchant")
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Error(diff)
+	}
+}
+
 func TestExtractAdyenStreetFormat(t *testing.T) {
 
 	cases := []struct {
@@ -410,3 +428,19 @@ func enhanceBaseAuthorizationDataWithAdditionalFields(authRequest *sleet.Authori
 		RegionCode:     common.SPtr("IL"),
 	}
 }
+
+func TestBuildAuthRequestAllowPartialAuth(t *testing.T) {
+	request := sleet_testing.BaseAuthorizationRequest()
+	request.AllowPartialAuth = true
+	got := buildAuthRequest(request, "merchant-account")
+	if diff := deep.Equal(got.AdditionalData, map[string]string{"allowPartialAuth": "true"}); diff != nil {
+		t.Error(diff)
+	}
+
+	request.Level3Data = sleet_testing.BaseLevel3Data()
+	got = buildAuthRequest(request, "merchant-account")
+	additionalData, _ := got.AdditionalData.(map[string]string)
+	if additionalData["allowPartialAuth"] != "true" || additionalData["enhancedSchemeData.customerReference"] == "" {
+		t.Errorf("expected the partial authorization flag with the level 3 data, got %v", got.AdditionalData)
+	}
+}
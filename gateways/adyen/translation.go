@@ -1,7 +1,12 @@
 package adyen
 
 import (
+	"strconv"
+
+	adyen_common "github.com/adyen/adyen-go-api-library/v4/src/common"
+
 	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
 )
 
 // AVSResponse represents an AVS response code received from Adyen
@@ -115,3 +120,83 @@ func translateAvs(adyenAVS AVSResponse) sleet.AVSResponse {
 	}
 	return sleetCode
 }
+
+// Refusal reasons which aren't payment errors, taken from: https://docs.adyen.com/development-resources/refusal-reasons
+var refusalResultTypeMap = map[string]sleet.ResultType{
+	"4":  sleet.ResultTypeServerError, // Acquirer Error
+	"7":  sleet.ResultTypeAPIError,    // Invalid Amount
+	"9":  sleet.ResultTypeServerError, // Issuer Unavailable
+	"10": sleet.ResultTypeAPIError,    // Not supported
+	"39": sleet.ResultTypeServerError, // RReq not received from DS
+	"42": sleet.ResultTypeServerError, // Transaction Timeout
+}
+
+// Refusal reasons of declines, taken from: https://docs.adyen.com/development-resources/refusal-reasons
+var declineReasonMap = map[string]sleet.DeclineReason{
+	"2":  sleet.DeclineReasonDoNotHonor,             // Refused
+	"3":  sleet.DeclineReasonCallIssuer,             // Referral
+	"4":  sleet.DeclineReasonTryAgainLater,          // Acquirer Error
+	"5":  sleet.DeclineReasonRestrictedCard,         // Blocked Card
+	"6":  sleet.DeclineReasonExpiredCard,            // Expired Card
+	"8":  sleet.DeclineReasonInvalidCardNumber,      // Invalid Card Number
+	"9":  sleet.DeclineReasonIssuerUnavailable,      // Issuer Unavailable
+	"11": sleet.DeclineReasonAuthenticationRequired, // 3D Not Authenticated
+	"12": sleet.DeclineReasonInsufficientFunds,      // Not enough balance
+	"14": sleet.DeclineReasonSuspectedFraud,         // Acquirer Fraud
+	"20": sleet.DeclineReasonSuspectedFraud,         // FRAUD
+	"22": sleet.DeclineReasonSuspectedFraud,         // FRAUD-CANCELLED
+	"23": sleet.DeclineReasonTransactionNotAllowed,  // Transaction Not Permitted
+	"24": sleet.DeclineReasonCVVFailure,             // CVC Declined
+	"25": sleet.DeclineReasonRestrictedCard,         // Restricted Card
+	"27": sleet.DeclineReasonDoNotHonor,             // Declined Non Generic
+	"28": sleet.DeclineReasonLimitExceeded,          // Withdrawal Amount Exceeded
+	"29": sleet.DeclineReasonLimitExceeded,          // Withdrawal Count Exceeded
+	"31": sleet.DeclineReasonSuspectedFraud,         // Issuer Suspected Fraud
+	"32": sleet.DeclineReasonAVSFailure,             // AVS Declined
+	"38": sleet.DeclineReasonAuthenticationRequired, // Authentication required
+	"42": sleet.DeclineReasonTryAgainLater,          // Transaction Timeout
+}
+
+// translateResultType converts the result code and refusal reason code of an Adyen payment to a Sleet result type.
+// Refused payments are payment errors unless the refusal reason shows the card was never checked.
+func translateResultType(resultCode adyen_common.ResultCode, refusalReasonCode string) sleet.ResultType {
+	switch resultCode {
+	case adyen_common.Authorised:
+		return sleet.ResultTypeSuccess
+	case adyen_common.Error:
+		return sleet.ResultTypeServerError
+	case adyen_common.Refused, adyen_common.Cancelled:
+		if resultType, ok := refusalResultTypeMap[refusalReasonCode]; ok {
+			return resultType
+		}
+		return sleet.ResultTypePaymentError
+	}
+	return sleet.ResultTypeUnknownError
+}
+
+// translateErrorResultType classifies an error returned by the Adyen library. API errors are classified by their HTTP
+// status, and other errors, such as timeouts, are server errors.
+func translateErrorResultType(err error) sleet.ResultType {
+	if apiError, ok := err.(adyen_common.APIError); ok {
+		if resultType := common.ResultTypeFromHTTPStatus(int(apiError.Status)); resultType != sleet.ResultTypeUnknownError {
+			return resultType
+		}
+		return sleet.ResultTypeAPIError
+	}
+	return sleet.ResultTypeServerError
+}
+
+// translateApprovedAmount returns the amount approved, which Adyen gives in minor units in the authorisedAmountValue and
+// authorisedAmountCurrency of the additional data of partial approvals. It is nil when Adyen doesn't report it.
+func translateApprovedAmount(additionalData map[string]interface{}) (*sleet.Amount, error) {
+	value, ok := additionalData["authorisedAmountValue"].(string)
+	if !ok || value == "" {
+		return nil, nil
+	}
+	amount, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	currency, _ := additionalData["authorisedAmountCurrency"].(string)
+	return &sleet.Amount{Amount: amount, Currency: currency}, nil
+}
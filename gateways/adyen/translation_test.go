@@ -0,0 +1,99 @@
+//go:build unit
+// +build unit
+
+package adyen
+
+import (
+	"errors"
+	"testing"
+
+	adyen_common "github.com/adyen/adyen-go-api-library/v4/src/common"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+)
+
+func TestTranslateResultType(t *testing.T) {
+	cases := []struct {
+		resultCode        adyen_common.ResultCode
+		refusalReasonCode string
+		want              sleet.ResultType
+	}{
+		{adyen_common.Authorised, "", sleet.ResultTypeSuccess},
+		{adyen_common.Refused, "2", sleet.ResultTypePaymentError},
+		{adyen_common.Refused, "6", sleet.ResultTypePaymentError},
+		{adyen_common.Refused, "24", sleet.ResultTypePaymentError},
+		{adyen_common.Refused, "7", sleet.ResultTypeAPIError},
+		{adyen_common.Refused, "4", sleet.ResultTypeServerError},
+		{adyen_common.Refused, "42", sleet.ResultTypeServerError},
+		{adyen_common.Cancelled, "", sleet.ResultTypePaymentError},
+		{adyen_common.Error, "", sleet.ResultTypeServerError},
+		{adyen_common.Pending, "", sleet.ResultTypeUnknownError},
+	}
+	for _, c := range cases {
+		t.Run(c.resultCode.String()+" "+c.refusalReasonCode, func(t *testing.T) {
+			if got := translateResultType(c.resultCode, c.refusalReasonCode); got != c.want {
+				t.Errorf("expected %s, got %s", c.want, got)
+			}
+		})
+	}
+}
+
+func TestTranslateErrorResultType(t *testing.T) {
+	cases := []struct {
+		label string
+		err   error
+		want  sleet.ResultType
+	}{
+		{"Validation", adyen_common.APIError{Status: 422, Code: "101", Type: "validation"}, sleet.ResultTypeAPIError},
+		{"Security", adyen_common.APIError{Status: 401, Code: "000", Type: "security"}, sleet.ResultTypeAPIError},
+		{"Internal", adyen_common.APIError{Status: 500, Code: "905", Type: "internal"}, sleet.ResultTypeServerError},
+		{"Unparsed", adyen_common.APIError{Code: "0"}, sleet.ResultTypeAPIError},
+		{"Timeout", errors.New("context deadline exceeded"), sleet.ResultTypeServerError},
+	}
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			if got := translateErrorResultType(c.err); got != c.want {
+				t.Errorf("expected %s, got %s", c.want, got)
+			}
+		})
+	}
+}
+
+func TestDeclineReasonMap(t *testing.T) {
+	cases := []struct {
+		resultCode        adyen_common.ResultCode
+		refusalReasonCode string
+		want              sleet.DeclineReason
+	}{
+		{adyen_common.Authorised, "", ""},
+		{adyen_common.Refused, "2", sleet.DeclineReasonDoNotHonor},
+		{adyen_common.Refused, "6", sleet.DeclineReasonExpiredCard},
+		{adyen_common.Refused, "12", sleet.DeclineReasonInsufficientFunds},
+		{adyen_common.Refused, "24", sleet.DeclineReasonCVVFailure},
+		{adyen_common.Refused, "9", sleet.DeclineReasonIssuerUnavailable},
+		{adyen_common.Refused, "7", ""},
+		{adyen_common.Refused, "99", sleet.DeclineReasonUnknown},
+	}
+	for _, c := range cases {
+		t.Run(c.resultCode.String()+" "+c.refusalReasonCode, func(t *testing.T) {
+			resultType := translateResultType(c.resultCode, c.refusalReasonCode)
+			if got := common.TranslateDeclineReason(declineReasonMap, c.refusalReasonCode, resultType); got != c.want {
+				t.Errorf("expected %q, got %q", c.want, got)
+			}
+		})
+	}
+}
+
+func TestTranslateApprovedAmount(t *testing.T) {
+	got, err := translateApprovedAmount(map[string]interface{}{"authorisedAmountValue": "600", "authorisedAmountCurrency": "USD"})
+	if err != nil || got == nil || *got != (sleet.Amount{Amount: 600, Currency: "USD"}) {
+		t.Errorf("expected an approval of 600 USD, got %+v, %v", got, err)
+	}
+	if got, err := translateApprovedAmount(map[string]interface{}{"cvcResult": "1 Matches"}); got != nil || err != nil {
+		t.Errorf("expected no approved amount when Adyen doesn't report it, got %+v, %v", got, err)
+	}
+	if _, err := translateApprovedAmount(map[string]interface{}{"authorisedAmountValue": "6.00"}); err == nil {
+		t.Error("expected an error for an amount which isn't in minor units")
+	}
+}
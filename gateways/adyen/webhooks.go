@@ -0,0 +1,138 @@
+package adyen
+
+import (
+	"crypto/hmac"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/adyen/adyen-go-api-library/v4/src/hmacvalidator"
+	"github.com/adyen/adyen-go-api-library/v4/src/notification"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/webhooks"
+)
+
+const (
+	eventCodeChargeback      = "CHARGEBACK"
+	eventCodeTechnicalCancel = "TECHNICAL_CANCEL"
+	hmacSignatureKey         = "hmacSignature"
+	modificationActionKey    = "modification.action"
+	modificationActionRefund = "refund"
+	notificationSuccessTrue  = "true"
+)
+
+var (
+	// assert parser interface
+	_ webhooks.Parser = &WebhookParser{}
+)
+
+// AcceptedResponse is the body Adyen expects in response to a notification request it delivered successfully
+const AcceptedResponse = "[accepted]"
+
+// WebhookParser verifies and translates Adyen standard notifications.
+// Every notification item is signed separately, so all items of a request must pass HMAC verification.
+type WebhookParser struct {
+	hmacKey string
+}
+
+// NewWebhookParser creates a parser using the hex encoded HMAC key configured for the webhook in the Customer Area
+func NewWebhookParser(hmacKey string) *WebhookParser {
+	return &WebhookParser{hmacKey: hmacKey}
+}
+
+// Parse verifies each notification item of the request and translates it into a webhook event
+func (p *WebhookParser) Parse(header http.Header, body []byte) ([]webhooks.WebhookEvent, error) {
+	var request notification.Notification
+	if err := json.Unmarshal(body, &request); err != nil {
+		return nil, err
+	}
+	if request.NotificationItems == nil {
+		return nil, nil
+	}
+
+	events := make([]webhooks.WebhookEvent, 0, len(*request.NotificationItems))
+	for _, notificationItem := range *request.NotificationItems {
+		item := notificationItem.NotificationRequestItem
+		if err := p.verify(item); err != nil {
+			return nil, err
+		}
+		events = append(events, translateNotificationItem(item, body))
+	}
+	return events, nil
+}
+
+// verify compares the item signature with the expected one in constant time
+func (p *WebhookParser) verify(item notification.NotificationRequestItem) error {
+	if item.AdditionalData == nil {
+		return webhooks.ErrMissingSignature
+	}
+	signature, ok := (*item.AdditionalData)[hmacSignatureKey].(string)
+	if !ok || signature == "" {
+		return webhooks.ErrMissingSignature
+	}
+	expected, err := hmacvalidator.CalculateHmac(item, p.hmacKey)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return webhooks.ErrInvalidSignature
+	}
+	return nil
+}
+
+func translateNotificationItem(item notification.NotificationRequestItem, body []byte) webhooks.WebhookEvent {
+	event := webhooks.WebhookEvent{
+		Kind:                         translateEventCode(item),
+		PsPEventType:                 item.EventCode,
+		TransactionReference:         item.PspReference,
+		OriginalTransactionReference: item.OriginalReference,
+		MerchantReference:            item.MerchantReference,
+		Success:                      isSuccessful(item),
+		RawPayload:                   body,
+	}
+	if item.Amount.Currency != "" {
+		event.Amount = &sleet.Amount{
+			Amount:   item.Amount.Value,
+			Currency: item.Amount.Currency,
+		}
+	}
+	return event
+}
+
+// isSuccessful reports whether the operation notified succeeded. Adyen sends *_FAILED events with success set to
+// true as the notification itself describes the failure.
+func isSuccessful(item notification.NotificationRequestItem) bool {
+	switch item.EventCode {
+	case notification.EventCodeCaptureFailed, notification.EventCodeRefundFailed:
+		return false
+	default:
+		return item.Success == notificationSuccessTrue
+	}
+}
+
+func translateEventCode(item notification.NotificationRequestItem) webhooks.EventKind {
+	switch item.EventCode {
+	case notification.EventCodeAuthorisation:
+		return webhooks.EventKindAuthorization
+	case notification.EventCodeCapture, notification.EventCodeCaptureFailed:
+		return webhooks.EventKindCapture
+	case notification.EventCodeCancellation, eventCodeTechnicalCancel:
+		return webhooks.EventKindVoid
+	case notification.EventCodeRefund, notification.EventCodeRefundFailed:
+		return webhooks.EventKindRefund
+	case notification.EventCodeCancelOrRefund:
+		// Adyen tells which modification was performed in the additional data
+		if item.AdditionalData != nil {
+			if action, ok := (*item.AdditionalData)[modificationActionKey].(string); ok &&
+				strings.EqualFold(action, modificationActionRefund) {
+				return webhooks.EventKindRefund
+			}
+		}
+		return webhooks.EventKindVoid
+	case eventCodeChargeback:
+		return webhooks.EventKindChargeback
+	default:
+		return webhooks.EventKindUnknown
+	}
+}
@@ -0,0 +1,137 @@
+//go:build unit
+// +build unit
+
+package adyen
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/adyen/adyen-go-api-library/v4/src/hmacvalidator"
+	"github.com/adyen/adyen-go-api-library/v4/src/notification"
+	"github.com/go-test/deep"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/webhooks"
+)
+
+const testHmacKey = "44782DEF547AAA06C910C43932B1EB0C71FC68D9D0C057550C48EC2ACF6BA056"
+
+func buildNotification(t *testing.T, key string, items ...notification.NotificationRequestItem) []byte {
+	notificationItems := make([]notification.NotificationItem, 0, len(items))
+	for _, item := range items {
+		signature, err := hmacvalidator.CalculateHmac(item, key)
+		if err != nil {
+			t.Fatalf("error signing notification item: %s", err)
+		}
+		additionalData := map[string]interface{}{hmacSignatureKey: signature}
+		if item.AdditionalData != nil {
+			for k, v := range *item.AdditionalData {
+				additionalData[k] = v
+			}
+		}
+		item.AdditionalData = &additionalData
+		notificationItems = append(notificationItems, notification.NotificationItem{NotificationRequestItem: item})
+	}
+	body, err := json.Marshal(notification.Notification{Live: "false", NotificationItems: &notificationItems})
+	if err != nil {
+		t.Fatalf("error marshalling notification: %s", err)
+	}
+	return body
+}
+
+func TestWebhookParserParse(t *testing.T) {
+	authorisation := notification.NotificationRequestItem{
+		Amount:              notification.Amount{Currency: "USD", Value: 100},
+		EventCode:           notification.EventCodeAuthorisation,
+		MerchantAccountCode: "TestMerchant",
+		MerchantReference:   "order-1",
+		PspReference:        "8535296650153317",
+		Success:             "true",
+	}
+	refund := notification.NotificationRequestItem{
+		AdditionalData:      &map[string]interface{}{modificationActionKey: "refund"},
+		Amount:              notification.Amount{Currency: "USD", Value: 50},
+		EventCode:           notification.EventCodeCancelOrRefund,
+		MerchantAccountCode: "TestMerchant",
+		MerchantReference:   "order-1",
+		OriginalReference:   "8535296650153317",
+		PspReference:        "8835296650153318",
+		Success:             "true",
+	}
+	captureFailed := notification.NotificationRequestItem{
+		Amount:              notification.Amount{Currency: "USD", Value: 100},
+		EventCode:           notification.EventCodeCaptureFailed,
+		MerchantAccountCode: "TestMerchant",
+		OriginalReference:   "8535296650153317",
+		PspReference:        "8835296650153319",
+		Success:             "true",
+	}
+	body := buildNotification(t, testHmacKey, authorisation, refund, captureFailed)
+
+	got, err := NewWebhookParser(testHmacKey).Parse(http.Header{}, body)
+	if err != nil {
+		t.Fatalf("error parsing notification: %s", err)
+	}
+
+	want := []webhooks.WebhookEvent{
+		{
+			Kind:                 webhooks.EventKindAuthorization,
+			PsPEventType:         notification.EventCodeAuthorisation,
+			TransactionReference: "8535296650153317",
+			MerchantReference:    "order-1",
+			Amount:               &sleet.Amount{Amount: 100, Currency: "USD"},
+			Success:              true,
+			RawPayload:           body,
+		},
+		{
+			Kind:                         webhooks.EventKindRefund,
+			PsPEventType:                 notification.EventCodeCancelOrRefund,
+			TransactionReference:         "8835296650153318",
+			OriginalTransactionReference: "8535296650153317",
+			MerchantReference:            "order-1",
+			Amount:                       &sleet.Amount{Amount: 50, Currency: "USD"},
+			Success:                      true,
+			RawPayload:                   body,
+		},
+		{
+			Kind:                         webhooks.EventKindCapture,
+			PsPEventType:                 notification.EventCodeCaptureFailed,
+			TransactionReference:         "8835296650153319",
+			OriginalTransactionReference: "8535296650153317",
+			Amount:                       &sleet.Amount{Amount: 100, Currency: "USD"},
+			Success:                      false,
+			RawPayload:                   body,
+		},
+	}
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestWebhookParserParseInvalidSignature(t *testing.T) {
+	item := notification.NotificationRequestItem{
+		Amount:       notification.Amount{Currency: "USD", Value: 100},
+		EventCode:    notification.EventCodeAuthorisation,
+		PspReference: "8535296650153317",
+		Success:      "true",
+	}
+
+	t.Run("Wrong key", func(t *testing.T) {
+		body := buildNotification(t, "00112233", item)
+		_, err := NewWebhookParser(testHmacKey).Parse(http.Header{}, body)
+		if err != webhooks.ErrInvalidSignature {
+			t.Errorf("expected %v, got %v", webhooks.ErrInvalidSignature, err)
+		}
+	})
+
+	t.Run("Missing signature", func(t *testing.T) {
+		items := []notification.NotificationItem{{NotificationRequestItem: item}}
+		body, _ := json.Marshal(notification.Notification{NotificationItems: &items})
+		_, err := NewWebhookParser(testHmacKey).Parse(http.Header{}, body)
+		if err != webhooks.ErrMissingSignature {
+			t.Errorf("expected %v, got %v", webhooks.ErrMissingSignature, err)
+		}
+	})
+}
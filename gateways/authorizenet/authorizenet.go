@@ -11,9 +11,15 @@ import (
 	"github.com/BoltApp/sleet/common"
 )
 
+const gatewayName = "authorizenet"
+
 var (
 	// assert client interface
-	_ sleet.ClientWithContext = &AuthorizeNetClient{}
+	_ sleet.ClientWithContext             = &AuthorizeNetClient{}
+	_ sleet.TransactionQuerierWithContext = &AuthorizeNetClient{}
+	_ sleet.TransactionFinderWithContext  = &AuthorizeNetClient{}
+	_ sleet.SaleWithContext               = &AuthorizeNetClient{}
+	_ sleet.CapabilitiesReporter          = &AuthorizeNetClient{}
 )
 
 // AuthorizeNetClient uses merchant name and transaction key to process requests. Optionally can provide custom http clients
@@ -22,20 +28,42 @@ type AuthorizeNetClient struct {
 	transactionKey string
 	httpClient     *http.Client
 	url            string
+	logger         sleet.Logger
 }
 
 // NewClient uses authentication above with a default http client
-func NewClient(merchantName string, transactionKey string, environment common.Environment) *AuthorizeNetClient {
-	return NewWithHttpClient(merchantName, transactionKey, environment, common.DefaultHttpClient())
+func NewClient(merchantName string, transactionKey string, environment common.Environment, options ...sleet.ClientOption) *AuthorizeNetClient {
+	return NewWithHttpClient(merchantName, transactionKey, environment, common.DefaultHttpClient(), options...)
 }
 
 // NewWithHttpClient uses authentication with custom http client
-func NewWithHttpClient(merchantName string, transactionKey string, environment common.Environment, httpClient *http.Client) *AuthorizeNetClient {
+func NewWithHttpClient(merchantName string, transactionKey string, environment common.Environment, httpClient *http.Client, options ...sleet.ClientOption) *AuthorizeNetClient {
+	clientOptions := sleet.NewClientOptions(options...)
 	return &AuthorizeNetClient{
 		merchantName:   merchantName,
 		transactionKey: transactionKey,
-		httpClient:     httpClient,
-		url:            authorizeNetURL(environment),
+		httpClient:     common.LoggingHttpClient(httpClient, gatewayName, clientOptions.Logger),
+		url:            common.OverrideBaseURL(authorizeNetURL(environment), clientOptions.BaseURL),
+		logger:         clientOptions.Logger,
+	}
+}
+
+// Capabilities describes the optional operations supported by Authorize.Net
+func (client *AuthorizeNetClient) Capabilities() sleet.Capabilities {
+	return sleet.Capabilities{
+		ContextSupport:       true,
+		Sale:                 true,
+		Vault:                true,
+		TransactionQuery:     true,
+		FindByReference:      true,
+		PartialAuthorization: true,
+		PartialCapture:       true,
+		PartialRefund:        true,
+		Level2:               true,
+		Level3:               true,
+		NetworkTokens:        true,
+		ApplePay:             true,
+		GooglePay:            true,
 	}
 }
 
@@ -47,7 +75,32 @@ func (client *AuthorizeNetClient) Authorize(request *sleet.AuthorizationRequest)
 // AuthorizeWithContext a transaction for specified amount using Auth.net REST APIs
 func (client *AuthorizeNetClient) AuthorizeWithContext(ctx context.Context, request *sleet.AuthorizationRequest) (*sleet.AuthorizationResponse, error) {
 	authorizeNetAuthorizeRequest := buildAuthRequest(client.merchantName, client.transactionKey, request)
-	response, httpResp, err := client.sendRequest(ctx, *authorizeNetAuthorizeRequest)
+	response, err := client.sendAuthRequest(ctx, authorizeNetAuthorizeRequest, request.Amount.Currency, request.Options)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationAuthorize, err)
+	}
+	return response, nil
+}
+
+// Sale authorizes and captures a transaction for specified amount in a single call
+func (client *AuthorizeNetClient) Sale(request *sleet.SaleRequest) (*sleet.SaleResponse, error) {
+	return client.SaleWithContext(context.TODO(), request)
+}
+
+// SaleWithContext authorizes and captures a transaction for specified amount in a single call using the
+// transactionTypeAuthCapture flag
+func (client *AuthorizeNetClient) SaleWithContext(ctx context.Context, request *sleet.SaleRequest) (*sleet.SaleResponse, error) {
+	authorizeNetSaleRequest := buildSaleRequest(client.merchantName, client.transactionKey, request)
+	resp, err := client.sendAuthRequest(ctx, authorizeNetSaleRequest, request.Amount.Currency, request.Options)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationSale, err)
+	}
+	return &sleet.SaleResponse{AuthorizationResponse: *resp}, nil
+}
+
+// sendAuthRequest sends an authorization or sale request and translates the transaction response
+func (client *AuthorizeNetClient) sendAuthRequest(ctx context.Context, request *Request, currency string, options map[string]interface{}) (*sleet.AuthorizationResponse, error) {
+	response, httpResp, err := client.sendRequest(ctx, *request)
 	if err != nil {
 		return nil, err
 	}
@@ -56,10 +109,16 @@ func (client *AuthorizeNetClient) AuthorizeWithContext(ctx context.Context, requ
 	if txnResponse.ResponseCode != ResponseCodeApproved {
 		errorCode = getErrorCode(txnResponse)
 	}
-	responseHeader := sleet.GetHTTPResponseHeader(request.Options, *httpResp)
+	responseHeader := sleet.GetHTTPResponseHeader(options, *httpResp)
+	success := txnResponse.ResponseCode == ResponseCodeApproved || txnResponse.ResponseCode == ResponseCodeHeld
+	resultType := sleet.ResultTypeSuccess
+	if !success {
+		resultType = translateResultType(txnResponse.ResponseCode, errorCode)
+	}
+	declineReason := common.TranslateDeclineReason(declineReasonMap, errorCode, resultType)
 
 	resp := sleet.AuthorizationResponse{
-		Success:              txnResponse.ResponseCode == ResponseCodeApproved || txnResponse.ResponseCode == ResponseCodeHeld,
+		Success:              success,
 		TransactionReference: txnResponse.TransID,
 		AvsResult:            translateAvs(txnResponse.AVSResultCode),
 		CvvResult:            translateCvv(txnResponse.CVVResultCode),
@@ -67,11 +126,22 @@ func (client *AuthorizeNetClient) AuthorizeWithContext(ctx context.Context, requ
 		CvvResultRaw:         string(txnResponse.CVVResultCode),
 		Response:             string(txnResponse.ResponseCode),
 		ErrorCode:            errorCode,
+		ResultType:           resultType,
+		DeclineReason:        declineReason,
 		StatusCode:           httpResp.StatusCode,
 		Metadata:             buildResponseMetadata(txnResponse),
 		Header:               responseHeader,
 	}
 
+	// prepaid cards report the approved amount, which is less than requested on a partial authorization
+	if txnResponse.PrePaidCard != nil && txnResponse.PrePaidCard.ApprovedAmount != "" {
+		approvedAmount, err := common.AmountFromDecimalString(txnResponse.PrePaidCard.ApprovedAmount, currency)
+		if err != nil {
+			return nil, common.ResponseError(gatewayName, httpResp.StatusCode, err)
+		}
+		resp.ApprovedAmount = &sleet.Amount{Amount: approvedAmount, Currency: currency}
+	}
+
 	return &resp, nil
 }
 
@@ -83,19 +153,35 @@ func (client *AuthorizeNetClient) Capture(request *sleet.CaptureRequest) (*sleet
 // CaptureWithContext captures an authorized transaction by transaction reference using the transactionTypePriorAuthCapture flag
 func (client *AuthorizeNetClient) CaptureWithContext(ctx context.Context, request *sleet.CaptureRequest) (*sleet.CaptureResponse, error) {
 	authorizeNetCaptureRequest := buildCaptureRequest(client.merchantName, client.transactionKey, request)
-	authorizeNetResponse, _, err := client.sendRequest(ctx, *authorizeNetCaptureRequest)
+	authorizeNetResponse, httpResp, err := client.sendRequest(ctx, *authorizeNetCaptureRequest)
 	if err != nil {
-		return nil, err
+		return nil, common.OperationError(gatewayName, sleet.OperationCapture, err)
 	}
 
+	responseHeader := sleet.GetHTTPResponseHeader(request.Options, *httpResp)
 	if authorizeNetResponse.TransactionResponse.ResponseCode != ResponseCodeApproved ||
 		isAlreadyCaptured(authorizeNetResponse.TransactionResponse) {
 		errorCode := getErrorCode(authorizeNetResponse.TransactionResponse)
-		return &sleet.CaptureResponse{ErrorCode: &errorCode}, nil
+		resultType := translateResultType(authorizeNetResponse.TransactionResponse.ResponseCode, errorCode)
+		if isAlreadyCaptured(authorizeNetResponse.TransactionResponse) {
+			resultType = sleet.ResultTypeAPIError
+		}
+		return &sleet.CaptureResponse{
+			ErrorCode:  &errorCode,
+			Message:    getErrorMessage(authorizeNetResponse),
+			ResultType: resultType,
+			StatusCode: httpResp.StatusCode,
+			Header:     responseHeader,
+		}, nil
 	}
 	return &sleet.CaptureResponse{
 		Success:              true,
 		TransactionReference: authorizeNetResponse.TransactionResponse.TransID,
+		ResultType:           sleet.ResultTypeSuccess,
+		Amount:               request.Amount,
+		Metadata:             buildResponseMetadata(authorizeNetResponse.TransactionResponse),
+		StatusCode:           httpResp.StatusCode,
+		Header:               responseHeader,
 	}, nil
 }
 
@@ -107,18 +193,29 @@ func (client *AuthorizeNetClient) Void(request *sleet.VoidRequest) (*sleet.VoidR
 // VoidWithContext voids an existing authorized transaction
 func (client *AuthorizeNetClient) VoidWithContext(ctx context.Context, request *sleet.VoidRequest) (*sleet.VoidResponse, error) {
 	authorizeNetCaptureRequest := buildVoidRequest(client.merchantName, client.transactionKey, request)
-	authorizeNetResponse, _, err := client.sendRequest(ctx, *authorizeNetCaptureRequest)
+	authorizeNetResponse, httpResp, err := client.sendRequest(ctx, *authorizeNetCaptureRequest)
 	if err != nil {
-		return nil, err
+		return nil, common.OperationError(gatewayName, sleet.OperationVoid, err)
 	}
 
+	responseHeader := sleet.GetHTTPResponseHeader(request.Options, *httpResp)
 	if authorizeNetResponse.TransactionResponse.ResponseCode != ResponseCodeApproved {
 		errorCode := getErrorCode(authorizeNetResponse.TransactionResponse)
-		return &sleet.VoidResponse{ErrorCode: &errorCode}, nil
+		return &sleet.VoidResponse{
+			ErrorCode:  &errorCode,
+			Message:    getErrorMessage(authorizeNetResponse),
+			ResultType: translateResultType(authorizeNetResponse.TransactionResponse.ResponseCode, errorCode),
+			StatusCode: httpResp.StatusCode,
+			Header:     responseHeader,
+		}, nil
 	}
 	return &sleet.VoidResponse{
 		Success:              true,
 		TransactionReference: authorizeNetResponse.TransactionResponse.TransID,
+		ResultType:           sleet.ResultTypeSuccess,
+		Metadata:             buildResponseMetadata(authorizeNetResponse.TransactionResponse),
+		StatusCode:           httpResp.StatusCode,
+		Header:               responseHeader,
 	}, nil
 }
 
@@ -135,7 +232,7 @@ func (client *AuthorizeNetClient) RefundWithContext(ctx context.Context, request
 			TransactionReference: request.TransactionReference,
 		})
 		if err != nil {
-			return nil, err
+			return nil, common.OperationError(gatewayName, sleet.OperationRefund, err)
 		}
 		creditCardNumber := transactionDetailsResponse.CardNumber
 		last4 := creditCardNumber[len(creditCardNumber)-4:]
@@ -144,22 +241,34 @@ func (client *AuthorizeNetClient) RefundWithContext(ctx context.Context, request
 
 	authorizeNetRefundRequest, err := buildRefundRequest(client.merchantName, client.transactionKey, request)
 	if err != nil {
-		return nil, err
+		return nil, common.OperationError(gatewayName, sleet.OperationRefund, err)
 	}
 
-	authorizeNetResponse, _, err := client.sendRequest(ctx, *authorizeNetRefundRequest)
+	authorizeNetResponse, httpResp, err := client.sendRequest(ctx, *authorizeNetRefundRequest)
 	if err != nil {
-		return nil, err
+		return nil, common.OperationError(gatewayName, sleet.OperationRefund, err)
 	}
 
+	responseHeader := sleet.GetHTTPResponseHeader(request.Options, *httpResp)
 	if authorizeNetResponse.TransactionResponse.ResponseCode != ResponseCodeApproved {
 		errorCode := getErrorCode(authorizeNetResponse.TransactionResponse)
-		response := sleet.RefundResponse{ErrorCode: &errorCode}
+		response := sleet.RefundResponse{
+			ErrorCode:  &errorCode,
+			Message:    getErrorMessage(authorizeNetResponse),
+			ResultType: translateResultType(authorizeNetResponse.TransactionResponse.ResponseCode, errorCode),
+			StatusCode: httpResp.StatusCode,
+			Header:     responseHeader,
+		}
 		return &response, nil
 	}
 	return &sleet.RefundResponse{
 		Success:              true,
 		TransactionReference: authorizeNetResponse.TransactionResponse.TransID,
+		ResultType:           sleet.ResultTypeSuccess,
+		Amount:               request.Amount,
+		Metadata:             buildResponseMetadata(authorizeNetResponse.TransactionResponse),
+		StatusCode:           httpResp.StatusCode,
+		Header:               responseHeader,
 	}, nil
 }
 
@@ -174,12 +283,12 @@ func (client *AuthorizeNetClient) GetTransactionDetails(request *sleet.Transacti
 func (client *AuthorizeNetClient) GetTransactionDetailsWithContext(ctx context.Context, request *sleet.TransactionDetailsRequest) (*sleet.TransactionDetailsResponse, error) {
 	authorizeNetTransactionDetailsRequest, err := BuildTransactionDetailsRequest(client.merchantName, client.transactionKey, request)
 	if err != nil {
-		return nil, err
+		return nil, common.OperationError(gatewayName, sleet.OperationGetTransactionDetails, err)
 	}
 
 	authorizeNetResponse, _, err := client.sendRequest(ctx, *authorizeNetTransactionDetailsRequest)
 	if err != nil {
-		return nil, err
+		return nil, common.OperationError(gatewayName, sleet.OperationGetTransactionDetails, err)
 	}
 
 	if authorizeNetResponse.Messsages.ResultCode != ResultCodeOK {
@@ -194,6 +303,71 @@ func (client *AuthorizeNetClient) GetTransactionDetailsWithContext(ctx context.C
 	}, nil
 }
 
+// QueryTransaction looks up the current state of a transaction with getTransactionDetails.
+// Auth.net does not return the currency of a transaction, so Currency is empty.
+func (client *AuthorizeNetClient) QueryTransaction(request *sleet.TransactionQueryRequest) (*sleet.TransactionQueryResponse, error) {
+	return client.QueryTransactionWithContext(context.TODO(), request)
+}
+
+// QueryTransactionWithContext looks up the current state of a transaction with getTransactionDetails.
+// Auth.net does not return the currency of a transaction, so Currency is empty.
+func (client *AuthorizeNetClient) QueryTransactionWithContext(ctx context.Context, request *sleet.TransactionQueryRequest) (*sleet.TransactionQueryResponse, error) {
+	authorizeNetTransactionDetailsRequest, err := BuildTransactionDetailsRequest(client.merchantName, client.transactionKey, &sleet.TransactionDetailsRequest{
+		TransactionReference: request.TransactionReference,
+	})
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationQueryTransaction, err)
+	}
+
+	authorizeNetResponse, _, err := client.sendRequest(ctx, *authorizeNetTransactionDetailsRequest)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationQueryTransaction, err)
+	}
+
+	if authorizeNetResponse.Messsages.ResultCode != ResultCodeOK || authorizeNetResponse.Transaction == nil {
+		errorCode := getMessagesErrorCode(authorizeNetResponse.Messsages)
+		return &sleet.TransactionQueryResponse{ErrorCode: &errorCode}, nil
+	}
+	return translateTransaction(authorizeNetResponse.Transaction), nil
+}
+
+// FindByClientReference searches the most recent unsettled transactions for those with the MerchantOrderReference,
+// which is sent to Auth.net as the invoice number. Settled transactions are not searched, as this is meant for
+// recovering authorizations whose outcome is unknown.
+func (client *AuthorizeNetClient) FindByClientReference(request *sleet.FindByClientReferenceRequest) (*sleet.FindByClientReferenceResponse, error) {
+	return client.FindByClientReferenceWithContext(context.TODO(), request)
+}
+
+// FindByClientReferenceWithContext searches the most recent unsettled transactions for those with the
+// MerchantOrderReference, which is sent to Auth.net as the invoice number.
+func (client *AuthorizeNetClient) FindByClientReferenceWithContext(ctx context.Context, request *sleet.FindByClientReferenceRequest) (*sleet.FindByClientReferenceResponse, error) {
+	if request.MerchantOrderReference == nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationFindByClientReference, sleet.ErrClientReferenceRequired)
+	}
+	invoiceNumber := sleet.TruncateString(*request.MerchantOrderReference, InvoiceNumberMaxLength)
+
+	authorizeNetResponse, _, err := client.sendRequest(ctx, *buildUnsettledTransactionListRequest(client.merchantName, client.transactionKey))
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationFindByClientReference, err)
+	}
+
+	if authorizeNetResponse.Messsages.ResultCode != ResultCodeOK {
+		errorCode := getMessagesErrorCode(authorizeNetResponse.Messsages)
+		return &sleet.FindByClientReferenceResponse{ErrorCode: &errorCode}, nil
+	}
+
+	transactions := []sleet.TransactionQueryResponse{}
+	for _, summary := range authorizeNetResponse.Transactions {
+		if summary.InvoiceNumber == invoiceNumber {
+			transactions = append(transactions, translateTransactionSummary(summary))
+		}
+	}
+	return &sleet.FindByClientReferenceResponse{
+		Success:      true,
+		Transactions: transactions,
+	}, nil
+}
+
 func (client *AuthorizeNetClient) sendRequest(ctx context.Context, data Request) (*Response, *http.Response, error) {
 	bodyJSON, err := json.Marshal(data)
 	if err != nil {
@@ -210,26 +384,28 @@ func (client *AuthorizeNetClient) sendRequest(ctx context.Context, data Request)
 
 	resp, err := client.httpClient.Do(request)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, common.TransportError(gatewayName, err)
 	}
 	defer func() {
 		err := resp.Body.Close()
 		if err != nil {
-			// TODO log
+			common.Logger(ctx, client.logger).Warn(common.LogCloseFailed, "gateway", gatewayName, "error", err)
 		}
 	}()
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, common.TransportError(gatewayName, err)
 	}
 	// trim UTF-8 BOM
 	bodyBytes := bytes.TrimPrefix(body, []byte("\xef\xbb\xbf"))
 	var authorizeNetResponse Response
 	err = json.Unmarshal(bodyBytes, &authorizeNetResponse)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, common.ResponseError(gatewayName, resp.StatusCode, err)
 	}
+	common.LogResult(ctx, client.logger, gatewayName, string(authorizeNetResponse.Messsages.ResultCode),
+		"response_code", string(authorizeNetResponse.TransactionResponse.ResponseCode))
 	return &authorizeNetResponse, resp, nil
 }
 
@@ -244,6 +420,21 @@ func getErrorCode(txnResponse TransactionResponse) string {
 	}
 }
 
+// getErrorMessage returns the text of the first transaction error, or of the first request message when the request
+// was rejected before a transaction was attempted.
+func getErrorMessage(response *Response) string {
+	if len(response.TransactionResponse.Errors) > 0 {
+		return response.TransactionResponse.Errors[0].ErrorText
+	}
+	if len(response.TransactionResponse.Messages) > 0 {
+		return response.TransactionResponse.Messages[0].Description
+	}
+	if len(response.Messsages.Message) > 0 {
+		return response.Messsages.Message[0].Text
+	}
+	return ""
+}
+
 func isAlreadyCaptured(txnResponse TransactionResponse) bool {
 	for _, message := range txnResponse.Messages {
 		if message.Code == MessageResponseCodeAlreadyCaptured {
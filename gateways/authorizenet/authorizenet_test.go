@@ -5,9 +5,11 @@ package authorizenet
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/jarcoal/httpmock"
@@ -21,7 +23,8 @@ func TestNewClient(t *testing.T) {
 	t.Run("Dev environment", func(t *testing.T) {
 		want := &AuthorizeNetClient{
 			url:            "https://apitest.authorize.net/xml/v1/request.api",
-			httpClient:     common.DefaultHttpClient(),
+			httpClient:     common.LoggingHttpClient(common.DefaultHttpClient(), gatewayName, sleet.NopLogger{}),
+			logger:         sleet.NopLogger{},
 			merchantName:   "MerchantName",
 			transactionKey: "Key",
 		}
@@ -37,7 +40,8 @@ func TestNewClient(t *testing.T) {
 	t.Run("Production environment", func(t *testing.T) {
 		want := &AuthorizeNetClient{
 			url:            "https://api.authorize.net/xml/v1/request.api",
-			httpClient:     common.DefaultHttpClient(),
+			httpClient:     common.LoggingHttpClient(common.DefaultHttpClient(), gatewayName, sleet.NopLogger{}),
+			logger:         sleet.NopLogger{},
 			merchantName:   "MerchantName",
 			transactionKey: "Key",
 		}
@@ -125,6 +129,7 @@ func TestAuthorize(t *testing.T) {
 			Metadata:             metadata,
 			StatusCode:           200,
 			Header:               http.Header{"X-Test-Header": {"test_header_value"}},
+			ResultType:           sleet.ResultTypeSuccess,
 		}
 
 		client := NewClient("MerchantName", "Key", common.Sandbox)
@@ -163,6 +168,47 @@ func TestAuthorize(t *testing.T) {
 			Response:             "2",
 			StatusCode:           200,
 			Header:               http.Header{"X-Test-Header": {"test_header_value"}},
+			ResultType:           sleet.ResultTypePaymentError,
+			DeclineReason:        sleet.DeclineReasonDoNotHonor,
+		}
+
+		client := NewClient("MerchantName", "Key", common.Sandbox)
+
+		got, err := client.Authorize(request)
+
+		if err != nil {
+			t.Fatalf("Error thrown after sending request %q", err)
+		}
+
+		if !cmp.Equal(*got, *want, sleet_t.CompareUnexported) {
+			t.Error("Response body does not match expected")
+			t.Error(cmp.Diff(*want, *got, sleet_t.CompareUnexported))
+		}
+	})
+
+	t.Run("With Partial Approval Response", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		httpmock.RegisterResponder("POST", url, func(req *http.Request) (*http.Response, error) {
+			authResponseRaw = helper.ReadFile("test_data/authPartialResponse.json")
+			return httpmock.NewBytesResponse(http.StatusOK, authResponseRaw), nil
+		})
+
+		want := &sleet.AuthorizationResponse{
+			Success:              true,
+			TransactionReference: "2149186849",
+			ApprovedAmount:       &sleet.Amount{Amount: 73, Currency: "USD"},
+			AvsResult:            sleet.AVSResponseMatch,
+			CvvResult:            sleet.CVVResponseMatch,
+			ErrorCode:            "295",
+			AvsResultRaw:         "Y",
+			CvvResultRaw:         "M",
+			Response:             "4",
+			Metadata:             map[string]string{sleet.AuthCodeMetadata: "HH5415"},
+			StatusCode:           200,
+			Header:               http.Header{},
+			ResultType:           sleet.ResultTypeSuccess,
 		}
 
 		client := NewClient("MerchantName", "Key", common.Sandbox)
@@ -221,6 +267,10 @@ func TestCapture(t *testing.T) {
 		want := &sleet.CaptureResponse{
 			Success:              true,
 			TransactionReference: "1234567890",
+			ResultType:           sleet.ResultTypeSuccess,
+			Amount:               &sleet.Amount{Amount: 100, Currency: "USD"},
+			Metadata:             map[string]string{sleet.AuthCodeMetadata: "HH5414"},
+			StatusCode:           200,
 		}
 
 		client := NewClient("MerchantName", "Key", common.Sandbox)
@@ -278,6 +328,9 @@ func TestVoid(t *testing.T) {
 		want := &sleet.VoidResponse{
 			Success:              true,
 			TransactionReference: "1234567890",
+			ResultType:           sleet.ResultTypeSuccess,
+			Metadata:             map[string]string{sleet.AuthCodeMetadata: "HH5414"},
+			StatusCode:           200,
 		}
 
 		client := NewClient("MerchantName", "Key", common.Sandbox)
@@ -336,6 +389,9 @@ func TestRefund(t *testing.T) {
 		want := &sleet.RefundResponse{
 			Success:              true,
 			TransactionReference: "1234569999",
+			ResultType:           sleet.ResultTypeSuccess,
+			Amount:               &sleet.Amount{Amount: 100, Currency: "USD"},
+			StatusCode:           200,
 		}
 
 		client := NewClient("MerchantName", "Key", common.Sandbox)
@@ -364,8 +420,11 @@ func TestRefund(t *testing.T) {
 		})
 
 		want := &sleet.RefundResponse{
-			Success:   false,
-			ErrorCode: common.SPtr("16"),
+			Success:    false,
+			ErrorCode:  common.SPtr("16"),
+			ResultType: sleet.ResultTypeAPIError,
+			Message:    "The transaction cannot be found.",
+			StatusCode: 200,
 		}
 
 		client := NewClient("MerchantName", "Key", common.Sandbox)
@@ -469,6 +528,186 @@ func TestGetTransactionDetails(t *testing.T) {
 	})
 }
 
+func TestQueryTransaction(t *testing.T) {
+	helper := sleet_t.NewTestHelper(t)
+	url := "https://apitest.authorize.net/xml/v1/request.api"
+
+	t.Run("With Success Response", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		httpmock.RegisterResponder("POST", url, func(req *http.Request) (*http.Response, error) {
+			transactionDetailsResponseRaw := helper.ReadFile("test_data/transactionDetailsSuccessResponse.json")
+			resp := httpmock.NewBytesResponse(http.StatusOK, transactionDetailsResponseRaw)
+			return resp, nil
+		})
+
+		createdAt := time.Date(2023, 3, 21, 20, 1, 31, 243000000, time.UTC)
+		updatedAt := time.Date(2023, 3, 22, 3, 40, 34, 663000000, time.UTC)
+		want := &sleet.TransactionQueryResponse{
+			Success:              true,
+			TransactionReference: "40116993894",
+			State:                sleet.TransactionStateSettled,
+			StateRaw:             "settledSuccessfully",
+			AuthorizedAmount:     10050,
+			CapturedAmount:       10050,
+			Last4:                "1111",
+			Network:              sleet.CreditCardNetworkVisa,
+			CreatedAt:            &createdAt,
+			UpdatedAt:            &updatedAt,
+		}
+
+		client := NewClient("MerchantName", "Key", common.Sandbox)
+
+		got, err := client.QueryTransaction(&sleet.TransactionQueryRequest{TransactionReference: "40116993894"})
+
+		if err != nil {
+			t.Fatalf("Error thrown after sending request %q", err)
+		}
+
+		if !cmp.Equal(*got, *want, sleet_t.CompareUnexported) {
+			t.Error("Response body does not match expected")
+			t.Error(cmp.Diff(*want, *got, sleet_t.CompareUnexported))
+		}
+	})
+
+	t.Run("With Error Response", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		httpmock.RegisterResponder("POST", url, func(req *http.Request) (*http.Response, error) {
+			transactionDetailsResponseRaw := helper.ReadFile("test_data/transactionDetailsErrorResponse.json")
+			resp := httpmock.NewBytesResponse(http.StatusOK, transactionDetailsResponseRaw)
+			return resp, nil
+		})
+
+		client := NewClient("MerchantName", "Key", common.Sandbox)
+
+		got, err := client.QueryTransaction(&sleet.TransactionQueryRequest{TransactionReference: "1234569999"})
+
+		if err != nil {
+			t.Fatalf("Error thrown after sending request %q", err)
+		}
+
+		if got.Success || got.ErrorCode == nil {
+			t.Errorf("Expected an unsuccessful response with an error code, got %+v", got)
+		}
+	})
+}
+
+func TestFindByClientReference(t *testing.T) {
+	helper := sleet_t.NewTestHelper(t)
+	url := "https://apitest.authorize.net/xml/v1/request.api"
+
+	t.Run("With Matching Transaction", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		httpmock.RegisterResponder("POST", url, func(req *http.Request) (*http.Response, error) {
+			transactionListResponseRaw := helper.ReadFile("test_data/unsettledTransactionListResponse.json")
+			resp := httpmock.NewBytesResponse(http.StatusOK, transactionListResponseRaw)
+			return resp, nil
+		})
+
+		createdAt := time.Date(2023, 3, 22, 18, 10, 5, 0, time.UTC)
+		want := &sleet.FindByClientReferenceResponse{
+			Success: true,
+			Transactions: []sleet.TransactionQueryResponse{
+				{
+					Success:              true,
+					TransactionReference: "40116994001",
+					State:                sleet.TransactionStateAuthorized,
+					StateRaw:             "authorizedPendingCapture",
+					AuthorizedAmount:     10050,
+					Last4:                "1111",
+					Network:              sleet.CreditCardNetworkVisa,
+					CreatedAt:            &createdAt,
+				},
+			},
+		}
+
+		client := NewClient("MerchantName", "Key", common.Sandbox)
+
+		got, err := client.FindByClientReference(&sleet.FindByClientReferenceRequest{
+			MerchantOrderReference: common.SPtr("order-1234"),
+		})
+
+		if err != nil {
+			t.Fatalf("Error thrown after sending request %q", err)
+		}
+
+		if !cmp.Equal(*got, *want, sleet_t.CompareUnexported) {
+			t.Error("Response body does not match expected")
+			t.Error(cmp.Diff(*want, *got, sleet_t.CompareUnexported))
+		}
+	})
+
+	t.Run("Without Merchant Order Reference", func(t *testing.T) {
+		client := NewClient("MerchantName", "Key", common.Sandbox)
+
+		_, err := client.FindByClientReference(&sleet.FindByClientReferenceRequest{
+			ClientTransactionReference: common.SPtr("order-1234"),
+		})
+
+		if !errors.Is(err, sleet.ErrClientReferenceRequired) {
+			t.Errorf("Expected ErrClientReferenceRequired, got %v", err)
+		}
+		var sleetErr *sleet.Error
+		if !errors.As(err, &sleetErr) || sleetErr.Kind != sleet.ErrorKindValidation {
+			t.Errorf("Expected a validation error, got %v", err)
+		}
+	})
+}
+
+func TestErrors(t *testing.T) {
+	url := "https://apitest.authorize.net/xml/v1/request.api"
+	request := sleet_t.BaseCaptureRequest()
+
+	cases := []struct {
+		label     string
+		responder httpmock.Responder
+		want      sleet.Error
+	}{
+		{
+			"Connection Failure",
+			httpmock.NewErrorResponder(errors.New("connection refused")),
+			sleet.Error{Kind: sleet.ErrorKindNetwork, Gateway: gatewayName, Operation: sleet.OperationCapture, Retryable: true},
+		},
+		{
+			"Gateway Error Page",
+			httpmock.NewStringResponder(http.StatusBadGateway, "<html>Bad Gateway</html>"),
+			sleet.Error{Kind: sleet.ErrorKindPSPAPI, Gateway: gatewayName, Operation: sleet.OperationCapture, Retryable: true, StatusCode: http.StatusBadGateway},
+		},
+		{
+			"Malformed Response",
+			httpmock.NewStringResponder(http.StatusOK, "{"),
+			sleet.Error{Kind: sleet.ErrorKindDecode, Gateway: gatewayName, Operation: sleet.OperationCapture, StatusCode: http.StatusOK},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			httpmock.Activate()
+			defer httpmock.DeactivateAndReset()
+			httpmock.RegisterResponder("POST", url, c.responder)
+
+			client := NewClient("MerchantName", "Key", common.Sandbox)
+			got, err := client.Capture(request)
+			if got != nil {
+				t.Errorf("Expected no response, got %v", got)
+			}
+			var sleetErr *sleet.Error
+			if !errors.As(err, &sleetErr) {
+				t.Fatalf("Expected a sleet.Error, got %v", err)
+			}
+			sleetErr.Err = nil
+			if *sleetErr != c.want {
+				t.Errorf("Expected %+v, got %+v", c.want, *sleetErr)
+			}
+		})
+	}
+}
+
 func TestAlreadyCaptured(t *testing.T) {
 	helper := sleet_t.NewTestHelper(t)
 
@@ -494,6 +733,9 @@ func TestAlreadyCaptured(t *testing.T) {
 			Success:              false,
 			TransactionReference: "",
 			ErrorCode:            common.SPtr("1"),
+			ResultType:           sleet.ResultTypeAPIError,
+			Message:              "This transaction has already been captured.",
+			StatusCode:           200,
 		}
 
 		client := NewClient("MerchantName", "Key", common.Sandbox)
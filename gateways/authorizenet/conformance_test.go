@@ -0,0 +1,31 @@
+//go:build unit
+// +build unit
+
+package authorizenet
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+	"github.com/BoltApp/sleet/testing/conformance"
+	"github.com/BoltApp/sleet/testing/fakes"
+)
+
+func TestConformance(t *testing.T) {
+	fake := fakes.NewAuthorizeNet()
+	defer fake.Close()
+
+	conformance.Run(t, conformance.Suite{
+		Gateway:   "authorizenet",
+		Transport: fake.Client().Transport,
+		NewClient: func(httpClient *http.Client) sleet.ClientWithContext {
+			return NewWithHttpClient(fakes.AuthorizeNetLoginID, fakes.AuthorizeNetTransactionKey, common.Sandbox, httpClient)
+		},
+		// the sandbox declines a billing ZIP code of 46282
+		Decline: func(request *sleet.AuthorizationRequest) { request.BillingAddress.PostalCode = common.SPtr("46282") },
+		AVS:     sleet.AVSResponseMatch,
+		CVV:     sleet.CVVResponseMatch,
+	})
+}
@@ -0,0 +1,27 @@
+package authorizenet
+
+import (
+	"net/http"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/registry"
+)
+
+// Auth.net credential keys of a registry.Config
+const (
+	credentialMerchantName   = "merchant_name"
+	credentialTransactionKey = "transaction_key"
+)
+
+func init() {
+	registry.Register("authorizenet", newRegistryClient)
+}
+
+// newRegistryClient builds an AuthorizeNetClient from a registry config
+func newRegistryClient(config *registry.Config, httpClient *http.Client, options ...sleet.ClientOption) (sleet.ClientWithContext, error) {
+	credentials, err := config.RequiredCredentials(credentialMerchantName, credentialTransactionKey)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithHttpClient(credentials[0], credentials[1], config.Environment, httpClient, options...), nil
+}
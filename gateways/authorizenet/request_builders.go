@@ -11,37 +11,44 @@ import (
 )
 
 const (
-	InvoiceNumberMaxLength = 20
+	InvoiceNumberMaxLength      = 20
+	MerchantCustomerIDMaxLength = 20
+	TransactionListMaxLength    = 1000
 )
 
+// verificationProfileDescription describes the temporary customer profiles created to verify cards
+const verificationProfileDescription = "Card verification"
+
 // Options
 const (
 	customerIPOption = "CustomerIP" // Pass as a string pointer
 )
 
+const settingAllowPartialAuth = "allowPartialAuth"
+
 func buildAuthRequest(merchantName string, transactionKey string, authRequest *sleet.AuthorizationRequest) *Request {
 	amountStr := sleet.AmountToDecimalString(&authRequest.Amount)
 	billingAddress := authRequest.BillingAddress
 
-	creditCard := CreditCard{
-		CardNumber:     authRequest.CreditCard.Number,
-		ExpirationDate: fmt.Sprintf("%d-%d", authRequest.CreditCard.ExpirationYear, authRequest.CreditCard.ExpirationMonth),
-	}
-	if authRequest.Cryptogram != "" {
-		// Apple Pay request
-		creditCard.IsPaymentToken = common.BPtr(true)
-		creditCard.Cryptogram = authRequest.Cryptogram
-	} else {
-		// Credit Card request
-		creditCard.CardCode = authRequest.CreditCard.CVV
-	}
-
 	authorizeRequest := CreateTransactionRequest{
 		MerchantAuthentication: authentication(merchantName, transactionKey),
 	}
 
 	var transactionRequest TransactionRequest
-	if authRequest.Options[sleet.GooglePayTokenOption] != nil {
+	if authRequest.StoredPaymentMethod != nil {
+		// Customer profile request, the billing address is stored on the payment profile
+		transactionRequest = TransactionRequest{
+			TransactionType: TransactionTypeAuthOnly,
+			Amount:          &amountStr,
+			Profile: &ProfilePayment{
+				CustomerProfileID: authRequest.StoredPaymentMethod.CustomerReference,
+				PaymentProfile: &PaymentProfileReference{
+					PaymentProfileID: authRequest.StoredPaymentMethod.Token,
+				},
+			},
+		}
+		billingAddress = nil
+	} else if authRequest.Options[sleet.GooglePayTokenOption] != nil {
 		// Google Pay request
 		googlePayToken := authRequest.Options[sleet.GooglePayTokenOption].(string)
 		encodedGooglePayToken := base64.StdEncoding.EncodeToString([]byte(googlePayToken))
@@ -56,6 +63,18 @@ func buildAuthRequest(merchantName string, transactionKey string, authRequest *s
 			},
 		}
 	} else {
+		creditCard := CreditCard{
+			CardNumber:     authRequest.CreditCard.Number,
+			ExpirationDate: fmt.Sprintf("%d-%d", authRequest.CreditCard.ExpirationYear, authRequest.CreditCard.ExpirationMonth),
+		}
+		if authRequest.Cryptogram != "" {
+			// Apple Pay request
+			creditCard.IsPaymentToken = common.BPtr(true)
+			creditCard.Cryptogram = authRequest.Cryptogram
+		} else {
+			// Credit Card request
+			creditCard.CardCode = authRequest.CreditCard.CVV
+		}
 		transactionRequest = TransactionRequest{
 			TransactionType: TransactionTypeAuthOnly,
 			Amount:          &amountStr,
@@ -71,16 +90,7 @@ func buildAuthRequest(merchantName string, transactionKey string, authRequest *s
 	authorizeRequest.TransactionRequest = transactionRequest
 
 	if billingAddress != nil {
-		authorizeRequest.TransactionRequest.BillingAddress = &BillingAddress{
-			FirstName:   authRequest.CreditCard.FirstName,
-			LastName:    authRequest.CreditCard.LastName,
-			Address:     billingAddress.StreetAddress1,
-			City:        billingAddress.Locality,
-			State:       billingAddress.RegionCode,
-			Zip:         billingAddress.PostalCode,
-			Country:     billingAddress.CountryCode,
-			PhoneNumber: billingAddress.PhoneNumber,
-		}
+		authorizeRequest.TransactionRequest.BillingAddress = buildBillingAddress(billingAddress, authRequest.CreditCard)
 		authorizeRequest.TransactionRequest.Customer = &Customer{
 			Email: common.SafeStr(billingAddress.Email),
 		}
@@ -101,9 +111,21 @@ func buildAuthRequest(merchantName string, transactionKey string, authRequest *s
 		}
 	}
 
+	if authRequest.AllowPartialAuth {
+		authorizeRequest.TransactionRequest.TransactionSettings = &TransactionSettings{
+			Setting: []Setting{{SettingName: settingAllowPartialAuth, SettingValue: "true"}},
+		}
+	}
+
 	return &Request{CreateTransactionRequest: &authorizeRequest}
 }
 
+func buildSaleRequest(merchantName string, transactionKey string, saleRequest *sleet.SaleRequest) *Request {
+	request := buildAuthRequest(merchantName, transactionKey, saleRequest)
+	request.CreateTransactionRequest.TransactionRequest.TransactionType = TransactionTypeAuthCapture
+	return request
+}
+
 func buildVoidRequest(merchantName string, transactionKey string, voidRequest *sleet.VoidRequest) *Request {
 	return &Request{
 		CreateTransactionRequest: &CreateTransactionRequest{
@@ -167,6 +189,105 @@ func buildRefundRequest(merchantName string, transactionKey string, refundReques
 	return request, nil
 }
 
+// buildStorePaymentMethodRequest creates a customer profile unless the payment profile is added to an existing one
+func buildStorePaymentMethodRequest(merchantName string, transactionKey string, storeRequest *sleet.StorePaymentMethodRequest) *Request {
+	paymentProfile := CustomerPaymentProfile{
+		Payment: Payment{
+			CreditCard: &CreditCard{
+				CardNumber:     storeRequest.CreditCard.Number,
+				ExpirationDate: fmt.Sprintf("%d-%02d", storeRequest.CreditCard.ExpirationYear, storeRequest.CreditCard.ExpirationMonth),
+				CardCode:       storeRequest.CreditCard.CVV,
+			},
+		},
+	}
+	if storeRequest.BillingAddress != nil {
+		paymentProfile.BillingAddress = buildBillingAddress(storeRequest.BillingAddress, storeRequest.CreditCard)
+	}
+
+	if storeRequest.CustomerReference != "" {
+		return &Request{
+			CreateCustomerPaymentProfileRequest: &CreateCustomerPaymentProfileRequest{
+				MerchantAuthentication: authentication(merchantName, transactionKey),
+				CustomerProfileID:      storeRequest.CustomerReference,
+				PaymentProfile:         paymentProfile,
+			},
+		}
+	}
+
+	profile := CustomerProfile{
+		MerchantCustomerID: sleet.TruncateString(storeRequest.ShopperReference, MerchantCustomerIDMaxLength),
+		PaymentProfiles:    &paymentProfile,
+	}
+	if storeRequest.BillingAddress != nil {
+		profile.Email = common.SafeStr(storeRequest.BillingAddress.Email)
+	}
+	return &Request{
+		CreateCustomerProfileRequest: &CreateCustomerProfileRequest{
+			MerchantAuthentication: authentication(merchantName, transactionKey),
+			Profile:                profile,
+		},
+	}
+}
+
+// buildVerificationRequest creates a temporary customer profile for the card, validated with a zero amount authorization
+func buildVerificationRequest(merchantName string, transactionKey string, verificationRequest *sleet.VerificationRequest) *Request {
+	request := buildStorePaymentMethodRequest(merchantName, transactionKey, &sleet.StorePaymentMethodRequest{
+		CreditCard:       verificationRequest.CreditCard,
+		BillingAddress:   verificationRequest.BillingAddress,
+		ShopperReference: common.SafeStr(verificationRequest.ClientTransactionReference),
+	})
+	request.CreateCustomerProfileRequest.Profile.Description = verificationProfileDescription
+	request.CreateCustomerProfileRequest.ValidationMode = ValidationModeLive
+	return request
+}
+
+func buildDeleteCustomerProfileRequest(merchantName string, transactionKey string, customerProfileID string) *Request {
+	return &Request{
+		DeleteCustomerProfileRequest: &DeleteCustomerProfileRequest{
+			MerchantAuthentication: authentication(merchantName, transactionKey),
+			CustomerProfileID:      customerProfileID,
+		},
+	}
+}
+
+func buildGetPaymentMethodRequest(merchantName string, transactionKey string, getRequest *sleet.GetPaymentMethodRequest) *Request {
+	return &Request{
+		GetCustomerPaymentProfileRequest: &CustomerPaymentProfileRequest{
+			MerchantAuthentication:   authentication(merchantName, transactionKey),
+			CustomerProfileID:        getRequest.StoredPaymentMethod.CustomerReference,
+			CustomerPaymentProfileID: getRequest.StoredPaymentMethod.Token,
+			UnmaskExpirationDate:     common.BPtr(true),
+		},
+	}
+}
+
+func buildDeletePaymentMethodRequest(merchantName string, transactionKey string, deleteRequest *sleet.DeletePaymentMethodRequest) *Request {
+	return &Request{
+		DeleteCustomerPaymentProfileRequest: &CustomerPaymentProfileRequest{
+			MerchantAuthentication:   authentication(merchantName, transactionKey),
+			CustomerProfileID:        deleteRequest.StoredPaymentMethod.CustomerReference,
+			CustomerPaymentProfileID: deleteRequest.StoredPaymentMethod.Token,
+		},
+	}
+}
+
+// buildBillingAddress uses the card holder name, if any, for the billing address
+func buildBillingAddress(billingAddress *sleet.Address, creditCard *sleet.CreditCard) *BillingAddress {
+	address := &BillingAddress{
+		Address:     billingAddress.StreetAddress1,
+		City:        billingAddress.Locality,
+		State:       billingAddress.RegionCode,
+		Zip:         billingAddress.PostalCode,
+		Country:     billingAddress.CountryCode,
+		PhoneNumber: billingAddress.PhoneNumber,
+	}
+	if creditCard != nil {
+		address.FirstName = creditCard.FirstName
+		address.LastName = creditCard.LastName
+	}
+	return address
+}
+
 func authentication(merchantName string, transactionKey string) MerchantAuthentication {
 	return MerchantAuthentication{
 		Name:           merchantName,
@@ -206,9 +327,13 @@ func addL2L3Data(
 	}
 
 	if authRequest.ShippingAddress != nil {
+		var firstName, lastName string
+		if authRequest.CreditCard != nil {
+			firstName, lastName = authRequest.CreditCard.FirstName, authRequest.CreditCard.LastName
+		}
 		authNetAuthRequest.TransactionRequest.ShippingAddress = &ShippingAddress{
-			FirstName: authRequest.CreditCard.FirstName,
-			LastName:  authRequest.CreditCard.LastName,
+			FirstName: firstName,
+			LastName:  lastName,
 			Company:   common.SafeStr(authRequest.ShippingAddress.Company),
 			Address:   authRequest.ShippingAddress.StreetAddress1,
 			City:      authRequest.ShippingAddress.Locality,
@@ -273,3 +398,20 @@ func BuildTransactionDetailsRequest(merchantName string, transactionKey string,
 	}
 	return request, nil
 }
+
+// buildUnsettledTransactionListRequest lists the most recent unsettled transactions first
+func buildUnsettledTransactionListRequest(merchantName string, transactionKey string) *Request {
+	return &Request{
+		GetUnsettledTransactionListRequest: &GetUnsettledTransactionListRequest{
+			MerchantAuthentication: authentication(merchantName, transactionKey),
+			Sorting: &Sorting{
+				OrderBy:         "submitTimeUTC",
+				OrderDescending: true,
+			},
+			Paging: &Paging{
+				Limit:  TransactionListMaxLength,
+				Offset: 1,
+			},
+		},
+	}
+}
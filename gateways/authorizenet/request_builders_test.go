@@ -38,6 +38,9 @@ func TestBuildAuthRequest(t *testing.T) {
 	}
 	withCustomerIP.Options[customerIPOption] = customerIP
 
+	withStoredPaymentMethod := sleet_testing.BaseStoredPaymentMethodAuthorizationRequest()
+	withStoredPaymentMethod.MerchantOrderReference = randomdata.Alphanumeric(InvoiceNumberMaxLength + 5)
+
 	amount := "1.00"
 	cases := []struct {
 		label string
@@ -339,6 +342,28 @@ func TestBuildAuthRequest(t *testing.T) {
 				},
 			},
 		},
+		{
+			"Stored Payment Method Auth Request",
+			withStoredPaymentMethod,
+			&Request{
+				CreateTransactionRequest: &CreateTransactionRequest{
+					MerchantAuthentication: MerchantAuthentication{Name: "MerchantName", TransactionKey: "Key"},
+					TransactionRequest: TransactionRequest{
+						TransactionType: TransactionTypeAuthOnly,
+						Amount:          &amount,
+						Profile: &ProfilePayment{
+							CustomerProfileID: "333333",
+							PaymentProfile: &PaymentProfileReference{
+								PaymentProfileID: "444444",
+							},
+						},
+						Order: &Order{
+							InvoiceNumber: withStoredPaymentMethod.MerchantOrderReference[:InvoiceNumberMaxLength],
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for _, c := range cases {
@@ -351,6 +376,30 @@ func TestBuildAuthRequest(t *testing.T) {
 	}
 }
 
+func TestBuildSaleRequest(t *testing.T) {
+	base := sleet_testing.BaseAuthorizationRequest()
+
+	want := buildAuthRequest("MerchantName", "Key", base)
+	want.CreateTransactionRequest.TransactionRequest.TransactionType = TransactionTypeAuthCapture
+
+	got := buildSaleRequest("MerchantName", "Key", base)
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestBuildAuthRequestAllowPartialAuth(t *testing.T) {
+	base := sleet_testing.BaseAuthorizationRequest()
+	base.AllowPartialAuth = true
+
+	want := &TransactionSettings{Setting: []Setting{{SettingName: "allowPartialAuth", SettingValue: "true"}}}
+
+	got := buildAuthRequest("MerchantName", "Key", base)
+	if diff := deep.Equal(got.CreateTransactionRequest.TransactionRequest.TransactionSettings, want); diff != nil {
+		t.Error(diff)
+	}
+}
+
 func TestBuildCaptureRequest(t *testing.T) {
 	base := sleet_testing.BaseCaptureRequest()
 
@@ -484,3 +533,78 @@ func TestAuthentication(t *testing.T) {
 		t.Error(diff)
 	}
 }
+
+func TestBuildStorePaymentMethodRequest(t *testing.T) {
+	base := sleet_testing.BaseStorePaymentMethodRequest()
+	base.ShopperReference = randomdata.Alphanumeric(MerchantCustomerIDMaxLength + 5)
+
+	existingCustomer := sleet_testing.BaseStorePaymentMethodRequest()
+	existingCustomer.CustomerReference = "333333"
+	existingCustomer.BillingAddress = nil
+
+	cases := []struct {
+		label string
+		in    *sleet.StorePaymentMethodRequest
+		want  *Request
+	}{
+		{
+			"New Customer Profile",
+			base,
+			&Request{
+				CreateCustomerProfileRequest: &CreateCustomerProfileRequest{
+					MerchantAuthentication: MerchantAuthentication{Name: "MerchantName", TransactionKey: "Key"},
+					Profile: CustomerProfile{
+						MerchantCustomerID: base.ShopperReference[:MerchantCustomerIDMaxLength],
+						Email:              "test@bolt.com",
+						PaymentProfiles: &CustomerPaymentProfile{
+							BillingAddress: &BillingAddress{
+								FirstName: "Bolt",
+								LastName:  "Checkout",
+								Address:   base.BillingAddress.StreetAddress1,
+								City:      base.BillingAddress.Locality,
+								State:     base.BillingAddress.RegionCode,
+								Zip:       base.BillingAddress.PostalCode,
+								Country:   base.BillingAddress.CountryCode,
+							},
+							Payment: Payment{
+								CreditCard: &CreditCard{
+									CardNumber:     "4111111111111111",
+									ExpirationDate: "2023-10",
+									CardCode:       "737",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			"Existing Customer Profile",
+			existingCustomer,
+			&Request{
+				CreateCustomerPaymentProfileRequest: &CreateCustomerPaymentProfileRequest{
+					MerchantAuthentication: MerchantAuthentication{Name: "MerchantName", TransactionKey: "Key"},
+					CustomerProfileID:      "333333",
+					PaymentProfile: CustomerPaymentProfile{
+						Payment: Payment{
+							CreditCard: &CreditCard{
+								CardNumber:     "4111111111111111",
+								ExpirationDate: "2023-10",
+								CardCode:       "737",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			got := buildStorePaymentMethodRequest("MerchantName", "Key", c.in)
+			if diff := deep.Equal(got, c.want); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
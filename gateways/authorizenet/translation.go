@@ -1,6 +1,12 @@
 package authorizenet
 
-import "github.com/BoltApp/sleet"
+import (
+	"strings"
+	"time"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+)
 
 var cvvMap = map[CVVResultCode]sleet.CVVResponse{
 	CVVResultMatched:         sleet.CVVResponseMatch,
@@ -44,3 +50,244 @@ func translateAvs(avs AVSResultCode) sleet.AVSResponse {
 	}
 	return sleetCode
 }
+
+// Response reason codes taken from: https://developer.authorize.net/api/reference/responseCodes.html
+var resultTypeMap = map[string]sleet.ResultType{
+	"2":      sleet.ResultTypePaymentError, // declined
+	"3":      sleet.ResultTypePaymentError, // referral to voice authorization
+	"4":      sleet.ResultTypePaymentError, // card to be picked up
+	"6":      sleet.ResultTypePaymentError, // invalid card number
+	"7":      sleet.ResultTypePaymentError, // invalid expiration date
+	"8":      sleet.ResultTypePaymentError, // expired card
+	"17":     sleet.ResultTypePaymentError, // card type not accepted
+	"27":     sleet.ResultTypePaymentError, // AVS mismatch
+	"37":     sleet.ResultTypePaymentError, // invalid card number
+	"44":     sleet.ResultTypePaymentError, // card code declined
+	"45":     sleet.ResultTypePaymentError, // AVS and card code declined
+	"65":     sleet.ResultTypePaymentError, // card code mismatch
+	"78":     sleet.ResultTypePaymentError, // invalid card code
+	"250":    sleet.ResultTypePaymentError, // fraud filter
+	"251":    sleet.ResultTypePaymentError, // fraud filter
+	"254":    sleet.ResultTypePaymentError, // declined after review
+	"315":    sleet.ResultTypePaymentError, // invalid card number
+	"316":    sleet.ResultTypePaymentError, // invalid expiration date
+	"317":    sleet.ResultTypePaymentError, // expired card
+	"5":      sleet.ResultTypeAPIError,     // invalid amount
+	"11":     sleet.ResultTypeAPIError,     // duplicate transaction
+	"13":     sleet.ResultTypeAPIError,     // invalid merchant login
+	"16":     sleet.ResultTypeAPIError,     // transaction not found
+	"33":     sleet.ResultTypeAPIError,     // required field missing
+	"47":     sleet.ResultTypeAPIError,     // capture exceeds the authorized amount
+	"54":     sleet.ResultTypeAPIError,     // referenced transaction can't be refunded
+	"55":     sleet.ResultTypeAPIError,     // refunds exceed the captured amount
+	"310":    sleet.ResultTypeAPIError,     // already voided
+	"311":    sleet.ResultTypeAPIError,     // already captured
+	"E00003": sleet.ResultTypeAPIError,     // invalid request
+	"E00007": sleet.ResultTypeAPIError,     // authentication failed
+	"19":     sleet.ResultTypeServerError,  // processing error, try again
+	"20":     sleet.ResultTypeServerError,
+	"21":     sleet.ResultTypeServerError,
+	"22":     sleet.ResultTypeServerError,
+	"23":     sleet.ResultTypeServerError,
+	"25":     sleet.ResultTypeServerError,
+	"26":     sleet.ResultTypeServerError,
+	"35":     sleet.ResultTypeServerError,
+	"57":     sleet.ResultTypeServerError,
+	"120":    sleet.ResultTypeServerError, // processor timeout
+	"121":    sleet.ResultTypeServerError,
+	"122":    sleet.ResultTypeServerError,
+	"E00001": sleet.ResultTypeServerError, // unexpected system error
+}
+
+// Response reason codes of declines
+var declineReasonMap = map[string]sleet.DeclineReason{
+	"2":   sleet.DeclineReasonDoNotHonor,            // declined
+	"3":   sleet.DeclineReasonCallIssuer,            // referral to voice authorization
+	"4":   sleet.DeclineReasonRestrictedCard,        // card to be picked up
+	"6":   sleet.DeclineReasonInvalidCardNumber,     // invalid card number
+	"7":   sleet.DeclineReasonInvalidExpirationDate, // invalid expiration date
+	"8":   sleet.DeclineReasonExpiredCard,           // expired card
+	"17":  sleet.DeclineReasonTransactionNotAllowed, // card type not accepted
+	"19":  sleet.DeclineReasonTryAgainLater,         // processing error, try again
+	"20":  sleet.DeclineReasonTryAgainLater,
+	"21":  sleet.DeclineReasonTryAgainLater,
+	"22":  sleet.DeclineReasonTryAgainLater,
+	"23":  sleet.DeclineReasonTryAgainLater,
+	"25":  sleet.DeclineReasonTryAgainLater,
+	"26":  sleet.DeclineReasonTryAgainLater,
+	"27":  sleet.DeclineReasonAVSFailure,        // AVS mismatch
+	"37":  sleet.DeclineReasonInvalidCardNumber, // invalid card number
+	"44":  sleet.DeclineReasonCVVFailure,        // card code declined
+	"45":  sleet.DeclineReasonAVSFailure,        // AVS and card code declined
+	"57":  sleet.DeclineReasonTryAgainLater,
+	"65":  sleet.DeclineReasonCVVFailure,        // card code mismatch
+	"78":  sleet.DeclineReasonCVVFailure,        // invalid card code
+	"120": sleet.DeclineReasonIssuerUnavailable, // processor timeout
+	"121": sleet.DeclineReasonIssuerUnavailable,
+	"122": sleet.DeclineReasonIssuerUnavailable,
+	"250": sleet.DeclineReasonSuspectedFraud,        // fraud filter
+	"251": sleet.DeclineReasonSuspectedFraud,        // fraud filter
+	"254": sleet.DeclineReasonSuspectedFraud,        // declined after review
+	"315": sleet.DeclineReasonInvalidCardNumber,     // invalid card number
+	"316": sleet.DeclineReasonInvalidExpirationDate, // invalid expiration date
+	"317": sleet.DeclineReasonExpiredCard,           // expired card
+}
+
+// translateResultType converts the response code and error code of a failed transaction to a Sleet result type.
+// Unknown error codes are payment errors when declined and API errors otherwise.
+func translateResultType(responseCode ResponseCode, errorCode string) sleet.ResultType {
+	if resultType, ok := resultTypeMap[errorCode]; ok {
+		return resultType
+	}
+	if responseCode == ResponseCodeDeclined {
+		return sleet.ResultTypePaymentError
+	}
+	if responseCode == ResponseCodeError {
+		return sleet.ResultTypeAPIError
+	}
+	return sleet.ResultTypeUnknownError
+}
+
+var cardTypeMap = map[string]sleet.CreditCardNetwork{
+	"Visa":            sleet.CreditCardNetworkVisa,
+	"MasterCard":      sleet.CreditCardNetworkMastercard,
+	"AmericanExpress": sleet.CreditCardNetworkAmex,
+	"Discover":        sleet.CreditCardNetworkDiscover,
+	"JCB":             sleet.CreditCardNetworkJcb,
+}
+
+// translateCardType converts an Auth.net card type to its equivalent Sleet network.
+func translateCardType(cardType string) sleet.CreditCardNetwork {
+	network, ok := cardTypeMap[cardType]
+	if !ok {
+		return sleet.CreditCardNetworkUnknown
+	}
+	return network
+}
+
+var transactionStatusMap = map[TransactionStatus]sleet.TransactionState{
+	TransactionStatusAuthorizedPendingCapture:   sleet.TransactionStateAuthorized,
+	TransactionStatusFDSAuthorizedPendingReview: sleet.TransactionStateAuthorized,
+	TransactionStatusApprovedReview:             sleet.TransactionStateAuthorized,
+	TransactionStatusCapturedPendingSettlement:  sleet.TransactionStateCaptured,
+	TransactionStatusSettledSuccessfully:        sleet.TransactionStateSettled,
+	TransactionStatusRefundPendingSettlement:    sleet.TransactionStateRefunded,
+	TransactionStatusRefundSettledSuccessfully:  sleet.TransactionStateRefunded,
+	TransactionStatusVoided:                     sleet.TransactionStateVoided,
+	TransactionStatusExpired:                    sleet.TransactionStateVoided,
+	TransactionStatusDeclined:                   sleet.TransactionStateDeclined,
+	TransactionStatusFailedReview:               sleet.TransactionStateDeclined,
+}
+
+// translateTransactionStatus converts an Auth.net transaction status to its equivalent Sleet state.
+func translateTransactionStatus(status TransactionStatus) sleet.TransactionState {
+	state, ok := transactionStatusMap[status]
+	if !ok {
+		return sleet.TransactionStateUnknown
+	}
+	return state
+}
+
+// translateTransaction converts getTransactionDetails results to a Sleet transaction query response.
+// Refunds are separate transactions in Auth.net, so the settled amount of a refund is its refunded amount.
+func translateTransaction(transaction *Transaction) *sleet.TransactionQueryResponse {
+	response := &sleet.TransactionQueryResponse{
+		Success:              true,
+		TransactionReference: transaction.TransID,
+		State:                translateTransactionStatus(transaction.TransactionStatus),
+		StateRaw:             string(transaction.TransactionStatus),
+		AuthorizedAmount:     common.AmountFromFloat(transaction.AuthAmount, ""),
+	}
+	switch response.State {
+	case sleet.TransactionStateRefunded:
+		response.AuthorizedAmount = 0
+		response.RefundedAmount = common.AmountFromFloat(transaction.SettleAmount, "")
+	case sleet.TransactionStateCaptured, sleet.TransactionStateSettled:
+		response.CapturedAmount = common.AmountFromFloat(transaction.SettleAmount, "")
+	}
+	if transaction.Payment != nil && transaction.Payment.CreditCard != nil {
+		cardNumber := transaction.Payment.CreditCard.CardNumber
+		if len(cardNumber) >= 4 {
+			response.Last4 = cardNumber[len(cardNumber)-4:]
+		}
+		response.Network = translateCardType(transaction.Payment.CreditCard.CardType)
+	}
+	if submitTime, err := time.Parse(time.RFC3339, transaction.SubmitTimeUTC); err == nil {
+		response.CreatedAt = &submitTime
+	}
+	if transaction.Batch != nil {
+		if settlementTime, err := time.Parse(time.RFC3339, transaction.Batch.SettlementTimeUTC); err == nil {
+			response.UpdatedAt = &settlementTime
+		}
+	}
+	return response
+}
+
+// translateTransactionSummary converts a transaction list result to a Sleet transaction query response.
+// The settled amount of an unsettled transaction is the amount it will settle for, so it is reported by state.
+func translateTransactionSummary(summary TransactionSummary) sleet.TransactionQueryResponse {
+	response := sleet.TransactionQueryResponse{
+		Success:              true,
+		TransactionReference: summary.TransID,
+		State:                translateTransactionStatus(summary.TransactionStatus),
+		StateRaw:             string(summary.TransactionStatus),
+		Network:              translateCardType(summary.AccountType),
+	}
+	amount := common.AmountFromFloat(summary.SettleAmount, "")
+	switch response.State {
+	case sleet.TransactionStateAuthorized:
+		response.AuthorizedAmount = amount
+	case sleet.TransactionStateRefunded:
+		response.RefundedAmount = amount
+	case sleet.TransactionStateCaptured, sleet.TransactionStateSettled:
+		response.AuthorizedAmount = amount
+		response.CapturedAmount = amount
+	}
+	if len(summary.AccountNumber) >= 4 {
+		response.Last4 = summary.AccountNumber[len(summary.AccountNumber)-4:]
+	}
+	if submitTime, err := time.Parse(time.RFC3339, summary.SubmitTimeUTC); err == nil {
+		response.CreatedAt = &submitTime
+	}
+	return response
+}
+
+// Positions of the fields of a direct response, a comma delimited transaction response returned by CIM requests
+const (
+	directResponseCodeIndex       = 0
+	directResponseReasonCodeIndex = 2
+	directResponseAVSIndex        = 5
+	directResponseTransIDIndex    = 6
+	directResponseCVVIndex        = 38
+)
+
+// translateDirectResponse converts the direct response of a profile validation to an authorization response
+func translateDirectResponse(directResponse string) sleet.AuthorizationResponse {
+	fields := strings.Split(directResponse, ",")
+	field := func(index int) string {
+		if index >= len(fields) {
+			return ""
+		}
+		return fields[index]
+	}
+
+	responseCode := ResponseCode(field(directResponseCodeIndex))
+	avsResultCode := AVSResultCode(field(directResponseAVSIndex))
+	cvvResultCode := CVVResultCode(field(directResponseCVVIndex))
+	response := sleet.AuthorizationResponse{
+		Success:              responseCode == ResponseCodeApproved,
+		TransactionReference: field(directResponseTransIDIndex),
+		AvsResult:            translateAvs(avsResultCode),
+		CvvResult:            translateCvv(cvvResultCode),
+		AvsResultRaw:         string(avsResultCode),
+		CvvResultRaw:         string(cvvResultCode),
+		Response:             string(responseCode),
+	}
+	response.ResultType = sleet.ResultTypeSuccess
+	if !response.Success {
+		response.ErrorCode = field(directResponseReasonCodeIndex)
+		response.ResultType = translateResultType(responseCode, response.ErrorCode)
+		response.DeclineReason = common.TranslateDeclineReason(declineReasonMap, response.ErrorCode, response.ResultType)
+	}
+	return response
+}
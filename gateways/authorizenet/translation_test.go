@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
 )
 
 func TestTranslateCvv(t *testing.T) {
@@ -62,3 +63,60 @@ func TestTranslateAvs(t *testing.T) {
 		})
 	}
 }
+
+func TestTranslateResultType(t *testing.T) {
+	cases := []struct {
+		responseCode ResponseCode
+		errorCode    string
+		want         sleet.ResultType
+	}{
+		{ResponseCodeDeclined, "2", sleet.ResultTypePaymentError},
+		{ResponseCodeDeclined, "8", sleet.ResultTypePaymentError},
+		{ResponseCodeDeclined, "65", sleet.ResultTypePaymentError},
+		{ResponseCodeDeclined, "999", sleet.ResultTypePaymentError},
+		{ResponseCodeError, "6", sleet.ResultTypePaymentError},
+		{ResponseCodeError, "11", sleet.ResultTypeAPIError},
+		{ResponseCodeError, "33", sleet.ResultTypeAPIError},
+		{ResponseCodeError, "310", sleet.ResultTypeAPIError},
+		{ResponseCodeError, "999", sleet.ResultTypeAPIError},
+		{ResponseCodeError, "19", sleet.ResultTypeServerError},
+		{ResponseCodeError, "120", sleet.ResultTypeServerError},
+		{"", "E00007", sleet.ResultTypeAPIError},
+		{"", "E00001", sleet.ResultTypeServerError},
+		{"", "", sleet.ResultTypeUnknownError},
+	}
+	for _, c := range cases {
+		t.Run(string(c.responseCode)+" "+c.errorCode, func(t *testing.T) {
+			if got := translateResultType(c.responseCode, c.errorCode); got != c.want {
+				t.Errorf("expected %s, got %s", c.want, got)
+			}
+		})
+	}
+}
+
+func TestDeclineReasonMap(t *testing.T) {
+	cases := []struct {
+		responseCode ResponseCode
+		errorCode    string
+		want         sleet.DeclineReason
+	}{
+		{ResponseCodeDeclined, "2", sleet.DeclineReasonDoNotHonor},
+		{ResponseCodeDeclined, "8", sleet.DeclineReasonExpiredCard},
+		{ResponseCodeDeclined, "27", sleet.DeclineReasonAVSFailure},
+		{ResponseCodeDeclined, "65", sleet.DeclineReasonCVVFailure},
+		{ResponseCodeDeclined, "251", sleet.DeclineReasonSuspectedFraud},
+		{ResponseCodeDeclined, "999", sleet.DeclineReasonUnknown},
+		{ResponseCodeError, "6", sleet.DeclineReasonInvalidCardNumber},
+		{ResponseCodeError, "120", sleet.DeclineReasonIssuerUnavailable},
+		{ResponseCodeError, "11", ""},
+		{"", "E00007", ""},
+	}
+	for _, c := range cases {
+		t.Run(string(c.responseCode)+" "+c.errorCode, func(t *testing.T) {
+			resultType := translateResultType(c.responseCode, c.errorCode)
+			if got := common.TranslateDeclineReason(declineReasonMap, c.errorCode, resultType); got != c.want {
+				t.Errorf("expected %q, got %q", c.want, got)
+			}
+		})
+	}
+}
@@ -92,8 +92,14 @@ const expirationDateXXXX = "XXXX"
 
 // Request contains a createTransactionRequest for authorizations
 type Request struct {
-	CreateTransactionRequest     *CreateTransactionRequest     `json:"createTransactionRequest,omitempty"`
-	GetTransactionDetailsRequest *GetTransactionDetailsRequest `json:"getTransactionDetailsRequest,omitempty"`
+	CreateTransactionRequest            *CreateTransactionRequest            `json:"createTransactionRequest,omitempty"`
+	GetTransactionDetailsRequest        *GetTransactionDetailsRequest        `json:"getTransactionDetailsRequest,omitempty"`
+	CreateCustomerProfileRequest        *CreateCustomerProfileRequest        `json:"createCustomerProfileRequest,omitempty"`
+	CreateCustomerPaymentProfileRequest *CreateCustomerPaymentProfileRequest `json:"createCustomerPaymentProfileRequest,omitempty"`
+	GetCustomerPaymentProfileRequest    *CustomerPaymentProfileRequest       `json:"getCustomerPaymentProfileRequest,omitempty"`
+	DeleteCustomerPaymentProfileRequest *CustomerPaymentProfileRequest       `json:"deleteCustomerPaymentProfileRequest,omitempty"`
+	DeleteCustomerProfileRequest        *DeleteCustomerProfileRequest        `json:"deleteCustomerProfileRequest,omitempty"`
+	GetUnsettledTransactionListRequest  *GetUnsettledTransactionListRequest  `json:"getUnsettledTransactionListRequest,omitempty"`
 }
 
 // GetTransactionDetailsRequest contains a transaction ID for fetching transaction details
@@ -102,6 +108,25 @@ type GetTransactionDetailsRequest struct {
 	TransID                string                 `json:"transId,omitempty"`
 }
 
+// GetUnsettledTransactionListRequest lists transactions which have not been settled yet
+type GetUnsettledTransactionListRequest struct {
+	MerchantAuthentication MerchantAuthentication `json:"merchantAuthentication"`
+	Sorting                *Sorting               `json:"sorting,omitempty"`
+	Paging                 *Paging                `json:"paging,omitempty"`
+}
+
+// Sorting orders the results of a transaction list request
+type Sorting struct {
+	OrderBy         string `json:"orderBy"`
+	OrderDescending bool   `json:"orderDescending"`
+}
+
+// Paging limits the results of a transaction list request, Offset starts at 1
+type Paging struct {
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
 // CreateTransactionRequest specifies the merchant authentication to be used for request as well as transaction
 // details specified in transactionRequest
 type CreateTransactionRequest struct {
@@ -128,17 +153,30 @@ type TransactionRequest struct {
 	TransactionType  TransactionType `json:"transactionType"`
 	Amount           *string         `json:"amount,omitempty"`
 	Payment          *Payment        `json:"payment,omitempty"`
+	Profile          *ProfilePayment `json:"profile,omitempty"`
 	RefTransactionID *string         `json:"refTransId,omitempty"`
 	Order            *Order          `json:"order,omitempty"`
 	LineItem         json.RawMessage `json:"lineItems,omitempty"` // this is really a repeating LineItem, but authorize.net expects it in object not array
 	// since not valid json, just going to represent as JSON string
-	Tax             *Tax             `json:"tax,omitempty"`
-	Duty            *Tax             `json:"duty,omitempty"`
-	Shipping        *Tax             `json:"shipping,omitempty"`
-	Customer        *Customer        `json:"customer,omitempty"`
-	BillingAddress  *BillingAddress  `json:"billTo,omitempty"`
-	ShippingAddress *ShippingAddress `json:"shipTo,omitempty"`
-	CustomerIP      *string          `json:"customerIP,omitempty"`
+	Tax                 *Tax                 `json:"tax,omitempty"`
+	Duty                *Tax                 `json:"duty,omitempty"`
+	Shipping            *Tax                 `json:"shipping,omitempty"`
+	Customer            *Customer            `json:"customer,omitempty"`
+	BillingAddress      *BillingAddress      `json:"billTo,omitempty"`
+	ShippingAddress     *ShippingAddress     `json:"shipTo,omitempty"`
+	CustomerIP          *string              `json:"customerIP,omitempty"`
+	TransactionSettings *TransactionSettings `json:"transactionSettings,omitempty"`
+}
+
+// TransactionSettings overrides the merchant's default settings for a single transaction
+type TransactionSettings struct {
+	Setting []Setting `json:"setting"`
+}
+
+// Setting is a single transaction setting such as allowPartialAuth
+type Setting struct {
+	SettingName  string `json:"settingName"`
+	SettingValue string `json:"settingValue"`
 }
 
 type LineItem struct {
@@ -182,6 +220,70 @@ type CreditCard struct {
 	CardCode       string `json:"cardCode,omitempty"`
 	IsPaymentToken *bool  `json:"isPaymentToken,omitempty"`
 	Cryptogram     string `json:"cryptogram,omitempty"`
+	CardType       string `json:"cardType,omitempty"` // only set in responses
+}
+
+// ProfilePayment is used in TransactionRequest to charge a stored customer payment profile instead of Payment
+type ProfilePayment struct {
+	CustomerProfileID string                   `json:"customerProfileId"`
+	PaymentProfile    *PaymentProfileReference `json:"paymentProfile"`
+}
+
+// PaymentProfileReference references a stored customer payment profile
+type PaymentProfileReference struct {
+	PaymentProfileID string `json:"paymentProfileId"`
+}
+
+// CreateCustomerProfileRequest creates a customer profile along with its first payment profile
+type CreateCustomerProfileRequest struct {
+	MerchantAuthentication MerchantAuthentication `json:"merchantAuthentication"`
+	Profile                CustomerProfile        `json:"profile"`
+	ValidationMode         ValidationMode         `json:"validationMode,omitempty"`
+}
+
+// ValidationMode selects how the payment profile is validated when it is created
+type ValidationMode string
+
+const (
+	// ValidationModeLive runs a zero amount authorization, voided by Auth.net, against the card
+	ValidationModeLive ValidationMode = "liveMode"
+)
+
+// DeleteCustomerProfileRequest deletes a customer profile along with its payment profiles
+type DeleteCustomerProfileRequest struct {
+	MerchantAuthentication MerchantAuthentication `json:"merchantAuthentication"`
+	CustomerProfileID      string                 `json:"customerProfileId"`
+}
+
+// CustomerProfile requires at least one of MerchantCustomerID, Description or Email
+type CustomerProfile struct {
+	MerchantCustomerID string                  `json:"merchantCustomerId,omitempty"`
+	Description        string                  `json:"description,omitempty"`
+	Email              string                  `json:"email,omitempty"`
+	PaymentProfiles    *CustomerPaymentProfile `json:"paymentProfiles,omitempty"`
+}
+
+// CreateCustomerPaymentProfileRequest adds a payment profile to an existing customer profile
+type CreateCustomerPaymentProfileRequest struct {
+	MerchantAuthentication MerchantAuthentication `json:"merchantAuthentication"`
+	CustomerProfileID      string                 `json:"customerProfileId"`
+	PaymentProfile         CustomerPaymentProfile `json:"paymentProfile"`
+}
+
+// CustomerPaymentProfile is a stored credit card and its billing address
+type CustomerPaymentProfile struct {
+	CustomerProfileID        string          `json:"customerProfileId,omitempty"`        // only set in responses
+	CustomerPaymentProfileID string          `json:"customerPaymentProfileId,omitempty"` // only set in responses
+	BillingAddress           *BillingAddress `json:"billTo,omitempty"`
+	Payment                  Payment         `json:"payment"`
+}
+
+// CustomerPaymentProfileRequest references a payment profile to get or delete
+type CustomerPaymentProfileRequest struct {
+	MerchantAuthentication   MerchantAuthentication `json:"merchantAuthentication"`
+	CustomerProfileID        string                 `json:"customerProfileId"`
+	CustomerPaymentProfileID string                 `json:"customerPaymentProfileId"`
+	UnmaskExpirationDate     *bool                  `json:"unmaskExpirationDate,omitempty"`
 }
 
 // ShippingAddress is used in TransactionRequest for making an auth call
@@ -216,18 +318,73 @@ type Order struct {
 
 // Response is a generic Auth.net response
 type Response struct {
-	TransactionResponse TransactionResponse `json:"transactionResponse"`
-	Transaction         *Transaction        `json:"transaction,omitempty"`
-	RefID               string              `json:"refId"`
-	Messsages           Messages            `json:"messages"`
+	TransactionResponse          TransactionResponse     `json:"transactionResponse"`
+	Transaction                  *Transaction            `json:"transaction,omitempty"`
+	RefID                        string                  `json:"refId"`
+	Messsages                    Messages                `json:"messages"`
+	CustomerProfileID            string                  `json:"customerProfileId,omitempty"`
+	CustomerPaymentProfileID     string                  `json:"customerPaymentProfileId,omitempty"`
+	CustomerPaymentProfileIDList []string                `json:"customerPaymentProfileIdList,omitempty"`
+	PaymentProfile               *CustomerPaymentProfile `json:"paymentProfile,omitempty"`
+	Transactions                 []TransactionSummary    `json:"transactions,omitempty"`
+	ValidationDirectResponseList []string                `json:"validationDirectResponseList,omitempty"`
 }
 
 // Transaction describes the transaction details
 type Transaction struct {
-	TransID string   `json:"transId,omitempty"`
-	Payment *Payment `json:"payment,omitempty"`
+	TransID           string            `json:"transId,omitempty"`
+	SubmitTimeUTC     string            `json:"submitTimeUTC,omitempty"`
+	TransactionType   string            `json:"transactionType,omitempty"`
+	TransactionStatus TransactionStatus `json:"transactionStatus,omitempty"`
+	AuthAmount        float64           `json:"authAmount,omitempty"`
+	SettleAmount      float64           `json:"settleAmount,omitempty"`
+	Batch             *Batch            `json:"batch,omitempty"`
+	Payment           *Payment          `json:"payment,omitempty"`
+}
+
+// TransactionSummary describes a transaction returned by the transaction list requests
+type TransactionSummary struct {
+	TransID           string            `json:"transId"`
+	SubmitTimeUTC     string            `json:"submitTimeUTC"`
+	TransactionStatus TransactionStatus `json:"transactionStatus"`
+	InvoiceNumber     string            `json:"invoiceNumber"`
+	AccountType       string            `json:"accountType"`
+	AccountNumber     string            `json:"accountNumber"`
+	SettleAmount      float64           `json:"settleAmount"`
 }
 
+// Batch describes the settlement batch of a transaction
+type Batch struct {
+	BatchID           string `json:"batchId"`
+	SettlementTimeUTC string `json:"settlementTimeUTC"`
+	SettlementState   string `json:"settlementState"`
+}
+
+// TransactionStatus is the status of a transaction returned by getTransactionDetails
+type TransactionStatus string
+
+// Transaction statuses returned by getTransactionDetails
+const (
+	TransactionStatusAuthorizedPendingCapture   TransactionStatus = "authorizedPendingCapture"
+	TransactionStatusCapturedPendingSettlement  TransactionStatus = "capturedPendingSettlement"
+	TransactionStatusCommunicationError         TransactionStatus = "communicationError"
+	TransactionStatusRefundSettledSuccessfully  TransactionStatus = "refundSettledSuccessfully"
+	TransactionStatusRefundPendingSettlement    TransactionStatus = "refundPendingSettlement"
+	TransactionStatusApprovedReview             TransactionStatus = "approvedReview"
+	TransactionStatusDeclined                   TransactionStatus = "declined"
+	TransactionStatusCouldNotVoid               TransactionStatus = "couldNotVoid"
+	TransactionStatusExpired                    TransactionStatus = "expired"
+	TransactionStatusGeneralError               TransactionStatus = "generalError"
+	TransactionStatusFailedReview               TransactionStatus = "failedReview"
+	TransactionStatusSettledSuccessfully        TransactionStatus = "settledSuccessfully"
+	TransactionStatusSettlementError            TransactionStatus = "settlementError"
+	TransactionStatusUnderReview                TransactionStatus = "underReview"
+	TransactionStatusVoided                     TransactionStatus = "voided"
+	TransactionStatusFDSPendingReview           TransactionStatus = "FDSPendingReview"
+	TransactionStatusFDSAuthorizedPendingReview TransactionStatus = "FDSAuthorizedPendingReview"
+	TransactionStatusReturnedItem               TransactionStatus = "returnedItem"
+)
+
 // TransactionResponse contains the information from issuer about AVS, CVV and whether or not authorization was successful
 type TransactionResponse struct {
 	ResponseCode   ResponseCode                 `json:"responseCode"`
@@ -240,10 +397,20 @@ type TransactionResponse struct {
 	TransHash      string                       `json:"transHash"`
 	AccountNumber  string                       `json:"accountNumber"`
 	AccountType    string                       `json:"accountType"`
+	SplitTenderID  string                       `json:"splitTenderId"`
+	PrePaidCard    *PrePaidCard                 `json:"prePaidCard"`
 	Messages       []TransactionResponseMessage `json:"messages"`
 	Errors         []Error                      `json:"errors"`
 }
 
+// PrePaidCard is returned for prepaid cards with the amount approved, which is less than the requested amount
+// on a partial authorization
+type PrePaidCard struct {
+	RequestedAmount string `json:"requestedAmount"`
+	ApprovedAmount  string `json:"approvedAmount"`
+	BalanceOnCard   string `json:"balanceOnCard"`
+}
+
 // MessageResponseCode message API response codes specific to AuthorizeNet
 type MessageResponseCode string
 
@@ -270,3 +437,36 @@ type Message struct {
 	Code string `json:"code"`
 	Text string `json:"text"`
 }
+
+// WebhookEventType is the type of an Auth.net webhook notification
+// See https://developer.authorize.net/api/reference/features/webhooks.html#Event_Types_and_Payloads
+type WebhookEventType string
+
+const (
+	WebhookEventAuthorizationCreated    WebhookEventType = "net.authorize.payment.authorization.created"
+	WebhookEventAuthCaptureCreated      WebhookEventType = "net.authorize.payment.authcapture.created"
+	WebhookEventCaptureCreated          WebhookEventType = "net.authorize.payment.capture.created"
+	WebhookEventPriorAuthCaptureCreated WebhookEventType = "net.authorize.payment.priorAuthCapture.created"
+	WebhookEventRefundCreated           WebhookEventType = "net.authorize.payment.refund.created"
+	WebhookEventVoidCreated             WebhookEventType = "net.authorize.payment.void.created"
+)
+
+// WebhookNotification is the body Auth.net posts to a webhook endpoint
+type WebhookNotification struct {
+	NotificationID string           `json:"notificationId"`
+	EventType      WebhookEventType `json:"eventType"`
+	EventDate      string           `json:"eventDate"`
+	WebhookID      string           `json:"webhookId"`
+	Payload        WebhookPayload   `json:"payload"`
+}
+
+// WebhookPayload describes the transaction a payment webhook notification is about
+type WebhookPayload struct {
+	ResponseCode  int     `json:"responseCode"`
+	AuthCode      string  `json:"authCode"`
+	AVSResponse   string  `json:"avsResponse"`
+	AuthAmount    float64 `json:"authAmount"`
+	InvoiceNumber string  `json:"invoiceNumber"`
+	EntityName    string  `json:"entityName"`
+	ID            string  `json:"id"`
+}
@@ -0,0 +1,114 @@
+package authorizenet
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+)
+
+var (
+	// assert vault interface
+	_ sleet.VaultWithContext = &AuthorizeNetClient{}
+)
+
+// StorePaymentMethod stores a credit card as a CIM customer payment profile.
+// A customer profile is created unless CustomerReference references an existing one.
+func (client *AuthorizeNetClient) StorePaymentMethod(request *sleet.StorePaymentMethodRequest) (*sleet.StorePaymentMethodResponse, error) {
+	return client.StorePaymentMethodWithContext(context.TODO(), request)
+}
+
+// StorePaymentMethodWithContext stores a credit card as a CIM customer payment profile.
+// A customer profile is created unless CustomerReference references an existing one.
+func (client *AuthorizeNetClient) StorePaymentMethodWithContext(ctx context.Context, request *sleet.StorePaymentMethodRequest) (*sleet.StorePaymentMethodResponse, error) {
+	authorizeNetStoreRequest := buildStorePaymentMethodRequest(client.merchantName, client.transactionKey, request)
+	authorizeNetResponse, _, err := client.sendRequest(ctx, *authorizeNetStoreRequest)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationStorePaymentMethod, err)
+	}
+
+	if authorizeNetResponse.Messsages.ResultCode != ResultCodeOK {
+		errorCode := getMessagesErrorCode(authorizeNetResponse.Messsages)
+		return &sleet.StorePaymentMethodResponse{ErrorCode: &errorCode}, nil
+	}
+
+	paymentProfileID := authorizeNetResponse.CustomerPaymentProfileID
+	if len(authorizeNetResponse.CustomerPaymentProfileIDList) > 0 {
+		paymentProfileID = authorizeNetResponse.CustomerPaymentProfileIDList[0]
+	}
+	customerProfileID := authorizeNetResponse.CustomerProfileID
+	if customerProfileID == "" {
+		customerProfileID = request.CustomerReference
+	}
+	return &sleet.StorePaymentMethodResponse{
+		Success: true,
+		StoredPaymentMethod: &sleet.StoredPaymentMethod{
+			CustomerReference: customerProfileID,
+			Token:             paymentProfileID,
+		},
+	}, nil
+}
+
+// GetPaymentMethod retrieves a CIM customer payment profile
+func (client *AuthorizeNetClient) GetPaymentMethod(request *sleet.GetPaymentMethodRequest) (*sleet.GetPaymentMethodResponse, error) {
+	return client.GetPaymentMethodWithContext(context.TODO(), request)
+}
+
+// GetPaymentMethodWithContext retrieves a CIM customer payment profile
+func (client *AuthorizeNetClient) GetPaymentMethodWithContext(ctx context.Context, request *sleet.GetPaymentMethodRequest) (*sleet.GetPaymentMethodResponse, error) {
+	authorizeNetGetRequest := buildGetPaymentMethodRequest(client.merchantName, client.transactionKey, request)
+	authorizeNetResponse, _, err := client.sendRequest(ctx, *authorizeNetGetRequest)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationGetPaymentMethod, err)
+	}
+
+	if authorizeNetResponse.Messsages.ResultCode != ResultCodeOK {
+		errorCode := getMessagesErrorCode(authorizeNetResponse.Messsages)
+		return &sleet.GetPaymentMethodResponse{ErrorCode: &errorCode}, nil
+	}
+
+	response := &sleet.GetPaymentMethodResponse{
+		Success: true,
+		StoredPaymentMethod: &sleet.StoredPaymentMethod{
+			CustomerReference: request.StoredPaymentMethod.CustomerReference,
+			Token:             request.StoredPaymentMethod.Token,
+		},
+	}
+	if authorizeNetResponse.PaymentProfile != nil && authorizeNetResponse.PaymentProfile.Payment.CreditCard != nil {
+		creditCard := authorizeNetResponse.PaymentProfile.Payment.CreditCard
+		// card numbers are masked as XXXX1111 and unmasked expiration dates formatted as YYYY-MM
+		response.Last4 = strings.TrimLeft(creditCard.CardNumber, "X")
+		fmt.Sscanf(creditCard.ExpirationDate, "%d-%d", &response.ExpirationYear, &response.ExpirationMonth)
+		response.Network = translateCardType(creditCard.CardType)
+	}
+	return response, nil
+}
+
+// DeletePaymentMethod deletes a CIM customer payment profile, the customer profile is kept
+func (client *AuthorizeNetClient) DeletePaymentMethod(request *sleet.DeletePaymentMethodRequest) (*sleet.DeletePaymentMethodResponse, error) {
+	return client.DeletePaymentMethodWithContext(context.TODO(), request)
+}
+
+// DeletePaymentMethodWithContext deletes a CIM customer payment profile, the customer profile is kept
+func (client *AuthorizeNetClient) DeletePaymentMethodWithContext(ctx context.Context, request *sleet.DeletePaymentMethodRequest) (*sleet.DeletePaymentMethodResponse, error) {
+	authorizeNetDeleteRequest := buildDeletePaymentMethodRequest(client.merchantName, client.transactionKey, request)
+	authorizeNetResponse, _, err := client.sendRequest(ctx, *authorizeNetDeleteRequest)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationDeletePaymentMethod, err)
+	}
+
+	if authorizeNetResponse.Messsages.ResultCode != ResultCodeOK {
+		errorCode := getMessagesErrorCode(authorizeNetResponse.Messsages)
+		return &sleet.DeletePaymentMethodResponse{ErrorCode: &errorCode}, nil
+	}
+	return &sleet.DeletePaymentMethodResponse{Success: true}, nil
+}
+
+func getMessagesErrorCode(messages Messages) string {
+	if len(messages.Message) > 0 {
+		return messages.Message[0].Code
+	}
+	return string(messages.ResultCode)
+}
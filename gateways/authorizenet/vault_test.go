@@ -0,0 +1,119 @@
+//go:build unit
+// +build unit
+
+package authorizenet
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/jarcoal/httpmock"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+	sleet_t "github.com/BoltApp/sleet/testing"
+)
+
+func TestStorePaymentMethod(t *testing.T) {
+	helper := sleet_t.NewTestHelper(t)
+	url := "https://apitest.authorize.net/xml/v1/request.api"
+	request := sleet_t.BaseStorePaymentMethodRequest()
+
+	t.Run("With Successful Response", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		httpmock.RegisterResponder("POST", url, func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewBytesResponse(http.StatusOK, helper.ReadFile("test_data/createCustomerProfileResponse.json")), nil
+		})
+
+		want := &sleet.StorePaymentMethodResponse{
+			Success: true,
+			StoredPaymentMethod: &sleet.StoredPaymentMethod{
+				CustomerReference: "190178",
+				Token:             "157497",
+			},
+		}
+
+		client := NewClient("MerchantName", "Key", common.Sandbox)
+		got, err := client.StorePaymentMethod(request)
+		if err != nil {
+			t.Fatalf("Error thrown after sending request %q", err)
+		}
+		if diff := deep.Equal(got, want); diff != nil {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("With Error Response", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		httpmock.RegisterResponder("POST", url, func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewBytesResponse(http.StatusOK, helper.ReadFile("test_data/createCustomerProfileErrorResponse.json")), nil
+		})
+
+		want := &sleet.StorePaymentMethodResponse{ErrorCode: common.SPtr("E00039")}
+
+		client := NewClient("MerchantName", "Key", common.Sandbox)
+		got, err := client.StorePaymentMethod(request)
+		if err != nil {
+			t.Fatalf("Error thrown after sending request %q", err)
+		}
+		if diff := deep.Equal(got, want); diff != nil {
+			t.Error(diff)
+		}
+	})
+}
+
+func TestGetPaymentMethod(t *testing.T) {
+	helper := sleet_t.NewTestHelper(t)
+	url := "https://apitest.authorize.net/xml/v1/request.api"
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("POST", url, func(req *http.Request) (*http.Response, error) {
+		return httpmock.NewBytesResponse(http.StatusOK, helper.ReadFile("test_data/getCustomerPaymentProfileResponse.json")), nil
+	})
+
+	want := &sleet.GetPaymentMethodResponse{
+		Success:             true,
+		StoredPaymentMethod: sleet_t.BaseStoredPaymentMethod(),
+		Last4:               "1111",
+		ExpirationMonth:     10,
+		ExpirationYear:      2023,
+		Network:             sleet.CreditCardNetworkVisa,
+	}
+
+	client := NewClient("MerchantName", "Key", common.Sandbox)
+	got, err := client.GetPaymentMethod(&sleet.GetPaymentMethodRequest{StoredPaymentMethod: *sleet_t.BaseStoredPaymentMethod()})
+	if err != nil {
+		t.Fatalf("Error thrown after sending request %q", err)
+	}
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestDeletePaymentMethod(t *testing.T) {
+	helper := sleet_t.NewTestHelper(t)
+	url := "https://apitest.authorize.net/xml/v1/request.api"
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("POST", url, func(req *http.Request) (*http.Response, error) {
+		return httpmock.NewBytesResponse(http.StatusOK, helper.ReadFile("test_data/deleteCustomerPaymentProfileResponse.json")), nil
+	})
+
+	client := NewClient("MerchantName", "Key", common.Sandbox)
+	got, err := client.DeletePaymentMethod(&sleet.DeletePaymentMethodRequest{StoredPaymentMethod: *sleet_t.BaseStoredPaymentMethod()})
+	if err != nil {
+		t.Fatalf("Error thrown after sending request %q", err)
+	}
+	if diff := deep.Equal(got, &sleet.DeletePaymentMethodResponse{Success: true}); diff != nil {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,74 @@
+package authorizenet
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+)
+
+var (
+	// assert verifier interface
+	_ sleet.VerifierWithContext = &AuthorizeNetClient{}
+)
+
+// Verify validates a credit card by creating a temporary CIM customer profile in live validation mode
+func (client *AuthorizeNetClient) Verify(request *sleet.VerificationRequest) (*sleet.VerificationResponse, error) {
+	return client.VerifyWithContext(context.TODO(), request)
+}
+
+// VerifyWithContext validates a credit card by creating a temporary CIM customer profile in live validation mode.
+// Auth.net runs and voids a zero amount authorization against the card, then the profile is deleted.
+// Payment methods other than credit cards are verified with an authorization and void.
+func (client *AuthorizeNetClient) VerifyWithContext(ctx context.Context, request *sleet.VerificationRequest) (*sleet.VerificationResponse, error) {
+	if request.CreditCard == nil {
+		response, err := sleet.VerifyByAuthorization(ctx, client, request)
+		return response, common.OperationError(gatewayName, sleet.OperationVerify, err)
+	}
+
+	authorizeNetVerificationRequest := buildVerificationRequest(client.merchantName, client.transactionKey, request)
+	authorizeNetResponse, httpResp, err := client.sendRequest(ctx, *authorizeNetVerificationRequest)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationVerify, err)
+	}
+	responseHeader := sleet.GetHTTPResponseHeader(request.Options, *httpResp)
+
+	// the profile is not created if the card fails validation, but the validation results are still returned
+	if len(authorizeNetResponse.ValidationDirectResponseList) == 0 {
+		errorCode := getMessagesErrorCode(authorizeNetResponse.Messsages)
+		resultType := translateResultType("", errorCode)
+		return &sleet.VerificationResponse{AuthorizationResponse: sleet.AuthorizationResponse{
+			ErrorCode:     errorCode,
+			ResultType:    resultType,
+			DeclineReason: common.TranslateDeclineReason(declineReasonMap, errorCode, resultType),
+			StatusCode:    httpResp.StatusCode,
+			Header:        responseHeader,
+		}}, nil
+	}
+	response := &sleet.VerificationResponse{
+		AuthorizationResponse: translateDirectResponse(authorizeNetResponse.ValidationDirectResponseList[0]),
+	}
+	response.StatusCode = httpResp.StatusCode
+	response.Header = responseHeader
+
+	if authorizeNetResponse.CustomerProfileID == "" {
+		return response, nil
+	}
+	deleteRequest := buildDeleteCustomerProfileRequest(client.merchantName, client.transactionKey, authorizeNetResponse.CustomerProfileID)
+	deleteResponse, _, err := client.sendRequest(ctx, *deleteRequest)
+	if err != nil {
+		return response, common.OperationError(gatewayName, sleet.OperationVerify, err)
+	}
+	if deleteResponse.Messsages.ResultCode != ResultCodeOK {
+		return response, common.OperationError(gatewayName, sleet.OperationVerify, &sleet.Error{
+			Kind: sleet.ErrorKindPSPAPI,
+			Err: fmt.Errorf(
+				"verification customer profile %s was not deleted, error code %s",
+				authorizeNetResponse.CustomerProfileID,
+				getMessagesErrorCode(deleteResponse.Messsages),
+			),
+		})
+	}
+	return response, nil
+}
@@ -0,0 +1,95 @@
+//go:build unit
+// +build unit
+
+package authorizenet
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/jarcoal/httpmock"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+	sleet_t "github.com/BoltApp/sleet/testing"
+)
+
+func TestVerify(t *testing.T) {
+	helper := sleet_t.NewTestHelper(t)
+	url := "https://apitest.authorize.net/xml/v1/request.api"
+	request := sleet_t.BaseAuthorizationRequest()
+
+	t.Run("With Verified Card", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		var deleted bool
+		httpmock.RegisterResponder("POST", url, func(req *http.Request) (*http.Response, error) {
+			body, _ := ioutil.ReadAll(req.Body)
+			if strings.Contains(string(body), "deleteCustomerProfileRequest") {
+				deleted = true
+				return httpmock.NewBytesResponse(http.StatusOK, helper.ReadFile("test_data/deleteCustomerPaymentProfileResponse.json")), nil
+			}
+			return httpmock.NewBytesResponse(http.StatusOK, helper.ReadFile("test_data/verificationResponse.json")), nil
+		})
+
+		want := &sleet.VerificationResponse{AuthorizationResponse: sleet.AuthorizationResponse{
+			Success:              true,
+			TransactionReference: "40000002153",
+			AvsResult:            sleet.AVSResponseMatch,
+			CvvResult:            sleet.CVVResponseMatch,
+			Response:             "1",
+			AvsResultRaw:         "Y",
+			CvvResultRaw:         "M",
+			StatusCode:           http.StatusOK,
+			ResultType:           sleet.ResultTypeSuccess,
+		}}
+
+		client := NewClient("MerchantName", "Key", common.Sandbox)
+		got, err := client.Verify(request)
+		if err != nil {
+			t.Fatalf("Error thrown after sending request %q", err)
+		}
+		if diff := deep.Equal(got, want); diff != nil {
+			t.Error(diff)
+		}
+		if !deleted {
+			t.Error("expected the verification customer profile to be deleted")
+		}
+	})
+
+	t.Run("With Declined Card", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		httpmock.RegisterResponder("POST", url, func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewBytesResponse(http.StatusOK, helper.ReadFile("test_data/verificationDeclineResponse.json")), nil
+		})
+
+		want := &sleet.VerificationResponse{AuthorizationResponse: sleet.AuthorizationResponse{
+			Success:              false,
+			TransactionReference: "0",
+			AvsResult:            sleet.AVSResponseNoMatch,
+			CvvResult:            sleet.CVVResponseNoMatch,
+			Response:             "2",
+			AvsResultRaw:         "N",
+			CvvResultRaw:         "N",
+			ErrorCode:            "2",
+			StatusCode:           http.StatusOK,
+			ResultType:           sleet.ResultTypePaymentError,
+			DeclineReason:        sleet.DeclineReasonDoNotHonor,
+		}}
+
+		client := NewClient("MerchantName", "Key", common.Sandbox)
+		got, err := client.Verify(request)
+		if err != nil {
+			t.Fatalf("Error thrown after sending request %q", err)
+		}
+		if diff := deep.Equal(got, want); diff != nil {
+			t.Error(diff)
+		}
+	})
+}
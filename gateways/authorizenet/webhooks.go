@@ -0,0 +1,100 @@
+package authorizenet
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/webhooks"
+)
+
+const (
+	signatureHeader = "X-ANET-Signature"
+	signaturePrefix = "sha512="
+)
+
+var (
+	// assert parser interface
+	_ webhooks.Parser = &WebhookParser{}
+)
+
+// WebhookParser verifies the X-ANET-Signature header of Auth.net webhooks and translates payment events.
+// Auth.net notifications do not carry the currency, so event amounts are in minor units with an empty currency.
+type WebhookParser struct {
+	signatureKey string
+}
+
+// NewWebhookParser creates a parser using the Signature Key generated in the merchant interface
+func NewWebhookParser(signatureKey string) *WebhookParser {
+	return &WebhookParser{signatureKey: signatureKey}
+}
+
+// Parse verifies the HMAC-SHA512 signature of the body and translates it into a webhook event
+func (p *WebhookParser) Parse(header http.Header, body []byte) ([]webhooks.WebhookEvent, error) {
+	signature := header.Get(signatureHeader)
+	if signature == "" {
+		return nil, webhooks.ErrMissingSignature
+	}
+	// Auth.net sends the prefix and an upper case hex digest, neither is guaranteed so both are case insensitive
+	if len(signature) < len(signaturePrefix) || !strings.EqualFold(signature[:len(signaturePrefix)], signaturePrefix) {
+		return nil, webhooks.ErrInvalidSignature
+	}
+	received, err := hex.DecodeString(signature[len(signaturePrefix):])
+	if err != nil {
+		return nil, webhooks.ErrInvalidSignature
+	}
+	mac := hmac.New(sha512.New, []byte(p.signatureKey))
+	mac.Write(body)
+	if !hmac.Equal(mac.Sum(nil), received) {
+		return nil, webhooks.ErrInvalidSignature
+	}
+
+	var notification WebhookNotification
+	if err := json.Unmarshal(body, &notification); err != nil {
+		return nil, err
+	}
+	event := translateWebhookNotification(notification)
+	event.RawPayload = body
+	return []webhooks.WebhookEvent{event}, nil
+}
+
+func translateWebhookNotification(notification WebhookNotification) webhooks.WebhookEvent {
+	event := webhooks.WebhookEvent{
+		Kind:                 translateWebhookEventType(notification.EventType),
+		PsPEventType:         string(notification.EventType),
+		EventID:              notification.NotificationID,
+		TransactionReference: notification.Payload.ID,
+		MerchantReference:    notification.Payload.InvoiceNumber,
+		Success:              strconv.Itoa(notification.Payload.ResponseCode) == string(ResponseCodeApproved),
+	}
+	if notification.Payload.AuthAmount != 0 {
+		// Auth.net only supports currencies with two decimal places
+		event.Amount = &sleet.Amount{
+			Amount: int64(math.Round(notification.Payload.AuthAmount * 100)),
+		}
+	}
+	return event
+}
+
+func translateWebhookEventType(eventType WebhookEventType) webhooks.EventKind {
+	switch eventType {
+	case WebhookEventAuthorizationCreated:
+		return webhooks.EventKindAuthorization
+	case WebhookEventAuthCaptureCreated:
+		return webhooks.EventKindSale
+	case WebhookEventCaptureCreated, WebhookEventPriorAuthCaptureCreated:
+		return webhooks.EventKindCapture
+	case WebhookEventRefundCreated:
+		return webhooks.EventKindRefund
+	case WebhookEventVoidCreated:
+		return webhooks.EventKindVoid
+	default:
+		return webhooks.EventKindUnknown
+	}
+}
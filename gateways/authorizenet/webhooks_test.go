@@ -0,0 +1,85 @@
+//go:build unit
+// +build unit
+
+package authorizenet
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+
+	"github.com/BoltApp/sleet"
+	sleet_t "github.com/BoltApp/sleet/testing"
+	"github.com/BoltApp/sleet/webhooks"
+)
+
+const testSignatureKey = "6A3DF6C7E36E8A3E3E0CA7C4F3A8D6D0A1C2E0F9B8A7C6D5E4F3A2B1C0D9E8F7"
+
+func signedHeader(body []byte, key string) http.Header {
+	mac := hmac.New(sha512.New, []byte(key))
+	mac.Write(body)
+	header := http.Header{}
+	header.Set(signatureHeader, "sha512="+strings.ToUpper(hex.EncodeToString(mac.Sum(nil))))
+	return header
+}
+
+func TestWebhookParserParse(t *testing.T) {
+	helper := sleet_t.NewTestHelper(t)
+	body := helper.ReadFile("test_data/webhookAuthCaptureNotification.json")
+
+	got, err := NewWebhookParser(testSignatureKey).Parse(signedHeader(body, testSignatureKey), body)
+	if err != nil {
+		t.Fatalf("error parsing notification: %s", err)
+	}
+
+	want := []webhooks.WebhookEvent{
+		{
+			Kind:                 webhooks.EventKindSale,
+			PsPEventType:         string(WebhookEventAuthCaptureCreated),
+			EventID:              "d0e8e7fe-c3e7-4add-a480-27bc5ce28a18",
+			TransactionReference: "60020981676",
+			MerchantReference:    "INV-12345",
+			Amount:               &sleet.Amount{Amount: 4501},
+			Success:              true,
+			RawPayload:           body,
+		},
+	}
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestWebhookParserParseInvalidSignature(t *testing.T) {
+	helper := sleet_t.NewTestHelper(t)
+	body := helper.ReadFile("test_data/webhookAuthCaptureNotification.json")
+
+	lowerCase := signedHeader(body, testSignatureKey)
+	lowerCase.Set(signatureHeader, strings.ToLower(lowerCase.Get(signatureHeader)))
+	noPrefix := signedHeader(body, testSignatureKey)
+	noPrefix.Set(signatureHeader, strings.TrimPrefix(noPrefix.Get(signatureHeader), signaturePrefix))
+
+	cases := []struct {
+		label  string
+		header http.Header
+		want   error
+	}{
+		{"Lower case signature", lowerCase, nil},
+		{"Missing header", http.Header{}, webhooks.ErrMissingSignature},
+		{"Missing prefix", noPrefix, webhooks.ErrInvalidSignature},
+		{"Wrong key", signedHeader(body, "other"), webhooks.ErrInvalidSignature},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			_, err := NewWebhookParser(testSignatureKey).Parse(c.header, body)
+			if err != c.want {
+				t.Errorf("expected %v, got %v", c.want, err)
+			}
+		})
+	}
+}
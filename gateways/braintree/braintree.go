@@ -3,6 +3,7 @@ package braintree
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
@@ -14,9 +15,17 @@ import (
 	"github.com/BoltApp/sleet/common"
 )
 
+const gatewayName = "braintree"
+
+// transactionStatusMetadata is the metadata key of the Braintree status of captures, voids and refunds
+const transactionStatusMetadata = "transaction_status"
+
 var (
 	// assert client interface
-	_ sleet.ClientWithContext = &BraintreeClient{}
+	_ sleet.ClientWithContext             = &BraintreeClient{}
+	_ sleet.TransactionQuerierWithContext = &BraintreeClient{}
+	_ sleet.SaleWithContext               = &BraintreeClient{}
+	_ sleet.CapabilitiesReporter          = &BraintreeClient{}
 
 	// make sure to use TLS1.2
 	// https://github.com/braintree-go/braintree-go/blob/a7114170e0095deebe5202ddb07e1bfdb6fcf8d8/braintree.go#L28
@@ -49,21 +58,36 @@ type BraintreeClient struct {
 	privateKey  string
 	environment braintree_go.Environment
 	httpClient  *http.Client
+	logger      sleet.Logger
 }
 
 // NewClient creates a Braintree client with creds and default http client
-func NewClient(merchantID string, publicKey string, privateKey string, environment common.Environment) *BraintreeClient {
-	return NewWithHttpClient(merchantID, publicKey, privateKey, environment, defaultClient)
+func NewClient(merchantID string, publicKey string, privateKey string, environment common.Environment, options ...sleet.ClientOption) *BraintreeClient {
+	return NewWithHttpClient(merchantID, publicKey, privateKey, environment, defaultClient, options...)
 }
 
 // NewWithHttpClient creates a Braintree client with creds and user specified http client for custom behavior
-func NewWithHttpClient(merchantID string, publicKey string, privateKey string, environment common.Environment, httpClient *http.Client) *BraintreeClient {
+func NewWithHttpClient(merchantID string, publicKey string, privateKey string, environment common.Environment, httpClient *http.Client, options ...sleet.ClientOption) *BraintreeClient {
+	clientOptions := sleet.NewClientOptions(options...)
 	return &BraintreeClient{
 		merchantID:  merchantID,
 		publicKey:   publicKey,
 		privateKey:  privateKey,
-		environment: braintreeEnvironment(environment),
-		httpClient:  httpClient,
+		environment: braintree_go.NewEnvironment(common.OverrideBaseURL(braintreeEnvironment(environment).BaseURL(), clientOptions.BaseURL)),
+		httpClient:  common.LoggingHttpClient(httpClient, gatewayName, clientOptions.Logger),
+		logger:      clientOptions.Logger,
+	}
+}
+
+// Capabilities describes the optional operations supported by Braintree
+func (client *BraintreeClient) Capabilities() sleet.Capabilities {
+	return sleet.Capabilities{
+		ContextSupport:   true,
+		Sale:             true,
+		Vault:            true,
+		TransactionQuery: true,
+		PartialCapture:   true,
+		PartialRefund:    true,
 	}
 }
 
@@ -76,23 +100,70 @@ func (client *BraintreeClient) Authorize(request *sleet.AuthorizationRequest) (*
 func (client *BraintreeClient) AuthorizeWithContext(ctx context.Context, request *sleet.AuthorizationRequest) (*sleet.AuthorizationResponse, error) {
 	authRequest, err := buildAuthRequest(request)
 	if err != nil {
-		return nil, err
+		return nil, common.OperationError(gatewayName, sleet.OperationAuthorize, err)
+	}
+	resp, err := client.createTransaction(ctx, authRequest, braintree_go.TransactionStatusAuthorized)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationAuthorize, err)
+	}
+	return resp, nil
+}
+
+// Sale authorizes a transaction and submits it for settlement in a single call
+func (client *BraintreeClient) Sale(request *sleet.SaleRequest) (*sleet.SaleResponse, error) {
+	return client.SaleWithContext(context.TODO(), request)
+}
+
+// SaleWithContext authorizes a transaction and submits it for settlement in a single call
+func (client *BraintreeClient) SaleWithContext(ctx context.Context, request *sleet.SaleRequest) (*sleet.SaleResponse, error) {
+	saleRequest, err := buildSaleRequest(request)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationSale, err)
+	}
+	resp, err := client.createTransaction(ctx, saleRequest, braintree_go.TransactionStatusSubmittedForSettlement)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationSale, err)
 	}
+	return &sleet.SaleResponse{AuthorizationResponse: *resp}, nil
+}
+
+// createTransaction creates the transaction, which is successful if it is created with the expected status
+func (client *BraintreeClient) createTransaction(ctx context.Context, request *braintree_go.TransactionRequest, successStatus braintree_go.TransactionStatus) (*sleet.AuthorizationResponse, error) {
 	btClient := braintree_go.NewWithHttpClient(client.environment, client.merchantID, client.publicKey, client.privateKey, client.httpClient)
-	auth, err := btClient.Transaction().Create(ctx, authRequest)
+	auth, err := btClient.Transaction().Create(ctx, request)
 	if err != nil {
-		var statusCode int
-		if respErr, ok := err.(*braintree_go.BraintreeError); ok && respErr != nil {
-			statusCode = respErr.StatusCode()
+		respErr, ok := err.(*braintree_go.BraintreeError)
+		if !ok || respErr == nil {
+			return nil, translateError(err)
 		}
-		return &sleet.AuthorizationResponse{Success: false, StatusCode: statusCode}, err
+		resultType := translateErrorResultType(err)
+		return &sleet.AuthorizationResponse{
+			Success:       false,
+			StatusCode:    respErr.StatusCode(),
+			Message:       respErr.ErrorMessage,
+			ErrorCode:     translateErrorErrorCode(err),
+			ResultType:    resultType,
+			DeclineReason: translateErrorDeclineReason(err, resultType),
+		}, nil
 	}
+	common.LogResult(ctx, client.logger, gatewayName, string(auth.Status), "processor_response_code", auth.ProcessorResponseCode)
 
 	avsResult := fmt.Sprintf("%s:%s:%s", auth.AVSErrorResponseCode, auth.AVSStreetAddressResponseCode, auth.AVSStreetAddressResponseCode)
+	resultType := sleet.ResultTypeSuccess
+	var declineReason sleet.DeclineReason
+	var errorCode string
+	if auth.Status != successStatus {
+		resultType = translateResultType(auth.Status, int(auth.ProcessorResponseCode), auth.GatewayRejectionReason)
+		declineReason = translateDeclineReason(auth.Status, int(auth.ProcessorResponseCode), auth.GatewayRejectionReason, resultType)
+		errorCode = translateErrorCode(auth.Status, int(auth.ProcessorResponseCode), auth.GatewayRejectionReason)
+	}
 	return &sleet.AuthorizationResponse{
-		Success:              auth.Status == braintree_go.TransactionStatusAuthorized,
+		Success:              auth.Status == successStatus,
+		ErrorCode:            errorCode,
 		TransactionReference: auth.Id,
 		Response:             auth.ProcessorAuthorizationCode,
+		ResultType:           resultType,
+		DeclineReason:        declineReason,
 		AvsResult:            sleet.AVSresponseZipMatchAddressMatch, // TODO: Add translator
 		CvvResult:            sleet.CVVResponseMatch,                // TODO: Add translator
 		AvsResultRaw:         avsResult,
@@ -109,16 +180,29 @@ func (client *BraintreeClient) Capture(request *sleet.CaptureRequest) (*sleet.Ca
 func (client *BraintreeClient) CaptureWithContext(ctx context.Context, request *sleet.CaptureRequest) (*sleet.CaptureResponse, error) {
 	amount, err := convertToBraintreeDecimal(request.Amount.Amount, request.Amount.Currency)
 	if err != nil {
-		return nil, err
+		return nil, common.OperationError(gatewayName, sleet.OperationCapture, err)
 	}
 	btClient := braintree_go.NewWithHttpClient(client.environment, client.merchantID, client.publicKey, client.privateKey, client.httpClient)
 	capture, err := btClient.Transaction().SubmitForSettlement(ctx, request.TransactionReference, amount)
 	if err != nil {
-		return &sleet.CaptureResponse{Success: false, TransactionReference: ""}, err
+		statusCode, message, ok := errorDetails(err)
+		if !ok {
+			return nil, common.OperationError(gatewayName, sleet.OperationCapture, translateError(err))
+		}
+		return &sleet.CaptureResponse{
+			Success:    false,
+			Message:    message,
+			ResultType: translateErrorResultType(err),
+			StatusCode: statusCode,
+		}, nil
 	}
+	common.LogResult(ctx, client.logger, gatewayName, string(capture.Status))
 	return &sleet.CaptureResponse{
 		Success:              true,
 		TransactionReference: capture.Id,
+		ResultType:           sleet.ResultTypeSuccess,
+		Amount:               request.Amount,
+		Metadata:             transactionMetadata(capture),
 	}, nil
 }
 
@@ -132,13 +216,23 @@ func (client *BraintreeClient) VoidWithContext(ctx context.Context, request *sle
 	btClient := braintree_go.NewWithHttpClient(client.environment, client.merchantID, client.publicKey, client.privateKey, client.httpClient)
 	void, err := btClient.Transaction().Void(ctx, request.TransactionReference)
 	if err != nil {
+		statusCode, message, ok := errorDetails(err)
+		if !ok {
+			return nil, common.OperationError(gatewayName, sleet.OperationVoid, translateError(err))
+		}
 		return &sleet.VoidResponse{
-			Success: false,
-		}, err
+			Success:    false,
+			Message:    message,
+			ResultType: translateErrorResultType(err),
+			StatusCode: statusCode,
+		}, nil
 	}
+	common.LogResult(ctx, client.logger, gatewayName, string(void.Status))
 	return &sleet.VoidResponse{
 		Success:              true,
 		TransactionReference: void.Id,
+		ResultType:           sleet.ResultTypeSuccess,
+		Metadata:             transactionMetadata(void),
 	}, nil
 }
 
@@ -151,17 +245,89 @@ func (client *BraintreeClient) Refund(request *sleet.RefundRequest) (*sleet.Refu
 func (client *BraintreeClient) RefundWithContext(ctx context.Context, request *sleet.RefundRequest) (*sleet.RefundResponse, error) {
 	amount, err := convertToBraintreeDecimal(request.Amount.Amount, request.Amount.Currency)
 	if err != nil {
-		return nil, err
+		return nil, common.OperationError(gatewayName, sleet.OperationRefund, err)
 	}
 	btClient := braintree_go.NewWithHttpClient(client.environment, client.merchantID, client.publicKey, client.privateKey, client.httpClient)
 	refund, err := btClient.Transaction().Refund(ctx, request.TransactionReference, amount)
 	if err != nil {
+		statusCode, message, ok := errorDetails(err)
+		if !ok {
+			return nil, common.OperationError(gatewayName, sleet.OperationRefund, translateError(err))
+		}
 		return &sleet.RefundResponse{
-			Success: false,
-		}, err
+			Success:    false,
+			Message:    message,
+			ResultType: translateErrorResultType(err),
+			StatusCode: statusCode,
+		}, nil
 	}
+	common.LogResult(ctx, client.logger, gatewayName, string(refund.Status))
 	return &sleet.RefundResponse{
 		Success:              true,
 		TransactionReference: refund.Id,
+		ResultType:           sleet.ResultTypeSuccess,
+		Amount:               request.Amount,
+		Metadata:             transactionMetadata(refund),
 	}, nil
 }
+
+// QueryTransaction finds a transaction and its refunds to report its current state
+func (client *BraintreeClient) QueryTransaction(request *sleet.TransactionQueryRequest) (*sleet.TransactionQueryResponse, error) {
+	return client.QueryTransactionWithContext(context.TODO(), request)
+}
+
+// QueryTransactionWithContext finds a transaction and its refunds to report its current state.
+// Braintree refunds are separate transactions, each one is looked up to total the refunded amount.
+func (client *BraintreeClient) QueryTransactionWithContext(ctx context.Context, request *sleet.TransactionQueryRequest) (*sleet.TransactionQueryResponse, error) {
+	btClient := braintree_go.NewWithHttpClient(client.environment, client.merchantID, client.publicKey, client.privateKey, client.httpClient)
+	transaction, err := btClient.Transaction().Find(ctx, request.TransactionReference)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationQueryTransaction, translateError(err))
+	}
+
+	response, err := translateTransaction(transaction)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationQueryTransaction, common.DecodeError(gatewayName, err))
+	}
+
+	if transaction.RefundIds == nil {
+		return response, nil
+	}
+	for _, refundID := range *transaction.RefundIds {
+		refund, err := btClient.Transaction().Find(ctx, refundID)
+		if err != nil {
+			return nil, common.OperationError(gatewayName, sleet.OperationQueryTransaction, translateError(err))
+		}
+		state := translateTransactionStatus(refund.Status)
+		if state != sleet.TransactionStateCaptured && state != sleet.TransactionStateSettled {
+			continue
+		}
+		amount, err := convertFromBraintreeDecimal(refund.Amount, refund.CurrencyISOCode)
+		if err != nil {
+			return nil, common.OperationError(gatewayName, sleet.OperationQueryTransaction, common.DecodeError(gatewayName, err))
+		}
+		response.RefundedAmount += amount.Amount
+	}
+	if response.RefundedAmount > 0 {
+		response.State = sleet.TransactionStateRefunded
+	}
+	return response, nil
+}
+
+// errorDetails returns the HTTP status and the message of an error returned by the Braintree library, which are only
+// known when Braintree responded with an error body.
+func errorDetails(err error) (int, string, bool) {
+	var braintreeError *braintree_go.BraintreeError
+	if !errors.As(err, &braintreeError) {
+		return 0, "", false
+	}
+	return braintreeError.StatusCode(), braintreeError.ErrorMessage, true
+}
+
+// transactionMetadata returns the status Braintree reports for a follow-on transaction, such as settling for a refund
+func transactionMetadata(transaction *braintree_go.Transaction) map[string]string {
+	if transaction.Status == "" {
+		return nil
+	}
+	return map[string]string{transactionStatusMetadata: string(transaction.Status)}
+}
@@ -0,0 +1,43 @@
+//go:build unit
+// +build unit
+
+package braintree
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	braintree_go "github.com/BoltApp/braintree-go"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+	"github.com/BoltApp/sleet/testing/conformance"
+	"github.com/BoltApp/sleet/testing/fakes"
+)
+
+func TestConformance(t *testing.T) {
+	fake := fakes.NewBraintree()
+	defer fake.Close()
+
+	conformance.Run(t, conformance.Suite{
+		Gateway:   "braintree",
+		Transport: fake.Client().Transport,
+		NewClient: func(httpClient *http.Client) sleet.ClientWithContext {
+			return NewWithHttpClient(fakes.BraintreeMerchantID, fakes.BraintreePublicKey, fakes.BraintreePrivateKey, common.Sandbox, httpClient)
+		},
+		// the sandbox declines amounts from $2000.00 to $2999.99 with the dollar amount as the processor response code
+		Decline: func(request *sleet.AuthorizationRequest) { request.Amount.Amount = 200000 },
+		AVS:     sleet.AVSresponseZipMatchAddressMatch,
+		CVV:     sleet.CVVResponseMatch,
+		// refunds need the transaction settled, which the sandbox's testing gateway does
+		Settle: func(transactionReference string) error {
+			gateway := braintree_go.NewWithHttpClient(braintree_go.Sandbox, fakes.BraintreeMerchantID, fakes.BraintreePublicKey, fakes.BraintreePrivateKey, fake.Client())
+			_, err := gateway.Testing().Settle(context.Background(), transactionReference)
+			return err
+		},
+		Skip: map[conformance.Behavior]string{
+			conformance.BehaviorResponseHeader: "the SDK doesn't expose the HTTP response",
+		},
+	})
+}
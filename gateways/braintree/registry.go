@@ -0,0 +1,28 @@
+package braintree
+
+import (
+	"net/http"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/registry"
+)
+
+// Braintree credential keys of a registry.Config
+const (
+	credentialMerchantID = "merchant_id"
+	credentialPublicKey  = "public_key"
+	credentialPrivateKey = "private_key"
+)
+
+func init() {
+	registry.Register("braintree", newRegistryClient)
+}
+
+// newRegistryClient builds a BraintreeClient from a registry config
+func newRegistryClient(config *registry.Config, httpClient *http.Client, options ...sleet.ClientOption) (sleet.ClientWithContext, error) {
+	credentials, err := config.RequiredCredentials(credentialMerchantID, credentialPublicKey, credentialPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithHttpClient(credentials[0], credentials[1], credentials[2], config.Environment, httpClient, options...), nil
+}
@@ -2,6 +2,7 @@ package braintree
 
 import (
 	"fmt"
+	"strings"
 
 	braintree_go "github.com/BoltApp/braintree-go"
 
@@ -18,31 +19,76 @@ func buildAuthRequest(authRequest *sleet.AuthorizationRequest) (*braintree_go.Tr
 	}
 
 	request := &braintree_go.TransactionRequest{
-		Type:   "sale",
-		Amount: amount,
-		CreditCard: &braintree_go.CreditCard{
-			Number:         card.Number,
-			ExpirationDate: fmt.Sprintf("%02d/%02d", card.ExpirationMonth, card.ExpirationMonth%100),
-			CVV:            card.CVV,
-		},
+		Type:    "sale",
+		Amount:  amount,
 		OrderId: common.SafeStr(authRequest.ClientTransactionReference),
 		Channel: authRequest.Channel,
 	}
 
+	if authRequest.StoredPaymentMethod != nil {
+		request.CustomerID = authRequest.StoredPaymentMethod.CustomerReference
+		request.PaymentMethodToken = authRequest.StoredPaymentMethod.Token
+		return request, nil
+	}
+
+	request.CreditCard = &braintree_go.CreditCard{
+		Number:         card.Number,
+		ExpirationDate: fmt.Sprintf("%02d/%02d", card.ExpirationMonth, card.ExpirationMonth%100),
+		CVV:            card.CVV,
+	}
 	if billingAddress != nil {
-		request.BillingAddress = &braintree_go.Address{
-			FirstName:         authRequest.CreditCard.FirstName,
-			LastName:          authRequest.CreditCard.LastName,
-			StreetAddress:     common.SafeStr(billingAddress.StreetAddress1),
-			Locality:          common.SafeStr(billingAddress.Locality),
-			Region:            common.SafeStr(billingAddress.RegionCode),
-			PostalCode:        common.SafeStr(billingAddress.PostalCode),
-			CountryCodeAlpha2: common.SafeStr(billingAddress.CountryCode),
-		}
+		request.BillingAddress = buildAddress(billingAddress, card)
+	}
+	return request, nil
+}
+
+func buildSaleRequest(saleRequest *sleet.SaleRequest) (*braintree_go.TransactionRequest, error) {
+	request, err := buildAuthRequest(saleRequest)
+	if err != nil {
+		return nil, err
 	}
+	request.Options = &braintree_go.TransactionOptions{SubmitForSettlement: true}
 	return request, nil
 }
 
+func buildAddress(billingAddress *sleet.Address, card *sleet.CreditCard) *braintree_go.Address {
+	return &braintree_go.Address{
+		FirstName:         card.FirstName,
+		LastName:          card.LastName,
+		StreetAddress:     common.SafeStr(billingAddress.StreetAddress1),
+		Locality:          common.SafeStr(billingAddress.Locality),
+		Region:            common.SafeStr(billingAddress.RegionCode),
+		PostalCode:        common.SafeStr(billingAddress.PostalCode),
+		CountryCodeAlpha2: common.SafeStr(billingAddress.CountryCode),
+	}
+}
+
+// buildStoreCustomerRequest creates a new customer with the card as its first payment method
+func buildStoreCustomerRequest(storeRequest *sleet.StorePaymentMethodRequest) *braintree_go.CustomerRequest {
+	return &braintree_go.CustomerRequest{
+		FirstName:  storeRequest.CreditCard.FirstName,
+		LastName:   storeRequest.CreditCard.LastName,
+		CreditCard: buildStoreCreditCard(storeRequest),
+	}
+}
+
+// buildStoreCreditCard adds the card to the customer in CustomerReference, if any
+func buildStoreCreditCard(storeRequest *sleet.StorePaymentMethodRequest) *braintree_go.CreditCard {
+	card := storeRequest.CreditCard
+	creditCard := &braintree_go.CreditCard{
+		CustomerId:      storeRequest.CustomerReference,
+		Number:          card.Number,
+		ExpirationMonth: fmt.Sprintf("%02d", card.ExpirationMonth),
+		ExpirationYear:  fmt.Sprintf("%d", card.ExpirationYear),
+		CVV:             card.CVV,
+		CardholderName:  strings.TrimSpace(card.FirstName + " " + card.LastName),
+	}
+	if storeRequest.BillingAddress != nil {
+		creditCard.BillingAddress = buildAddress(storeRequest.BillingAddress, card)
+	}
+	return creditCard
+}
+
 func convertToBraintreeDecimal(amount int64, currencyCode string) (*braintree_go.Decimal, error) {
 	code, err := common.GetCode(currencyCode)
 	if err != nil {
@@ -51,3 +97,36 @@ func convertToBraintreeDecimal(amount int64, currencyCode string) (*braintree_go
 	precision := common.CURRENCIES[code].Precision
 	return braintree_go.NewDecimal(amount, precision), nil
 }
+
+// convertFromBraintreeDecimal converts a Braintree decimal amount to the minor units of the currency
+func convertFromBraintreeDecimal(amount *braintree_go.Decimal, currencyCode string) (*sleet.Amount, error) {
+	code, err := common.GetCode(currencyCode)
+	if err != nil {
+		return nil, err
+	}
+	precision := common.CURRENCIES[code].Precision
+	value := amount.Unscaled
+	for scale := amount.Scale; scale < precision; scale++ {
+		value *= 10
+	}
+	for scale := amount.Scale; scale > precision; scale-- {
+		value /= 10
+	}
+	return &sleet.Amount{Amount: value, Currency: string(code)}, nil
+}
+
+// buildVerificationRequest verifies the card and its billing address without creating a payment method
+func buildVerificationRequest(request *sleet.VerificationRequest) *verificationRequest {
+	card := request.CreditCard
+	creditCard := &braintree_go.CreditCard{
+		Number:          card.Number,
+		ExpirationMonth: fmt.Sprintf("%02d", card.ExpirationMonth),
+		ExpirationYear:  fmt.Sprintf("%d", card.ExpirationYear),
+		CVV:             card.CVV,
+		CardholderName:  strings.TrimSpace(card.FirstName + " " + card.LastName),
+	}
+	if request.BillingAddress != nil {
+		creditCard.BillingAddress = buildAddress(request.BillingAddress, card)
+	}
+	return &verificationRequest{CreditCard: creditCard}
+}
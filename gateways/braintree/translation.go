@@ -0,0 +1,297 @@
+package braintree
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	braintree_go "github.com/BoltApp/braintree-go"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+)
+
+// cardTypeMap maps Braintree credit card types to sleet networks
+var cardTypeMap = map[string]sleet.CreditCardNetwork{
+	"Visa":             sleet.CreditCardNetworkVisa,
+	"MasterCard":       sleet.CreditCardNetworkMastercard,
+	"American Express": sleet.CreditCardNetworkAmex,
+	"Discover":         sleet.CreditCardNetworkDiscover,
+	"JCB":              sleet.CreditCardNetworkJcb,
+	"UnionPay":         sleet.CreditCardNetworkUnionpay,
+}
+
+func translateCardType(cardType string) sleet.CreditCardNetwork {
+	network, ok := cardTypeMap[cardType]
+	if !ok {
+		return sleet.CreditCardNetworkUnknown
+	}
+	return network
+}
+
+var transactionStatusMap = map[braintree_go.TransactionStatus]sleet.TransactionState{
+	braintree_go.TransactionStatusAuthorized:             sleet.TransactionStateAuthorized,
+	braintree_go.TransactionStatusSubmittedForSettlement: sleet.TransactionStateCaptured,
+	braintree_go.TransactionStatusSettling:               sleet.TransactionStateCaptured,
+	braintree_go.TransactionStatusSettlementPending:      sleet.TransactionStateCaptured,
+	braintree_go.TransactionStatusSettled:                sleet.TransactionStateSettled,
+	braintree_go.TransactionStatusSettlementConfirmed:    sleet.TransactionStateSettled,
+	braintree_go.TransactionStatusVoided:                 sleet.TransactionStateVoided,
+	braintree_go.TransactionStatusAuthorizationExpired:   sleet.TransactionStateVoided,
+	braintree_go.TransactionStatusProcessorDeclined:      sleet.TransactionStateDeclined,
+	braintree_go.TransactionStatusGatewayRejected:        sleet.TransactionStateDeclined,
+	braintree_go.TransactionStatusFailed:                 sleet.TransactionStateDeclined,
+	braintree_go.TransactionStatusSettlementDeclined:     sleet.TransactionStateDeclined,
+}
+
+func translateTransactionStatus(status braintree_go.TransactionStatus) sleet.TransactionState {
+	state, ok := transactionStatusMap[status]
+	if !ok {
+		return sleet.TransactionStateUnknown
+	}
+	return state
+}
+
+// translateTransaction converts a Braintree transaction to a Sleet transaction query response.
+// The transaction amount is the captured amount once submitted for settlement.
+func translateTransaction(transaction *braintree_go.Transaction) (*sleet.TransactionQueryResponse, error) {
+	response := &sleet.TransactionQueryResponse{
+		Success:              true,
+		TransactionReference: transaction.Id,
+		State:                translateTransactionStatus(transaction.Status),
+		StateRaw:             string(transaction.Status),
+		Currency:             transaction.CurrencyISOCode,
+		CreatedAt:            transaction.CreatedAt,
+		UpdatedAt:            transaction.UpdatedAt,
+	}
+	if transaction.Amount != nil {
+		amount, err := convertFromBraintreeDecimal(transaction.Amount, transaction.CurrencyISOCode)
+		if err != nil {
+			return nil, err
+		}
+		response.AuthorizedAmount = amount.Amount
+		if response.State == sleet.TransactionStateCaptured || response.State == sleet.TransactionStateSettled {
+			response.CapturedAmount = amount.Amount
+		}
+	}
+	if transaction.CreditCard != nil {
+		response.Last4 = transaction.CreditCard.Last4
+		response.Network = translateCardType(transaction.CreditCard.CardType)
+	}
+	return response, nil
+}
+
+// translateAvs combines the street address and postal code results of a verification.
+// The error code is only set when AVS could not be performed.
+func translateAvs(errorCode, streetCode, postalCode braintree_go.AVSResponseCode) sleet.AVSResponse {
+	switch errorCode {
+	case braintree_go.AVSResponseCodeSystemError:
+		return sleet.AVSResponseError
+	case braintree_go.AVSResponseCodeNotSupported:
+		return sleet.AVSResponseUnsupported
+	}
+
+	streetMatch := streetCode == braintree_go.AVSResponseCodeMatches
+	postalMatch := postalCode == braintree_go.AVSResponseCodeMatches
+	streetNoMatch := streetCode == braintree_go.AVSResponseCodeDoesNotMatch
+	postalNoMatch := postalCode == braintree_go.AVSResponseCodeDoesNotMatch
+	switch {
+	case streetMatch && postalMatch:
+		return sleet.AVSresponseZipMatchAddressMatch
+	case streetMatch && postalNoMatch:
+		return sleet.AVSResponseZipNoMatchAddressMatch
+	case streetNoMatch && postalMatch:
+		return sleet.AVSResponseZip5MatchAddressNoMatch
+	case streetNoMatch && postalNoMatch:
+		return sleet.AVSResponseNoMatch
+	case streetMatch:
+		return sleet.AVSResponseZipUnverifiedAddressMatch
+	case postalMatch:
+		return sleet.AVSResponseZipMatchAddressUnverified
+	case streetCode == braintree_go.AVSResponseCodeNotProvided && postalCode == braintree_go.AVSResponseCodeNotProvided:
+		return sleet.AVSResponseSkipped
+	}
+	return sleet.AVSResponseUnknown
+}
+
+var cvvMap = map[braintree_go.CVVResponseCode]sleet.CVVResponse{
+	braintree_go.CVVResponseCodeMatches:                  sleet.CVVResponseMatch,
+	braintree_go.CVVResponseCodeDoesNotMatch:             sleet.CVVResponseNoMatch,
+	braintree_go.CVVResponseCodeNotVerified:              sleet.CVVResponseNotProcessed,
+	braintree_go.CVVResponseCodeNotProvided:              sleet.CVVResponseSkipped,
+	braintree_go.CVVResponseCodeIssuerDoesNotParticipate: sleet.CVVResponseUnsupported,
+	braintree_go.CVVResponseCodeNotApplicable:            sleet.CVVResponseUnsupported,
+}
+
+func translateCvv(code braintree_go.CVVResponseCode) sleet.CVVResponse {
+	cvv, ok := cvvMap[code]
+	if !ok {
+		return sleet.CVVResponseUnknown
+	}
+	return cvv
+}
+
+// translateVerification converts a Braintree credit card verification to a Sleet verification response
+func translateVerification(verification *verification) *sleet.VerificationResponse {
+	response := &sleet.VerificationResponse{AuthorizationResponse: sleet.AuthorizationResponse{
+		Success:              verification.Status == verificationStatusVerified,
+		TransactionReference: verification.ID,
+		Response:             verification.ProcessorResponseCode,
+		AvsResult: translateAvs(
+			verification.AVSErrorResponseCode,
+			verification.AVSStreetAddressResponseCode,
+			verification.AVSPostalCodeResponseCode,
+		),
+		CvvResult: translateCvv(verification.CVVResponseCode),
+		AvsResultRaw: fmt.Sprintf(
+			"%s:%s:%s",
+			verification.AVSErrorResponseCode,
+			verification.AVSStreetAddressResponseCode,
+			verification.AVSPostalCodeResponseCode,
+		),
+		CvvResultRaw: string(verification.CVVResponseCode),
+	}}
+	response.ResultType = sleet.ResultTypeSuccess
+	if !response.Success {
+		response.ErrorCode = verification.ProcessorResponseCode
+		processorResponseCode, _ := strconv.Atoi(verification.ProcessorResponseCode)
+		status := braintree_go.TransactionStatus(verification.Status)
+		rejectionReason := braintree_go.GatewayRejectionReason(verification.GatewayRejectionReason)
+		response.ResultType = translateResultType(status, processorResponseCode, rejectionReason)
+		response.DeclineReason = translateDeclineReason(status, processorResponseCode, rejectionReason, response.ResultType)
+	}
+	return response
+}
+
+// translateResultType converts the status of a transaction or verification which was not successful to a Sleet result
+// type. Processor response codes from 3000 are processor outages, see
+// https://developer.paypal.com/braintree/articles/control-panel/transactions/declines
+func translateResultType(status braintree_go.TransactionStatus, processorResponseCode int, rejectionReason braintree_go.GatewayRejectionReason) sleet.ResultType {
+	switch status {
+	case braintree_go.TransactionStatusProcessorDeclined:
+		if processorResponseCode >= 3000 {
+			return sleet.ResultTypeServerError
+		}
+		return sleet.ResultTypePaymentError
+	case braintree_go.TransactionStatusGatewayRejected:
+		switch rejectionReason {
+		case braintree_go.GatewayRejectionReasonDuplicate, braintree_go.GatewayRejectionReasonApplicationIncomplete:
+			return sleet.ResultTypeAPIError
+		}
+		return sleet.ResultTypePaymentError
+	case braintree_go.TransactionStatusSettlementDeclined:
+		return sleet.ResultTypePaymentError
+	case braintree_go.TransactionStatusFailed:
+		return sleet.ResultTypeServerError
+	}
+	return sleet.ResultTypeUnknownError
+}
+
+// Processor response codes of declines, taken from:
+// https://developer.paypal.com/braintree/articles/control-panel/transactions/declines
+var declineReasonMap = map[string]sleet.DeclineReason{
+	"2000": sleet.DeclineReasonDoNotHonor,             // Do Not Honor
+	"2001": sleet.DeclineReasonInsufficientFunds,      // Insufficient Funds
+	"2002": sleet.DeclineReasonLimitExceeded,          // Limit Exceeded
+	"2003": sleet.DeclineReasonLimitExceeded,          // Cardholder's Activity Limit Exceeded
+	"2004": sleet.DeclineReasonExpiredCard,            // Expired Card
+	"2005": sleet.DeclineReasonInvalidCardNumber,      // Invalid Credit Card Number
+	"2006": sleet.DeclineReasonInvalidExpirationDate,  // Invalid Expiration Date
+	"2007": sleet.DeclineReasonInvalidCardNumber,      // No Account
+	"2008": sleet.DeclineReasonInvalidCardNumber,      // Card Account Length Error
+	"2009": sleet.DeclineReasonInvalidCardNumber,      // No Such Issuer
+	"2010": sleet.DeclineReasonCVVFailure,             // Card Issuer Declined CVV
+	"2011": sleet.DeclineReasonCallIssuer,             // Voice Authorization Required
+	"2012": sleet.DeclineReasonLostOrStolenCard,       // Processor Declined - Possible Lost Card
+	"2013": sleet.DeclineReasonLostOrStolenCard,       // Processor Declined - Possible Stolen Card
+	"2014": sleet.DeclineReasonSuspectedFraud,         // Processor Declined - Fraud Suspected
+	"2015": sleet.DeclineReasonTransactionNotAllowed,  // Transaction Not Allowed
+	"2019": sleet.DeclineReasonTransactionNotAllowed,  // Invalid Transaction
+	"2038": sleet.DeclineReasonDoNotHonor,             // Processor Declined
+	"2044": sleet.DeclineReasonCallIssuer,             // Declined - Call Issuer
+	"2046": sleet.DeclineReasonDoNotHonor,             // Declined
+	"2047": sleet.DeclineReasonRestrictedCard,         // Call Issuer. Pick Up Card
+	"2057": sleet.DeclineReasonRestrictedCard,         // Issuer or Cardholder has put a restriction on the card
+	"2060": sleet.DeclineReasonAVSFailure,             // Address Verification and Card Security Code Failed
+	"2099": sleet.DeclineReasonAuthenticationRequired, // Cardholder Authentication Required
+	"3000": sleet.DeclineReasonIssuerUnavailable,      // Processor Network Unavailable - Try Again
+}
+
+var rejectionDeclineReasonMap = map[braintree_go.GatewayRejectionReason]sleet.DeclineReason{
+	braintree_go.GatewayRejectionReasonAVS:          sleet.DeclineReasonAVSFailure,
+	braintree_go.GatewayRejectionReasonAVSAndCVV:    sleet.DeclineReasonAVSFailure,
+	braintree_go.GatewayRejectionReasonCVV:          sleet.DeclineReasonCVVFailure,
+	braintree_go.GatewayRejectionReasonFraud:        sleet.DeclineReasonSuspectedFraud,
+	braintree_go.GatewayRejectionReasonThreeDSecure: sleet.DeclineReasonAuthenticationRequired,
+}
+
+// translateDeclineReason converts the processor response code of an unsuccessful transaction or verification, or its
+// gateway rejection reason when Braintree rejected it before it reached the processor, to a Sleet decline reason
+func translateDeclineReason(status braintree_go.TransactionStatus, processorResponseCode int, rejectionReason braintree_go.GatewayRejectionReason, resultType sleet.ResultType) sleet.DeclineReason {
+	if status == braintree_go.TransactionStatusGatewayRejected {
+		if reason, ok := rejectionDeclineReasonMap[rejectionReason]; ok {
+			return reason
+		}
+	}
+	return common.TranslateDeclineReason(declineReasonMap, strconv.Itoa(processorResponseCode), resultType)
+}
+
+// translateErrorDeclineReason returns the decline reason of the transaction of an error returned by the Braintree
+// library, if any
+func translateErrorDeclineReason(err error, resultType sleet.ResultType) sleet.DeclineReason {
+	var braintreeError *braintree_go.BraintreeError
+	if !errors.As(err, &braintreeError) || braintreeError.Transaction == nil {
+		return ""
+	}
+	transaction := braintreeError.Transaction
+	return translateDeclineReason(transaction.Status, int(transaction.ProcessorResponseCode), transaction.GatewayRejectionReason, resultType)
+}
+
+// translateErrorCode returns the processor response code of an unsuccessful transaction, or its gateway rejection
+// reason when Braintree rejected it before it reached the processor
+func translateErrorCode(status braintree_go.TransactionStatus, processorResponseCode int, rejectionReason braintree_go.GatewayRejectionReason) string {
+	if status == braintree_go.TransactionStatusGatewayRejected || processorResponseCode == 0 {
+		return string(rejectionReason)
+	}
+	return strconv.Itoa(processorResponseCode)
+}
+
+// translateErrorErrorCode returns the error code of the transaction of an error returned by the Braintree library,
+// if any
+func translateErrorErrorCode(err error) string {
+	var braintreeError *braintree_go.BraintreeError
+	if !errors.As(err, &braintreeError) || braintreeError.Transaction == nil {
+		return ""
+	}
+	transaction := braintreeError.Transaction
+	return translateErrorCode(transaction.Status, int(transaction.ProcessorResponseCode), transaction.GatewayRejectionReason)
+}
+
+// translateError classifies an error returned by the Braintree library without an error body. Braintree error
+// statuses without one are PsP API errors, any other error is classified by its type.
+func translateError(err error) *sleet.Error {
+	var apiError braintree_go.APIError
+	if errors.As(err, &apiError) {
+		return common.ResponseError(gatewayName, apiError.StatusCode(), err)
+	}
+	return common.ClassifyError(gatewayName, err)
+}
+
+// translateErrorResultType classifies an error returned by the Braintree library. Errors with a transaction are
+// classified by its status and validation errors by their HTTP status, other errors are server errors.
+func translateErrorResultType(err error) sleet.ResultType {
+	var braintreeError *braintree_go.BraintreeError
+	if !errors.As(err, &braintreeError) {
+		return sleet.ResultTypeServerError
+	}
+	if transaction := braintreeError.Transaction; transaction != nil {
+		resultType := translateResultType(transaction.Status, int(transaction.ProcessorResponseCode), transaction.GatewayRejectionReason)
+		if resultType != sleet.ResultTypeUnknownError {
+			return resultType
+		}
+	}
+	if resultType := common.ResultTypeFromHTTPStatus(braintreeError.StatusCode()); resultType != sleet.ResultTypeUnknownError {
+		return resultType
+	}
+	return sleet.ResultTypeAPIError
+}
@@ -0,0 +1,162 @@
+//go:build unit
+// +build unit
+
+package braintree
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	braintree_go "github.com/BoltApp/braintree-go"
+	"github.com/go-test/deep"
+
+	"github.com/BoltApp/sleet"
+)
+
+func TestTranslateTransaction(t *testing.T) {
+	createdAt := time.Date(2023, 3, 21, 20, 1, 31, 0, time.UTC)
+	in := &braintree_go.Transaction{
+		Id:              "txn_1",
+		Status:          braintree_go.TransactionStatusSubmittedForSettlement,
+		CurrencyISOCode: "USD",
+		Amount:          braintree_go.NewDecimal(10050, 2),
+		CreditCard:      &braintree_go.CreditCard{Last4: "1111", CardType: "Visa"},
+		CreatedAt:       &createdAt,
+		UpdatedAt:       &createdAt,
+	}
+
+	got, err := translateTransaction(in)
+	if err != nil {
+		t.Fatalf("error translating transaction: %s", err)
+	}
+
+	want := &sleet.TransactionQueryResponse{
+		Success:              true,
+		TransactionReference: "txn_1",
+		State:                sleet.TransactionStateCaptured,
+		StateRaw:             "submitted_for_settlement",
+		Currency:             "USD",
+		AuthorizedAmount:     10050,
+		CapturedAmount:       10050,
+		Last4:                "1111",
+		Network:              sleet.CreditCardNetworkVisa,
+		CreatedAt:            &createdAt,
+		UpdatedAt:            &createdAt,
+	}
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestTranslateResultType(t *testing.T) {
+	cases := []struct {
+		status                braintree_go.TransactionStatus
+		processorResponseCode int
+		rejectionReason       braintree_go.GatewayRejectionReason
+		want                  sleet.ResultType
+	}{
+		{braintree_go.TransactionStatusProcessorDeclined, 2000, "", sleet.ResultTypePaymentError},
+		{braintree_go.TransactionStatusProcessorDeclined, 2001, "", sleet.ResultTypePaymentError},
+		{braintree_go.TransactionStatusProcessorDeclined, 2010, "", sleet.ResultTypePaymentError},
+		{braintree_go.TransactionStatusProcessorDeclined, 3000, "", sleet.ResultTypeServerError},
+		{braintree_go.TransactionStatusGatewayRejected, 0, braintree_go.GatewayRejectionReasonCVV, sleet.ResultTypePaymentError},
+		{braintree_go.TransactionStatusGatewayRejected, 0, braintree_go.GatewayRejectionReasonFraud, sleet.ResultTypePaymentError},
+		{braintree_go.TransactionStatusGatewayRejected, 0, braintree_go.GatewayRejectionReasonDuplicate, sleet.ResultTypeAPIError},
+		{braintree_go.TransactionStatusSettlementDeclined, 4001, "", sleet.ResultTypePaymentError},
+		{braintree_go.TransactionStatusFailed, 0, "", sleet.ResultTypeServerError},
+		{braintree_go.TransactionStatusAuthorizing, 0, "", sleet.ResultTypeUnknownError},
+	}
+	for _, c := range cases {
+		t.Run(string(c.status)+" "+string(c.rejectionReason), func(t *testing.T) {
+			if got := translateResultType(c.status, c.processorResponseCode, c.rejectionReason); got != c.want {
+				t.Errorf("expected %s, got %s", c.want, got)
+			}
+		})
+	}
+}
+
+func TestTranslateErrorResultType(t *testing.T) {
+	declined := &braintree_go.BraintreeError{
+		ErrorMessage: "Do Not Honor",
+		Transaction: &braintree_go.Transaction{
+			Status:                braintree_go.TransactionStatusProcessorDeclined,
+			ProcessorResponseCode: 2000,
+		},
+	}
+	if got := translateErrorResultType(declined); got != sleet.ResultTypePaymentError {
+		t.Errorf("expected a declined transaction to be a payment error, got %s", got)
+	}
+	if got := translateErrorResultType(&braintree_go.BraintreeError{ErrorMessage: "Amount is required."}); got != sleet.ResultTypeAPIError {
+		t.Errorf("expected a validation error to be an API error, got %s", got)
+	}
+	if got := translateErrorResultType(errors.New("connection reset by peer")); got != sleet.ResultTypeServerError {
+		t.Errorf("expected a network error to be a server error, got %s", got)
+	}
+}
+
+func TestTranslateDeclineReason(t *testing.T) {
+	cases := []struct {
+		status                braintree_go.TransactionStatus
+		processorResponseCode int
+		rejectionReason       braintree_go.GatewayRejectionReason
+		want                  sleet.DeclineReason
+	}{
+		{braintree_go.TransactionStatusProcessorDeclined, 2000, "", sleet.DeclineReasonDoNotHonor},
+		{braintree_go.TransactionStatusProcessorDeclined, 2001, "", sleet.DeclineReasonInsufficientFunds},
+		{braintree_go.TransactionStatusProcessorDeclined, 2004, "", sleet.DeclineReasonExpiredCard},
+		{braintree_go.TransactionStatusProcessorDeclined, 2013, "", sleet.DeclineReasonLostOrStolenCard},
+		{braintree_go.TransactionStatusProcessorDeclined, 2099, "", sleet.DeclineReasonAuthenticationRequired},
+		{braintree_go.TransactionStatusProcessorDeclined, 2999, "", sleet.DeclineReasonUnknown},
+		{braintree_go.TransactionStatusProcessorDeclined, 3000, "", sleet.DeclineReasonIssuerUnavailable},
+		{braintree_go.TransactionStatusGatewayRejected, 0, braintree_go.GatewayRejectionReasonCVV, sleet.DeclineReasonCVVFailure},
+		{braintree_go.TransactionStatusGatewayRejected, 0, braintree_go.GatewayRejectionReasonFraud, sleet.DeclineReasonSuspectedFraud},
+		{braintree_go.TransactionStatusGatewayRejected, 0, braintree_go.GatewayRejectionReasonDuplicate, ""},
+		{braintree_go.TransactionStatusFailed, 0, "", ""},
+	}
+	for _, c := range cases {
+		t.Run(string(c.status)+" "+string(c.rejectionReason), func(t *testing.T) {
+			resultType := translateResultType(c.status, c.processorResponseCode, c.rejectionReason)
+			if got := translateDeclineReason(c.status, c.processorResponseCode, c.rejectionReason, resultType); got != c.want {
+				t.Errorf("expected %q, got %q", c.want, got)
+			}
+		})
+	}
+
+	declined := &braintree_go.BraintreeError{
+		ErrorMessage: "Insufficient Funds",
+		Transaction: &braintree_go.Transaction{
+			Status:                braintree_go.TransactionStatusProcessorDeclined,
+			ProcessorResponseCode: 2001,
+		},
+	}
+	if got := translateErrorDeclineReason(declined, sleet.ResultTypePaymentError); got != sleet.DeclineReasonInsufficientFunds {
+		t.Errorf("expected the decline reason of the error's transaction, got %q", got)
+	}
+	if got := translateErrorDeclineReason(errors.New("connection reset by peer"), sleet.ResultTypeServerError); got != "" {
+		t.Errorf("expected no decline reason for a network error, got %q", got)
+	}
+}
+
+func TestTranslateErrorCode(t *testing.T) {
+	if got := translateErrorCode(braintree_go.TransactionStatusProcessorDeclined, 2001, ""); got != "2001" {
+		t.Errorf("expected the processor response code of a declined transaction, got %q", got)
+	}
+	if got := translateErrorCode(braintree_go.TransactionStatusGatewayRejected, 0, braintree_go.GatewayRejectionReasonCVV); got != string(braintree_go.GatewayRejectionReasonCVV) {
+		t.Errorf("expected the rejection reason of a rejected transaction, got %q", got)
+	}
+
+	declined := &braintree_go.BraintreeError{
+		ErrorMessage: "Do Not Honor",
+		Transaction: &braintree_go.Transaction{
+			Status:                braintree_go.TransactionStatusProcessorDeclined,
+			ProcessorResponseCode: 2000,
+		},
+	}
+	if got := translateErrorErrorCode(declined); got != "2000" {
+		t.Errorf("expected the error code of the error's transaction, got %q", got)
+	}
+	if got := translateErrorErrorCode(errors.New("connection reset by peer")); got != "" {
+		t.Errorf("expected no error code for a network error, got %q", got)
+	}
+}
@@ -0,0 +1,119 @@
+package braintree
+
+import (
+	"context"
+	"strconv"
+
+	braintree_go "github.com/BoltApp/braintree-go"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+)
+
+var (
+	// assert vault interface
+	_ sleet.VaultWithContext = &BraintreeClient{}
+)
+
+// StorePaymentMethod stores a credit card in the Braintree vault, creating a customer if none is given
+func (client *BraintreeClient) StorePaymentMethod(request *sleet.StorePaymentMethodRequest) (*sleet.StorePaymentMethodResponse, error) {
+	return client.StorePaymentMethodWithContext(context.TODO(), request)
+}
+
+// StorePaymentMethodWithContext stores a credit card in the Braintree vault, creating a customer if none is given
+func (client *BraintreeClient) StorePaymentMethodWithContext(ctx context.Context, request *sleet.StorePaymentMethodRequest) (*sleet.StorePaymentMethodResponse, error) {
+	btClient := braintree_go.NewWithHttpClient(client.environment, client.merchantID, client.publicKey, client.privateKey, client.httpClient)
+
+	var card *braintree_go.CreditCard
+	if request.CustomerReference == "" {
+		customer, err := btClient.Customer().Create(ctx, buildStoreCustomerRequest(request))
+		if err != nil {
+			if braintreeError, ok := err.(*braintree_go.BraintreeError); ok {
+				return &sleet.StorePaymentMethodResponse{Success: false, ErrorCode: vaultErrorCode(braintreeError)}, nil
+			}
+			return nil, common.OperationError(gatewayName, sleet.OperationStorePaymentMethod, translateError(err))
+		}
+		if customer.CreditCards != nil && len(customer.CreditCards.CreditCard) > 0 {
+			card = customer.CreditCards.CreditCard[0]
+		}
+	} else {
+		created, err := btClient.CreditCard().Create(ctx, buildStoreCreditCard(request))
+		if err != nil {
+			if braintreeError, ok := err.(*braintree_go.BraintreeError); ok {
+				return &sleet.StorePaymentMethodResponse{Success: false, ErrorCode: vaultErrorCode(braintreeError)}, nil
+			}
+			return nil, common.OperationError(gatewayName, sleet.OperationStorePaymentMethod, translateError(err))
+		}
+		card = created
+	}
+
+	if card == nil {
+		return &sleet.StorePaymentMethodResponse{Success: false}, nil
+	}
+	return &sleet.StorePaymentMethodResponse{
+		Success: true,
+		StoredPaymentMethod: &sleet.StoredPaymentMethod{
+			CustomerReference: card.CustomerId,
+			Token:             card.Token,
+		},
+	}, nil
+}
+
+// GetPaymentMethod retrieves a credit card stored in the Braintree vault
+func (client *BraintreeClient) GetPaymentMethod(request *sleet.GetPaymentMethodRequest) (*sleet.GetPaymentMethodResponse, error) {
+	return client.GetPaymentMethodWithContext(context.TODO(), request)
+}
+
+// GetPaymentMethodWithContext retrieves a credit card stored in the Braintree vault
+func (client *BraintreeClient) GetPaymentMethodWithContext(ctx context.Context, request *sleet.GetPaymentMethodRequest) (*sleet.GetPaymentMethodResponse, error) {
+	btClient := braintree_go.NewWithHttpClient(client.environment, client.merchantID, client.publicKey, client.privateKey, client.httpClient)
+	card, err := btClient.CreditCard().Find(ctx, request.StoredPaymentMethod.Token)
+	if err != nil {
+		if braintreeError, ok := err.(*braintree_go.BraintreeError); ok {
+			return &sleet.GetPaymentMethodResponse{Success: false, ErrorCode: vaultErrorCode(braintreeError)}, nil
+		}
+		return nil, common.OperationError(gatewayName, sleet.OperationGetPaymentMethod, translateError(err))
+	}
+
+	// Braintree always returns the expiration of stored cards so parsing errors are not expected
+	expirationMonth, _ := strconv.Atoi(card.ExpirationMonth)
+	expirationYear, _ := strconv.Atoi(card.ExpirationYear)
+	return &sleet.GetPaymentMethodResponse{
+		Success: true,
+		StoredPaymentMethod: &sleet.StoredPaymentMethod{
+			CustomerReference: card.CustomerId,
+			Token:             card.Token,
+		},
+		Last4:           card.Last4,
+		ExpirationMonth: expirationMonth,
+		ExpirationYear:  expirationYear,
+		Network:         translateCardType(card.CardType),
+	}, nil
+}
+
+// DeletePaymentMethod deletes a credit card from the Braintree vault
+func (client *BraintreeClient) DeletePaymentMethod(request *sleet.DeletePaymentMethodRequest) (*sleet.DeletePaymentMethodResponse, error) {
+	return client.DeletePaymentMethodWithContext(context.TODO(), request)
+}
+
+// DeletePaymentMethodWithContext deletes a credit card from the Braintree vault
+func (client *BraintreeClient) DeletePaymentMethodWithContext(ctx context.Context, request *sleet.DeletePaymentMethodRequest) (*sleet.DeletePaymentMethodResponse, error) {
+	btClient := braintree_go.NewWithHttpClient(client.environment, client.merchantID, client.publicKey, client.privateKey, client.httpClient)
+	err := btClient.CreditCard().Delete(ctx, &braintree_go.CreditCard{Token: request.StoredPaymentMethod.Token})
+	if err != nil {
+		if braintreeError, ok := err.(*braintree_go.BraintreeError); ok {
+			return &sleet.DeletePaymentMethodResponse{Success: false, ErrorCode: vaultErrorCode(braintreeError)}, nil
+		}
+		return nil, common.OperationError(gatewayName, sleet.OperationDeletePaymentMethod, translateError(err))
+	}
+	return &sleet.DeletePaymentMethodResponse{Success: true}, nil
+}
+
+// vaultErrorCode returns the code of the first validation error in a Braintree error response, if it has any
+func vaultErrorCode(braintreeError *braintree_go.BraintreeError) *string {
+	validationErrors := braintreeError.All()
+	if len(validationErrors) == 0 {
+		return nil
+	}
+	return &validationErrors[0].Code
+}
@@ -0,0 +1,119 @@
+package braintree
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	braintree_go "github.com/BoltApp/braintree-go"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+)
+
+var (
+	// assert verifier interface
+	_ sleet.VerifierWithContext = &BraintreeClient{}
+)
+
+// verificationAPIVersion is the Braintree API version sent with verification requests
+const verificationAPIVersion = "6"
+
+const verificationStatusVerified = "verified"
+
+// verificationRequest verifies a card without creating a payment method.
+// The braintree-go SDK has no verification gateway, so the request is sent directly.
+type verificationRequest struct {
+	XMLName    xml.Name                 `xml:"verification"`
+	CreditCard *braintree_go.CreditCard `xml:"credit-card"`
+}
+
+type verification struct {
+	ID                           string                       `xml:"id"`
+	Status                       string                       `xml:"status"`
+	ProcessorResponseCode        string                       `xml:"processor-response-code"`
+	GatewayRejectionReason       string                       `xml:"gateway-rejection-reason"`
+	AVSErrorResponseCode         braintree_go.AVSResponseCode `xml:"avs-error-response-code"`
+	AVSPostalCodeResponseCode    braintree_go.AVSResponseCode `xml:"avs-postal-code-response-code"`
+	AVSStreetAddressResponseCode braintree_go.AVSResponseCode `xml:"avs-street-address-response-code"`
+	CVVResponseCode              braintree_go.CVVResponseCode `xml:"cvv-response-code"`
+}
+
+// verificationErrorResponse is returned for failed verifications, with the verification itself if the card was
+// declined by the processor
+type verificationErrorResponse struct {
+	Message      string        `xml:"message"`
+	Verification *verification `xml:"verification"`
+}
+
+// Verify validates a credit card with a Braintree credit card verification
+func (client *BraintreeClient) Verify(request *sleet.VerificationRequest) (*sleet.VerificationResponse, error) {
+	return client.VerifyWithContext(context.TODO(), request)
+}
+
+// VerifyWithContext validates a credit card with a Braintree credit card verification, which runs a zero amount
+// authorization where the processor supports it. Stored payment methods are verified with an authorization and void.
+func (client *BraintreeClient) VerifyWithContext(ctx context.Context, request *sleet.VerificationRequest) (*sleet.VerificationResponse, error) {
+	if request.CreditCard == nil {
+		response, err := sleet.VerifyByAuthorization(ctx, client, request)
+		return response, common.OperationError(gatewayName, sleet.OperationVerify, err)
+	}
+
+	body, err := xml.Marshal(buildVerificationRequest(request))
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationVerify, err)
+	}
+	btClient := braintree_go.NewWithHttpClient(client.environment, client.merchantID, client.publicKey, client.privateKey, client.httpClient)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, btClient.MerchantURL()+"/verifications", bytes.NewReader(body))
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationVerify, err)
+	}
+	req.Header.Set("Content-Type", "application/xml")
+	req.Header.Set("Accept", "application/xml")
+	req.Header.Set("X-ApiVersion", verificationAPIVersion)
+	req.SetBasicAuth(client.publicKey, client.privateKey)
+
+	resp, err := client.httpClient.Do(req)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationVerify, common.TransportError(gatewayName, err))
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationVerify, common.TransportError(gatewayName, err))
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		result := &verification{}
+		if err := xml.Unmarshal(respBody, result); err != nil {
+			return nil, common.OperationError(gatewayName, sleet.OperationVerify, common.ResponseError(gatewayName, resp.StatusCode, err))
+		}
+		common.LogResult(ctx, client.logger, gatewayName, result.Status, "processor_response_code", result.ProcessorResponseCode)
+		response := translateVerification(result)
+		response.StatusCode = resp.StatusCode
+		return response, nil
+	case http.StatusUnprocessableEntity:
+		result := &verificationErrorResponse{}
+		if err := xml.Unmarshal(respBody, result); err != nil {
+			return nil, common.OperationError(gatewayName, sleet.OperationVerify, common.ResponseError(gatewayName, resp.StatusCode, err))
+		}
+		// validation errors are returned without a verification
+		if result.Verification == nil {
+			return &sleet.VerificationResponse{AuthorizationResponse: sleet.AuthorizationResponse{
+				StatusCode: resp.StatusCode,
+				Message:    result.Message,
+				ResultType: sleet.ResultTypeAPIError,
+			}}, nil
+		}
+		common.LogResult(ctx, client.logger, gatewayName, result.Verification.Status, "processor_response_code", result.Verification.ProcessorResponseCode)
+		response := translateVerification(result.Verification)
+		response.StatusCode = resp.StatusCode
+		return response, nil
+	}
+	return nil, common.OperationError(gatewayName, sleet.OperationVerify, common.ResponseError(gatewayName, resp.StatusCode,
+		fmt.Errorf("braintree: verification failed with status %d", resp.StatusCode)))
+}
@@ -0,0 +1,84 @@
+//go:build unit
+// +build unit
+
+package braintree
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/jarcoal/httpmock"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+	sleet_t "github.com/BoltApp/sleet/testing"
+)
+
+func TestVerify(t *testing.T) {
+	helper := sleet_t.NewTestHelper(t)
+	url := "https://api.sandbox.braintreegateway.com:443/merchants/MerchantID/verifications"
+	request := sleet_t.BaseAuthorizationRequest()
+
+	t.Run("With Verified Card", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		httpmock.RegisterResponder("POST", url, func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewBytesResponse(http.StatusCreated, helper.ReadFile("test_data/verificationResponse.xml")), nil
+		})
+
+		want := &sleet.VerificationResponse{AuthorizationResponse: sleet.AuthorizationResponse{
+			Success:              true,
+			TransactionReference: "8nbqbg7m",
+			Response:             "1000",
+			AvsResult:            sleet.AVSresponseZipMatchAddressMatch,
+			CvvResult:            sleet.CVVResponseMatch,
+			AvsResultRaw:         ":M:M",
+			CvvResultRaw:         "M",
+			StatusCode:           http.StatusCreated,
+			ResultType:           sleet.ResultTypeSuccess,
+		}}
+
+		client := NewWithHttpClient("MerchantID", "PublicKey", "PrivateKey", common.Sandbox, &http.Client{})
+		got, err := client.Verify(request)
+		if err != nil {
+			t.Fatalf("Error thrown after sending request %q", err)
+		}
+		if diff := deep.Equal(got, want); diff != nil {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("With Declined Card", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		httpmock.RegisterResponder("POST", url, func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewBytesResponse(http.StatusUnprocessableEntity, helper.ReadFile("test_data/verificationDeclineResponse.xml")), nil
+		})
+
+		want := &sleet.VerificationResponse{AuthorizationResponse: sleet.AuthorizationResponse{
+			Success:              false,
+			TransactionReference: "4bymw8ma",
+			Response:             "2000",
+			AvsResult:            sleet.AVSResponseZipNoMatchAddressMatch,
+			CvvResult:            sleet.CVVResponseNoMatch,
+			AvsResultRaw:         ":M:N",
+			CvvResultRaw:         "N",
+			ErrorCode:            "2000",
+			StatusCode:           http.StatusUnprocessableEntity,
+			ResultType:           sleet.ResultTypePaymentError,
+			DeclineReason:        sleet.DeclineReasonDoNotHonor,
+		}}
+
+		client := NewWithHttpClient("MerchantID", "PublicKey", "PrivateKey", common.Sandbox, &http.Client{})
+		got, err := client.Verify(request)
+		if err != nil {
+			t.Fatalf("Error thrown after sending request %q", err)
+		}
+		if diff := deep.Equal(got, want); diff != nil {
+			t.Error(diff)
+		}
+	})
+}
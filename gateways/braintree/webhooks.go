@@ -0,0 +1,103 @@
+package braintree
+
+import (
+	"net/http"
+	"net/url"
+
+	braintree_go "github.com/BoltApp/braintree-go"
+
+	"github.com/BoltApp/sleet/common"
+	"github.com/BoltApp/sleet/webhooks"
+)
+
+const (
+	signatureField = "bt_signature"
+	payloadField   = "bt_payload"
+)
+
+var (
+	// assert parser interface
+	_ webhooks.Parser = &WebhookParser{}
+)
+
+// WebhookParser verifies and translates Braintree webhook notifications.
+// Braintree posts notifications as a form with a signed base64 encoded payload.
+type WebhookParser struct {
+	gateway *braintree_go.Braintree
+}
+
+// NewWebhookParser creates a parser with the same creds used by the Braintree client
+func NewWebhookParser(merchantID string, publicKey string, privateKey string, environment common.Environment) *WebhookParser {
+	return &WebhookParser{
+		gateway: braintree_go.New(braintreeEnvironment(environment), merchantID, publicKey, privateKey),
+	}
+}
+
+// Parse verifies the signature of the form encoded body and translates the notification into a webhook event
+func (p *WebhookParser) Parse(header http.Header, body []byte) ([]webhooks.WebhookEvent, error) {
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, err
+	}
+	signature := form.Get(signatureField)
+	if signature == "" {
+		return nil, webhooks.ErrMissingSignature
+	}
+
+	notification, err := p.gateway.WebhookNotification().Parse(signature, form.Get(payloadField))
+	if err != nil {
+		if _, ok := err.(braintree_go.SignatureError); ok {
+			return nil, webhooks.ErrInvalidSignature
+		}
+		return nil, err
+	}
+
+	event, err := translateNotification(notification)
+	if err != nil {
+		return nil, err
+	}
+	event.RawPayload = body
+	return []webhooks.WebhookEvent{*event}, nil
+}
+
+func translateNotification(notification *braintree_go.WebhookNotification) (*webhooks.WebhookEvent, error) {
+	event := &webhooks.WebhookEvent{
+		Kind:         webhooks.EventKindUnknown,
+		PsPEventType: notification.Kind,
+	}
+
+	switch notification.Kind {
+	case braintree_go.TransactionSettledWebhook, braintree_go.TransactionSettlementDeclinedWebhook,
+		braintree_go.TransactionDisbursedWebhook:
+		event.Kind = webhooks.EventKindSettlement
+		event.Success = notification.Kind != braintree_go.TransactionSettlementDeclinedWebhook
+		if transaction := notification.Subject.Transaction; transaction != nil {
+			event.TransactionReference = transaction.Id
+			event.MerchantReference = transaction.OrderId
+			if transaction.Amount != nil {
+				amount, err := convertFromBraintreeDecimal(transaction.Amount, transaction.CurrencyISOCode)
+				if err != nil {
+					return nil, err
+				}
+				event.Amount = amount
+			}
+		}
+	case braintree_go.DisputeOpenedWebhook:
+		event.Kind = webhooks.EventKindChargeback
+		event.Success = true
+		if dispute := notification.Dispute(); dispute != nil {
+			if dispute.Transaction != nil {
+				event.TransactionReference = dispute.Transaction.ID
+				event.MerchantReference = dispute.Transaction.OrderID
+			}
+			if dispute.AmountDisputed != nil {
+				amount, err := convertFromBraintreeDecimal(dispute.AmountDisputed, dispute.CurrencyISOCode)
+				if err != nil {
+					return nil, err
+				}
+				event.Amount = amount
+			}
+		}
+	}
+	return event, nil
+}
@@ -0,0 +1,92 @@
+//go:build unit
+// +build unit
+
+package braintree
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	braintree_go "github.com/BoltApp/braintree-go"
+	"github.com/go-test/deep"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+	"github.com/BoltApp/sleet/webhooks"
+)
+
+func buildNotificationBody(t *testing.T, kind string, publicKey string, privateKey string) []byte {
+	webhookTesting := braintree_go.New(braintree_go.Sandbox, "merchant", publicKey, privateKey).WebhookTesting()
+	payload := webhookTesting.SamplePayload(kind, "txn_1")
+	signature, err := webhookTesting.SignPayload(payload)
+	if err != nil {
+		t.Fatalf("error signing payload: %s", err)
+	}
+	return []byte(url.Values{signatureField: {signature}, payloadField: {payload}}.Encode())
+}
+
+func TestWebhookParserParse(t *testing.T) {
+	body := buildNotificationBody(t, braintree_go.TransactionSettledWebhook, "public", "private")
+	got, err := NewWebhookParser("merchant", "public", "private", common.Sandbox).Parse(http.Header{}, body)
+	if err != nil {
+		t.Fatalf("error parsing notification: %s", err)
+	}
+
+	want := []webhooks.WebhookEvent{
+		{
+			Kind:                 webhooks.EventKindSettlement,
+			PsPEventType:         braintree_go.TransactionSettledWebhook,
+			TransactionReference: "txn_1",
+			Amount:               &sleet.Amount{Amount: 10000, Currency: "USD"},
+			Success:              true,
+			RawPayload:           body,
+		},
+	}
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestWebhookParserParseInvalidSignature(t *testing.T) {
+	cases := []struct {
+		label string
+		body  []byte
+		want  error
+	}{
+		{"Missing signature", []byte(url.Values{payloadField: {"payload"}}.Encode()), webhooks.ErrMissingSignature},
+		{"Wrong private key", buildNotificationBody(t, braintree_go.TransactionSettledWebhook, "public", "other"), webhooks.ErrInvalidSignature},
+		{"Wrong public key", buildNotificationBody(t, braintree_go.TransactionSettledWebhook, "other", "private"), webhooks.ErrInvalidSignature},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			_, err := NewWebhookParser("merchant", "public", "private", common.Sandbox).Parse(http.Header{}, c.body)
+			if err != c.want {
+				t.Errorf("expected %v, got %v", c.want, err)
+			}
+		})
+	}
+}
+
+func TestConvertFromBraintreeDecimal(t *testing.T) {
+	cases := []struct {
+		in       *braintree_go.Decimal
+		currency string
+		want     int64
+	}{
+		{braintree_go.NewDecimal(10000, 2), "USD", 10000},
+		{braintree_go.NewDecimal(100, 0), "USD", 10000},
+		{braintree_go.NewDecimal(1000, 1), "JPY", 100},
+	}
+
+	for _, c := range cases {
+		got, err := convertFromBraintreeDecimal(c.in, c.currency)
+		if err != nil {
+			t.Fatalf("error converting amount: %s", err)
+		}
+		if got.Amount != c.want {
+			t.Errorf("expected %d, got %d", c.want, got.Amount)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/base64"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -13,23 +14,32 @@ import (
 	"github.com/BoltApp/sleet/common"
 )
 
+const gatewayName = "cardconnect"
+
 var (
 	// assert client interface
-	_ sleet.ClientWithContext = &CardConnectClient{}
+	_ sleet.ClientWithContext             = &CardConnectClient{}
+	_ sleet.TransactionQuerierWithContext = &CardConnectClient{}
+	_ sleet.SaleWithContext               = &CardConnectClient{}
+	_ sleet.VerifierWithContext           = &CardConnectClient{}
+	_ sleet.CapabilitiesReporter          = &CardConnectClient{}
 )
 
-func NewClient(username string, password string, merchantID string, URL string, environment common.Environment) *CardConnectClient {
-	return NewWithHttpClient(username, password, merchantID, URL, environment, common.DefaultHttpClient())
+func NewClient(username string, password string, merchantID string, URL string, environment common.Environment, options ...sleet.ClientOption) *CardConnectClient {
+	return NewWithHttpClient(username, password, merchantID, URL, environment, common.DefaultHttpClient(), options...)
 }
 
 // NewWithHttpClient uses authentication with custom http client
-func NewWithHttpClient(username string, password string, merchantID string, URL string, environment common.Environment, httpClient *http.Client) *CardConnectClient {
+func NewWithHttpClient(username string, password string, merchantID string, URL string, environment common.Environment, httpClient *http.Client, options ...sleet.ClientOption) *CardConnectClient {
+	clientOptions := sleet.NewClientOptions(options...)
 	return &CardConnectClient{
-		httpClient: httpClient,
+		httpClient: common.LoggingHttpClient(httpClient, gatewayName, clientOptions.Logger),
+		logger:     clientOptions.Logger,
 		username:   username,
 		password:   password,
 		merchantID: merchantID,
 		URL:        normalizeURL(URL),
+		baseURL:    clientOptions.BaseURL,
 	}
 }
 
@@ -42,7 +52,7 @@ func (client *CardConnectClient) buildURL(path string) (string, error) {
 	url.Path = path
 	url.Scheme = "https"
 
-	return url.String(), nil
+	return common.OverrideBaseURL(url.String(), client.baseURL), nil
 }
 
 func normalizeURL(URL string) string {
@@ -54,18 +64,38 @@ func normalizeURL(URL string) string {
 }
 
 func (client *CardConnectClient) sendRequest(ctx context.Context, request *Request, path string) (*Response, *http.Response, error) {
+	return client.sendRequestWithMethod(ctx, http.MethodPost, request, path)
+}
+
+func (client *CardConnectClient) sendRequestWithMethod(ctx context.Context, method string, request *Request, path string) (*Response, *http.Response, error) {
 	request.MerchantID = client.merchantID
 
 	data, err := request.Marshal()
 	if err != nil {
 		return nil, nil, err
 	}
+
+	bodyText, resp, err := client.do(ctx, method, path, bytes.NewReader(data))
+	if err != nil {
+		return nil, resp, err
+	}
+
+	response, err := UnmarshalResponse(bodyText)
+	if err != nil {
+		return nil, resp, common.ResponseError(gatewayName, resp.StatusCode, err)
+	}
+
+	common.LogResult(ctx, client.logger, gatewayName, response.RespCode, "response_status", response.RespStat)
+	return &response, resp, nil
+}
+
+func (client *CardConnectClient) do(ctx context.Context, method string, path string, body io.Reader) ([]byte, *http.Response, error) {
 	url, err := client.buildURL(path)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(data))
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -78,22 +108,28 @@ func (client *CardConnectClient) sendRequest(ctx context.Context, request *Reque
 
 	resp, err := client.httpClient.Do(req)
 	if err != nil {
-		return nil, resp, err
+		return nil, resp, common.TransportError(gatewayName, err)
 	}
 
 	defer resp.Body.Close()
 
 	bodyText, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, resp, err
+		return nil, resp, common.TransportError(gatewayName, err)
 	}
+	return bodyText, resp, nil
+}
 
-	response, err := UnmarshalResponse(bodyText)
-	if err != nil {
-		return nil, resp, err
+// Capabilities describes the optional operations supported by CardConnect
+func (client *CardConnectClient) Capabilities() sleet.Capabilities {
+	return sleet.Capabilities{
+		ContextSupport:   true,
+		Sale:             true,
+		Vault:            true,
+		TransactionQuery: true,
+		PartialCapture:   true,
+		PartialRefund:    true,
 	}
-
-	return &response, resp, nil
 }
 
 // Authorize a transaction. This transaction must be captured to receive funds
@@ -103,13 +139,46 @@ func (client *CardConnectClient) Authorize(request *sleet.AuthorizationRequest)
 
 // AuthorizeWithContext authorizes a transaction. This transaction must be captured to receive funds
 func (client *CardConnectClient) AuthorizeWithContext(ctx context.Context, request *sleet.AuthorizationRequest) (*sleet.AuthorizationResponse, error) {
-	response, httpResponse, err := client.sendRequest(ctx, buildAuthorizeParams(request), AuthorizePath)
+	response, err := client.sendAuthRequest(ctx, buildAuthorizeParams(request), request.Options)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationAuthorize, err)
+	}
+	return response, nil
+}
+
+// Sale authorizes and captures a transaction in a single call
+func (client *CardConnectClient) Sale(request *sleet.SaleRequest) (*sleet.SaleResponse, error) {
+	return client.SaleWithContext(context.TODO(), request)
+}
+
+// SaleWithContext authorizes and captures a transaction in a single call
+func (client *CardConnectClient) SaleWithContext(ctx context.Context, request *sleet.SaleRequest) (*sleet.SaleResponse, error) {
+	resp, err := client.sendAuthRequest(ctx, buildSaleParams(request), request.Options)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationSale, err)
+	}
+	return &sleet.SaleResponse{AuthorizationResponse: *resp}, nil
+}
+
+// Verify checks a card by authorizing and voiding a transaction
+func (client *CardConnectClient) Verify(request *sleet.VerificationRequest) (*sleet.VerificationResponse, error) {
+	return client.VerifyWithContext(context.TODO(), request)
+}
+
+// VerifyWithContext checks a card by authorizing and voiding a transaction
+func (client *CardConnectClient) VerifyWithContext(ctx context.Context, request *sleet.VerificationRequest) (*sleet.VerificationResponse, error) {
+	response, err := sleet.VerifyByAuthorization(ctx, client, request)
+	return response, common.OperationError(gatewayName, sleet.OperationVerify, err)
+}
+
+func (client *CardConnectClient) sendAuthRequest(ctx context.Context, request *Request, options map[string]interface{}) (*sleet.AuthorizationResponse, error) {
+	response, httpResponse, err := client.sendRequest(ctx, request, AuthorizePath)
 	if err != nil {
 		return nil, err
 	}
 
-	responseHeader := sleet.GetHTTPResponseHeader(request.Options, *httpResponse)
-	if httpResponse.StatusCode == http.StatusOK && response.RespStat == "A" {
+	responseHeader := sleet.GetHTTPResponseHeader(options, *httpResponse)
+	if httpResponse.StatusCode == http.StatusOK && response.RespStat == respStatApproved {
 		return &sleet.AuthorizationResponse{
 			Success:               true,
 			TransactionReference:  response.RetRef,
@@ -120,13 +189,17 @@ func (client *CardConnectClient) AuthorizeWithContext(ctx context.Context, reque
 			CvvResultRaw:          response.CVVResp,
 			CvvResult:             translateCvv(response.CVVResp),
 			ExternalTransactionID: response.RetRef,
+			ResultType:            sleet.ResultTypeSuccess,
 		}, nil
 	}
 
+	resultType := translateResultType(response.RespStat, response.RespProc, httpResponse.StatusCode)
 	return &sleet.AuthorizationResponse{
-		ErrorCode:  response.RespCode,
-		StatusCode: httpResponse.StatusCode,
-		Header:     responseHeader,
+		ErrorCode:     response.RespCode,
+		ResultType:    resultType,
+		DeclineReason: translateDeclineReason(response.RespProc, response.RespCode, resultType),
+		StatusCode:    httpResponse.StatusCode,
+		Header:        responseHeader,
 	}, nil
 }
 
@@ -139,18 +212,28 @@ func (client *CardConnectClient) Capture(request *sleet.CaptureRequest) (*sleet.
 func (client *CardConnectClient) CaptureWithContext(ctx context.Context, request *sleet.CaptureRequest) (*sleet.CaptureResponse, error) {
 	response, httpResponse, err := client.sendRequest(ctx, buildCaptureParams(request), CapturePath)
 	if err != nil {
-		return nil, err
+		return nil, common.OperationError(gatewayName, sleet.OperationCapture, err)
 	}
 
-	if httpResponse.StatusCode == http.StatusOK && response.RespStat == "A" {
+	responseHeader := sleet.GetHTTPResponseHeader(request.Options, *httpResponse)
+	if httpResponse.StatusCode == http.StatusOK && response.RespStat == respStatApproved {
 		return &sleet.CaptureResponse{
 			Success:              true,
 			TransactionReference: response.RetRef,
+			ResultType:           sleet.ResultTypeSuccess,
+			Amount:               translateAmount(response, request.Amount),
+			Metadata:             buildResponseMetadata(response),
+			StatusCode:           httpResponse.StatusCode,
+			Header:               responseHeader,
 		}, nil
 	}
 
 	return &sleet.CaptureResponse{
-		ErrorCode: &response.RespCode,
+		ErrorCode:  &response.RespCode,
+		Message:    response.RespText,
+		ResultType: translateResultType(response.RespStat, response.RespProc, httpResponse.StatusCode),
+		StatusCode: httpResponse.StatusCode,
+		Header:     responseHeader,
 	}, nil
 }
 
@@ -163,17 +246,26 @@ func (client *CardConnectClient) Void(request *sleet.VoidRequest) (*sleet.VoidRe
 func (client *CardConnectClient) VoidWithContext(ctx context.Context, request *sleet.VoidRequest) (*sleet.VoidResponse, error) {
 	response, httpResponse, err := client.sendRequest(ctx, buildVoidParams(request), VoidPath)
 	if err != nil {
-		return nil, err
+		return nil, common.OperationError(gatewayName, sleet.OperationVoid, err)
 	}
 
-	if httpResponse.StatusCode == http.StatusOK && response.RespStat == "A" {
+	responseHeader := sleet.GetHTTPResponseHeader(request.Options, *httpResponse)
+	if httpResponse.StatusCode == http.StatusOK && response.RespStat == respStatApproved {
 		return &sleet.VoidResponse{
-			Success: true,
+			Success:    true,
+			ResultType: sleet.ResultTypeSuccess,
+			Metadata:   buildResponseMetadata(response),
+			StatusCode: httpResponse.StatusCode,
+			Header:     responseHeader,
 		}, nil
 	}
 
 	return &sleet.VoidResponse{
-		ErrorCode: &response.RespCode,
+		ErrorCode:  &response.RespCode,
+		Message:    response.RespText,
+		ResultType: translateResultType(response.RespStat, response.RespProc, httpResponse.StatusCode),
+		StatusCode: httpResponse.StatusCode,
+		Header:     responseHeader,
 	}, nil
 }
 
@@ -186,16 +278,57 @@ func (client *CardConnectClient) Refund(request *sleet.RefundRequest) (*sleet.Re
 func (client *CardConnectClient) RefundWithContext(ctx context.Context, request *sleet.RefundRequest) (*sleet.RefundResponse, error) {
 	response, httpResponse, err := client.sendRequest(ctx, buildRefundParams(request), RefundPath)
 	if err != nil {
-		return nil, err
+		return nil, common.OperationError(gatewayName, sleet.OperationRefund, err)
 	}
 
-	if httpResponse.StatusCode == http.StatusOK && response.RespStat == "A" {
+	responseHeader := sleet.GetHTTPResponseHeader(request.Options, *httpResponse)
+	if httpResponse.StatusCode == http.StatusOK && response.RespStat == respStatApproved {
 		return &sleet.RefundResponse{
-			Success: true,
+			Success:              true,
+			TransactionReference: response.RetRef,
+			ResultType:           sleet.ResultTypeSuccess,
+			Amount:               translateAmount(response, request.Amount),
+			Metadata:             buildResponseMetadata(response),
+			StatusCode:           httpResponse.StatusCode,
+			Header:               responseHeader,
 		}, nil
 	}
 
 	return &sleet.RefundResponse{
-		ErrorCode: &response.RespCode,
+		ErrorCode:  &response.RespCode,
+		Message:    response.RespText,
+		ResultType: translateResultType(response.RespStat, response.RespProc, httpResponse.StatusCode),
+		StatusCode: httpResponse.StatusCode,
+		Header:     responseHeader,
 	}, nil
 }
+
+// QueryTransaction retrieves the status of a transaction by retref
+func (client *CardConnectClient) QueryTransaction(request *sleet.TransactionQueryRequest) (*sleet.TransactionQueryResponse, error) {
+	return client.QueryTransactionWithContext(context.TODO(), request)
+}
+
+// QueryTransactionWithContext retrieves the status of a transaction by retref
+func (client *CardConnectClient) QueryTransactionWithContext(ctx context.Context, request *sleet.TransactionQueryRequest) (*sleet.TransactionQueryResponse, error) {
+	bodyText, httpResponse, err := client.do(ctx, http.MethodGet, buildInquirePath(request, client.merchantID), nil)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationQueryTransaction, err)
+	}
+
+	response, err := UnmarshalResponse(bodyText)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationQueryTransaction, common.ResponseError(gatewayName, httpResponse.StatusCode, err))
+	}
+
+	if httpResponse.StatusCode != http.StatusOK || response.RespStat != respStatApproved {
+		return &sleet.TransactionQueryResponse{
+			ErrorCode: &response.RespCode,
+		}, nil
+	}
+
+	queryResponse, err := translateInquireResponse(&response)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationQueryTransaction, common.ResponseError(gatewayName, httpResponse.StatusCode, err))
+	}
+	return queryResponse, nil
+}
@@ -0,0 +1,34 @@
+//go:build unit
+// +build unit
+
+package cardconnect
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+	"github.com/BoltApp/sleet/testing/conformance"
+	"github.com/BoltApp/sleet/testing/fakes"
+)
+
+func TestConformance(t *testing.T) {
+	fake := fakes.NewCardConnect()
+	defer fake.Close()
+
+	conformance.Run(t, conformance.Suite{
+		Gateway:   "cardconnect",
+		Transport: fake.Client().Transport,
+		NewClient: func(httpClient *http.Client) sleet.ClientWithContext {
+			return NewWithHttpClient(fakes.CardConnectUsername, fakes.CardConnectPassword, fakes.CardConnectMerchantID, fakes.CardConnectURL, common.Sandbox, httpClient)
+		},
+		// the UAT environment declines amounts from $1000.00 to $1999.99
+		Decline: func(request *sleet.AuthorizationRequest) { request.Amount.Amount = 100000 },
+		AVS:     sleet.AVSResponseMatch,
+		CVV:     sleet.CVVResponseMatch,
+		Skip: map[conformance.Behavior]string{
+			conformance.BehaviorRefund: "CardPointe refunds settled transactions, which UAT never settles",
+		},
+	})
+}
@@ -0,0 +1,29 @@
+package cardconnect
+
+import (
+	"net/http"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/registry"
+)
+
+// CardConnect credential keys of a registry.Config, the URL is the merchant's CardPointe site
+const (
+	credentialUsername   = "username"
+	credentialPassword   = "password"
+	credentialMerchantID = "merchant_id"
+	credentialURL        = "url"
+)
+
+func init() {
+	registry.Register("cardconnect", newRegistryClient)
+}
+
+// newRegistryClient builds a CardConnectClient from a registry config
+func newRegistryClient(config *registry.Config, httpClient *http.Client, options ...sleet.ClientOption) (sleet.ClientWithContext, error) {
+	credentials, err := config.RequiredCredentials(credentialUsername, credentialPassword, credentialMerchantID, credentialURL)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithHttpClient(credentials[0], credentials[1], credentials[2], credentials[3], config.Environment, httpClient, options...), nil
+}
@@ -14,7 +14,6 @@ var (
 )
 
 func buildAuthorizeParams(request *sleet.AuthorizationRequest) *Request {
-	expirationDate := fmt.Sprintf("%02d%02d", request.CreditCard.ExpirationMonth, request.CreditCard.ExpirationYear%100)
 	amount := sleet.AmountToDecimalString(&request.Amount)
 
 	var COF *string = nil
@@ -40,28 +39,87 @@ func buildAuthorizeParams(request *sleet.AuthorizationRequest) *Request {
 		}
 	}
 
-	name := request.CreditCard.FirstName + " " + request.CreditCard.LastName
-
-	return &Request{
+	cardConnectRequest := &Request{
 		Amount:       &amount,
-		Expiry:       &expirationDate,
-		Account:      &request.CreditCard.Number,
-		CVV2:         &request.CreditCard.CVV,
 		COF:          COF,
 		COFScheduled: COFScheduled,
 		Currency:     &request.Amount.Currency,
 		OrderID:      &request.MerchantOrderReference,
-		Name:         &name,
-		Region:       request.BillingAddress.RegionCode,
-		Country:      request.BillingAddress.CountryCode,
-		City:         request.BillingAddress.Locality,
-		Company:      request.BillingAddress.Company,
-		Address:      request.BillingAddress.StreetAddress1,
-		Address2:     request.BillingAddress.StreetAddress2,
-		Postal:       request.BillingAddress.PostalCode,
-		Phone:        request.BillingAddress.PhoneNumber,
-		Email:        request.BillingAddress.Email,
 	}
+	if request.BillingAddress != nil {
+		addBillingAddress(cardConnectRequest, request.BillingAddress)
+	}
+
+	if request.StoredPaymentMethod != nil {
+		profile := buildProfile(request.StoredPaymentMethod)
+		cardConnectRequest.Profile = &profile
+		return cardConnectRequest
+	}
+
+	expirationDate := buildExpiry(request.CreditCard)
+	name := request.CreditCard.FirstName + " " + request.CreditCard.LastName
+	cardConnectRequest.Expiry = &expirationDate
+	cardConnectRequest.Account = &request.CreditCard.Number
+	cardConnectRequest.CVV2 = &request.CreditCard.CVV
+	cardConnectRequest.Name = &name
+	return cardConnectRequest
+}
+
+// buildStoreProfileParams creates a profile, or adds an account to an existing one if CustomerReference is set
+func buildStoreProfileParams(request *sleet.StorePaymentMethodRequest) *Request {
+	expirationDate := buildExpiry(request.CreditCard)
+	name := request.CreditCard.FirstName + " " + request.CreditCard.LastName
+
+	cardConnectRequest := &Request{
+		Account: &request.CreditCard.Number,
+		Expiry:  &expirationDate,
+		Name:    &name,
+	}
+	if request.CustomerReference != "" {
+		cardConnectRequest.Profile = &request.CustomerReference
+		cardConnectRequest.ProfileUpdate = &YES
+	}
+	if request.BillingAddress != nil {
+		addBillingAddress(cardConnectRequest, request.BillingAddress)
+	}
+	return cardConnectRequest
+}
+
+// buildProfilePath returns the path of a stored account, as used by the get and delete profile endpoints
+func buildProfilePath(storedPaymentMethod sleet.StoredPaymentMethod, merchantID string) string {
+	return fmt.Sprintf("%s/%s/%s/%s", ProfilePath, storedPaymentMethod.CustomerReference, storedPaymentMethod.Token, merchantID)
+}
+
+// buildInquirePath returns the path of the inquire service for a transaction
+func buildInquirePath(request *sleet.TransactionQueryRequest, merchantID string) string {
+	return fmt.Sprintf("%s/%s/%s", InquirePath, request.TransactionReference, merchantID)
+}
+
+// buildProfile returns the profileid/acctid reference used to authorize a stored account
+func buildProfile(storedPaymentMethod *sleet.StoredPaymentMethod) string {
+	return storedPaymentMethod.CustomerReference + "/" + storedPaymentMethod.Token
+}
+
+func buildExpiry(card *sleet.CreditCard) string {
+	return fmt.Sprintf("%02d%02d", card.ExpirationMonth, card.ExpirationYear%100)
+}
+
+func addBillingAddress(cardConnectRequest *Request, billingAddress *sleet.Address) {
+	cardConnectRequest.Region = billingAddress.RegionCode
+	cardConnectRequest.Country = billingAddress.CountryCode
+	cardConnectRequest.City = billingAddress.Locality
+	cardConnectRequest.Company = billingAddress.Company
+	cardConnectRequest.Address = billingAddress.StreetAddress1
+	cardConnectRequest.Address2 = billingAddress.StreetAddress2
+	cardConnectRequest.Postal = billingAddress.PostalCode
+	cardConnectRequest.Phone = billingAddress.PhoneNumber
+	cardConnectRequest.Email = billingAddress.Email
+}
+
+func buildSaleParams(request *sleet.SaleRequest) *Request {
+	params := buildAuthorizeParams(request)
+	params.Capture = &YES
+	return params
 }
 
 func buildCaptureParams(request *sleet.CaptureRequest) *Request {
@@ -39,6 +39,9 @@ func TestBuildAuthRequest(t *testing.T) {
 	mastercardName := applepayBase.CreditCard.FirstName + " " + applepayBase.CreditCard.LastName
 	applepayName := applepayBase.CreditCard.FirstName + " " + applepayBase.CreditCard.LastName
 
+	storedBase := sleet_testing.BaseStoredPaymentMethodAuthorizationRequest()
+	storedProfile := "333333/444444"
+
 	cases := []struct {
 		label string
 		in    *sleet.AuthorizationRequest
@@ -120,6 +123,22 @@ func TestBuildAuthRequest(t *testing.T) {
 				Country:  applepayBase.BillingAddress.CountryCode,
 			},
 		},
+		{
+			"Auth with stored profile",
+			storedBase,
+			Request{
+				Amount:   &defaultTestAmount,
+				Currency: &storedBase.Amount.Currency,
+				OrderID:  &storedBase.MerchantOrderReference,
+				Profile:  &storedProfile,
+				Region:   storedBase.BillingAddress.RegionCode,
+				Address:  storedBase.BillingAddress.StreetAddress1,
+				Address2: storedBase.BillingAddress.StreetAddress2,
+				City:     storedBase.BillingAddress.Locality,
+				Postal:   storedBase.BillingAddress.PostalCode,
+				Country:  storedBase.BillingAddress.CountryCode,
+			},
+		},
 	}
 
 	for _, c := range cases {
@@ -132,6 +151,18 @@ func TestBuildAuthRequest(t *testing.T) {
 	}
 }
 
+func TestBuildSaleRequest(t *testing.T) {
+	base := sleet_testing.BaseAuthorizationRequest()
+
+	want := buildAuthorizeParams(base)
+	want.Capture = &YES
+
+	got := buildSaleParams(base)
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Error(diff)
+	}
+}
+
 func TestBuildCaptureRequest(t *testing.T) {
 	base := sleet_testing.BaseCaptureRequest()
 	cases := []struct {
@@ -213,3 +244,61 @@ func TestBuildRefundRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildStoreProfileRequest(t *testing.T) {
+	base := sleet_testing.BaseStorePaymentMethodRequest()
+	existingProfile := *sleet_testing.BaseStorePaymentMethodRequest()
+	existingProfile.CustomerReference = "333333"
+
+	name := base.CreditCard.FirstName + " " + base.CreditCard.LastName
+
+	cases := []struct {
+		label string
+		in    *sleet.StorePaymentMethodRequest
+		want  Request
+	}{
+		{
+			"New profile",
+			base,
+			Request{
+				Account:  &base.CreditCard.Number,
+				Expiry:   &defaultTestExpirationDate,
+				Name:     &name,
+				Region:   base.BillingAddress.RegionCode,
+				Address:  base.BillingAddress.StreetAddress1,
+				Address2: base.BillingAddress.StreetAddress2,
+				City:     base.BillingAddress.Locality,
+				Postal:   base.BillingAddress.PostalCode,
+				Country:  base.BillingAddress.CountryCode,
+				Email:    base.BillingAddress.Email,
+			},
+		},
+		{
+			"Existing profile",
+			&existingProfile,
+			Request{
+				Account:       &base.CreditCard.Number,
+				Expiry:        &defaultTestExpirationDate,
+				Name:          &name,
+				Region:        base.BillingAddress.RegionCode,
+				Address:       base.BillingAddress.StreetAddress1,
+				Address2:      base.BillingAddress.StreetAddress2,
+				City:          base.BillingAddress.Locality,
+				Postal:        base.BillingAddress.PostalCode,
+				Country:       base.BillingAddress.CountryCode,
+				Email:         base.BillingAddress.Email,
+				Profile:       &existingProfile.CustomerReference,
+				ProfileUpdate: &YES,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			got := buildStoreProfileParams(c.in)
+			if diff := deep.Equal(got, &c.want); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
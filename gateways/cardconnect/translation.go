@@ -1,6 +1,9 @@
 package cardconnect
 
-import "github.com/BoltApp/sleet"
+import (
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+)
 
 // Codes taken from: https://developer.cardpointe.com/cardconnect-api#authorization-response
 var cvvMap = map[string]sleet.CVVResponse{
@@ -46,3 +49,168 @@ func translateAvs(rawAvs string) sleet.AVSResponse {
 	}
 	return sleetCode
 }
+
+// Account types returned by the profile service
+var acctTypeMap = map[string]sleet.CreditCardNetwork{
+	"VISA": sleet.CreditCardNetworkVisa,
+	"MC":   sleet.CreditCardNetworkMastercard,
+	"AMEX": sleet.CreditCardNetworkAmex,
+	"DISC": sleet.CreditCardNetworkDiscover,
+	"JCB":  sleet.CreditCardNetworkJcb,
+}
+
+// Settlement statuses returned by the inquire service
+var setlStatMap = map[string]sleet.TransactionState{
+	"Authorized":         sleet.TransactionStateAuthorized,
+	"Queued for Capture": sleet.TransactionStateCaptured,
+	"Accepted":           sleet.TransactionStateSettled,
+	"Voided":             sleet.TransactionStateVoided,
+	"Rejected":           sleet.TransactionStateDeclined,
+	"Declined":           sleet.TransactionStateDeclined,
+}
+
+// translateInquireResponse converts an inquire response to a Sleet transaction query response.
+// Refunds have their own retref in CardConnect, so refunded amounts are not reported on the original transaction.
+func translateInquireResponse(response *Response) (*sleet.TransactionQueryResponse, error) {
+	var currency string
+	if response.Currency != nil {
+		currency = *response.Currency
+	}
+	state, ok := setlStatMap[common.SafeStr(response.SetlStat)]
+	if !ok {
+		state = sleet.TransactionStateUnknown
+	}
+	queryResponse := &sleet.TransactionQueryResponse{
+		Success:              true,
+		TransactionReference: response.RetRef,
+		State:                state,
+		StateRaw:             common.SafeStr(response.SetlStat),
+		Currency:             currency,
+		Network:              acctTypeMap[response.AcctType],
+	}
+	if response.Amount != "" {
+		amount, err := common.AmountFromDecimalString(response.Amount, currency)
+		if err != nil {
+			return nil, err
+		}
+		queryResponse.AuthorizedAmount = amount
+		if state == sleet.TransactionStateCaptured || state == sleet.TransactionStateSettled {
+			queryResponse.CapturedAmount = amount
+		}
+	}
+	// the account is a CardSecure token which keeps the last 4 digits of the card
+	if len(response.Account) >= 4 {
+		queryResponse.Last4 = response.Account[len(response.Account)-4:]
+	}
+	return queryResponse, nil
+}
+
+// translateAmount converts the amount of a capture or refund response, falling back to the requested amount when
+// CardConnect doesn't return it or its currency is unknown.
+func translateAmount(response *Response, requested *sleet.Amount) *sleet.Amount {
+	currency := common.SafeStr(response.Currency)
+	if requested != nil {
+		currency = requested.Currency
+	}
+	if response.Amount == "" || currency == "" {
+		return requested
+	}
+	amount, err := common.AmountFromDecimalString(response.Amount, currency)
+	if err != nil {
+		return requested
+	}
+	return &sleet.Amount{Amount: amount, Currency: currency}
+}
+
+// buildResponseMetadata keeps the authorization code and settlement batch of a response
+func buildResponseMetadata(response *Response) map[string]string {
+	metadata := make(map[string]string)
+	if response.AuthCode != "" {
+		metadata[sleet.AuthCodeMetadata] = response.AuthCode
+	}
+	if response.Batchid != nil {
+		metadata[batchIDMetadata] = *response.Batchid
+	}
+	if len(metadata) == 0 {
+		return nil
+	}
+	return metadata
+}
+
+// Response statuses taken from: https://developer.cardpointe.com/gateway-response-codes
+const (
+	respStatApproved = "A"
+	respStatRetry    = "B"
+	respStatDeclined = "C"
+	respProcGateway  = "PPS" // declines by the CardPointe gateway itself, such as invalid fields
+)
+
+// batchIDMetadata is the metadata key of the settlement batch a capture was added to
+const batchIDMetadata = "batchid"
+
+// translateResultType converts the response status and processor of a CardConnect response to a Sleet result type.
+// Declines by the CardPointe gateway are API errors, and responses without a status are classified by HTTP status.
+func translateResultType(respStat string, respProc string, statusCode int) sleet.ResultType {
+	switch respStat {
+	case respStatApproved:
+		return sleet.ResultTypeSuccess
+	case respStatRetry:
+		return sleet.ResultTypeServerError
+	case respStatDeclined:
+		if respProc == respProcGateway {
+			return sleet.ResultTypeAPIError
+		}
+		return sleet.ResultTypePaymentError
+	}
+	return common.ResultTypeFromHTTPStatus(statusCode)
+}
+
+// Decline codes are specific to the processor in respproc, taken from:
+// https://developer.cardpointe.com/gateway-response-codes
+var declineReasonMap = map[string]map[string]sleet.DeclineReason{
+	// First Data North
+	"FNOR": {
+		"01": sleet.DeclineReasonCallIssuer,
+		"02": sleet.DeclineReasonCallIssuer,
+		"04": sleet.DeclineReasonRestrictedCard,
+		"05": sleet.DeclineReasonDoNotHonor,
+		"14": sleet.DeclineReasonInvalidCardNumber,
+		"41": sleet.DeclineReasonLostOrStolenCard,
+		"43": sleet.DeclineReasonLostOrStolenCard,
+		"51": sleet.DeclineReasonInsufficientFunds,
+		"54": sleet.DeclineReasonExpiredCard,
+		"57": sleet.DeclineReasonTransactionNotAllowed,
+		"59": sleet.DeclineReasonSuspectedFraud,
+		"61": sleet.DeclineReasonLimitExceeded,
+		"62": sleet.DeclineReasonRestrictedCard,
+		"65": sleet.DeclineReasonLimitExceeded,
+		"91": sleet.DeclineReasonIssuerUnavailable,
+		"96": sleet.DeclineReasonTryAgainLater,
+		"N7": sleet.DeclineReasonCVVFailure,
+	},
+	// First Data Rapid Connect
+	"RPCT": {
+		"100": sleet.DeclineReasonDoNotHonor,
+		"101": sleet.DeclineReasonExpiredCard,
+		"102": sleet.DeclineReasonSuspectedFraud,
+		"104": sleet.DeclineReasonRestrictedCard,
+		"107": sleet.DeclineReasonCallIssuer,
+		"116": sleet.DeclineReasonInsufficientFunds,
+		"118": sleet.DeclineReasonInvalidCardNumber,
+		"119": sleet.DeclineReasonTransactionNotAllowed,
+		"121": sleet.DeclineReasonLimitExceeded,
+		"123": sleet.DeclineReasonLimitExceeded,
+		"200": sleet.DeclineReasonRestrictedCard,
+		"208": sleet.DeclineReasonLostOrStolenCard,
+		"209": sleet.DeclineReasonLostOrStolenCard,
+		"504": sleet.DeclineReasonTryAgainLater,
+		"531": sleet.DeclineReasonCVVFailure,
+		"902": sleet.DeclineReasonIssuerUnavailable,
+	},
+}
+
+// translateDeclineReason converts the processor specific response code of a declined CardConnect response to a
+// Sleet decline reason.
+func translateDeclineReason(respProc string, respCode string, resultType sleet.ResultType) sleet.DeclineReason {
+	return common.TranslateDeclineReason(declineReasonMap[respProc], respCode, resultType)
+}
@@ -0,0 +1,81 @@
+//go:build unit
+// +build unit
+
+package cardconnect
+
+import (
+	"testing"
+
+	"github.com/BoltApp/sleet"
+)
+
+func TestTranslateResultType(t *testing.T) {
+	cases := []struct {
+		label      string
+		respStat   string
+		respProc   string
+		statusCode int
+		want       sleet.ResultType
+	}{
+		{"Approved", "A", "FNOR", 200, sleet.ResultTypeSuccess},
+		{"Processor Decline", "C", "FNOR", 200, sleet.ResultTypePaymentError},
+		{"Network Decline", "C", "RPCT", 200, sleet.ResultTypePaymentError},
+		{"Gateway Decline", "C", "PPS", 200, sleet.ResultTypeAPIError},
+		{"Retry", "B", "PPS", 200, sleet.ResultTypeServerError},
+		{"Unauthorized", "", "", 401, sleet.ResultTypeAPIError},
+		{"Outage", "", "", 503, sleet.ResultTypeServerError},
+	}
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			if got := translateResultType(c.respStat, c.respProc, c.statusCode); got != c.want {
+				t.Errorf("expected %s, got %s", c.want, got)
+			}
+		})
+	}
+}
+
+func TestTranslateDeclineReason(t *testing.T) {
+	cases := []struct {
+		label      string
+		respProc   string
+		respCode   string
+		resultType sleet.ResultType
+		want       sleet.DeclineReason
+	}{
+		{"Approved", "FNOR", "00", sleet.ResultTypeSuccess, ""},
+		{"Insufficient Funds", "FNOR", "51", sleet.ResultTypePaymentError, sleet.DeclineReasonInsufficientFunds},
+		{"Rapid Connect Expired Card", "RPCT", "101", sleet.ResultTypePaymentError, sleet.DeclineReasonExpiredCard},
+		{"Code Of Another Processor", "RPCT", "51", sleet.ResultTypePaymentError, sleet.DeclineReasonUnknown},
+		{"Gateway Decline", "PPS", "11", sleet.ResultTypeAPIError, ""},
+	}
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			if got := translateDeclineReason(c.respProc, c.respCode, c.resultType); got != c.want {
+				t.Errorf("expected %q, got %q", c.want, got)
+			}
+		})
+	}
+}
+
+func TestTranslateAmount(t *testing.T) {
+	usd := "USD"
+	cases := []struct {
+		label     string
+		response  *Response
+		requested *sleet.Amount
+		want      *sleet.Amount
+	}{
+		{"Reported Amount", &Response{Amount: "5.25"}, &sleet.Amount{Amount: 1000, Currency: "USD"}, &sleet.Amount{Amount: 525, Currency: "USD"}},
+		{"Full Capture", &Response{Amount: "10.00", Currency: &usd}, nil, &sleet.Amount{Amount: 1000, Currency: "USD"}},
+		{"Missing Amount", &Response{}, &sleet.Amount{Amount: 1000, Currency: "USD"}, &sleet.Amount{Amount: 1000, Currency: "USD"}},
+		{"Unknown Currency", &Response{Amount: "10.00"}, nil, nil},
+	}
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			got := translateAmount(c.response, c.requested)
+			if (got == nil) != (c.want == nil) || (got != nil && *got != *c.want) {
+				t.Errorf("expected %v, got %v", c.want, got)
+			}
+		})
+	}
+}
@@ -3,6 +3,8 @@ package cardconnect
 import (
 	"encoding/json"
 	"net/http"
+
+	"github.com/BoltApp/sleet"
 )
 
 const (
@@ -10,6 +12,8 @@ const (
 	CapturePath   = "/cardconnect/rest/capture"
 	VoidPath      = "/cardconnect/rest/void"
 	RefundPath    = "/cardconnect/rest/refund"
+	ProfilePath   = "/cardconnect/rest/profile"
+	InquirePath   = "/cardconnect/rest/inquire"
 )
 
 type CardConnectClient struct {
@@ -18,6 +22,8 @@ type CardConnectClient struct {
 	merchantID string
 	httpClient *http.Client
 	URL        string
+	baseURL    string // overrides the scheme and host of URL when set
+	logger     sleet.Logger
 }
 
 func UnmarshalRequest(data []byte) (Request, error) {
@@ -32,8 +38,8 @@ func (r *Request) Marshal() ([]byte, error) {
 
 type Request struct {
 	MerchantID    string  `json:"merchid"`
-	Account       *string `json:"account"`
-	Expiry        *string `json:"expiry"`
+	Account       *string `json:"account,omitempty"`
+	Expiry        *string `json:"expiry,omitempty"`
 	Amount        *string `json:"amount,omitempty"`
 	Currency      *string `json:"currency,omitempty"`
 	CVV2          *string `json:"cvv2,omitempty"`
@@ -52,6 +58,9 @@ type Request struct {
 	Phone         *string `json:"phone,omitempty"`
 	Email         *string `json:"email,omitempty"`
 	Company       *string `json:"company,omitempty"`
+	Profile       *string `json:"profile,omitempty"`
+	ProfileUpdate *string `json:"profileupdate,omitempty"`
+	Capture       *string `json:"capture,omitempty"`
 }
 
 func UnmarshalResponse(data []byte) (Response, error) {
@@ -89,6 +98,10 @@ type Response struct {
 	OrderID *string `json:"orderId"`
 
 	Currency *string `json:"currency"`
+
+	ProfileID string `json:"profileid"`
+	AcctID    string `json:"acctid"`
+	AcctType  string `json:"accttype"`
 }
 
 type BinInfo struct {
@@ -0,0 +1,126 @@
+package cardconnect
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+)
+
+var (
+	// assert vault interface
+	_ sleet.VaultWithContext = &CardConnectClient{}
+)
+
+// StorePaymentMethod stores a card as an account of a CardConnect profile.
+// The stored payment method's CustomerReference is the profile id and Token is the account id.
+func (client *CardConnectClient) StorePaymentMethod(request *sleet.StorePaymentMethodRequest) (*sleet.StorePaymentMethodResponse, error) {
+	return client.StorePaymentMethodWithContext(context.TODO(), request)
+}
+
+// StorePaymentMethodWithContext stores a card as an account of a CardConnect profile.
+// The stored payment method's CustomerReference is the profile id and Token is the account id.
+func (client *CardConnectClient) StorePaymentMethodWithContext(ctx context.Context, request *sleet.StorePaymentMethodRequest) (*sleet.StorePaymentMethodResponse, error) {
+	response, httpResponse, err := client.sendRequestWithMethod(ctx, http.MethodPut, buildStoreProfileParams(request), ProfilePath)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationStorePaymentMethod, err)
+	}
+
+	if httpResponse.StatusCode == http.StatusOK && response.RespStat == respStatApproved {
+		return &sleet.StorePaymentMethodResponse{
+			Success: true,
+			StoredPaymentMethod: &sleet.StoredPaymentMethod{
+				CustomerReference: response.ProfileID,
+				Token:             response.AcctID,
+			},
+		}, nil
+	}
+
+	return &sleet.StorePaymentMethodResponse{
+		ErrorCode: &response.RespCode,
+	}, nil
+}
+
+// GetPaymentMethod retrieves an account of a CardConnect profile
+func (client *CardConnectClient) GetPaymentMethod(request *sleet.GetPaymentMethodRequest) (*sleet.GetPaymentMethodResponse, error) {
+	return client.GetPaymentMethodWithContext(context.TODO(), request)
+}
+
+// GetPaymentMethodWithContext retrieves an account of a CardConnect profile
+func (client *CardConnectClient) GetPaymentMethodWithContext(ctx context.Context, request *sleet.GetPaymentMethodRequest) (*sleet.GetPaymentMethodResponse, error) {
+	bodyText, httpResponse, err := client.do(ctx, http.MethodGet, buildProfilePath(request.StoredPaymentMethod, client.merchantID), nil)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationGetPaymentMethod, err)
+	}
+
+	// the profile service returns a list of accounts, with a single entry when the account id is given
+	var responses []Response
+	if err := json.Unmarshal(bodyText, &responses); err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationGetPaymentMethod, common.ResponseError(gatewayName, httpResponse.StatusCode, err))
+	}
+	if httpResponse.StatusCode != http.StatusOK || len(responses) == 0 {
+		return &sleet.GetPaymentMethodResponse{
+			ErrorCode: common.SPtr(strconv.Itoa(httpResponse.StatusCode)),
+		}, nil
+	}
+
+	response := responses[0]
+	if response.RespStat != respStatApproved {
+		return &sleet.GetPaymentMethodResponse{
+			ErrorCode: &response.RespCode,
+		}, nil
+	}
+
+	paymentMethod := &sleet.GetPaymentMethodResponse{
+		Success: true,
+		StoredPaymentMethod: &sleet.StoredPaymentMethod{
+			CustomerReference: response.ProfileID,
+			Token:             response.AcctID,
+		},
+		Network: acctTypeMap[response.AcctType],
+	}
+	// the account is a CardSecure token which keeps the last 4 digits of the card
+	if len(response.Account) >= 4 {
+		paymentMethod.Last4 = response.Account[len(response.Account)-4:]
+	}
+	// expiry is formatted as MMYY
+	if len(response.Expiry) == 4 {
+		paymentMethod.ExpirationMonth, _ = strconv.Atoi(response.Expiry[:2])
+		year, err := strconv.Atoi(response.Expiry[2:])
+		if err == nil {
+			paymentMethod.ExpirationYear = 2000 + year
+		}
+	}
+	return paymentMethod, nil
+}
+
+// DeletePaymentMethod deletes an account of a CardConnect profile
+func (client *CardConnectClient) DeletePaymentMethod(request *sleet.DeletePaymentMethodRequest) (*sleet.DeletePaymentMethodResponse, error) {
+	return client.DeletePaymentMethodWithContext(context.TODO(), request)
+}
+
+// DeletePaymentMethodWithContext deletes an account of a CardConnect profile
+func (client *CardConnectClient) DeletePaymentMethodWithContext(ctx context.Context, request *sleet.DeletePaymentMethodRequest) (*sleet.DeletePaymentMethodResponse, error) {
+	bodyText, httpResponse, err := client.do(ctx, http.MethodDelete, buildProfilePath(request.StoredPaymentMethod, client.merchantID), nil)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationDeletePaymentMethod, err)
+	}
+
+	response, err := UnmarshalResponse(bodyText)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationDeletePaymentMethod, common.ResponseError(gatewayName, httpResponse.StatusCode, err))
+	}
+
+	if httpResponse.StatusCode == http.StatusOK && response.RespStat == respStatApproved {
+		return &sleet.DeletePaymentMethodResponse{
+			Success: true,
+		}, nil
+	}
+
+	return &sleet.DeletePaymentMethodResponse{
+		ErrorCode: &response.RespCode,
+	}, nil
+}
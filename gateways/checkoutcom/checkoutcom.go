@@ -2,7 +2,10 @@ package checkoutcom
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 
 	"github.com/BoltApp/sleet"
@@ -12,9 +15,17 @@ import (
 	"github.com/checkout/checkout-sdk-go/payments"
 )
 
+const gatewayName = "checkoutcom"
+
 var (
 	// assert client interface
-	_ sleet.ClientWithContext = &CheckoutComClient{}
+	_ sleet.ClientWithContext              = &CheckoutComClient{}
+	_ sleet.TransactionQuerierWithContext  = &CheckoutComClient{}
+	_ sleet.TransactionFinderWithContext   = &CheckoutComClient{}
+	_ sleet.SaleWithContext                = &CheckoutComClient{}
+	_ sleet.VerifierWithContext            = &CheckoutComClient{}
+	_ sleet.IncrementAuthorizerWithContext = &CheckoutComClient{}
+	_ sleet.CapabilitiesReporter           = &CheckoutComClient{}
 )
 
 // checkout.com documentation here: https://www.checkout.com/docs/four/payments/accept-payments, SDK here: https://github.com/checkout/checkout-sdk-go
@@ -25,23 +36,34 @@ type CheckoutComClient struct {
 	processingChannelId *string
 	httpClient          *http.Client
 	env                 checkout.SupportedEnvironment
+	baseURL             string
+	logger              sleet.Logger
 }
 
 const AcceptedStatusCode = 202
 
+// actionIDMetadata is the metadata key of the ID of a capture, refund or void action
+const actionIDMetadata = "action_id"
+
+// incrementAuthorizationPath is the authorizations endpoint of a payment, which increments its authorization
+const incrementAuthorizationPath = "/payments/%s/authorizations"
+
 // NewClient creates a CheckoutComClient
 // Note: PCID is optional to support legacy checkout.com merchants whose PCID is linked to their API key.
 // New merchants will need to provide their PCID or ask their checkout.com rep to disable the field requirement.
-func NewClient(env common.Environment, apiKey string, processingChannelId *string) *CheckoutComClient {
-	return NewWithHTTPClient(env, apiKey, processingChannelId, common.DefaultHttpClient())
+func NewClient(env common.Environment, apiKey string, processingChannelId *string, options ...sleet.ClientOption) *CheckoutComClient {
+	return NewWithHTTPClient(env, apiKey, processingChannelId, common.DefaultHttpClient(), options...)
 }
 
 // NewWithHTTPClient uses a custom http client for requests
-func NewWithHTTPClient(env common.Environment, apiKey string, processingChannelId *string, httpClient *http.Client) *CheckoutComClient {
+func NewWithHTTPClient(env common.Environment, apiKey string, processingChannelId *string, httpClient *http.Client, options ...sleet.ClientOption) *CheckoutComClient {
+	clientOptions := sleet.NewClientOptions(options...)
 	return &CheckoutComClient{
 		apiKey:              apiKey,
-		httpClient:          httpClient,
+		httpClient:          common.LoggingHttpClient(httpClient, gatewayName, clientOptions.Logger),
+		logger:              clientOptions.Logger,
 		env:                 GetEnv(env),
+		baseURL:             clientOptions.BaseURL,
 		processingChannelId: processingChannelId,
 	}
 }
@@ -52,10 +74,26 @@ func (client *CheckoutComClient) generateCheckoutDCClient() (*payments.Client, e
 		return nil, err
 	}
 	config.HTTPClient = client.httpClient
+	if client.baseURL != "" {
+		config.URI = common.SPtr(common.OverrideBaseURL(*config.URI, client.baseURL))
+	}
 
 	return payments.NewClient(*config), nil
 }
 
+// Capabilities describes the optional operations supported by Checkout.com
+func (client *CheckoutComClient) Capabilities() sleet.Capabilities {
+	return sleet.Capabilities{
+		ContextSupport:           true,
+		Sale:                     true,
+		TransactionQuery:         true,
+		FindByReference:          true,
+		IncrementalAuthorization: true,
+		PartialCapture:           true,
+		PartialRefund:            true,
+	}
+}
+
 // Authorize a transaction for specified amount
 func (client *CheckoutComClient) Authorize(request *sleet.AuthorizationRequest) (*sleet.AuthorizationResponse, error) {
 	return client.AuthorizeWithContext(context.TODO(), request)
@@ -63,34 +101,76 @@ func (client *CheckoutComClient) Authorize(request *sleet.AuthorizationRequest)
 
 // AuthorizeWithContext authorizes a transaction for specified amount
 // NOTE -- checkout's SDK does not support context...
-func (client *CheckoutComClient) AuthorizeWithContext(_ context.Context, request *sleet.AuthorizationRequest) (*sleet.AuthorizationResponse, error) {
-	checkoutComClient, err := client.generateCheckoutDCClient()
+func (client *CheckoutComClient) AuthorizeWithContext(ctx context.Context, request *sleet.AuthorizationRequest) (*sleet.AuthorizationResponse, error) {
+	input, err := buildChargeParams(request, client.processingChannelId)
 	if err != nil {
-		return nil, err
+		return nil, common.OperationError(gatewayName, sleet.OperationAuthorize, err)
 	}
+	resp, err := client.requestPayment(ctx, input)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationAuthorize, err)
+	}
+	return resp, nil
+}
 
-	input, err := buildChargeParams(request, client.processingChannelId)
+// Sale authorizes a transaction for specified amount and captures it immediately
+func (client *CheckoutComClient) Sale(request *sleet.SaleRequest) (*sleet.SaleResponse, error) {
+	return client.SaleWithContext(context.TODO(), request)
+}
+
+// SaleWithContext authorizes a transaction for specified amount and captures it immediately
+// NOTE -- checkout's SDK does not support context...
+func (client *CheckoutComClient) SaleWithContext(ctx context.Context, request *sleet.SaleRequest) (*sleet.SaleResponse, error) {
+	input, err := buildSaleParams(request, client.processingChannelId)
 	if err != nil {
-		return nil, err
+		return nil, common.OperationError(gatewayName, sleet.OperationSale, err)
 	}
+	resp, err := client.requestPayment(ctx, input)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationSale, err)
+	}
+	return &sleet.SaleResponse{AuthorizationResponse: *resp}, nil
+}
 
-	response, err := checkoutComClient.Request(input, nil)
-	var statusCode int
-	if response != nil && response.StatusResponse != nil {
-		statusCode = response.StatusResponse.StatusCode
+// Verify authorizes the card for a nominal amount and voids the authorization once approved
+func (client *CheckoutComClient) Verify(request *sleet.VerificationRequest) (*sleet.VerificationResponse, error) {
+	return client.VerifyWithContext(context.TODO(), request)
+}
+
+// VerifyWithContext authorizes the card for a nominal amount and voids the authorization once approved
+func (client *CheckoutComClient) VerifyWithContext(ctx context.Context, request *sleet.VerificationRequest) (*sleet.VerificationResponse, error) {
+	response, err := sleet.VerifyByAuthorization(ctx, client, request)
+	return response, common.OperationError(gatewayName, sleet.OperationVerify, err)
+}
+
+// requestPayment requests a payment, which is authorized and captured if the request is for capture. The context is
+// only used for logging, as the SDK does not support it.
+func (client *CheckoutComClient) requestPayment(ctx context.Context, input *payments.Request) (*sleet.AuthorizationResponse, error) {
+	checkoutComClient, err := client.generateCheckoutDCClient()
+	if err != nil {
+		return nil, err
 	}
 
+	response, err := checkoutComClient.Request(input, nil)
 	if err != nil {
+		statusCode, message, ok := errorDetails(err)
+		if !ok {
+			return nil, common.ClassifyError(gatewayName, err)
+		}
 		return &sleet.AuthorizationResponse{
 			Success:              false,
 			TransactionReference: "",
 			AvsResult:            sleet.AVSResponseUnknown,
 			CvvResult:            sleet.CVVResponseUnknown,
 			ErrorCode:            err.Error(),
+			Message:              message,
+			ResultType:           translateErrorResultType(err),
 			StatusCode:           statusCode,
-		}, err
+		}, nil
 	}
 
+	statusCode := response.StatusResponse.StatusCode
+	common.LogResult(ctx, client.logger, gatewayName, response.Processed.ResponseCode, "status", string(response.Processed.Status))
 	if *response.Processed.Approved {
 		return &sleet.AuthorizationResponse{
 			Success:              true,
@@ -100,6 +180,7 @@ func (client *CheckoutComClient) AuthorizeWithContext(_ context.Context, request
 			AvsResultRaw:         response.Processed.Source.AVSCheck,
 			CvvResultRaw:         response.Processed.Source.CVVCheck,
 			Response:             response.Processed.ResponseCode,
+			ResultType:           sleet.ResultTypeSuccess,
 			StatusCode:           statusCode,
 		}, nil
 	} else {
@@ -110,11 +191,69 @@ func (client *CheckoutComClient) AuthorizeWithContext(_ context.Context, request
 			CvvResult:            sleet.CVVResponseUnknown,
 			Response:             response.Processed.ResponseCode,
 			ErrorCode:            response.Processed.ResponseCode,
+			ResultType:           translateResultType(response.Processed.ResponseCode),
+			DeclineReason:        translateDeclineReason(response.Processed.ResponseCode),
 			StatusCode:           statusCode,
 		}, nil
 	}
 }
 
+// IncrementAuthorization increments the authorization of a payment by charge ID
+func (client *CheckoutComClient) IncrementAuthorization(request *sleet.IncrementAuthorizationRequest) (*sleet.IncrementAuthorizationResponse, error) {
+	return client.IncrementAuthorizationWithContext(context.TODO(), request)
+}
+
+// IncrementAuthorizationWithContext increments the authorization of a payment by charge ID
+// NOTE -- checkout's SDK does not support context...
+// The request is posted with the SDK's HTTP client, as the SDK's IncrementAuthorization does not handle failed requests.
+// The new total is taken from the balances of the response, which may be left out, so the request must have the
+// AuthorizedAmount.
+func (client *CheckoutComClient) IncrementAuthorizationWithContext(ctx context.Context, request *sleet.IncrementAuthorizationRequest) (*sleet.IncrementAuthorizationResponse, error) {
+	if request.AuthorizedAmount == 0 {
+		return nil, common.OperationError(gatewayName, sleet.OperationIncrementAuthorization, sleet.ErrAuthorizedAmountRequired)
+	}
+	checkoutComClient, err := client.generateCheckoutDCClient()
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationIncrementAuthorization, err)
+	}
+
+	input, err := buildIncrementAuthorizationParams(request)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationIncrementAuthorization, err)
+	}
+
+	response, err := checkoutComClient.API.Post(fmt.Sprintf(incrementAuthorizationPath, request.TransactionReference), input, nil)
+	if err != nil {
+		if _, _, ok := errorDetails(err); ok {
+			return &sleet.IncrementAuthorizationResponse{Success: false, ErrorCode: common.SPtr(err.Error())}, nil
+		}
+		return nil, common.OperationError(gatewayName, sleet.OperationIncrementAuthorization, common.ClassifyError(gatewayName, err))
+	}
+
+	var authorization payments.AuthorizationResponse
+	if err := json.Unmarshal(response.ResponseBody, &authorization); err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationIncrementAuthorization, common.ResponseError(gatewayName, response.StatusCode, err))
+	}
+	common.LogResult(ctx, client.logger, gatewayName, authorization.ResponseCode)
+	if authorization.Approved == nil || !*authorization.Approved {
+		return &sleet.IncrementAuthorizationResponse{
+			Success:              false,
+			ErrorCode:            common.SPtr(authorization.ResponseCode),
+			TransactionReference: authorization.ActionID,
+		}, nil
+	}
+
+	authorizedAmount := request.NewAuthorizedAmount()
+	if authorization.Balances != nil {
+		authorizedAmount.Amount = int64(authorization.Balances.TotalAuthorized)
+	}
+	return &sleet.IncrementAuthorizationResponse{
+		Success:              true,
+		TransactionReference: authorization.ActionID,
+		AuthorizedAmount:     authorizedAmount,
+	}, nil
+}
+
 // Capture an authorized transaction by charge ID
 func (client *CheckoutComClient) Capture(request *sleet.CaptureRequest) (*sleet.CaptureResponse, error) {
 	return client.CaptureWithContext(context.TODO(), request)
@@ -125,27 +264,49 @@ func (client *CheckoutComClient) Capture(request *sleet.CaptureRequest) (*sleet.
 func (client *CheckoutComClient) CaptureWithContext(ctx context.Context, request *sleet.CaptureRequest) (*sleet.CaptureResponse, error) {
 	checkoutComClient, err := client.generateCheckoutDCClient()
 	if err != nil {
-		return nil, err
+		return nil, common.OperationError(gatewayName, sleet.OperationCapture, err)
 	}
 
 	input, err := buildCaptureParams(request)
 	if err != nil {
-		return nil, err
+		return nil, common.OperationError(gatewayName, sleet.OperationCapture, err)
 	}
 
 	response, err := checkoutComClient.Captures(request.TransactionReference, input, nil)
 
 	if err != nil {
-		return &sleet.CaptureResponse{Success: false, ErrorCode: common.SPtr(err.Error())}, err
+		statusCode, message, ok := errorDetails(err)
+		if !ok {
+			return nil, common.OperationError(gatewayName, sleet.OperationCapture, common.ClassifyError(gatewayName, err))
+		}
+		return &sleet.CaptureResponse{
+			Success:    false,
+			ErrorCode:  common.SPtr(err.Error()),
+			Message:    message,
+			ResultType: translateErrorResultType(err),
+			StatusCode: statusCode,
+		}, nil
 	}
 
+	responseHeader := translateResponseHeader(response.StatusResponse, request.Options)
 	if response.StatusResponse.StatusCode == AcceptedStatusCode {
-		return &sleet.CaptureResponse{Success: true, TransactionReference: request.TransactionReference}, nil
+		return &sleet.CaptureResponse{
+			Success:              true,
+			TransactionReference: request.TransactionReference,
+			ResultType:           sleet.ResultTypeSuccess,
+			Amount:               request.Amount,
+			Metadata:             translateAcceptedMetadata(response.Accepted),
+			StatusCode:           response.StatusResponse.StatusCode,
+			Header:               responseHeader,
+		}, nil
 	} else {
 		return &sleet.CaptureResponse{
 			Success:              false,
 			ErrorCode:            common.SPtr(strconv.Itoa(response.StatusResponse.StatusCode)),
 			TransactionReference: request.TransactionReference,
+			ResultType:           common.ResultTypeFromHTTPStatus(response.StatusResponse.StatusCode),
+			StatusCode:           response.StatusResponse.StatusCode,
+			Header:               responseHeader,
 		}, nil
 	}
 }
@@ -160,26 +321,48 @@ func (client *CheckoutComClient) Refund(request *sleet.RefundRequest) (*sleet.Re
 func (client *CheckoutComClient) RefundWithContext(ctx context.Context, request *sleet.RefundRequest) (*sleet.RefundResponse, error) {
 	checkoutComClient, err := client.generateCheckoutDCClient()
 	if err != nil {
-		return nil, err
+		return nil, common.OperationError(gatewayName, sleet.OperationRefund, err)
 	}
 
 	input, err := buildRefundParams(request)
 	if err != nil {
-		return nil, err
+		return nil, common.OperationError(gatewayName, sleet.OperationRefund, err)
 	}
 
 	response, err := checkoutComClient.Refunds(request.TransactionReference, input, nil)
 	if err != nil {
-		return &sleet.RefundResponse{Success: false, ErrorCode: common.SPtr(err.Error())}, err
+		statusCode, message, ok := errorDetails(err)
+		if !ok {
+			return nil, common.OperationError(gatewayName, sleet.OperationRefund, common.ClassifyError(gatewayName, err))
+		}
+		return &sleet.RefundResponse{
+			Success:    false,
+			ErrorCode:  common.SPtr(err.Error()),
+			Message:    message,
+			ResultType: translateErrorResultType(err),
+			StatusCode: statusCode,
+		}, nil
 	}
 
+	responseHeader := translateResponseHeader(response.StatusResponse, request.Options)
 	if response.StatusResponse.StatusCode == AcceptedStatusCode {
-		return &sleet.RefundResponse{Success: true, TransactionReference: response.Accepted.Reference}, nil
+		return &sleet.RefundResponse{
+			Success:              true,
+			TransactionReference: response.Accepted.Reference,
+			ResultType:           sleet.ResultTypeSuccess,
+			Amount:               request.Amount,
+			Metadata:             translateAcceptedMetadata(response.Accepted),
+			StatusCode:           response.StatusResponse.StatusCode,
+			Header:               responseHeader,
+		}, nil
 	} else {
 		return &sleet.RefundResponse{
 			Success:              false,
 			ErrorCode:            common.SPtr(strconv.Itoa(response.StatusResponse.StatusCode)),
 			TransactionReference: request.TransactionReference,
+			ResultType:           common.ResultTypeFromHTTPStatus(response.StatusResponse.StatusCode),
+			StatusCode:           response.StatusResponse.StatusCode,
+			Header:               responseHeader,
 		}, nil
 	}
 }
@@ -194,27 +377,128 @@ func (client *CheckoutComClient) Void(request *sleet.VoidRequest) (*sleet.VoidRe
 func (client *CheckoutComClient) VoidWithContext(ctx context.Context, request *sleet.VoidRequest) (*sleet.VoidResponse, error) {
 	checkoutComClient, err := client.generateCheckoutDCClient()
 	if err != nil {
-		return nil, err
+		return nil, common.OperationError(gatewayName, sleet.OperationVoid, err)
 	}
 
 	input, err := buildVoidParams(request)
 	if err != nil {
-		return nil, err
+		return nil, common.OperationError(gatewayName, sleet.OperationVoid, err)
 	}
 
 	response, err := checkoutComClient.Voids(request.TransactionReference, input, nil)
 
 	if err != nil {
-		return &sleet.VoidResponse{Success: false, ErrorCode: common.SPtr(err.Error())}, err
+		statusCode, message, ok := errorDetails(err)
+		if !ok {
+			return nil, common.OperationError(gatewayName, sleet.OperationVoid, common.ClassifyError(gatewayName, err))
+		}
+		return &sleet.VoidResponse{
+			Success:    false,
+			ErrorCode:  common.SPtr(err.Error()),
+			Message:    message,
+			ResultType: translateErrorResultType(err),
+			StatusCode: statusCode,
+		}, nil
 	}
 
+	responseHeader := translateResponseHeader(response.StatusResponse, request.Options)
 	if response.StatusResponse.StatusCode == AcceptedStatusCode {
-		return &sleet.VoidResponse{Success: true, TransactionReference: response.Accepted.Reference}, nil
+		return &sleet.VoidResponse{
+			Success:              true,
+			TransactionReference: response.Accepted.Reference,
+			ResultType:           sleet.ResultTypeSuccess,
+			Metadata:             translateAcceptedMetadata(response.Accepted),
+			StatusCode:           response.StatusResponse.StatusCode,
+			Header:               responseHeader,
+		}, nil
 	} else {
 		return &sleet.VoidResponse{
 			Success:              false,
 			ErrorCode:            common.SPtr(strconv.Itoa(response.StatusResponse.StatusCode)),
 			TransactionReference: request.TransactionReference,
+			ResultType:           common.ResultTypeFromHTTPStatus(response.StatusResponse.StatusCode),
+			StatusCode:           response.StatusResponse.StatusCode,
+			Header:               responseHeader,
 		}, nil
 	}
 }
+
+// QueryTransaction retrieves a payment and its actions by payment ID
+func (client *CheckoutComClient) QueryTransaction(request *sleet.TransactionQueryRequest) (*sleet.TransactionQueryResponse, error) {
+	return client.QueryTransactionWithContext(context.TODO(), request)
+}
+
+// QueryTransactionWithContext retrieves a payment and its actions by payment ID
+// NOTE -- checkout's SDK does not support context...
+func (client *CheckoutComClient) QueryTransactionWithContext(ctx context.Context, request *sleet.TransactionQueryRequest) (*sleet.TransactionQueryResponse, error) {
+	checkoutComClient, err := client.generateCheckoutDCClient()
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationQueryTransaction, err)
+	}
+
+	paymentResponse, err := checkoutComClient.Get(request.TransactionReference)
+	if err != nil {
+		if _, _, ok := errorDetails(err); ok {
+			return &sleet.TransactionQueryResponse{Success: false, ErrorCode: common.SPtr(err.Error())}, nil
+		}
+		return nil, common.OperationError(gatewayName, sleet.OperationQueryTransaction, common.ClassifyError(gatewayName, err))
+	}
+
+	actionsResponse, err := checkoutComClient.Actions(request.TransactionReference)
+	if err != nil {
+		if _, _, ok := errorDetails(err); ok {
+			return &sleet.TransactionQueryResponse{Success: false, ErrorCode: common.SPtr(err.Error())}, nil
+		}
+		return nil, common.OperationError(gatewayName, sleet.OperationQueryTransaction, common.ClassifyError(gatewayName, err))
+	}
+
+	return translatePayment(paymentResponse.Payment, actionsResponse.Actions), nil
+}
+
+// FindByClientReference retrieves the payments with the MerchantOrderReference as their reference, and their actions
+func (client *CheckoutComClient) FindByClientReference(request *sleet.FindByClientReferenceRequest) (*sleet.FindByClientReferenceResponse, error) {
+	return client.FindByClientReferenceWithContext(context.TODO(), request)
+}
+
+// FindByClientReferenceWithContext retrieves the payments with the MerchantOrderReference as their reference, and their actions
+// NOTE -- checkout's SDK does not support context...
+func (client *CheckoutComClient) FindByClientReferenceWithContext(ctx context.Context, request *sleet.FindByClientReferenceRequest) (*sleet.FindByClientReferenceResponse, error) {
+	if request.MerchantOrderReference == nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationFindByClientReference, sleet.ErrClientReferenceRequired)
+	}
+
+	checkoutComClient, err := client.generateCheckoutDCClient()
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationFindByClientReference, err)
+	}
+
+	// the SDK has no method for the get payment list endpoint
+	listResponse, err := checkoutComClient.API.Get("/payments?reference=" + url.QueryEscape(*request.MerchantOrderReference))
+	if err != nil {
+		if _, _, ok := errorDetails(err); ok {
+			return &sleet.FindByClientReferenceResponse{Success: false, ErrorCode: common.SPtr(err.Error())}, nil
+		}
+		return nil, common.OperationError(gatewayName, sleet.OperationFindByClientReference, common.ClassifyError(gatewayName, err))
+	}
+	var list paymentList
+	if err := json.Unmarshal(listResponse.ResponseBody, &list); err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationFindByClientReference, common.ResponseError(gatewayName, listResponse.StatusCode, err))
+	}
+
+	transactions := make([]sleet.TransactionQueryResponse, 0, len(list.Data))
+	for i := range list.Data {
+		payment := &list.Data[i]
+		actionsResponse, err := checkoutComClient.Actions(payment.ID)
+		if err != nil {
+			if _, _, ok := errorDetails(err); ok {
+				return &sleet.FindByClientReferenceResponse{Success: false, ErrorCode: common.SPtr(err.Error())}, nil
+			}
+			return nil, common.OperationError(gatewayName, sleet.OperationFindByClientReference, common.ClassifyError(gatewayName, err))
+		}
+		transactions = append(transactions, *translatePayment(payment, actionsResponse.Actions))
+	}
+	return &sleet.FindByClientReferenceResponse{
+		Success:      true,
+		Transactions: transactions,
+	}, nil
+}
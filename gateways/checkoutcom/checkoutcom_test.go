@@ -0,0 +1,28 @@
+//go:build unit
+// +build unit
+
+package checkoutcom
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+)
+
+func TestIncrementAuthorizationRequiresAuthorizedAmount(t *testing.T) {
+	client := NewWithHTTPClient(common.Sandbox, "sk_sbox_key", nil, &http.Client{})
+	got, err := client.IncrementAuthorization(&sleet.IncrementAuthorizationRequest{
+		TransactionReference: "pay_mbabizu24mvu3mela5njyhpit4",
+		Amount:               sleet.Amount{Amount: 500, Currency: "USD"},
+	})
+	var sleetErr *sleet.Error
+	if !errors.As(err, &sleetErr) || sleetErr.Kind != sleet.ErrorKindValidation || !errors.Is(err, sleet.ErrAuthorizedAmountRequired) {
+		t.Errorf("expected a validation error for the missing AuthorizedAmount, got %v", err)
+	}
+	if got != nil {
+		t.Errorf("expected no response, got %+v", got)
+	}
+}
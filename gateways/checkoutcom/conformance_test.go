@@ -0,0 +1,35 @@
+//go:build unit
+// +build unit
+
+package checkoutcom
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+	"github.com/BoltApp/sleet/testing/conformance"
+	"github.com/BoltApp/sleet/testing/fakes"
+)
+
+func TestConformance(t *testing.T) {
+	fake := fakes.NewCheckoutCom()
+	defer fake.Close()
+
+	conformance.Run(t, conformance.Suite{
+		Gateway:   "checkoutcom",
+		Transport: fake.Client().Transport,
+		NewClient: func(httpClient *http.Client) sleet.ClientWithContext {
+			return NewWithHTTPClient(common.Sandbox, fakes.CheckoutComSecretKey, common.SPtr(fakes.CheckoutComProcessingChannelID), httpClient)
+		},
+		// the sandbox declines this card for insufficient funds
+		Decline: func(request *sleet.AuthorizationRequest) { request.CreditCard.Number = "4544249167673670" },
+		AVS:     sleet.AVSresponseZipMatchAddressMatch,
+		CVV:     sleet.CVVResponseMatch,
+		Skip: map[conformance.Behavior]string{
+			conformance.BehaviorResponseHeader: "the SDK drops the headers of POST responses",
+			conformance.BehaviorContextCancel:  "the SDK takes no context",
+		},
+	})
+}
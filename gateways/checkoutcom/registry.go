@@ -0,0 +1,27 @@
+package checkoutcom
+
+import (
+	"net/http"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/registry"
+)
+
+// Checkout.com credential keys of a registry.Config, the processing channel is optional for legacy merchants
+const (
+	credentialAPIKey              = "api_key"
+	credentialProcessingChannelID = "processing_channel_id"
+)
+
+func init() {
+	registry.Register("checkoutcom", newRegistryClient)
+}
+
+// newRegistryClient builds a CheckoutComClient from a registry config
+func newRegistryClient(config *registry.Config, httpClient *http.Client, options ...sleet.ClientOption) (sleet.ClientWithContext, error) {
+	apiKey, err := config.Credential(credentialAPIKey)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithHTTPClient(config.Environment, apiKey, config.OptionalCredential(credentialProcessingChannelID), httpClient, options...), nil
+}
@@ -49,6 +49,15 @@ func buildChargeParams(authRequest *sleet.AuthorizationRequest, processingChanne
 	return request, nil
 }
 
+func buildSaleParams(saleRequest *sleet.SaleRequest, processingChannelId *string) (*payments.Request, error) {
+	request, err := buildChargeParams(saleRequest, processingChannelId)
+	if err != nil {
+		return nil, err
+	}
+	request.Capture = common.BPtr(true)
+	return request, nil
+}
+
 func initializeProcessingInitiator(authRequest *sleet.AuthorizationRequest, request *payments.Request, source *payments.CardSource) {
 	// see documentation for instructions on stored credentials, merchant-initiated transactions, and subscriptions:
 	// https://www.checkout.com/docs/four/payments/accept-payments/use-saved-details/about-stored-card-details
@@ -100,6 +109,19 @@ func buildCaptureParams(captureRequest *sleet.CaptureRequest) (*payments.Capture
 	return request, nil
 }
 
+// buildIncrementAuthorizationParams increments the authorization by the additional amount
+func buildIncrementAuthorizationParams(incrementRequest *sleet.IncrementAuthorizationRequest) (*payments.AuthorizationRequest, error) {
+	request := &payments.AuthorizationRequest{
+		Amount: uint64(incrementRequest.Amount.Amount),
+	}
+
+	if incrementRequest.MerchantOrderReference != nil {
+		request.Reference = *incrementRequest.MerchantOrderReference
+	}
+
+	return request, nil
+}
+
 func buildVoidParams(voidRequest *sleet.VoidRequest) (*payments.VoidsRequest, error) {
 	request := &payments.VoidsRequest{}
 
@@ -1,6 +1,17 @@
 package checkoutcom
 
-import "github.com/BoltApp/sleet"
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/checkout/checkout-sdk-go"
+	checkout_common "github.com/checkout/checkout-sdk-go/common"
+	"github.com/checkout/checkout-sdk-go/payments"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+)
 
 var cvvMap = map[CVVResponseCode]sleet.CVVResponse{
 	CVVResponseMatched:       sleet.CVVResponseMatch,
@@ -55,3 +66,183 @@ func translateAvs(avs AVSResponseCode) sleet.AVSResponse {
 	}
 	return sleetCode
 }
+
+// serverErrorResponseCodes are the soft declines which are caused by the issuer or the network being unavailable
+var serverErrorResponseCodes = map[string]bool{
+	"20068": true, // response received too late
+	"20091": true, // issuer unavailable
+	"20096": true, // system malfunction
+}
+
+// translateResultType classifies a checkout.com response code. 10xxx codes are approvals, and soft declines (20xxx),
+// hard declines (30xxx) and risk responses (4xxxx) are payment errors unless the issuer could not be reached.
+func translateResultType(responseCode string) sleet.ResultType {
+	if serverErrorResponseCodes[responseCode] {
+		return sleet.ResultTypeServerError
+	}
+	if len(responseCode) != 5 {
+		return sleet.ResultTypeUnknownError
+	}
+	switch responseCode[0] {
+	case '1':
+		return sleet.ResultTypeSuccess
+	case '2', '3', '4':
+		return sleet.ResultTypePaymentError
+	}
+	return sleet.ResultTypeUnknownError
+}
+
+// translateErrorResultType classifies an error returned by the checkout.com SDK by its HTTP status. Errors without a
+// response, such as timeouts, are server errors.
+func translateErrorResultType(err error) sleet.ResultType {
+	var apiError *checkout_common.Error
+	if errors.As(err, &apiError) {
+		if resultType := common.ResultTypeFromHTTPStatus(apiError.StatusCode); resultType != sleet.ResultTypeUnknownError {
+			return resultType
+		}
+		return sleet.ResultTypeAPIError
+	}
+	return sleet.ResultTypeServerError
+}
+
+// errorDetails returns the HTTP status of an error returned by the checkout.com SDK, and its error codes as a message.
+// It is false for errors without a response from checkout.com, such as timeouts.
+func errorDetails(err error) (int, string, bool) {
+	var apiError *checkout_common.Error
+	if !errors.As(err, &apiError) || apiError.StatusCode == 0 {
+		return 0, "", false
+	}
+	if apiError.Data != nil && len(apiError.Data.ErrorCodes) > 0 {
+		return apiError.StatusCode, strings.Join(apiError.Data.ErrorCodes, ", "), true
+	}
+	return apiError.StatusCode, apiError.Status, true
+}
+
+// translateResponseHeader returns the headers requested with sleet.ResponseHeaderOption from a checkout.com response
+func translateResponseHeader(response *checkout.StatusResponse, options map[string]interface{}) http.Header {
+	if response == nil || response.Headers == nil {
+		return nil
+	}
+	return sleet.GetHTTPResponseHeader(options, http.Response{Header: response.Headers.Header})
+}
+
+// translateAcceptedMetadata keeps the ID checkout.com gives to the capture, refund or void action of a payment
+func translateAcceptedMetadata(accepted *payments.Accepted) map[string]string {
+	if accepted == nil || accepted.ActionID == "" {
+		return nil
+	}
+	return map[string]string{actionIDMetadata: accepted.ActionID}
+}
+
+// Response codes taken from: https://www.checkout.com/docs/resources/codes/response-codes
+var declineReasonMap = map[string]sleet.DeclineReason{
+	"20001": sleet.DeclineReasonCallIssuer,
+	"20002": sleet.DeclineReasonCallIssuer,
+	"20005": sleet.DeclineReasonDoNotHonor,
+	"20012": sleet.DeclineReasonTransactionNotAllowed,
+	"20014": sleet.DeclineReasonInvalidCardNumber,
+	"20019": sleet.DeclineReasonTryAgainLater,
+	"20051": sleet.DeclineReasonInsufficientFunds,
+	"20054": sleet.DeclineReasonExpiredCard,
+	"20057": sleet.DeclineReasonTransactionNotAllowed,
+	"20058": sleet.DeclineReasonTransactionNotAllowed,
+	"20059": sleet.DeclineReasonSuspectedFraud,
+	"20061": sleet.DeclineReasonLimitExceeded,
+	"20062": sleet.DeclineReasonRestrictedCard,
+	"20065": sleet.DeclineReasonLimitExceeded,
+	"20068": sleet.DeclineReasonTryAgainLater,
+	"20087": sleet.DeclineReasonCVVFailure,
+	"20091": sleet.DeclineReasonIssuerUnavailable,
+	"20096": sleet.DeclineReasonTryAgainLater,
+	"20154": sleet.DeclineReasonAuthenticationRequired,
+	"30004": sleet.DeclineReasonRestrictedCard,
+	"30007": sleet.DeclineReasonRestrictedCard,
+	"30033": sleet.DeclineReasonExpiredCard,
+	"30041": sleet.DeclineReasonLostOrStolenCard,
+	"30043": sleet.DeclineReasonLostOrStolenCard,
+	"40101": sleet.DeclineReasonSuspectedFraud,
+}
+
+// translateDeclineReason converts the response code of a declined checkout.com payment to a Sleet decline reason.
+func translateDeclineReason(responseCode string) sleet.DeclineReason {
+	return common.TranslateDeclineReason(declineReasonMap, responseCode, translateResultType(responseCode))
+}
+
+var paymentStatusMap = map[string]sleet.TransactionState{
+	payments.Authorized:          sleet.TransactionStateAuthorized,
+	payments.CardVerified:        sleet.TransactionStateAuthorized,
+	payments.Captured:            sleet.TransactionStateCaptured,
+	payments.PartiallyCaptured:   sleet.TransactionStateCaptured,
+	string(checkout_common.Paid): sleet.TransactionStateCaptured,
+	payments.Refunded:            sleet.TransactionStateRefunded,
+	payments.PartiallyRefunded:   sleet.TransactionStateRefunded,
+	payments.Voided:              sleet.TransactionStateVoided,
+	payments.Canceled:            sleet.TransactionStateVoided,
+	payments.Expired:             sleet.TransactionStateVoided,
+	payments.Declined:            sleet.TransactionStateDeclined,
+}
+
+func translatePaymentStatus(status string) sleet.TransactionState {
+	state, ok := paymentStatusMap[status]
+	if !ok {
+		return sleet.TransactionStateUnknown
+	}
+	return state
+}
+
+var schemeMap = map[string]sleet.CreditCardNetwork{
+	"VISA":             sleet.CreditCardNetworkVisa,
+	"MASTERCARD":       sleet.CreditCardNetworkMastercard,
+	"AMERICAN EXPRESS": sleet.CreditCardNetworkAmex,
+	"AMEX":             sleet.CreditCardNetworkAmex,
+	"DISCOVER":         sleet.CreditCardNetworkDiscover,
+	"JCB":              sleet.CreditCardNetworkJcb,
+	"UNIONPAY":         sleet.CreditCardNetworkUnionpay,
+}
+
+// translateScheme converts a checkout.com card scheme, which is not consistently cased, to its equivalent Sleet network.
+func translateScheme(scheme string) sleet.CreditCardNetwork {
+	network, ok := schemeMap[strings.ToUpper(scheme)]
+	if !ok {
+		return sleet.CreditCardNetworkUnknown
+	}
+	return network
+}
+
+// translatePayment converts a checkout.com payment and its actions to a Sleet transaction query response.
+// Amounts are totalled from the approved actions as the payment only carries the requested amount.
+func translatePayment(payment *payments.Payment, actions []*payments.Action) *sleet.TransactionQueryResponse {
+	response := &sleet.TransactionQueryResponse{
+		Success:              true,
+		TransactionReference: payment.ID,
+		State:                translatePaymentStatus(string(payment.Status)),
+		StateRaw:             string(payment.Status),
+		Currency:             payment.Currency,
+	}
+	if !payment.RequestedOn.IsZero() {
+		requestedOn := payment.RequestedOn
+		response.CreatedAt = &requestedOn
+	}
+	if payment.Source != nil && payment.Source.CardSourceResponse != nil {
+		response.Last4 = payment.Source.CardSourceResponse.Last4
+		response.Network = translateScheme(payment.Source.CardSourceResponse.Scheme)
+	}
+	for _, action := range actions {
+		if action.Approved == nil || !*action.Approved {
+			continue
+		}
+		switch action.Type {
+		case actionTypeAuthorization:
+			response.AuthorizedAmount += int64(action.Amount)
+		case actionTypeCapture:
+			response.CapturedAmount += int64(action.Amount)
+		case actionTypeRefund:
+			response.RefundedAmount += int64(action.Amount)
+		}
+		if response.UpdatedAt == nil || action.ProcessedOn.After(*response.UpdatedAt) {
+			processedOn := action.ProcessedOn
+			response.UpdatedAt = &processedOn
+		}
+	}
+	return response
+}
@@ -0,0 +1,154 @@
+//go:build unit
+// +build unit
+
+package checkoutcom
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/checkout/checkout-sdk-go/common"
+	"github.com/checkout/checkout-sdk-go/payments"
+	"github.com/go-test/deep"
+
+	"github.com/BoltApp/sleet"
+)
+
+func TestTranslatePayment(t *testing.T) {
+	approved := true
+	declined := false
+	requestedOn := time.Date(2023, 3, 21, 20, 1, 31, 0, time.UTC)
+	capturedOn := requestedOn.Add(time.Hour)
+	refundedOn := capturedOn.Add(time.Hour)
+
+	payment := &payments.Payment{
+		ID:          "pay_1",
+		RequestedOn: requestedOn,
+		Currency:    "USD",
+		Status:      common.PaymentAction(payments.PartiallyRefunded),
+		Source: &payments.SourceResponse{
+			CardSourceResponse: &payments.CardSourceResponse{Scheme: "Visa", Last4: "4242"},
+		},
+	}
+	actions := []*payments.Action{
+		{Type: "Refund", Amount: 500, Approved: &declined, ProcessedOn: refundedOn.Add(time.Hour)},
+		{Type: "Refund", Amount: 300, Approved: &approved, ProcessedOn: refundedOn},
+		{Type: "Capture", Amount: 1000, Approved: &approved, ProcessedOn: capturedOn},
+		{Type: "Authorization", Amount: 1000, Approved: &approved, ProcessedOn: requestedOn},
+	}
+
+	want := &sleet.TransactionQueryResponse{
+		Success:              true,
+		TransactionReference: "pay_1",
+		State:                sleet.TransactionStateRefunded,
+		StateRaw:             "Partially Refunded",
+		Currency:             "USD",
+		AuthorizedAmount:     1000,
+		CapturedAmount:       1000,
+		RefundedAmount:       300,
+		Last4:                "4242",
+		Network:              sleet.CreditCardNetworkVisa,
+		CreatedAt:            &requestedOn,
+		UpdatedAt:            &refundedOn,
+	}
+
+	got := translatePayment(payment, actions)
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestTranslateResultType(t *testing.T) {
+	cases := []struct {
+		label        string
+		responseCode string
+		want         sleet.ResultType
+	}{
+		{"Approved", "10000", sleet.ResultTypeSuccess},
+		{"Approved With Risk", "10100", sleet.ResultTypeSuccess},
+		{"Insufficient Funds", "20051", sleet.ResultTypePaymentError},
+		{"Issuer Unavailable", "20091", sleet.ResultTypeServerError},
+		{"System Malfunction", "20096", sleet.ResultTypeServerError},
+		{"Stolen Card", "30043", sleet.ResultTypePaymentError},
+		{"Risk Blocked", "40101", sleet.ResultTypePaymentError},
+		{"Unknown", "", sleet.ResultTypeUnknownError},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			if got := translateResultType(c.responseCode); got != c.want {
+				t.Errorf("expected %s, got %s", c.want, got)
+			}
+		})
+	}
+}
+
+func TestTranslateDeclineReason(t *testing.T) {
+	cases := []struct {
+		label        string
+		responseCode string
+		want         sleet.DeclineReason
+	}{
+		{"Approved", "10000", ""},
+		{"Do Not Honor", "20005", sleet.DeclineReasonDoNotHonor},
+		{"Insufficient Funds", "20051", sleet.DeclineReasonInsufficientFunds},
+		{"Issuer Unavailable", "20091", sleet.DeclineReasonIssuerUnavailable},
+		{"Stolen Card", "30043", sleet.DeclineReasonLostOrStolenCard},
+		{"Risk Blocked", "40101", sleet.DeclineReasonSuspectedFraud},
+		{"Untranslated Decline", "20099", sleet.DeclineReasonUnknown},
+		{"Unknown", "", ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			if got := translateDeclineReason(c.responseCode); got != c.want {
+				t.Errorf("expected %q, got %q", c.want, got)
+			}
+		})
+	}
+}
+
+func TestTranslateErrorResultType(t *testing.T) {
+	cases := []struct {
+		label string
+		err   error
+		want  sleet.ResultType
+	}{
+		{"Unprocessable", &common.Error{Status: "422 Unprocessable Entity", StatusCode: 422}, sleet.ResultTypeAPIError},
+		{"Unauthorized", fmt.Errorf("capture: %w", &common.Error{Status: "401 Unauthorized", StatusCode: 401}), sleet.ResultTypeAPIError},
+		{"Bad Gateway", &common.Error{Status: "502 Bad Gateway", StatusCode: 502}, sleet.ResultTypeServerError},
+		{"Timeout", errors.New("context deadline exceeded"), sleet.ResultTypeServerError},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			if got := translateErrorResultType(c.err); got != c.want {
+				t.Errorf("expected %s, got %s", c.want, got)
+			}
+		})
+	}
+}
+
+func TestErrorDetails(t *testing.T) {
+	cases := []struct {
+		label          string
+		err            error
+		wantStatusCode int
+		wantMessage    string
+	}{
+		{"Error Codes", &common.Error{Status: "422 Unprocessable Entity", StatusCode: 422, Data: &common.ErrorDetails{ErrorCodes: []string{"amount_invalid", "currency_required"}}}, 422, "amount_invalid, currency_required"},
+		{"Status Only", &common.Error{Status: "404 Not Found", StatusCode: 404}, 404, "404 Not Found"},
+		{"Timeout", errors.New("context deadline exceeded"), 0, ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			statusCode, message, ok := errorDetails(c.err)
+			if statusCode != c.wantStatusCode || message != c.wantMessage || ok != (c.wantStatusCode != 0) {
+				t.Errorf("expected %d %q, got %d %q %t", c.wantStatusCode, c.wantMessage, statusCode, message, ok)
+			}
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package checkoutcom
 
+import "github.com/checkout/checkout-sdk-go/payments"
+
 type CVVResponseCode string
 
 // See https://www.checkout.com/docs/resources/codes/cvv-response-codes
@@ -43,3 +45,51 @@ const (
 	AVSResponseCardholderNameAndStreetAndPostalMatch       AVSResponseCode = "AE6"
 	AVSResponseCardholderNameAndStreetMatch                AVSResponseCode = "AE7"
 )
+
+// WebhookEventType is the type of a checkout.com webhook event
+// See https://www.checkout.com/docs/four/workflows/webhook-event-types
+type WebhookEventType string
+
+const (
+	WebhookEventPaymentApproved        WebhookEventType = "payment_approved"
+	WebhookEventPaymentDeclined        WebhookEventType = "payment_declined"
+	WebhookEventPaymentCaptured        WebhookEventType = "payment_captured"
+	WebhookEventPaymentCaptureDeclined WebhookEventType = "payment_capture_declined"
+	WebhookEventPaymentVoided          WebhookEventType = "payment_voided"
+	WebhookEventPaymentVoidDeclined    WebhookEventType = "payment_void_declined"
+	WebhookEventPaymentRefunded        WebhookEventType = "payment_refunded"
+	WebhookEventPaymentRefundDeclined  WebhookEventType = "payment_refund_declined"
+	WebhookEventDisputeReceived        WebhookEventType = "dispute_received"
+)
+
+// webhookEvent is the body checkout.com posts to a webhook endpoint
+type webhookEvent struct {
+	ID        string           `json:"id"`
+	Type      WebhookEventType `json:"type"`
+	CreatedOn string           `json:"created_on"`
+	Data      webhookEventData `json:"data"`
+}
+
+type webhookEventData struct {
+	ID        string `json:"id"`
+	ActionID  string `json:"action_id"`
+	PaymentID string `json:"payment_id"` // only set on dispute events
+	Reference string `json:"reference"`
+	Amount    int64  `json:"amount"`
+	Currency  string `json:"currency"`
+}
+
+// paymentList is returned by the get payment list endpoint, which searches payments by reference
+type paymentList struct {
+	Limit      int                `json:"limit"`
+	Skip       int                `json:"skip"`
+	TotalCount int                `json:"total_count"`
+	Data       []payments.Payment `json:"data"`
+}
+
+// Payment action types returned by the get payment actions endpoint
+const (
+	actionTypeAuthorization = "Authorization"
+	actionTypeCapture       = "Capture"
+	actionTypeRefund        = "Refund"
+)
@@ -0,0 +1,92 @@
+package checkoutcom
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/webhooks"
+)
+
+const signatureHeader = "Cko-Signature"
+
+var (
+	// assert parser interface
+	_ webhooks.Parser = &WebhookParser{}
+)
+
+// WebhookParser verifies the Cko-Signature header of checkout.com webhooks and translates payment events.
+// The SDK does not provide signature verification so it is done here.
+type WebhookParser struct {
+	secretKey string
+}
+
+// NewWebhookParser creates a parser using the secret key configured for the webhook
+func NewWebhookParser(secretKey string) *WebhookParser {
+	return &WebhookParser{secretKey: secretKey}
+}
+
+// Parse verifies the HMAC-SHA256 signature of the body and translates it into a webhook event
+func (p *WebhookParser) Parse(header http.Header, body []byte) ([]webhooks.WebhookEvent, error) {
+	signature := header.Get(signatureHeader)
+	if signature == "" {
+		return nil, webhooks.ErrMissingSignature
+	}
+	received, err := hex.DecodeString(signature)
+	if err != nil {
+		return nil, webhooks.ErrInvalidSignature
+	}
+	mac := hmac.New(sha256.New, []byte(p.secretKey))
+	mac.Write(body)
+	if !hmac.Equal(mac.Sum(nil), received) {
+		return nil, webhooks.ErrInvalidSignature
+	}
+
+	var event webhookEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, err
+	}
+	translated := translateWebhookEvent(event)
+	translated.RawPayload = body
+	return []webhooks.WebhookEvent{translated}, nil
+}
+
+func translateWebhookEvent(event webhookEvent) webhooks.WebhookEvent {
+	translated := webhooks.WebhookEvent{
+		Kind:                 webhooks.EventKindUnknown,
+		PsPEventType:         string(event.Type),
+		EventID:              event.ID,
+		TransactionReference: event.Data.ID,
+		MerchantReference:    event.Data.Reference,
+	}
+	if event.Data.Currency != "" {
+		translated.Amount = &sleet.Amount{
+			Amount:   event.Data.Amount,
+			Currency: event.Data.Currency,
+		}
+	}
+
+	switch event.Type {
+	case WebhookEventPaymentApproved, WebhookEventPaymentDeclined:
+		translated.Kind = webhooks.EventKindAuthorization
+		translated.Success = event.Type == WebhookEventPaymentApproved
+	case WebhookEventPaymentCaptured, WebhookEventPaymentCaptureDeclined:
+		translated.Kind = webhooks.EventKindCapture
+		translated.Success = event.Type == WebhookEventPaymentCaptured
+	case WebhookEventPaymentVoided, WebhookEventPaymentVoidDeclined:
+		translated.Kind = webhooks.EventKindVoid
+		translated.Success = event.Type == WebhookEventPaymentVoided
+	case WebhookEventPaymentRefunded, WebhookEventPaymentRefundDeclined:
+		translated.Kind = webhooks.EventKindRefund
+		translated.Success = event.Type == WebhookEventPaymentRefunded
+	case WebhookEventDisputeReceived:
+		// dispute events are about the dispute, the payment is referenced separately
+		translated.Kind = webhooks.EventKindChargeback
+		translated.TransactionReference = event.Data.PaymentID
+		translated.Success = true
+	}
+	return translated
+}
@@ -0,0 +1,88 @@
+//go:build unit
+// +build unit
+
+package checkoutcom
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+
+	"github.com/go-test/deep"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/webhooks"
+)
+
+const testSecretKey = "webhook-secret"
+
+const paymentCapturedBody = `{
+  "id": "evt_az5sblvku4ge3dwpztvyizgcau",
+  "type": "payment_captured",
+  "created_on": "2019-08-24T14:15:22Z",
+  "data": {
+    "id": "pay_mbabizu24mvu3mela5njyhpit4",
+    "action_id": "act_y3oqhf46pyzuxjbcn2giaqnb44",
+    "reference": "ORD-5023-4E89",
+    "amount": 6540,
+    "currency": "USD"
+  }
+}`
+
+func sign(body []byte, key string) http.Header {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(body)
+	header := http.Header{}
+	header.Set(signatureHeader, hex.EncodeToString(mac.Sum(nil)))
+	return header
+}
+
+func TestWebhookParserParse(t *testing.T) {
+	body := []byte(paymentCapturedBody)
+	got, err := NewWebhookParser(testSecretKey).Parse(sign(body, testSecretKey), body)
+	if err != nil {
+		t.Fatalf("error parsing event: %s", err)
+	}
+
+	want := []webhooks.WebhookEvent{
+		{
+			Kind:                 webhooks.EventKindCapture,
+			PsPEventType:         string(WebhookEventPaymentCaptured),
+			EventID:              "evt_az5sblvku4ge3dwpztvyizgcau",
+			TransactionReference: "pay_mbabizu24mvu3mela5njyhpit4",
+			MerchantReference:    "ORD-5023-4E89",
+			Amount:               &sleet.Amount{Amount: 6540, Currency: "USD"},
+			Success:              true,
+			RawPayload:           body,
+		},
+	}
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestWebhookParserParseInvalidSignature(t *testing.T) {
+	body := []byte(paymentCapturedBody)
+	tampered := sign(body, testSecretKey)
+	tampered.Set(signatureHeader, tampered.Get(signatureHeader)[2:])
+	cases := []struct {
+		label  string
+		header http.Header
+		want   error
+	}{
+		{"Missing header", http.Header{}, webhooks.ErrMissingSignature},
+		{"Wrong key", sign(body, "other-secret"), webhooks.ErrInvalidSignature},
+		{"Truncated signature", tampered, webhooks.ErrInvalidSignature},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			_, err := NewWebhookParser(testSecretKey).Parse(c.header, body)
+			if err != c.want {
+				t.Errorf("expected %v, got %v", c.want, err)
+			}
+		})
+	}
+}
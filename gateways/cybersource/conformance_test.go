@@ -0,0 +1,31 @@
+//go:build unit
+// +build unit
+
+package cybersource
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+	"github.com/BoltApp/sleet/testing/conformance"
+	"github.com/BoltApp/sleet/testing/fakes"
+)
+
+func TestConformance(t *testing.T) {
+	fake := fakes.NewCybersource()
+	defer fake.Close()
+
+	conformance.Run(t, conformance.Suite{
+		Gateway:   "cybersource",
+		Transport: fake.Client().Transport,
+		NewClient: func(httpClient *http.Client) sleet.ClientWithContext {
+			return NewWithHttpClient(common.Sandbox, fakes.CybersourceMerchantID, fakes.CybersourceKeyID, fakes.CybersourceSharedSecret, httpClient)
+		},
+		// the test card the sandbox declines as the issuer would
+		Decline: func(request *sleet.AuthorizationRequest) { request.CreditCard.Number = "4000000000000002" },
+		AVS:     sleet.AVSResponseZip5MatchAddressMatch,
+		CVV:     sleet.CVVResponseMatch,
+	})
+}
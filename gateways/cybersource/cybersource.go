@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
@@ -18,37 +19,77 @@ import (
 )
 
 const (
-	authPath = "/pts/v2/payments/"
+	authPath               = "/pts/v2/payments/"
+	transactionDetailsPath = "/tss/v2/transactions/"
+	searchPath             = "/tss/v2/searches"
+	timeoutReversalPath    = "/pts/v2/reversals"
+
+	gatewayName = "cybersource"
+
+	// reconciliationIDMetadata is the metadata key of the ID matching a transaction to its settlement
+	reconciliationIDMetadata = "reconciliation_id"
 )
 
 var (
 	// assert client interface
-	_ sleet.ClientWithContext = &CybersourceClient{}
+	_ sleet.ClientWithContext              = &CybersourceClient{}
+	_ sleet.TransactionQuerierWithContext  = &CybersourceClient{}
+	_ sleet.TransactionFinderWithContext   = &CybersourceClient{}
+	_ sleet.TimeoutReverserWithContext     = &CybersourceClient{}
+	_ sleet.SaleWithContext                = &CybersourceClient{}
+	_ sleet.VerifierWithContext            = &CybersourceClient{}
+	_ sleet.IncrementAuthorizerWithContext = &CybersourceClient{}
+	_ sleet.CapabilitiesReporter           = &CybersourceClient{}
 )
 
 // CybersourceClient represents an HTTP client and the associated authentication information required for making an API request.
 type CybersourceClient struct {
 	host              string
+	baseURL           string // overrides the scheme and host of host when set
 	merchantID        string
 	sharedSecretKeyID string
 	sharedSecretKey   string
 	httpClient        *http.Client
+	logger            sleet.Logger
 }
 
 // NewClient returns a new client for making CyberSource API requests for a given merchant using a specified authentication key.
-func NewClient(env common.Environment, merchantID string, sharedSecretKeyID string, sharedSecretKey string) *CybersourceClient {
-	return NewWithHttpClient(env, merchantID, sharedSecretKeyID, sharedSecretKey, common.DefaultHttpClient())
+func NewClient(env common.Environment, merchantID string, sharedSecretKeyID string, sharedSecretKey string, options ...sleet.ClientOption) *CybersourceClient {
+	return NewWithHttpClient(env, merchantID, sharedSecretKeyID, sharedSecretKey, common.DefaultHttpClient(), options...)
 }
 
 // NewWithHttpClient returns a client for making CyberSource API requests for a given merchant using a specified authentication key.
 // The given HTTP client will be used to make the requests.
-func NewWithHttpClient(env common.Environment, merchantID string, sharedSecretKeyID string, sharedSecretKey string, httpClient *http.Client) *CybersourceClient {
+func NewWithHttpClient(env common.Environment, merchantID string, sharedSecretKeyID string, sharedSecretKey string, httpClient *http.Client, options ...sleet.ClientOption) *CybersourceClient {
+	clientOptions := sleet.NewClientOptions(options...)
 	return &CybersourceClient{
 		host:              cybersourceHost(env),
+		baseURL:           clientOptions.BaseURL,
 		merchantID:        merchantID,
 		sharedSecretKeyID: sharedSecretKeyID,
 		sharedSecretKey:   sharedSecretKey,
-		httpClient:        httpClient,
+		httpClient:        common.LoggingHttpClient(httpClient, gatewayName, clientOptions.Logger),
+		logger:            clientOptions.Logger,
+	}
+}
+
+// Capabilities describes the optional operations supported by CyberSource
+func (client *CybersourceClient) Capabilities() sleet.Capabilities {
+	return sleet.Capabilities{
+		ContextSupport:           true,
+		Sale:                     true,
+		TransactionQuery:         true,
+		FindByReference:          true,
+		TimeoutReversal:          true,
+		IncrementalAuthorization: true,
+		PartialAuthorization:     true,
+		PartialCapture:           true,
+		MultipleCapture:          true,
+		PartialRefund:            true,
+		Level2:                   true,
+		Level3:                   true,
+		NetworkTokens:            true,
+		ApplePay:                 true,
 	}
 }
 
@@ -67,27 +108,78 @@ func (client *CybersourceClient) Authorize(request *sleet.AuthorizationRequest)
 func (client *CybersourceClient) AuthorizeWithContext(ctx context.Context, request *sleet.AuthorizationRequest) (*sleet.AuthorizationResponse, error) {
 	cybersourceAuthRequest, err := buildAuthRequest(request)
 	if err != nil {
-		return nil, err
+		return nil, common.OperationError(gatewayName, sleet.OperationAuthorize, err)
+	}
+	response, err := client.sendAuthRequest(ctx, cybersourceAuthRequest, request.Options)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationAuthorize, err)
+	}
+	return response, nil
+}
+
+// Sale authorizes and captures a payment through CyberSource in a single call by setting
+// processingInformation.capture. If successful, the sale response will be returned.
+func (client *CybersourceClient) Sale(request *sleet.SaleRequest) (*sleet.SaleResponse, error) {
+	return client.SaleWithContext(context.TODO(), request)
+}
+
+// SaleWithContext authorizes and captures a payment through CyberSource in a single call by setting
+// processingInformation.capture. If successful, the sale response will be returned.
+func (client *CybersourceClient) SaleWithContext(ctx context.Context, request *sleet.SaleRequest) (*sleet.SaleResponse, error) {
+	cybersourceSaleRequest, err := buildSaleRequest(request)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationSale, err)
+	}
+	resp, err := client.sendAuthRequest(ctx, cybersourceSaleRequest, request.Options)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationSale, err)
+	}
+	return &sleet.SaleResponse{AuthorizationResponse: *resp}, nil
+}
+
+// Verify validates a card through CyberSource with a zero amount authorization, which holds no funds and needs no
+// reversal.
+func (client *CybersourceClient) Verify(request *sleet.VerificationRequest) (*sleet.VerificationResponse, error) {
+	return client.VerifyWithContext(context.TODO(), request)
+}
+
+// VerifyWithContext validates a card through CyberSource with a zero amount authorization, which holds no funds and
+// needs no reversal.
+func (client *CybersourceClient) VerifyWithContext(ctx context.Context, request *sleet.VerificationRequest) (*sleet.VerificationResponse, error) {
+	cybersourceVerificationRequest, err := buildVerificationRequest(request)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationVerify, err)
+	}
+	resp, err := client.sendAuthRequest(ctx, cybersourceVerificationRequest, request.Options)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationVerify, err)
 	}
+	return &sleet.VerificationResponse{AuthorizationResponse: *resp}, nil
+}
 
-	cybersourceResponse, httpResponse, err := client.sendRequest(ctx, authPath, cybersourceAuthRequest)
+// sendAuthRequest sends an authorization or sale request and translates the response
+func (client *CybersourceClient) sendAuthRequest(ctx context.Context, request *Request, options map[string]interface{}) (*sleet.AuthorizationResponse, error) {
+	cybersourceResponse, httpResponse, err := client.sendRequest(ctx, authPath, request)
 	if err != nil {
 		return nil, err
 	}
 
-	responseHeader := sleet.GetHTTPResponseHeader(request.Options, *httpResponse)
+	responseHeader := sleet.GetHTTPResponseHeader(options, *httpResponse)
 	// Status 400 or 502 - Failed
 	if cybersourceResponse.ErrorReason != nil {
+		resultType := translateResultType(*cybersourceResponse.ErrorReason, cybersourceResponse.Status, httpResponse.StatusCode)
 		response := sleet.AuthorizationResponse{
-			Success:    false,
-			ErrorCode:  *cybersourceResponse.ErrorReason,
-			StatusCode: httpResponse.StatusCode,
-			Header:     responseHeader,
+			Success:       false,
+			ErrorCode:     *cybersourceResponse.ErrorReason,
+			ResultType:    resultType,
+			DeclineReason: common.TranslateDeclineReason(declineReasonMap, *cybersourceResponse.ErrorReason, resultType),
+			StatusCode:    httpResponse.StatusCode,
+			Header:        responseHeader,
 		}
 		return &response, nil
 		// Status 401 - during a cybersource outage, most fields were empty and ID was nil
 	} else if cybersourceResponse.ID == nil {
-		return &sleet.AuthorizationResponse{Success: false}, nil
+		return nil, common.ResponseError(gatewayName, httpResponse.StatusCode, errors.New("response has no ID"))
 	}
 
 	// Status 201 - Succeeded or failed
@@ -100,11 +192,18 @@ func (client *CybersourceClient) AuthorizeWithContext(ctx context.Context, reque
 		success = true
 	}
 
+	resultType := sleet.ResultTypeSuccess
+	if !success {
+		resultType = translateResultType(errorCode, cybersourceResponse.Status, httpResponse.StatusCode)
+	}
+
 	response := &sleet.AuthorizationResponse{
 		Success:              success,
 		TransactionReference: *cybersourceResponse.ID,
 		Response:             cybersourceResponse.Status,
 		ErrorCode:            errorCode,
+		ResultType:           resultType,
+		DeclineReason:        common.TranslateDeclineReason(declineReasonMap, errorCode, resultType),
 		StatusCode:           httpResponse.StatusCode,
 		Header:               responseHeader,
 	}
@@ -115,9 +214,73 @@ func (client *CybersourceClient) AuthorizeWithContext(ctx context.Context, reque
 		response.CvvResultRaw = cybersourceResponse.ProcessorInformation.CardVerification.ResultCode
 		response.ExternalTransactionID = cybersourceResponse.ProcessorInformation.TransactionID
 	}
+	// authorizedAmount is less than the totalAmount requested when the status is PARTIAL_AUTHORIZED. The authorization
+	// stands even if it can't be parsed, so the response is kept without an ApprovedAmount.
+	if cybersourceResponse.OrderInformation != nil && cybersourceResponse.OrderInformation.AmountDetails.AuthorizedAmount != "" {
+		amountDetails := cybersourceResponse.OrderInformation.AmountDetails
+		approvedAmount, err := common.AmountFromDecimalString(amountDetails.AuthorizedAmount, amountDetails.Currency)
+		if err != nil {
+			common.Logger(ctx, client.logger).Warn(common.LogInvalidField, "gateway", gatewayName, "field", "authorizedAmount", "error", err)
+		} else {
+			response.ApprovedAmount = &sleet.Amount{Amount: approvedAmount, Currency: amountDetails.Currency}
+		}
+	}
 	return response, nil
 }
 
+// IncrementAuthorization raises the amount of an authorized CyberSource payment with an incremental authorization.
+// If successful, the authorization can be captured up to its new total.
+func (client *CybersourceClient) IncrementAuthorization(request *sleet.IncrementAuthorizationRequest) (*sleet.IncrementAuthorizationResponse, error) {
+	return client.IncrementAuthorizationWithContext(context.TODO(), request)
+}
+
+// IncrementAuthorizationWithContext raises the amount of an authorized CyberSource payment with an incremental
+// authorization. If successful, the authorization can be captured up to its new total. CyberSource doesn't report the
+// new total, so the request must have the AuthorizedAmount.
+func (client *CybersourceClient) IncrementAuthorizationWithContext(ctx context.Context, request *sleet.IncrementAuthorizationRequest) (*sleet.IncrementAuthorizationResponse, error) {
+	if request.TransactionReference == "" {
+		return nil, common.OperationError(gatewayName, sleet.OperationIncrementAuthorization, errors.New("TransactionReference given to increment authorization request is empty"))
+	}
+	if request.AuthorizedAmount == 0 {
+		return nil, common.OperationError(gatewayName, sleet.OperationIncrementAuthorization, sleet.ErrAuthorizedAmountRequired)
+	}
+	cybersourceIncrementRequest, err := buildIncrementAuthorizationRequest(request)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationIncrementAuthorization, err)
+	}
+	cybersourceResponse, httpResponse, err := client.sendRequestWithMethod(ctx, http.MethodPatch, authPath+request.TransactionReference, cybersourceIncrementRequest)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationIncrementAuthorization, err)
+	}
+	if cybersourceResponse.ErrorInformation != nil {
+		return &sleet.IncrementAuthorizationResponse{
+			Success:   false,
+			ErrorCode: &cybersourceResponse.ErrorInformation.Reason,
+		}, nil
+	}
+	if cybersourceResponse.ErrorReason != nil {
+		return &sleet.IncrementAuthorizationResponse{
+			Success:   false,
+			ErrorCode: cybersourceResponse.ErrorReason,
+		}, nil
+	}
+	if cybersourceResponse.ID == nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationIncrementAuthorization, common.ResponseError(gatewayName, httpResponse.StatusCode, errors.New("response has no ID")))
+	}
+	if cybersourceResponse.Status != "AUTHORIZED" {
+		return &sleet.IncrementAuthorizationResponse{
+			Success:              false,
+			TransactionReference: *cybersourceResponse.ID,
+			ErrorCode:            common.SPtr(cybersourceResponse.Status),
+		}, nil
+	}
+	return &sleet.IncrementAuthorizationResponse{
+		Success:              true,
+		TransactionReference: *cybersourceResponse.ID,
+		AuthorizedAmount:     request.NewAuthorizedAmount(),
+	}, nil
+}
+
 // Capture captures an authorized payment through CyberSource. If successful, the capture response will be returned.
 // Multiple captures can be made on the same authorization, but the total amount captured should not exceed the
 // total authorized amount.
@@ -130,30 +293,50 @@ func (client *CybersourceClient) Capture(request *sleet.CaptureRequest) (*sleet.
 // total authorized amount.
 func (client *CybersourceClient) CaptureWithContext(ctx context.Context, request *sleet.CaptureRequest) (*sleet.CaptureResponse, error) {
 	if request.TransactionReference == "" {
-		return nil, errors.New("TransactionReference given to capture request is empty")
+		return nil, common.OperationError(gatewayName, sleet.OperationCapture, errors.New("TransactionReference given to capture request is empty"))
 	}
 	cybersourceCaptureRequest, err := buildCaptureRequest(request)
 	if err != nil {
-		return nil, err
+		return nil, common.OperationError(gatewayName, sleet.OperationCapture, err)
 	}
 	capturePath := authPath + request.TransactionReference + "/captures"
-	cybersourceResponse, _, err := client.sendRequest(ctx, capturePath, cybersourceCaptureRequest)
+	cybersourceResponse, httpResponse, err := client.sendRequest(ctx, capturePath, cybersourceCaptureRequest)
 	if err != nil {
-		return nil, err
+		return nil, common.OperationError(gatewayName, sleet.OperationCapture, err)
 	}
+	responseHeader := sleet.GetHTTPResponseHeader(request.Options, *httpResponse)
 	if cybersourceResponse.ErrorInformation != nil {
 		return &sleet.CaptureResponse{
-			Success:   false,
-			ErrorCode: &cybersourceResponse.ErrorInformation.Reason,
+			Success:    false,
+			ErrorCode:  &cybersourceResponse.ErrorInformation.Reason,
+			Message:    cybersourceResponse.ErrorInformation.Message,
+			ResultType: translateResultType(cybersourceResponse.ErrorInformation.Reason, cybersourceResponse.Status, httpResponse.StatusCode),
+			StatusCode: httpResponse.StatusCode,
+			Header:     responseHeader,
 		}, nil
 	}
-	if cybersourceResponse.ErrorReason != nil || cybersourceResponse.ID == nil {
+	if cybersourceResponse.ErrorReason != nil {
 		return &sleet.CaptureResponse{
-			Success:   false,
-			ErrorCode: cybersourceResponse.ErrorReason,
+			Success:    false,
+			ErrorCode:  cybersourceResponse.ErrorReason,
+			Message:    common.SafeStr(cybersourceResponse.ErrorMessage),
+			ResultType: translateResultType(common.SafeStr(cybersourceResponse.ErrorReason), cybersourceResponse.Status, httpResponse.StatusCode),
+			StatusCode: httpResponse.StatusCode,
+			Header:     responseHeader,
 		}, nil
 	}
-	return &sleet.CaptureResponse{Success: true, TransactionReference: *cybersourceResponse.ID}, nil
+	if cybersourceResponse.ID == nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationCapture, common.ResponseError(gatewayName, httpResponse.StatusCode, errors.New("response has no ID")))
+	}
+	return &sleet.CaptureResponse{
+		Success:              true,
+		TransactionReference: *cybersourceResponse.ID,
+		ResultType:           sleet.ResultTypeSuccess,
+		Amount:               translateAmount(cybersourceResponse, request.Amount),
+		Metadata:             buildResponseMetadata(cybersourceResponse),
+		StatusCode:           httpResponse.StatusCode,
+		Header:               responseHeader,
+	}, nil
 }
 
 // Void cancels a CyberSource payment. If successful, the void response will be returned. A previously voided
@@ -166,30 +349,49 @@ func (client *CybersourceClient) Void(request *sleet.VoidRequest) (*sleet.VoidRe
 // payment or one that has already been settled cannot be voided.
 func (client *CybersourceClient) VoidWithContext(ctx context.Context, request *sleet.VoidRequest) (*sleet.VoidResponse, error) {
 	if request.TransactionReference == "" {
-		return nil, errors.New("TransactionReference given to void request is empty")
+		return nil, common.OperationError(gatewayName, sleet.OperationVoid, errors.New("TransactionReference given to void request is empty"))
 	}
 	cybersourceVoidRequest, err := buildVoidRequest(request)
 	if err != nil {
-		return nil, err
+		return nil, common.OperationError(gatewayName, sleet.OperationVoid, err)
 	}
 	voidPath := authPath + request.TransactionReference + "/voids"
-	cybersourceResponse, _, err := client.sendRequest(ctx, voidPath, cybersourceVoidRequest)
+	cybersourceResponse, httpResponse, err := client.sendRequest(ctx, voidPath, cybersourceVoidRequest)
 	if err != nil {
-		return nil, err
+		return nil, common.OperationError(gatewayName, sleet.OperationVoid, err)
 	}
+	responseHeader := sleet.GetHTTPResponseHeader(request.Options, *httpResponse)
 	if cybersourceResponse.ErrorInformation != nil {
 		return &sleet.VoidResponse{
-			Success:   false,
-			ErrorCode: &cybersourceResponse.ErrorInformation.Reason,
+			Success:    false,
+			ErrorCode:  &cybersourceResponse.ErrorInformation.Reason,
+			Message:    cybersourceResponse.ErrorInformation.Message,
+			ResultType: translateResultType(cybersourceResponse.ErrorInformation.Reason, cybersourceResponse.Status, httpResponse.StatusCode),
+			StatusCode: httpResponse.StatusCode,
+			Header:     responseHeader,
 		}, nil
 	}
 	if cybersourceResponse.ErrorReason != nil {
 		return &sleet.VoidResponse{
-			Success:   false,
-			ErrorCode: cybersourceResponse.ErrorReason,
+			Success:    false,
+			ErrorCode:  cybersourceResponse.ErrorReason,
+			Message:    common.SafeStr(cybersourceResponse.ErrorMessage),
+			ResultType: translateResultType(common.SafeStr(cybersourceResponse.ErrorReason), cybersourceResponse.Status, httpResponse.StatusCode),
+			StatusCode: httpResponse.StatusCode,
+			Header:     responseHeader,
 		}, nil
 	}
-	return &sleet.VoidResponse{TransactionReference: *cybersourceResponse.ID, Success: true}, nil
+	if cybersourceResponse.ID == nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationVoid, common.ResponseError(gatewayName, httpResponse.StatusCode, errors.New("response has no ID")))
+	}
+	return &sleet.VoidResponse{
+		TransactionReference: *cybersourceResponse.ID,
+		Success:              true,
+		ResultType:           sleet.ResultTypeSuccess,
+		Metadata:             buildResponseMetadata(cybersourceResponse),
+		StatusCode:           httpResponse.StatusCode,
+		Header:               responseHeader,
+	}, nil
 }
 
 // Refund refunds a CyberSource payment. If successful, the refund response will be returned. Multiple
@@ -202,65 +404,294 @@ func (client *CybersourceClient) Refund(request *sleet.RefundRequest) (*sleet.Re
 // refunds can be made on the same payment, but the total amount refunded should not exceed the payment total.
 func (client *CybersourceClient) RefundWithContext(ctx context.Context, request *sleet.RefundRequest) (*sleet.RefundResponse, error) {
 	if request.TransactionReference == "" {
-		return nil, errors.New("TransactionReference given to refund request is empty")
+		return nil, common.OperationError(gatewayName, sleet.OperationRefund, errors.New("TransactionReference given to refund request is empty"))
 	}
 	cybersourceRefundRequest, err := buildRefundRequest(request)
 	if err != nil {
-		return nil, err
+		return nil, common.OperationError(gatewayName, sleet.OperationRefund, err)
 	}
 	refundPath := authPath + request.TransactionReference + "/refunds"
-	cybersourceResponse, _, err := client.sendRequest(ctx, refundPath, cybersourceRefundRequest)
+	cybersourceResponse, httpResponse, err := client.sendRequest(ctx, refundPath, cybersourceRefundRequest)
 	if err != nil {
-		return nil, err
+		return nil, common.OperationError(gatewayName, sleet.OperationRefund, err)
 	}
+	responseHeader := sleet.GetHTTPResponseHeader(request.Options, *httpResponse)
 	if cybersourceResponse.ErrorInformation != nil {
 		return &sleet.RefundResponse{
-			Success:   false,
-			ErrorCode: &cybersourceResponse.ErrorInformation.Reason,
+			Success:    false,
+			ErrorCode:  &cybersourceResponse.ErrorInformation.Reason,
+			Message:    cybersourceResponse.ErrorInformation.Message,
+			ResultType: translateResultType(cybersourceResponse.ErrorInformation.Reason, cybersourceResponse.Status, httpResponse.StatusCode),
+			StatusCode: httpResponse.StatusCode,
+			Header:     responseHeader,
 		}, nil
 	}
 	if cybersourceResponse.ErrorReason != nil {
 		return &sleet.RefundResponse{
+			Success:    false,
+			ErrorCode:  cybersourceResponse.ErrorReason,
+			Message:    common.SafeStr(cybersourceResponse.ErrorMessage),
+			ResultType: translateResultType(common.SafeStr(cybersourceResponse.ErrorReason), cybersourceResponse.Status, httpResponse.StatusCode),
+			StatusCode: httpResponse.StatusCode,
+			Header:     responseHeader,
+		}, nil
+	}
+	if cybersourceResponse.ID == nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationRefund, common.ResponseError(gatewayName, httpResponse.StatusCode, errors.New("response has no ID")))
+	}
+	return &sleet.RefundResponse{
+		Success:              true,
+		TransactionReference: *cybersourceResponse.ID,
+		ResultType:           sleet.ResultTypeSuccess,
+		Amount:               translateAmount(cybersourceResponse, request.Amount),
+		Metadata:             buildResponseMetadata(cybersourceResponse),
+		StatusCode:           httpResponse.StatusCode,
+		Header:               responseHeader,
+	}, nil
+}
+
+// ReverseTimedOutAuthorization reverses an authorization whose outcome is unknown by the ClientTransactionReference,
+// which is sent to CyberSource as the client reference transaction id of the authorization.
+func (client *CybersourceClient) ReverseTimedOutAuthorization(request *sleet.TimeoutReversalRequest) (*sleet.TimeoutReversalResponse, error) {
+	return client.ReverseTimedOutAuthorizationWithContext(context.TODO(), request)
+}
+
+// ReverseTimedOutAuthorizationWithContext reverses an authorization whose outcome is unknown by the
+// ClientTransactionReference, which is sent to CyberSource as the client reference transaction id of the authorization.
+// CyberSource answers MISSING_AUTH when it has no approved authorization for it, which is reported with NotAuthorized.
+func (client *CybersourceClient) ReverseTimedOutAuthorizationWithContext(ctx context.Context, request *sleet.TimeoutReversalRequest) (*sleet.TimeoutReversalResponse, error) {
+	cybersourceReversalRequest, err := buildTimeoutReversalRequest(request)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationReverseTimedOutAuthorization, err)
+	}
+	cybersourceResponse, _, err := client.sendRequest(ctx, timeoutReversalPath, cybersourceReversalRequest)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationReverseTimedOutAuthorization, err)
+	}
+	reason := cybersourceResponse.ErrorReason
+	if cybersourceResponse.ErrorInformation != nil {
+		reason = &cybersourceResponse.ErrorInformation.Reason
+	}
+	if reason != nil {
+		if *reason == "MISSING_AUTH" {
+			return &sleet.TimeoutReversalResponse{Success: true, NotAuthorized: true}, nil
+		}
+		return &sleet.TimeoutReversalResponse{
 			Success:   false,
-			ErrorCode: cybersourceResponse.ErrorReason,
+			ErrorCode: reason,
+		}, nil
+	}
+	if cybersourceResponse.Status != "REVERSED" {
+		return &sleet.TimeoutReversalResponse{
+			Success:   false,
+			ErrorCode: &cybersourceResponse.Status,
+		}, nil
+	}
+	return &sleet.TimeoutReversalResponse{TransactionReference: common.SafeStr(cybersourceResponse.ID), Success: true}, nil
+}
+
+// QueryTransaction retrieves a transaction and its follow-on transactions from the Transaction Details API.
+// CyberSource does not report settlement in transaction details, so captured transactions are never settled.
+func (client *CybersourceClient) QueryTransaction(request *sleet.TransactionQueryRequest) (*sleet.TransactionQueryResponse, error) {
+	return client.QueryTransactionWithContext(context.TODO(), request)
+}
+
+// QueryTransactionWithContext retrieves a transaction and its follow-on transactions from the Transaction Details API.
+// CyberSource does not report settlement in transaction details, so captured transactions are never settled.
+func (client *CybersourceClient) QueryTransactionWithContext(ctx context.Context, request *sleet.TransactionQueryRequest) (*sleet.TransactionQueryResponse, error) {
+	if request.TransactionReference == "" {
+		return nil, common.OperationError(gatewayName, sleet.OperationQueryTransaction, errors.New("TransactionReference given to query request is empty"))
+	}
+
+	transaction, httpResponse, err := client.sendGetRequest(ctx, transactionDetailsPath+request.TransactionReference)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationQueryTransaction, err)
+	}
+	if httpResponse.StatusCode != http.StatusOK {
+		return &sleet.TransactionQueryResponse{
+			Success:   false,
+			ErrorCode: transaction.ErrorReason,
+		}, nil
+	}
+
+	relatedTransactions := []*TransactionDetailsResponse{}
+	if transaction.Links != nil {
+		for _, link := range transaction.Links.RelatedTransactions {
+			relatedURL, err := url.Parse(link.Href)
+			if err != nil {
+				return nil, common.OperationError(gatewayName, sleet.OperationQueryTransaction, common.ResponseError(gatewayName, httpResponse.StatusCode, err))
+			}
+			related, httpResponse, err := client.sendGetRequest(ctx, relatedURL.Path)
+			if err != nil {
+				return nil, common.OperationError(gatewayName, sleet.OperationQueryTransaction, err)
+			}
+			if httpResponse.StatusCode != http.StatusOK {
+				return &sleet.TransactionQueryResponse{
+					Success:   false,
+					ErrorCode: related.ErrorReason,
+				}, nil
+			}
+			relatedTransactions = append(relatedTransactions, related)
+		}
+	}
+
+	response, err := translateTransactionDetails(transaction, relatedTransactions)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationQueryTransaction, common.ResponseError(gatewayName, httpResponse.StatusCode, err))
+	}
+	return response, nil
+}
+
+// FindByClientReference searches for transactions with the MerchantOrderReference as their client reference code
+// using the Transaction Search API. Follow-on transactions, such as captures, are returned as separate transactions.
+func (client *CybersourceClient) FindByClientReference(request *sleet.FindByClientReferenceRequest) (*sleet.FindByClientReferenceResponse, error) {
+	return client.FindByClientReferenceWithContext(context.TODO(), request)
+}
+
+// FindByClientReferenceWithContext searches for transactions with the MerchantOrderReference as their client reference
+// code using the Transaction Search API. Follow-on transactions, such as captures, are returned as separate transactions.
+func (client *CybersourceClient) FindByClientReferenceWithContext(ctx context.Context, request *sleet.FindByClientReferenceRequest) (*sleet.FindByClientReferenceResponse, error) {
+	searchRequest, err := buildSearchRequest(request)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationFindByClientReference, err)
+	}
+
+	searchResponse, httpResponse, err := client.sendSearchRequest(ctx, searchRequest)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationFindByClientReference, err)
+	}
+	if httpResponse.StatusCode != http.StatusCreated && httpResponse.StatusCode != http.StatusOK {
+		return &sleet.FindByClientReferenceResponse{
+			Success:   false,
+			ErrorCode: searchResponse.ErrorReason,
 		}, nil
 	}
-	return &sleet.RefundResponse{Success: true, TransactionReference: *cybersourceResponse.ID}, nil
+
+	transactions := []sleet.TransactionQueryResponse{}
+	if searchResponse.Embedded != nil {
+		for i := range searchResponse.Embedded.TransactionSummaries {
+			transaction, err := translateTransactionDetails(&searchResponse.Embedded.TransactionSummaries[i], nil)
+			if err != nil {
+				return nil, common.OperationError(gatewayName, sleet.OperationFindByClientReference, common.ResponseError(gatewayName, httpResponse.StatusCode, err))
+			}
+			transactions = append(transactions, *transaction)
+		}
+	}
+	return &sleet.FindByClientReferenceResponse{
+		Success:      true,
+		Transactions: transactions,
+	}, nil
+}
+
+// sendGetRequest retrieves a transaction from the Transaction Details API
+func (client *CybersourceClient) sendGetRequest(ctx context.Context, path string) (*TransactionDetailsResponse, *http.Response, error) {
+	req, err := client.buildGETRequest(ctx, path)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Add("User-Agent", common.UserAgent())
+	resp, err := client.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, common.TransportError(gatewayName, err)
+	}
+	defer func() {
+		err := resp.Body.Close()
+		if err != nil {
+			common.Logger(ctx, client.logger).Warn(common.LogCloseFailed, "gateway", gatewayName, "error", err)
+		}
+	}()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, common.TransportError(gatewayName, err)
+	}
+	var transactionDetailsResponse TransactionDetailsResponse
+	err = json.Unmarshal(respBody, &transactionDetailsResponse)
+	if err != nil {
+		return nil, nil, common.ResponseError(gatewayName, resp.StatusCode, err)
+	}
+	return &transactionDetailsResponse, resp, nil
+}
+
+// sendSearchRequest creates a search with the Transaction Search API, which responds with the matching transactions
+func (client *CybersourceClient) sendSearchRequest(ctx context.Context, data *SearchRequest) (*SearchResponse, *http.Response, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	req, err := client.buildPOSTRequest(ctx, searchPath, payload)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Add("User-Agent", common.UserAgent())
+	resp, err := client.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, common.TransportError(gatewayName, err)
+	}
+	defer func() {
+		err := resp.Body.Close()
+		if err != nil {
+			common.Logger(ctx, client.logger).Warn(common.LogCloseFailed, "gateway", gatewayName, "error", err)
+		}
+	}()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, common.TransportError(gatewayName, err)
+	}
+	var searchResponse SearchResponse
+	err = json.Unmarshal(respBody, &searchResponse)
+	if err != nil {
+		return nil, nil, common.ResponseError(gatewayName, resp.StatusCode, err)
+	}
+	return &searchResponse, resp, nil
 }
 
 // sendRequest sends an API request with the give payload to the specified CyberSource endpoint.
 // If the request is successfully sent, its response message will be returned.
 func (client *CybersourceClient) sendRequest(ctx context.Context, path string, data *Request) (*Response, *http.Response, error) {
+	return client.sendRequestWithMethod(ctx, http.MethodPost, path, data)
+}
+
+// sendRequestWithMethod sends an API request with the given payload and method, such as PATCH for incremental
+// authorizations, to the specified CyberSource endpoint.
+func (client *CybersourceClient) sendRequestWithMethod(ctx context.Context, method string, path string, data *Request) (*Response, *http.Response, error) {
 	payload, err := json.Marshal(data)
 	if err != nil {
 		return nil, nil, err
 	}
-	req, err := client.buildPOSTRequest(ctx, path, payload)
+	req, err := client.buildRequestWithBody(ctx, method, path, payload)
 	if err != nil {
 		return nil, nil, err
 	}
 	req.Header.Add("User-Agent", common.UserAgent())
 	resp, err := client.httpClient.Do(req)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, common.TransportError(gatewayName, err)
 	}
 	defer func() {
 		err := resp.Body.Close()
 		if err != nil {
-			// TODO log
+			common.Logger(ctx, client.logger).Warn(common.LogCloseFailed, "gateway", gatewayName, "error", err)
 		}
 	}()
 
-	fmt.Printf("status %s\n", resp.Status) // debug
 	respBody, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, common.TransportError(gatewayName, err)
 	}
 	var cybersourceResponse Response
 	err = json.Unmarshal(respBody, &cybersourceResponse)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, common.ResponseError(gatewayName, resp.StatusCode, err)
+	}
+	reason := ""
+	if cybersourceResponse.ErrorInformation != nil {
+		reason = cybersourceResponse.ErrorInformation.Reason
+	} else if cybersourceResponse.ErrorReason != nil {
+		reason = *cybersourceResponse.ErrorReason
 	}
+	common.LogResult(ctx, client.logger, gatewayName, cybersourceResponse.Status, "reason", reason)
 	return &cybersourceResponse, resp, nil
 }
 
@@ -268,35 +699,31 @@ func (client *CybersourceClient) sendRequest(ctx context.Context, path string, d
 // The HTTP request will be returned signed and ready to send, and its body and existing headers
 // should not be modified.
 func (client *CybersourceClient) buildPOSTRequest(ctx context.Context, path string, data []byte) (*http.Request, error) {
-	url := "https://" + client.host + path // weird thing where we need path to include forward /
+	return client.buildRequestWithBody(ctx, http.MethodPost, path, data)
+}
+
+// buildRequestWithBody creates a signed HTTP request with the given method for a payload, the request target of the
+// signature includes the method.
+func (client *CybersourceClient) buildRequestWithBody(ctx context.Context, method string, path string, data []byte) (*http.Request, error) {
+	url, host, target := client.requestTarget(path) // weird thing where we need path to include forward /
 
 	// Create request digest and signature
 	payloadHash := sha256.Sum256(data)
 	digest := "SHA-256=" + base64.StdEncoding.EncodeToString(payloadHash[:])
 	now := time.Now().UTC().Format(time.RFC1123Z)
-	sig := "host: " + client.host + "\ndate: " + now + "\n(request-target): post " + path + "\ndigest: " + digest + "\nv-c-merchant-id: " + client.merchantID
-	sigBytes := []byte(sig)
-	decodedSecret, err := base64.StdEncoding.DecodeString(client.sharedSecretKey)
+	sig := "host: " + host + "\ndate: " + now + "\n(request-target): " + strings.ToLower(method) + " " + target + "\ndigest: " + digest + "\nv-c-merchant-id: " + client.merchantID
+	signatureHeader, err := client.buildSignatureHeader(sig, "host date (request-target) digest v-c-merchant-id")
 	if err != nil {
 		return nil, err
 	}
-	hmacSha256 := hmac.New(sha256.New, decodedSecret)
-	hmacSha256.Write(sigBytes)
-	signature := base64.StdEncoding.EncodeToString(hmacSha256.Sum(nil))
 
-	// Create signature header
-	keyID := client.sharedSecretKeyID
-	algorithm := "HmacSHA256"
-	headers := "host date (request-target) digest v-c-merchant-id"
-	signatureHeader := fmt.Sprintf(`keyid="%s",algorithm="%s",headers="%s",signature="%s"`, keyID, algorithm, headers, signature)
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(string(data)))
+	req, err := http.NewRequestWithContext(ctx, method, url, strings.NewReader(string(data)))
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Add("v-c-merchant-id", client.merchantID)
-	req.Header.Add("Host", client.host)
+	req.Header.Add("Host", host)
 	req.Header.Add("Date", now)
 	req.Header.Add("Digest", digest)
 	req.Header.Add("Signature", signatureHeader)
@@ -304,3 +731,56 @@ func (client *CybersourceClient) buildPOSTRequest(ctx context.Context, path stri
 
 	return req, nil
 }
+
+// requestTarget returns the URL of the path, and the host and request target its signature covers. With a base URL
+// they are those of the URL the request is sent to, so the signature matches the request as it is received.
+func (client *CybersourceClient) requestTarget(path string) (string, string, string) {
+	if client.baseURL == "" {
+		return "https://" + client.host + path, client.host, path
+	}
+	target, err := url.Parse(common.OverrideBaseURL("https://"+client.host+path, client.baseURL))
+	if err != nil {
+		return client.baseURL, client.host, path
+	}
+	return target.String(), target.Host, target.RequestURI()
+}
+
+// buildGETRequest creates a signed HTTP request for a specified endpoint. GET requests have no body, so unlike
+// POST requests no digest is signed.
+func (client *CybersourceClient) buildGETRequest(ctx context.Context, path string) (*http.Request, error) {
+	url, host, target := client.requestTarget(path)
+
+	now := time.Now().UTC().Format(time.RFC1123Z)
+	sig := "host: " + host + "\ndate: " + now + "\n(request-target): get " + target + "\nv-c-merchant-id: " + client.merchantID
+	signatureHeader, err := client.buildSignatureHeader(sig, "host date (request-target) v-c-merchant-id")
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("v-c-merchant-id", client.merchantID)
+	req.Header.Add("Host", host)
+	req.Header.Add("Date", now)
+	req.Header.Add("Signature", signatureHeader)
+
+	return req, nil
+}
+
+// buildSignatureHeader signs the given signature string, which contains the given headers, with the shared secret key
+func (client *CybersourceClient) buildSignatureHeader(sig string, headers string) (string, error) {
+	decodedSecret, err := base64.StdEncoding.DecodeString(client.sharedSecretKey)
+	if err != nil {
+		return "", err
+	}
+	hmacSha256 := hmac.New(sha256.New, decodedSecret)
+	hmacSha256.Write([]byte(sig))
+	signature := base64.StdEncoding.EncodeToString(hmacSha256.Sum(nil))
+
+	keyID := client.sharedSecretKeyID
+	algorithm := "HmacSHA256"
+	return fmt.Sprintf(`keyid="%s",algorithm="%s",headers="%s",signature="%s"`, keyID, algorithm, headers, signature), nil
+}
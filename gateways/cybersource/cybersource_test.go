@@ -0,0 +1,156 @@
+//go:build unit
+// +build unit
+
+package cybersource
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+	sleet_testing "github.com/BoltApp/sleet/testing"
+)
+
+// warnings records the messages logged as warnings
+type warnings []string
+
+func (w *warnings) Debug(string, ...interface{})          {}
+func (w *warnings) Info(string, ...interface{})           {}
+func (w *warnings) Error(string, ...interface{})          {}
+func (w *warnings) Warn(message string, _ ...interface{}) { *w = append(*w, message) }
+
+// newTestClient returns a client whose requests are answered with the status code and body
+func newTestClient(t *testing.T, statusCode int, body string, options ...sleet.ClientOption) *CybersourceClient {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	options = append(options, sleet.WithBaseURL(server.URL))
+	return NewWithHttpClient(common.Custom, "merchant", "key id", "c2VjcmV0", server.Client(), options...)
+}
+
+func TestAuthorizeApprovedAmount(t *testing.T) {
+	authorized := func(authorizedAmount string) string {
+		return `{"id": "6790000000000000000001", "status": "PARTIAL_AUTHORIZED",
+			"orderInformation": {"amountDetails": {"authorizedAmount": "` + authorizedAmount + `", "currency": "USD"}}}`
+	}
+
+	t.Run("Partial Approval", func(t *testing.T) {
+		client := newTestClient(t, http.StatusCreated, authorized("0.60"))
+		got, err := client.Authorize(sleet_testing.BaseAuthorizationRequest())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.Success || got.ApprovedAmount == nil || *got.ApprovedAmount != (sleet.Amount{Amount: 60, Currency: "USD"}) {
+			t.Errorf("expected an approval of 60 USD, got %+v", got)
+		}
+	})
+
+	t.Run("Unparseable Amount Keeps The Authorization", func(t *testing.T) {
+		var logged warnings
+		client := newTestClient(t, http.StatusCreated, authorized("sixty cents"), sleet.WithLogger(&logged))
+		got, err := client.Authorize(sleet_testing.BaseAuthorizationRequest())
+		if err != nil {
+			t.Fatalf("expected the authorization to be returned, got %v", err)
+		}
+		if !got.Success || got.TransactionReference != "6790000000000000000001" || got.ApprovedAmount != nil {
+			t.Errorf("expected a successful authorization without an approved amount, got %+v", got)
+		}
+		if len(logged) != 1 || logged[0] != common.LogInvalidField {
+			t.Errorf("expected the parse failure to be logged, got %v", logged)
+		}
+	})
+}
+
+func TestResponseWithoutID(t *testing.T) {
+	// during a cybersource outage, 401s were returned with most fields empty
+	client := newTestClient(t, http.StatusUnauthorized, `{"status": ""}`)
+
+	t.Run("Authorize", func(t *testing.T) {
+		got, err := client.Authorize(sleet_testing.BaseAuthorizationRequest())
+		var sleetErr *sleet.Error
+		if !errors.As(err, &sleetErr) {
+			t.Fatalf("expected a *sleet.Error, got %v", err)
+		}
+		if sleetErr.Kind != sleet.ErrorKindAuthentication || sleetErr.StatusCode != http.StatusUnauthorized || sleetErr.Operation != sleet.OperationAuthorize {
+			t.Errorf("expected an authentication error of the authorization, got %+v", sleetErr)
+		}
+		if got != nil {
+			t.Errorf("expected no response, got %+v", got)
+		}
+	})
+
+	t.Run("Increment Authorization", func(t *testing.T) {
+		got, err := client.IncrementAuthorization(&sleet.IncrementAuthorizationRequest{
+			TransactionReference: "6790000000000000000001",
+			Amount:               sleet.Amount{Amount: 100, Currency: "USD"},
+			AuthorizedAmount:     100,
+		})
+		var sleetErr *sleet.Error
+		if !errors.As(err, &sleetErr) || sleetErr.Kind != sleet.ErrorKindAuthentication {
+			t.Errorf("expected an authentication error, got %v", err)
+		}
+		if got != nil {
+			t.Errorf("expected no response, got %+v", got)
+		}
+	})
+
+	t.Run("Void", func(t *testing.T) {
+		got, err := client.Void(&sleet.VoidRequest{TransactionReference: "6790000000000000000001"})
+		var sleetErr *sleet.Error
+		if !errors.As(err, &sleetErr) || sleetErr.Kind != sleet.ErrorKindAuthentication {
+			t.Errorf("expected an authentication error, got %v", err)
+		}
+		if got != nil {
+			t.Errorf("expected no response, got %+v", got)
+		}
+	})
+}
+
+func TestReverseTimedOutAuthorization(t *testing.T) {
+	cases := []struct {
+		name       string
+		statusCode int
+		body       string
+		want       sleet.TimeoutReversalResponse
+	}{
+		{"Reversed", http.StatusCreated, `{"id": "6790000000000000000002", "status": "REVERSED"}`,
+			sleet.TimeoutReversalResponse{Success: true, TransactionReference: "6790000000000000000002"}},
+		{"Not Authorized", http.StatusBadRequest, `{"status": "INVALID_REQUEST", "reason": "MISSING_AUTH"}`,
+			sleet.TimeoutReversalResponse{Success: true, NotAuthorized: true}},
+		{"Not Reversed", http.StatusCreated, `{"id": "6790000000000000000002", "status": "PENDING"}`,
+			sleet.TimeoutReversalResponse{Success: false, ErrorCode: common.SPtr("PENDING")}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			client := newTestClient(t, c.statusCode, c.body)
+			got, err := client.ReverseTimedOutAuthorization(sleet.NewTimeoutReversalRequest(sleet_testing.BaseAuthorizationRequest()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Success != c.want.Success || got.NotAuthorized != c.want.NotAuthorized ||
+				got.TransactionReference != c.want.TransactionReference || common.SafeStr(got.ErrorCode) != common.SafeStr(c.want.ErrorCode) {
+				t.Errorf("expected %+v, got %+v", c.want, got)
+			}
+		})
+	}
+}
+
+func TestIncrementAuthorizationRequiresAuthorizedAmount(t *testing.T) {
+	client := newTestClient(t, http.StatusCreated, `{"id": "6790000000000000000003", "status": "AUTHORIZED"}`)
+	request := sleet_testing.BaseIncrementAuthorizationRequest()
+	request.AuthorizedAmount = 0
+	got, err := client.IncrementAuthorization(request)
+	var sleetErr *sleet.Error
+	if !errors.As(err, &sleetErr) || sleetErr.Kind != sleet.ErrorKindValidation || !errors.Is(err, sleet.ErrAuthorizedAmountRequired) {
+		t.Errorf("expected a validation error for the missing AuthorizedAmount, got %v", err)
+	}
+	if got != nil {
+		t.Errorf("expected no response, got %+v", got)
+	}
+}
@@ -0,0 +1,28 @@
+package cybersource
+
+import (
+	"net/http"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/registry"
+)
+
+// CyberSource credential keys of a registry.Config, for HTTP signature authentication
+const (
+	credentialMerchantID        = "merchant_id"
+	credentialSharedSecretKeyID = "shared_secret_key_id"
+	credentialSharedSecretKey   = "shared_secret_key"
+)
+
+func init() {
+	registry.Register("cybersource", newRegistryClient)
+}
+
+// newRegistryClient builds a CybersourceClient from a registry config
+func newRegistryClient(config *registry.Config, httpClient *http.Client, options ...sleet.ClientOption) (sleet.ClientWithContext, error) {
+	credentials, err := config.RequiredCredentials(credentialMerchantID, credentialSharedSecretKeyID, credentialSharedSecretKey)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithHttpClient(config.Environment, credentials[0], credentials[1], credentials[2], httpClient, options...), nil
+}
@@ -32,7 +32,8 @@ func TestBuildAuthRequest(t *testing.T) {
 			base,
 			&Request{
 				ClientReferenceInformation: &ClientReferenceInformation{
-					Code: base.MerchantOrderReference,
+					Code:          base.MerchantOrderReference,
+					TransactionID: *base.ClientTransactionReference,
 					Partner: Partner{
 						SolutionID: base.Channel,
 					},
@@ -87,7 +88,8 @@ func TestBuildAuthRequest(t *testing.T) {
 			visaApplepayBase,
 			&Request{
 				ClientReferenceInformation: &ClientReferenceInformation{
-					Code: visaApplepayBase.MerchantOrderReference,
+					Code:          visaApplepayBase.MerchantOrderReference,
+					TransactionID: *visaApplepayBase.ClientTransactionReference,
 					Partner: Partner{
 						SolutionID: visaApplepayBase.Channel,
 					},
@@ -149,7 +151,8 @@ func TestBuildAuthRequest(t *testing.T) {
 			mastercardApplepayBase,
 			&Request{
 				ClientReferenceInformation: &ClientReferenceInformation{
-					Code: mastercardApplepayBase.MerchantOrderReference,
+					Code:          mastercardApplepayBase.MerchantOrderReference,
+					TransactionID: *mastercardApplepayBase.ClientTransactionReference,
 					Partner: Partner{
 						SolutionID: mastercardApplepayBase.Channel,
 					},
@@ -211,7 +214,8 @@ func TestBuildAuthRequest(t *testing.T) {
 			discoverApplepayBase,
 			&Request{
 				ClientReferenceInformation: &ClientReferenceInformation{
-					Code: discoverApplepayBase.MerchantOrderReference,
+					Code:          discoverApplepayBase.MerchantOrderReference,
+					TransactionID: *discoverApplepayBase.ClientTransactionReference,
 					Partner: Partner{
 						SolutionID: discoverApplepayBase.Channel,
 					},
@@ -272,7 +276,8 @@ func TestBuildAuthRequest(t *testing.T) {
 			amexApplepayBase,
 			&Request{
 				ClientReferenceInformation: &ClientReferenceInformation{
-					Code: amexApplepayBase.MerchantOrderReference,
+					Code:          amexApplepayBase.MerchantOrderReference,
+					TransactionID: *amexApplepayBase.ClientTransactionReference,
 					Partner: Partner{
 						SolutionID: amexApplepayBase.Channel,
 					},
@@ -333,7 +338,8 @@ func TestBuildAuthRequest(t *testing.T) {
 			amexLongCryptoApplepayBase,
 			&Request{
 				ClientReferenceInformation: &ClientReferenceInformation{
-					Code: amexLongCryptoApplepayBase.MerchantOrderReference,
+					Code:          amexLongCryptoApplepayBase.MerchantOrderReference,
+					TransactionID: *amexLongCryptoApplepayBase.ClientTransactionReference,
 					Partner: Partner{
 						SolutionID: amexLongCryptoApplepayBase.Channel,
 					},
@@ -402,6 +408,52 @@ func TestBuildAuthRequest(t *testing.T) {
 	}
 }
 
+func TestBuildSaleRequest(t *testing.T) {
+	base := sleet_testing.BaseAuthorizationRequest()
+
+	want, err := buildAuthRequest(base)
+	if err != nil {
+		t.Fatalf("Error thrown after building auth request %s", err)
+	}
+	want.ProcessingInformation.Capture = true
+
+	got, err := buildSaleRequest(base)
+	if err != nil {
+		t.Fatalf("Error thrown after building sale request %s", err)
+	}
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestBuildAuthRequestAllowPartialAuth(t *testing.T) {
+	base := sleet_testing.BaseAuthorizationRequest()
+	base.AllowPartialAuth = true
+
+	got, err := buildAuthRequest(base)
+	if err != nil {
+		t.Fatalf("Error thrown after building auth request %s", err)
+	}
+	if !got.ProcessingInformation.AuthorizationOptions.PartialAuthIndicator {
+		t.Error("expected partialAuthIndicator to be set")
+	}
+}
+
+func TestBuildVerificationRequest(t *testing.T) {
+	base := sleet_testing.BaseAuthorizationRequest()
+
+	got, err := buildVerificationRequest(base)
+	if err != nil {
+		t.Fatalf("Error thrown after building verification request %s", err)
+	}
+	if got.OrderInformation.AmountDetails.Amount != "0.00" {
+		t.Errorf("expected a zero amount, got %s", got.OrderInformation.AmountDetails.Amount)
+	}
+	if base.Amount.Amount == 0 {
+		t.Error("expected the request amount to be unchanged")
+	}
+}
+
 func TestBuildCaptureRequest(t *testing.T) {
 	base := sleet_testing.BaseCaptureRequest()
 	base.MerchantOrderReference = common.SPtr("cart_display_id")
@@ -444,6 +496,45 @@ func TestBuildCaptureRequest(t *testing.T) {
 	}
 }
 
+func TestBuildIncrementAuthorizationRequest(t *testing.T) {
+	base := sleet_testing.BaseIncrementAuthorizationRequest()
+	base.MerchantOrderReference = common.SPtr("cart_display_id")
+
+	want := &Request{
+		ProcessingInformation: &ProcessingInformation{
+			AuthorizationOptions: &AuthorizationOptions{
+				Initiator: &Initiator{
+					InitiatorType:        InitiatorTypeMerchant,
+					StoredCredentialUsed: true,
+				},
+			},
+		},
+		OrderInformation: &OrderInformation{
+			AmountDetails: AmountDetails{
+				AdditionalAmount: "0.50",
+				Currency:         "USD",
+			},
+		},
+		ClientReferenceInformation: &ClientReferenceInformation{
+			Code: *base.MerchantOrderReference,
+		},
+		MerchantDefinedInformation: []MerchantDefinedInformation{
+			{
+				Key:   "1",
+				Value: *base.ClientTransactionReference,
+			},
+		},
+	}
+
+	got, err := buildIncrementAuthorizationRequest(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Error(diff)
+	}
+}
+
 func TestBuildVoidRequest(t *testing.T) {
 	base := sleet_testing.BaseVoidRequest()
 	base.MerchantOrderReference = common.SPtr("cart_display_id")
@@ -582,3 +673,69 @@ func TestBuildCaptureRequestWithOptions(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildSearchRequest(t *testing.T) {
+	t.Run("With Merchant Order Reference", func(t *testing.T) {
+		want := &SearchRequest{
+			Name:     "sleet client reference search",
+			Timezone: "UTC",
+			Query:    "clientReferenceInformation.code:cart_display_id",
+			Limit:    searchLimit,
+			Sort:     "submitTimeUtc:desc",
+		}
+
+		got, err := buildSearchRequest(&sleet.FindByClientReferenceRequest{
+			MerchantOrderReference: common.SPtr("cart_display_id"),
+		})
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if diff := deep.Equal(got, want); diff != nil {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("Without Merchant Order Reference", func(t *testing.T) {
+		_, err := buildSearchRequest(&sleet.FindByClientReferenceRequest{})
+		if err != sleet.ErrClientReferenceRequired {
+			t.Errorf("expected ErrClientReferenceRequired, got %v", err)
+		}
+	})
+}
+
+func TestBuildTimeoutReversalRequest(t *testing.T) {
+	authRequest := sleet_testing.BaseAuthorizationRequest()
+	authRequest.MerchantOrderReference = "cart_display_id"
+	base := sleet.NewTimeoutReversalRequest(authRequest)
+
+	t.Run("With Client Transaction Reference", func(t *testing.T) {
+		want := &Request{
+			ClientReferenceInformation: &ClientReferenceInformation{
+				Code:          *base.MerchantOrderReference,
+				TransactionID: *base.ClientTransactionReference,
+			},
+			ReversalInformation: &ReversalInformation{
+				AmountDetails: AmountDetails{
+					Amount:   sleet.AmountToDecimalString(&base.Amount),
+					Currency: base.Amount.Currency,
+				},
+				Reason: "timeout",
+			},
+		}
+
+		got, err := buildTimeoutReversalRequest(base)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if diff := deep.Equal(got, want); diff != nil {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("Without Client Transaction Reference", func(t *testing.T) {
+		_, err := buildTimeoutReversalRequest(&sleet.TimeoutReversalRequest{})
+		if err != sleet.ErrClientReferenceRequired {
+			t.Errorf("expected ErrClientReferenceRequired, got %v", err)
+		}
+	})
+}
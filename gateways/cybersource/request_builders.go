@@ -15,6 +15,9 @@ const (
 	InitiatorTypeConsumer = "consumer"
 )
 
+// searchLimit is the maximum number of transactions returned by a search
+const searchLimit = 100
+
 const (
 	AmexCryptogramMaxLength   = 40
 	AmexCryptogramSplitLength = 20
@@ -66,13 +69,14 @@ func buildAuthRequest(authRequest *sleet.AuthorizationRequest) (*Request, error)
 	amountStr := sleet.AmountToDecimalString(&authRequest.Amount)
 	request := &Request{
 		ClientReferenceInformation: &ClientReferenceInformation{
-			Code: authRequest.MerchantOrderReference,
+			Code:          authRequest.MerchantOrderReference,
+			TransactionID: common.SafeStr(authRequest.ClientTransactionReference),
 			Partner: Partner{
 				SolutionID: authRequest.Channel,
 			},
 		},
 		ProcessingInformation: &ProcessingInformation{
-			Capture:           false, // no autocapture for now
+			Capture:           false, // captured by buildSaleRequest
 			CommerceIndicator: string(CommerceIndicatorInternet),
 			AuthorizationOptions: &AuthorizationOptions{
 				Initiator: &Initiator{
@@ -80,6 +84,7 @@ func buildAuthRequest(authRequest *sleet.AuthorizationRequest) (*Request, error)
 					CredentialStoredOnFile: credentialStoredOnFile,
 					StoredCredentialUsed:   storedCredentialUsed,
 				},
+				PartialAuthIndicator: authRequest.AllowPartialAuth,
 			},
 		},
 		PaymentInformation: &PaymentInformation{
@@ -159,6 +164,21 @@ func buildAuthRequest(authRequest *sleet.AuthorizationRequest) (*Request, error)
 	return request, nil
 }
 
+func buildSaleRequest(saleRequest *sleet.SaleRequest) (*Request, error) {
+	request, err := buildAuthRequest(saleRequest)
+	if err != nil {
+		return nil, err
+	}
+	request.ProcessingInformation.Capture = true
+	return request, nil
+}
+
+func buildVerificationRequest(verificationRequest *sleet.VerificationRequest) (*Request, error) {
+	authRequest := *verificationRequest
+	authRequest.Amount.Amount = 0
+	return buildAuthRequest(&authRequest)
+}
+
 func buildCaptureRequest(captureRequest *sleet.CaptureRequest) (*Request, error) {
 	amountStr := sleet.AmountToDecimalString(captureRequest.Amount)
 	request := &Request{
@@ -195,6 +215,39 @@ func buildCaptureRequest(captureRequest *sleet.CaptureRequest) (*Request, error)
 	return request, nil
 }
 
+// buildIncrementAuthorizationRequest raises the authorization by the additional amount, as a merchant initiated
+// transaction using the credentials stored by the authorization
+func buildIncrementAuthorizationRequest(incrementRequest *sleet.IncrementAuthorizationRequest) (*Request, error) {
+	request := &Request{
+		ProcessingInformation: &ProcessingInformation{
+			AuthorizationOptions: &AuthorizationOptions{
+				Initiator: &Initiator{
+					InitiatorType:        InitiatorTypeMerchant,
+					StoredCredentialUsed: true,
+				},
+			},
+		},
+		OrderInformation: &OrderInformation{
+			AmountDetails: AmountDetails{
+				AdditionalAmount: sleet.AmountToDecimalString(&incrementRequest.Amount),
+				Currency:         incrementRequest.Amount.Currency,
+			},
+		},
+	}
+	if incrementRequest.MerchantOrderReference != nil {
+		request.ClientReferenceInformation = &ClientReferenceInformation{
+			Code: *incrementRequest.MerchantOrderReference,
+		}
+	}
+	if incrementRequest.ClientTransactionReference != nil {
+		request.MerchantDefinedInformation = append(request.MerchantDefinedInformation, MerchantDefinedInformation{
+			Key:   "1",
+			Value: *incrementRequest.ClientTransactionReference,
+		})
+	}
+	return request, nil
+}
+
 func buildVoidRequest(voidRequest *sleet.VoidRequest) (*Request, error) {
 	// Maybe add reason / more details, but for now nothing
 	request := &Request{}
@@ -236,6 +289,42 @@ func buildRefundRequest(refundRequest *sleet.RefundRequest) (*Request, error) {
 	return request, nil
 }
 
+// buildTimeoutReversalRequest reverses the authorization sent with the ClientTransactionReference as its transaction id
+func buildTimeoutReversalRequest(reversalRequest *sleet.TimeoutReversalRequest) (*Request, error) {
+	if reversalRequest.ClientTransactionReference == nil {
+		return nil, sleet.ErrClientReferenceRequired
+	}
+	return &Request{
+		ClientReferenceInformation: &ClientReferenceInformation{
+			Code:          common.SafeStr(reversalRequest.MerchantOrderReference),
+			TransactionID: *reversalRequest.ClientTransactionReference,
+		},
+		ReversalInformation: &ReversalInformation{
+			AmountDetails: AmountDetails{
+				Amount:   sleet.AmountToDecimalString(&reversalRequest.Amount),
+				Currency: reversalRequest.Amount.Currency,
+			},
+			Reason: "timeout",
+		},
+	}, nil
+}
+
+// buildSearchRequest searches for the transactions sent with the MerchantOrderReference as the client reference code
+func buildSearchRequest(findRequest *sleet.FindByClientReferenceRequest) (*SearchRequest, error) {
+	if findRequest.MerchantOrderReference == nil {
+		return nil, sleet.ErrClientReferenceRequired
+	}
+	return &SearchRequest{
+		Save:     false,
+		Name:     "sleet client reference search",
+		Timezone: "UTC",
+		Query:    "clientReferenceInformation.code:" + *findRequest.MerchantOrderReference,
+		Offset:   0,
+		Limit:    searchLimit,
+		Sort:     "submitTimeUtc:desc",
+	}, nil
+}
+
 func buildApplepayRequest(authRequest *sleet.AuthorizationRequest, request *Request) error {
 	request.PaymentInformation = &PaymentInformation{
 		TokenizedCard: &TokenizedCard{
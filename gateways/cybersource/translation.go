@@ -1,6 +1,11 @@
 package cybersource
 
-import "github.com/BoltApp/sleet"
+import (
+	"time"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+)
 
 // Codes taken from: https://support.cybersource.com/s/article/Where-can-I-find-a-list-of-all-the-reply-codes-for-CVV-CVN-validation
 var cvvMap = map[string]sleet.CVVResponse{
@@ -65,3 +70,196 @@ func translateAvs(rawAvs string) sleet.AVSResponse {
 	}
 	return sleetCode
 }
+
+// Reasons taken from: https://developer.cybersource.com/api-reference-assets/index.html#payments (response codes)
+var resultTypeMap = map[string]sleet.ResultType{
+	"AVS_FAILED":                              sleet.ResultTypePaymentError,
+	"BLACKLISTED_CUSTOMER":                    sleet.ResultTypePaymentError,
+	"CONSUMER_AUTHENTICATION_FAILED":          sleet.ResultTypePaymentError,
+	"CONSUMER_AUTHENTICATION_REQUIRED":        sleet.ResultTypePaymentError,
+	"CONTACT_PROCESSOR":                       sleet.ResultTypePaymentError,
+	"CV_FAILED":                               sleet.ResultTypePaymentError,
+	"CVN_NOT_MATCH":                           sleet.ResultTypePaymentError,
+	"DECISION_PROFILE_REJECT":                 sleet.ResultTypePaymentError,
+	"EXCEEDS_CREDIT_LIMIT":                    sleet.ResultTypePaymentError,
+	"EXPIRED_CARD":                            sleet.ResultTypePaymentError,
+	"GENERAL_DECLINE":                         sleet.ResultTypePaymentError,
+	"INSUFFICIENT_FUND":                       sleet.ResultTypePaymentError,
+	"INVALID_ACCOUNT":                         sleet.ResultTypePaymentError,
+	"INVALID_CVN":                             sleet.ResultTypePaymentError,
+	"PAYMENT_REFUSED":                         sleet.ResultTypePaymentError,
+	"PROCESSOR_DECLINED":                      sleet.ResultTypePaymentError,
+	"SCORE_EXCEEDS_THRESHOLD":                 sleet.ResultTypePaymentError,
+	"STOLEN_LOST_CARD":                        sleet.ResultTypePaymentError,
+	"UNAUTHORIZED_CARD":                       sleet.ResultTypePaymentError,
+	"AUTHORIZATION_ALREADY_REVERSED":          sleet.ResultTypeAPIError,
+	"CARD_TYPE_NOT_ACCEPTED":                  sleet.ResultTypeAPIError,
+	"DUPLICATE_REQUEST":                       sleet.ResultTypeAPIError,
+	"EXCEEDS_AUTH_AMOUNT":                     sleet.ResultTypeAPIError,
+	"INVALID_AMOUNT":                          sleet.ResultTypeAPIError,
+	"INVALID_CARD":                            sleet.ResultTypeAPIError,
+	"INVALID_DATA":                            sleet.ResultTypeAPIError,
+	"INVALID_MERCHANT_CONFIGURATION":          sleet.ResultTypeAPIError,
+	"MISSING_AUTH":                            sleet.ResultTypeAPIError,
+	"MISSING_FIELD":                           sleet.ResultTypeAPIError,
+	"NOT_SUPPORTED":                           sleet.ResultTypeAPIError,
+	"TRANSACTION_ALREADY_REVERSED_OR_SETTLED": sleet.ResultTypeAPIError,
+	"ISSUER_UNAVAILABLE":                      sleet.ResultTypeServerError,
+	"PROCESSOR_ERROR":                         sleet.ResultTypeServerError,
+	"PROCESSOR_TIMEOUT":                       sleet.ResultTypeServerError,
+	"PROCESSOR_UNAVAILABLE":                   sleet.ResultTypeServerError,
+	"SERVER_TIMEOUT":                          sleet.ResultTypeServerError,
+	"SERVICE_TIMEOUT":                         sleet.ResultTypeServerError,
+	"SYSTEM_ERROR":                            sleet.ResultTypeServerError,
+}
+
+// translateResultType converts the reason CyberSource gave for a failed request to a Sleet result type. Unknown
+// reasons are classified by the status of the payment, then by the HTTP status of the response.
+func translateResultType(reason string, status string, statusCode int) sleet.ResultType {
+	if resultType, ok := resultTypeMap[reason]; ok {
+		return resultType
+	}
+	switch status {
+	case "DECLINED":
+		return sleet.ResultTypePaymentError
+	case "INVALID_REQUEST":
+		return sleet.ResultTypeAPIError
+	case "SERVER_ERROR":
+		return sleet.ResultTypeServerError
+	}
+	return common.ResultTypeFromHTTPStatus(statusCode)
+}
+
+var declineReasonMap = map[string]sleet.DeclineReason{
+	"AVS_FAILED":                       sleet.DeclineReasonAVSFailure,
+	"BLACKLISTED_CUSTOMER":             sleet.DeclineReasonSuspectedFraud,
+	"CONSUMER_AUTHENTICATION_FAILED":   sleet.DeclineReasonAuthenticationRequired,
+	"CONSUMER_AUTHENTICATION_REQUIRED": sleet.DeclineReasonAuthenticationRequired,
+	"CONTACT_PROCESSOR":                sleet.DeclineReasonCallIssuer,
+	"CV_FAILED":                        sleet.DeclineReasonCVVFailure,
+	"CVN_NOT_MATCH":                    sleet.DeclineReasonCVVFailure,
+	"DECISION_PROFILE_REJECT":          sleet.DeclineReasonSuspectedFraud,
+	"EXCEEDS_CREDIT_LIMIT":             sleet.DeclineReasonLimitExceeded,
+	"EXPIRED_CARD":                     sleet.DeclineReasonExpiredCard,
+	"GENERAL_DECLINE":                  sleet.DeclineReasonDoNotHonor,
+	"INSUFFICIENT_FUND":                sleet.DeclineReasonInsufficientFunds,
+	"INVALID_ACCOUNT":                  sleet.DeclineReasonInvalidCardNumber,
+	"INVALID_CVN":                      sleet.DeclineReasonCVVFailure,
+	"ISSUER_UNAVAILABLE":               sleet.DeclineReasonIssuerUnavailable,
+	"PAYMENT_REFUSED":                  sleet.DeclineReasonDoNotHonor,
+	"PROCESSOR_DECLINED":               sleet.DeclineReasonDoNotHonor,
+	"SCORE_EXCEEDS_THRESHOLD":          sleet.DeclineReasonSuspectedFraud,
+	"STOLEN_LOST_CARD":                 sleet.DeclineReasonLostOrStolenCard,
+	"UNAUTHORIZED_CARD":                sleet.DeclineReasonTransactionNotAllowed,
+}
+
+// translateAmount converts the total amount CyberSource reports for a capture or refund, falling back to the requested
+// amount when the response has none.
+func translateAmount(response *Response, requested *sleet.Amount) *sleet.Amount {
+	if response.OrderInformation == nil {
+		return requested
+	}
+	details := response.OrderInformation.AmountDetails
+	if details.Amount == "" || details.Currency == "" {
+		return requested
+	}
+	amount, err := common.AmountFromDecimalString(details.Amount, details.Currency)
+	if err != nil {
+		return requested
+	}
+	return &sleet.Amount{Amount: amount, Currency: details.Currency}
+}
+
+// buildResponseMetadata keeps the reconciliation ID CyberSource uses to match a transaction to its settlement
+func buildResponseMetadata(response *Response) map[string]string {
+	if response.ReconciliationID == nil {
+		return nil
+	}
+	return map[string]string{reconciliationIDMetadata: *response.ReconciliationID}
+}
+
+var cardTypeMap = map[CardType]sleet.CreditCardNetwork{
+	CardTypeVisa:       sleet.CreditCardNetworkVisa,
+	CardTypeMastercard: sleet.CreditCardNetworkMastercard,
+	CardTypeAmex:       sleet.CreditCardNetworkAmex,
+	CardTypeDiscover:   sleet.CreditCardNetworkDiscover,
+}
+
+// translateTransactionDetails combines a transaction and its follow-on transactions into a Sleet transaction query
+// response. The state is the furthest successful service run across all of them.
+func translateTransactionDetails(transaction *TransactionDetailsResponse, relatedTransactions []*TransactionDetailsResponse) (*sleet.TransactionQueryResponse, error) {
+	response := &sleet.TransactionQueryResponse{
+		Success:              true,
+		TransactionReference: transaction.ID,
+		State:                sleet.TransactionStateUnknown,
+	}
+	if transaction.ApplicationInformation != nil {
+		response.StateRaw = transaction.ApplicationInformation.Status
+	}
+	if transaction.PaymentInformation != nil && transaction.PaymentInformation.Card != nil {
+		response.Last4 = transaction.PaymentInformation.Card.Suffix
+		response.Network = cardTypeMap[transaction.PaymentInformation.Card.Type]
+	}
+	if submitTime, err := time.Parse(time.RFC3339, transaction.SubmitTimeUTC); err == nil {
+		response.CreatedAt = &submitTime
+	}
+
+	authorized, declined, voided := false, false, false
+	for _, details := range append([]*TransactionDetailsResponse{transaction}, relatedTransactions...) {
+		if details.ApplicationInformation == nil {
+			continue
+		}
+		var amount int64
+		if details.OrderInformation != nil {
+			amountDetails := details.OrderInformation.AmountDetails
+			response.Currency = amountDetails.Currency
+			if amountDetails.Amount != "" {
+				var err error
+				amount, err = common.AmountFromDecimalString(amountDetails.Amount, amountDetails.Currency)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+		for _, application := range details.ApplicationInformation.Applications {
+			succeeded := application.RCode == applicationSuccess
+			switch application.Name {
+			case applicationAuth:
+				authorized = authorized || succeeded
+				declined = declined || !succeeded
+				if succeeded {
+					response.AuthorizedAmount += amount
+				}
+			case applicationBill:
+				if succeeded {
+					response.CapturedAmount += amount
+				}
+			case applicationCredit:
+				if succeeded {
+					response.RefundedAmount += amount
+				}
+			case applicationAuthReversal, applicationVoid:
+				voided = voided || succeeded
+			}
+		}
+		if submitTime, err := time.Parse(time.RFC3339, details.SubmitTimeUTC); err == nil {
+			if response.UpdatedAt == nil || submitTime.After(*response.UpdatedAt) {
+				response.UpdatedAt = &submitTime
+			}
+		}
+	}
+
+	switch {
+	case voided:
+		response.State = sleet.TransactionStateVoided
+	case response.RefundedAmount > 0:
+		response.State = sleet.TransactionStateRefunded
+	case response.CapturedAmount > 0:
+		response.State = sleet.TransactionStateCaptured
+	case authorized:
+		response.State = sleet.TransactionStateAuthorized
+	case declined:
+		response.State = sleet.TransactionStateDeclined
+	}
+	return response, nil
+}
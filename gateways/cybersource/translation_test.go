@@ -0,0 +1,179 @@
+//go:build unit
+// +build unit
+
+package cybersource
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+)
+
+func TestTranslateTransactionDetails(t *testing.T) {
+	authorization := &TransactionDetailsResponse{
+		ID:            "6790000000000000000001",
+		SubmitTimeUTC: "2023-03-21T20:01:31Z",
+		ApplicationInformation: &ApplicationInformation{
+			Status:       "TRANSMITTED",
+			Applications: []Application{{Name: applicationAuth, RCode: applicationSuccess}},
+		},
+		OrderInformation: &OrderInformation{
+			AmountDetails: AmountDetails{Amount: "10.50", Currency: "USD"},
+		},
+		PaymentInformation: &TransactionDetailsPaymentDetails{
+			Card: &TransactionDetailsCard{Suffix: "1111", Type: CardTypeVisa},
+		},
+	}
+	capture := &TransactionDetailsResponse{
+		ID:            "6790000000000000000002",
+		SubmitTimeUTC: "2023-03-21T21:01:31Z",
+		ApplicationInformation: &ApplicationInformation{
+			Applications: []Application{{Name: applicationBill, RCode: applicationSuccess}},
+		},
+		OrderInformation: &OrderInformation{
+			AmountDetails: AmountDetails{Amount: "10.00", Currency: "USD"},
+		},
+	}
+	declined := &TransactionDetailsResponse{
+		ID:            "6790000000000000000003",
+		SubmitTimeUTC: "2023-03-21T20:01:31Z",
+		ApplicationInformation: &ApplicationInformation{
+			Status:       "DECLINED",
+			Applications: []Application{{Name: applicationAuth, RCode: "0"}},
+		},
+	}
+
+	createdAt := time.Date(2023, 3, 21, 20, 1, 31, 0, time.UTC)
+	capturedAt := time.Date(2023, 3, 21, 21, 1, 31, 0, time.UTC)
+
+	cases := []struct {
+		label   string
+		in      *TransactionDetailsResponse
+		related []*TransactionDetailsResponse
+		want    *sleet.TransactionQueryResponse
+	}{
+		{
+			"Captured authorization",
+			authorization,
+			[]*TransactionDetailsResponse{capture},
+			&sleet.TransactionQueryResponse{
+				Success:              true,
+				TransactionReference: authorization.ID,
+				State:                sleet.TransactionStateCaptured,
+				StateRaw:             "TRANSMITTED",
+				Currency:             "USD",
+				AuthorizedAmount:     1050,
+				CapturedAmount:       1000,
+				Last4:                "1111",
+				Network:              sleet.CreditCardNetworkVisa,
+				CreatedAt:            &createdAt,
+				UpdatedAt:            &capturedAt,
+			},
+		},
+		{
+			"Declined authorization",
+			declined,
+			nil,
+			&sleet.TransactionQueryResponse{
+				Success:              true,
+				TransactionReference: declined.ID,
+				State:                sleet.TransactionStateDeclined,
+				StateRaw:             "DECLINED",
+				CreatedAt:            &createdAt,
+				UpdatedAt:            &createdAt,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			got, err := translateTransactionDetails(c.in, c.related)
+			if err != nil {
+				t.Fatalf("error translating transaction details: %s", err)
+			}
+			if diff := deep.Equal(got, c.want); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestTranslateResultType(t *testing.T) {
+	cases := []struct {
+		reason     string
+		status     string
+		statusCode int
+		want       sleet.ResultType
+	}{
+		{"INSUFFICIENT_FUND", "DECLINED", 201, sleet.ResultTypePaymentError},
+		{"EXPIRED_CARD", "DECLINED", 201, sleet.ResultTypePaymentError},
+		{"STOLEN_LOST_CARD", "DECLINED", 201, sleet.ResultTypePaymentError},
+		{"NEW_DECLINE_REASON", "DECLINED", 201, sleet.ResultTypePaymentError},
+		{"MISSING_FIELD", "INVALID_REQUEST", 400, sleet.ResultTypeAPIError},
+		{"DUPLICATE_REQUEST", "INVALID_REQUEST", 400, sleet.ResultTypeAPIError},
+		{"TRANSACTION_ALREADY_REVERSED_OR_SETTLED", "INVALID_REQUEST", 400, sleet.ResultTypeAPIError},
+		{"PROCESSOR_UNAVAILABLE", "", 400, sleet.ResultTypeServerError},
+		{"SYSTEM_ERROR", "SERVER_ERROR", 502, sleet.ResultTypeServerError},
+		{"", "", 401, sleet.ResultTypeAPIError},
+		{"", "", 503, sleet.ResultTypeServerError},
+		{"", "", 201, sleet.ResultTypeUnknownError},
+	}
+	for _, c := range cases {
+		t.Run(c.reason+" "+c.status, func(t *testing.T) {
+			if got := translateResultType(c.reason, c.status, c.statusCode); got != c.want {
+				t.Errorf("expected %s, got %s", c.want, got)
+			}
+		})
+	}
+}
+
+func TestDeclineReasonMap(t *testing.T) {
+	cases := []struct {
+		reason string
+		status string
+		want   sleet.DeclineReason
+	}{
+		{"", "AUTHORIZED", ""},
+		{"INSUFFICIENT_FUND", "DECLINED", sleet.DeclineReasonInsufficientFunds},
+		{"STOLEN_LOST_CARD", "DECLINED", sleet.DeclineReasonLostOrStolenCard},
+		{"CV_FAILED", "AUTHORIZED_RISK_DECLINED", sleet.DeclineReasonCVVFailure},
+		{"NEW_DECLINE_REASON", "DECLINED", sleet.DeclineReasonUnknown},
+		{"MISSING_FIELD", "INVALID_REQUEST", ""},
+		{"ISSUER_UNAVAILABLE", "", sleet.DeclineReasonIssuerUnavailable},
+	}
+	for _, c := range cases {
+		t.Run(c.reason+" "+c.status, func(t *testing.T) {
+			resultType := sleet.ResultTypeSuccess
+			if c.status != "AUTHORIZED" {
+				resultType = translateResultType(c.reason, c.status, 201)
+			}
+			if got := common.TranslateDeclineReason(declineReasonMap, c.reason, resultType); got != c.want {
+				t.Errorf("expected %q, got %q", c.want, got)
+			}
+		})
+	}
+}
+
+func TestTranslateAmount(t *testing.T) {
+	requested := &sleet.Amount{Amount: 1000, Currency: "USD"}
+	cases := []struct {
+		label    string
+		response *Response
+		want     *sleet.Amount
+	}{
+		{"Reported Amount", &Response{OrderInformation: &OrderInformation{AmountDetails: AmountDetails{Amount: "7.50", Currency: "USD"}}}, &sleet.Amount{Amount: 750, Currency: "USD"}},
+		{"Missing Order Information", &Response{}, requested},
+		{"Missing Currency", &Response{OrderInformation: &OrderInformation{AmountDetails: AmountDetails{Amount: "7.50"}}}, requested},
+	}
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			if diff := deep.Equal(translateAmount(c.response, requested), c.want); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -27,6 +27,7 @@ type Request struct {
 	PaymentInformation                *PaymentInformation                `json:"paymentInformation,omitempty"`
 	MerchantDefinedInformation        []MerchantDefinedInformation       `json:"merchantDefinedInformation,omitempty"`
 	ConsumerAuthenticationInformation *ConsumerAuthenticationInformation `json:"consumerAuthenticationInformation,omitempty"`
+	ReversalInformation               *ReversalInformation               `json:"reversalInformation,omitempty"`
 }
 
 // Response contains all of the fields for all Cybersource API call responses
@@ -62,10 +63,16 @@ type Detail struct {
 // ClientReferenceInformation is used by the client to identify transactions on their side to tie with Cybersource transactions
 type ClientReferenceInformation struct {
 	Code          string  `json:"code"`
-	TransactionID string  `json:"transactionID"`
+	TransactionID string  `json:"transactionId,omitempty"` // merchant generated id, identifies an authorization for timeout reversals
 	Partner       Partner `json:"partner,omitempty"`
 }
 
+// ReversalInformation specifies the amount to reverse and why for authorization reversals
+type ReversalInformation struct {
+	AmountDetails AmountDetails `json:"amountDetails"`
+	Reason        string        `json:"reason,omitempty"`
+}
+
 type Partner struct {
 	SolutionID string `json:"solutionID,omitempty"`
 }
@@ -120,6 +127,7 @@ type BillingInformation struct {
 // AmountDetails specifies various amount, currency information for auth calls
 type AmountDetails struct {
 	AuthorizedAmount string `json:"authorizedAmount,omitempty"`
+	AdditionalAmount string `json:"additionalAmount,omitempty"` // incremental authorization field
 	Amount           string `json:"totalAmount,omitempty"`
 	Currency         string `json:"currency"`
 	DiscountAmount   string `json:"discountAmount,omitempty"` // Level 3 field
@@ -203,7 +211,8 @@ type Link struct {
 }
 
 type AuthorizationOptions struct {
-	Initiator *Initiator `json:"initiator,omitempty"`
+	Initiator            *Initiator `json:"initiator,omitempty"`
+	PartialAuthIndicator bool       `json:"partialAuthIndicator,omitempty"`
 }
 
 type Initiator struct {
@@ -223,3 +232,84 @@ type CaptureOptions struct {
 	CaptureSequenceNumber string `json:"captureSequenceNumber,omitempty"`
 	TotalCaptureCount     string `json:"totalCaptureCount,omitempty"`
 }
+
+// TransactionDetailsResponse is returned by the Transaction Details API for a single transaction.
+// Follow-on transactions, such as the capture of an authorization, are separate transactions referenced in Links.
+type TransactionDetailsResponse struct {
+	ID                     string                            `json:"id"`
+	SubmitTimeUTC          string                            `json:"submitTimeUTC"`
+	ApplicationInformation *ApplicationInformation           `json:"applicationInformation,omitempty"`
+	OrderInformation       *OrderInformation                 `json:"orderInformation,omitempty"`
+	PaymentInformation     *TransactionDetailsPaymentDetails `json:"paymentInformation,omitempty"`
+	Links                  *TransactionDetailsLinks          `json:"_links,omitempty"`
+	ErrorReason            *string                           `json:"reason,omitempty"`
+	ErrorMessage           *string                           `json:"message,omitempty"`
+}
+
+// ApplicationInformation lists the CyberSource services (applications) run for a transaction and their results
+type ApplicationInformation struct {
+	Status       string        `json:"status"`
+	ReasonCode   string        `json:"reasonCode"`
+	Applications []Application `json:"applications"`
+}
+
+// Application is the result of a single CyberSource service, RCode is "1" if the service succeeded
+type Application struct {
+	Name     string `json:"name"`
+	RCode    string `json:"rCode"`
+	RFlag    string `json:"rFlag"`
+	RMessage string `json:"rMessage"`
+}
+
+// SearchRequest searches transactions with the Transaction Search API, Query uses its field:value query syntax
+type SearchRequest struct {
+	Save     bool   `json:"save"`
+	Name     string `json:"name"`
+	Timezone string `json:"timezone"`
+	Query    string `json:"query"`
+	Offset   int    `json:"offset"`
+	Limit    int    `json:"limit"`
+	Sort     string `json:"sort"`
+}
+
+// SearchResponse is returned by the Transaction Search API. Transaction summaries have the same shape as the
+// Transaction Details API response without the links to follow-on transactions.
+type SearchResponse struct {
+	ID           string          `json:"id"`
+	TotalCount   int             `json:"totalCount"`
+	Embedded     *SearchEmbedded `json:"_embedded,omitempty"`
+	ErrorReason  *string         `json:"reason,omitempty"`
+	ErrorMessage *string         `json:"message,omitempty"`
+}
+
+// SearchEmbedded contains the transactions matching a search
+type SearchEmbedded struct {
+	TransactionSummaries []TransactionDetailsResponse `json:"transactionSummaries"`
+}
+
+// TransactionDetailsPaymentDetails has the masked card of a transaction
+type TransactionDetailsPaymentDetails struct {
+	Card *TransactionDetailsCard `json:"card,omitempty"`
+}
+
+// TransactionDetailsCard is the masked card of a transaction
+type TransactionDetailsCard struct {
+	Suffix string   `json:"suffix"`
+	Type   CardType `json:"type"`
+}
+
+// TransactionDetailsLinks references the transaction itself and its follow-on transactions
+type TransactionDetailsLinks struct {
+	Self                *Link  `json:"self,omitempty"`
+	RelatedTransactions []Link `json:"relatedTransactions,omitempty"`
+}
+
+// CyberSource services reported in ApplicationInformation
+const (
+	applicationAuth         = "ics_auth"
+	applicationAuthReversal = "ics_auth_reversal"
+	applicationBill         = "ics_bill"
+	applicationCredit       = "ics_credit"
+	applicationVoid         = "ics_void"
+	applicationSuccess      = "1"
+)
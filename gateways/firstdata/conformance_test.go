@@ -0,0 +1,31 @@
+//go:build unit
+// +build unit
+
+package firstdata
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+	"github.com/BoltApp/sleet/testing/conformance"
+	"github.com/BoltApp/sleet/testing/fakes"
+)
+
+func TestConformance(t *testing.T) {
+	fake := fakes.NewFirstdata()
+	defer fake.Close()
+
+	conformance.Run(t, conformance.Suite{
+		Gateway:   "firstdata",
+		Transport: fake.Client().Transport,
+		NewClient: func(httpClient *http.Client) sleet.ClientWithContext {
+			return NewWithHttpClient(common.Sandbox, Credentials{ApiKey: fakes.FirstdataApiKey, ApiSecret: fakes.FirstdataApiSecret}, httpClient)
+		},
+		Decline: func(request *sleet.AuthorizationRequest) { request.CreditCard.Number = fakes.FirstdataDeclinedCard },
+		// the request has no address the processor checks
+		AVS: sleet.AVSResponseSkipped,
+		CVV: sleet.CVVResponseMatch,
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -19,19 +20,27 @@ import (
 
 const (
 	endpoint = "/payments"
+
+	gatewayName = "firstdata"
 )
 
 var (
 	// assert client interface
-	_ sleet.ClientWithContext = &FirstdataClient{}
+	_ sleet.ClientWithContext             = &FirstdataClient{}
+	_ sleet.TransactionQuerierWithContext = &FirstdataClient{}
+	_ sleet.SaleWithContext               = &FirstdataClient{}
+	_ sleet.VerifierWithContext           = &FirstdataClient{}
+	_ sleet.CapabilitiesReporter          = &FirstdataClient{}
 )
 
 // FirstdataClient contains the endpoint and credentials for the firstdata api as well as a client to send requests
 type FirstdataClient struct {
 	host            string
+	baseURL         string // overrides the scheme and host of host when set
 	credentials     Credentials
 	clientRequestID string
 	httpClient      *http.Client
+	logger          sleet.Logger
 }
 
 // Credentials contains the merchant api key and secret for the firstdata gateway
@@ -41,24 +50,43 @@ type Credentials struct {
 }
 
 // NewClient creates a new firstdataClient with the given credentials and a default httpClient
-func NewClient(env common.Environment, credentials Credentials) *FirstdataClient {
+func NewClient(env common.Environment, credentials Credentials, options ...sleet.ClientOption) *FirstdataClient {
+	return NewWithHttpClient(env, credentials, common.DefaultHttpClient(), options...)
+}
+
+// NewWithHttpClient creates a new firstdataClient with the given credentials and a custom httpClient
+func NewWithHttpClient(env common.Environment, credentials Credentials, httpClient *http.Client, options ...sleet.ClientOption) *FirstdataClient {
+	clientOptions := sleet.NewClientOptions(options...)
 	return &FirstdataClient{
 		host:        firstdataHost(env),
+		baseURL:     clientOptions.BaseURL,
 		credentials: credentials,
-		httpClient:  common.DefaultHttpClient(),
+		httpClient:  common.LoggingHttpClient(httpClient, gatewayName, clientOptions.Logger),
+		logger:      clientOptions.Logger,
 	}
 }
 
 // primaryURL returns the url used for firstdata Primary Transactions (Auth)
 // https://docs.firstdata.com/org/gateway/docs/api#create-primary-transaction
 func (client *FirstdataClient) primaryURL() string {
-	return "https://" + client.host + endpoint
+	return common.OverrideBaseURL("https://"+client.host+endpoint, client.baseURL)
 }
 
 // secondaryURL composes the url used for firstdata Seconday Transactions (Capture,Void,Refund) given a transaction reference
 // https://docs.firstdata.com/org/gateway/docs/api#secondary-transaction
 func (client *FirstdataClient) secondaryURL(ref string) string {
-	return "https://" + client.host + endpoint + "/" + ref
+	return common.OverrideBaseURL("https://"+client.host+endpoint+"/"+ref, client.baseURL)
+}
+
+// Capabilities describes the optional operations supported by FirstData
+func (client *FirstdataClient) Capabilities() sleet.Capabilities {
+	return sleet.Capabilities{
+		ContextSupport:   true,
+		Sale:             true,
+		TransactionQuery: true,
+		PartialCapture:   true,
+		PartialRefund:    true,
+	}
 }
 
 // Authorize make a payment authorization request to FirstData for the given payment details. If successful, the
@@ -72,10 +100,51 @@ func (client *FirstdataClient) Authorize(request *sleet.AuthorizationRequest) (*
 func (client *FirstdataClient) AuthorizeWithContext(ctx context.Context, request *sleet.AuthorizationRequest) (*sleet.AuthorizationResponse, error) {
 	firstdataAuthRequest, err := buildAuthRequest(request)
 	if err != nil {
-		return nil, err
+		return nil, common.OperationError(gatewayName, sleet.OperationAuthorize, err)
 	}
+	response, err := client.sendPrimaryRequest(ctx, request, firstdataAuthRequest)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationAuthorize, err)
+	}
+	return response, nil
+}
 
-	firstdataResponse, httpResponse, err := client.sendRequest(ctx, *request.ClientTransactionReference, client.primaryURL(), *firstdataAuthRequest)
+// Sale makes a payment sale request to FirstData, authorizing and capturing the given payment details in a single
+// call. If successful, the sale response will be returned.
+func (client *FirstdataClient) Sale(request *sleet.SaleRequest) (*sleet.SaleResponse, error) {
+	return client.SaleWithContext(context.TODO(), request)
+}
+
+// SaleWithContext makes a payment sale request to FirstData, authorizing and capturing the given payment details in
+// a single call. If successful, the sale response will be returned.
+func (client *FirstdataClient) SaleWithContext(ctx context.Context, request *sleet.SaleRequest) (*sleet.SaleResponse, error) {
+	firstdataSaleRequest, err := buildSaleRequest(request)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationSale, err)
+	}
+	resp, err := client.sendPrimaryRequest(ctx, request, firstdataSaleRequest)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationSale, err)
+	}
+	return &sleet.SaleResponse{AuthorizationResponse: *resp}, nil
+}
+
+// Verify makes a pre-authorization request to FirstData for the given payment details and voids it once approved.
+// The verification response has the AVS and CVV results of the pre-authorization.
+func (client *FirstdataClient) Verify(request *sleet.VerificationRequest) (*sleet.VerificationResponse, error) {
+	return client.VerifyWithContext(context.TODO(), request)
+}
+
+// VerifyWithContext makes a pre-authorization request to FirstData for the given payment details and voids it once
+// approved. The verification response has the AVS and CVV results of the pre-authorization.
+func (client *FirstdataClient) VerifyWithContext(ctx context.Context, request *sleet.VerificationRequest) (*sleet.VerificationResponse, error) {
+	response, err := sleet.VerifyByAuthorization(ctx, client, request)
+	return response, common.OperationError(gatewayName, sleet.OperationVerify, err)
+}
+
+// sendPrimaryRequest sends an authorization or sale as a primary transaction and translates the response
+func (client *FirstdataClient) sendPrimaryRequest(ctx context.Context, request *sleet.AuthorizationRequest, firstdataRequest *Request) (*sleet.AuthorizationResponse, error) {
+	firstdataResponse, httpResponse, err := client.sendRequest(ctx, *request.ClientTransactionReference, client.primaryURL(), *firstdataRequest)
 	if err != nil {
 		return nil, err
 	}
@@ -83,11 +152,14 @@ func (client *FirstdataClient) AuthorizeWithContext(ctx context.Context, request
 	success := false
 	responseHeader := sleet.GetHTTPResponseHeader(request.Options, *httpResponse)
 	if firstdataResponse.Error != nil {
+		resultType := translateErrorResultType(firstdataResponse, httpResponse.StatusCode)
 		response := sleet.AuthorizationResponse{
-			Success:    false,
-			ErrorCode:  firstdataResponse.Error.Code,
-			StatusCode: httpResponse.StatusCode,
-			Header:     responseHeader,
+			Success:       false,
+			ErrorCode:     firstdataResponse.Error.Code,
+			ResultType:    resultType,
+			DeclineReason: translateDeclineReason(firstdataResponse, resultType),
+			StatusCode:    httpResponse.StatusCode,
+			Header:        responseHeader,
 		}
 		return &response, nil
 	}
@@ -97,15 +169,25 @@ func (client *FirstdataClient) AuthorizeWithContext(ctx context.Context, request
 	}
 
 	avs := firstdataResponse.Processor.AVSResponse
+	resultType := translateResultType(firstdataResponse.TransactionStatus, httpResponse.StatusCode)
+
+	// a declined transaction has no error, so the processor's response code explains it
+	errorCode := ""
+	if !success {
+		errorCode = firstdataResponse.Processor.ResponseCode
+	}
 
 	return &sleet.AuthorizationResponse{
 		Success:              success,
+		ErrorCode:            errorCode,
 		TransactionReference: firstdataResponse.IPGTransactionId,
 		AvsResult:            translateAvs(firstdataResponse.Processor.AVSResponse),
 		CvvResult:            translateCvv(firstdataResponse.Processor.SecurityCodeResponse),
 		Response:             string(firstdataResponse.TransactionState),
 		AvsResultRaw:         fmt.Sprintf("%s:%s", avs.StreetMatch, avs.PostCodeMatch),
 		CvvResultRaw:         string(firstdataResponse.Processor.SecurityCodeResponse),
+		ResultType:           resultType,
+		DeclineReason:        translateDeclineReason(firstdataResponse, resultType),
 		StatusCode:           httpResponse.StatusCode,
 		Header:               responseHeader,
 	}, nil
@@ -120,21 +202,37 @@ func (client *FirstdataClient) Capture(request *sleet.CaptureRequest) (*sleet.Ca
 func (client *FirstdataClient) CaptureWithContext(ctx context.Context, request *sleet.CaptureRequest) (*sleet.CaptureResponse, error) {
 	firstdataCaptureRequest := buildCaptureRequest(request)
 
-	firstdataResponse, _, err := client.sendRequest(ctx,
+	firstdataResponse, httpResponse, err := client.sendRequest(ctx,
 		*request.ClientTransactionReference,
 		client.secondaryURL(request.TransactionReference),
 		firstdataCaptureRequest,
 	)
 	if err != nil {
-		return nil, err
+		return nil, common.OperationError(gatewayName, sleet.OperationCapture, err)
 	}
 
+	responseHeader := sleet.GetHTTPResponseHeader(request.Options, *httpResponse)
 	if firstdataResponse.Error != nil {
-		response := sleet.CaptureResponse{Success: false, ErrorCode: &firstdataResponse.Error.Code}
+		response := sleet.CaptureResponse{
+			Success:    false,
+			ErrorCode:  &firstdataResponse.Error.Code,
+			Message:    firstdataResponse.Error.Message,
+			ResultType: translateErrorResultType(firstdataResponse, httpResponse.StatusCode),
+			StatusCode: httpResponse.StatusCode,
+			Header:     responseHeader,
+		}
 		return &response, nil
 	}
 
-	return &sleet.CaptureResponse{Success: true, TransactionReference: firstdataResponse.IPGTransactionId}, nil
+	return &sleet.CaptureResponse{
+		Success:              true,
+		TransactionReference: firstdataResponse.IPGTransactionId,
+		ResultType:           sleet.ResultTypeSuccess,
+		Amount:               translateApprovedAmount(firstdataResponse, request.Amount),
+		Metadata:             buildResponseMetadata(firstdataResponse),
+		StatusCode:           httpResponse.StatusCode,
+		Header:               responseHeader,
+	}, nil
 }
 
 // Void transforms a sleet void request into a first data VoidTransaction request and makes the request
@@ -148,20 +246,35 @@ func (client *FirstdataClient) Void(request *sleet.VoidRequest) (*sleet.VoidResp
 func (client *FirstdataClient) VoidWithContext(ctx context.Context, request *sleet.VoidRequest) (*sleet.VoidResponse, error) {
 	firstdataVoidRequest := buildVoidRequest(request)
 
-	firstdataResponse, _, err := client.sendRequest(ctx,
+	firstdataResponse, httpResponse, err := client.sendRequest(ctx,
 		*request.ClientTransactionReference,
 		client.secondaryURL(request.TransactionReference),
 		firstdataVoidRequest,
 	)
 	if err != nil {
-		return nil, err
+		return nil, common.OperationError(gatewayName, sleet.OperationVoid, err)
 	}
 
+	responseHeader := sleet.GetHTTPResponseHeader(request.Options, *httpResponse)
 	if firstdataResponse.Error != nil {
-		response := sleet.VoidResponse{Success: false, ErrorCode: &firstdataResponse.Error.Code}
+		response := sleet.VoidResponse{
+			Success:    false,
+			ErrorCode:  &firstdataResponse.Error.Code,
+			Message:    firstdataResponse.Error.Message,
+			ResultType: translateErrorResultType(firstdataResponse, httpResponse.StatusCode),
+			StatusCode: httpResponse.StatusCode,
+			Header:     responseHeader,
+		}
 		return &response, nil
 	}
-	return &sleet.VoidResponse{Success: true, TransactionReference: firstdataResponse.IPGTransactionId}, nil
+	return &sleet.VoidResponse{
+		Success:              true,
+		TransactionReference: firstdataResponse.IPGTransactionId,
+		ResultType:           sleet.ResultTypeSuccess,
+		Metadata:             buildResponseMetadata(firstdataResponse),
+		StatusCode:           httpResponse.StatusCode,
+		Header:               responseHeader,
+	}, nil
 }
 
 // Refund refunds a Firstdata payment.
@@ -175,21 +288,68 @@ func (client *FirstdataClient) Refund(request *sleet.RefundRequest) (*sleet.Refu
 func (client *FirstdataClient) RefundWithContext(ctx context.Context, request *sleet.RefundRequest) (*sleet.RefundResponse, error) {
 	firstdataRefundRequest := buildRefundRequest(request)
 
-	firstdataResponse, _, err := client.sendRequest(ctx,
+	firstdataResponse, httpResponse, err := client.sendRequest(ctx,
 		*request.ClientTransactionReference,
 		client.secondaryURL(request.TransactionReference),
 		firstdataRefundRequest,
 	)
 
 	if err != nil {
-		return nil, err
+		return nil, common.OperationError(gatewayName, sleet.OperationRefund, err)
 	}
 
+	responseHeader := sleet.GetHTTPResponseHeader(request.Options, *httpResponse)
 	if firstdataResponse.Error != nil {
-		response := sleet.RefundResponse{Success: false, ErrorCode: &firstdataResponse.Error.Code}
+		response := sleet.RefundResponse{
+			Success:    false,
+			ErrorCode:  &firstdataResponse.Error.Code,
+			Message:    firstdataResponse.Error.Message,
+			ResultType: translateErrorResultType(firstdataResponse, httpResponse.StatusCode),
+			StatusCode: httpResponse.StatusCode,
+			Header:     responseHeader,
+		}
 		return &response, nil
 	}
-	return &sleet.RefundResponse{Success: true, TransactionReference: firstdataResponse.IPGTransactionId}, nil
+	return &sleet.RefundResponse{
+		Success:              true,
+		TransactionReference: firstdataResponse.IPGTransactionId,
+		ResultType:           sleet.ResultTypeSuccess,
+		Amount:               translateApprovedAmount(firstdataResponse, request.Amount),
+		Metadata:             buildResponseMetadata(firstdataResponse),
+		StatusCode:           httpResponse.StatusCode,
+		Header:               responseHeader,
+	}, nil
+}
+
+// QueryTransaction retrieves the state of a transaction through FirstData.
+// ClientTransactionReference is required as FirstData requires a unique id for every request.
+func (client *FirstdataClient) QueryTransaction(request *sleet.TransactionQueryRequest) (*sleet.TransactionQueryResponse, error) {
+	return client.QueryTransactionWithContext(context.TODO(), request)
+}
+
+// QueryTransactionWithContext retrieves the state of a transaction through FirstData.
+// ClientTransactionReference is required as FirstData requires a unique id for every request.
+func (client *FirstdataClient) QueryTransactionWithContext(ctx context.Context, request *sleet.TransactionQueryRequest) (*sleet.TransactionQueryResponse, error) {
+	if request.ClientTransactionReference == nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationQueryTransaction, errors.New("ClientTransactionReference given to query request is empty"))
+	}
+
+	firstdataResponse, _, err := client.send(ctx,
+		http.MethodGet,
+		*request.ClientTransactionReference,
+		client.secondaryURL(request.TransactionReference),
+		nil,
+	)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationQueryTransaction, err)
+	}
+
+	if firstdataResponse.Error != nil {
+		response := sleet.TransactionQueryResponse{Success: false, ErrorCode: &firstdataResponse.Error.Code}
+		return &response, nil
+	}
+
+	return translateTransaction(firstdataResponse), nil
 }
 
 // makeSignature generates a signature in accordance with the first data specification https://docs.firstdata.com/org/gateway/node/394
@@ -211,12 +371,18 @@ func (client *FirstdataClient) sendRequest(ctx context.Context, reqId, url strin
 		return nil, nil, err
 	}
 
+	return client.send(ctx, http.MethodPost, reqId, url, bodyJSON)
+}
+
+// send signs and sends an API request with the given body, which is empty for GET requests, to the specified
+// firstdata endpoint. If the request is successfully sent, its response message will be returned.
+func (client *FirstdataClient) send(ctx context.Context, method, reqId, url string, body []byte) (*Response, *http.Response, error) {
 	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
-	signature := makeSignature(timestamp, client.credentials.ApiKey, client.credentials.ApiSecret, reqId, string(bodyJSON))
+	signature := makeSignature(timestamp, client.credentials.ApiKey, client.credentials.ApiSecret, reqId, string(body))
 
-	reader := bytes.NewReader(bodyJSON)
+	reader := bytes.NewReader(body)
 
-	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, reader)
+	request, err := http.NewRequestWithContext(ctx, method, url, reader)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -229,20 +395,22 @@ func (client *FirstdataClient) sendRequest(ctx context.Context, reqId, url strin
 
 	resp, err := client.httpClient.Do(request)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, common.TransportError(gatewayName, err)
 	}
 
 	defer resp.Body.Close()
 
-	body, err := ioutil.ReadAll(resp.Body)
+	respBody, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, common.TransportError(gatewayName, err)
 	}
 
 	var firstdataResponse Response
-	err = json.Unmarshal(body, &firstdataResponse)
+	err = json.Unmarshal(respBody, &firstdataResponse)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, common.ResponseError(gatewayName, resp.StatusCode, err)
 	}
+	common.LogResult(ctx, client.logger, gatewayName, firstdataResponse.Processor.ResponseCode,
+		"transaction_status", string(firstdataResponse.TransactionStatus))
 	return &firstdataResponse, resp, nil
 }
@@ -28,7 +28,8 @@ func TestNewClient(t *testing.T) {
 		want := &FirstdataClient{
 			host:        "cert.api.firstdata.com/gateway/v2",
 			credentials: Credentials{defaultApiKey, defaultApiSecret},
-			httpClient:  common.DefaultHttpClient(),
+			httpClient:  common.LoggingHttpClient(common.DefaultHttpClient(), gatewayName, sleet.NopLogger{}),
+			logger:      sleet.NopLogger{},
 		}
 
 		got := NewClient(common.Sandbox, Credentials{defaultApiKey, defaultApiSecret})
@@ -43,7 +44,8 @@ func TestNewClient(t *testing.T) {
 		want := &FirstdataClient{
 			host:        "prod.api.firstdata.com/gateway/v2",
 			credentials: Credentials{defaultApiKey, defaultApiSecret},
-			httpClient:  common.DefaultHttpClient(),
+			httpClient:  common.LoggingHttpClient(common.DefaultHttpClient(), gatewayName, sleet.NopLogger{}),
+			logger:      sleet.NopLogger{},
 		}
 
 		got := NewClient(common.Production, Credentials{defaultApiKey, defaultApiSecret})
@@ -78,6 +80,17 @@ func TestPrimaryURL(t *testing.T) {
 			t.Errorf("Got %q, want %q", got, want)
 		}
 	})
+
+	t.Run("Custom environment", func(t *testing.T) {
+		client := NewClient(common.Custom, Credentials{defaultApiKey, defaultApiSecret}, sleet.WithBaseURL("http://127.0.0.1:8080"))
+
+		want := "http://127.0.0.1:8080/gateway/v2/payments"
+		got := client.primaryURL()
+
+		if got != want {
+			t.Errorf("Got %q, want %q", got, want)
+		}
+	})
 }
 
 func TestSecondaryURL(t *testing.T) {
@@ -258,6 +271,7 @@ func TestAuthorize(t *testing.T) {
 			AvsResultRaw:         "NO_INPUT_DATA:NO_INPUT_DATA",
 			CvvResultRaw:         "NOT_CHECKED",
 			StatusCode:           200,
+			ResultType:           sleet.ResultTypeSuccess,
 		}
 
 		if !cmp.Equal(*got, *want, sleet_t.CompareUnexported) {
@@ -288,6 +302,7 @@ func TestAuthorize(t *testing.T) {
 			Success:    false,
 			ErrorCode:  "403",
 			StatusCode: 200,
+			ResultType: sleet.ResultTypeAPIError,
 		}
 
 		if !cmp.Equal(*got, *want, sleet_t.CompareUnexported) {
@@ -328,6 +343,10 @@ func TestCapture(t *testing.T) {
 		want := &sleet.CaptureResponse{
 			Success:              true,
 			TransactionReference: "84538652787",
+			ResultType:           sleet.ResultTypeSuccess,
+			Amount:               &sleet.Amount{Amount: 19, Currency: "USD"},
+			Metadata:             map[string]string{"authCode": "OK5922"},
+			StatusCode:           200,
 		}
 
 		if !cmp.Equal(*got, *want, sleet_t.CompareUnexported) {
@@ -356,8 +375,11 @@ func TestCapture(t *testing.T) {
 
 		errorCode := "403"
 		want := &sleet.CaptureResponse{
-			Success:   false,
-			ErrorCode: &errorCode,
+			Success:    false,
+			ErrorCode:  &errorCode,
+			ResultType: sleet.ResultTypeAPIError,
+			Message:    "Message Signature has expired",
+			StatusCode: 200,
 		}
 
 		if !cmp.Equal(*got, *want, sleet_t.CompareUnexported) {
@@ -396,6 +418,9 @@ func TestVoid(t *testing.T) {
 		want := &sleet.VoidResponse{
 			Success:              true,
 			TransactionReference: "84539110984",
+			ResultType:           sleet.ResultTypeSuccess,
+			Metadata:             map[string]string{"authCode": "OK5432"},
+			StatusCode:           200,
 		}
 
 		if !cmp.Equal(*got, *want, sleet_t.CompareUnexported) {
@@ -422,8 +447,11 @@ func TestVoid(t *testing.T) {
 
 		errorCode := "403"
 		want := &sleet.VoidResponse{
-			Success:   false,
-			ErrorCode: &errorCode,
+			Success:    false,
+			ErrorCode:  &errorCode,
+			ResultType: sleet.ResultTypeAPIError,
+			Message:    "Message Signature has expired",
+			StatusCode: 200,
 		}
 
 		if !cmp.Equal(*got, *want, sleet_t.CompareUnexported) {
@@ -462,6 +490,9 @@ func TestRefund(t *testing.T) {
 		want := &sleet.RefundResponse{
 			Success:              true,
 			TransactionReference: "84539111123",
+			ResultType:           sleet.ResultTypeSuccess,
+			Amount:               &sleet.Amount{Amount: 1204, Currency: "USD"},
+			StatusCode:           200,
 		}
 
 		if !cmp.Equal(*got, *want, sleet_t.CompareUnexported) {
@@ -489,6 +520,91 @@ func TestRefund(t *testing.T) {
 
 		errorCode := "403"
 		want := &sleet.RefundResponse{
+			Success:    false,
+			ErrorCode:  &errorCode,
+			ResultType: sleet.ResultTypeAPIError,
+			Message:    "Message Signature has expired",
+			StatusCode: 200,
+		}
+
+		if !cmp.Equal(*got, *want, sleet_t.CompareUnexported) {
+			t.Error("Response body does not match expected")
+			t.Error(cmp.Diff(*want, *got, sleet_t.CompareUnexported))
+		}
+	})
+}
+
+// TestQueryTransaction tests that the QueryTransaction method appropriately handles successful and failed firstdata responses and returns an appropriate sleet Response struct
+func TestQueryTransaction(t *testing.T) {
+	helper := sleet_t.NewTestHelper(t)
+	url := "https://cert.api.firstdata.com/gateway/v2/payments/84538652787"
+
+	var queryResponseRaw, responseErrorRaw []byte
+	queryResponseRaw = helper.ReadFile("test_data/queryResponse.json")
+	responseErrorRaw = helper.ReadFile("test_data/400Response.json")
+
+	reqId := defaultReqId
+	request := &sleet.TransactionQueryRequest{
+		TransactionReference:       "84538652787",
+		ClientTransactionReference: &reqId,
+	}
+
+	t.Run("With Successful Response", func(t *testing.T) {
+
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		httpmock.RegisterResponder("GET", url, func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewBytesResponse(http.StatusOK, queryResponseRaw)
+			return resp, nil
+		})
+
+		firstDataClient := NewClient(common.Sandbox, Credentials{defaultApiKey, defaultApiSecret})
+
+		got, err := firstDataClient.QueryTransaction(request)
+		if err != nil {
+			t.Errorf("ERROR THROWN: Got %q, after calling QueryTransaction", err)
+		}
+
+		transactionTime := time.Unix(1594573266, 0).UTC()
+		want := &sleet.TransactionQueryResponse{
+			Success:              true,
+			TransactionReference: "84538652787",
+			State:                sleet.TransactionStateCaptured,
+			StateRaw:             "CAPTURED",
+			Currency:             "USD",
+			AuthorizedAmount:     19,
+			CapturedAmount:       19,
+			Last4:                "1111",
+			Network:              sleet.CreditCardNetworkVisa,
+			CreatedAt:            &transactionTime,
+		}
+
+		if !cmp.Equal(*got, *want, sleet_t.CompareUnexported) {
+			t.Error("Response body does not match expected")
+			t.Error(cmp.Diff(*want, *got, sleet_t.CompareUnexported))
+		}
+	})
+
+	t.Run("With Error Response", func(t *testing.T) {
+
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		httpmock.RegisterResponder("GET", url, func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewBytesResponse(http.StatusOK, responseErrorRaw)
+			return resp, nil
+		})
+
+		firstDataClient := NewClient(common.Sandbox, Credentials{defaultApiKey, defaultApiSecret})
+
+		got, err := firstDataClient.QueryTransaction(request)
+		if err != nil {
+			t.Errorf("ERROR THROWN: Got %q, after calling QueryTransaction", err)
+		}
+
+		errorCode := "403"
+		want := &sleet.TransactionQueryResponse{
 			Success:   false,
 			ErrorCode: &errorCode,
 		}
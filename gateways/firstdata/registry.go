@@ -0,0 +1,27 @@
+package firstdata
+
+import (
+	"net/http"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/registry"
+)
+
+// FirstData credential keys of a registry.Config
+const (
+	credentialAPIKey    = "api_key"
+	credentialAPISecret = "api_secret"
+)
+
+func init() {
+	registry.Register("firstdata", newRegistryClient)
+}
+
+// newRegistryClient builds a FirstdataClient from a registry config
+func newRegistryClient(config *registry.Config, httpClient *http.Client, options ...sleet.ClientOption) (sleet.ClientWithContext, error) {
+	credentials, err := config.RequiredCredentials(credentialAPIKey, credentialAPISecret)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithHttpClient(config.Environment, Credentials{ApiKey: credentials[0], ApiSecret: credentials[1]}, httpClient, options...), nil
+}
@@ -66,6 +66,21 @@ func TestBuildAuthRequest(t *testing.T) {
 	}
 }
 
+func TestBuildSaleRequest(t *testing.T) {
+	base := sleet_testing.BaseAuthorizationRequest()
+
+	want, _ := buildAuthRequest(base)
+	want.RequestType = RequestTypeSale
+
+	got, err := buildSaleRequest(base)
+	if err != nil {
+		t.Fatalf("Error thrown after building sale request %s", err)
+	}
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Error(diff)
+	}
+}
+
 func TestBuildCaptureRequest(t *testing.T) {
 	base := sleet_testing.BaseCaptureRequest()
 
@@ -35,6 +35,15 @@ func buildAuthRequest(authRequest *sleet.AuthorizationRequest) (*Request, error)
 	return request, nil
 }
 
+func buildSaleRequest(saleRequest *sleet.SaleRequest) (*Request, error) {
+	request, err := buildAuthRequest(saleRequest)
+	if err != nil {
+		return nil, err
+	}
+	request.RequestType = RequestTypeSale
+	return request, nil
+}
+
 func buildCaptureRequest(captureRequest *sleet.CaptureRequest) Request {
 	amountStr := sleet.AmountToString(captureRequest.Amount)
 	request := Request{
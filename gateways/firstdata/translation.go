@@ -1,6 +1,11 @@
 package firstdata
 
-import "github.com/BoltApp/sleet"
+import (
+	"time"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+)
 
 var cvvMap = map[CVVResponseCode]sleet.CVVResponse{
 	CVVResponseMatched:      sleet.CVVResponseMatch,
@@ -49,3 +54,136 @@ func translateAvs(avs AVSResponse) sleet.AVSResponse {
 	}
 	return sleetCode
 }
+
+var resultTypeMap = map[TransactionStatus]sleet.ResultType{
+	StatusApproved:         sleet.ResultTypeSuccess,
+	StatusWaiting:          sleet.ResultTypeSuccess,
+	StatusDeclined:         sleet.ResultTypePaymentError,
+	StatusValidationFailed: sleet.ResultTypeAPIError,
+	StatusProcessingFailed: sleet.ResultTypeServerError,
+}
+
+// translateResultType converts a Firstdata transaction status to a Sleet result type. Error responses which don't
+// carry a transaction status, such as authentication failures, are classified by their HTTP status.
+func translateResultType(status TransactionStatus, statusCode int) sleet.ResultType {
+	resultType, ok := resultTypeMap[status]
+	if !ok {
+		return common.ResultTypeFromHTTPStatus(statusCode)
+	}
+	return resultType
+}
+
+// translateErrorResultType classifies a Firstdata error response, which is an API error unless its transaction status
+// or HTTP status say otherwise.
+func translateErrorResultType(response *Response, statusCode int) sleet.ResultType {
+	if resultType := translateResultType(response.TransactionStatus, statusCode); resultType != sleet.ResultTypeUnknownError {
+		return resultType
+	}
+	return sleet.ResultTypeAPIError
+}
+
+// Processor response codes follow ISO 8583, see: https://docs.fiserv.dev/public/docs/response-codes
+var declineReasonMap = map[string]sleet.DeclineReason{
+	"01": sleet.DeclineReasonCallIssuer,
+	"02": sleet.DeclineReasonCallIssuer,
+	"04": sleet.DeclineReasonRestrictedCard,
+	"05": sleet.DeclineReasonDoNotHonor,
+	"14": sleet.DeclineReasonInvalidCardNumber,
+	"15": sleet.DeclineReasonInvalidCardNumber,
+	"41": sleet.DeclineReasonLostOrStolenCard,
+	"43": sleet.DeclineReasonLostOrStolenCard,
+	"51": sleet.DeclineReasonInsufficientFunds,
+	"54": sleet.DeclineReasonExpiredCard,
+	"57": sleet.DeclineReasonTransactionNotAllowed,
+	"58": sleet.DeclineReasonTransactionNotAllowed,
+	"59": sleet.DeclineReasonSuspectedFraud,
+	"61": sleet.DeclineReasonLimitExceeded,
+	"62": sleet.DeclineReasonRestrictedCard,
+	"65": sleet.DeclineReasonLimitExceeded,
+	"82": sleet.DeclineReasonCVVFailure,
+	"91": sleet.DeclineReasonIssuerUnavailable,
+	"96": sleet.DeclineReasonTryAgainLater,
+	"N7": sleet.DeclineReasonCVVFailure,
+}
+
+// translateDeclineReason converts the processor response code of a declined Firstdata transaction to a Sleet decline
+// reason.
+func translateDeclineReason(response *Response, resultType sleet.ResultType) sleet.DeclineReason {
+	return common.TranslateDeclineReason(declineReasonMap, response.Processor.ResponseCode, resultType)
+}
+
+// translateApprovedAmount converts the amount Firstdata approved for a capture or refund, falling back to the requested
+// amount when the response has none.
+func translateApprovedAmount(response *Response, requested *sleet.Amount) *sleet.Amount {
+	if response.ApprovedAmount.Currency == "" {
+		return requested
+	}
+	return &sleet.Amount{
+		Amount:   common.AmountFromFloat(response.ApprovedAmount.Total, response.ApprovedAmount.Currency),
+		Currency: response.ApprovedAmount.Currency,
+	}
+}
+
+// buildResponseMetadata keeps the approval code the processor returned for a transaction
+func buildResponseMetadata(response *Response) map[string]string {
+	if response.Processor.AuthorizationCode == "" {
+		return nil
+	}
+	return map[string]string{sleet.AuthCodeMetadata: response.Processor.AuthorizationCode}
+}
+
+var transactionStateMap = map[TransactionState]sleet.TransactionState{
+	StateAuthorized: sleet.TransactionStateAuthorized,
+	StateCaptured:   sleet.TransactionStateCaptured,
+	StateSettled:    sleet.TransactionStateSettled,
+	StateVoided:     sleet.TransactionStateVoided,
+	StateDeclined:   sleet.TransactionStateDeclined,
+}
+
+var brandMap = map[string]sleet.CreditCardNetwork{
+	"VISA":       sleet.CreditCardNetworkVisa,
+	"MASTERCARD": sleet.CreditCardNetworkMastercard,
+	"AMEX":       sleet.CreditCardNetworkAmex,
+	"DISCOVER":   sleet.CreditCardNetworkDiscover,
+	"JCB":        sleet.CreditCardNetworkJcb,
+}
+
+// translateTransaction converts a Firstdata transaction to a Sleet transaction query response.
+// Returns are separate transactions in Firstdata, so the approved amount of a return is its refunded amount.
+func translateTransaction(transaction *Response) *sleet.TransactionQueryResponse {
+	state, ok := transactionStateMap[transaction.TransactionState]
+	if !ok {
+		state = sleet.TransactionStateUnknown
+	}
+	if transaction.TransactionStatus == StatusDeclined {
+		state = sleet.TransactionStateDeclined
+	}
+
+	amount := common.AmountFromFloat(transaction.ApprovedAmount.Total, transaction.ApprovedAmount.Currency)
+	response := &sleet.TransactionQueryResponse{
+		Success:              true,
+		TransactionReference: transaction.IPGTransactionId,
+		State:                state,
+		StateRaw:             string(transaction.TransactionState),
+		Currency:             transaction.ApprovedAmount.Currency,
+	}
+	switch {
+	case transaction.TransactionType == transactionTypeReturn && state != sleet.TransactionStateDeclined:
+		response.State = sleet.TransactionStateRefunded
+		response.RefundedAmount = amount
+	case state == sleet.TransactionStateCaptured || state == sleet.TransactionStateSettled:
+		response.AuthorizedAmount = amount
+		response.CapturedAmount = amount
+	case state != sleet.TransactionStateDeclined:
+		response.AuthorizedAmount = amount
+	}
+	if details := transaction.PaymentMethodDetails; details != nil && details.PaymentCard != nil {
+		response.Last4 = details.PaymentCard.Last4
+		response.Network = brandMap[details.PaymentCard.Brand]
+	}
+	if transaction.TransactionTime != 0 {
+		transactionTime := time.Unix(int64(transaction.TransactionTime), 0).UTC()
+		response.CreatedAt = &transactionTime
+	}
+	return response
+}
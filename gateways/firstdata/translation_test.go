@@ -4,6 +4,7 @@
 package firstdata
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/BoltApp/sleet"
@@ -67,3 +68,75 @@ func TestTranslateAvs(t *testing.T) {
 		})
 	}
 }
+
+func TestTranslateResultType(t *testing.T) {
+	cases := []struct {
+		status     TransactionStatus
+		statusCode int
+		want       sleet.ResultType
+	}{
+		{StatusApproved, 200, sleet.ResultTypeSuccess},
+		{StatusWaiting, 200, sleet.ResultTypeSuccess},
+		{StatusDeclined, 409, sleet.ResultTypePaymentError},
+		{StatusValidationFailed, 400, sleet.ResultTypeAPIError},
+		{StatusProcessingFailed, 502, sleet.ResultTypeServerError},
+		{"", 401, sleet.ResultTypeAPIError},
+		{"", 503, sleet.ResultTypeServerError},
+		{"", 200, sleet.ResultTypeUnknownError},
+	}
+
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("%s %d", c.status, c.statusCode), func(t *testing.T) {
+			got := translateResultType(c.status, c.statusCode)
+			if got != c.want {
+				t.Errorf("Got %q, want %q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestTranslateErrorResultType(t *testing.T) {
+	cases := []struct {
+		status     TransactionStatus
+		statusCode int
+		want       sleet.ResultType
+	}{
+		{StatusDeclined, 409, sleet.ResultTypePaymentError},
+		{"", 500, sleet.ResultTypeServerError},
+		{"", 200, sleet.ResultTypeAPIError},
+	}
+
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("%s %d", c.status, c.statusCode), func(t *testing.T) {
+			got := translateErrorResultType(&Response{TransactionStatus: c.status, Error: &Error{Code: "403"}}, c.statusCode)
+			if got != c.want {
+				t.Errorf("Got %q, want %q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestTranslateDeclineReason(t *testing.T) {
+	cases := []struct {
+		responseCode string
+		resultType   sleet.ResultType
+		want         sleet.DeclineReason
+	}{
+		{"00", sleet.ResultTypeSuccess, ""},
+		{"05", sleet.ResultTypePaymentError, sleet.DeclineReasonDoNotHonor},
+		{"51", sleet.ResultTypePaymentError, sleet.DeclineReasonInsufficientFunds},
+		{"54", sleet.ResultTypePaymentError, sleet.DeclineReasonExpiredCard},
+		{"91", sleet.ResultTypeServerError, sleet.DeclineReasonIssuerUnavailable},
+		{"Z9", sleet.ResultTypePaymentError, sleet.DeclineReasonUnknown},
+		{"", sleet.ResultTypeAPIError, ""},
+	}
+
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("%s %s", c.responseCode, c.resultType), func(t *testing.T) {
+			got := translateDeclineReason(&Response{Processor: ProcessorData{ResponseCode: c.responseCode}}, c.resultType)
+			if got != c.want {
+				t.Errorf("Got %q, want %q", got, c.want)
+			}
+		})
+	}
+}
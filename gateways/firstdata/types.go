@@ -5,11 +5,15 @@ type RequestType string
 
 const (
 	RequestTypeAuth    RequestType = "PaymentCardPreAuthTransaction"
+	RequestTypeSale    RequestType = "PaymentCardSaleTransaction"
 	RequestTypeCapture RequestType = "PostAuthTransaction"
 	RequestTypeRefund  RequestType = "ReturnTransaction"
 	RequestTypeVoid    RequestType = "VoidTransaction"
 )
 
+// transactionTypeReturn is the transactionType of refunds in firstdata responses
+const transactionTypeReturn = "RETURN"
+
 // TransactionStatus represents the valid transaction statuses that can be present in a firstdata response
 type TransactionStatus string
 
@@ -71,20 +75,21 @@ type Request struct {
 // Response contains all of the relevant fields for all firstdata API call responses.
 // This struct contains the combined fields of the firstdata TransactionResponse,ErrorResponse and TransactionErrorResponse
 type Response struct {
-	ClientRequestId     string            `json:"clientRequestId"`
-	ApiTraceId          string            `json:"apiTraceId"`
-	ResponseType        string            `json:"responseType"`
-	OrderId             *string           `json:"orderId"`
-	IPGTransactionId    string            `json:"ipgTransactionId"`
-	TransactionType     string            `json:"transactionType"`
-	TransactionOrigin   string            `json:"transactionOrigin"`
-	TransactionTime     int               `json:"transactionTime"` //EPOCH seconds
-	ApprovedAmount      ApprovedAmount    `json:"approvedAmount"`
-	TransactionStatus   TransactionStatus `json:"transactionStatus"`
-	TransactionState    TransactionState  `json:"transactionState"`
-	SchemeTransactionId string            `json:"schemeTransactionId"`
-	Processor           ProcessorData     `json:"processor"`
-	Error               *Error            `json:"error"`
+	ClientRequestId      string                `json:"clientRequestId"`
+	ApiTraceId           string                `json:"apiTraceId"`
+	ResponseType         string                `json:"responseType"`
+	OrderId              *string               `json:"orderId"`
+	IPGTransactionId     string                `json:"ipgTransactionId"`
+	TransactionType      string                `json:"transactionType"`
+	TransactionOrigin    string                `json:"transactionOrigin"`
+	TransactionTime      int                   `json:"transactionTime"` //EPOCH seconds
+	ApprovedAmount       ApprovedAmount        `json:"approvedAmount"`
+	TransactionStatus    TransactionStatus     `json:"transactionStatus"`
+	TransactionState     TransactionState      `json:"transactionState"`
+	SchemeTransactionId  string                `json:"schemeTransactionId"`
+	PaymentMethodDetails *PaymentMethodDetails `json:"paymentMethodDetails"`
+	Processor            ProcessorData         `json:"processor"`
+	Error                *Error                `json:"error"`
 }
 
 // Error holds error information returned from a firstdata API call
@@ -118,6 +123,17 @@ type PaymentCard struct {
 	ExpiryDate   ExpiryDate `json:"expiryDate"`
 }
 
+// PaymentMethodDetails contains the masked payment medium returned from a firstdata response
+type PaymentMethodDetails struct {
+	PaymentCard *PaymentCardDetails `json:"paymentCard"`
+}
+
+// PaymentCardDetails contains the masked credit card returned from a firstdata response
+type PaymentCardDetails struct {
+	Last4 string `json:"last4"`
+	Brand string `json:"brand"`
+}
+
 // ExpiryDate contains the expiry month and year (in 2 digit format) for a credit card
 type ExpiryDate struct {
 	Month string `json:"month"`
@@ -0,0 +1,29 @@
+//go:build unit
+// +build unit
+
+package nmi
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+	"github.com/BoltApp/sleet/testing/conformance"
+	"github.com/BoltApp/sleet/testing/fakes"
+)
+
+func TestConformance(t *testing.T) {
+	fake := fakes.NewNMI()
+	defer fake.Close()
+
+	conformance.Run(t, conformance.Suite{
+		Gateway:   "nmi",
+		Transport: fake.Client().Transport,
+		NewClient: func(httpClient *http.Client) sleet.ClientWithContext {
+			return NewWithHttpClient(common.Sandbox, fakes.NMISecurityKey, httpClient)
+		},
+		// NMI's test mode declines amounts under $1.00
+		Decline: func(request *sleet.AuthorizationRequest) { request.Amount.Amount = 50 },
+	})
+}
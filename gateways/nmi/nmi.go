@@ -2,6 +2,8 @@ package nmi
 
 import (
 	"context"
+	"encoding/xml"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -15,32 +17,64 @@ import (
 
 const (
 	transactionEndpoint = "https://secure.networkmerchants.com/api/transact.php"
+	queryEndpoint       = "https://secure.networkmerchants.com/api/query.php"
+
+	gatewayName = "nmi"
 )
 
+// requiredResponseFields are the fields of every transaction response
+var requiredResponseFields = []string{"response", "response_code", "responsetext"}
+
 var (
 	// assert client interface
-	_ sleet.ClientWithContext = &NMIClient{}
+	_ sleet.ClientWithContext             = &NMIClient{}
+	_ sleet.TransactionQuerierWithContext = &NMIClient{}
+	_ sleet.TransactionFinderWithContext  = &NMIClient{}
+	_ sleet.SaleWithContext               = &NMIClient{}
+	_ sleet.VerifierWithContext           = &NMIClient{}
+	_ sleet.CapabilitiesReporter          = &NMIClient{}
 )
 
 // NMIClient represents an HTTP client and the associated authentication information required for making a Direct Post API request.
 type NMIClient struct {
-	testMode    bool
-	securityKey string
-	httpClient  *http.Client
+	testMode       bool
+	securityKey    string
+	transactionURL string
+	queryURL       string
+	httpClient     *http.Client
+	logger         sleet.Logger
 }
 
 // NewClient returns a new client for making NMI Direct Post API requests for a given merchant using a specified security key.
-func NewClient(env common.Environment, securityKey string) *NMIClient {
-	return NewWithHttpClient(env, securityKey, common.DefaultHttpClient())
+func NewClient(env common.Environment, securityKey string, options ...sleet.ClientOption) *NMIClient {
+	return NewWithHttpClient(env, securityKey, common.DefaultHttpClient(), options...)
 }
 
 // NewWithHttpClient returns a client for making NMI Direct Post API requests for a given merchant using a specified security key.
 // The provided HTTP client will be used to make the requests.
-func NewWithHttpClient(env common.Environment, securityKey string, httpClient *http.Client) *NMIClient {
+func NewWithHttpClient(env common.Environment, securityKey string, httpClient *http.Client, options ...sleet.ClientOption) *NMIClient {
+	clientOptions := sleet.NewClientOptions(options...)
 	return &NMIClient{
-		testMode:    nmiTestMode(env),
-		securityKey: securityKey,
-		httpClient:  httpClient,
+		testMode:       nmiTestMode(env),
+		securityKey:    securityKey,
+		transactionURL: common.OverrideBaseURL(transactionEndpoint, clientOptions.BaseURL),
+		queryURL:       common.OverrideBaseURL(queryEndpoint, clientOptions.BaseURL),
+		httpClient:     common.LoggingHttpClient(httpClient, gatewayName, clientOptions.Logger),
+		logger:         clientOptions.Logger,
+	}
+}
+
+// Capabilities describes the optional operations supported by NMI
+func (client *NMIClient) Capabilities() sleet.Capabilities {
+	return sleet.Capabilities{
+		ContextSupport:       true,
+		Sale:                 true,
+		Vault:                true,
+		TransactionQuery:     true,
+		FindByReference:      true,
+		PartialAuthorization: true,
+		PartialCapture:       true,
+		PartialRefund:        true,
 	}
 }
 
@@ -54,25 +88,69 @@ func (client *NMIClient) Authorize(request *sleet.AuthorizationRequest) (*sleet.
 // authorization response will be returned.
 func (client *NMIClient) AuthorizeWithContext(ctx context.Context, request *sleet.AuthorizationRequest) (*sleet.AuthorizationResponse, error) {
 	nmiAuthRequest := buildAuthRequest(client.testMode, client.securityKey, request)
+	response, err := client.sendAuthRequest(ctx, nmiAuthRequest, request.Options)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationAuthorize, err)
+	}
+	return response, nil
+}
+
+// Sale makes a payment sale request to NMI, authorizing and capturing the given payment details in a single call.
+// If successful, the sale response will be returned.
+func (client *NMIClient) Sale(request *sleet.SaleRequest) (*sleet.SaleResponse, error) {
+	return client.SaleWithContext(context.TODO(), request)
+}
 
-	nmiResponse, httpResponse, err := client.sendRequest(ctx, nmiAuthRequest)
+// SaleWithContext makes a payment sale request to NMI, authorizing and capturing the given payment details in a
+// single call. If successful, the sale response will be returned.
+func (client *NMIClient) SaleWithContext(ctx context.Context, request *sleet.SaleRequest) (*sleet.SaleResponse, error) {
+	nmiSaleRequest := buildSaleRequest(client.testMode, client.securityKey, request)
+	resp, err := client.sendAuthRequest(ctx, nmiSaleRequest, request.Options)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationSale, err)
+	}
+	return &sleet.SaleResponse{AuthorizationResponse: *resp}, nil
+}
+
+// Verify makes a validate request to NMI, checking the given payment details with AVS and CVV without
+// authorizing an amount. If successful, the verification response will be returned.
+func (client *NMIClient) Verify(request *sleet.VerificationRequest) (*sleet.VerificationResponse, error) {
+	return client.VerifyWithContext(context.TODO(), request)
+}
+
+// VerifyWithContext makes a validate request to NMI, checking the given payment details with AVS and CVV without
+// authorizing an amount. If successful, the verification response will be returned.
+func (client *NMIClient) VerifyWithContext(ctx context.Context, request *sleet.VerificationRequest) (*sleet.VerificationResponse, error) {
+	nmiVerificationRequest := buildVerificationRequest(client.testMode, client.securityKey, request)
+	resp, err := client.sendAuthRequest(ctx, nmiVerificationRequest, request.Options)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationVerify, err)
+	}
+	return &sleet.VerificationResponse{AuthorizationResponse: *resp}, nil
+}
+
+// sendAuthRequest sends an auth, sale or validate request and translates the response
+func (client *NMIClient) sendAuthRequest(ctx context.Context, nmiRequest *Request, options map[string]interface{}) (*sleet.AuthorizationResponse, error) {
+	nmiResponse, httpResponse, err := client.sendRequest(ctx, nmiRequest)
 	if err != nil {
 		return nil, err
 	}
 
-	responseHeader := sleet.GetHTTPResponseHeader(request.Options, *httpResponse)
-	// "2" means declined and "3" means bad request
-	if nmiResponse.Response != "1" {
+	responseHeader := sleet.GetHTTPResponseHeader(options, *httpResponse)
+	if nmiResponse.Response != responseApproved {
+		resultType := translateResultType(nmiResponse.Response, nmiResponse.ResponseCode)
 		return &sleet.AuthorizationResponse{
-			Success:    false,
-			Response:   nmiResponse.ResponseCode,
-			ErrorCode:  nmiResponse.ResponseCode,
-			StatusCode: httpResponse.StatusCode,
-			Header:     responseHeader,
+			Success:       false,
+			Response:      nmiResponse.ResponseCode,
+			ErrorCode:     nmiResponse.ResponseCode,
+			ResultType:    resultType,
+			DeclineReason: common.TranslateDeclineReason(declineReasonMap, nmiResponse.ResponseCode, resultType),
+			StatusCode:    httpResponse.StatusCode,
+			Header:        responseHeader,
 		}, nil
 	}
 
-	return &sleet.AuthorizationResponse{
+	response := &sleet.AuthorizationResponse{
 		Success:              true,
 		TransactionReference: nmiResponse.TransactionID,
 		AvsResult:            sleet.AVSResponseUnknown,
@@ -80,9 +158,18 @@ func (client *NMIClient) AuthorizeWithContext(ctx context.Context, request *slee
 		Response:             nmiResponse.ResponseCode,
 		AvsResultRaw:         nmiResponse.AVSResponseCode,
 		CvvResultRaw:         nmiResponse.CVVResponseCode,
+		ResultType:           sleet.ResultTypeSuccess,
 		StatusCode:           httpResponse.StatusCode,
 		Header:               responseHeader,
-	}, nil
+	}
+	if nmiResponse.AmountAuthorized != "" && nmiRequest.Currency != nil {
+		approvedAmount, err := common.AmountFromDecimalString(nmiResponse.AmountAuthorized, *nmiRequest.Currency)
+		if err != nil {
+			return nil, common.ResponseError(gatewayName, httpResponse.StatusCode, err)
+		}
+		response.ApprovedAmount = &sleet.Amount{Amount: approvedAmount, Currency: *nmiRequest.Currency}
+	}
+	return response, nil
 }
 
 // Capture captures an authorized payment through NMI. If successful, the capture response will be returned.
@@ -96,24 +183,32 @@ func (client *NMIClient) Capture(request *sleet.CaptureRequest) (*sleet.CaptureR
 func (client *NMIClient) CaptureWithContext(ctx context.Context, request *sleet.CaptureRequest) (*sleet.CaptureResponse, error) {
 	nmiCaptureRequest := buildCaptureRequest(client.testMode, client.securityKey, request)
 
-	nmiResponse, _, err := client.sendRequest(ctx, nmiCaptureRequest)
+	nmiResponse, httpResponse, err := client.sendRequest(ctx, nmiCaptureRequest)
 	if err != nil {
-		return nil, err
+		return nil, common.OperationError(gatewayName, sleet.OperationCapture, err)
 	}
 
-	// "2" means declined and "3" means bad request
-	if nmiResponse.Response != "1" {
+	responseHeader := sleet.GetHTTPResponseHeader(request.Options, *httpResponse)
+	if nmiResponse.Response != responseApproved {
 		return &sleet.CaptureResponse{
 			Success: false,
 			// transactionid is not always returned for bad captures, and, when it is, it's the id of the original transaction
 			TransactionReference: request.TransactionReference,
 			ErrorCode:            &nmiResponse.ResponseCode,
+			ResultType:           translateResultType(nmiResponse.Response, nmiResponse.ResponseCode),
+			Message:              nmiResponse.ResponseText,
+			StatusCode:           httpResponse.StatusCode,
+			Header:               responseHeader,
 		}, nil
 	}
 
 	return &sleet.CaptureResponse{
 		Success:              true,
 		TransactionReference: nmiResponse.TransactionID,
+		ResultType:           sleet.ResultTypeSuccess,
+		Amount:               request.Amount,
+		StatusCode:           httpResponse.StatusCode,
+		Header:               responseHeader,
 	}, nil
 }
 
@@ -128,24 +223,31 @@ func (client *NMIClient) Void(request *sleet.VoidRequest) (*sleet.VoidResponse,
 func (client *NMIClient) VoidWithContext(ctx context.Context, request *sleet.VoidRequest) (*sleet.VoidResponse, error) {
 	nmiVoidRequest := buildVoidRequest(client.testMode, client.securityKey, request)
 
-	nmiResponse, _, err := client.sendRequest(ctx, nmiVoidRequest)
+	nmiResponse, httpResponse, err := client.sendRequest(ctx, nmiVoidRequest)
 	if err != nil {
-		return nil, err
+		return nil, common.OperationError(gatewayName, sleet.OperationVoid, err)
 	}
 
-	// "2" means declined and "3" means bad request
-	if nmiResponse.Response != "1" {
+	responseHeader := sleet.GetHTTPResponseHeader(request.Options, *httpResponse)
+	if nmiResponse.Response != responseApproved {
 		return &sleet.VoidResponse{
 			Success: false,
 			// transactionid is not always returned for bad voids, and, when it is, it's the id of the original transaction
 			TransactionReference: request.TransactionReference,
 			ErrorCode:            &nmiResponse.ResponseCode,
+			ResultType:           translateResultType(nmiResponse.Response, nmiResponse.ResponseCode),
+			Message:              nmiResponse.ResponseText,
+			StatusCode:           httpResponse.StatusCode,
+			Header:               responseHeader,
 		}, nil
 	}
 
 	return &sleet.VoidResponse{
 		Success:              true,
 		TransactionReference: nmiResponse.TransactionID,
+		ResultType:           sleet.ResultTypeSuccess,
+		StatusCode:           httpResponse.StatusCode,
+		Header:               responseHeader,
 	}, nil
 }
 
@@ -162,24 +264,106 @@ func (client *NMIClient) Refund(request *sleet.RefundRequest) (*sleet.RefundResp
 func (client *NMIClient) RefundWithContext(ctx context.Context, request *sleet.RefundRequest) (*sleet.RefundResponse, error) {
 	nmiRefundRequest := buildRefundRequest(client.testMode, client.securityKey, request)
 
-	nmiResponse, _, err := client.sendRequest(ctx, nmiRefundRequest)
+	nmiResponse, httpResponse, err := client.sendRequest(ctx, nmiRefundRequest)
 	if err != nil {
-		return nil, err
+		return nil, common.OperationError(gatewayName, sleet.OperationRefund, err)
 	}
 
-	// "2" means declined and "3" means bad request
-	if nmiResponse.Response != "1" {
+	responseHeader := sleet.GetHTTPResponseHeader(request.Options, *httpResponse)
+	if nmiResponse.Response != responseApproved {
 		return &sleet.RefundResponse{
 			Success: false,
 			// No transactionid is returned for unsuccessful refunds because refunds create new transactions
 			TransactionReference: request.TransactionReference,
 			ErrorCode:            &nmiResponse.ResponseCode,
+			ResultType:           translateResultType(nmiResponse.Response, nmiResponse.ResponseCode),
+			Message:              nmiResponse.ResponseText,
+			StatusCode:           httpResponse.StatusCode,
+			Header:               responseHeader,
 		}, nil
 	}
 
 	return &sleet.RefundResponse{
 		Success:              true,
 		TransactionReference: nmiResponse.TransactionID,
+		ResultType:           sleet.ResultTypeSuccess,
+		Amount:               request.Amount,
+		StatusCode:           httpResponse.StatusCode,
+		Header:               responseHeader,
+	}, nil
+}
+
+// QueryTransaction retrieves a NMI transaction and the actions taken on it through the Query API.
+func (client *NMIClient) QueryTransaction(request *sleet.TransactionQueryRequest) (*sleet.TransactionQueryResponse, error) {
+	return client.QueryTransactionWithContext(context.TODO(), request)
+}
+
+// QueryTransactionWithContext retrieves a NMI transaction and the actions taken on it through the Query API.
+func (client *NMIClient) QueryTransactionWithContext(ctx context.Context, request *sleet.TransactionQueryRequest) (*sleet.TransactionQueryResponse, error) {
+	queryRequest := buildTransactionQueryRequest(client.securityKey, request)
+
+	queryResponse, err := client.sendQueryRequest(ctx, queryRequest)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationQueryTransaction, err)
+	}
+
+	if queryResponse.ErrorResponse != "" {
+		return &sleet.TransactionQueryResponse{
+			Success:   false,
+			ErrorCode: &queryResponse.ErrorResponse,
+		}, nil
+	}
+	if len(queryResponse.Transactions) == 0 {
+		return &sleet.TransactionQueryResponse{
+			Success:   false,
+			ErrorCode: common.SPtr("transaction not found"),
+		}, nil
+	}
+
+	response, err := translateTransaction(queryResponse.Transactions[0])
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationQueryTransaction, common.DecodeError(gatewayName, err))
+	}
+	return response, nil
+}
+
+// FindByClientReference retrieves the NMI transactions with the MerchantOrderReference as their order ID
+// through the Query API.
+func (client *NMIClient) FindByClientReference(request *sleet.FindByClientReferenceRequest) (*sleet.FindByClientReferenceResponse, error) {
+	return client.FindByClientReferenceWithContext(context.TODO(), request)
+}
+
+// FindByClientReferenceWithContext retrieves the NMI transactions with the MerchantOrderReference as their order ID
+// through the Query API.
+func (client *NMIClient) FindByClientReferenceWithContext(ctx context.Context, request *sleet.FindByClientReferenceRequest) (*sleet.FindByClientReferenceResponse, error) {
+	if request.MerchantOrderReference == nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationFindByClientReference, sleet.ErrClientReferenceRequired)
+	}
+	queryRequest := buildFindByOrderIDRequest(client.securityKey, request)
+
+	queryResponse, err := client.sendQueryRequest(ctx, queryRequest)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationFindByClientReference, err)
+	}
+
+	if queryResponse.ErrorResponse != "" {
+		return &sleet.FindByClientReferenceResponse{
+			Success:   false,
+			ErrorCode: &queryResponse.ErrorResponse,
+		}, nil
+	}
+
+	transactions := make([]sleet.TransactionQueryResponse, 0, len(queryResponse.Transactions))
+	for _, transaction := range queryResponse.Transactions {
+		translated, err := translateTransaction(transaction)
+		if err != nil {
+			return nil, common.OperationError(gatewayName, sleet.OperationFindByClientReference, common.DecodeError(gatewayName, err))
+		}
+		transactions = append(transactions, *translated)
+	}
+	return &sleet.FindByClientReferenceResponse{
+		Success:      true,
+		Transactions: transactions,
 	}, nil
 }
 
@@ -192,12 +376,12 @@ func (client *NMIClient) sendRequest(ctx context.Context, data *Request) (*Respo
 		return nil, nil, err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, transactionEndpoint, strings.NewReader(formData.Encode()))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, client.transactionURL, strings.NewReader(formData.Encode()))
 	if err != nil {
 		return nil, nil, err
 	}
 
-	parsedUrl, err := url.Parse(transactionEndpoint)
+	parsedUrl, err := url.Parse(client.transactionURL)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -207,29 +391,79 @@ func (client *NMIClient) sendRequest(ctx context.Context, data *Request) (*Respo
 
 	resp, err := client.httpClient.Do(req)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, common.TransportError(gatewayName, err)
 	}
 	defer func() {
 		err := resp.Body.Close()
 		if err != nil {
-			// TODO log
+			common.Logger(ctx, client.logger).Warn(common.LogCloseFailed, "gateway", gatewayName, "error", err)
 		}
 	}()
 
 	respBody, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, common.TransportError(gatewayName, err)
 	}
 	parsedFormData, err := url.ParseQuery(string(respBody))
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, common.ResponseError(gatewayName, resp.StatusCode, err)
+	}
+	// NMI answers every transaction with these fields, a response without them was truncated
+	for _, field := range requiredResponseFields {
+		if _, ok := parsedFormData[field]; !ok {
+			return nil, nil, common.ResponseError(gatewayName, resp.StatusCode, fmt.Errorf("response has no %s field", field))
+		}
 	}
 	decoder := form.NewDecoder()
 	nmiResponse := Response{}
 	err = decoder.Decode(&nmiResponse, parsedFormData)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, common.ResponseError(gatewayName, resp.StatusCode, err)
 	}
 
+	common.LogResult(ctx, client.logger, gatewayName, nmiResponse.ResponseCode, "response", nmiResponse.Response)
 	return &nmiResponse, resp, nil
 }
+
+// sendQueryRequest sends a request to the NMI Query API, which responds with XML
+func (client *NMIClient) sendQueryRequest(ctx context.Context, data *QueryRequest) (*QueryResponse, error) {
+	encoder := form.NewEncoder()
+	formData, err := encoder.Encode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, client.queryURL, strings.NewReader(formData.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	parsedUrl, err := url.Parse(client.queryURL)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Host", parsedUrl.Hostname())
+	req.Header.Add("User-Agent", common.UserAgent())
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := client.httpClient.Do(req)
+	if err != nil {
+		return nil, common.TransportError(gatewayName, err)
+	}
+	defer func() {
+		err := resp.Body.Close()
+		if err != nil {
+			common.Logger(ctx, client.logger).Warn(common.LogCloseFailed, "gateway", gatewayName, "error", err)
+		}
+	}()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, common.TransportError(gatewayName, err)
+	}
+	queryResponse := QueryResponse{}
+	if err := xml.Unmarshal(respBody, &queryResponse); err != nil {
+		return nil, common.ResponseError(gatewayName, resp.StatusCode, err)
+	}
+	return &queryResponse, nil
+}
@@ -0,0 +1,24 @@
+package nmi
+
+import (
+	"net/http"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/registry"
+)
+
+// credentialSecurityKey is the NMI credential key of a registry.Config
+const credentialSecurityKey = "security_key"
+
+func init() {
+	registry.Register("nmi", newRegistryClient)
+}
+
+// newRegistryClient builds an NMIClient from a registry config
+func newRegistryClient(config *registry.Config, httpClient *http.Client, options ...sleet.ClientOption) (sleet.ClientWithContext, error) {
+	securityKey, err := config.Credential(credentialSecurityKey)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithHttpClient(config.Environment, securityKey, httpClient, options...), nil
+}
@@ -7,48 +7,144 @@ import (
 	"github.com/shopspring/decimal"
 
 	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
 )
 
 // NMI transaction types
 const (
-	auth    = "auth"
-	capture = "capture"
-	refund  = "refund"
-	void    = "void"
+	auth     = "auth"
+	sale     = "sale"
+	validate = "validate"
+	capture  = "capture"
+	refund   = "refund"
+	void     = "void"
 )
 
-func buildAuthRequest(testMode bool, securityKey string, request *sleet.AuthorizationRequest) *Request {
-	zeroPad := ""
-	if request.CreditCard.ExpirationMonth < 10 {
-		zeroPad = "0"
-	}
-	cardExpiration := fmt.Sprintf(
-		"%s%s%s",
-		zeroPad,
-		strconv.Itoa(request.CreditCard.ExpirationMonth),
-		strconv.Itoa(request.CreditCard.ExpirationYear)[2:],
-	)
+// NMI customer vault actions and reports
+const (
+	addCustomer         = "add_customer"
+	deleteCustomer      = "delete_customer"
+	customerVaultReport = "customer_vault"
+)
 
-	return &Request{
-		Address1:              request.BillingAddress.StreetAddress1,
-		Address2:              request.BillingAddress.StreetAddress2,
+// settlePartial accepts a partial approval, the approved amount is settled rather than held for further payments
+const settlePartial = "settle_partial"
+
+func buildAuthRequest(testMode bool, securityKey string, request *sleet.AuthorizationRequest) *Request {
+	nmiRequest := &Request{
 		Amount:                formatAmount(request.Amount.Amount),
-		CardExpiration:        &cardExpiration,
-		CardNumber:            &request.CreditCard.Number,
-		City:                  request.BillingAddress.Locality,
 		Currency:              &request.Amount.Currency,
-		CVV:                   &request.CreditCard.CVV,
-		FirstName:             &request.CreditCard.FirstName,
-		LastName:              &request.CreditCard.LastName,
 		MerchantDefinedField1: request.ClientTransactionReference,
 		OrderID:               request.MerchantOrderReference,
 		SecurityKey:           securityKey,
-		State:                 request.BillingAddress.RegionCode,
 		TestMode:              enableTestMode(testMode),
 		TransactionType:       auth,
-		ZipCode:               request.BillingAddress.PostalCode,
-		Email:                 request.BillingAddress.Email,
 	}
+	if request.BillingAddress != nil {
+		addBillingAddress(nmiRequest, request.BillingAddress)
+	}
+	if request.AllowPartialAuth {
+		nmiRequest.PartialPayments = common.SPtr(settlePartial)
+	}
+
+	if request.StoredPaymentMethod != nil {
+		nmiRequest.CustomerVaultID = &request.StoredPaymentMethod.Token
+		return nmiRequest
+	}
+
+	cardExpiration := formatCardExpiration(request.CreditCard)
+	nmiRequest.CardExpiration = &cardExpiration
+	nmiRequest.CardNumber = &request.CreditCard.Number
+	nmiRequest.CVV = &request.CreditCard.CVV
+	nmiRequest.FirstName = &request.CreditCard.FirstName
+	nmiRequest.LastName = &request.CreditCard.LastName
+	return nmiRequest
+}
+
+func buildSaleRequest(testMode bool, securityKey string, request *sleet.SaleRequest) *Request {
+	nmiRequest := buildAuthRequest(testMode, securityKey, request)
+	nmiRequest.TransactionType = sale
+	return nmiRequest
+}
+
+// buildVerificationRequest validates the payment method, NMI requires validations to be for a zero amount
+func buildVerificationRequest(testMode bool, securityKey string, request *sleet.VerificationRequest) *Request {
+	nmiRequest := buildAuthRequest(testMode, securityKey, request)
+	nmiRequest.Amount = formatAmount(0)
+	nmiRequest.TransactionType = validate
+	return nmiRequest
+}
+
+// buildStorePaymentMethodRequest adds a customer to the Customer Vault, NMI generates the customer vault id
+func buildStorePaymentMethodRequest(testMode bool, securityKey string, request *sleet.StorePaymentMethodRequest) *Request {
+	cardExpiration := formatCardExpiration(request.CreditCard)
+	nmiRequest := &Request{
+		CardExpiration: &cardExpiration,
+		CardNumber:     &request.CreditCard.Number,
+		CustomerVault:  common.SPtr(addCustomer),
+		FirstName:      &request.CreditCard.FirstName,
+		LastName:       &request.CreditCard.LastName,
+		SecurityKey:    securityKey,
+		TestMode:       enableTestMode(testMode),
+	}
+	if request.BillingAddress != nil {
+		addBillingAddress(nmiRequest, request.BillingAddress)
+	}
+	return nmiRequest
+}
+
+func buildDeletePaymentMethodRequest(testMode bool, securityKey string, request *sleet.DeletePaymentMethodRequest) *Request {
+	return &Request{
+		CustomerVault:   common.SPtr(deleteCustomer),
+		CustomerVaultID: &request.StoredPaymentMethod.Token,
+		SecurityKey:     securityKey,
+		TestMode:        enableTestMode(testMode),
+	}
+}
+
+func buildGetPaymentMethodRequest(securityKey string, request *sleet.GetPaymentMethodRequest) *QueryRequest {
+	return &QueryRequest{
+		CustomerVaultID: &request.StoredPaymentMethod.Token,
+		ReportType:      customerVaultReport,
+		SecurityKey:     securityKey,
+	}
+}
+
+func buildTransactionQueryRequest(securityKey string, request *sleet.TransactionQueryRequest) *QueryRequest {
+	return &QueryRequest{
+		SecurityKey:   securityKey,
+		TransactionID: &request.TransactionReference,
+	}
+}
+
+func buildFindByOrderIDRequest(securityKey string, request *sleet.FindByClientReferenceRequest) *QueryRequest {
+	return &QueryRequest{
+		SecurityKey: securityKey,
+		OrderID:     request.MerchantOrderReference,
+	}
+}
+
+func addBillingAddress(nmiRequest *Request, billingAddress *sleet.Address) {
+	nmiRequest.Address1 = billingAddress.StreetAddress1
+	nmiRequest.Address2 = billingAddress.StreetAddress2
+	nmiRequest.City = billingAddress.Locality
+	nmiRequest.State = billingAddress.RegionCode
+	nmiRequest.ZipCode = billingAddress.PostalCode
+	nmiRequest.Email = billingAddress.Email
+}
+
+// formatCardExpiration formats the expiration as MMYY
+func formatCardExpiration(card *sleet.CreditCard) string {
+	zeroPad := ""
+	if card.ExpirationMonth < 10 {
+		zeroPad = "0"
+	}
+	return fmt.Sprintf(
+		"%s%s%s",
+		zeroPad,
+		strconv.Itoa(card.ExpirationMonth),
+		strconv.Itoa(card.ExpirationYear)[2:],
+	)
 }
 
 func buildCaptureRequest(testMode bool, securityKey string, request *sleet.CaptureRequest) *Request {
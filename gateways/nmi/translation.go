@@ -0,0 +1,151 @@
+package nmi
+
+import (
+	"strings"
+	"time"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+)
+
+var cardTypeMap = map[string]sleet.CreditCardNetwork{
+	"visa":       sleet.CreditCardNetworkVisa,
+	"mastercard": sleet.CreditCardNetworkMastercard,
+	"amex":       sleet.CreditCardNetworkAmex,
+	"discover":   sleet.CreditCardNetworkDiscover,
+	"jcb":        sleet.CreditCardNetworkJcb,
+	"unionpay":   sleet.CreditCardNetworkUnionpay,
+}
+
+// Values of the response field of a transaction response
+const (
+	responseApproved = "1"
+	responseDeclined = "2"
+	responseError    = "3"
+)
+
+// resultTypeMap classifies the response codes of transaction errors. 3xx codes are gateway rejections and 4xx codes
+// are processor errors, which are server errors unless they're caused by the request or the merchant's configuration.
+var resultTypeMap = map[string]sleet.ResultType{
+	"300": sleet.ResultTypeAPIError,    // rejected by the gateway
+	"400": sleet.ResultTypeServerError, // error returned by the processor
+	"410": sleet.ResultTypeAPIError,    // invalid merchant configuration
+	"411": sleet.ResultTypeAPIError,    // merchant account is inactive
+	"420": sleet.ResultTypeServerError, // communication error
+	"421": sleet.ResultTypeServerError, // communication error with the issuer
+	"430": sleet.ResultTypeAPIError,    // duplicate transaction at the processor
+	"440": sleet.ResultTypeAPIError,    // processor format error
+	"441": sleet.ResultTypeAPIError,    // invalid transaction information
+	"460": sleet.ResultTypeAPIError,    // processor feature not available
+	"461": sleet.ResultTypeAPIError,    // unsupported card type
+}
+
+// translateResultType converts the response and response code of a transaction response to a Sleet result type.
+// Declines are payment errors and errors are classified by their response code.
+func translateResultType(response, responseCode string) sleet.ResultType {
+	switch response {
+	case responseApproved:
+		return sleet.ResultTypeSuccess
+	case responseDeclined:
+		return sleet.ResultTypePaymentError
+	case responseError:
+		if resultType, ok := resultTypeMap[responseCode]; ok {
+			return resultType
+		}
+		return sleet.ResultTypeAPIError
+	}
+	return sleet.ResultTypeUnknownError
+}
+
+// declineReasonMap translates the 2xx response codes of declines, and the 42x codes of issuers which couldn't be reached
+var declineReasonMap = map[string]sleet.DeclineReason{
+	"201": sleet.DeclineReasonDoNotHonor,            // do not honor
+	"202": sleet.DeclineReasonInsufficientFunds,     // insufficient funds
+	"203": sleet.DeclineReasonLimitExceeded,         // over limit
+	"204": sleet.DeclineReasonTransactionNotAllowed, // transaction not allowed
+	"220": sleet.DeclineReasonInvalidCardNumber,     // incorrect payment information
+	"221": sleet.DeclineReasonInvalidCardNumber,     // no such card issuer
+	"222": sleet.DeclineReasonInvalidCardNumber,     // no card number on file with issuer
+	"223": sleet.DeclineReasonExpiredCard,           // expired card
+	"224": sleet.DeclineReasonInvalidExpirationDate, // invalid expiration date
+	"225": sleet.DeclineReasonCVVFailure,            // invalid card security code
+	"240": sleet.DeclineReasonCallIssuer,            // call issuer for further information
+	"250": sleet.DeclineReasonRestrictedCard,        // pick up card
+	"251": sleet.DeclineReasonLostOrStolenCard,      // lost card
+	"252": sleet.DeclineReasonLostOrStolenCard,      // stolen card
+	"253": sleet.DeclineReasonSuspectedFraud,        // fraudulent card
+	"264": sleet.DeclineReasonTryAgainLater,         // declined, retry in a few days
+	"420": sleet.DeclineReasonIssuerUnavailable,     // communication error
+	"421": sleet.DeclineReasonIssuerUnavailable,     // communication error with the issuer
+}
+
+// Transaction conditions returned by the Query API
+var conditionMap = map[string]sleet.TransactionState{
+	"pending":           sleet.TransactionStateAuthorized,
+	"pendingsettlement": sleet.TransactionStateCaptured,
+	"complete":          sleet.TransactionStateSettled,
+	"canceled":          sleet.TransactionStateVoided,
+	"failed":            sleet.TransactionStateDeclined,
+}
+
+// Action types returned by the Query API
+const (
+	actionTypeAuth    = "auth"
+	actionTypeSale    = "sale"
+	actionTypeCapture = "capture"
+	actionTypeRefund  = "refund"
+	actionTypeCredit  = "credit"
+
+	queryActionDateFormat = "20060102150405"
+)
+
+// translateTransaction converts a Query API transaction to a Sleet transaction query response.
+// Amounts are totalled from the successful actions taken on the transaction.
+func translateTransaction(transaction QueryTransaction) (*sleet.TransactionQueryResponse, error) {
+	state, ok := conditionMap[transaction.Condition]
+	if !ok {
+		state = sleet.TransactionStateUnknown
+	}
+	response := &sleet.TransactionQueryResponse{
+		Success:              true,
+		TransactionReference: transaction.TransactionID,
+		State:                state,
+		StateRaw:             transaction.Condition,
+		Currency:             strings.ToUpper(transaction.Currency),
+		Network:              cardTypeMap[strings.ToLower(transaction.CardType)],
+	}
+	if len(transaction.CardNumber) >= 4 {
+		response.Last4 = transaction.CardNumber[len(transaction.CardNumber)-4:]
+	}
+
+	for _, action := range transaction.Actions {
+		if date, err := time.Parse(queryActionDateFormat, action.Date); err == nil {
+			if response.CreatedAt == nil {
+				response.CreatedAt = &date
+			}
+			response.UpdatedAt = &date
+		}
+		if action.Success != "1" {
+			continue
+		}
+		amount, err := common.AmountFromDecimalString(action.Amount, transaction.Currency)
+		if err != nil {
+			return nil, err
+		}
+		switch action.ActionType {
+		case actionTypeAuth:
+			response.AuthorizedAmount += amount
+		case actionTypeSale:
+			response.AuthorizedAmount += amount
+			response.CapturedAmount += amount
+		case actionTypeCapture:
+			response.CapturedAmount += amount
+		case actionTypeRefund, actionTypeCredit:
+			response.RefundedAmount += amount
+		}
+	}
+	if response.RefundedAmount > 0 && state != sleet.TransactionStateVoided {
+		response.State = sleet.TransactionStateRefunded
+	}
+	return response, nil
+}
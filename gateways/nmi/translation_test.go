@@ -0,0 +1,61 @@
+//go:build unit
+// +build unit
+
+package nmi
+
+import (
+	"testing"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+)
+
+func TestTranslateResultType(t *testing.T) {
+	cases := []struct {
+		label        string
+		response     string
+		responseCode string
+		want         sleet.ResultType
+	}{
+		{"Approved", "1", "100", sleet.ResultTypeSuccess},
+		{"Insufficient Funds", "2", "202", sleet.ResultTypePaymentError},
+		{"Expired Card", "2", "223", sleet.ResultTypePaymentError},
+		{"Gateway Rejection", "3", "300", sleet.ResultTypeAPIError},
+		{"Processor Error", "3", "400", sleet.ResultTypeServerError},
+		{"Issuer Unreachable", "3", "421", sleet.ResultTypeServerError},
+		{"Duplicate Transaction", "3", "430", sleet.ResultTypeAPIError},
+		{"Unknown Error Code", "3", "499", sleet.ResultTypeAPIError},
+		{"Missing Response", "", "", sleet.ResultTypeUnknownError},
+	}
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			if got := translateResultType(c.response, c.responseCode); got != c.want {
+				t.Errorf("expected %s, got %s", c.want, got)
+			}
+		})
+	}
+}
+
+func TestDeclineReasonMap(t *testing.T) {
+	cases := []struct {
+		label        string
+		response     string
+		responseCode string
+		want         sleet.DeclineReason
+	}{
+		{"Approved", "1", "100", ""},
+		{"Insufficient Funds", "2", "202", sleet.DeclineReasonInsufficientFunds},
+		{"Stolen Card", "2", "252", sleet.DeclineReasonLostOrStolenCard},
+		{"Declined By Processor", "2", "200", sleet.DeclineReasonUnknown},
+		{"Issuer Unreachable", "3", "421", sleet.DeclineReasonIssuerUnavailable},
+		{"Gateway Rejection", "3", "300", ""},
+	}
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			resultType := translateResultType(c.response, c.responseCode)
+			if got := common.TranslateDeclineReason(declineReasonMap, c.responseCode, resultType); got != c.want {
+				t.Errorf("expected %q, got %q", c.want, got)
+			}
+		})
+	}
+}
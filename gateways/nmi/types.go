@@ -1,5 +1,7 @@
 package nmi
 
+import "encoding/xml"
+
 // Request contains the information needed for all request types (Auth, Capture, Void, Refund)
 type Request struct {
 	Address1              *string `form:"address1,omitempty"`
@@ -9,29 +11,79 @@ type Request struct {
 	CardNumber            *string `form:"ccnumber,omitempty"`
 	City                  *string `form:"city,omitempty"`
 	Currency              *string `form:"currency,omitempty"`
+	CustomerVault         *string `form:"customer_vault,omitempty"`
+	CustomerVaultID       *string `form:"customer_vault_id,omitempty"`
 	CVV                   *string `form:"cvv,omitempty"`
 	FirstName             *string `form:"first_name,omitempty"`
 	LastName              *string `form:"last_name,omitempty"`
 	MerchantDefinedField1 *string `form:"merchant_defined_field_1,omitempty"`
 	OrderID               string  `form:"orderid,omitempty"`
+	PartialPayments       *string `form:"partial_payments,omitempty"`
 	SecurityKey           string  `form:"security_key"`
 	State                 *string `form:"state,omitempty"`
 	TestMode              *string `form:"test_mode"`
 	TransactionID         *string `form:"transactionid,omitempty"`
-	TransactionType       string  `form:"type"`
+	TransactionType       string  `form:"type,omitempty"` // not set for customer vault requests
 	ZipCode               *string `form:"zip,omitempty"`
 	Email                 *string `form:"email,omitempty"`
 }
 
 // Response contains all of the fields for all Cybersource API call responses
 type Response struct {
-	AuthCode        string `form:"authcode"`
-	AVSResponseCode string `form:"avsresponse"`
-	CVVResponseCode string `form:"cvvresponse"`
-	OrderID         string `form:"orderid"`
-	Response        string `form:"response"`
-	ResponseCode    string `form:"response_code"`
-	ResponseText    string `form:"responsetext"`
-	TransactionID   string `form:"transactionid"`
-	Type            string `form:"type"`
+	AmountAuthorized string `form:"amount_authorized"` // less than the requested amount on a partial approval
+	AuthCode         string `form:"authcode"`
+	AVSResponseCode  string `form:"avsresponse"`
+	CustomerVaultID  string `form:"customer_vault_id"`
+	CVVResponseCode  string `form:"cvvresponse"`
+	OrderID          string `form:"orderid"`
+	Response         string `form:"response"`
+	ResponseCode     string `form:"response_code"`
+	ResponseText     string `form:"responsetext"`
+	TransactionID    string `form:"transactionid"`
+	Type             string `form:"type"`
+}
+
+// QueryRequest is sent to the Query API to retrieve information stored by NMI
+type QueryRequest struct {
+	CustomerVaultID *string `form:"customer_vault_id,omitempty"`
+	OrderID         *string `form:"order_id,omitempty"`
+	ReportType      string  `form:"report_type,omitempty"`
+	SecurityKey     string  `form:"security_key"`
+	TransactionID   *string `form:"transaction_id,omitempty"`
+}
+
+// QueryResponse contains the XML returned by the Query API
+type QueryResponse struct {
+	XMLName       xml.Name           `xml:"nm_response"`
+	Customers     []VaultCustomer    `xml:"customer_vault>customer"`
+	Transactions  []QueryTransaction `xml:"transaction"`
+	ErrorResponse string             `xml:"error_response"`
+}
+
+// VaultCustomer is a customer stored in the NMI Customer Vault, card details are masked
+type VaultCustomer struct {
+	CustomerVaultID string `xml:"customer_vault_id"`
+	FirstName       string `xml:"first_name"`
+	LastName        string `xml:"last_name"`
+	CardNumber      string `xml:"cc_number"`
+	CardExpiration  string `xml:"cc_exp"`
+	CardType        string `xml:"cc_type"`
+}
+
+// QueryTransaction is a transaction returned by the Query API with every action taken on it
+type QueryTransaction struct {
+	TransactionID string        `xml:"transaction_id"`
+	Condition     string        `xml:"condition"`
+	CardNumber    string        `xml:"cc_number"`
+	CardType      string        `xml:"cc_type"`
+	Currency      string        `xml:"currency"`
+	Actions       []QueryAction `xml:"action"`
+}
+
+// QueryAction is a single action taken on a transaction, Date is formatted as YYYYMMDDhhmmss
+type QueryAction struct {
+	Amount     string `xml:"amount"`
+	ActionType string `xml:"action_type"`
+	Date       string `xml:"date"`
+	Success    string `xml:"success"`
 }
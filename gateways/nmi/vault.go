@@ -0,0 +1,114 @@
+package nmi
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+)
+
+var (
+	// assert vault interface
+	_ sleet.VaultWithContext = &NMIClient{}
+)
+
+// StorePaymentMethod adds the credit card to the NMI Customer Vault.
+// NMI stores a single card per vault entry, so only the Token of the stored payment method is set.
+func (client *NMIClient) StorePaymentMethod(request *sleet.StorePaymentMethodRequest) (*sleet.StorePaymentMethodResponse, error) {
+	return client.StorePaymentMethodWithContext(context.TODO(), request)
+}
+
+// StorePaymentMethodWithContext adds the credit card to the NMI Customer Vault.
+// NMI stores a single card per vault entry, so only the Token of the stored payment method is set.
+func (client *NMIClient) StorePaymentMethodWithContext(ctx context.Context, request *sleet.StorePaymentMethodRequest) (*sleet.StorePaymentMethodResponse, error) {
+	nmiRequest := buildStorePaymentMethodRequest(client.testMode, client.securityKey, request)
+
+	nmiResponse, _, err := client.sendRequest(ctx, nmiRequest)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationStorePaymentMethod, err)
+	}
+
+	if nmiResponse.Response != responseApproved {
+		return &sleet.StorePaymentMethodResponse{
+			Success:   false,
+			ErrorCode: &nmiResponse.ResponseCode,
+		}, nil
+	}
+
+	return &sleet.StorePaymentMethodResponse{
+		Success:             true,
+		StoredPaymentMethod: &sleet.StoredPaymentMethod{Token: nmiResponse.CustomerVaultID},
+	}, nil
+}
+
+// GetPaymentMethod retrieves the masked card of a Customer Vault entry through the NMI Query API
+func (client *NMIClient) GetPaymentMethod(request *sleet.GetPaymentMethodRequest) (*sleet.GetPaymentMethodResponse, error) {
+	return client.GetPaymentMethodWithContext(context.TODO(), request)
+}
+
+// GetPaymentMethodWithContext retrieves the masked card of a Customer Vault entry through the NMI Query API
+func (client *NMIClient) GetPaymentMethodWithContext(ctx context.Context, request *sleet.GetPaymentMethodRequest) (*sleet.GetPaymentMethodResponse, error) {
+	queryRequest := buildGetPaymentMethodRequest(client.securityKey, request)
+
+	queryResponse, err := client.sendQueryRequest(ctx, queryRequest)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationGetPaymentMethod, err)
+	}
+
+	if queryResponse.ErrorResponse != "" {
+		return &sleet.GetPaymentMethodResponse{
+			Success:   false,
+			ErrorCode: &queryResponse.ErrorResponse,
+		}, nil
+	}
+	if len(queryResponse.Customers) == 0 {
+		return &sleet.GetPaymentMethodResponse{
+			Success:   false,
+			ErrorCode: common.SPtr("customer vault id not found"),
+		}, nil
+	}
+
+	customer := queryResponse.Customers[0]
+	response := &sleet.GetPaymentMethodResponse{
+		Success:             true,
+		StoredPaymentMethod: &sleet.StoredPaymentMethod{Token: customer.CustomerVaultID},
+		Network:             cardTypeMap[strings.ToLower(customer.CardType)],
+	}
+	if len(customer.CardNumber) >= 4 {
+		response.Last4 = customer.CardNumber[len(customer.CardNumber)-4:]
+	}
+	// cc_exp is formatted as MMYY
+	if len(customer.CardExpiration) == 4 {
+		response.ExpirationMonth, _ = strconv.Atoi(customer.CardExpiration[:2])
+		year, err := strconv.Atoi(customer.CardExpiration[2:])
+		if err == nil {
+			response.ExpirationYear = 2000 + year
+		}
+	}
+	return response, nil
+}
+
+// DeletePaymentMethod removes the entry from the NMI Customer Vault
+func (client *NMIClient) DeletePaymentMethod(request *sleet.DeletePaymentMethodRequest) (*sleet.DeletePaymentMethodResponse, error) {
+	return client.DeletePaymentMethodWithContext(context.TODO(), request)
+}
+
+// DeletePaymentMethodWithContext removes the entry from the NMI Customer Vault
+func (client *NMIClient) DeletePaymentMethodWithContext(ctx context.Context, request *sleet.DeletePaymentMethodRequest) (*sleet.DeletePaymentMethodResponse, error) {
+	nmiRequest := buildDeletePaymentMethodRequest(client.testMode, client.securityKey, request)
+
+	nmiResponse, _, err := client.sendRequest(ctx, nmiRequest)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationDeletePaymentMethod, err)
+	}
+
+	if nmiResponse.Response != responseApproved {
+		return &sleet.DeletePaymentMethodResponse{
+			Success:   false,
+			ErrorCode: &nmiResponse.ResponseCode,
+		}, nil
+	}
+	return &sleet.DeletePaymentMethodResponse{Success: true}, nil
+}
@@ -0,0 +1,30 @@
+//go:build unit
+// +build unit
+
+package orbital
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+	"github.com/BoltApp/sleet/testing/conformance"
+	"github.com/BoltApp/sleet/testing/fakes"
+)
+
+func TestConformance(t *testing.T) {
+	fake := fakes.NewOrbital()
+	defer fake.Close()
+
+	conformance.Run(t, conformance.Suite{
+		Gateway:   "orbital",
+		Transport: fake.Client().Transport,
+		NewClient: func(httpClient *http.Client) sleet.ClientWithContext {
+			return NewWithHttpClient(common.Sandbox, Credentials{Username: fakes.OrbitalUsername, Password: fakes.OrbitalPassword, MerchantID: fakes.OrbitalMerchantID}, httpClient)
+		},
+		Decline: func(request *sleet.AuthorizationRequest) { request.CreditCard.Number = fakes.OrbitalDeclinedCard },
+		AVS:     sleet.AVSResponseMatch,
+		CVV:     sleet.CVVResponseMatch,
+	})
+}
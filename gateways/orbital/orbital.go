@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/xml"
+	"errors"
 	"io/ioutil"
 	"net/http"
 	"strconv"
@@ -12,9 +13,19 @@ import (
 	"github.com/BoltApp/sleet/common"
 )
 
+const gatewayName = "orbital"
+
+// ErrNotTraced is returned when reversing an authorization which was sent without sleet.TimeoutReversalOption, and
+// so without a trace number to retrieve it by
+var ErrNotTraced = errors.New("orbital: the authorization was not sent with a trace number to reverse it by")
+
 var (
 	// assert client interface
-	_ sleet.Client = &OrbitalClient{}
+	_ sleet.ClientWithContext          = &OrbitalClient{}
+	_ sleet.TimeoutReverserWithContext = &OrbitalClient{}
+	_ sleet.SaleWithContext            = &OrbitalClient{}
+	_ sleet.VerifierWithContext        = &OrbitalClient{}
+	_ sleet.CapabilitiesReporter       = &OrbitalClient{}
 )
 
 type Credentials struct {
@@ -27,17 +38,35 @@ type OrbitalClient struct {
 	host        string
 	credentials Credentials
 	httpClient  *http.Client
+	logger      sleet.Logger
 }
 
-func NewClient(env common.Environment, credentials Credentials) *OrbitalClient {
-	return NewWithHttpClient(env, credentials, common.DefaultHttpClient())
+func NewClient(env common.Environment, credentials Credentials, options ...sleet.ClientOption) *OrbitalClient {
+	return NewWithHttpClient(env, credentials, common.DefaultHttpClient(), options...)
 }
 
-func NewWithHttpClient(env common.Environment, credentials Credentials, httpClient *http.Client) *OrbitalClient {
+func NewWithHttpClient(env common.Environment, credentials Credentials, httpClient *http.Client, options ...sleet.ClientOption) *OrbitalClient {
+	clientOptions := sleet.NewClientOptions(options...)
 	return &OrbitalClient{
-		host:        orbitalHost(env),
+		host:        common.OverrideBaseURL(orbitalHost(env), clientOptions.BaseURL),
 		credentials: credentials,
-		httpClient:  httpClient,
+		httpClient:  common.LoggingHttpClient(httpClient, gatewayName, clientOptions.Logger),
+		logger:      clientOptions.Logger,
+	}
+}
+
+// Capabilities describes the optional operations supported by Orbital
+func (client *OrbitalClient) Capabilities() sleet.Capabilities {
+	return sleet.Capabilities{
+		ContextSupport:       true,
+		Sale:                 true,
+		TimeoutReversal:      true,
+		PartialAuthorization: true,
+		PartialCapture:       true,
+		PartialRefund:        true,
+		NetworkTokens:        true,
+		ApplePay:             true,
+		Currencies:           []string{"USD", "CAD", "GBP", "EUR"}, // the currencies of currencyMap
 	}
 }
 
@@ -47,17 +76,57 @@ func (client *OrbitalClient) Authorize(request *sleet.AuthorizationRequest) (*sl
 
 func (client *OrbitalClient) AuthorizeWithContext(ctx context.Context, request *sleet.AuthorizationRequest) (*sleet.AuthorizationResponse, error) {
 	authRequest := buildAuthRequest(request, client.credentials)
+	response, err := client.sendNewOrderRequest(ctx, authRequest, traceNumber(request, MessageTypeAuth), request.Amount.Currency, request.Options)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationAuthorize, err)
+	}
+	return response, nil
+}
+
+// Sale authorizes and captures a transaction in a single NewOrder request
+func (client *OrbitalClient) Sale(request *sleet.SaleRequest) (*sleet.SaleResponse, error) {
+	return client.SaleWithContext(context.TODO(), request)
+}
+
+// SaleWithContext authorizes and captures a transaction in a single NewOrder request
+func (client *OrbitalClient) SaleWithContext(ctx context.Context, request *sleet.SaleRequest) (*sleet.SaleResponse, error) {
+	saleRequest := buildSaleRequest(request, client.credentials)
+	resp, err := client.sendNewOrderRequest(ctx, saleRequest, traceNumber(request, MessageTypeAuthAndCapture), request.Amount.Currency, request.Options)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationSale, err)
+	}
+	return &sleet.SaleResponse{AuthorizationResponse: *resp}, nil
+}
+
+// Verify validates a card with a $0 authorization
+func (client *OrbitalClient) Verify(request *sleet.VerificationRequest) (*sleet.VerificationResponse, error) {
+	return client.VerifyWithContext(context.TODO(), request)
+}
+
+// VerifyWithContext validates a card with a $0 authorization. It is not traced, so that an authorization sent with
+// the same ClientTransactionReference is not answered with the response to the verification.
+func (client *OrbitalClient) VerifyWithContext(ctx context.Context, request *sleet.VerificationRequest) (*sleet.VerificationResponse, error) {
+	verificationRequest := buildVerificationRequest(request, client.credentials)
+	resp, err := client.sendNewOrderRequest(ctx, verificationRequest, "", request.Amount.Currency, request.Options)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationVerify, err)
+	}
+	return &sleet.VerificationResponse{AuthorizationResponse: *resp}, nil
+}
 
-	orbitalResponse, httpResponse, err := client.sendRequest(ctx, authRequest)
+// sendNewOrderRequest sends an authorization, sale or verification and translates the response
+func (client *OrbitalClient) sendNewOrderRequest(ctx context.Context, newOrderRequest Request, trace string, currency string, options map[string]interface{}) (*sleet.AuthorizationResponse, error) {
+	orbitalResponse, httpResponse, err := client.sendTracedRequest(ctx, newOrderRequest, trace)
 	if err != nil {
 		return nil, err
 	}
 
-	responseHeader := sleet.GetHTTPResponseHeader(request.Options, *httpResponse)
+	responseHeader := sleet.GetHTTPResponseHeader(options, *httpResponse)
 	if orbitalResponse.Body.ProcStatus != ProcStatusSuccess {
 		if orbitalResponse.Body.RespCode != "" {
 			return &sleet.AuthorizationResponse{
 				ErrorCode:  orbitalResponse.Body.RespCode,
+				ResultType: translateResultType(orbitalResponse.Body.ProcStatus, orbitalResponse.Body.RespCode, httpResponse.StatusCode),
 				StatusCode: httpResponse.StatusCode,
 				Header:     responseHeader,
 			}, nil
@@ -65,27 +134,39 @@ func (client *OrbitalClient) AuthorizeWithContext(ctx context.Context, request *
 
 		return &sleet.AuthorizationResponse{
 			ErrorCode:  RespCodeNotPresent,
+			ResultType: translateResultType(orbitalResponse.Body.ProcStatus, orbitalResponse.Body.RespCode, httpResponse.StatusCode),
 			StatusCode: httpResponse.StatusCode,
 			Header:     responseHeader,
 		}, nil
 	}
 
-	if orbitalResponse.Body.RespCode != RespCodeApproved {
+	partialAuth := orbitalResponse.Body.PartialAuthOccurred == PartialAuthOccurredYes
+	if !isApproved(orbitalResponse.Body) {
+		resultType := translateResultType(orbitalResponse.Body.ProcStatus, orbitalResponse.Body.RespCode, httpResponse.StatusCode)
 		return &sleet.AuthorizationResponse{
-			ErrorCode:  orbitalResponse.Body.RespCode,
-			StatusCode: httpResponse.StatusCode,
-			Header:     responseHeader,
+			ErrorCode:     orbitalResponse.Body.RespCode,
+			ResultType:    resultType,
+			DeclineReason: common.TranslateDeclineReason(declineReasonMap, orbitalResponse.Body.RespCode, resultType),
+			StatusCode:    httpResponse.StatusCode,
+			Header:        responseHeader,
 		}, nil
 	}
 
+	var approvedAmount *sleet.Amount
+	if partialAuth {
+		approvedAmount = &sleet.Amount{Amount: int64(orbitalResponse.Body.RedeemedAmount), Currency: currency}
+	}
+
 	return &sleet.AuthorizationResponse{
 		Success:              true,
+		ApprovedAmount:       approvedAmount,
 		TransactionReference: orbitalResponse.Body.TxRefNum,
 		AvsResult:            translateAvs(orbitalResponse.Body.AVSRespCode),
 		CvvResult:            translateCvv(orbitalResponse.Body.CVV2RespCode),
 		Response:             strconv.Itoa(int(orbitalResponse.Body.ApprovalStatus)),
 		AvsResultRaw:         string(orbitalResponse.Body.AVSRespCode),
 		CvvResultRaw:         string(orbitalResponse.Body.CVV2RespCode),
+		ResultType:           sleet.ResultTypeSuccess,
 		StatusCode:           httpResponse.StatusCode,
 		Header:               responseHeader,
 	}, nil
@@ -98,27 +179,50 @@ func (client *OrbitalClient) Capture(request *sleet.CaptureRequest) (*sleet.Capt
 func (client *OrbitalClient) CaptureWithContext(ctx context.Context, request *sleet.CaptureRequest) (*sleet.CaptureResponse, error) {
 	captureRequest := buildCaptureRequest(request, client.credentials)
 
-	orbitalResponse, _, err := client.sendRequest(ctx, captureRequest)
+	orbitalResponse, httpResponse, err := client.sendRequest(ctx, captureRequest)
 	if err != nil {
-		return nil, err
+		return nil, common.OperationError(gatewayName, sleet.OperationCapture, err)
 	}
 
+	responseHeader := sleet.GetHTTPResponseHeader(request.Options, *httpResponse)
 	if orbitalResponse.Body.ProcStatus != ProcStatusSuccess {
 		if orbitalResponse.Body.RespCode != "" {
-			return &sleet.CaptureResponse{ErrorCode: &orbitalResponse.Body.RespCode}, nil
+			return &sleet.CaptureResponse{
+				ErrorCode:  &orbitalResponse.Body.RespCode,
+				ResultType: translateResultType(orbitalResponse.Body.ProcStatus, orbitalResponse.Body.RespCode, httpResponse.StatusCode),
+				Message:    orbitalResponse.Body.StatusMsg,
+				StatusCode: httpResponse.StatusCode,
+				Header:     responseHeader,
+			}, nil
 		}
 
 		errorCode := RespCodeNotPresent
-		return &sleet.CaptureResponse{ErrorCode: &errorCode}, nil
+		return &sleet.CaptureResponse{
+			ErrorCode:  &errorCode,
+			ResultType: translateResultType(orbitalResponse.Body.ProcStatus, orbitalResponse.Body.RespCode, httpResponse.StatusCode),
+			Message:    orbitalResponse.Body.StatusMsg,
+			StatusCode: httpResponse.StatusCode,
+			Header:     responseHeader,
+		}, nil
 	}
 
 	if orbitalResponse.Body.RespCode != RespCodeApproved {
-		return &sleet.CaptureResponse{ErrorCode: &orbitalResponse.Body.RespCode}, nil
+		return &sleet.CaptureResponse{
+			ErrorCode:  &orbitalResponse.Body.RespCode,
+			ResultType: translateResultType(orbitalResponse.Body.ProcStatus, orbitalResponse.Body.RespCode, httpResponse.StatusCode),
+			Message:    orbitalResponse.Body.StatusMsg,
+			StatusCode: httpResponse.StatusCode,
+			Header:     responseHeader,
+		}, nil
 	}
 
 	return &sleet.CaptureResponse{
 		Success:              true,
 		TransactionReference: orbitalResponse.Body.TxRefNum,
+		ResultType:           sleet.ResultTypeSuccess,
+		Amount:               request.Amount,
+		StatusCode:           httpResponse.StatusCode,
+		Header:               responseHeader,
 	}, nil
 }
 
@@ -129,19 +233,29 @@ func (client *OrbitalClient) Void(request *sleet.VoidRequest) (*sleet.VoidRespon
 func (client *OrbitalClient) VoidWithContext(ctx context.Context, request *sleet.VoidRequest) (*sleet.VoidResponse, error) {
 	voidRequest := buildVoidRequest(request, client.credentials)
 
-	orbitalResponse, _, err := client.sendRequest(ctx, voidRequest)
+	orbitalResponse, httpResponse, err := client.sendRequest(ctx, voidRequest)
 	if err != nil {
-		return nil, err
+		return nil, common.OperationError(gatewayName, sleet.OperationVoid, err)
 	}
 
+	responseHeader := sleet.GetHTTPResponseHeader(request.Options, *httpResponse)
 	if orbitalResponse.Body.ProcStatus != ProcStatusSuccess {
 		errorCode := RespCodeNotPresent
-		return &sleet.VoidResponse{ErrorCode: &errorCode}, nil
+		return &sleet.VoidResponse{
+			ErrorCode:  &errorCode,
+			ResultType: translateResultType(orbitalResponse.Body.ProcStatus, orbitalResponse.Body.RespCode, httpResponse.StatusCode),
+			Message:    orbitalResponse.Body.StatusMsg,
+			StatusCode: httpResponse.StatusCode,
+			Header:     responseHeader,
+		}, nil
 	}
 
 	return &sleet.VoidResponse{
 		Success:              true,
 		TransactionReference: orbitalResponse.Body.TxRefNum,
+		ResultType:           sleet.ResultTypeSuccess,
+		StatusCode:           httpResponse.StatusCode,
+		Header:               responseHeader,
 	}, nil
 }
 
@@ -152,27 +266,101 @@ func (client *OrbitalClient) Refund(request *sleet.RefundRequest) (*sleet.Refund
 func (client *OrbitalClient) RefundWithContext(ctx context.Context, request *sleet.RefundRequest) (*sleet.RefundResponse, error) {
 	refundRequest := buildRefundRequest(request, client.credentials)
 
-	orbitalResponse, _, err := client.sendRequest(ctx, refundRequest)
+	orbitalResponse, httpResponse, err := client.sendRequest(ctx, refundRequest)
 	if err != nil {
-		return nil, err
+		return nil, common.OperationError(gatewayName, sleet.OperationRefund, err)
 	}
 
+	responseHeader := sleet.GetHTTPResponseHeader(request.Options, *httpResponse)
 	if orbitalResponse.Body.ProcStatus != ProcStatusSuccess {
 		if orbitalResponse.Body.RespCode != "" {
-			return &sleet.RefundResponse{ErrorCode: &orbitalResponse.Body.RespCode}, nil
+			return &sleet.RefundResponse{
+				ErrorCode:  &orbitalResponse.Body.RespCode,
+				ResultType: translateResultType(orbitalResponse.Body.ProcStatus, orbitalResponse.Body.RespCode, httpResponse.StatusCode),
+				Message:    orbitalResponse.Body.StatusMsg,
+				StatusCode: httpResponse.StatusCode,
+				Header:     responseHeader,
+			}, nil
 		}
 
 		errorCode := RespCodeNotPresent
-		return &sleet.RefundResponse{ErrorCode: &errorCode}, nil
+		return &sleet.RefundResponse{
+			ErrorCode:  &errorCode,
+			ResultType: translateResultType(orbitalResponse.Body.ProcStatus, orbitalResponse.Body.RespCode, httpResponse.StatusCode),
+			Message:    orbitalResponse.Body.StatusMsg,
+			StatusCode: httpResponse.StatusCode,
+			Header:     responseHeader,
+		}, nil
 	}
 
 	return &sleet.RefundResponse{
 		Success:              true,
 		TransactionReference: orbitalResponse.Body.TxRefNum,
+		ResultType:           sleet.ResultTypeSuccess,
+		Amount:               request.Amount,
+		StatusCode:           httpResponse.StatusCode,
+		Header:               responseHeader,
 	}, nil
 }
 
+// ReverseTimedOutAuthorization reverses an authorization whose outcome is unknown, see ReverseTimedOutAuthorizationWithContext
+func (client *OrbitalClient) ReverseTimedOutAuthorization(request *sleet.TimeoutReversalRequest) (*sleet.TimeoutReversalResponse, error) {
+	return client.ReverseTimedOutAuthorizationWithContext(context.TODO(), request)
+}
+
+// ReverseTimedOutAuthorizationWithContext retries the AuthorizationRequest with its trace number, which returns the
+// original response if Orbital processed it, and reverses the resulting authorization by its TxRefNum.
+// Nothing is reversed if the retried authorization was declined, which is reported with NotAuthorized. The
+// authorization must have been sent with sleet.TimeoutReversalOption, as reversal.Client does, for it to have a
+// trace number.
+func (client *OrbitalClient) ReverseTimedOutAuthorizationWithContext(ctx context.Context, request *sleet.TimeoutReversalRequest) (*sleet.TimeoutReversalResponse, error) {
+	if request.AuthorizationRequest == nil || request.AuthorizationRequest.ClientTransactionReference == nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationReverseTimedOutAuthorization, sleet.ErrClientReferenceRequired)
+	}
+
+	trace := traceNumber(request.AuthorizationRequest, MessageTypeAuth)
+	if trace == "" {
+		return nil, common.OperationError(gatewayName, sleet.OperationReverseTimedOutAuthorization, ErrNotTraced)
+	}
+	authRequest := buildAuthRequest(request.AuthorizationRequest, client.credentials)
+	authResponse, _, err := client.sendTracedRequest(ctx, authRequest, trace)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationReverseTimedOutAuthorization, err)
+	}
+	if authResponse.Body.ProcStatus != ProcStatusSuccess {
+		// the retry was not processed, so the outcome of the authorization is still unknown
+		return &sleet.TimeoutReversalResponse{Success: false, ErrorCode: common.SPtr(strconv.Itoa(authResponse.Body.ProcStatus))}, nil
+	}
+	if !isApproved(authResponse.Body) {
+		return &sleet.TimeoutReversalResponse{Success: true, NotAuthorized: true}, nil
+	}
+
+	voidResponse, err := client.VoidWithContext(ctx, &sleet.VoidRequest{
+		TransactionReference:       authResponse.Body.TxRefNum,
+		ClientTransactionReference: request.ClientTransactionReference,
+		Options:                    request.Options,
+	})
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationReverseTimedOutAuthorization, err)
+	}
+	return &sleet.TimeoutReversalResponse{
+		Success:              voidResponse.Success,
+		TransactionReference: voidResponse.TransactionReference,
+		ErrorCode:            voidResponse.ErrorCode,
+	}, nil
+}
+
+// isApproved reports whether a processed authorization was approved, in full or for part of its amount
+func isApproved(body ResponseBody) bool {
+	return body.RespCode == RespCodeApproved || body.PartialAuthOccurred == PartialAuthOccurredYes
+}
+
 func (client *OrbitalClient) sendRequest(ctx context.Context, data Request) (*Response, *http.Response, error) {
+	return client.sendTracedRequest(ctx, data, "")
+}
+
+// sendTracedRequest sends the request with the trace number, if any, so it can be safely retried
+func (client *OrbitalClient) sendTracedRequest(ctx context.Context, data Request, traceNumber string) (*Response, *http.Response, error) {
 	bodyXML, err := xml.Marshal(data)
 	if err != nil {
 		return nil, nil, err
@@ -191,25 +379,30 @@ func (client *OrbitalClient) sendRequest(ctx context.Context, data Request) (*Re
 	request.Header.Add("Content-transfer-encoding", ContentTransferEncoding)
 	request.Header.Add("Request-number", RequestNumber)
 	request.Header.Add("Document-type", DocumentType)
+	if traceNumber != "" {
+		request.Header.Add(TraceNumberHeader, traceNumber)
+		request.Header.Add(MerchantIDHeader, strconv.Itoa(client.credentials.MerchantID))
+	}
 
 	resp, err := client.httpClient.Do(request)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, common.TransportError(gatewayName, err)
 	}
 
 	defer resp.Body.Close()
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, common.TransportError(gatewayName, err)
 	}
 
 	var orbitalResponse Response
 
 	err = xml.Unmarshal(body, &orbitalResponse)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, common.ResponseError(gatewayName, resp.StatusCode, err)
 	}
 
+	common.LogResult(ctx, client.logger, gatewayName, orbitalResponse.Body.RespCode, "proc_status", orbitalResponse.Body.ProcStatus)
 	return &orbitalResponse, resp, nil
 }
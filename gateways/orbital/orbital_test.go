@@ -28,7 +28,8 @@ func TestNewClient(t *testing.T) {
 	t.Run("Dev environment", func(t *testing.T) {
 		want := &OrbitalClient{
 			host:        "https://orbitalvar1.chasepaymentech.com/authorize",
-			httpClient:  common.DefaultHttpClient(),
+			httpClient:  common.LoggingHttpClient(common.DefaultHttpClient(), gatewayName, sleet.NopLogger{}),
+			logger:      sleet.NopLogger{},
 			credentials: Credentials{"username", "password", 1},
 		}
 
@@ -43,7 +44,8 @@ func TestNewClient(t *testing.T) {
 	t.Run("Production environment", func(t *testing.T) {
 		want := &OrbitalClient{
 			host:        "https://orbital1.chasepaymentech.com/authorize",
-			httpClient:  common.DefaultHttpClient(),
+			httpClient:  common.LoggingHttpClient(common.DefaultHttpClient(), gatewayName, sleet.NopLogger{}),
+			logger:      sleet.NopLogger{},
 			credentials: Credentials{"username", "password", 1},
 		}
 
@@ -180,6 +182,7 @@ func TestAuthorize(t *testing.T) {
 			CvvResultRaw:         string(CVVResponseMatched),
 			Response:             strconv.Itoa(int(ApprovalStatusApproved)),
 			StatusCode:           200,
+			ResultType:           sleet.ResultTypeSuccess,
 		}
 
 		client := NewClient(common.Sandbox, Credentials{"username", "password", 1})
@@ -195,6 +198,48 @@ func TestAuthorize(t *testing.T) {
 			t.Error(cmp.Diff(*want, *got, sleet_t.CompareUnexported))
 		}
 	})
+
+	t.Run("With Partial Approval Response", func(t *testing.T) {
+		httpmock.Activate()
+		defer httpmock.DeactivateAndReset()
+
+		partialRequest := *request
+		partialRequest.AllowPartialAuth = true
+
+		httpmock.RegisterResponder("POST", url, func(req *http.Request) (*http.Response, error) {
+			body, _ := ioutil.ReadAll(req.Body)
+			if !strings.Contains(string(body), "<PartialAuthInd>Y</PartialAuthInd>") {
+				t.Error("expected the partial authorization indicator to be sent")
+			}
+			return httpmock.NewBytesResponse(http.StatusOK, helper.ReadFile("test_data/authPartialResponse.xml")), nil
+		})
+
+		want := &sleet.AuthorizationResponse{
+			Success:              true,
+			TransactionReference: "11112",
+			ApprovedAmount:       &sleet.Amount{Amount: 60, Currency: "USD"},
+			AvsResult:            sleet.AVSResponseMatch,
+			CvvResult:            sleet.CVVResponseMatch,
+			AvsResultRaw:         string(AVSResponseMatch),
+			CvvResultRaw:         string(CVVResponseMatched),
+			Response:             strconv.Itoa(int(ApprovalStatusApproved)),
+			StatusCode:           200,
+			ResultType:           sleet.ResultTypeSuccess,
+		}
+
+		client := NewClient(common.Sandbox, Credentials{"username", "password", 1})
+
+		got, err := client.Authorize(&partialRequest)
+
+		if err != nil {
+			t.Fatalf("Error thrown after sending request %q", err)
+		}
+
+		if !cmp.Equal(*got, *want, sleet_t.CompareUnexported) {
+			t.Error("Response body does not match expected")
+			t.Error(cmp.Diff(*want, *got, sleet_t.CompareUnexported))
+		}
+	})
 }
 
 func TestCapture(t *testing.T) {
@@ -231,6 +276,9 @@ func TestCapture(t *testing.T) {
 		want := &sleet.CaptureResponse{
 			Success:              true,
 			TransactionReference: "11111",
+			ResultType:           sleet.ResultTypeSuccess,
+			Amount:               &sleet.Amount{Amount: 100, Currency: "USD"},
+			StatusCode:           200,
 		}
 
 		client := NewClient(common.Sandbox, credentials)
@@ -280,6 +328,8 @@ func TestVoid(t *testing.T) {
 		want := &sleet.VoidResponse{
 			Success:              true,
 			TransactionReference: "11111",
+			ResultType:           sleet.ResultTypeSuccess,
+			StatusCode:           200,
 		}
 
 		client := NewClient(common.Sandbox, credentials)
@@ -330,6 +380,9 @@ func TestRefund(t *testing.T) {
 		want := &sleet.RefundResponse{
 			Success:              true,
 			TransactionReference: "11111",
+			ResultType:           sleet.ResultTypeSuccess,
+			Amount:               &sleet.Amount{Amount: 100, Currency: "USD"},
+			StatusCode:           200,
 		}
 
 		client := NewClient(common.Sandbox, credentials)
@@ -0,0 +1,36 @@
+package orbital
+
+import (
+	"net/http"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/registry"
+)
+
+// Orbital credential keys of a registry.Config, the merchant ID is numeric
+const (
+	credentialUsername   = "username"
+	credentialPassword   = "password"
+	credentialMerchantID = "merchant_id"
+)
+
+func init() {
+	registry.Register("orbital", newRegistryClient)
+}
+
+// newRegistryClient builds an OrbitalClient from a registry config
+func newRegistryClient(config *registry.Config, httpClient *http.Client, options ...sleet.ClientOption) (sleet.ClientWithContext, error) {
+	credentials, err := config.RequiredCredentials(credentialUsername, credentialPassword)
+	if err != nil {
+		return nil, err
+	}
+	merchantID, err := config.IntCredential(credentialMerchantID)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithHttpClient(config.Environment, Credentials{
+		Username:   credentials[0],
+		Password:   credentials[1],
+		MerchantID: merchantID,
+	}, httpClient, options...), nil
+}
@@ -2,6 +2,8 @@ package orbital
 
 import (
 	"encoding/xml"
+	"fmt"
+	"hash/fnv"
 	"strconv"
 
 	"github.com/BoltApp/sleet"
@@ -27,7 +29,7 @@ func buildAuthRequest(authRequest *sleet.AuthorizationRequest, credentials Crede
 		CurrencyExponent:          CurrencyExponentDefault,
 		CardSecVal:                authRequest.CreditCard.CVV,
 		OrderID:                   *authRequest.ClientTransactionReference,
-		Amount:                    amount,
+		Amount:                    &amount,
 		AVSzip:                    *authRequest.BillingAddress.PostalCode,
 		AVSaddress1:               *authRequest.BillingAddress.StreetAddress1,
 		AVSaddress2:               authRequest.BillingAddress.StreetAddress2,
@@ -40,6 +42,10 @@ func buildAuthRequest(authRequest *sleet.AuthorizationRequest, credentials Crede
 		body.CardSecValInd = CardSecPresent
 	}
 
+	if authRequest.AllowPartialAuth {
+		body.PartialAuthInd = PartialAuthIndicatorYes
+	}
+
 	if authRequest.Cryptogram != "" && authRequest.ECI != "" {
 		body.DPANInd = "Y"
 		body.DigitalTokenCryptogram = authRequest.Cryptogram
@@ -49,12 +55,24 @@ func buildAuthRequest(authRequest *sleet.AuthorizationRequest, credentials Crede
 	return Request{Body: body}
 }
 
+func buildSaleRequest(saleRequest *sleet.SaleRequest, credentials Credentials) Request {
+	request := buildAuthRequest(saleRequest, credentials)
+	request.Body.MessageType = MessageTypeAuthAndCapture
+	return request
+}
+
+func buildVerificationRequest(verificationRequest *sleet.VerificationRequest, credentials Credentials) Request {
+	authRequest := *verificationRequest
+	authRequest.Amount.Amount = 0
+	return buildAuthRequest(&authRequest, credentials)
+}
+
 func buildCaptureRequest(captureRequest *sleet.CaptureRequest, credentials Credentials) Request {
 	body := RequestBody{
 		OrbitalConnectionUsername: credentials.Username,
 		OrbitalConnectionPassword: credentials.Password,
 		MerchantID:                credentials.MerchantID,
-		Amount:                    captureRequest.Amount.Amount,
+		Amount:                    &captureRequest.Amount.Amount,
 		BIN:                       BINStratus,
 		TerminalID:                TerminalIDStratus,
 		TxRefNum:                  captureRequest.TransactionReference,
@@ -95,10 +113,26 @@ func buildRefundRequest(refundRequest *sleet.RefundRequest, credentials Credenti
 		CurrencyCode:              code,
 		CurrencyExponent:          CurrencyExponentDefault,
 		OrderID:                   *refundRequest.ClientTransactionReference,
-		Amount:                    amount,
+		Amount:                    &amount,
 		TxRefNum:                  refundRequest.TransactionReference,
 	}
 
 	body.XMLName = xml.Name{Local: RequestTypeNewOrder}
 	return Request{Body: body}
 }
+
+// traceNumber derives the Orbital trace number of an authorization or sale opted into timeout reversal from its
+// ClientTransactionReference, message type and amount, so that it can be retried to retrieve its original response
+// when its outcome is unknown. Other requests are not traced, as Orbital answers a trace number it has seen with the
+// response it gave the first time.
+func traceNumber(authRequest *sleet.AuthorizationRequest, messageType MessageType) string {
+	if reversible, _ := authRequest.Options[sleet.TimeoutReversalOption].(bool); !reversible {
+		return ""
+	}
+	if authRequest.ClientTransactionReference == nil {
+		return ""
+	}
+	hash := fnv.New64a()
+	fmt.Fprintf(hash, "%s|%s|%d|%s", messageType, *authRequest.ClientTransactionReference, authRequest.Amount.Amount, authRequest.Amount.Currency)
+	return strconv.FormatUint(hash.Sum64()%TraceNumberMax+1, 10)
+}
@@ -5,11 +5,13 @@ package orbital
 
 import (
 	"encoding/xml"
+	"strings"
 	"testing"
 
 	"github.com/go-test/deep"
 
 	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
 
 	sleet_testing "github.com/BoltApp/sleet/testing"
 )
@@ -56,7 +58,7 @@ func TestBuildAuthRequest(t *testing.T) {
 					CurrencyCode:              CurrencyCodeUSD,
 					CurrencyExponent:          CurrencyExponentDefault,
 					CardSecValInd:             CardSecPresent,
-					Amount:                    100,
+					Amount:                    common.I64Ptr(100),
 					OrderID:                   *visaBase.ClientTransactionReference,
 					AVSzip:                    *visaBase.BillingAddress.PostalCode,
 					AVSaddress1:               *visaBase.BillingAddress.StreetAddress1,
@@ -86,7 +88,7 @@ func TestBuildAuthRequest(t *testing.T) {
 					CurrencyCode:              CurrencyCodeUSD,
 					CurrencyExponent:          CurrencyExponentDefault,
 					CardSecValInd:             CardSecPresent,
-					Amount:                    100,
+					Amount:                    common.I64Ptr(100),
 					OrderID:                   *discoverBase.ClientTransactionReference,
 					AVSzip:                    *discoverBase.BillingAddress.PostalCode,
 					AVSaddress1:               *discoverBase.BillingAddress.StreetAddress1,
@@ -115,7 +117,7 @@ func TestBuildAuthRequest(t *testing.T) {
 					CardSecVal:                mastercardBase.CreditCard.CVV,
 					CurrencyCode:              CurrencyCodeUSD,
 					CurrencyExponent:          CurrencyExponentDefault,
-					Amount:                    100,
+					Amount:                    common.I64Ptr(100),
 					OrderID:                   *mastercardBase.ClientTransactionReference,
 					AVSzip:                    *mastercardBase.BillingAddress.PostalCode,
 					AVSaddress1:               *mastercardBase.BillingAddress.StreetAddress1,
@@ -144,7 +146,7 @@ func TestBuildAuthRequest(t *testing.T) {
 					CardSecVal:                applepayBase.CreditCard.CVV,
 					CurrencyCode:              CurrencyCodeUSD,
 					CurrencyExponent:          CurrencyExponentDefault,
-					Amount:                    100,
+					Amount:                    common.I64Ptr(100),
 					OrderID:                   *applepayBase.ClientTransactionReference,
 					AVSzip:                    *applepayBase.BillingAddress.PostalCode,
 					AVSaddress1:               *applepayBase.BillingAddress.StreetAddress1,
@@ -169,6 +171,40 @@ func TestBuildAuthRequest(t *testing.T) {
 	}
 }
 
+func TestBuildSaleRequest(t *testing.T) {
+	base := sleet_testing.BaseAuthorizationRequest()
+	credentials := Credentials{"username", "password", 1}
+
+	want := buildAuthRequest(base, credentials)
+	want.Body.MessageType = MessageTypeAuthAndCapture
+
+	got := buildSaleRequest(base, credentials)
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestBuildVerificationRequest(t *testing.T) {
+	base := sleet_testing.BaseAuthorizationRequest()
+	credentials := Credentials{"username", "password", 1}
+
+	want := buildAuthRequest(base, credentials)
+	want.Body.Amount = common.I64Ptr(0)
+
+	got := buildVerificationRequest(base, credentials)
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Error(diff)
+	}
+
+	body, err := xml.Marshal(got)
+	if err != nil {
+		t.Fatalf("Error marshaling verification request %s", err)
+	}
+	if !strings.Contains(string(body), "<Amount>0</Amount>") {
+		t.Errorf("expected a $0 amount in %s", body)
+	}
+}
+
 func TestBuildCaptureRequest(t *testing.T) {
 	base := sleet_testing.BaseCaptureRequest()
 	credentials := Credentials{"username", "password", 1}
@@ -189,7 +225,7 @@ func TestBuildCaptureRequest(t *testing.T) {
 					XMLName:                   xml.Name{Local: RequestTypeCapture},
 					BIN:                       BINStratus,
 					TerminalID:                TerminalIDStratus,
-					Amount:                    100,
+					Amount:                    common.I64Ptr(100),
 					TxRefNum:                  base.TransactionReference,
 					OrderID:                   *base.ClientTransactionReference,
 				},
@@ -269,7 +305,7 @@ func TestBuildRefundRequest(t *testing.T) {
 					MessageType:               MessageTypeRefund,
 					CurrencyCode:              CurrencyCodeUSD,
 					CurrencyExponent:          CurrencyExponentDefault,
-					Amount:                    100,
+					Amount:                    common.I64Ptr(100),
 					TxRefNum:                  base.TransactionReference,
 					OrderID:                   *base.ClientTransactionReference,
 				},
@@ -286,3 +322,46 @@ func TestBuildRefundRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestTraceNumber(t *testing.T) {
+	base := sleet_testing.BaseAuthorizationRequest()
+	base.Options = map[string]interface{}{sleet.TimeoutReversalOption: true}
+	retry := *base
+
+	t.Run("Same Reference", func(t *testing.T) {
+		got := traceNumber(base, MessageTypeAuth)
+		if got == "" || got != traceNumber(&retry, MessageTypeAuth) {
+			t.Errorf("expected the same trace number for a retried authorization, got %q and %q", got, traceNumber(&retry, MessageTypeAuth))
+		}
+		if len(got) > 16 {
+			t.Errorf("trace number %q is longer than 16 digits", got)
+		}
+	})
+
+	t.Run("Sale Or Changed Amount", func(t *testing.T) {
+		got := traceNumber(base, MessageTypeAuth)
+		if sale := traceNumber(base, MessageTypeAuthAndCapture); sale == got {
+			t.Errorf("expected a sale to have another trace number than the authorization, got %q", sale)
+		}
+		changed := *base
+		changed.Amount.Amount++
+		if other := traceNumber(&changed, MessageTypeAuth); other == got {
+			t.Errorf("expected a changed amount to have another trace number, got %q", other)
+		}
+	})
+
+	t.Run("Not Opted Into Reversal", func(t *testing.T) {
+		notReversible := *base
+		notReversible.Options = nil
+		if got := traceNumber(&notReversible, MessageTypeAuth); got != "" {
+			t.Errorf("expected no trace number, got %q", got)
+		}
+	})
+
+	t.Run("Without Reference", func(t *testing.T) {
+		base.ClientTransactionReference = nil
+		if got := traceNumber(base, MessageTypeAuth); got != "" {
+			t.Errorf("expected no trace number, got %q", got)
+		}
+	})
+}
@@ -1,6 +1,9 @@
 package orbital
 
-import "github.com/BoltApp/sleet"
+import (
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+)
 
 var currencyMap = map[string]CurrencyCode{
 	"USD": CurrencyCodeUSD,
@@ -44,3 +47,42 @@ func translateAvs(avs AVSResponseCode) sleet.AVSResponse {
 	}
 	return sleetCode
 }
+
+// translateResultType classifies an unsuccessful Orbital response. A ProcStatus other than success means the request
+// was rejected by the gateway before reaching the issuer, while a RespCode other than approved is an issuer decline.
+func translateResultType(procStatus int, respCode string, statusCode int) sleet.ResultType {
+	if procStatus != ProcStatusSuccess {
+		if resultType := common.ResultTypeFromHTTPStatus(statusCode); resultType != sleet.ResultTypeUnknownError {
+			return resultType
+		}
+		return sleet.ResultTypeAPIError
+	}
+	switch respCode {
+	case RespCodeApproved:
+		return sleet.ResultTypeSuccess
+	case "", RespCodeNotPresent:
+		return sleet.ResultTypeUnknownError
+	}
+	return sleet.ResultTypePaymentError
+}
+
+// Response codes taken from the Orbital Gateway XML interface specification
+var declineReasonMap = map[string]sleet.DeclineReason{
+	"01": sleet.DeclineReasonCallIssuer,
+	"02": sleet.DeclineReasonCallIssuer,
+	"04": sleet.DeclineReasonRestrictedCard,
+	"05": sleet.DeclineReasonDoNotHonor,
+	"14": sleet.DeclineReasonInvalidCardNumber,
+	"15": sleet.DeclineReasonInvalidCardNumber,
+	"33": sleet.DeclineReasonExpiredCard,
+	"41": sleet.DeclineReasonLostOrStolenCard,
+	"51": sleet.DeclineReasonInsufficientFunds,
+	"54": sleet.DeclineReasonExpiredCard,
+	"57": sleet.DeclineReasonTransactionNotAllowed,
+	"59": sleet.DeclineReasonSuspectedFraud,
+	"61": sleet.DeclineReasonLimitExceeded,
+	"62": sleet.DeclineReasonRestrictedCard,
+	"65": sleet.DeclineReasonLimitExceeded,
+	"91": sleet.DeclineReasonIssuerUnavailable,
+	"N7": sleet.DeclineReasonCVVFailure,
+}
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
 )
 
 func TestCurrencyMap(t *testing.T) {
@@ -80,3 +81,55 @@ func TestTranslateAvs(t *testing.T) {
 		})
 	}
 }
+
+func TestTranslateResultType(t *testing.T) {
+	cases := []struct {
+		label      string
+		procStatus int
+		respCode   string
+		statusCode int
+		want       sleet.ResultType
+	}{
+		{"Approved", ProcStatusSuccess, RespCodeApproved, 200, sleet.ResultTypeSuccess},
+		{"Do Not Honor", ProcStatusSuccess, "05", 200, sleet.ResultTypePaymentError},
+		{"Insufficient Funds", ProcStatusSuccess, "51", 200, sleet.ResultTypePaymentError},
+		{"Missing Response Code", ProcStatusSuccess, "", 200, sleet.ResultTypeUnknownError},
+		{"Invalid Credentials", 9576, "", 200, sleet.ResultTypeAPIError},
+		{"Rejected With Response Code", 841, "05", 200, sleet.ResultTypeAPIError},
+		{"Outage", 20400, "", 503, sleet.ResultTypeServerError},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			got := translateResultType(c.procStatus, c.respCode, c.statusCode)
+			if got != c.want {
+				t.Errorf("Got %q, want %q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestDeclineReasonMap(t *testing.T) {
+	cases := []struct {
+		label    string
+		respCode string
+		want     sleet.DeclineReason
+	}{
+		{"Approved", RespCodeApproved, ""},
+		{"Do Not Honor", "05", sleet.DeclineReasonDoNotHonor},
+		{"Insufficient Funds", "51", sleet.DeclineReasonInsufficientFunds},
+		{"Expired Card", "33", sleet.DeclineReasonExpiredCard},
+		{"Untranslated Decline", "B7", sleet.DeclineReasonUnknown},
+		{"Missing Response Code", "", ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			resultType := translateResultType(ProcStatusSuccess, c.respCode, 200)
+			got := common.TranslateDeclineReason(declineReasonMap, c.respCode, resultType)
+			if got != c.want {
+				t.Errorf("Got %q, want %q", got, c.want)
+			}
+		})
+	}
+}
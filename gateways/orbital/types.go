@@ -98,6 +98,9 @@ const (
 	ContentTransferEncoding = "text"
 	RequestNumber           = "1"
 	DocumentType            = "Request"
+	TraceNumberHeader       = "Trace-number" // a retried request with the same trace number returns the original response
+	MerchantIDHeader        = "Merchant-Id"  // required along with the trace number
+	TraceNumberMax          = 9999999999999999
 )
 
 type Request struct {
@@ -112,6 +115,11 @@ const (
 	RespCodeNotPresent = "zz" // returned in place of RespCode when none is returned by the api
 )
 
+const (
+	PartialAuthIndicatorYes = "Y"
+	PartialAuthOccurredYes  = "Y"
+)
+
 type Response struct {
 	XMLName xml.Name     `xml:"Response"`
 	Body    ResponseBody `xml:",any"`
@@ -143,26 +151,29 @@ type RequestBody struct {
 	AVScountryCode            string           `xml:"AVScountryCode,omitempty"`
 	AVSphoneNum               string           `xml:"AVSphoneNum,omitempty"`
 	OrderID                   string           `xml:"OrderID,omitempty"`                // generated id, max 22 chars
-	Amount                    int64            `xml:"Amount,omitempty"`                 //int with the last 2 digits being implied decimals ie 100.25 is sent as 10025, 90 is sent as 9000
+	Amount                    *int64           `xml:"Amount,omitempty"`                 //int with the last 2 digits being implied decimals ie 100.25 is sent as 10025, 90 is sent as 9000
+	PartialAuthInd            string           `xml:"PartialAuthInd,omitempty"`         // Y to accept approvals for less than Amount
 	DPANInd                   string           `xml:"DPANInd,omitempty"`                // does this token represent a device based Primary Account Number (DPAN). Y if yes, omit if not. Pan goes in AccountNum
 	DigitalTokenCryptogram    string           `xml:"DigitalTokenCryptogram,omitempty"` // cryptogram for network tokenized cards (i.e. ApplePay)
 }
 
 type ResponseBody struct {
-	XMLName        xml.Name
-	IndustryType   string          `xml:"IndustryType"`
-	MessageType    string          `xml:"MessageType"`
-	MerchantID     int             `xml:"MerchantID"`
-	TerminalID     int             `xml:"TerminalID"`
-	AccountNum     string          `xml:"AccountNum"`
-	OrderID        string          `xml:"OrderID"`
-	TxRefNum       string          `xml:"TxRefNum"`
-	TxRefIdx       int             `xml:"TxRefIdx"`
-	RespCode       string          `xml:"RespCode"`
-	StatusMsg      string          `xml:"StatusMsg"`
-	ProcStatus     int             `xml:"ProcStatus"`
-	AVSRespCode    AVSResponseCode `xml:"AVSRespCode"`
-	CVV2RespCode   CVVResponseCode `xml:"CVV2RespCode"`
-	ApprovalStatus ApprovalStatus  `xml:"ApprovalStatus"`
-	RedeemedAmount int             `xml:"RedeemedAmount"`
+	XMLName             xml.Name
+	IndustryType        string          `xml:"IndustryType"`
+	MessageType         string          `xml:"MessageType"`
+	MerchantID          int             `xml:"MerchantID"`
+	TerminalID          int             `xml:"TerminalID"`
+	AccountNum          string          `xml:"AccountNum"`
+	OrderID             string          `xml:"OrderID"`
+	TxRefNum            string          `xml:"TxRefNum"`
+	TxRefIdx            int             `xml:"TxRefIdx"`
+	RespCode            string          `xml:"RespCode"`
+	StatusMsg           string          `xml:"StatusMsg"`
+	ProcStatus          int             `xml:"ProcStatus"`
+	AVSRespCode         AVSResponseCode `xml:"AVSRespCode"`
+	CVV2RespCode        CVVResponseCode `xml:"CVV2RespCode"`
+	ApprovalStatus      ApprovalStatus  `xml:"ApprovalStatus"`
+	PartialAuthOccurred string          `xml:"PartialAuthOccurred"`
+	RequestAmount       int             `xml:"RequestAmount"`
+	RedeemedAmount      int             `xml:"RedeemedAmount"` // the amount approved on a partial authorization
 }
@@ -0,0 +1,29 @@
+//go:build unit
+// +build unit
+
+package paypalpayflow
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+	"github.com/BoltApp/sleet/testing/conformance"
+	"github.com/BoltApp/sleet/testing/fakes"
+)
+
+func TestConformance(t *testing.T) {
+	fake := fakes.NewPaypalPayflow()
+	defer fake.Close()
+
+	conformance.Run(t, conformance.Suite{
+		Gateway:   "paypalpayflow",
+		Transport: fake.Client().Transport,
+		NewClient: func(httpClient *http.Client) sleet.ClientWithContext {
+			return NewWithHttpClient(fakes.PaypalPayflowPartner, fakes.PaypalPayflowPassword, fakes.PaypalPayflowVendor, fakes.PaypalPayflowUser, common.Sandbox, httpClient)
+		},
+		// the pilot environment declines amounts over $1000.00
+		Decline: func(request *sleet.AuthorizationRequest) { request.Amount.Amount = 200000 },
+	})
+}
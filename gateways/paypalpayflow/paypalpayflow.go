@@ -11,24 +11,32 @@ import (
 	"github.com/BoltApp/sleet/common"
 )
 
+const gatewayName = "paypalpayflow"
+
 var (
 	// assert client interface
-	_ sleet.ClientWithContext = &PaypalPayflowClient{}
+	_ sleet.ClientWithContext             = &PaypalPayflowClient{}
+	_ sleet.TransactionQuerierWithContext = &PaypalPayflowClient{}
+	_ sleet.SaleWithContext               = &PaypalPayflowClient{}
+	_ sleet.VerifierWithContext           = &PaypalPayflowClient{}
+	_ sleet.CapabilitiesReporter          = &PaypalPayflowClient{}
 )
 
-func NewClient(partner string, password string, vendor string, user string, environment common.Environment) *PaypalPayflowClient {
-	return NewWithHttpClient(partner, password, vendor, user, environment, common.DefaultHttpClient())
+func NewClient(partner string, password string, vendor string, user string, environment common.Environment, options ...sleet.ClientOption) *PaypalPayflowClient {
+	return NewWithHttpClient(partner, password, vendor, user, environment, common.DefaultHttpClient(), options...)
 }
 
 // NewWithHttpClient uses authentication with custom http client
-func NewWithHttpClient(partner string, password string, vendor string, user string, environment common.Environment, httpClient *http.Client) *PaypalPayflowClient {
+func NewWithHttpClient(partner string, password string, vendor string, user string, environment common.Environment, httpClient *http.Client, options ...sleet.ClientOption) *PaypalPayflowClient {
+	clientOptions := sleet.NewClientOptions(options...)
 	return &PaypalPayflowClient{
-		httpClient: httpClient,
+		httpClient: common.LoggingHttpClient(httpClient, gatewayName, clientOptions.Logger),
+		logger:     clientOptions.Logger,
 		partner:    partner,
 		password:   password,
 		vendor:     vendor,
 		user:       user,
-		url:        paypalURL(environment),
+		url:        common.OverrideBaseURL(paypalURL(environment), clientOptions.BaseURL),
 	}
 }
 
@@ -63,6 +71,7 @@ func (client *PaypalPayflowClient) sendRequest(ctx context.Context, request *Req
 		"BILLTOCOUNTRY":   request.BillToCountry,
 		"CARDONFILE":      request.CardOnFile,
 		"TXID":            request.TxID,
+		"PARTIALAUTH":     request.PartialAuth,
 	}
 	for k, v := range fields {
 		switch v := v.(type) {
@@ -89,28 +98,47 @@ func (client *PaypalPayflowClient) sendRequest(ctx context.Context, request *Req
 
 	resp, err := client.httpClient.Do(req)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, common.TransportError(gatewayName, err)
 	}
 
 	defer resp.Body.Close()
 
 	bodyText, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, common.TransportError(gatewayName, err)
 	}
 
 	response := make(Response)
 	for _, line := range strings.Split(string(bodyText), "&") {
-		line := strings.Split(strings.TrimSpace(line), "=")
+		line := strings.SplitN(strings.TrimSpace(line), "=", 2)
 		if len(line) != 2 {
-			continue
+			return nil, nil, common.ResponseError(gatewayName, resp.StatusCode, fmt.Errorf("response field %q has no value", line[0]))
 		}
 		response[line[0]] = line[1]
 	}
+	// every response has a RESULT and RESPMSG, a response without them was truncated
+	for _, field := range []string{resultFieldName, messageFieldName} {
+		if _, ok := response[field]; !ok {
+			return nil, nil, common.ResponseError(gatewayName, resp.StatusCode, fmt.Errorf("response has no %s field", field))
+		}
+	}
 
+	common.LogResult(ctx, client.logger, gatewayName, response[resultFieldName])
 	return &response, resp, nil
 }
 
+// Capabilities describes the optional operations supported by PayPal Payflow
+func (client *PaypalPayflowClient) Capabilities() sleet.Capabilities {
+	return sleet.Capabilities{
+		ContextSupport:       true,
+		Sale:                 true,
+		TransactionQuery:     true,
+		PartialAuthorization: true,
+		PartialCapture:       true,
+		PartialRefund:        true,
+	}
+}
+
 // Authorize a transaction. This transaction must be captured to receive funds
 func (client *PaypalPayflowClient) Authorize(request *sleet.AuthorizationRequest) (*sleet.AuthorizationResponse, error) {
 	return client.AuthorizeWithContext(context.TODO(), request)
@@ -118,27 +146,81 @@ func (client *PaypalPayflowClient) Authorize(request *sleet.AuthorizationRequest
 
 // AuthorizeWithContext a transaction. This transaction must be captured to receive funds
 func (client *PaypalPayflowClient) AuthorizeWithContext(ctx context.Context, request *sleet.AuthorizationRequest) (*sleet.AuthorizationResponse, error) {
-	response, httpResponse, err := client.sendRequest(ctx, buildAuthorizeParams(request))
+	response, err := client.sendAuthRequest(ctx, buildAuthorizeParams(request), request.Options)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationAuthorize, err)
+	}
+	return response, nil
+}
+
+// Sale authorizes and captures a transaction in a single call
+func (client *PaypalPayflowClient) Sale(request *sleet.SaleRequest) (*sleet.SaleResponse, error) {
+	return client.SaleWithContext(context.TODO(), request)
+}
+
+// SaleWithContext authorizes and captures a transaction in a single call
+func (client *PaypalPayflowClient) SaleWithContext(ctx context.Context, request *sleet.SaleRequest) (*sleet.SaleResponse, error) {
+	resp, err := client.sendAuthRequest(ctx, buildSaleParams(request), request.Options)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationSale, err)
+	}
+	return &sleet.SaleResponse{AuthorizationResponse: *resp}, nil
+}
+
+// Verify checks a card with an authorization followed by a void
+func (client *PaypalPayflowClient) Verify(request *sleet.VerificationRequest) (*sleet.VerificationResponse, error) {
+	return client.VerifyWithContext(context.TODO(), request)
+}
+
+// VerifyWithContext checks a card with an authorization followed by a void
+func (client *PaypalPayflowClient) VerifyWithContext(ctx context.Context, request *sleet.VerificationRequest) (*sleet.VerificationResponse, error) {
+	response, err := sleet.VerifyByAuthorization(ctx, client, request)
+	return response, common.OperationError(gatewayName, sleet.OperationVerify, err)
+}
+
+func (client *PaypalPayflowClient) sendAuthRequest(ctx context.Context, request *Request, options map[string]interface{}) (*sleet.AuthorizationResponse, error) {
+	response, httpResponse, err := client.sendRequest(ctx, request)
 	if err != nil {
 		return nil, err
 	}
 
-	responseHeader := sleet.GetHTTPResponseHeader(request.Options, *httpResponse)
+	responseHeader := sleet.GetHTTPResponseHeader(options, *httpResponse)
 	transactionID, ok1 := (*response)[transactionFieldName]
 	result, ok2 := (*response)[resultFieldName]
+	if ok2 && result == successResponse {
+		// a HIGH verbosity approval gives the approved amount, without which a partial approval looks complete
+		for _, field := range []string{transactionFieldName, amountFieldName} {
+			if _, ok := (*response)[field]; !ok {
+				return nil, common.ResponseError(gatewayName, httpResponse.StatusCode, fmt.Errorf("approval has no %s field", field))
+			}
+		}
+	}
 	if ok1 && ok2 && result == successResponse {
-		return &sleet.AuthorizationResponse{
+		resp := &sleet.AuthorizationResponse{
 			Success:              true,
 			TransactionReference: transactionID,
+			ResultType:           sleet.ResultTypeSuccess,
 			StatusCode:           httpResponse.StatusCode,
 			Header:               responseHeader,
-		}, nil
+		}
+		// AMT is the amount approved, which is less than requested on a partial authorization
+		if amount, ok := (*response)[amountFieldName]; ok && request.Currency != nil {
+			approvedAmount, err := common.AmountFromDecimalString(amount, *request.Currency)
+			if err != nil {
+				return nil, common.ResponseError(gatewayName, httpResponse.StatusCode, err)
+			}
+			resp.ApprovedAmount = &sleet.Amount{Amount: approvedAmount, Currency: *request.Currency}
+		}
+		return resp, nil
 	}
 
+	resultType := translateResultType(result, httpResponse.StatusCode)
 	return &sleet.AuthorizationResponse{
-		ErrorCode:  result,
-		StatusCode: httpResponse.StatusCode,
-		Header:     responseHeader,
+		ErrorCode:     result,
+		ResultType:    resultType,
+		DeclineReason: common.TranslateDeclineReason(declineReasonMap, result, resultType),
+		StatusCode:    httpResponse.StatusCode,
+		Header:        responseHeader,
 	}, nil
 }
 
@@ -149,22 +231,31 @@ func (client *PaypalPayflowClient) Capture(request *sleet.CaptureRequest) (*slee
 
 // CaptureWithContext an authorized transaction
 func (client *PaypalPayflowClient) CaptureWithContext(ctx context.Context, request *sleet.CaptureRequest) (*sleet.CaptureResponse, error) {
-	response, _, err := client.sendRequest(ctx, buildCaptureParams(request))
+	response, httpResponse, err := client.sendRequest(ctx, buildCaptureParams(request))
 	if err != nil {
-		return nil, err
+		return nil, common.OperationError(gatewayName, sleet.OperationCapture, err)
 	}
 
+	responseHeader := sleet.GetHTTPResponseHeader(request.Options, *httpResponse)
 	transactionID, ok1 := (*response)[transactionFieldName]
 	result, ok2 := (*response)[resultFieldName]
 	if ok1 && ok2 && result == successResponse {
 		return &sleet.CaptureResponse{
 			Success:              true,
 			TransactionReference: transactionID,
+			ResultType:           sleet.ResultTypeSuccess,
+			Amount:               request.Amount,
+			StatusCode:           httpResponse.StatusCode,
+			Header:               responseHeader,
 		}, nil
 	}
 
 	return &sleet.CaptureResponse{
-		ErrorCode: &result,
+		ErrorCode:  &result,
+		ResultType: translateResultType(result, httpResponse.StatusCode),
+		Message:    (*response)[messageFieldName],
+		StatusCode: httpResponse.StatusCode,
+		Header:     responseHeader,
 	}, nil
 }
 
@@ -175,20 +266,29 @@ func (client *PaypalPayflowClient) Void(request *sleet.VoidRequest) (*sleet.Void
 
 // VoidWithContext an authorized transaction
 func (client *PaypalPayflowClient) VoidWithContext(ctx context.Context, request *sleet.VoidRequest) (*sleet.VoidResponse, error) {
-	response, _, err := client.sendRequest(ctx, buildVoidParams(request))
+	response, httpResponse, err := client.sendRequest(ctx, buildVoidParams(request))
 	if err != nil {
-		return nil, err
+		return nil, common.OperationError(gatewayName, sleet.OperationVoid, err)
 	}
 
+	responseHeader := sleet.GetHTTPResponseHeader(request.Options, *httpResponse)
 	result, ok := (*response)[resultFieldName]
 	if ok && result == successResponse {
 		return &sleet.VoidResponse{
-			Success: true,
+			Success:              true,
+			TransactionReference: (*response)[transactionFieldName],
+			ResultType:           sleet.ResultTypeSuccess,
+			StatusCode:           httpResponse.StatusCode,
+			Header:               responseHeader,
 		}, nil
 	}
 
 	return &sleet.VoidResponse{
-		ErrorCode: &result,
+		ErrorCode:  &result,
+		ResultType: translateResultType(result, httpResponse.StatusCode),
+		Message:    (*response)[messageFieldName],
+		StatusCode: httpResponse.StatusCode,
+		Header:     responseHeader,
 	}, nil
 }
 
@@ -199,19 +299,55 @@ func (client *PaypalPayflowClient) Refund(request *sleet.RefundRequest) (*sleet.
 
 // RefundWithContext a captured transaction
 func (client *PaypalPayflowClient) RefundWithContext(ctx context.Context, request *sleet.RefundRequest) (*sleet.RefundResponse, error) {
-	response, _, err := client.sendRequest(ctx, buildRefundParams(request))
+	response, httpResponse, err := client.sendRequest(ctx, buildRefundParams(request))
 	if err != nil {
-		return nil, err
+		return nil, common.OperationError(gatewayName, sleet.OperationRefund, err)
 	}
 
+	responseHeader := sleet.GetHTTPResponseHeader(request.Options, *httpResponse)
 	result, ok := (*response)[resultFieldName]
 	if ok && result == successResponse {
 		return &sleet.RefundResponse{
-			Success: true,
+			Success:              true,
+			TransactionReference: (*response)[transactionFieldName],
+			ResultType:           sleet.ResultTypeSuccess,
+			Amount:               request.Amount,
+			StatusCode:           httpResponse.StatusCode,
+			Header:               responseHeader,
 		}, nil
 	}
 
 	return &sleet.RefundResponse{
-		ErrorCode: &result,
+		ErrorCode:  &result,
+		ResultType: translateResultType(result, httpResponse.StatusCode),
+		Message:    (*response)[messageFieldName],
+		StatusCode: httpResponse.StatusCode,
+		Header:     responseHeader,
 	}, nil
 }
+
+// QueryTransaction runs an inquiry transaction to retrieve the state of a transaction
+func (client *PaypalPayflowClient) QueryTransaction(request *sleet.TransactionQueryRequest) (*sleet.TransactionQueryResponse, error) {
+	return client.QueryTransactionWithContext(context.TODO(), request)
+}
+
+// QueryTransactionWithContext runs an inquiry transaction to retrieve the state of a transaction
+func (client *PaypalPayflowClient) QueryTransactionWithContext(ctx context.Context, request *sleet.TransactionQueryRequest) (*sleet.TransactionQueryResponse, error) {
+	response, httpResponse, err := client.sendRequest(ctx, buildInquiryParams(request))
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationQueryTransaction, err)
+	}
+
+	result, ok := (*response)[resultFieldName]
+	if !ok || result != successResponse {
+		return &sleet.TransactionQueryResponse{
+			ErrorCode: &result,
+		}, nil
+	}
+
+	queryResponse, err := translateInquiryResponse(request.TransactionReference, *response)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationQueryTransaction, common.ResponseError(gatewayName, httpResponse.StatusCode, err))
+	}
+	return queryResponse, nil
+}
@@ -0,0 +1,29 @@
+package paypalpayflow
+
+import (
+	"net/http"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/registry"
+)
+
+// Payflow credential keys of a registry.Config, matching the PARTNER, PWD, VENDOR and USER parameters
+const (
+	credentialPartner  = "partner"
+	credentialPassword = "password"
+	credentialVendor   = "vendor"
+	credentialUser     = "user"
+)
+
+func init() {
+	registry.Register("paypalpayflow", newRegistryClient)
+}
+
+// newRegistryClient builds a PaypalPayflowClient from a registry config
+func newRegistryClient(config *registry.Config, httpClient *http.Client, options ...sleet.ClientOption) (sleet.ClientWithContext, error) {
+	credentials, err := config.RequiredCredentials(credentialPartner, credentialPassword, credentialVendor, credentialUser)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithHttpClient(credentials[0], credentials[1], credentials[2], credentials[3], config.Environment, httpClient, options...), nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
 )
 
 var (
@@ -37,6 +38,11 @@ func buildAuthorizeParams(request *sleet.AuthorizationRequest) *Request {
 		}
 	}
 
+	var partialAuth *string
+	if request.AllowPartialAuth {
+		partialAuth = common.SPtr(partialAuthEnabled)
+	}
+
 	return &Request{
 		TrxType:            AUTHORIZATION,
 		Amount:             &amount,
@@ -54,9 +60,16 @@ func buildAuthorizeParams(request *sleet.AuthorizationRequest) *Request {
 		BillToCountry:      request.BillingAddress.CountryCode,
 		CardOnFile:         CardOnFile,
 		TxID:               request.PreviousExternalTransactionID,
+		PartialAuth:        partialAuth,
 	}
 }
 
+func buildSaleParams(request *sleet.SaleRequest) *Request {
+	params := buildAuthorizeParams(request)
+	params.TrxType = SALE
+	return params
+}
+
 func buildCaptureParams(request *sleet.CaptureRequest) *Request {
 	amount := sleet.AmountToDecimalString(request.Amount)
 	return &Request{
@@ -97,3 +110,12 @@ func buildRefundParams(request *sleet.RefundRequest) *Request {
 		Currency:   currency,
 	}
 }
+
+func buildInquiryParams(request *sleet.TransactionQueryRequest) *Request {
+	return &Request{
+		TrxType:    INQUIRY,
+		OriginalID: &request.TransactionReference,
+		Verbosity:  &defaultVerbosity,
+		Tender:     &defaultTender,
+	}
+}
@@ -132,6 +132,28 @@ func TestBuildAuthRequest(t *testing.T) {
 	}
 }
 
+func TestBuildSaleRequest(t *testing.T) {
+	base := sleet_testing.BaseAuthorizationRequest()
+
+	want := buildAuthorizeParams(base)
+	want.TrxType = SALE
+
+	got := buildSaleParams(base)
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestBuildAuthRequestAllowPartialAuth(t *testing.T) {
+	base := sleet_testing.BaseAuthorizationRequest()
+	base.AllowPartialAuth = true
+
+	got := buildAuthorizeParams(base)
+	if got.PartialAuth == nil || *got.PartialAuth != "Y" {
+		t.Errorf("expected PARTIALAUTH to be Y, got %v", got.PartialAuth)
+	}
+}
+
 func TestBuildCaptureRequest(t *testing.T) {
 	base := sleet_testing.BaseCaptureRequest()
 	cases := []struct {
@@ -224,3 +246,31 @@ func TestBuildRefundRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildInquiryRequest(t *testing.T) {
+	cases := []struct {
+		label string
+		in    *sleet.TransactionQueryRequest
+		want  Request
+	}{
+		{
+			"Basic Inquiry Request",
+			&sleet.TransactionQueryRequest{TransactionReference: OriginalID},
+			Request{
+				TrxType:    INQUIRY,
+				OriginalID: &OriginalID,
+				Verbosity:  &defaultTestVerbosity,
+				Tender:     &defaultTestTender,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			got := buildInquiryParams(c.in)
+			if diff := deep.Equal(got, &c.want); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
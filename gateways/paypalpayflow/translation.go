@@ -1 +1,131 @@
 package paypalpayflow
+
+import (
+	"strings"
+	"time"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+)
+
+// resultTypeMap classifies the RESULT values of unsuccessful transactions
+var resultTypeMap = map[string]sleet.ResultType{
+	"1":    sleet.ResultTypeAPIError,     // user authentication failed
+	"2":    sleet.ResultTypeAPIError,     // invalid tender type
+	"3":    sleet.ResultTypeAPIError,     // invalid transaction type
+	"4":    sleet.ResultTypeAPIError,     // invalid amount format
+	"5":    sleet.ResultTypeAPIError,     // invalid merchant information
+	"7":    sleet.ResultTypeAPIError,     // field format error
+	"12":   sleet.ResultTypePaymentError, // declined
+	"13":   sleet.ResultTypePaymentError, // referral
+	"23":   sleet.ResultTypePaymentError, // invalid account number
+	"24":   sleet.ResultTypePaymentError, // invalid expiration date
+	"26":   sleet.ResultTypeAPIError,     // invalid vendor account
+	"30":   sleet.ResultTypeAPIError,     // duplicate transaction
+	"50":   sleet.ResultTypePaymentError, // insufficient funds
+	"104":  sleet.ResultTypeServerError,  // timeout waiting for the processor
+	"105":  sleet.ResultTypeAPIError,     // credit error
+	"108":  sleet.ResultTypeAPIError,     // void error
+	"111":  sleet.ResultTypeAPIError,     // capture error
+	"112":  sleet.ResultTypePaymentError, // failed AVS check
+	"114":  sleet.ResultTypePaymentError, // CVV2 mismatch
+	"125":  sleet.ResultTypePaymentError, // declined by fraud protection
+	"126":  sleet.ResultTypePaymentError, // flagged for review by fraud protection
+	"150":  sleet.ResultTypeServerError,  // issuing bank timed out
+	"151":  sleet.ResultTypeServerError,  // issuing bank unavailable
+	"1000": sleet.ResultTypeServerError,  // generic host error
+}
+
+// translateResultType converts the RESULT of an unsuccessful transaction to a Sleet result type. Negative results are
+// communication errors, and responses without a RESULT are classified by their HTTP status.
+func translateResultType(result string, statusCode int) sleet.ResultType {
+	if result == "" {
+		return common.ResultTypeFromHTTPStatus(statusCode)
+	}
+	if strings.HasPrefix(result, "-") {
+		return sleet.ResultTypeServerError
+	}
+	resultType, ok := resultTypeMap[result]
+	if !ok {
+		return sleet.ResultTypeUnknownError
+	}
+	return resultType
+}
+
+// declineReasonMap translates the RESULT values of declined transactions
+var declineReasonMap = map[string]sleet.DeclineReason{
+	"12":  sleet.DeclineReasonDoNotHonor,            // declined
+	"13":  sleet.DeclineReasonCallIssuer,            // referral
+	"23":  sleet.DeclineReasonInvalidCardNumber,     // invalid account number
+	"24":  sleet.DeclineReasonInvalidExpirationDate, // invalid expiration date
+	"50":  sleet.DeclineReasonInsufficientFunds,     // insufficient funds
+	"51":  sleet.DeclineReasonLimitExceeded,         // exceeds per transaction limit
+	"104": sleet.DeclineReasonTryAgainLater,         // timeout waiting for the processor
+	"112": sleet.DeclineReasonAVSFailure,            // failed AVS check
+	"114": sleet.DeclineReasonCVVFailure,            // CVV2 mismatch
+	"125": sleet.DeclineReasonSuspectedFraud,        // declined by fraud protection
+	"126": sleet.DeclineReasonSuspectedFraud,        // flagged for review by fraud protection
+	"150": sleet.DeclineReasonIssuerUnavailable,     // issuing bank timed out
+	"151": sleet.DeclineReasonIssuerUnavailable,     // issuing bank unavailable
+}
+
+// Transaction states returned by inquiry transactions
+var transStateMap = map[string]sleet.TransactionState{
+	"1":  sleet.TransactionStateDeclined,
+	"3":  sleet.TransactionStateAuthorized,
+	"4":  sleet.TransactionStateCaptured,
+	"6":  sleet.TransactionStateCaptured,
+	"7":  sleet.TransactionStateCaptured,
+	"8":  sleet.TransactionStateSettled,
+	"9":  sleet.TransactionStateCaptured,
+	"12": sleet.TransactionStateDeclined,
+}
+
+// Card types returned by inquiry transactions
+var cardTypeMap = map[string]sleet.CreditCardNetwork{
+	"0": sleet.CreditCardNetworkVisa,
+	"1": sleet.CreditCardNetworkMastercard,
+	"2": sleet.CreditCardNetworkDiscover,
+	"3": sleet.CreditCardNetworkAmex,
+	"5": sleet.CreditCardNetworkJcb,
+}
+
+// transTimeFormat is the format of TRANSTIME, which is in Pacific time
+const transTimeFormat = "2006-01-02 15:04:05"
+
+// translateInquiryResponse converts an inquiry response to a Sleet transaction query response.
+// Payflow does not return the currency of the original transaction, so Currency is empty.
+func translateInquiryResponse(transactionReference string, response Response) (*sleet.TransactionQueryResponse, error) {
+	state, ok := transStateMap[response[transStateFieldName]]
+	if !ok {
+		state = sleet.TransactionStateUnknown
+	}
+	if origResult, ok := response[origResultFieldName]; ok && origResult != successResponse {
+		state = sleet.TransactionStateDeclined
+	}
+
+	queryResponse := &sleet.TransactionQueryResponse{
+		Success:              true,
+		TransactionReference: transactionReference,
+		State:                state,
+		StateRaw:             response[transStateFieldName],
+		Last4:                response[acctFieldName],
+		Network:              cardTypeMap[response[cardTypeFieldName]],
+	}
+	if amt, ok := response[amountFieldName]; ok && state != sleet.TransactionStateDeclined {
+		amount, err := common.AmountFromDecimalString(amt, "")
+		if err != nil {
+			return nil, err
+		}
+		queryResponse.AuthorizedAmount = amount
+		if state == sleet.TransactionStateCaptured || state == sleet.TransactionStateSettled {
+			queryResponse.CapturedAmount = amount
+		}
+	}
+	if location, err := time.LoadLocation("America/Los_Angeles"); err == nil {
+		if transTime, err := time.ParseInLocation(transTimeFormat, response[transTimeFieldName], location); err == nil {
+			queryResponse.CreatedAt = &transTime
+		}
+	}
+	return queryResponse, nil
+}
@@ -0,0 +1,63 @@
+//go:build unit
+// +build unit
+
+package paypalpayflow
+
+import (
+	"testing"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+)
+
+func TestTranslateResultType(t *testing.T) {
+	cases := []struct {
+		label      string
+		result     string
+		statusCode int
+		want       sleet.ResultType
+	}{
+		{"Authentication Failed", "1", 200, sleet.ResultTypeAPIError},
+		{"Declined", "12", 200, sleet.ResultTypePaymentError},
+		{"Insufficient Funds", "50", 200, sleet.ResultTypePaymentError},
+		{"Duplicate Transaction", "30", 200, sleet.ResultTypeAPIError},
+		{"CVV Mismatch", "114", 200, sleet.ResultTypePaymentError},
+		{"Issuer Unavailable", "151", 200, sleet.ResultTypeServerError},
+		{"Connection Timeout", "-12", 200, sleet.ResultTypeServerError},
+		{"Unknown Result", "999", 200, sleet.ResultTypeUnknownError},
+		{"No Result", "", 502, sleet.ResultTypeServerError},
+	}
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			if got := translateResultType(c.result, c.statusCode); got != c.want {
+				t.Errorf("expected %s, got %s", c.want, got)
+			}
+		})
+	}
+}
+
+func TestDeclineReasonMap(t *testing.T) {
+	cases := []struct {
+		label  string
+		result string
+		want   sleet.DeclineReason
+	}{
+		{"Approved", "0", ""},
+		{"Declined", "12", sleet.DeclineReasonDoNotHonor},
+		{"Insufficient Funds", "50", sleet.DeclineReasonInsufficientFunds},
+		{"CVV2 Mismatch", "114", sleet.DeclineReasonCVVFailure},
+		{"Issuing Bank Unavailable", "151", sleet.DeclineReasonIssuerUnavailable},
+		{"Authentication Failed", "1", ""},
+	}
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			resultType := sleet.ResultTypeSuccess
+			if c.result != "0" {
+				resultType = translateResultType(c.result, 200)
+			}
+			if got := common.TranslateDeclineReason(declineReasonMap, c.result, resultType); got != c.want {
+				t.Errorf("expected %q, got %q", c.want, got)
+			}
+		})
+	}
+}
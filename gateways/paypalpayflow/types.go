@@ -1,6 +1,10 @@
 package paypalpayflow
 
-import "net/http"
+import (
+	"net/http"
+
+	"github.com/BoltApp/sleet"
+)
 
 type PaypalPayflowClient struct {
 	partner    string
@@ -9,21 +13,36 @@ type PaypalPayflowClient struct {
 	user       string
 	httpClient *http.Client
 	url        string
+	logger     sleet.Logger
 }
 
 const (
 	REFUND        = "C"
 	AUTHORIZATION = "A"
+	SALE          = "S"
 	CAPTURE       = "D"
 	VOID          = "V"
+	INQUIRY       = "I"
 )
 
 const (
 	successResponse      = "0"
 	transactionFieldName = "PNREF"
 	resultFieldName      = "RESULT"
+	messageFieldName     = "RESPMSG"
+
+	// inquiry response fields
+	origResultFieldName = "ORIGRESULT"
+	transStateFieldName = "TRANSSTATE"
+	amountFieldName     = "AMT"
+	acctFieldName       = "ACCT"
+	cardTypeFieldName   = "CARDTYPE"
+	transTimeFieldName  = "TRANSTIME"
 )
 
+// partialAuthEnabled opts an authorization in to partial approvals, AMT is then the approved amount in the response
+const partialAuthEnabled = "Y"
+
 type Request struct {
 	TrxType            string
 	Amount             *string
@@ -42,6 +61,7 @@ type Request struct {
 	BillToCountry      *string // country code
 	CardOnFile         *string
 	TxID               *string
+	PartialAuth        *string
 }
 
 type Response map[string]string
@@ -0,0 +1,33 @@
+//go:build unit
+// +build unit
+
+package rocketgate
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+	"github.com/BoltApp/sleet/testing/conformance"
+	"github.com/BoltApp/sleet/testing/fakes"
+)
+
+func TestConformance(t *testing.T) {
+	fake := fakes.NewRocketgate()
+	defer fake.Close()
+
+	conformance.Run(t, conformance.Suite{
+		Gateway:   "rocketgate",
+		Transport: fake.Client().Transport,
+		NewClient: func(httpClient *http.Client) sleet.ClientWithContext {
+			return NewWithHttpClient(common.Sandbox, fakes.RocketgateMerchantID, fakes.RocketgateMerchantPassword, nil, httpClient)
+		},
+		// the dev gateway declines an amount of $0.01
+		Decline: func(request *sleet.AuthorizationRequest) { request.Amount.Amount = 1 },
+		Skip: map[conformance.Behavior]string{
+			conformance.BehaviorResponseHeader: "the SDK doesn't expose the HTTP response",
+			conformance.BehaviorContextCancel:  "the SDK takes no context",
+		},
+	})
+}
@@ -0,0 +1,28 @@
+package rocketgate
+
+import (
+	"net/http"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/registry"
+)
+
+// RocketGate credential keys of a registry.Config, the merchant account is optional
+const (
+	credentialMerchantID       = "merchant_id"
+	credentialMerchantPassword = "merchant_password"
+	credentialMerchantAccount  = "merchant_account"
+)
+
+func init() {
+	registry.Register("rocketgate", newRegistryClient)
+}
+
+// newRegistryClient builds a RocketgateClient from a registry config
+func newRegistryClient(config *registry.Config, httpClient *http.Client, options ...sleet.ClientOption) (sleet.ClientWithContext, error) {
+	credentials, err := config.RequiredCredentials(credentialMerchantID, credentialMerchantPassword)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithHttpClient(config.Environment, credentials[0], credentials[1], config.OptionalCredential(credentialMerchantAccount), httpClient, options...), nil
+}
@@ -141,3 +141,17 @@ func buildRefundRequest(
 
 	return gatewayRequest
 }
+
+func buildLookupRequest(
+	merchantID string,
+	merchantPassword string,
+	queryRequest *sleet.TransactionQueryRequest,
+) *request.GatewayRequest {
+	gatewayRequest := request.NewGatewayRequest()
+
+	gatewayRequest.Set(request.MERCHANT_ID, merchantID)
+	gatewayRequest.Set(request.MERCHANT_PASSWORD, merchantPassword)
+	gatewayRequest.Set(request.TRANSACT_ID, queryRequest.TransactionReference)
+
+	return gatewayRequest
+}
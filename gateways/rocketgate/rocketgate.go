@@ -2,8 +2,12 @@ package rocketgate
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 
+	"github.com/rocketgate/rocketgate-go-sdk/request"
 	"github.com/rocketgate/rocketgate-go-sdk/response"
 	"github.com/rocketgate/rocketgate-go-sdk/service"
 
@@ -11,9 +15,21 @@ import (
 	"github.com/BoltApp/sleet/common"
 )
 
+const gatewayName = "rocketgate"
+
+// The host and servlet RocketGate's SDK sends requests to in test mode, which a base URL replaces
+const (
+	rocketgateServletHost = "dev-gateway.rocketgate.com"
+	rocketgateServlet     = "/gateway/servlet/ServiceDispatcherAccess"
+)
+
 var (
 	// assert client interface
-	_ sleet.ClientWithContext = &RocketgateClient{}
+	_ sleet.ClientWithContext             = &RocketgateClient{}
+	_ sleet.TransactionQuerierWithContext = &RocketgateClient{}
+	_ sleet.SaleWithContext               = &RocketgateClient{}
+	_ sleet.VerifierWithContext           = &RocketgateClient{}
+	_ sleet.CapabilitiesReporter          = &RocketgateClient{}
 )
 
 // RocketgateClient represents an HTTP client and the associated authentication information required for
@@ -23,7 +39,9 @@ type RocketgateClient struct {
 	merchantID       string
 	merchantPassword string
 	merchantAccount  *string
+	baseURL          string
 	httpClient       *http.Client
+	logger           sleet.Logger
 }
 
 // NewClient creates a Rocketgate client
@@ -32,8 +50,9 @@ func NewClient(
 	merchantID string,
 	merchantPassword string,
 	merchantAccount *string,
+	options ...sleet.ClientOption,
 ) *RocketgateClient {
-	return NewWithHttpClient(env, merchantID, merchantPassword, merchantAccount, common.DefaultHttpClient())
+	return NewWithHttpClient(env, merchantID, merchantPassword, merchantAccount, common.DefaultHttpClient(), options...)
 }
 
 // NewWithHttpClient creates a Rocketgate client for custom behavior
@@ -43,13 +62,58 @@ func NewWithHttpClient(
 	merchantPassword string,
 	merchantAccount *string,
 	httpClient *http.Client,
+	options ...sleet.ClientOption,
 ) *RocketgateClient {
+	clientOptions := sleet.NewClientOptions(options...)
 	return &RocketgateClient{
 		testMode:         rocketgateTestMode(env),
 		merchantID:       merchantID,
 		merchantPassword: merchantPassword,
 		merchantAccount:  merchantAccount,
-		httpClient:       httpClient,
+		baseURL:          clientOptions.BaseURL,
+		httpClient:       common.LoggingHttpClient(httpClient, gatewayName, clientOptions.Logger),
+		logger:           clientOptions.Logger,
+	}
+}
+
+// newGatewayService returns a service of RocketGate's SDK for the environment. When a base URL is set, the request
+// names it as its server: RocketGate's SDK otherwise sends confirmations and follow-on transactions to the host of the
+// site which processed the payment. A base URL without a host is an error rather than a fallback to production.
+func (client *RocketgateClient) newGatewayService(gatewayRequest *request.GatewayRequest) (*service.GatewayService, error) {
+	gatewayService := service.NewGatewayService()
+	gatewayService.SetTestMode(client.testMode)
+	gatewayService.SetHttpClient(client.httpClient)
+	if client.baseURL == "" {
+		return gatewayService, nil
+	}
+	serviceURL, err := url.Parse(common.OverrideBaseURL("https://"+rocketgateServletHost+rocketgateServlet, client.baseURL))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL %q: %w", client.baseURL, err)
+	}
+	if serviceURL.Hostname() == "" {
+		return nil, fmt.Errorf("base URL %q has no host", client.baseURL)
+	}
+	port, _ := strconv.Atoi(serviceURL.Port())
+	if port == 0 && serviceURL.Scheme == "http" {
+		port = 80
+	} else if port == 0 {
+		port = 443
+	}
+	gatewayRequest.Set(request.GATEWAY_PROTOCOL, serviceURL.Scheme)
+	gatewayRequest.Set(request.GATEWAY_SERVER, serviceURL.Hostname())
+	gatewayRequest.SetInt(request.GATEWAY_PORTNO, port)
+	gatewayRequest.Set(request.GATEWAY_SERVLET, serviceURL.Path)
+	return gatewayService, nil
+}
+
+// Capabilities describes the optional operations supported by RocketGate
+func (client *RocketgateClient) Capabilities() sleet.Capabilities {
+	return sleet.Capabilities{
+		ContextSupport:   true,
+		Sale:             true,
+		TransactionQuery: true,
+		PartialCapture:   true,
+		PartialRefund:    true,
 	}
 }
 
@@ -60,30 +124,56 @@ func (client *RocketgateClient) Authorize(request *sleet.AuthorizationRequest) (
 
 // AuthorizeWithContext a transaction. This transaction must be captured to receive funds
 // NOTE -- RocketGate's SDK does not support context, this method exists to fulfill the ClientWithContext interface
-func (client *RocketgateClient) AuthorizeWithContext(_ context.Context, request *sleet.AuthorizationRequest) (*sleet.AuthorizationResponse, error) {
-	gatewayService := service.NewGatewayService()
+func (client *RocketgateClient) AuthorizeWithContext(ctx context.Context, request *sleet.AuthorizationRequest) (*sleet.AuthorizationResponse, error) {
 	gatewayResponse := response.NewGatewayResponse()
 	gatewayRequest := buildAuthRequest(client.merchantID, client.merchantPassword, client.merchantAccount, request)
+	gatewayService, err := client.newGatewayService(gatewayRequest)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationAuthorize, err)
+	}
 
-	gatewayService.SetTestMode(client.testMode)
-	gatewayService.SetHttpClient(client.httpClient)
+	success := gatewayService.PerformAuthOnly(gatewayRequest, gatewayResponse)
+	client.logResult(ctx, gatewayResponse)
+	if err := sdkError(gatewayResponse); err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationAuthorize, err)
+	}
+	return translateAuthResponse(success, gatewayResponse), nil
+}
 
-	if !gatewayService.PerformAuthOnly(gatewayRequest, gatewayResponse) {
-		return &sleet.AuthorizationResponse{
-			Success:              false,
-			Response:             gatewayResponse.Get(response.RESPONSE_CODE),
-			ErrorCode:            gatewayResponse.Get(response.REASON_CODE),
-			TransactionReference: "",
-			AvsResult:            sleet.AVSResponseUnknown,
-			CvvResult:            sleet.CVVResponseUnknown,
-		}, nil
+// Sale authorizes and captures a transaction in a single purchase
+func (client *RocketgateClient) Sale(request *sleet.SaleRequest) (*sleet.SaleResponse, error) {
+	return client.SaleWithContext(context.TODO(), request)
+}
+
+// SaleWithContext authorizes and captures a transaction in a single purchase
+// NOTE -- RocketGate's SDK does not support context, this method exists to fulfill the SaleWithContext interface
+func (client *RocketgateClient) SaleWithContext(ctx context.Context, request *sleet.SaleRequest) (*sleet.SaleResponse, error) {
+	gatewayResponse := response.NewGatewayResponse()
+	gatewayRequest := buildAuthRequest(client.merchantID, client.merchantPassword, client.merchantAccount, request)
+	gatewayService, err := client.newGatewayService(gatewayRequest)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationSale, err)
 	}
 
-	return &sleet.AuthorizationResponse{
-		Success:              true,
-		TransactionReference: gatewayResponse.Get(response.TRANSACT_ID),
-		Response:             gatewayResponse.Get(response.RESPONSE_CODE),
-	}, nil
+	success := gatewayService.PerformPurchase(gatewayRequest, gatewayResponse)
+	client.logResult(ctx, gatewayResponse)
+	if err := sdkError(gatewayResponse); err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationSale, err)
+	}
+	resp := translateAuthResponse(success, gatewayResponse)
+	return &sleet.SaleResponse{AuthorizationResponse: *resp}, nil
+}
+
+// Verify checks a card with an authorization which is voided once approved
+func (client *RocketgateClient) Verify(request *sleet.VerificationRequest) (*sleet.VerificationResponse, error) {
+	return client.VerifyWithContext(context.TODO(), request)
+}
+
+// VerifyWithContext checks a card with an authorization which is voided once approved
+// NOTE -- RocketGate's SDK does not support context, the authorization and void ignore it
+func (client *RocketgateClient) VerifyWithContext(ctx context.Context, request *sleet.VerificationRequest) (*sleet.VerificationResponse, error) {
+	response, err := sleet.VerifyByAuthorization(ctx, client, request)
+	return response, common.OperationError(gatewayName, sleet.OperationVerify, err)
 }
 
 // Capture an authorized transaction
@@ -93,25 +183,35 @@ func (client *RocketgateClient) Capture(request *sleet.CaptureRequest) (*sleet.C
 
 // CaptureWithContext an authorized transaction
 // NOTE -- RocketGate's SDK does not support context, this method exists to fulfill the ClientWithContext interface
-func (client *RocketgateClient) CaptureWithContext(_ context.Context, request *sleet.CaptureRequest) (*sleet.CaptureResponse, error) {
-	gatewayService := service.NewGatewayService()
+func (client *RocketgateClient) CaptureWithContext(ctx context.Context, request *sleet.CaptureRequest) (*sleet.CaptureResponse, error) {
 	gatewayResponse := response.NewGatewayResponse()
 	gatewayRequest := buildCaptureRequest(client.merchantID, client.merchantPassword, request)
+	gatewayService, err := client.newGatewayService(gatewayRequest)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationCapture, err)
+	}
 
-	gatewayService.SetTestMode(client.testMode)
-
-	if !gatewayService.PerformTicket(gatewayRequest, gatewayResponse) {
+	success := gatewayService.PerformTicket(gatewayRequest, gatewayResponse)
+	client.logResult(ctx, gatewayResponse)
+	if err := sdkError(gatewayResponse); err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationCapture, err)
+	}
+	if !success {
 		errCode := gatewayResponse.Get(response.REASON_CODE)
 		return &sleet.CaptureResponse{
 			Success:              false,
 			ErrorCode:            &errCode,
 			TransactionReference: "",
+			ResultType:           translateResultType(gatewayResponse),
+			Message:              gatewayResponse.Get(response.EXCEPTION),
 		}, nil
 	}
 
 	return &sleet.CaptureResponse{
 		Success:              true,
 		TransactionReference: gatewayResponse.Get(response.TRANSACT_ID),
+		ResultType:           sleet.ResultTypeSuccess,
+		Amount:               translateAmount(gatewayResponse, request.Amount),
 	}, nil
 }
 
@@ -122,24 +222,33 @@ func (client *RocketgateClient) Void(request *sleet.VoidRequest) (*sleet.VoidRes
 
 // VoidWithContext an authorized transaction
 // NOTE -- RocketGate's SDK does not support context, this method exists to fulfill the ClientWithContext interface
-func (client *RocketgateClient) VoidWithContext(_ context.Context, request *sleet.VoidRequest) (*sleet.VoidResponse, error) {
-	gatewayService := service.NewGatewayService()
+func (client *RocketgateClient) VoidWithContext(ctx context.Context, request *sleet.VoidRequest) (*sleet.VoidResponse, error) {
 	gatewayResponse := response.NewGatewayResponse()
 	gatewayRequest := buildVoidRequest(client.merchantID, client.merchantPassword, request)
+	gatewayService, err := client.newGatewayService(gatewayRequest)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationVoid, err)
+	}
 
-	gatewayService.SetTestMode(client.testMode)
-
-	if !gatewayService.PerformVoid(gatewayRequest, gatewayResponse) {
+	success := gatewayService.PerformVoid(gatewayRequest, gatewayResponse)
+	client.logResult(ctx, gatewayResponse)
+	if err := sdkError(gatewayResponse); err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationVoid, err)
+	}
+	if !success {
 		errCode := gatewayResponse.Get(response.REASON_CODE)
 		return &sleet.VoidResponse{
-			Success:   false,
-			ErrorCode: &errCode,
+			Success:    false,
+			ErrorCode:  &errCode,
+			ResultType: translateResultType(gatewayResponse),
+			Message:    gatewayResponse.Get(response.EXCEPTION),
 		}, nil
 	}
 
 	return &sleet.VoidResponse{
 		Success:              true,
 		TransactionReference: gatewayResponse.Get(response.TRANSACT_ID),
+		ResultType:           sleet.ResultTypeSuccess,
 	}, nil
 }
 
@@ -150,23 +259,76 @@ func (client *RocketgateClient) Refund(request *sleet.RefundRequest) (*sleet.Ref
 
 // RefundWithContext a captured transaction
 // NOTE -- RocketGate's SDK does not support context, this method exists to fulfill the ClientWithContext interface
-func (client *RocketgateClient) RefundWithContext(_ context.Context, request *sleet.RefundRequest) (*sleet.RefundResponse, error) {
-	gatewayService := service.NewGatewayService()
+func (client *RocketgateClient) RefundWithContext(ctx context.Context, request *sleet.RefundRequest) (*sleet.RefundResponse, error) {
 	gatewayResponse := response.NewGatewayResponse()
 	gatewayRequest := buildRefundRequest(client.merchantID, client.merchantPassword, request)
+	gatewayService, err := client.newGatewayService(gatewayRequest)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationRefund, err)
+	}
 
-	gatewayService.SetTestMode(client.testMode)
-
-	if !gatewayService.PerformCredit(gatewayRequest, gatewayResponse) {
+	success := gatewayService.PerformCredit(gatewayRequest, gatewayResponse)
+	client.logResult(ctx, gatewayResponse)
+	if err := sdkError(gatewayResponse); err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationRefund, err)
+	}
+	if !success {
 		errCode := gatewayResponse.Get(response.REASON_CODE)
 		return &sleet.RefundResponse{
-			Success:   false,
-			ErrorCode: &errCode,
+			Success:    false,
+			ErrorCode:  &errCode,
+			ResultType: translateResultType(gatewayResponse),
+			Message:    gatewayResponse.Get(response.EXCEPTION),
 		}, nil
 	}
 
 	return &sleet.RefundResponse{
 		Success:              true,
 		TransactionReference: gatewayResponse.Get(response.TRANSACT_ID),
+		ResultType:           sleet.ResultTypeSuccess,
+		Amount:               translateAmount(gatewayResponse, request.Amount),
 	}, nil
 }
+
+// QueryTransaction looks up a previous transaction
+func (client *RocketgateClient) QueryTransaction(request *sleet.TransactionQueryRequest) (*sleet.TransactionQueryResponse, error) {
+	return client.QueryTransactionWithContext(context.TODO(), request)
+}
+
+// QueryTransactionWithContext looks up a previous transaction
+// NOTE -- RocketGate's SDK does not support context, this method exists to fulfill the TransactionQuerierWithContext interface
+func (client *RocketgateClient) QueryTransactionWithContext(ctx context.Context, request *sleet.TransactionQueryRequest) (*sleet.TransactionQueryResponse, error) {
+	gatewayResponse := response.NewGatewayResponse()
+	gatewayRequest := buildLookupRequest(client.merchantID, client.merchantPassword, request)
+	gatewayService, err := client.newGatewayService(gatewayRequest)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationQueryTransaction, err)
+	}
+
+	// the lookup itself failing is distinguished from looking up a declined transaction by its codes
+	gatewayService.PerformLookup(gatewayRequest, gatewayResponse)
+	client.logResult(ctx, gatewayResponse)
+	if err := sdkError(gatewayResponse); err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationQueryTransaction, err)
+	}
+	responseCode := gatewayResponse.GetInt(response.RESPONSE_CODE)
+	reasonCode := gatewayResponse.GetInt(response.REASON_CODE)
+	if responseCode == response.RESPONSE_SYSTEM_ERROR ||
+		responseCode == response.RESPONSE_REQUEST_ERROR ||
+		reasonCode == response.REASON_NOMATCHING_XACT ||
+		reasonCode == response.REASON_INVALID_REFGUID {
+		errCode := gatewayResponse.Get(response.REASON_CODE)
+		return &sleet.TransactionQueryResponse{
+			Success:   false,
+			ErrorCode: &errCode,
+		}, nil
+	}
+
+	return translateLookupResponse(request.TransactionReference, gatewayResponse), nil
+}
+
+// logResult logs the response and reason codes RocketGate returned for a request
+func (client *RocketgateClient) logResult(ctx context.Context, gatewayResponse *response.GatewayResponse) {
+	common.LogResult(ctx, client.logger, gatewayName, gatewayResponse.Get(response.RESPONSE_CODE),
+		"reason_code", gatewayResponse.Get(response.REASON_CODE))
+}
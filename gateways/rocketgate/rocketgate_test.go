@@ -0,0 +1,41 @@
+//go:build unit
+// +build unit
+
+package rocketgate
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+	sleet_testing "github.com/BoltApp/sleet/testing"
+)
+
+// unreachable fails the test for any request sent
+type unreachable struct {
+	t *testing.T
+}
+
+func (u unreachable) RoundTrip(request *http.Request) (*http.Response, error) {
+	u.t.Errorf("expected no request to be sent, got one to %s", request.URL)
+	return nil, errors.New("unreachable")
+}
+
+func TestInvalidBaseURL(t *testing.T) {
+	for _, baseURL := range []string{"://no-scheme", "localhost:8080", "/servlet"} {
+		t.Run(baseURL, func(t *testing.T) {
+			client := NewWithHttpClient(common.Sandbox, "merchant", "password", nil, &http.Client{Transport: unreachable{t}}, sleet.WithBaseURL(baseURL))
+
+			resp, err := client.Authorize(sleet_testing.BaseAuthorizationRequest())
+			var sleetErr *sleet.Error
+			if resp != nil || !errors.As(err, &sleetErr) || sleetErr.Kind != sleet.ErrorKindValidation || sleetErr.Operation != sleet.OperationAuthorize {
+				t.Errorf("expected a validation error of the authorization, got %+v, %v", resp, err)
+			}
+			if _, err := client.Void(&sleet.VoidRequest{TransactionReference: "reference"}); !errors.As(err, &sleetErr) || sleetErr.Operation != sleet.OperationVoid {
+				t.Errorf("expected a validation error of the void, got %v", err)
+			}
+		})
+	}
+}
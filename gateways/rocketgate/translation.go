@@ -0,0 +1,165 @@
+package rocketgate
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/rocketgate/rocketgate-go-sdk/response"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+)
+
+// transactionTimeFormat is the gateway local time format used for transactionTime
+const transactionTimeFormat = "2006-01-02 15:04:05"
+
+var cardTypeMap = map[string]sleet.CreditCardNetwork{
+	"VISA": sleet.CreditCardNetworkVisa,
+	"MC":   sleet.CreditCardNetworkMastercard,
+	"AMEX": sleet.CreditCardNetworkAmex,
+	"DISC": sleet.CreditCardNetworkDiscover,
+	"JCB":  sleet.CreditCardNetworkJcb,
+}
+
+// translateLookupResponse converts a Lookup response into a sleet.TransactionQueryResponse.
+// RocketGate's Lookup returns the outcome of the original transaction only, so approved transactions
+// are reported as authorized and later tickets, voids or credits are not reflected.
+func translateLookupResponse(transactionReference string, gatewayResponse *response.GatewayResponse) *sleet.TransactionQueryResponse {
+	responseCode := gatewayResponse.Get(response.RESPONSE_CODE)
+	currency := gatewayResponse.Get(response.SETTLED_CURRENCY)
+
+	queryResponse := &sleet.TransactionQueryResponse{
+		Success:              true,
+		TransactionReference: transactionReference,
+		StateRaw:             responseCode,
+		Currency:             currency,
+		Last4:                gatewayResponse.Get(response.CARD_LAST_FOUR),
+		Network:              cardTypeMap[gatewayResponse.Get(response.CARD_TYPE)],
+	}
+
+	if responseCode == strconv.Itoa(response.RESPONSE_SUCCESS) {
+		queryResponse.State = sleet.TransactionStateAuthorized
+		if amount, err := common.AmountFromDecimalString(gatewayResponse.Get(response.SETTLED_AMOUNT), currency); err == nil {
+			queryResponse.AuthorizedAmount = amount
+		}
+	} else {
+		queryResponse.State = sleet.TransactionStateDeclined
+	}
+
+	if transactionTime, err := time.Parse(transactionTimeFormat, gatewayResponse.Get(response.TRANSACTION_TIME)); err == nil {
+		queryResponse.CreatedAt = &transactionTime
+	}
+	return queryResponse
+}
+
+// translateAmount returns the amount RocketGate reports as settled by a ticket or credit, falling back to the
+// requested amount when the response carries none
+func translateAmount(gatewayResponse *response.GatewayResponse, requested *sleet.Amount) *sleet.Amount {
+	currency := gatewayResponse.Get(response.SETTLED_CURRENCY)
+	if currency == "" {
+		return requested
+	}
+	amount, err := common.AmountFromDecimalString(gatewayResponse.Get(response.SETTLED_AMOUNT), currency)
+	if err != nil {
+		return requested
+	}
+	return &sleet.Amount{Amount: amount, Currency: currency}
+}
+
+// reasonResultTypeMap holds the reason codes whose result type differs from the one of their response code
+var reasonResultTypeMap = map[string]sleet.ResultType{
+	strconv.Itoa(response.REASON_NOMATCHING_XACT):  sleet.ResultTypeAPIError,
+	strconv.Itoa(response.REASON_CANNOT_VOID):      sleet.ResultTypeAPIError,
+	strconv.Itoa(response.REASON_CANNOT_CREDIT):    sleet.ResultTypeAPIError,
+	strconv.Itoa(response.REASON_CANNOT_TICKET):    sleet.ResultTypeAPIError,
+	strconv.Itoa(response.REASON_BANK_UNAVAILABLE): sleet.ResultTypeServerError,
+}
+
+var responseResultTypeMap = map[string]sleet.ResultType{
+	strconv.Itoa(response.RESPONSE_SUCCESS):       sleet.ResultTypeSuccess,
+	strconv.Itoa(response.RESPONSE_BANK_FAIL):     sleet.ResultTypePaymentError,
+	strconv.Itoa(response.RESPONSE_RISK_FAIL):     sleet.ResultTypePaymentError,
+	strconv.Itoa(response.RESPONSE_SYSTEM_ERROR):  sleet.ResultTypeServerError,
+	strconv.Itoa(response.RESPONSE_REQUEST_ERROR): sleet.ResultTypeAPIError,
+}
+
+// translateResultType converts a RocketGate response code, which already distinguishes bank, risk, system and request
+// failures, to a Sleet result type, refined by the reason code for failures RocketGate reports as the wrong class.
+func translateResultType(gatewayResponse *response.GatewayResponse) sleet.ResultType {
+	if resultType, ok := reasonResultTypeMap[gatewayResponse.Get(response.REASON_CODE)]; ok {
+		return resultType
+	}
+	if resultType, ok := responseResultTypeMap[gatewayResponse.Get(response.RESPONSE_CODE)]; ok {
+		return resultType
+	}
+	return sleet.ResultTypeUnknownError
+}
+
+var declineReasonMap = map[string]sleet.DeclineReason{
+	strconv.Itoa(response.REASON_DECLINED):                   sleet.DeclineReasonDoNotHonor,
+	strconv.Itoa(response.REASON_DECLINED_OVERLIMIT):         sleet.DeclineReasonLimitExceeded,
+	strconv.Itoa(response.REASON_DECLINED_CVV2):              sleet.DeclineReasonCVVFailure,
+	strconv.Itoa(response.REASON_DECLINED_EXPIRED):           sleet.DeclineReasonExpiredCard,
+	strconv.Itoa(response.REASON_DECLINED_CALL):              sleet.DeclineReasonCallIssuer,
+	strconv.Itoa(response.REASON_DECLINED_PICKUP):            sleet.DeclineReasonRestrictedCard,
+	strconv.Itoa(response.REASON_DECLINED_EXCESSIVEUSE):      sleet.DeclineReasonLimitExceeded,
+	strconv.Itoa(response.REASON_DECLINE_INVALID_CARDNO):     sleet.DeclineReasonInvalidCardNumber,
+	strconv.Itoa(response.REASON_DECLINE_INVALID_EXPIRATION): sleet.DeclineReasonInvalidExpirationDate,
+	strconv.Itoa(response.REASON_BANK_UNAVAILABLE):           sleet.DeclineReasonIssuerUnavailable,
+	strconv.Itoa(response.REASON_DECLINED_AVS):               sleet.DeclineReasonAVSFailure,
+	strconv.Itoa(response.REASON_DECLINED_RISK):              sleet.DeclineReasonSuspectedFraud,
+	strconv.Itoa(response.REASON_DECLINED_STOLEN):            sleet.DeclineReasonLostOrStolenCard,
+	strconv.Itoa(response.REASON_BANK_INVALID_TRANSACTION):   sleet.DeclineReasonTransactionNotAllowed,
+	strconv.Itoa(response.REASON_CVV2_REQUIRED):              sleet.DeclineReasonCVVFailure,
+	strconv.Itoa(response.REASON_RISK_FAIL):                  sleet.DeclineReasonSuspectedFraud,
+	strconv.Itoa(response.REASON_CUSTOMER_BLOCKED):           sleet.DeclineReasonSuspectedFraud,
+	strconv.Itoa(response.REASON_3DSECURE_SCA_REQUIRED):      sleet.DeclineReasonAuthenticationRequired,
+	strconv.Itoa(response.REASON_BANK_TIMEOUT_ERROR):         sleet.DeclineReasonTryAgainLater,
+}
+
+// sdkError returns the error of a request the SDK failed to send or whose response it failed to read or decode,
+// which the SDK reports as a response with the exception it caught rather than as an error. Responses of RocketGate
+// have no exception, nil is returned for them.
+func sdkError(gatewayResponse *response.GatewayResponse) *sleet.Error {
+	exception := gatewayResponse.Get(response.EXCEPTION)
+	if exception == "" {
+		return nil
+	}
+	err := errors.New(exception)
+	switch gatewayResponse.GetInt(response.REASON_CODE) {
+	case response.REASON_RESPONSE_READ_TIMEOUT:
+		return &sleet.Error{Kind: sleet.ErrorKindTimeout, Gateway: gatewayName, Retryable: true, Err: err}
+	case response.REASON_UNABLE_TO_CONNECT, response.REASON_REQUEST_XMIT_ERROR, response.REASON_RESPONSE_READ_ERROR:
+		return &sleet.Error{Kind: sleet.ErrorKindNetwork, Gateway: gatewayName, Retryable: true, Err: err}
+	case response.REASON_BUGCHECK: // the response status wasn't 200
+		return &sleet.Error{Kind: sleet.ErrorKindPSPAPI, Gateway: gatewayName, Err: err}
+	case response.REASON_XML_ERROR:
+		return common.DecodeError(gatewayName, err)
+	}
+	return nil
+}
+
+// translateAuthResponse translates the response to an auth only or purchase request
+func translateAuthResponse(success bool, gatewayResponse *response.GatewayResponse) *sleet.AuthorizationResponse {
+	if !success {
+		resultType := translateResultType(gatewayResponse)
+		return &sleet.AuthorizationResponse{
+			Success:              false,
+			Response:             gatewayResponse.Get(response.RESPONSE_CODE),
+			ErrorCode:            gatewayResponse.Get(response.REASON_CODE),
+			ResultType:           resultType,
+			DeclineReason:        common.TranslateDeclineReason(declineReasonMap, gatewayResponse.Get(response.REASON_CODE), resultType),
+			TransactionReference: "",
+			AvsResult:            sleet.AVSResponseUnknown,
+			CvvResult:            sleet.CVVResponseUnknown,
+		}
+	}
+
+	return &sleet.AuthorizationResponse{
+		Success:              true,
+		TransactionReference: gatewayResponse.Get(response.TRANSACT_ID),
+		Response:             gatewayResponse.Get(response.RESPONSE_CODE),
+		ResultType:           sleet.ResultTypeSuccess,
+	}
+}
@@ -0,0 +1,127 @@
+//go:build unit
+// +build unit
+
+package rocketgate
+
+import (
+	"testing"
+
+	"github.com/rocketgate/rocketgate-go-sdk/response"
+
+	"github.com/BoltApp/sleet"
+)
+
+func TestTranslateResultType(t *testing.T) {
+	cases := []struct {
+		label        string
+		responseCode int
+		reasonCode   int
+		want         sleet.ResultType
+	}{
+		{"Approved", response.RESPONSE_SUCCESS, response.REASON_SUCCESS, sleet.ResultTypeSuccess},
+		{"Declined", response.RESPONSE_BANK_FAIL, response.REASON_DECLINED, sleet.ResultTypePaymentError},
+		{"Expired Card", response.RESPONSE_BANK_FAIL, response.REASON_DECLINED_EXPIRED, sleet.ResultTypePaymentError},
+		{"Bank Unavailable", response.RESPONSE_BANK_FAIL, response.REASON_BANK_UNAVAILABLE, sleet.ResultTypeServerError},
+		{"Cannot Void", response.RESPONSE_BANK_FAIL, response.REASON_CANNOT_VOID, sleet.ResultTypeAPIError},
+		{"Risk Declined", response.RESPONSE_RISK_FAIL, response.REASON_RISK_FAIL, sleet.ResultTypePaymentError},
+		{"Connection Failed", response.RESPONSE_SYSTEM_ERROR, response.REASON_UNABLE_TO_CONNECT, sleet.ResultTypeServerError},
+		{"Invalid Merchant", response.RESPONSE_REQUEST_ERROR, response.REASON_INVALID_MERCHANT_ID, sleet.ResultTypeAPIError},
+	}
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			gatewayResponse := response.NewGatewayResponse()
+			gatewayResponse.SetResults(c.responseCode, c.reasonCode)
+			if got := translateResultType(gatewayResponse); got != c.want {
+				t.Errorf("expected %s, got %s", c.want, got)
+			}
+		})
+	}
+
+	t.Run("Missing Response", func(t *testing.T) {
+		if got := translateResultType(response.NewGatewayResponse()); got != sleet.ResultTypeUnknownError {
+			t.Errorf("expected %s, got %s", sleet.ResultTypeUnknownError, got)
+		}
+	})
+}
+
+func TestTranslateAuthResponseDeclineReason(t *testing.T) {
+	cases := []struct {
+		label        string
+		responseCode int
+		reasonCode   int
+		want         sleet.DeclineReason
+	}{
+		{"Declined", response.RESPONSE_BANK_FAIL, response.REASON_DECLINED, sleet.DeclineReasonDoNotHonor},
+		{"Expired Card", response.RESPONSE_BANK_FAIL, response.REASON_DECLINED_EXPIRED, sleet.DeclineReasonExpiredCard},
+		{"Bank Unavailable", response.RESPONSE_BANK_FAIL, response.REASON_BANK_UNAVAILABLE, sleet.DeclineReasonIssuerUnavailable},
+		{"Risk Declined", response.RESPONSE_RISK_FAIL, response.REASON_RISK_FAIL, sleet.DeclineReasonSuspectedFraud},
+		{"Previous Hard Decline", response.RESPONSE_BANK_FAIL, response.REASON_PREVIOUS_HARD_DECLINE, sleet.DeclineReasonUnknown},
+		{"Invalid Merchant", response.RESPONSE_REQUEST_ERROR, response.REASON_INVALID_MERCHANT_ID, ""},
+	}
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			gatewayResponse := response.NewGatewayResponse()
+			gatewayResponse.SetResults(c.responseCode, c.reasonCode)
+			if got := translateAuthResponse(false, gatewayResponse).DeclineReason; got != c.want {
+				t.Errorf("expected %q, got %q", c.want, got)
+			}
+		})
+	}
+}
+
+func TestTranslateAmount(t *testing.T) {
+	requested := &sleet.Amount{Amount: 1000, Currency: "USD"}
+	cases := []struct {
+		label    string
+		amount   string
+		currency string
+		want     *sleet.Amount
+	}{
+		{"Settled Amount", "5.25", "USD", &sleet.Amount{Amount: 525, Currency: "USD"}},
+		{"No Settled Currency", "5.25", "", requested},
+		{"Invalid Settled Amount", "abc", "USD", requested},
+	}
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			gatewayResponse := response.NewGatewayResponse()
+			gatewayResponse.Set(response.SETTLED_AMOUNT, c.amount)
+			gatewayResponse.Set(response.SETTLED_CURRENCY, c.currency)
+			if got := translateAmount(gatewayResponse, requested); *got != *c.want {
+				t.Errorf("expected %v, got %v", *c.want, *got)
+			}
+		})
+	}
+}
+
+func TestSDKError(t *testing.T) {
+	cases := []struct {
+		label      string
+		reasonCode int
+		exception  string
+		want       sleet.ErrorKind
+	}{
+		{"Read Timeout", response.REASON_RESPONSE_READ_TIMEOUT, "i/o timeout", sleet.ErrorKindTimeout},
+		{"Transmit Error", response.REASON_REQUEST_XMIT_ERROR, "connection refused", sleet.ErrorKindNetwork},
+		{"HTTP Error", response.REASON_BUGCHECK, "HTTP error code 503", sleet.ErrorKindPSPAPI},
+		{"Invalid XML", response.REASON_XML_ERROR, "invalid xml: unexpected EOF", sleet.ErrorKindDecode},
+	}
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			gatewayResponse := response.NewGatewayResponse()
+			gatewayResponse.SetResults(response.RESPONSE_SYSTEM_ERROR, c.reasonCode)
+			gatewayResponse.Set(response.EXCEPTION, c.exception)
+			got := sdkError(gatewayResponse)
+			if got == nil || got.Kind != c.want || got.Err.Error() != c.exception {
+				t.Errorf("expected a %s error with the exception, got %v", c.want, got)
+			}
+		})
+	}
+
+	t.Run("RocketGate Response", func(t *testing.T) {
+		gatewayResponse := response.NewGatewayResponse()
+		gatewayResponse.SetResults(response.RESPONSE_SYSTEM_ERROR, response.REASON_UNABLE_TO_CONNECT)
+		if got := sdkError(gatewayResponse); got != nil {
+			t.Errorf("expected no error for a response without an exception, got %v", got)
+		}
+	})
+}
@@ -0,0 +1,18 @@
+package simulator
+
+import (
+	"net/http"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/registry"
+)
+
+func init() {
+	registry.Register(gatewayName, newRegistryClient)
+}
+
+// newRegistryClient builds a SimulatorClient from a registry config. The simulator has no credentials and sends no
+// requests, so the config and http client are unused.
+func newRegistryClient(_ *registry.Config, _ *http.Client, options ...sleet.ClientOption) (sleet.ClientWithContext, error) {
+	return NewClient(options...), nil
+}
@@ -0,0 +1,171 @@
+package simulator
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+)
+
+// Card numbers which trigger an outcome when authorized, like the test cards of a PsP sandbox. Any other card number
+// is approved, with AVS and CVV results taken from the request.
+const (
+	CardDeclined          = "4000000000000002" // declined by the issuer with do not honor
+	CardInsufficientFunds = "4000000000009995" // declined for insufficient funds
+	CardExpired           = "4000000000000069" // declined as an expired card
+	CardLostOrStolen      = "4000000000009987" // declined as a lost or stolen card
+	CardCVVNoMatch        = "4000000000000127" // declined as the CVV does not match
+	CardAVSNoMatch        = "4000000000000010" // approved although neither the ZIP code nor the street address match
+	CardIssuerUnavailable = "4000000000000119" // declined as the issuer could not be reached, the payment can be retried
+)
+
+// Amounts in minor units which trigger an outcome for any card. They apply to every operation with an amount.
+const (
+	AmountDeclined        int64 = 2005 // authorizations are declined with do not honor
+	AmountPartialApproval int64 = 7500 // authorizations allowing a partial approval are approved for half the amount
+	AmountServerError     int64 = 5013 // the operation fails with a 500 from the PsP and is not recorded
+	AmountTimeout         int64 = 5041 // the operation is recorded but times out before its response is read
+)
+
+// Raw codes of the simulated PsP. Declines use ISO 8583 response codes, rejected requests are named after their cause.
+const (
+	codeApproved           = "00"
+	codeDoNotHonor         = "05"
+	codeInsufficientFunds  = "51"
+	codeExpiredCard        = "54"
+	codeLostOrStolen       = "43"
+	codeCVVNoMatch         = "N7"
+	codeIssuerUnavailable  = "91"
+	codeNotFound           = "transaction_not_found"
+	codeInvalidState       = "invalid_transaction_state"
+	codeAmountExceeded     = "amount_exceeded"
+	codeCurrencyMismatch   = "currency_mismatch"
+	codeAuthorizedMismatch = "authorized_amount_mismatch"
+)
+
+// Raw AVS and CVV results, as Visa reports them
+const (
+	avsMatch      = "Y"
+	avsNoMatch    = "N"
+	avsNotChecked = "U"
+	cvvMatch      = "M"
+	cvvNoMatch    = "N"
+	cvvNotChecked = "P"
+)
+
+var declineReasonMap = map[string]sleet.DeclineReason{
+	codeDoNotHonor:        sleet.DeclineReasonDoNotHonor,
+	codeInsufficientFunds: sleet.DeclineReasonInsufficientFunds,
+	codeExpiredCard:       sleet.DeclineReasonExpiredCard,
+	codeLostOrStolen:      sleet.DeclineReasonLostOrStolenCard,
+	codeCVVNoMatch:        sleet.DeclineReasonCVVFailure,
+	codeIssuerUnavailable: sleet.DeclineReasonIssuerUnavailable,
+}
+
+var cardDeclines = map[string]string{
+	CardDeclined:          codeDoNotHonor,
+	CardInsufficientFunds: codeInsufficientFunds,
+	CardExpired:           codeExpiredCard,
+	CardLostOrStolen:      codeLostOrStolen,
+	CardCVVNoMatch:        codeCVVNoMatch,
+	CardIssuerUnavailable: codeIssuerUnavailable,
+}
+
+// authorizationOutcome is the simulated issuer's answer to an authorization
+type authorizationOutcome struct {
+	code           string
+	approvedAmount int64
+	avsResult      sleet.AVSResponse
+	avsResultRaw   string
+	cvvResult      sleet.CVVResponse
+	cvvResultRaw   string
+}
+
+// authorize decides the outcome of an authorization from its card number and amount
+func authorize(request *sleet.AuthorizationRequest) authorizationOutcome {
+	outcome := authorizationOutcome{code: codeApproved, approvedAmount: request.Amount.Amount}
+	outcome.avsResult, outcome.avsResultRaw = checkAddress(request)
+	outcome.cvvResult, outcome.cvvResultRaw = checkCVV(request)
+
+	number := cardNumber(request)
+	if code, ok := cardDeclines[number]; ok {
+		outcome.code = code
+	} else if request.Amount.Amount == AmountDeclined {
+		outcome.code = codeDoNotHonor
+	}
+	switch {
+	case number == CardCVVNoMatch:
+		outcome.cvvResult, outcome.cvvResultRaw = sleet.CVVResponseNoMatch, cvvNoMatch
+	case number == CardAVSNoMatch:
+		outcome.avsResult, outcome.avsResultRaw = sleet.AVSResponseNoMatch, avsNoMatch
+	case request.Amount.Amount == AmountPartialApproval && request.AllowPartialAuth && outcome.code == codeApproved:
+		outcome.approvedAmount = request.Amount.Amount / 2
+	}
+	return outcome
+}
+
+// resultType classifies the code of an authorization, only the issuer being unavailable can be retried
+func resultType(code string) sleet.ResultType {
+	switch code {
+	case codeApproved:
+		return sleet.ResultTypeSuccess
+	case codeIssuerUnavailable:
+		return sleet.ResultTypeServerError
+	}
+	return sleet.ResultTypePaymentError
+}
+
+// checkAddress matches the billing address when one is given, the simulated issuer knows every address
+func checkAddress(request *sleet.AuthorizationRequest) (sleet.AVSResponse, string) {
+	if request.BillingAddress == nil || request.BillingAddress.PostalCode == nil {
+		return sleet.AVSResponseSkipped, avsNotChecked
+	}
+	return sleet.AVSResponseMatch, avsMatch
+}
+
+// checkCVV matches the CVV when one is given
+func checkCVV(request *sleet.AuthorizationRequest) (sleet.CVVResponse, string) {
+	if request.CreditCard == nil || request.CreditCard.CVV == "" {
+		return sleet.CVVResponseSkipped, cvvNotChecked
+	}
+	return sleet.CVVResponseMatch, cvvMatch
+}
+
+// amountError returns the error triggered by a magic amount, and whether the operation is still recorded
+func amountError(amount int64) (*sleet.Error, bool) {
+	switch amount {
+	case AmountServerError:
+		return common.ResponseError(gatewayName, http.StatusInternalServerError, errors.New("simulator: internal server error")), false
+	case AmountTimeout:
+		return common.TransportError(gatewayName, context.DeadlineExceeded), true
+	}
+	return nil, false
+}
+
+func cardNumber(request *sleet.AuthorizationRequest) string {
+	if request.CreditCard == nil {
+		return ""
+	}
+	return strings.ReplaceAll(request.CreditCard.Number, " ", "")
+}
+
+// cardNetwork returns the network of the card, from the request or else from the card number's prefix
+func cardNetwork(card *sleet.CreditCard) sleet.CreditCardNetwork {
+	if card.Network != sleet.CreditCardNetworkUnknown {
+		return card.Network
+	}
+	switch {
+	case strings.HasPrefix(card.Number, "4"):
+		return sleet.CreditCardNetworkVisa
+	case strings.HasPrefix(card.Number, "5"), strings.HasPrefix(card.Number, "2"):
+		return sleet.CreditCardNetworkMastercard
+	case strings.HasPrefix(card.Number, "34"), strings.HasPrefix(card.Number, "37"):
+		return sleet.CreditCardNetworkAmex
+	case strings.HasPrefix(card.Number, "6"):
+		return sleet.CreditCardNetworkDiscover
+	}
+	return sleet.CreditCardNetworkUnknown
+}
@@ -0,0 +1,465 @@
+// Package simulator is an in-memory PsP for testing services built on sleet without mocking sleet.Client by hand.
+// Transactions move through the same states as at a real PsP, and magic card numbers and amounts trigger declines,
+// AVS and CVV outcomes, server errors and timeouts, like the test cards of a PsP sandbox.
+package simulator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+)
+
+const gatewayName = "simulator"
+
+var (
+	// assert client interface
+	_ sleet.ClientWithContext              = &SimulatorClient{}
+	_ sleet.TransactionQuerierWithContext  = &SimulatorClient{}
+	_ sleet.TransactionFinderWithContext   = &SimulatorClient{}
+	_ sleet.TimeoutReverserWithContext     = &SimulatorClient{}
+	_ sleet.SaleWithContext                = &SimulatorClient{}
+	_ sleet.VerifierWithContext            = &SimulatorClient{}
+	_ sleet.IncrementAuthorizerWithContext = &SimulatorClient{}
+	_ sleet.CapabilitiesReporter           = &SimulatorClient{}
+)
+
+// SimulatorClient holds its transactions in memory and is safe for concurrent use. TransactionReferences are
+// numbered in the order operations are made, so a client replaying the same operations returns the same references.
+type SimulatorClient struct {
+	mu           sync.Mutex
+	sequence     int
+	transactions map[string]*transaction
+	history      []*transaction // in the order they were authorized
+	now          func() time.Time
+	logger       sleet.Logger
+}
+
+// NewClient creates a simulator client without any transactions
+func NewClient(options ...sleet.ClientOption) *SimulatorClient {
+	clientOptions := sleet.NewClientOptions(options...)
+	return &SimulatorClient{
+		transactions: make(map[string]*transaction),
+		now:          time.Now,
+		logger:       clientOptions.Logger,
+	}
+}
+
+// Capabilities describes the optional operations supported by the simulator
+func (client *SimulatorClient) Capabilities() sleet.Capabilities {
+	return sleet.Capabilities{
+		ContextSupport:           true,
+		Sale:                     true,
+		TransactionQuery:         true,
+		FindByReference:          true,
+		TimeoutReversal:          true,
+		IncrementalAuthorization: true,
+		PartialAuthorization:     true,
+		PartialCapture:           true,
+		PartialRefund:            true,
+	}
+}
+
+// Authorize a transaction. This transaction must be captured to receive funds
+func (client *SimulatorClient) Authorize(request *sleet.AuthorizationRequest) (*sleet.AuthorizationResponse, error) {
+	return client.AuthorizeWithContext(context.TODO(), request)
+}
+
+// AuthorizeWithContext authorizes a transaction, which is declined for the magic card numbers and amounts
+func (client *SimulatorClient) AuthorizeWithContext(ctx context.Context, request *sleet.AuthorizationRequest) (*sleet.AuthorizationResponse, error) {
+	response, err := client.authorize(ctx, request, false)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationAuthorize, err)
+	}
+	return response, nil
+}
+
+// Sale authorizes a transaction and captures it in a single call
+func (client *SimulatorClient) Sale(request *sleet.SaleRequest) (*sleet.SaleResponse, error) {
+	return client.SaleWithContext(context.TODO(), request)
+}
+
+// SaleWithContext authorizes a transaction and captures the approved amount in a single call
+func (client *SimulatorClient) SaleWithContext(ctx context.Context, request *sleet.SaleRequest) (*sleet.SaleResponse, error) {
+	response, err := client.authorize(ctx, request, true)
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationSale, err)
+	}
+	return &sleet.SaleResponse{AuthorizationResponse: *response}, nil
+}
+
+// Verify authorizes the card and voids the authorization once approved
+func (client *SimulatorClient) Verify(request *sleet.VerificationRequest) (*sleet.VerificationResponse, error) {
+	return client.VerifyWithContext(context.TODO(), request)
+}
+
+// VerifyWithContext authorizes the card for a nominal amount and voids the authorization once approved
+func (client *SimulatorClient) VerifyWithContext(ctx context.Context, request *sleet.VerificationRequest) (*sleet.VerificationResponse, error) {
+	response, err := sleet.VerifyByAuthorization(ctx, client, request)
+	return response, common.OperationError(gatewayName, sleet.OperationVerify, err)
+}
+
+// authorize records the authorization, approved or declined, and captures it for a sale
+func (client *SimulatorClient) authorize(ctx context.Context, request *sleet.AuthorizationRequest, capture bool) (*sleet.AuthorizationResponse, error) {
+	if err := validateAuthorization(request); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, common.TransportError(gatewayName, err)
+	}
+	amountErr, recorded := amountError(request.Amount.Amount)
+	if amountErr != nil && !recorded {
+		return nil, amountErr
+	}
+
+	outcome := authorize(request)
+	client.mu.Lock()
+	now := client.now()
+	t := &transaction{
+		reference:                  client.nextReference(),
+		clientTransactionReference: common.SafeStr(request.ClientTransactionReference),
+		merchantOrderReference:     request.MerchantOrderReference,
+		state:                      sleet.TransactionStateDeclined,
+		code:                       outcome.code,
+		currency:                   strings.ToUpper(request.Amount.Currency),
+		createdAt:                  now,
+		updatedAt:                  now,
+	}
+	if card := request.CreditCard; card != nil {
+		t.network = cardNetwork(card)
+		if len(card.Number) >= 4 {
+			t.last4 = card.Number[len(card.Number)-4:]
+		}
+	}
+	if outcome.code == codeApproved {
+		t.state = sleet.TransactionStateAuthorized
+		t.authorizedAmount = outcome.approvedAmount
+		if capture {
+			t.capture(nil, now)
+		}
+	}
+	client.transactions[t.reference] = t
+	client.history = append(client.history, t)
+	client.mu.Unlock()
+
+	common.LogResult(ctx, client.logger, gatewayName, outcome.code)
+	if amountErr != nil {
+		return nil, amountErr
+	}
+
+	response := &sleet.AuthorizationResponse{
+		Success:              outcome.code == codeApproved,
+		TransactionReference: t.reference,
+		AvsResult:            outcome.avsResult,
+		CvvResult:            outcome.cvvResult,
+		AvsResultRaw:         outcome.avsResultRaw,
+		CvvResultRaw:         outcome.cvvResultRaw,
+		Response:             outcome.code,
+		ResultType:           resultType(outcome.code),
+		StatusCode:           http.StatusOK,
+	}
+	if response.Success {
+		response.ApprovedAmount = &sleet.Amount{Amount: outcome.approvedAmount, Currency: t.currency}
+	} else {
+		response.ErrorCode = outcome.code
+		response.DeclineReason = common.TranslateDeclineReason(declineReasonMap, outcome.code, response.ResultType)
+	}
+	return response, nil
+}
+
+// Capture an authorized transaction by reference, for all of it if the request has no amount
+func (client *SimulatorClient) Capture(request *sleet.CaptureRequest) (*sleet.CaptureResponse, error) {
+	return client.CaptureWithContext(context.TODO(), request)
+}
+
+// CaptureWithContext captures an authorized transaction by reference. An authorization is captured once, for at most
+// its amount.
+func (client *SimulatorClient) CaptureWithContext(ctx context.Context, request *sleet.CaptureRequest) (*sleet.CaptureResponse, error) {
+	var captured int64
+	var currency string
+	rejected, err := client.simulate(ctx, sleet.OperationCapture, request.TransactionReference, request.Amount, func(t *transaction, now time.Time) *rejection {
+		var rejected *rejection
+		captured, rejected = t.capture(request.Amount, now)
+		currency = t.currency
+		return rejected
+	})
+	if err != nil {
+		return nil, err
+	}
+	if rejected != nil {
+		return &sleet.CaptureResponse{
+			Success:              false,
+			TransactionReference: request.TransactionReference,
+			ErrorCode:            common.SPtr(rejected.code),
+			Message:              rejected.message,
+			ResultType:           common.ResultTypeFromHTTPStatus(rejected.statusCode),
+			StatusCode:           rejected.statusCode,
+		}, nil
+	}
+	return &sleet.CaptureResponse{
+		Success:              true,
+		TransactionReference: request.TransactionReference,
+		ResultType:           sleet.ResultTypeSuccess,
+		Amount:               &sleet.Amount{Amount: captured, Currency: currency},
+		StatusCode:           http.StatusOK,
+	}, nil
+}
+
+// Void an authorized transaction by reference
+func (client *SimulatorClient) Void(request *sleet.VoidRequest) (*sleet.VoidResponse, error) {
+	return client.VoidWithContext(context.TODO(), request)
+}
+
+// VoidWithContext voids an authorized transaction by reference. Captured and already voided transactions are
+// rejected.
+func (client *SimulatorClient) VoidWithContext(ctx context.Context, request *sleet.VoidRequest) (*sleet.VoidResponse, error) {
+	rejected, err := client.simulate(ctx, sleet.OperationVoid, request.TransactionReference, nil, func(t *transaction, now time.Time) *rejection {
+		return t.void(now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if rejected != nil {
+		return &sleet.VoidResponse{
+			Success:              false,
+			TransactionReference: request.TransactionReference,
+			ErrorCode:            common.SPtr(rejected.code),
+			Message:              rejected.message,
+			ResultType:           common.ResultTypeFromHTTPStatus(rejected.statusCode),
+			StatusCode:           rejected.statusCode,
+		}, nil
+	}
+	return &sleet.VoidResponse{
+		Success:              true,
+		TransactionReference: request.TransactionReference,
+		ResultType:           sleet.ResultTypeSuccess,
+		StatusCode:           http.StatusOK,
+	}, nil
+}
+
+// Refund a captured transaction by reference, for all that is left of it if the request has no amount
+func (client *SimulatorClient) Refund(request *sleet.RefundRequest) (*sleet.RefundResponse, error) {
+	return client.RefundWithContext(context.TODO(), request)
+}
+
+// RefundWithContext refunds a captured transaction by reference. A capture can be refunded several times, up to its
+// amount, and each refund has its own TransactionReference.
+func (client *SimulatorClient) RefundWithContext(ctx context.Context, request *sleet.RefundRequest) (*sleet.RefundResponse, error) {
+	var refunded int64
+	var currency, reference string
+	rejected, err := client.simulate(ctx, sleet.OperationRefund, request.TransactionReference, request.Amount, func(t *transaction, now time.Time) *rejection {
+		var rejected *rejection
+		refunded, rejected = t.refund(request.Amount, now)
+		if rejected == nil {
+			currency, reference = t.currency, client.nextReference()
+		}
+		return rejected
+	})
+	if err != nil {
+		return nil, err
+	}
+	if rejected != nil {
+		return &sleet.RefundResponse{
+			Success:              false,
+			TransactionReference: request.TransactionReference,
+			ErrorCode:            common.SPtr(rejected.code),
+			Message:              rejected.message,
+			ResultType:           common.ResultTypeFromHTTPStatus(rejected.statusCode),
+			StatusCode:           rejected.statusCode,
+		}, nil
+	}
+	return &sleet.RefundResponse{
+		Success:              true,
+		TransactionReference: reference,
+		ResultType:           sleet.ResultTypeSuccess,
+		Amount:               &sleet.Amount{Amount: refunded, Currency: currency},
+		StatusCode:           http.StatusOK,
+	}, nil
+}
+
+// IncrementAuthorization raises the amount of an authorized transaction
+func (client *SimulatorClient) IncrementAuthorization(request *sleet.IncrementAuthorizationRequest) (*sleet.IncrementAuthorizationResponse, error) {
+	return client.IncrementAuthorizationWithContext(context.TODO(), request)
+}
+
+// IncrementAuthorizationWithContext raises the amount of an authorized transaction which has not been captured. The
+// request must have the AuthorizedAmount, which is checked against the transaction.
+func (client *SimulatorClient) IncrementAuthorizationWithContext(ctx context.Context, request *sleet.IncrementAuthorizationRequest) (*sleet.IncrementAuthorizationResponse, error) {
+	if request.AuthorizedAmount == 0 {
+		return nil, common.OperationError(gatewayName, sleet.OperationIncrementAuthorization, sleet.ErrAuthorizedAmountRequired)
+	}
+	var authorized sleet.Amount
+	var reference string
+	rejected, err := client.simulate(ctx, sleet.OperationIncrementAuthorization, request.TransactionReference, &request.Amount, func(t *transaction, now time.Time) *rejection {
+		rejected := t.increment(request, now)
+		if rejected == nil {
+			authorized, reference = sleet.Amount{Amount: t.authorizedAmount, Currency: t.currency}, client.nextReference()
+		}
+		return rejected
+	})
+	if err != nil {
+		return nil, err
+	}
+	if rejected != nil {
+		return &sleet.IncrementAuthorizationResponse{
+			Success:    false,
+			ErrorCode:  common.SPtr(rejected.code),
+			Message:    rejected.message,
+			StatusCode: rejected.statusCode,
+		}, nil
+	}
+	return &sleet.IncrementAuthorizationResponse{
+		Success:              true,
+		TransactionReference: reference,
+		AuthorizedAmount:     authorized,
+	}, nil
+}
+
+// QueryTransaction looks up the current state of a transaction by reference
+func (client *SimulatorClient) QueryTransaction(request *sleet.TransactionQueryRequest) (*sleet.TransactionQueryResponse, error) {
+	return client.QueryTransactionWithContext(context.TODO(), request)
+}
+
+// QueryTransactionWithContext looks up the current state of a transaction by the reference of its authorization
+func (client *SimulatorClient) QueryTransactionWithContext(ctx context.Context, request *sleet.TransactionQueryRequest) (*sleet.TransactionQueryResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationQueryTransaction, common.TransportError(gatewayName, err))
+	}
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	t, ok := client.transactions[request.TransactionReference]
+	if !ok {
+		return &sleet.TransactionQueryResponse{
+			Success:              false,
+			TransactionReference: request.TransactionReference,
+			ErrorCode:            common.SPtr(codeNotFound),
+		}, nil
+	}
+	return t.query(), nil
+}
+
+// FindByClientReference returns the transactions authorized with the ClientTransactionReference, or else the
+// MerchantOrderReference
+func (client *SimulatorClient) FindByClientReference(request *sleet.FindByClientReferenceRequest) (*sleet.FindByClientReferenceResponse, error) {
+	return client.FindByClientReferenceWithContext(context.TODO(), request)
+}
+
+// FindByClientReferenceWithContext returns the transactions authorized with the ClientTransactionReference, or else
+// the MerchantOrderReference, most recent first
+func (client *SimulatorClient) FindByClientReferenceWithContext(ctx context.Context, request *sleet.FindByClientReferenceRequest) (*sleet.FindByClientReferenceResponse, error) {
+	if request.ClientTransactionReference == nil && request.MerchantOrderReference == nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationFindByClientReference, sleet.ErrClientReferenceRequired)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationFindByClientReference, common.TransportError(gatewayName, err))
+	}
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	transactions := make([]sleet.TransactionQueryResponse, 0)
+	for i := len(client.history) - 1; i >= 0; i-- {
+		if t := client.history[i]; matchesReference(t, request.ClientTransactionReference, request.MerchantOrderReference) {
+			transactions = append(transactions, *t.query())
+		}
+	}
+	return &sleet.FindByClientReferenceResponse{
+		Success:      true,
+		Transactions: transactions,
+	}, nil
+}
+
+// ReverseTimedOutAuthorization voids the authorizations made with the ClientTransactionReference
+func (client *SimulatorClient) ReverseTimedOutAuthorization(request *sleet.TimeoutReversalRequest) (*sleet.TimeoutReversalResponse, error) {
+	return client.ReverseTimedOutAuthorizationWithContext(context.TODO(), request)
+}
+
+// ReverseTimedOutAuthorizationWithContext voids the authorizations made with the ClientTransactionReference which
+// have not been captured. Like a PsP, it succeeds when there is nothing to reverse.
+func (client *SimulatorClient) ReverseTimedOutAuthorizationWithContext(ctx context.Context, request *sleet.TimeoutReversalRequest) (*sleet.TimeoutReversalResponse, error) {
+	if request.ClientTransactionReference == nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationReverseTimedOutAuthorization, sleet.ErrClientReferenceRequired)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationReverseTimedOutAuthorization, common.TransportError(gatewayName, err))
+	}
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	response := &sleet.TimeoutReversalResponse{Success: true}
+	now := client.now()
+	for _, t := range client.history {
+		if matchesReference(t, request.ClientTransactionReference, nil) && t.void(now) == nil {
+			response.TransactionReference = t.reference
+		}
+	}
+	common.LogResult(ctx, client.logger, gatewayName, codeApproved)
+	return response, nil
+}
+
+// simulate applies an operation to the transaction with the reference. An amount which isn't positive is invalid, and
+// magic amounts fail the operation before it is applied, or after it is for AmountTimeout. A rejection is the simulated
+// PsP's answer, returned without an error.
+func (client *SimulatorClient) simulate(ctx context.Context, operation string, reference string, amount *sleet.Amount, apply func(t *transaction, now time.Time) *rejection) (*rejection, error) {
+	if amount != nil && amount.Amount <= 0 {
+		return nil, common.OperationError(gatewayName, operation, errors.New("simulator: the amount must be positive"))
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, common.OperationError(gatewayName, operation, common.TransportError(gatewayName, err))
+	}
+	var amountErr *sleet.Error
+	if amount != nil {
+		var recorded bool
+		if amountErr, recorded = amountError(amount.Amount); amountErr != nil && !recorded {
+			return nil, common.OperationError(gatewayName, operation, amountErr)
+		}
+	}
+
+	client.mu.Lock()
+	rejected := notFound(reference)
+	if t, ok := client.transactions[reference]; ok {
+		rejected = apply(t, client.now())
+	}
+	client.mu.Unlock()
+
+	code := codeApproved
+	if rejected != nil {
+		code = rejected.code
+	}
+	common.LogResult(ctx, client.logger, gatewayName, code)
+	if amountErr != nil {
+		return nil, common.OperationError(gatewayName, operation, amountErr)
+	}
+	return rejected, nil
+}
+
+// nextReference numbers the next TransactionReference, the caller must hold the lock
+func (client *SimulatorClient) nextReference() string {
+	client.sequence++
+	return fmt.Sprintf("sim_%06d", client.sequence)
+}
+
+func validateAuthorization(request *sleet.AuthorizationRequest) error {
+	switch {
+	case request.CreditCard == nil && request.StoredPaymentMethod == nil:
+		return errors.New("simulator: a credit card or stored payment method is required")
+	case request.Amount.Amount <= 0:
+		return errors.New("simulator: the amount must be positive")
+	case request.Amount.Currency == "":
+		return errors.New("simulator: the currency is required")
+	}
+	return nil
+}
+
+func matchesReference(t *transaction, clientTransactionReference *string, merchantOrderReference *string) bool {
+	if clientTransactionReference != nil {
+		return t.clientTransactionReference == *clientTransactionReference
+	}
+	return merchantOrderReference != nil && t.merchantOrderReference == *merchantOrderReference
+}
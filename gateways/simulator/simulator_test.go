@@ -0,0 +1,517 @@
+package simulator
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+	sleet_t "github.com/BoltApp/sleet/testing"
+)
+
+func authorizationRequest(number string, amount int64) *sleet.AuthorizationRequest {
+	request := sleet_t.BaseAuthorizationRequest()
+	request.CreditCard.Number = number
+	request.Amount.Amount = amount
+	return request
+}
+
+func authorized(t *testing.T, client *SimulatorClient, amount int64) string {
+	t.Helper()
+	resp, err := client.Authorize(authorizationRequest("4111111111111111", amount))
+	if err != nil || !resp.Success {
+		t.Fatalf("expected the authorization to be approved, got %+v, %v", resp, err)
+	}
+	return resp.TransactionReference
+}
+
+func query(t *testing.T, client *SimulatorClient, reference string) *sleet.TransactionQueryResponse {
+	t.Helper()
+	resp, err := client.QueryTransaction(&sleet.TransactionQueryRequest{TransactionReference: reference})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestAuthorizeScenarios(t *testing.T) {
+	cases := []struct {
+		label       string
+		number      string
+		amount      int64
+		partial     bool
+		wantCode    string
+		wantReason  sleet.DeclineReason
+		wantResult  sleet.ResultType
+		wantAVS     sleet.AVSResponse
+		wantCVV     sleet.CVVResponse
+		wantApprove int64
+	}{
+		{"Approved", "4111111111111111", 100, false, codeApproved, "", sleet.ResultTypeSuccess, sleet.AVSResponseMatch, sleet.CVVResponseMatch, 100},
+		{"Declined Card", CardDeclined, 100, false, codeDoNotHonor, sleet.DeclineReasonDoNotHonor, sleet.ResultTypePaymentError, sleet.AVSResponseMatch, sleet.CVVResponseMatch, 0},
+		{"Declined Amount", "4111111111111111", AmountDeclined, false, codeDoNotHonor, sleet.DeclineReasonDoNotHonor, sleet.ResultTypePaymentError, sleet.AVSResponseMatch, sleet.CVVResponseMatch, 0},
+		{"Insufficient Funds", CardInsufficientFunds, 100, false, codeInsufficientFunds, sleet.DeclineReasonInsufficientFunds, sleet.ResultTypePaymentError, sleet.AVSResponseMatch, sleet.CVVResponseMatch, 0},
+		{"Expired", CardExpired, 100, false, codeExpiredCard, sleet.DeclineReasonExpiredCard, sleet.ResultTypePaymentError, sleet.AVSResponseMatch, sleet.CVVResponseMatch, 0},
+		{"Lost Or Stolen", CardLostOrStolen, 100, false, codeLostOrStolen, sleet.DeclineReasonLostOrStolenCard, sleet.ResultTypePaymentError, sleet.AVSResponseMatch, sleet.CVVResponseMatch, 0},
+		{"CVV No Match", CardCVVNoMatch, 100, false, codeCVVNoMatch, sleet.DeclineReasonCVVFailure, sleet.ResultTypePaymentError, sleet.AVSResponseMatch, sleet.CVVResponseNoMatch, 0},
+		{"AVS No Match", CardAVSNoMatch, 100, false, codeApproved, "", sleet.ResultTypeSuccess, sleet.AVSResponseNoMatch, sleet.CVVResponseMatch, 100},
+		{"Issuer Unavailable", CardIssuerUnavailable, 100, false, codeIssuerUnavailable, sleet.DeclineReasonIssuerUnavailable, sleet.ResultTypeServerError, sleet.AVSResponseMatch, sleet.CVVResponseMatch, 0},
+		{"Partial Approval", "4111111111111111", AmountPartialApproval, true, codeApproved, "", sleet.ResultTypeSuccess, sleet.AVSResponseMatch, sleet.CVVResponseMatch, AmountPartialApproval / 2},
+		{"Partial Approval Not Allowed", "4111111111111111", AmountPartialApproval, false, codeApproved, "", sleet.ResultTypeSuccess, sleet.AVSResponseMatch, sleet.CVVResponseMatch, AmountPartialApproval},
+	}
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			request := authorizationRequest(c.number, c.amount)
+			request.AllowPartialAuth = c.partial
+			resp, err := NewClient().Authorize(request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Response != c.wantCode || resp.DeclineReason != c.wantReason || resp.ResultType != c.wantResult {
+				t.Errorf("expected code %s, reason %q and %s, got %+v", c.wantCode, c.wantReason, c.wantResult, resp)
+			}
+			if resp.AvsResult != c.wantAVS || resp.CvvResult != c.wantCVV {
+				t.Errorf("expected AVS %v and CVV %v, got %v and %v", c.wantAVS, c.wantCVV, resp.AvsResult, resp.CvvResult)
+			}
+			if c.wantApprove == 0 {
+				if resp.Success || resp.ApprovedAmount != nil || resp.ErrorCode != c.wantCode {
+					t.Errorf("expected a decline with error code %s, got %+v", c.wantCode, resp)
+				}
+			} else if !resp.Success || resp.ApprovedAmount == nil || resp.ApprovedAmount.Amount != c.wantApprove {
+				t.Errorf("expected %d approved, got %+v", c.wantApprove, resp)
+			}
+		})
+	}
+
+	t.Run("Skipped Checks", func(t *testing.T) {
+		request := authorizationRequest("4111111111111111", 100)
+		request.BillingAddress = nil
+		request.CreditCard.CVV = ""
+		resp, err := NewClient().Authorize(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.AvsResult != sleet.AVSResponseSkipped || resp.CvvResult != sleet.CVVResponseSkipped {
+			t.Errorf("expected the checks to be skipped, got %v and %v", resp.AvsResult, resp.CvvResult)
+		}
+	})
+
+	t.Run("Invalid Request", func(t *testing.T) {
+		request := authorizationRequest("4111111111111111", 0)
+		resp, err := NewClient().Authorize(request)
+		var sleetErr *sleet.Error
+		if resp != nil || !errors.As(err, &sleetErr) || sleetErr.Kind != sleet.ErrorKindValidation {
+			t.Errorf("expected a validation error, got %+v, %v", resp, err)
+		}
+	})
+}
+
+func TestAmountErrors(t *testing.T) {
+	t.Run("Server Error", func(t *testing.T) {
+		client := NewClient()
+		resp, err := client.Authorize(authorizationRequest("4111111111111111", AmountServerError))
+		var sleetErr *sleet.Error
+		if resp != nil || !errors.As(err, &sleetErr) {
+			t.Fatalf("expected an error, got %+v, %v", resp, err)
+		}
+		if sleetErr.Kind != sleet.ErrorKindPSPAPI || sleetErr.StatusCode != http.StatusInternalServerError || !sleetErr.Retryable {
+			t.Errorf("expected a retryable 500, got %+v", sleetErr)
+		}
+		if next := authorized(t, client, 100); next != "sim_000001" {
+			t.Errorf("expected the failed authorization not to be recorded, got %s", next)
+		}
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		client := NewClient()
+		request := authorizationRequest("4111111111111111", AmountTimeout)
+		resp, err := client.Authorize(request)
+		var sleetErr *sleet.Error
+		if resp != nil || !errors.As(err, &sleetErr) || sleetErr.Kind != sleet.ErrorKindTimeout {
+			t.Fatalf("expected a timeout, got %+v, %v", resp, err)
+		}
+		if got := query(t, client, "sim_000001"); !got.Success || got.State != sleet.TransactionStateAuthorized {
+			t.Errorf("expected the authorization to be recorded, got %+v", got)
+		}
+		reversal, err := client.ReverseTimedOutAuthorization(&sleet.TimeoutReversalRequest{ClientTransactionReference: request.ClientTransactionReference})
+		if err != nil || !reversal.Success || reversal.TransactionReference != "sim_000001" {
+			t.Fatalf("expected the authorization to be reversed, got %+v, %v", reversal, err)
+		}
+		if got := query(t, client, "sim_000001"); got.State != sleet.TransactionStateVoided {
+			t.Errorf("expected the authorization to be voided, got %s", got.State)
+		}
+	})
+
+	t.Run("Capture Server Error", func(t *testing.T) {
+		client := NewClient()
+		reference := authorized(t, client, 10000)
+		resp, err := client.Capture(&sleet.CaptureRequest{TransactionReference: reference, Amount: &sleet.Amount{Amount: AmountServerError, Currency: "USD"}})
+		if resp != nil || err == nil {
+			t.Fatalf("expected an error, got %+v, %v", resp, err)
+		}
+		if got := query(t, client, reference); got.State != sleet.TransactionStateAuthorized {
+			t.Errorf("expected the capture not to be recorded, got %s", got.State)
+		}
+	})
+
+	t.Run("Cancelled Context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := NewClient().AuthorizeWithContext(ctx, authorizationRequest("4111111111111111", 100))
+		var sleetErr *sleet.Error
+		if !errors.As(err, &sleetErr) || sleetErr.Kind != sleet.ErrorKindTimeout || !errors.Is(err, context.Canceled) {
+			t.Errorf("expected a timeout wrapping the cancellation, got %v", err)
+		}
+	})
+}
+
+func TestStateMachine(t *testing.T) {
+	usd := func(amount int64) *sleet.Amount {
+		return &sleet.Amount{Amount: amount, Currency: "USD"}
+	}
+
+	t.Run("Capture And Refund", func(t *testing.T) {
+		client := NewClient()
+		reference := authorized(t, client, 1000)
+
+		capture, err := client.Capture(&sleet.CaptureRequest{TransactionReference: reference, Amount: usd(800)})
+		if err != nil || !capture.Success || capture.Amount.Amount != 800 {
+			t.Fatalf("expected 800 to be captured, got %+v, %v", capture, err)
+		}
+		refund, err := client.Refund(&sleet.RefundRequest{TransactionReference: reference, Amount: usd(300)})
+		if err != nil || !refund.Success || refund.TransactionReference != "sim_000002" {
+			t.Fatalf("expected a refund with its own reference, got %+v, %v", refund, err)
+		}
+		refund, err = client.Refund(&sleet.RefundRequest{TransactionReference: reference, Amount: usd(500)})
+		if err != nil || !refund.Success {
+			t.Fatalf("expected the rest to be refunded, got %+v, %v", refund, err)
+		}
+
+		got := query(t, client, reference)
+		if got.State != sleet.TransactionStateRefunded || got.AuthorizedAmount != 1000 || got.CapturedAmount != 800 || got.RefundedAmount != 800 {
+			t.Errorf("unexpected transaction %+v", got)
+		}
+		if got.Last4 != "1111" || got.Network != sleet.CreditCardNetworkVisa || got.Currency != "USD" {
+			t.Errorf("unexpected card details %+v", got)
+		}
+	})
+
+	cases := []struct {
+		label    string
+		setup    func(client *SimulatorClient, reference string)
+		operate  func(client *SimulatorClient, reference string) (bool, *string, error)
+		wantCode string
+	}{
+		{
+			label: "Over Capture",
+			operate: func(client *SimulatorClient, reference string) (bool, *string, error) {
+				resp, err := client.Capture(&sleet.CaptureRequest{TransactionReference: reference, Amount: usd(1001)})
+				return resp.Success, resp.ErrorCode, err
+			},
+			wantCode: codeAmountExceeded,
+		},
+		{
+			label: "Double Capture",
+			setup: func(client *SimulatorClient, reference string) {
+				client.Capture(&sleet.CaptureRequest{TransactionReference: reference, Amount: usd(100)})
+			},
+			operate: func(client *SimulatorClient, reference string) (bool, *string, error) {
+				resp, err := client.Capture(&sleet.CaptureRequest{TransactionReference: reference, Amount: usd(100)})
+				return resp.Success, resp.ErrorCode, err
+			},
+			wantCode: codeInvalidState,
+		},
+		{
+			label: "Double Void",
+			setup: func(client *SimulatorClient, reference string) {
+				client.Void(&sleet.VoidRequest{TransactionReference: reference})
+			},
+			operate: func(client *SimulatorClient, reference string) (bool, *string, error) {
+				resp, err := client.Void(&sleet.VoidRequest{TransactionReference: reference})
+				return resp.Success, resp.ErrorCode, err
+			},
+			wantCode: codeInvalidState,
+		},
+		{
+			label: "Void After Capture",
+			setup: func(client *SimulatorClient, reference string) {
+				client.Capture(&sleet.CaptureRequest{TransactionReference: reference})
+			},
+			operate: func(client *SimulatorClient, reference string) (bool, *string, error) {
+				resp, err := client.Void(&sleet.VoidRequest{TransactionReference: reference})
+				return resp.Success, resp.ErrorCode, err
+			},
+			wantCode: codeInvalidState,
+		},
+		{
+			label: "Refund Before Capture",
+			operate: func(client *SimulatorClient, reference string) (bool, *string, error) {
+				resp, err := client.Refund(&sleet.RefundRequest{TransactionReference: reference, Amount: usd(100)})
+				return resp.Success, resp.ErrorCode, err
+			},
+			wantCode: codeInvalidState,
+		},
+		{
+			label: "Over Refund",
+			setup: func(client *SimulatorClient, reference string) {
+				client.Capture(&sleet.CaptureRequest{TransactionReference: reference, Amount: usd(600)})
+				client.Refund(&sleet.RefundRequest{TransactionReference: reference, Amount: usd(500)})
+			},
+			operate: func(client *SimulatorClient, reference string) (bool, *string, error) {
+				resp, err := client.Refund(&sleet.RefundRequest{TransactionReference: reference, Amount: usd(101)})
+				return resp.Success, resp.ErrorCode, err
+			},
+			wantCode: codeAmountExceeded,
+		},
+		{
+			label: "Currency Mismatch",
+			operate: func(client *SimulatorClient, reference string) (bool, *string, error) {
+				resp, err := client.Capture(&sleet.CaptureRequest{TransactionReference: reference, Amount: &sleet.Amount{Amount: 100, Currency: "EUR"}})
+				return resp.Success, resp.ErrorCode, err
+			},
+			wantCode: codeCurrencyMismatch,
+		},
+		{
+			label: "Unknown Transaction",
+			operate: func(client *SimulatorClient, _ string) (bool, *string, error) {
+				resp, err := client.Void(&sleet.VoidRequest{TransactionReference: "sim_999999"})
+				return resp.Success, resp.ErrorCode, err
+			},
+			wantCode: codeNotFound,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			client := NewClient()
+			reference := authorized(t, client, 1000)
+			if c.setup != nil {
+				c.setup(client, reference)
+			}
+			success, errorCode, err := c.operate(client, reference)
+			if err != nil {
+				t.Fatalf("expected a rejection without an error, got %v", err)
+			}
+			if success || errorCode == nil || *errorCode != c.wantCode {
+				t.Errorf("expected a rejection with %s, got success %t and %v", c.wantCode, success, common.SafeStr(errorCode))
+			}
+		})
+	}
+
+	t.Run("Amounts Must Be Positive", func(t *testing.T) {
+		isValidationError := func(err error) bool {
+			var sleetErr *sleet.Error
+			return errors.As(err, &sleetErr) && sleetErr.Kind == sleet.ErrorKindValidation
+		}
+		client := NewClient()
+		reference := authorized(t, client, 1000)
+
+		for _, amount := range []int64{0, -500} {
+			if resp, err := client.Capture(&sleet.CaptureRequest{TransactionReference: reference, Amount: usd(amount)}); resp != nil || !isValidationError(err) {
+				t.Errorf("expected a capture of %d to be invalid, got %+v, %v", amount, resp, err)
+			}
+			resp, err := client.IncrementAuthorization(&sleet.IncrementAuthorizationRequest{
+				TransactionReference: reference,
+				Amount:               *usd(amount),
+				AuthorizedAmount:     1000,
+			})
+			if resp != nil || !isValidationError(err) {
+				t.Errorf("expected an increment of %d to be invalid, got %+v, %v", amount, resp, err)
+			}
+		}
+
+		if _, err := client.Capture(&sleet.CaptureRequest{TransactionReference: reference, Amount: usd(1000)}); err != nil {
+			t.Fatal(err)
+		}
+		for _, amount := range []int64{0, -500} {
+			if resp, err := client.Refund(&sleet.RefundRequest{TransactionReference: reference, Amount: usd(amount)}); resp != nil || !isValidationError(err) {
+				t.Errorf("expected a refund of %d to be invalid, got %+v, %v", amount, resp, err)
+			}
+		}
+		if resp, err := client.Refund(&sleet.RefundRequest{TransactionReference: reference, Amount: usd(1500)}); err != nil || resp.Success {
+			t.Errorf("expected a refund of more than was captured to be rejected, got %+v, %v", resp, err)
+		}
+
+		got := query(t, client, reference)
+		if got.State != sleet.TransactionStateCaptured || got.AuthorizedAmount != 1000 || got.CapturedAmount != 1000 || got.RefundedAmount != 0 {
+			t.Errorf("expected the invalid amounts to leave the transaction unchanged, got %+v", got)
+		}
+	})
+
+	t.Run("Rejection Response", func(t *testing.T) {
+		client := NewClient()
+		reference := authorized(t, client, 1000)
+		client.Void(&sleet.VoidRequest{TransactionReference: reference})
+		resp, _ := client.Void(&sleet.VoidRequest{TransactionReference: reference})
+		if resp.StatusCode != http.StatusConflict || resp.ResultType != common.ResultTypeFromHTTPStatus(http.StatusConflict) || resp.Message == "" {
+			t.Errorf("expected a 409 with a message, got %+v", resp)
+		}
+	})
+}
+
+func TestSale(t *testing.T) {
+	client := NewClient()
+	resp, err := client.Sale(authorizationRequest("4111111111111111", 1000))
+	if err != nil || !resp.Success {
+		t.Fatalf("expected the sale to be approved, got %+v, %v", resp, err)
+	}
+	if got := query(t, client, resp.TransactionReference); got.State != sleet.TransactionStateCaptured || got.CapturedAmount != 1000 {
+		t.Errorf("expected the sale to be captured, got %+v", got)
+	}
+
+	resp, err = client.Sale(authorizationRequest(CardDeclined, 1000))
+	if err != nil || resp.Success {
+		t.Fatalf("expected the sale to be declined, got %+v, %v", resp, err)
+	}
+	if got := query(t, client, resp.TransactionReference); got.State != sleet.TransactionStateDeclined || common.SafeStr(got.ErrorCode) != codeDoNotHonor {
+		t.Errorf("expected the decline to be recorded, got %+v", got)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	client := NewClient()
+	request := authorizationRequest("4111111111111111", 0)
+	resp, err := client.Verify(request)
+	if err != nil || !resp.Success {
+		t.Fatalf("expected the card to be verified, got %+v, %v", resp, err)
+	}
+	if got := query(t, client, resp.TransactionReference); got.State != sleet.TransactionStateVoided {
+		t.Errorf("expected the verification to be voided, got %s", got.State)
+	}
+}
+
+func TestIncrementAuthorization(t *testing.T) {
+	client := NewClient()
+	reference := authorized(t, client, 1000)
+
+	resp, err := client.IncrementAuthorization(&sleet.IncrementAuthorizationRequest{
+		TransactionReference: reference,
+		Amount:               sleet.Amount{Amount: 500, Currency: "USD"},
+		AuthorizedAmount:     1000,
+	})
+	if err != nil || !resp.Success || resp.AuthorizedAmount.Amount != 1500 {
+		t.Fatalf("expected 1500 to be authorized, got %+v, %v", resp, err)
+	}
+
+	resp, err = client.IncrementAuthorization(&sleet.IncrementAuthorizationRequest{
+		TransactionReference: reference,
+		Amount:               sleet.Amount{Amount: 500, Currency: "USD"},
+		AuthorizedAmount:     1000,
+	})
+	if err != nil || resp.Success || common.SafeStr(resp.ErrorCode) != codeAuthorizedMismatch ||
+		resp.StatusCode != http.StatusUnprocessableEntity || resp.Message == "" {
+		t.Errorf("expected a stale authorized amount to be rejected, got %+v, %v", resp, err)
+	}
+
+	_, err = client.IncrementAuthorization(&sleet.IncrementAuthorizationRequest{
+		TransactionReference: reference,
+		Amount:               sleet.Amount{Amount: 500, Currency: "USD"},
+	})
+	if !errors.Is(err, sleet.ErrAuthorizedAmountRequired) {
+		t.Errorf("expected ErrAuthorizedAmountRequired, got %v", err)
+	}
+}
+
+func TestFindByClientReference(t *testing.T) {
+	client := NewClient()
+	first := authorizationRequest("4111111111111111", 100)
+	first.ClientTransactionReference = common.SPtr("order-1")
+	first.MerchantOrderReference = "merchant-1"
+	second := authorizationRequest(CardDeclined, 100)
+	second.ClientTransactionReference = common.SPtr("order-1")
+	other := authorizationRequest("4111111111111111", 100)
+	other.ClientTransactionReference = common.SPtr("order-2")
+	for _, request := range []*sleet.AuthorizationRequest{first, second, other} {
+		if _, err := client.Authorize(request); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resp, err := client.FindByClientReference(&sleet.FindByClientReferenceRequest{ClientTransactionReference: common.SPtr("order-1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Transactions) != 2 || resp.Transactions[0].TransactionReference != "sim_000002" || resp.Transactions[1].TransactionReference != "sim_000001" {
+		t.Errorf("expected the two transactions most recent first, got %+v", resp.Transactions)
+	}
+
+	resp, err = client.FindByClientReference(&sleet.FindByClientReferenceRequest{MerchantOrderReference: common.SPtr("merchant-1")})
+	if err != nil || len(resp.Transactions) != 1 || resp.Transactions[0].TransactionReference != "sim_000001" {
+		t.Errorf("expected the transaction by merchant order reference, got %+v, %v", resp, err)
+	}
+
+	if _, err := client.FindByClientReference(&sleet.FindByClientReferenceRequest{}); !errors.Is(err, sleet.ErrClientReferenceRequired) {
+		t.Errorf("expected ErrClientReferenceRequired, got %v", err)
+	}
+}
+
+func TestQueryTransaction(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	client := NewClient()
+	client.now = func() time.Time { return created }
+	reference := authorized(t, client, 100)
+
+	got := query(t, client, reference)
+	if got.CreatedAt == nil || !got.CreatedAt.Equal(created) || got.StateRaw != string(sleet.TransactionStateAuthorized) {
+		t.Errorf("unexpected transaction %+v", got)
+	}
+
+	got = query(t, client, "sim_999999")
+	if got.Success || common.SafeStr(got.ErrorCode) != codeNotFound {
+		t.Errorf("expected an unknown transaction not to be found, got %+v", got)
+	}
+}
+
+func TestDeterministicReferences(t *testing.T) {
+	run := func() []string {
+		client := NewClient()
+		var references []string
+		for _, number := range []string{"4111111111111111", CardDeclined, "4111111111111111"} {
+			resp, err := client.Authorize(authorizationRequest(number, 100))
+			if err != nil {
+				t.Fatal(err)
+			}
+			references = append(references, resp.TransactionReference)
+		}
+		return references
+	}
+	first, second := run(), run()
+	want := []string{"sim_000001", "sim_000002", "sim_000003"}
+	for i := range want {
+		if first[i] != want[i] || second[i] != want[i] {
+			t.Errorf("expected %v from both runs, got %v and %v", want, first, second)
+			break
+		}
+	}
+}
+
+func TestConcurrentUse(t *testing.T) {
+	client := NewClient()
+	reference := authorized(t, client, 1000)
+	if _, err := client.Capture(&sleet.CaptureRequest{TransactionReference: reference}); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	refunded := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Refund(&sleet.RefundRequest{TransactionReference: reference, Amount: &sleet.Amount{Amount: 100, Currency: "USD"}})
+			if err == nil && resp.Success {
+				mu.Lock()
+				refunded++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if refunded != 10 {
+		t.Errorf("expected exactly 10 refunds of 100, got %d", refunded)
+	}
+}
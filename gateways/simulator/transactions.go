@@ -0,0 +1,165 @@
+package simulator
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/BoltApp/sleet"
+)
+
+// transaction is an authorization held by the simulator. It moves from authorized to captured or voided, and from
+// captured to refunded, declined authorizations are kept to be queried.
+type transaction struct {
+	reference                  string
+	clientTransactionReference string
+	merchantOrderReference     string
+	state                      sleet.TransactionState
+	code                       string // the authorization's response code
+	currency                   string
+	authorizedAmount           int64
+	capturedAmount             int64
+	refundedAmount             int64
+	last4                      string
+	network                    sleet.CreditCardNetwork
+	createdAt                  time.Time
+	updatedAt                  time.Time
+}
+
+// rejection is the simulated PsP refusing an operation, with the status it responds with
+type rejection struct {
+	code       string
+	statusCode int
+	message    string
+}
+
+func notFound(reference string) *rejection {
+	return &rejection{code: codeNotFound, statusCode: http.StatusNotFound, message: fmt.Sprintf("no transaction %s", reference)}
+}
+
+func (t *transaction) invalidState(operation string) *rejection {
+	return &rejection{
+		code:       codeInvalidState,
+		statusCode: http.StatusConflict,
+		message:    fmt.Sprintf("transaction %s is %s and cannot be %s", t.reference, t.state, operation),
+	}
+}
+
+func (t *transaction) checkCurrency(amount *sleet.Amount) *rejection {
+	if amount == nil || strings.EqualFold(amount.Currency, t.currency) {
+		return nil
+	}
+	return &rejection{
+		code:       codeCurrencyMismatch,
+		statusCode: http.StatusUnprocessableEntity,
+		message:    fmt.Sprintf("transaction %s is in %s, not %s", t.reference, t.currency, amount.Currency),
+	}
+}
+
+func amountExceeded(amount int64, available int64) *rejection {
+	return &rejection{
+		code:       codeAmountExceeded,
+		statusCode: http.StatusUnprocessableEntity,
+		message:    fmt.Sprintf("amount %d exceeds the %d available", amount, available),
+	}
+}
+
+// capture captures the amount, or all of the authorization if the amount is nil. An authorization is captured once.
+func (t *transaction) capture(amount *sleet.Amount, now time.Time) (int64, *rejection) {
+	if t.state != sleet.TransactionStateAuthorized {
+		return 0, t.invalidState("captured")
+	}
+	if rejected := t.checkCurrency(amount); rejected != nil {
+		return 0, rejected
+	}
+	captured := t.authorizedAmount
+	if amount != nil {
+		captured = amount.Amount
+	}
+	if captured > t.authorizedAmount {
+		return 0, amountExceeded(captured, t.authorizedAmount)
+	}
+	t.capturedAmount = captured
+	t.state = sleet.TransactionStateCaptured
+	t.updatedAt = now
+	return captured, nil
+}
+
+// void cancels an authorization which has not been captured
+func (t *transaction) void(now time.Time) *rejection {
+	if t.state != sleet.TransactionStateAuthorized {
+		return t.invalidState("voided")
+	}
+	t.state = sleet.TransactionStateVoided
+	t.updatedAt = now
+	return nil
+}
+
+// refund refunds the amount, or all that is left of the capture if the amount is nil. A capture can be refunded
+// several times up to its amount.
+func (t *transaction) refund(amount *sleet.Amount, now time.Time) (int64, *rejection) {
+	if t.state != sleet.TransactionStateCaptured && t.state != sleet.TransactionStateRefunded {
+		return 0, t.invalidState("refunded")
+	}
+	if rejected := t.checkCurrency(amount); rejected != nil {
+		return 0, rejected
+	}
+	available := t.capturedAmount - t.refundedAmount
+	refunded := available
+	if amount != nil {
+		refunded = amount.Amount
+	}
+	if refunded > available {
+		return 0, amountExceeded(refunded, available)
+	}
+	t.refundedAmount += refunded
+	t.state = sleet.TransactionStateRefunded
+	t.updatedAt = now
+	return refunded, nil
+}
+
+// increment raises an authorization which has not been captured. The request's AuthorizedAmount must be the total
+// authorized so far, as PsPs which take the new total require.
+func (t *transaction) increment(request *sleet.IncrementAuthorizationRequest, now time.Time) *rejection {
+	if t.state != sleet.TransactionStateAuthorized {
+		return t.invalidState("incremented")
+	}
+	if rejected := t.checkCurrency(&request.Amount); rejected != nil {
+		return rejected
+	}
+	if request.AuthorizedAmount != t.authorizedAmount {
+		return &rejection{
+			code:       codeAuthorizedMismatch,
+			statusCode: http.StatusUnprocessableEntity,
+			message:    fmt.Sprintf("transaction %s has %d authorized, not %d", t.reference, t.authorizedAmount, request.AuthorizedAmount),
+		}
+	}
+	t.authorizedAmount += request.Amount.Amount
+	t.updatedAt = now
+	return nil
+}
+
+// query describes the transaction as a TransactionQuerier does
+func (t *transaction) query() *sleet.TransactionQueryResponse {
+	createdAt, updatedAt := t.createdAt, t.updatedAt
+	response := &sleet.TransactionQueryResponse{
+		Success:              true,
+		TransactionReference: t.reference,
+		State:                t.state,
+		StateRaw:             string(t.state),
+		Currency:             t.currency,
+		AuthorizedAmount:     t.authorizedAmount,
+		CapturedAmount:       t.capturedAmount,
+		RefundedAmount:       t.refundedAmount,
+		Last4:                t.last4,
+		Network:              t.network,
+		CreatedAt:            &createdAt,
+		UpdatedAt:            &updatedAt,
+	}
+	if t.state == sleet.TransactionStateDeclined {
+		code := t.code
+		response.ErrorCode = &code
+	}
+	return response
+}
@@ -0,0 +1,33 @@
+//go:build unit
+// +build unit
+
+package stripe
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/testing/conformance"
+	"github.com/BoltApp/sleet/testing/fakes"
+)
+
+func TestConformance(t *testing.T) {
+	fake := fakes.NewStripe()
+	defer fake.Close()
+
+	conformance.Run(t, conformance.Suite{
+		Gateway:   "stripe",
+		Transport: fake.Client().Transport,
+		NewClient: func(httpClient *http.Client) sleet.ClientWithContext {
+			return NewWithHTTPClient(fakes.StripeAPIKey, httpClient)
+		},
+		// Stripe's generic decline test card
+		Decline: func(request *sleet.AuthorizationRequest) { request.CreditCard.Number = "4000000000000002" },
+		AVS:     sleet.AVSresponseZipMatchAddressMatch,
+		CVV:     sleet.CVVResponseMatch,
+		Skip: map[conformance.Behavior]string{
+			conformance.BehaviorResponseHeader: "stripe-go v70 doesn't expose the HTTP response",
+		},
+	})
+}
@@ -0,0 +1,28 @@
+package stripe
+
+import (
+	"net/http"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/registry"
+)
+
+// credentialAPIKey is the Stripe secret key in a registry.Config, Stripe selects test mode from the key
+const credentialAPIKey = "api_key"
+
+func init() {
+	registry.Register("stripe", newRegistryClient)
+}
+
+// newRegistryClient builds a StripeClient from a registry config. Without HTTP settings it keeps the default client
+// of NewClient, which disables HTTP/2.
+func newRegistryClient(config *registry.Config, httpClient *http.Client, options ...sleet.ClientOption) (sleet.ClientWithContext, error) {
+	apiKey, err := config.Credential(credentialAPIKey)
+	if err != nil {
+		return nil, err
+	}
+	if config.HTTP == (registry.HTTPConfig{}) {
+		httpClient = defaultHttpClient
+	}
+	return NewWithHTTPClient(apiKey, httpClient, options...), nil
+}
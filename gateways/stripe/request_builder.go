@@ -10,6 +10,21 @@ import (
 )
 
 func buildChargeParams(ctx context.Context, authRequest *sleet.AuthorizationRequest) *stripe.ChargeParams {
+	if authRequest.StoredPaymentMethod != nil {
+		// card payment methods attached to a customer can be charged as a source
+		return &stripe.ChargeParams{
+			Params: stripe.Params{
+				Context: ctx,
+			},
+			Amount:   stripe.Int64(authRequest.Amount.Amount),
+			Currency: stripe.String(authRequest.Amount.Currency),
+			Customer: stripe.String(authRequest.StoredPaymentMethod.CustomerReference),
+			Source: &stripe.SourceParams{
+				Token: stripe.String(authRequest.StoredPaymentMethod.Token),
+			},
+			Capture: stripe.Bool(false),
+		}
+	}
 	return &stripe.ChargeParams{
 		Params: stripe.Params{
 			Context: ctx,
@@ -29,6 +44,12 @@ func buildChargeParams(ctx context.Context, authRequest *sleet.AuthorizationRequ
 	}
 }
 
+func buildSaleParams(ctx context.Context, saleRequest *sleet.SaleRequest) *stripe.ChargeParams {
+	params := buildChargeParams(ctx, saleRequest)
+	params.Capture = stripe.Bool(true)
+	return params
+}
+
 func buildRefundParams(ctx context.Context, refundRequest *sleet.RefundRequest) *stripe.RefundParams {
 	return &stripe.RefundParams{
 		Params: stripe.Params{
@@ -56,3 +77,84 @@ func buildVoidParams(ctx context.Context, voidRequest *sleet.VoidRequest) *strip
 		Charge: stripe.String(voidRequest.TransactionReference),
 	}
 }
+
+func buildPaymentMethodParams(ctx context.Context, storeRequest *sleet.StorePaymentMethodRequest) *stripe.PaymentMethodParams {
+	card := storeRequest.CreditCard
+	params := &stripe.PaymentMethodParams{
+		Params: stripe.Params{
+			Context: ctx,
+		},
+		Type: stripe.String(string(stripe.PaymentMethodTypeCard)),
+		Card: &stripe.PaymentMethodCardParams{
+			Number:   stripe.String(card.Number),
+			ExpMonth: stripe.String(strconv.Itoa(card.ExpirationMonth)),
+			ExpYear:  stripe.String(strconv.Itoa(card.ExpirationYear)),
+			CVC:      stripe.String(card.CVV),
+		},
+		BillingDetails: &stripe.BillingDetailsParams{
+			Name: stripe.String(card.FirstName + " " + card.LastName),
+		},
+	}
+	if address := storeRequest.BillingAddress; address != nil {
+		params.BillingDetails.Email = address.Email
+		params.BillingDetails.Phone = address.PhoneNumber
+		params.BillingDetails.Address = &stripe.AddressParams{
+			Line1:      address.StreetAddress1,
+			Line2:      address.StreetAddress2,
+			City:       address.Locality,
+			State:      address.RegionCode,
+			PostalCode: address.PostalCode,
+			Country:    address.CountryCode,
+		}
+	}
+	return params
+}
+
+func buildCustomerParams(ctx context.Context, storeRequest *sleet.StorePaymentMethodRequest, paymentMethodID string) *stripe.CustomerParams {
+	params := &stripe.CustomerParams{
+		Params: stripe.Params{
+			Context: ctx,
+		},
+		Name:          stripe.String(storeRequest.CreditCard.FirstName + " " + storeRequest.CreditCard.LastName),
+		PaymentMethod: stripe.String(paymentMethodID),
+	}
+	if storeRequest.ShopperReference != "" {
+		params.AddMetadata(shopperReferenceMetadata, storeRequest.ShopperReference)
+	}
+	if storeRequest.BillingAddress != nil {
+		params.Email = storeRequest.BillingAddress.Email
+	}
+	return params
+}
+
+func buildAttachParams(ctx context.Context, customerID string) *stripe.PaymentMethodAttachParams {
+	return &stripe.PaymentMethodAttachParams{
+		Params: stripe.Params{
+			Context: ctx,
+		},
+		Customer: stripe.String(customerID),
+	}
+}
+
+// buildVerificationPaymentMethodParams creates the card payment method to verify, it is not attached to a customer
+func buildVerificationPaymentMethodParams(ctx context.Context, verificationRequest *sleet.VerificationRequest) *stripe.PaymentMethodParams {
+	return buildPaymentMethodParams(ctx, &sleet.StorePaymentMethodRequest{
+		CreditCard:     verificationRequest.CreditCard,
+		BillingAddress: verificationRequest.BillingAddress,
+	})
+}
+
+// buildSetupIntentParams confirms a SetupIntent for the payment method, which checks the card with its issuer.
+// The payment method is expanded to return its card checks.
+func buildSetupIntentParams(ctx context.Context, paymentMethodID string) *stripe.SetupIntentParams {
+	params := &stripe.SetupIntentParams{
+		Params: stripe.Params{
+			Context: ctx,
+		},
+		Confirm:            stripe.Bool(true),
+		PaymentMethod:      stripe.String(paymentMethodID),
+		PaymentMethodTypes: []*string{stripe.String(string(stripe.PaymentMethodTypeCard))},
+	}
+	params.AddExpand("payment_method")
+	return params
+}
@@ -0,0 +1,56 @@
+//go:build unit
+// +build unit
+
+package stripe
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-test/deep"
+	"github.com/stripe/stripe-go"
+
+	sleet_testing "github.com/BoltApp/sleet/testing"
+)
+
+func TestBuildChargeParamsStoredPaymentMethod(t *testing.T) {
+	base := sleet_testing.BaseStoredPaymentMethodAuthorizationRequest()
+	want := &stripe.ChargeParams{
+		Params: stripe.Params{
+			Context: context.TODO(),
+		},
+		Amount:   stripe.Int64(100),
+		Currency: stripe.String("USD"),
+		Customer: stripe.String("333333"),
+		Source: &stripe.SourceParams{
+			Token: stripe.String("444444"),
+		},
+		Capture: stripe.Bool(false),
+	}
+
+	got := buildChargeParams(context.TODO(), base)
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestBuildSaleParams(t *testing.T) {
+	base := sleet_testing.BaseStoredPaymentMethodAuthorizationRequest()
+	want := &stripe.ChargeParams{
+		Params: stripe.Params{
+			Context: context.TODO(),
+		},
+		Amount:   stripe.Int64(100),
+		Currency: stripe.String("USD"),
+		Customer: stripe.String("333333"),
+		Source: &stripe.SourceParams{
+			Token: stripe.String("444444"),
+		},
+		Capture: stripe.Bool(true),
+	}
+
+	got := buildSaleParams(context.TODO(), base)
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Error(diff)
+	}
+}
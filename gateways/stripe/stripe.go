@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/tls"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/BoltApp/sleet"
@@ -16,13 +17,19 @@ import (
 
 var (
 	// assert client interface
-	_ sleet.ClientWithContext = &StripeClient{}
+	_ sleet.ClientWithContext             = &StripeClient{}
+	_ sleet.TransactionQuerierWithContext = &StripeClient{}
+	_ sleet.SaleWithContext               = &StripeClient{}
+	_ sleet.CapabilitiesReporter          = &StripeClient{}
 )
 
+const gatewayName = "stripe"
+
 // StripeClient uses API-Key and custom http client to make http calls
 type StripeClient struct {
-	apiKey     string
-	httpClient *http.Client
+	apiKey  string
+	backend stripe.Backend
+	logger  sleet.Logger
 }
 
 var defaultHttpClient = &http.Client{
@@ -36,17 +43,39 @@ var defaultHttpClient = &http.Client{
 
 // NewClient uses default http client with provided Stripe API Key
 // Note: the environment is kind of explicitly given to us by the apiKey
-func NewClient(apiKey string) *StripeClient {
-	return NewWithHTTPClient(apiKey, defaultHttpClient)
+func NewClient(apiKey string, options ...sleet.ClientOption) *StripeClient {
+	return NewWithHTTPClient(apiKey, defaultHttpClient, options...)
 }
 
 // NewWithHTTPClient uses a custom http client for requests
-func NewWithHTTPClient(apiKey string, httpClient *http.Client) *StripeClient {
+func NewWithHTTPClient(apiKey string, httpClient *http.Client, options ...sleet.ClientOption) *StripeClient {
 	// set the Stripe global key for requests
 	stripe.Key = apiKey
+	clientOptions := sleet.NewClientOptions(options...)
+	// stripe-go's shared backend has its own http client, so each client gets a backend sending requests through its own
+	backend := stripe.GetBackendWithConfig(stripe.APIBackend, &stripe.BackendConfig{
+		HTTPClient:    common.LoggingHttpClient(httpClient, gatewayName, clientOptions.Logger),
+		LeveledLogger: stripe.DefaultLeveledLogger,
+		LogLevel:      stripe.LogLevel,
+		Logger:        stripe.Logger,
+		URL:           common.OverrideBaseURL(stripe.APIURL, clientOptions.BaseURL),
+	})
 	return &StripeClient{
-		apiKey:     apiKey,
-		httpClient: httpClient,
+		apiKey:  apiKey,
+		backend: backend,
+		logger:  clientOptions.Logger,
+	}
+}
+
+// Capabilities describes the optional operations supported by Stripe
+func (client *StripeClient) Capabilities() sleet.Capabilities {
+	return sleet.Capabilities{
+		ContextSupport:   true,
+		Sale:             true,
+		Vault:            true,
+		TransactionQuery: true,
+		PartialCapture:   true,
+		PartialRefund:    true,
 	}
 }
 
@@ -57,18 +86,59 @@ func (client *StripeClient) Authorize(request *sleet.AuthorizationRequest) (*sle
 
 // AuthorizeWithContext a transaction for specified amount using stripe-go library
 func (client *StripeClient) AuthorizeWithContext(ctx context.Context, request *sleet.AuthorizationRequest) (*sleet.AuthorizationResponse, error) {
-	chargeClient := charge.Client{B: stripe.GetBackend(stripe.APIBackend), Key: client.apiKey}
-	charge, err := chargeClient.New(buildChargeParams(ctx, request))
+	resp, err := client.createCharge(buildChargeParams(ctx, request))
 	if err != nil {
-		return &sleet.AuthorizationResponse{Success: false, TransactionReference: "", AvsResult: sleet.AVSResponseUnknown, CvvResult: sleet.CVVResponseUnknown, ErrorCode: err.Error()}, err
+		return nil, common.OperationError(gatewayName, sleet.OperationAuthorize, err)
 	}
+	return resp, nil
+}
+
+// Sale creates a charge which is captured immediately
+func (client *StripeClient) Sale(request *sleet.SaleRequest) (*sleet.SaleResponse, error) {
+	return client.SaleWithContext(context.TODO(), request)
+}
+
+// SaleWithContext creates a charge which is captured immediately
+func (client *StripeClient) SaleWithContext(ctx context.Context, request *sleet.SaleRequest) (*sleet.SaleResponse, error) {
+	resp, err := client.createCharge(buildSaleParams(ctx, request))
+	if err != nil {
+		return nil, common.OperationError(gatewayName, sleet.OperationSale, err)
+	}
+	return &sleet.SaleResponse{AuthorizationResponse: *resp}, nil
+}
+
+func (client *StripeClient) createCharge(params *stripe.ChargeParams) (*sleet.AuthorizationResponse, error) {
+	chargeClient := charge.Client{B: client.backend, Key: client.apiKey}
+	charge, err := chargeClient.New(params)
+	client.logResult(params.Context, charge, err)
+	if err != nil {
+		statusCode, message, ok := translateErrorDetails(err)
+		if !ok {
+			return nil, common.ClassifyError(gatewayName, err)
+		}
+		resultType := translateErrorResultType(err)
+		return &sleet.AuthorizationResponse{
+			Success:              false,
+			TransactionReference: "",
+			AvsResult:            sleet.AVSResponseUnknown,
+			CvvResult:            sleet.CVVResponseUnknown,
+			ErrorCode:            err.Error(),
+			Message:              message,
+			ResultType:           resultType,
+			DeclineReason:        translateErrorDeclineReason(err, resultType),
+			StatusCode:           statusCode,
+		}, nil
+	}
+	avsResultRaw, cvvResultRaw := cardChecks(charge)
 	return &sleet.AuthorizationResponse{
 		Success:              true,
 		TransactionReference: charge.ID,
 		AvsResult:            sleet.AVSresponseZipMatchAddressMatch, // TODO: Add translator
 		CvvResult:            sleet.CVVResponseMatch,                // TODO: Add translator
-		AvsResultRaw:         string(charge.Source.Card.AddressLine1Check),
-		CvvResultRaw:         string(charge.Source.Card.CVCCheck)}, nil
+		AvsResultRaw:         avsResultRaw,
+		CvvResultRaw:         cvvResultRaw,
+		ResultType:           sleet.ResultTypeSuccess,
+	}, nil
 }
 
 // Capture an authorized transaction by charge ID
@@ -78,12 +148,28 @@ func (client *StripeClient) Capture(request *sleet.CaptureRequest) (*sleet.Captu
 
 // CaptureWithContext an authorized transaction by charge ID
 func (client *StripeClient) CaptureWithContext(ctx context.Context, request *sleet.CaptureRequest) (*sleet.CaptureResponse, error) {
-	chargeClient := charge.Client{B: stripe.GetBackend(stripe.APIBackend), Key: client.apiKey}
+	chargeClient := charge.Client{B: client.backend, Key: client.apiKey}
 	capture, err := chargeClient.Capture(request.TransactionReference, buildCaptureParams(ctx, request))
+	client.logResult(ctx, capture, err)
 	if err != nil {
-		return &sleet.CaptureResponse{Success: false, ErrorCode: common.SPtr(err.Error())}, nil
+		statusCode, message, ok := translateErrorDetails(err)
+		if !ok {
+			return nil, common.OperationError(gatewayName, sleet.OperationCapture, common.ClassifyError(gatewayName, err))
+		}
+		return &sleet.CaptureResponse{
+			Success:    false,
+			ErrorCode:  common.SPtr(err.Error()),
+			Message:    message,
+			ResultType: translateErrorResultType(err),
+			StatusCode: statusCode,
+		}, nil
 	}
-	return &sleet.CaptureResponse{Success: true, TransactionReference: capture.ID}, nil
+	return &sleet.CaptureResponse{
+		Success:              true,
+		TransactionReference: capture.ID,
+		ResultType:           sleet.ResultTypeSuccess,
+		Amount:               translateCapturedAmount(capture),
+	}, nil
 }
 
 // Refund a captured transaction with amount and charge ID
@@ -93,12 +179,29 @@ func (client *StripeClient) Refund(request *sleet.RefundRequest) (*sleet.RefundR
 
 // RefundWithContext a captured transaction with amount and charge ID
 func (client *StripeClient) RefundWithContext(ctx context.Context, request *sleet.RefundRequest) (*sleet.RefundResponse, error) {
-	refundClient := refund.Client{B: stripe.GetBackend(stripe.APIBackend), Key: client.apiKey}
+	refundClient := refund.Client{B: client.backend, Key: client.apiKey}
 	refund, err := refundClient.New(buildRefundParams(ctx, request))
+	client.logResult(ctx, refund, err)
 	if err != nil {
-		return &sleet.RefundResponse{Success: false, ErrorCode: common.SPtr(err.Error())}, nil
+		statusCode, message, ok := translateErrorDetails(err)
+		if !ok {
+			return nil, common.OperationError(gatewayName, sleet.OperationRefund, common.ClassifyError(gatewayName, err))
+		}
+		return &sleet.RefundResponse{
+			Success:    false,
+			ErrorCode:  common.SPtr(err.Error()),
+			Message:    message,
+			ResultType: translateErrorResultType(err),
+			StatusCode: statusCode,
+		}, nil
 	}
-	return &sleet.RefundResponse{Success: true, TransactionReference: refund.ID}, nil
+	return &sleet.RefundResponse{
+		Success:              true,
+		TransactionReference: refund.ID,
+		ResultType:           sleet.ResultTypeSuccess,
+		Amount:               &sleet.Amount{Amount: refund.Amount, Currency: strings.ToUpper(string(refund.Currency))},
+		Metadata:             translateRefundMetadata(refund),
+	}, nil
 }
 
 // Void an authorized transaction with charge ID
@@ -108,10 +211,63 @@ func (client *StripeClient) Void(request *sleet.VoidRequest) (*sleet.VoidRespons
 
 // VoidWithContext an authorized transaction with charge ID
 func (client *StripeClient) VoidWithContext(ctx context.Context, request *sleet.VoidRequest) (*sleet.VoidResponse, error) {
-	voidClient := refund.Client{B: stripe.GetBackend(stripe.APIBackend), Key: client.apiKey}
+	voidClient := refund.Client{B: client.backend, Key: client.apiKey}
 	void, err := voidClient.New(buildVoidParams(ctx, request))
+	client.logResult(ctx, void, err)
+	if err != nil {
+		statusCode, message, ok := translateErrorDetails(err)
+		if !ok {
+			return nil, common.OperationError(gatewayName, sleet.OperationVoid, common.ClassifyError(gatewayName, err))
+		}
+		return &sleet.VoidResponse{
+			Success:    false,
+			ErrorCode:  common.SPtr(err.Error()),
+			Message:    message,
+			ResultType: translateErrorResultType(err),
+			StatusCode: statusCode,
+		}, nil
+	}
+	return &sleet.VoidResponse{
+		Success:              true,
+		TransactionReference: void.ID,
+		ResultType:           sleet.ResultTypeSuccess,
+		Metadata:             translateRefundMetadata(void),
+	}, nil
+}
+
+// QueryTransaction retrieves a charge by charge ID
+func (client *StripeClient) QueryTransaction(request *sleet.TransactionQueryRequest) (*sleet.TransactionQueryResponse, error) {
+	return client.QueryTransactionWithContext(context.TODO(), request)
+}
+
+// QueryTransactionWithContext retrieves a charge by charge ID
+func (client *StripeClient) QueryTransactionWithContext(ctx context.Context, request *sleet.TransactionQueryRequest) (*sleet.TransactionQueryResponse, error) {
+	chargeClient := charge.Client{B: client.backend, Key: client.apiKey}
+	charge, err := chargeClient.Get(request.TransactionReference, &stripe.ChargeParams{Params: stripe.Params{Context: ctx}})
 	if err != nil {
-		return &sleet.VoidResponse{Success: false, ErrorCode: common.SPtr(err.Error())}, nil
+		if !isStripeError(err) {
+			return nil, common.OperationError(gatewayName, sleet.OperationQueryTransaction, common.ClassifyError(gatewayName, err))
+		}
+		return &sleet.TransactionQueryResponse{Success: false, ErrorCode: common.SPtr(err.Error())}, nil
+	}
+	return translateCharge(charge), nil
+}
+
+// logResult logs the status of the object Stripe responded with, or the error code and decline code of a failed request
+func (client *StripeClient) logResult(ctx context.Context, object interface{}, err error) {
+	if stripeErr, ok := err.(*stripe.Error); ok {
+		common.LogResult(ctx, client.logger, gatewayName, string(stripeErr.Code), "decline_code", string(stripeErr.DeclineCode))
+		return
+	}
+	if err != nil {
+		return
+	}
+	switch o := object.(type) {
+	case *stripe.Charge:
+		common.LogResult(ctx, client.logger, gatewayName, string(o.Status))
+	case *stripe.Refund:
+		common.LogResult(ctx, client.logger, gatewayName, string(o.Status))
+	case *stripe.PaymentIntent:
+		common.LogResult(ctx, client.logger, gatewayName, string(o.Status))
 	}
-	return &sleet.VoidResponse{Success: true, TransactionReference: void.ID}, nil
 }
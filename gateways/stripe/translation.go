@@ -0,0 +1,276 @@
+package stripe
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/stripe/stripe-go"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+)
+
+// Charge statuses, a charge is pending until the payment method confirms it
+const (
+	chargeStatusSucceeded = "succeeded"
+	chargeStatusFailed    = "failed"
+)
+
+// refundStatusMetadata is the metadata key of the status of the refund backing a refund or void
+const refundStatusMetadata = "refund_status"
+
+var errorResultTypeMap = map[stripe.ErrorType]sleet.ResultType{
+	stripe.ErrorTypeCard:           sleet.ResultTypePaymentError,
+	stripe.ErrorTypeInvalidRequest: sleet.ResultTypeAPIError,
+	stripe.ErrorTypeAuthentication: sleet.ResultTypeAPIError,
+	stripe.ErrorTypePermission:     sleet.ResultTypeAPIError,
+	stripe.ErrorTypeRateLimit:      sleet.ResultTypeServerError,
+	stripe.ErrorTypeAPI:            sleet.ResultTypeServerError,
+	stripe.ErrorTypeAPIConnection:  sleet.ResultTypeServerError,
+}
+
+// translateErrorResultType classifies an error returned by the Stripe library by its type. Card errors are payment
+// errors unless the card could not be processed, and errors without a known type fall back to their HTTP status.
+func translateErrorResultType(err error) sleet.ResultType {
+	var stripeError *stripe.Error
+	if !errors.As(err, &stripeError) {
+		return sleet.ResultTypeServerError
+	}
+	if stripeError.Code == stripe.ErrorCodeProcessingError {
+		return sleet.ResultTypeServerError
+	}
+	if resultType, ok := errorResultTypeMap[stripeError.Type]; ok {
+		return resultType
+	}
+	if resultType := common.ResultTypeFromHTTPStatus(stripeError.HTTPStatusCode); resultType != sleet.ResultTypeUnknownError {
+		return resultType
+	}
+	return sleet.ResultTypeAPIError
+}
+
+// declineReasonMap translates Stripe decline codes, and the error codes of card errors without a decline code, see:
+// https://stripe.com/docs/declines/codes
+var declineReasonMap = map[string]sleet.DeclineReason{
+	"authentication_required":         sleet.DeclineReasonAuthenticationRequired,
+	"call_issuer":                     sleet.DeclineReasonCallIssuer,
+	"card_not_supported":              sleet.DeclineReasonTransactionNotAllowed,
+	"card_velocity_exceeded":          sleet.DeclineReasonLimitExceeded,
+	"do_not_honor":                    sleet.DeclineReasonDoNotHonor,
+	"expired_card":                    sleet.DeclineReasonExpiredCard,
+	"fraudulent":                      sleet.DeclineReasonSuspectedFraud,
+	"generic_decline":                 sleet.DeclineReasonDoNotHonor,
+	"incorrect_cvc":                   sleet.DeclineReasonCVVFailure,
+	"incorrect_number":                sleet.DeclineReasonInvalidCardNumber,
+	"incorrect_zip":                   sleet.DeclineReasonAVSFailure,
+	"insufficient_funds":              sleet.DeclineReasonInsufficientFunds,
+	"invalid_account":                 sleet.DeclineReasonInvalidCardNumber,
+	"invalid_cvc":                     sleet.DeclineReasonCVVFailure,
+	"invalid_expiry_month":            sleet.DeclineReasonInvalidExpirationDate,
+	"invalid_expiry_year":             sleet.DeclineReasonInvalidExpirationDate,
+	"invalid_number":                  sleet.DeclineReasonInvalidCardNumber,
+	"issuer_not_available":            sleet.DeclineReasonIssuerUnavailable,
+	"lost_card":                       sleet.DeclineReasonLostOrStolenCard,
+	"merchant_blacklist":              sleet.DeclineReasonSuspectedFraud,
+	"not_permitted":                   sleet.DeclineReasonTransactionNotAllowed,
+	"pickup_card":                     sleet.DeclineReasonRestrictedCard,
+	"restricted_card":                 sleet.DeclineReasonRestrictedCard,
+	"stolen_card":                     sleet.DeclineReasonLostOrStolenCard,
+	"transaction_not_allowed":         sleet.DeclineReasonTransactionNotAllowed,
+	"try_again_later":                 sleet.DeclineReasonTryAgainLater,
+	"withdrawal_count_limit_exceeded": sleet.DeclineReasonLimitExceeded,
+}
+
+// translateDeclineReason converts the decline code of a Stripe error, or its error code when the issuer didn't give
+// one, to a Sleet decline reason.
+func translateDeclineReason(stripeError *stripe.Error, resultType sleet.ResultType) sleet.DeclineReason {
+	code := ""
+	if stripeError != nil {
+		code = string(stripeError.DeclineCode)
+		if code == "" {
+			code = string(stripeError.Code)
+		}
+	}
+	return common.TranslateDeclineReason(declineReasonMap, code, resultType)
+}
+
+// translateErrorDeclineReason converts an error returned by the Stripe library to a Sleet decline reason.
+func translateErrorDeclineReason(err error, resultType sleet.ResultType) sleet.DeclineReason {
+	var stripeError *stripe.Error
+	errors.As(err, &stripeError)
+	return translateDeclineReason(stripeError, resultType)
+}
+
+// translateErrorDetails returns the HTTP status and message of an error returned by the Stripe library, and whether
+// Stripe responded with it
+func translateErrorDetails(err error) (int, string, bool) {
+	var stripeError *stripe.Error
+	if !errors.As(err, &stripeError) {
+		return 0, "", false
+	}
+	return stripeError.HTTPStatusCode, stripeError.Msg, true
+}
+
+// isStripeError is true for errors Stripe responded with, rather than errors sending the request or reading its response
+func isStripeError(err error) bool {
+	var stripeError *stripe.Error
+	return errors.As(err, &stripeError)
+}
+
+// translateCapturedAmount returns the amount captured on a charge. Stripe refunds the uncaptured remainder of a
+// partial capture, so it is what was authorized less what has been refunded.
+func translateCapturedAmount(charge *stripe.Charge) *sleet.Amount {
+	return &sleet.Amount{
+		Amount:   charge.Amount - charge.AmountRefunded,
+		Currency: strings.ToUpper(string(charge.Currency)),
+	}
+}
+
+// translateRefundMetadata keeps the status of a refund, which may still be pending when it is created
+func translateRefundMetadata(refund *stripe.Refund) map[string]string {
+	return map[string]string{refundStatusMetadata: string(refund.Status)}
+}
+
+var brandMap = map[stripe.PaymentMethodCardBrand]sleet.CreditCardNetwork{
+	stripe.PaymentMethodCardBrandVisa:       sleet.CreditCardNetworkVisa,
+	stripe.PaymentMethodCardBrandMastercard: sleet.CreditCardNetworkMastercard,
+	stripe.PaymentMethodCardBrandAmex:       sleet.CreditCardNetworkAmex,
+	stripe.PaymentMethodCardBrandDiscover:   sleet.CreditCardNetworkDiscover,
+	stripe.PaymentMethodCardBrandJCB:        sleet.CreditCardNetworkJcb,
+	stripe.PaymentMethodCardBrandUnionpay:   sleet.CreditCardNetworkUnionpay,
+}
+
+// translateBrand converts a Stripe card brand to its equivalent Sleet network.
+func translateBrand(brand stripe.PaymentMethodCardBrand) sleet.CreditCardNetwork {
+	network, ok := brandMap[brand]
+	if !ok {
+		return sleet.CreditCardNetworkUnknown
+	}
+	return network
+}
+
+// cardChecks returns the raw address line and CVC checks of a charge.
+// Charges of raw cards have a card source while charges of payment methods only have payment method details.
+func cardChecks(charge *stripe.Charge) (string, string) {
+	if charge.Source != nil && charge.Source.Card != nil {
+		return string(charge.Source.Card.AddressLine1Check), string(charge.Source.Card.CVCCheck)
+	}
+	if details := charge.PaymentMethodDetails; details != nil && details.Card != nil && details.Card.Checks != nil {
+		return string(details.Card.Checks.AddressLine1Check), string(details.Card.Checks.CVCCheck)
+	}
+	return "", ""
+}
+
+// translateCharge converts a Stripe charge to a Sleet transaction query response.
+// Stripe releases uncaptured charges by refunding them, so a refunded uncaptured charge is voided.
+func translateCharge(charge *stripe.Charge) *sleet.TransactionQueryResponse {
+	response := &sleet.TransactionQueryResponse{
+		Success:              true,
+		TransactionReference: charge.ID,
+		State:                translateChargeState(charge),
+		StateRaw:             charge.Status,
+		Currency:             strings.ToUpper(string(charge.Currency)),
+		AuthorizedAmount:     charge.Amount,
+	}
+	if charge.Captured {
+		response.CapturedAmount = charge.Amount
+		response.RefundedAmount = charge.AmountRefunded
+	}
+	if details := charge.PaymentMethodDetails; details != nil && details.Card != nil {
+		response.Last4 = details.Card.Last4
+		response.Network = translateBrand(details.Card.Brand)
+	}
+	if charge.Created != 0 {
+		created := time.Unix(charge.Created, 0).UTC()
+		response.CreatedAt = &created
+	}
+	return response
+}
+
+func translateChargeState(charge *stripe.Charge) sleet.TransactionState {
+	switch {
+	case charge.Status == chargeStatusFailed:
+		return sleet.TransactionStateDeclined
+	case charge.Status != chargeStatusSucceeded:
+		return sleet.TransactionStateUnknown
+	case !charge.Captured && charge.Refunded:
+		return sleet.TransactionStateVoided
+	case !charge.Captured:
+		return sleet.TransactionStateAuthorized
+	case charge.AmountRefunded > 0:
+		return sleet.TransactionStateRefunded
+	default:
+		return sleet.TransactionStateCaptured
+	}
+}
+
+// translateAvs combines the address line and postal code checks of a card
+func translateAvs(line1Check, postalCodeCheck stripe.CardVerification) sleet.AVSResponse {
+	line1Pass := line1Check == stripe.CardVerificationPass
+	postalPass := postalCodeCheck == stripe.CardVerificationPass
+	line1Fail := line1Check == stripe.CardVerificationFail
+	postalFail := postalCodeCheck == stripe.CardVerificationFail
+	switch {
+	case line1Pass && postalPass:
+		return sleet.AVSresponseZipMatchAddressMatch
+	case line1Pass && postalFail:
+		return sleet.AVSResponseZipNoMatchAddressMatch
+	case line1Fail && postalPass:
+		return sleet.AVSResponseZip5MatchAddressNoMatch
+	case line1Fail && postalFail:
+		return sleet.AVSResponseNoMatch
+	case line1Pass:
+		return sleet.AVSResponseZipUnverifiedAddressMatch
+	case postalPass:
+		return sleet.AVSResponseZipMatchAddressUnverified
+	case line1Check == stripe.CardVerificationUnavailable && postalCodeCheck == stripe.CardVerificationUnavailable:
+		return sleet.AVSResponseUnsupported
+	case line1Check == "" && postalCodeCheck == "":
+		return sleet.AVSResponseSkipped
+	}
+	return sleet.AVSResponseUnknown
+}
+
+var cvvMap = map[stripe.CardVerification]sleet.CVVResponse{
+	stripe.CardVerificationPass:        sleet.CVVResponseMatch,
+	stripe.CardVerificationFail:        sleet.CVVResponseNoMatch,
+	stripe.CardVerificationUnavailable: sleet.CVVResponseUnsupported,
+	stripe.CardVerificationUnchecked:   sleet.CVVResponseNotProcessed,
+	"":                                 sleet.CVVResponseSkipped,
+}
+
+func translateCvv(cvcCheck stripe.CardVerification) sleet.CVVResponse {
+	cvv, ok := cvvMap[cvcCheck]
+	if !ok {
+		return sleet.CVVResponseUnknown
+	}
+	return cvv
+}
+
+// translateSetupIntent converts a confirmed SetupIntent to a Sleet verification response.
+// The card checks are only returned if the payment method was expanded.
+func translateSetupIntent(setupIntent *stripe.SetupIntent) *sleet.VerificationResponse {
+	response := &sleet.VerificationResponse{AuthorizationResponse: sleet.AuthorizationResponse{
+		Success:              setupIntent.Status == stripe.SetupIntentStatusSucceeded,
+		TransactionReference: setupIntent.ID,
+		Response:             string(setupIntent.Status),
+		AvsResult:            sleet.AVSResponseSkipped,
+		CvvResult:            sleet.CVVResponseSkipped,
+		ResultType:           sleet.ResultTypeSuccess,
+	}}
+	if !response.Success {
+		// the setup intent requires another payment method or an action from the customer
+		response.ErrorCode = string(setupIntent.Status)
+		response.ResultType = sleet.ResultTypePaymentError
+		response.DeclineReason = translateDeclineReason(setupIntent.LastSetupError, response.ResultType)
+	}
+	if pm := setupIntent.PaymentMethod; pm != nil && pm.Card != nil && pm.Card.Checks != nil {
+		checks := pm.Card.Checks
+		response.AvsResult = translateAvs(checks.AddressLine1Check, checks.AddressPostalCodeCheck)
+		response.CvvResult = translateCvv(checks.CVCCheck)
+		response.AvsResultRaw = fmt.Sprintf("%s:%s", checks.AddressLine1Check, checks.AddressPostalCodeCheck)
+		response.CvvResultRaw = string(checks.CVCCheck)
+	}
+	return response
+}
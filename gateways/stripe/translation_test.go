@@ -0,0 +1,209 @@
+//go:build unit
+// +build unit
+
+package stripe
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+	"github.com/stripe/stripe-go"
+
+	"github.com/BoltApp/sleet"
+)
+
+func TestTranslateCharge(t *testing.T) {
+	raw, err := ioutil.ReadFile("testdata/charges_success.json")
+	if err != nil {
+		t.Fatalf("error reading charge: %s", err)
+	}
+	var authorized stripe.Charge
+	if err := json.Unmarshal(raw, &authorized); err != nil {
+		t.Fatalf("error unmarshalling charge: %s", err)
+	}
+	created := time.Unix(1574003063, 0).UTC()
+
+	refunded := authorized
+	refunded.Captured = true
+	refunded.AmountRefunded = 50
+
+	voided := authorized
+	voided.Refunded = true
+	voided.AmountRefunded = authorized.Amount
+
+	cases := []struct {
+		label string
+		in    *stripe.Charge
+		want  *sleet.TransactionQueryResponse
+	}{
+		{
+			"Uncaptured charge",
+			&authorized,
+			&sleet.TransactionQueryResponse{
+				Success:              true,
+				TransactionReference: authorized.ID,
+				State:                sleet.TransactionStateAuthorized,
+				StateRaw:             "succeeded",
+				Currency:             "USD",
+				AuthorizedAmount:     authorized.Amount,
+				Last4:                "1111",
+				Network:              sleet.CreditCardNetworkVisa,
+				CreatedAt:            &created,
+			},
+		},
+		{
+			"Partially refunded charge",
+			&refunded,
+			&sleet.TransactionQueryResponse{
+				Success:              true,
+				TransactionReference: authorized.ID,
+				State:                sleet.TransactionStateRefunded,
+				StateRaw:             "succeeded",
+				Currency:             "USD",
+				AuthorizedAmount:     authorized.Amount,
+				CapturedAmount:       authorized.Amount,
+				RefundedAmount:       50,
+				Last4:                "1111",
+				Network:              sleet.CreditCardNetworkVisa,
+				CreatedAt:            &created,
+			},
+		},
+		{
+			"Released uncaptured charge",
+			&voided,
+			&sleet.TransactionQueryResponse{
+				Success:              true,
+				TransactionReference: authorized.ID,
+				State:                sleet.TransactionStateVoided,
+				StateRaw:             "succeeded",
+				Currency:             "USD",
+				AuthorizedAmount:     authorized.Amount,
+				Last4:                "1111",
+				Network:              sleet.CreditCardNetworkVisa,
+				CreatedAt:            &created,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			got := translateCharge(c.in)
+			if diff := deep.Equal(got, c.want); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestTranslateSetupIntent(t *testing.T) {
+	raw, err := ioutil.ReadFile("testdata/setup_intents_success.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var setupIntent stripe.SetupIntent
+	if err := json.Unmarshal(raw, &setupIntent); err != nil {
+		t.Fatal(err)
+	}
+
+	want := &sleet.VerificationResponse{AuthorizationResponse: sleet.AuthorizationResponse{
+		Success:              true,
+		TransactionReference: "seti_1NyqXb2eZvKYlo2C3d8fJ0aB",
+		Response:             "succeeded",
+		AvsResult:            sleet.AVSResponseZipNoMatchAddressMatch,
+		CvvResult:            sleet.CVVResponseMatch,
+		AvsResultRaw:         "pass:fail",
+		CvvResultRaw:         "pass",
+		ResultType:           sleet.ResultTypeSuccess,
+	}}
+
+	got := translateSetupIntent(&setupIntent)
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestTranslateErrorResultType(t *testing.T) {
+	cases := []struct {
+		label string
+		err   error
+		want  sleet.ResultType
+	}{
+		{"Card Declined", &stripe.Error{Type: stripe.ErrorTypeCard, Code: stripe.ErrorCodeCardDeclined, HTTPStatusCode: 402}, sleet.ResultTypePaymentError},
+		{"Expired Card", &stripe.Error{Type: stripe.ErrorTypeCard, Code: stripe.ErrorCodeExpiredCard, HTTPStatusCode: 402}, sleet.ResultTypePaymentError},
+		{"Processing Error", &stripe.Error{Type: stripe.ErrorTypeCard, Code: stripe.ErrorCodeProcessingError, HTTPStatusCode: 402}, sleet.ResultTypeServerError},
+		{"Invalid Request", &stripe.Error{Type: stripe.ErrorTypeInvalidRequest, HTTPStatusCode: 400}, sleet.ResultTypeAPIError},
+		{"Authentication", &stripe.Error{Type: stripe.ErrorTypeAuthentication, HTTPStatusCode: 401}, sleet.ResultTypeAPIError},
+		{"Rate Limit", &stripe.Error{Type: stripe.ErrorTypeRateLimit, HTTPStatusCode: 429}, sleet.ResultTypeServerError},
+		{"API Error", &stripe.Error{Type: stripe.ErrorTypeAPI, HTTPStatusCode: 500}, sleet.ResultTypeServerError},
+		{"Untyped", &stripe.Error{HTTPStatusCode: 409}, sleet.ResultTypeAPIError},
+		{"Wrapped", fmt.Errorf("capture: %w", &stripe.Error{Type: stripe.ErrorTypeCard}), sleet.ResultTypePaymentError},
+		{"Network", errors.New("connection reset by peer"), sleet.ResultTypeServerError},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			if got := translateErrorResultType(c.err); got != c.want {
+				t.Errorf("expected %s, got %s", c.want, got)
+			}
+		})
+	}
+}
+
+func TestTranslateErrorDeclineReason(t *testing.T) {
+	cases := []struct {
+		label string
+		err   error
+		want  sleet.DeclineReason
+	}{
+		{"Insufficient Funds", &stripe.Error{Type: stripe.ErrorTypeCard, Code: stripe.ErrorCodeCardDeclined, DeclineCode: "insufficient_funds"}, sleet.DeclineReasonInsufficientFunds},
+		{"Stolen Card", &stripe.Error{Type: stripe.ErrorTypeCard, Code: stripe.ErrorCodeCardDeclined, DeclineCode: "stolen_card"}, sleet.DeclineReasonLostOrStolenCard},
+		{"Expired Card Without Decline Code", &stripe.Error{Type: stripe.ErrorTypeCard, Code: stripe.ErrorCodeExpiredCard}, sleet.DeclineReasonExpiredCard},
+		{"Untranslated Decline Code", &stripe.Error{Type: stripe.ErrorTypeCard, Code: stripe.ErrorCodeCardDeclined, DeclineCode: "new_account_information_available"}, sleet.DeclineReasonUnknown},
+		{"Wrapped", fmt.Errorf("charge: %w", &stripe.Error{Type: stripe.ErrorTypeCard, DeclineCode: "do_not_honor"}), sleet.DeclineReasonDoNotHonor},
+		{"Invalid Request", &stripe.Error{Type: stripe.ErrorTypeInvalidRequest, Code: stripe.ErrorCodeParameterMissing, HTTPStatusCode: 400}, ""},
+		{"Network", errors.New("connection reset by peer"), ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			if got := translateErrorDeclineReason(c.err, translateErrorResultType(c.err)); got != c.want {
+				t.Errorf("expected %q, got %q", c.want, got)
+			}
+		})
+	}
+}
+
+func TestTranslateErrorDetails(t *testing.T) {
+	cases := []struct {
+		label          string
+		err            error
+		wantStatusCode int
+		wantMessage    string
+	}{
+		{"Card Declined", &stripe.Error{Type: stripe.ErrorTypeCard, HTTPStatusCode: 402, Msg: "Your card was declined."}, 402, "Your card was declined."},
+		{"Wrapped", fmt.Errorf("refund: %w", &stripe.Error{HTTPStatusCode: 400, Msg: "Charge has already been refunded."}), 400, "Charge has already been refunded."},
+		{"Network", errors.New("connection reset by peer"), 0, ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			statusCode, message, ok := translateErrorDetails(c.err)
+			if statusCode != c.wantStatusCode || message != c.wantMessage || ok != (c.wantStatusCode != 0) {
+				t.Errorf("expected %d %q, got %d %q %t", c.wantStatusCode, c.wantMessage, statusCode, message, ok)
+			}
+		})
+	}
+}
+
+func TestTranslateCapturedAmount(t *testing.T) {
+	charge := &stripe.Charge{Amount: 1000, AmountRefunded: 250, Currency: stripe.CurrencyUSD}
+	want := &sleet.Amount{Amount: 750, Currency: "USD"}
+	if diff := deep.Equal(translateCapturedAmount(charge), want); diff != nil {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,139 @@
+package stripe
+
+import (
+	"context"
+	"errors"
+
+	"github.com/stripe/stripe-go"
+	"github.com/stripe/stripe-go/customer"
+	"github.com/stripe/stripe-go/paymentmethod"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+)
+
+// shopperReferenceMetadata is the customer metadata key holding the merchant's shopper reference
+const shopperReferenceMetadata = "shopper_reference"
+
+// errTokenRequired is returned for a stored payment method without the ID of its Stripe payment method
+var errTokenRequired = errors.New("StoredPaymentMethod given to the request has no Token")
+
+var (
+	// assert vault interface
+	_ sleet.VaultWithContext = &StripeClient{}
+)
+
+// StorePaymentMethod creates a card payment method and attaches it to a customer, creating one if none is given
+func (client *StripeClient) StorePaymentMethod(request *sleet.StorePaymentMethodRequest) (*sleet.StorePaymentMethodResponse, error) {
+	return client.StorePaymentMethodWithContext(context.TODO(), request)
+}
+
+// StorePaymentMethodWithContext creates a card payment method and attaches it to a customer, creating one if none is given
+func (client *StripeClient) StorePaymentMethodWithContext(ctx context.Context, request *sleet.StorePaymentMethodRequest) (*sleet.StorePaymentMethodResponse, error) {
+	paymentMethodClient := paymentmethod.Client{B: client.backend, Key: client.apiKey}
+	paymentMethod, err := paymentMethodClient.New(buildPaymentMethodParams(ctx, request))
+	if err != nil {
+		if !isStripeError(err) {
+			return nil, common.OperationError(gatewayName, sleet.OperationStorePaymentMethod, common.ClassifyError(gatewayName, err))
+		}
+		return &sleet.StorePaymentMethodResponse{Success: false, ErrorCode: common.SPtr(err.Error())}, nil
+	}
+
+	customerID := request.CustomerReference
+	if customerID == "" {
+		// the customer is created with the payment method attached, so it isn't created if the card is declined
+		customerClient := customer.Client{B: client.backend, Key: client.apiKey}
+		newCustomer, err := customerClient.New(buildCustomerParams(ctx, request, paymentMethod.ID))
+		if err != nil {
+			if !isStripeError(err) {
+				return nil, common.OperationError(gatewayName, sleet.OperationStorePaymentMethod, common.ClassifyError(gatewayName, err))
+			}
+			return &sleet.StorePaymentMethodResponse{Success: false, ErrorCode: common.SPtr(err.Error())}, nil
+		}
+		return &sleet.StorePaymentMethodResponse{
+			Success: true,
+			StoredPaymentMethod: &sleet.StoredPaymentMethod{
+				CustomerReference: newCustomer.ID,
+				Token:             paymentMethod.ID,
+			},
+		}, nil
+	}
+
+	paymentMethod, err = paymentMethodClient.Attach(paymentMethod.ID, buildAttachParams(ctx, customerID))
+	if err != nil {
+		if !isStripeError(err) {
+			return nil, common.OperationError(gatewayName, sleet.OperationStorePaymentMethod, common.ClassifyError(gatewayName, err))
+		}
+		return &sleet.StorePaymentMethodResponse{Success: false, ErrorCode: common.SPtr(err.Error())}, nil
+	}
+	return &sleet.StorePaymentMethodResponse{
+		Success: true,
+		StoredPaymentMethod: &sleet.StoredPaymentMethod{
+			CustomerReference: customerID,
+			Token:             paymentMethod.ID,
+		},
+	}, nil
+}
+
+// GetPaymentMethod retrieves a card payment method
+func (client *StripeClient) GetPaymentMethod(request *sleet.GetPaymentMethodRequest) (*sleet.GetPaymentMethodResponse, error) {
+	return client.GetPaymentMethodWithContext(context.TODO(), request)
+}
+
+// GetPaymentMethodWithContext retrieves a card payment method
+func (client *StripeClient) GetPaymentMethodWithContext(ctx context.Context, request *sleet.GetPaymentMethodRequest) (*sleet.GetPaymentMethodResponse, error) {
+	if request.StoredPaymentMethod.Token == "" {
+		return nil, common.OperationError(gatewayName, sleet.OperationGetPaymentMethod, errTokenRequired)
+	}
+	paymentMethodClient := paymentmethod.Client{B: client.backend, Key: client.apiKey}
+	paymentMethod, err := paymentMethodClient.Get(request.StoredPaymentMethod.Token, &stripe.PaymentMethodParams{
+		Params: stripe.Params{Context: ctx},
+	})
+	if err != nil {
+		if !isStripeError(err) {
+			return nil, common.OperationError(gatewayName, sleet.OperationGetPaymentMethod, common.ClassifyError(gatewayName, err))
+		}
+		return &sleet.GetPaymentMethodResponse{Success: false, ErrorCode: common.SPtr(err.Error())}, nil
+	}
+
+	response := &sleet.GetPaymentMethodResponse{
+		Success: true,
+		StoredPaymentMethod: &sleet.StoredPaymentMethod{
+			CustomerReference: request.StoredPaymentMethod.CustomerReference,
+			Token:             paymentMethod.ID,
+		},
+	}
+	if paymentMethod.Customer != nil {
+		response.StoredPaymentMethod.CustomerReference = paymentMethod.Customer.ID
+	}
+	if paymentMethod.Card != nil {
+		response.Last4 = paymentMethod.Card.Last4
+		response.ExpirationMonth = int(paymentMethod.Card.ExpMonth)
+		response.ExpirationYear = int(paymentMethod.Card.ExpYear)
+		response.Network = translateBrand(paymentMethod.Card.Brand)
+	}
+	return response, nil
+}
+
+// DeletePaymentMethod detaches a payment method from its customer, it cannot be used again
+func (client *StripeClient) DeletePaymentMethod(request *sleet.DeletePaymentMethodRequest) (*sleet.DeletePaymentMethodResponse, error) {
+	return client.DeletePaymentMethodWithContext(context.TODO(), request)
+}
+
+// DeletePaymentMethodWithContext detaches a payment method from its customer, it cannot be used again
+func (client *StripeClient) DeletePaymentMethodWithContext(ctx context.Context, request *sleet.DeletePaymentMethodRequest) (*sleet.DeletePaymentMethodResponse, error) {
+	if request.StoredPaymentMethod.Token == "" {
+		return nil, common.OperationError(gatewayName, sleet.OperationDeletePaymentMethod, errTokenRequired)
+	}
+	paymentMethodClient := paymentmethod.Client{B: client.backend, Key: client.apiKey}
+	_, err := paymentMethodClient.Detach(request.StoredPaymentMethod.Token, &stripe.PaymentMethodDetachParams{
+		Params: stripe.Params{Context: ctx},
+	})
+	if err != nil {
+		if !isStripeError(err) {
+			return nil, common.OperationError(gatewayName, sleet.OperationDeletePaymentMethod, common.ClassifyError(gatewayName, err))
+		}
+		return &sleet.DeletePaymentMethodResponse{Success: false, ErrorCode: common.SPtr(err.Error())}, nil
+	}
+	return &sleet.DeletePaymentMethodResponse{Success: true}, nil
+}
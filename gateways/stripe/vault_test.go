@@ -0,0 +1,109 @@
+//go:build unit
+// +build unit
+
+package stripe
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-test/deep"
+
+	"github.com/BoltApp/sleet"
+	sleet_testing "github.com/BoltApp/sleet/testing"
+)
+
+// vaultServer answers the Stripe endpoints used by the vault and records the requests it receives
+func vaultServer(t *testing.T, requests *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("Error parsing form: %v", err)
+		}
+		*requests = append(*requests, r.Method+" "+r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/payment_methods":
+			w.Write([]byte(`{"id":"pm_1","object":"payment_method"}`))
+		case "/v1/customers":
+			if r.PostForm.Get("payment_method") != "pm_1" {
+				t.Errorf("Customer created with payment_method %q, want pm_1", r.PostForm.Get("payment_method"))
+			}
+			w.Write([]byte(`{"id":"cus_1","object":"customer"}`))
+		case "/v1/payment_methods/pm_1/attach":
+			w.Write([]byte(`{"id":"pm_1","object":"payment_method"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"type":"invalid_request_error","message":"not found"}}`))
+		}
+	}))
+}
+
+func TestStorePaymentMethod(t *testing.T) {
+	t.Run("New Customer", func(t *testing.T) {
+		var requests []string
+		server := vaultServer(t, &requests)
+		defer server.Close()
+		client := NewWithHTTPClient("sk_test", server.Client(), sleet.WithBaseURL(server.URL))
+
+		got, err := client.StorePaymentMethod(sleet_testing.BaseStorePaymentMethodRequest())
+		if err != nil {
+			t.Fatalf("Error thrown after storing payment method: %v", err)
+		}
+		want := &sleet.StorePaymentMethodResponse{
+			Success:             true,
+			StoredPaymentMethod: &sleet.StoredPaymentMethod{CustomerReference: "cus_1", Token: "pm_1"},
+		}
+		if diff := deep.Equal(got, want); diff != nil {
+			t.Error(diff)
+		}
+		// the customer is created with the payment method, so a declined attach can't leave an empty customer
+		if diff := deep.Equal(requests, []string{"POST /v1/payment_methods", "POST /v1/customers"}); diff != nil {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("Existing Customer", func(t *testing.T) {
+		var requests []string
+		server := vaultServer(t, &requests)
+		defer server.Close()
+		client := NewWithHTTPClient("sk_test", server.Client(), sleet.WithBaseURL(server.URL))
+
+		request := sleet_testing.BaseStorePaymentMethodRequest()
+		request.CustomerReference = "cus_2"
+		got, err := client.StorePaymentMethod(request)
+		if err != nil {
+			t.Fatalf("Error thrown after storing payment method: %v", err)
+		}
+		want := &sleet.StorePaymentMethodResponse{
+			Success:             true,
+			StoredPaymentMethod: &sleet.StoredPaymentMethod{CustomerReference: "cus_2", Token: "pm_1"},
+		}
+		if diff := deep.Equal(got, want); diff != nil {
+			t.Error(diff)
+		}
+		if diff := deep.Equal(requests, []string{"POST /v1/payment_methods", "POST /v1/payment_methods/pm_1/attach"}); diff != nil {
+			t.Error(diff)
+		}
+	})
+}
+
+func TestPaymentMethodRequiresToken(t *testing.T) {
+	var requests []string
+	server := vaultServer(t, &requests)
+	defer server.Close()
+	client := NewWithHTTPClient("sk_test", server.Client(), sleet.WithBaseURL(server.URL))
+
+	_, getErr := client.GetPaymentMethod(&sleet.GetPaymentMethodRequest{})
+	_, deleteErr := client.DeletePaymentMethod(&sleet.DeletePaymentMethodRequest{})
+	for _, err := range []error{getErr, deleteErr} {
+		var sleetErr *sleet.Error
+		if !errors.As(err, &sleetErr) || sleetErr.Kind != sleet.ErrorKindValidation {
+			t.Errorf("Expected a validation error, got %v", err)
+		}
+	}
+	if len(requests) != 0 {
+		t.Errorf("Expected no requests to Stripe, got %v", requests)
+	}
+}
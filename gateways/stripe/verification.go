@@ -0,0 +1,58 @@
+package stripe
+
+import (
+	"context"
+
+	"github.com/stripe/stripe-go/paymentmethod"
+	"github.com/stripe/stripe-go/setupintent"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+)
+
+var (
+	// assert verifier interface
+	_ sleet.VerifierWithContext = &StripeClient{}
+)
+
+// Verify validates a card by confirming a SetupIntent for it
+func (client *StripeClient) Verify(request *sleet.VerificationRequest) (*sleet.VerificationResponse, error) {
+	return client.VerifyWithContext(context.TODO(), request)
+}
+
+// VerifyWithContext validates a card by creating a payment method and confirming a SetupIntent for it, which has
+// Stripe check the card with its issuer without holding funds. Stored payment methods are verified with an
+// authorization which is voided.
+func (client *StripeClient) VerifyWithContext(ctx context.Context, request *sleet.VerificationRequest) (*sleet.VerificationResponse, error) {
+	if request.CreditCard == nil {
+		response, err := sleet.VerifyByAuthorization(ctx, client, request)
+		return response, common.OperationError(gatewayName, sleet.OperationVerify, err)
+	}
+
+	paymentMethodClient := paymentmethod.Client{B: client.backend, Key: client.apiKey}
+	paymentMethod, err := paymentMethodClient.New(buildVerificationPaymentMethodParams(ctx, request))
+	if err != nil {
+		if !isStripeError(err) {
+			return nil, common.OperationError(gatewayName, sleet.OperationVerify, common.ClassifyError(gatewayName, err))
+		}
+		resultType := translateErrorResultType(err)
+		return &sleet.VerificationResponse{AuthorizationResponse: sleet.AuthorizationResponse{
+			Success: false, AvsResult: sleet.AVSResponseUnknown, CvvResult: sleet.CVVResponseUnknown, ErrorCode: err.Error(),
+			ResultType: resultType, DeclineReason: translateErrorDeclineReason(err, resultType),
+		}}, nil
+	}
+
+	setupIntentClient := setupintent.Client{B: client.backend, Key: client.apiKey}
+	setupIntent, err := setupIntentClient.New(buildSetupIntentParams(ctx, paymentMethod.ID))
+	if err != nil {
+		if !isStripeError(err) {
+			return nil, common.OperationError(gatewayName, sleet.OperationVerify, common.ClassifyError(gatewayName, err))
+		}
+		resultType := translateErrorResultType(err)
+		return &sleet.VerificationResponse{AuthorizationResponse: sleet.AuthorizationResponse{
+			Success: false, AvsResult: sleet.AVSResponseUnknown, CvvResult: sleet.CVVResponseUnknown, ErrorCode: err.Error(),
+			ResultType: resultType, DeclineReason: translateErrorDeclineReason(err, resultType),
+		}}, nil
+	}
+	return translateSetupIntent(setupIntent), nil
+}
@@ -0,0 +1,125 @@
+package stripe
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/stripe/stripe-go"
+	"github.com/stripe/stripe-go/webhook"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/webhooks"
+)
+
+const (
+	signatureHeader = "Stripe-Signature"
+
+	eventTypeChargeSucceeded     = "charge.succeeded"
+	eventTypeChargeFailed        = "charge.failed"
+	eventTypeChargeCaptured      = "charge.captured"
+	eventTypeChargeRefunded      = "charge.refunded"
+	eventTypeChargeExpired       = "charge.expired"
+	eventTypeChargeDisputeCreate = "charge.dispute.created"
+)
+
+var (
+	// assert parser interface
+	_ webhooks.Parser = &WebhookParser{}
+)
+
+// WebhookParser verifies the Stripe-Signature header of webhook events and translates charge events
+type WebhookParser struct {
+	endpointSecret string
+}
+
+// NewWebhookParser creates a parser using the signing secret (whsec_...) of the webhook endpoint
+func NewWebhookParser(endpointSecret string) *WebhookParser {
+	return &WebhookParser{endpointSecret: endpointSecret}
+}
+
+// Parse verifies the signature and timestamp of the event and translates it into a webhook event
+func (p *WebhookParser) Parse(header http.Header, body []byte) ([]webhooks.WebhookEvent, error) {
+	event, err := webhook.ConstructEvent(body, header.Get(signatureHeader), p.endpointSecret)
+	switch err {
+	case nil:
+	case webhook.ErrNotSigned:
+		return nil, webhooks.ErrMissingSignature
+	case webhook.ErrInvalidHeader, webhook.ErrNoValidSignature, webhook.ErrTooOld:
+		return nil, webhooks.ErrInvalidSignature
+	default:
+		return nil, err
+	}
+
+	translated, err := translateEvent(event)
+	if err != nil {
+		return nil, err
+	}
+	translated.RawPayload = body
+	return []webhooks.WebhookEvent{*translated}, nil
+}
+
+func translateEvent(event stripe.Event) (*webhooks.WebhookEvent, error) {
+	translated := &webhooks.WebhookEvent{
+		Kind:         webhooks.EventKindUnknown,
+		PsPEventType: event.Type,
+		EventID:      event.ID,
+	}
+	if event.Data == nil {
+		return translated, nil
+	}
+
+	switch event.Type {
+	case eventTypeChargeSucceeded, eventTypeChargeFailed, eventTypeChargeCaptured,
+		eventTypeChargeRefunded, eventTypeChargeExpired:
+		var charge stripe.Charge
+		if err := json.Unmarshal(event.Data.Raw, &charge); err != nil {
+			return nil, err
+		}
+		translated.TransactionReference = charge.ID
+		translated.Amount = buildAmount(charge.Amount, charge.Currency)
+		translated.Success = event.Type != eventTypeChargeFailed
+		switch event.Type {
+		case eventTypeChargeSucceeded, eventTypeChargeFailed:
+			translated.Kind = webhooks.EventKindAuthorization
+			if charge.Captured {
+				translated.Kind = webhooks.EventKindSale
+			}
+		case eventTypeChargeCaptured:
+			translated.Kind = webhooks.EventKindCapture
+		case eventTypeChargeRefunded:
+			// sleet voids an uncaptured charge by refunding it
+			translated.Kind = webhooks.EventKindRefund
+			if !charge.Captured {
+				translated.Kind = webhooks.EventKindVoid
+			}
+			translated.Amount = buildAmount(charge.AmountRefunded, charge.Currency)
+		case eventTypeChargeExpired:
+			translated.Kind = webhooks.EventKindVoid
+		}
+	case eventTypeChargeDisputeCreate:
+		var dispute stripe.Dispute
+		if err := json.Unmarshal(event.Data.Raw, &dispute); err != nil {
+			return nil, err
+		}
+		translated.Kind = webhooks.EventKindChargeback
+		translated.Amount = buildAmount(dispute.Amount, dispute.Currency)
+		translated.Success = true
+		if dispute.Charge != nil {
+			translated.TransactionReference = dispute.Charge.ID
+		}
+	default:
+		if id, ok := event.Data.Object["id"].(string); ok {
+			translated.TransactionReference = id
+		}
+	}
+	return translated, nil
+}
+
+// buildAmount converts Stripe lower case currencies to the upper case ISO codes used by sleet
+func buildAmount(amount int64, currency stripe.Currency) *sleet.Amount {
+	return &sleet.Amount{
+		Amount:   amount,
+		Currency: strings.ToUpper(string(currency)),
+	}
+}
@@ -0,0 +1,117 @@
+//go:build unit
+// +build unit
+
+package stripe
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+	"github.com/stripe/stripe-go/webhook"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/webhooks"
+)
+
+const testEndpointSecret = "whsec_test_secret"
+
+func buildEventBody(t *testing.T, eventType string) []byte {
+	charge, err := ioutil.ReadFile("testdata/charges_success.json")
+	if err != nil {
+		t.Fatalf("error reading charge: %s", err)
+	}
+	return []byte(fmt.Sprintf(`{"id":"evt_1","object":"event","type":"%s","data":{"object":%s}}`, eventType, charge))
+}
+
+func signedHeader(body []byte, secret string, timestamp time.Time) http.Header {
+	signature := webhook.ComputeSignature(timestamp, body, secret)
+	header := http.Header{}
+	header.Set(signatureHeader, fmt.Sprintf("t=%d,v1=%s", timestamp.Unix(), hex.EncodeToString(signature)))
+	return header
+}
+
+func TestWebhookParserParse(t *testing.T) {
+	cases := []struct {
+		label     string
+		eventType string
+		want      webhooks.WebhookEvent
+	}{
+		{
+			"Authorization",
+			eventTypeChargeSucceeded,
+			webhooks.WebhookEvent{
+				Kind:                 webhooks.EventKindAuthorization,
+				PsPEventType:         eventTypeChargeSucceeded,
+				EventID:              "evt_1",
+				TransactionReference: "ch_1FfpIZFSEDlaFyqYGbP2DpkI",
+				Amount:               &sleet.Amount{Amount: 100, Currency: "USD"},
+				Success:              true,
+			},
+		},
+		{
+			"Void of uncaptured charge",
+			eventTypeChargeRefunded,
+			webhooks.WebhookEvent{
+				Kind:                 webhooks.EventKindVoid,
+				PsPEventType:         eventTypeChargeRefunded,
+				EventID:              "evt_1",
+				TransactionReference: "ch_1FfpIZFSEDlaFyqYGbP2DpkI",
+				Amount:               &sleet.Amount{Amount: 0, Currency: "USD"},
+				Success:              true,
+			},
+		},
+		{
+			"Failed authorization",
+			eventTypeChargeFailed,
+			webhooks.WebhookEvent{
+				Kind:                 webhooks.EventKindAuthorization,
+				PsPEventType:         eventTypeChargeFailed,
+				EventID:              "evt_1",
+				TransactionReference: "ch_1FfpIZFSEDlaFyqYGbP2DpkI",
+				Amount:               &sleet.Amount{Amount: 100, Currency: "USD"},
+				Success:              false,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			body := buildEventBody(t, c.eventType)
+			got, err := NewWebhookParser(testEndpointSecret).Parse(signedHeader(body, testEndpointSecret, time.Now()), body)
+			if err != nil {
+				t.Fatalf("error parsing event: %s", err)
+			}
+			c.want.RawPayload = body
+			if diff := deep.Equal(got, []webhooks.WebhookEvent{c.want}); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestWebhookParserParseInvalidSignature(t *testing.T) {
+	body := buildEventBody(t, eventTypeChargeSucceeded)
+	cases := []struct {
+		label  string
+		header http.Header
+		want   error
+	}{
+		{"Missing header", http.Header{}, webhooks.ErrMissingSignature},
+		{"Wrong secret", signedHeader(body, "whsec_other", time.Now()), webhooks.ErrInvalidSignature},
+		{"Outside tolerance", signedHeader(body, testEndpointSecret, time.Now().Add(-time.Hour)), webhooks.ErrInvalidSignature},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			_, err := NewWebhookParser(testEndpointSecret).Parse(c.header, body)
+			if err != c.want {
+				t.Errorf("expected %v, got %v", c.want, err)
+			}
+		})
+	}
+}
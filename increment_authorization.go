@@ -0,0 +1,55 @@
+package sleet
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrAuthorizedAmountRequired is returned by PsPs which take the new total of an authorization, or don't report it,
+// when an IncrementAuthorizationRequest has no AuthorizedAmount
+var ErrAuthorizedAmountRequired = errors.New("sleet: AuthorizedAmount is required to increment this authorization")
+
+// IncrementAuthorizer is implemented by clients of PsPs which can raise the amount of an existing authorization, such
+// as when a hotel stay is extended or items are added to a marketplace order.
+type IncrementAuthorizer interface {
+	IncrementAuthorization(request *IncrementAuthorizationRequest) (*IncrementAuthorizationResponse, error)
+}
+
+// IncrementAuthorizerWithContext is a superset of `IncrementAuthorizer` that includes additional methods that take
+// `context.Context` as parameters.
+type IncrementAuthorizerWithContext interface {
+	IncrementAuthorizer
+	IncrementAuthorizationWithContext(ctx context.Context, request *IncrementAuthorizationRequest) (*IncrementAuthorizationResponse, error)
+}
+
+// IncrementAuthorizationRequest raises the authorization with TransactionReference by Amount. AuthorizedAmount is the
+// total authorized before the increment, used to report the new total. It is required by PsPs which take the new total
+// instead of the increment, or don't report it.
+type IncrementAuthorizationRequest struct {
+	TransactionReference       string
+	Amount                     Amount
+	AuthorizedAmount           int64
+	ClientTransactionReference *string // Custom transaction reference metadata that will be associated with this request
+	MerchantOrderReference     *string // Custom merchant order reference that will be associated with this request
+	Options                    map[string]interface{}
+}
+
+// NewAuthorizedAmount returns the total which is authorized once the increment is approved
+func (request *IncrementAuthorizationRequest) NewAuthorizedAmount() Amount {
+	return Amount{
+		Amount:   request.AuthorizedAmount + request.Amount.Amount,
+		Currency: request.Amount.Currency,
+	}
+}
+
+// IncrementAuthorizationResponse has Success be true if the increment was approved. AuthorizedAmount is the total
+// authorized after the increment. TransactionReference identifies the increment at the PsP, the authorization is still
+// captured with its own TransactionReference.
+type IncrementAuthorizationResponse struct {
+	Success              bool
+	TransactionReference string
+	AuthorizedAmount     Amount
+	ErrorCode            *string
+	Message              string // message from the gateway describing the reason for a failed increment
+	StatusCode           int    // the status code from raw PSP http response
+}
@@ -0,0 +1,22 @@
+module github.com/BoltApp/sleet/instrumentation
+
+go 1.20
+
+require (
+	github.com/BoltApp/sleet v0.0.0-20261018125759-c093ea43f258
+	go.opentelemetry.io/otel v1.24.0
+	go.opentelemetry.io/otel/metric v1.24.0
+	go.opentelemetry.io/otel/sdk v1.24.0
+	go.opentelemetry.io/otel/sdk/metric v1.24.0
+	go.opentelemetry.io/otel/trace v1.24.0
+)
+
+require (
+	github.com/go-logr/logr v1.4.1 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	golang.org/x/sys v0.17.0 // indirect
+)
+
+// the instrumentation is developed against the sleet of this repository. Replacements only apply to this module, so
+// users resolve sleet by the version required above, which must be raised when the instrumentation needs newer sleet.
+replace github.com/BoltApp/sleet => ../
@@ -0,0 +1,38 @@
+package instrumentation
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Transport adds the HTTP status of each response to the span in the request's context, so the status of operations
+// whose responses don't report one is still traced
+type Transport struct {
+	Next http.RoundTripper // http.DefaultTransport when nil
+}
+
+// NewHTTPClient returns a copy of httpClient whose requests add their HTTP status to the span of the operation, for use
+// with gateway constructors taking an http client
+func NewHTTPClient(httpClient *http.Client) *http.Client {
+	instrumented := &http.Client{}
+	if httpClient != nil {
+		*instrumented = *httpClient
+	}
+	instrumented.Transport = &Transport{Next: instrumented.Transport}
+	return instrumented
+}
+
+func (t *Transport) RoundTrip(request *http.Request) (*http.Response, error) {
+	// http.DefaultTransport is read on each request so that it can be replaced in tests
+	next := t.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	response, err := next.RoundTrip(request)
+	if err == nil {
+		trace.SpanFromContext(request.Context()).SetAttributes(HTTPStatusCodeKey.Int(response.StatusCode))
+	}
+	return response, err
+}
@@ -0,0 +1,291 @@
+// Package instrumentation traces sleet clients and records their metrics with OpenTelemetry. Spans and metrics carry
+// the gateway, operation, currency, result type, HTTP status, PsP error code and error kind of each
+// operation, never card data.
+package instrumentation
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/middleware"
+)
+
+// ScopeName is the name of the tracer and meter of the package
+const ScopeName = "github.com/BoltApp/sleet/instrumentation"
+
+// Attribute keys set on spans and metrics
+const (
+	GatewayKey        = attribute.Key("sleet.gateway")
+	OperationKey      = attribute.Key("sleet.operation")
+	CurrencyKey       = attribute.Key("sleet.currency")
+	ResultTypeKey     = attribute.Key("sleet.result_type")
+	SuccessKey        = attribute.Key("sleet.success")
+	ErrorCodeKey      = attribute.Key("sleet.error_code")
+	ErrorKindKey      = attribute.Key("sleet.error_kind")
+	HTTPStatusCodeKey = attribute.Key("http.response.status_code")
+)
+
+// Names of the metrics recorded for each operation
+const (
+	RequestsMetric = "sleet.client.requests"
+	DurationMetric = "sleet.client.duration"
+)
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+}
+
+// Option configures the providers used by the instrumentation
+type Option func(config *config)
+
+// WithTracerProvider creates spans with provider instead of the global TracerProvider
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(config *config) {
+		config.tracerProvider = provider
+	}
+}
+
+// WithMeterProvider records metrics with provider instead of the global MeterProvider
+func WithMeterProvider(provider metric.MeterProvider) Option {
+	return func(config *config) {
+		config.meterProvider = provider
+	}
+}
+
+// Wrap returns client with every operation traced and measured as requests to gateway
+func Wrap(client sleet.ClientWithContext, gateway string, options ...Option) (*middleware.Client, error) {
+	interceptor, err := Interceptor(gateway, options...)
+	if err != nil {
+		return nil, err
+	}
+	return middleware.Wrap(client, interceptor), nil
+}
+
+// Interceptor returns a middleware.Interceptor starting a span for each operation, as a child of the span in the
+// caller's context, and counting the operation and its duration. The span is in the context passed to the client, so
+// requests sent through an http client from NewHTTPClient add their HTTP status to it.
+func Interceptor(gateway string, options ...Option) (middleware.Interceptor, error) {
+	config := &config{
+		tracerProvider: otel.GetTracerProvider(),
+		meterProvider:  otel.GetMeterProvider(),
+	}
+	for _, option := range options {
+		option(config)
+	}
+
+	tracer := config.tracerProvider.Tracer(ScopeName)
+	meter := config.meterProvider.Meter(ScopeName)
+	requests, err := meter.Int64Counter(RequestsMetric,
+		metric.WithDescription("Number of operations sent to the gateway"),
+		metric.WithUnit("{request}"))
+	if err != nil {
+		return nil, err
+	}
+	duration, err := meter.Float64Histogram(DurationMetric,
+		metric.WithDescription("Duration of operations sent to the gateway"),
+		metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+
+	return func(next middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, operation middleware.Operation, request interface{}) (interface{}, error) {
+			attributes := []attribute.KeyValue{GatewayKey.String(gateway), OperationKey.String(string(operation))}
+			if currency := requestCurrency(request); currency != "" {
+				attributes = append(attributes, CurrencyKey.String(currency))
+			}
+
+			ctx, span := tracer.Start(ctx, "sleet."+string(operation),
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(attributes...))
+			defer span.End()
+
+			start := time.Now()
+			response, err := next(ctx, operation, request)
+			elapsed := time.Since(start)
+
+			result := describe(response, err)
+			outcome := []attribute.KeyValue{ResultTypeKey.String(string(result.resultType)), SuccessKey.Bool(result.success)}
+			if result.errorKind != "" {
+				outcome = append(outcome, ErrorKindKey.String(string(result.errorKind)))
+			}
+			span.SetAttributes(outcome...)
+			if result.errorCode != "" {
+				span.SetAttributes(ErrorCodeKey.String(result.errorCode))
+			}
+			if result.statusCode != 0 {
+				span.SetAttributes(HTTPStatusCodeKey.Int(result.statusCode))
+			}
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			} else if !result.success {
+				span.SetStatus(codes.Error, string(result.resultType))
+			}
+
+			// error codes and HTTP statuses are left off the metrics to bound their cardinality
+			measured := metric.WithAttributes(append(attributes, outcome...)...)
+			requests.Add(ctx, 1, measured)
+			duration.Record(ctx, elapsed.Seconds(), measured)
+			return response, err
+		}
+	}, nil
+}
+
+// requestCurrency returns the currency of the requests which have an amount
+func requestCurrency(request interface{}) string {
+	switch r := request.(type) {
+	case *sleet.AuthorizationRequest:
+		if r != nil {
+			return r.Amount.Currency
+		}
+	case *sleet.CaptureRequest:
+		if r != nil && r.Amount != nil {
+			return r.Amount.Currency
+		}
+	case *sleet.RefundRequest:
+		if r != nil && r.Amount != nil {
+			return r.Amount.Currency
+		}
+	case *sleet.IncrementAuthorizationRequest:
+		if r != nil {
+			return r.Amount.Currency
+		}
+	case *sleet.TimeoutReversalRequest:
+		if r != nil {
+			return r.Amount.Currency
+		}
+	}
+	return ""
+}
+
+type result struct {
+	success    bool
+	resultType sleet.ResultType
+	errorCode  string
+	statusCode int
+	errorKind  sleet.ErrorKind
+}
+
+// describe extracts the attributes of a response. Responses which don't report a ResultType are Approved when
+// successful, a ServerError when the client returned an error and Unknown otherwise. A *sleet.Error gives its kind and
+// the status code of the PsP response, if there was one.
+func describe(response interface{}, err error) result {
+	var r result
+	switch response := response.(type) {
+	case *sleet.AuthorizationResponse:
+		if response != nil {
+			r = authorizationResult(response)
+		}
+	case *sleet.SaleResponse:
+		if response != nil {
+			r = authorizationResult(&response.AuthorizationResponse)
+		}
+	case *sleet.VerificationResponse:
+		if response != nil {
+			r = authorizationResult(&response.AuthorizationResponse)
+		}
+	case *sleet.CaptureResponse:
+		if response != nil {
+			r = result{
+				success:    response.Success,
+				resultType: response.ResultType,
+				errorCode:  stringValue(response.ErrorCode),
+				statusCode: response.StatusCode,
+			}
+		}
+	case *sleet.VoidResponse:
+		if response != nil {
+			r = result{
+				success:    response.Success,
+				resultType: response.ResultType,
+				errorCode:  stringValue(response.ErrorCode),
+				statusCode: response.StatusCode,
+			}
+		}
+	case *sleet.RefundResponse:
+		if response != nil {
+			r = result{
+				success:    response.Success,
+				resultType: response.ResultType,
+				errorCode:  stringValue(response.ErrorCode),
+				statusCode: response.StatusCode,
+			}
+		}
+	case *sleet.IncrementAuthorizationResponse:
+		if response != nil {
+			r = result{success: response.Success, errorCode: stringValue(response.ErrorCode)}
+		}
+	case *sleet.TransactionQueryResponse:
+		if response != nil {
+			r = result{success: response.Success, errorCode: stringValue(response.ErrorCode)}
+		}
+	case *sleet.FindByClientReferenceResponse:
+		if response != nil {
+			r = result{success: response.Success, errorCode: stringValue(response.ErrorCode)}
+		}
+	case *sleet.TimeoutReversalResponse:
+		if response != nil {
+			r = result{success: response.Success, errorCode: stringValue(response.ErrorCode)}
+		}
+	case *sleet.StorePaymentMethodResponse:
+		if response != nil {
+			r = result{success: response.Success, errorCode: stringValue(response.ErrorCode)}
+		}
+	case *sleet.GetPaymentMethodResponse:
+		if response != nil {
+			r = result{success: response.Success, errorCode: stringValue(response.ErrorCode)}
+		}
+	case *sleet.DeletePaymentMethodResponse:
+		if response != nil {
+			r = result{success: response.Success, errorCode: stringValue(response.ErrorCode)}
+		}
+	}
+
+	if err != nil {
+		r.success = false
+		var sleetErr *sleet.Error
+		if errors.As(err, &sleetErr) {
+			r.errorKind = sleetErr.Kind
+			if r.statusCode == 0 {
+				r.statusCode = sleetErr.StatusCode
+			}
+		}
+	}
+	if r.resultType == "" {
+		switch {
+		case r.success:
+			r.resultType = sleet.ResultTypeSuccess
+		case err != nil:
+			r.resultType = sleet.ResultTypeServerError
+		default:
+			r.resultType = sleet.ResultTypeUnknownError
+		}
+	}
+	return r
+}
+
+func authorizationResult(response *sleet.AuthorizationResponse) result {
+	return result{
+		success:    response.Success,
+		resultType: response.ResultType,
+		errorCode:  response.ErrorCode,
+		statusCode: response.StatusCode,
+	}
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
@@ -0,0 +1,213 @@
+package instrumentation
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/BoltApp/sleet"
+)
+
+// fakeClient declines authorizations, fails voids and sends captures to url with httpClient
+type fakeClient struct {
+	httpClient *http.Client
+	url        string
+}
+
+func (c *fakeClient) Authorize(request *sleet.AuthorizationRequest) (*sleet.AuthorizationResponse, error) {
+	return c.AuthorizeWithContext(context.TODO(), request)
+}
+
+func (c *fakeClient) AuthorizeWithContext(ctx context.Context, request *sleet.AuthorizationRequest) (*sleet.AuthorizationResponse, error) {
+	return &sleet.AuthorizationResponse{
+		Success:    false,
+		ErrorCode:  "05",
+		ResultType: sleet.ResultTypePaymentError,
+		StatusCode: http.StatusOK,
+	}, nil
+}
+
+func (c *fakeClient) Capture(request *sleet.CaptureRequest) (*sleet.CaptureResponse, error) {
+	return c.CaptureWithContext(context.TODO(), request)
+}
+
+func (c *fakeClient) CaptureWithContext(ctx context.Context, request *sleet.CaptureRequest) (*sleet.CaptureResponse, error) {
+	httpRequest, _ := http.NewRequestWithContext(ctx, http.MethodPost, c.url, nil)
+	response, err := c.httpClient.Do(httpRequest)
+	if err != nil {
+		return nil, err
+	}
+	response.Body.Close()
+	return &sleet.CaptureResponse{Success: true, TransactionReference: "ref"}, nil
+}
+
+func (c *fakeClient) Void(request *sleet.VoidRequest) (*sleet.VoidResponse, error) {
+	return c.VoidWithContext(context.TODO(), request)
+}
+
+func (c *fakeClient) VoidWithContext(ctx context.Context, request *sleet.VoidRequest) (*sleet.VoidResponse, error) {
+	return nil, &sleet.Error{Kind: sleet.ErrorKindNetwork, Gateway: "fake", Operation: sleet.OperationVoid, Retryable: true, Err: errors.New("connection reset")}
+}
+
+func (c *fakeClient) Refund(request *sleet.RefundRequest) (*sleet.RefundResponse, error) {
+	return c.RefundWithContext(context.TODO(), request)
+}
+
+func (c *fakeClient) RefundWithContext(ctx context.Context, request *sleet.RefundRequest) (*sleet.RefundResponse, error) {
+	return &sleet.RefundResponse{Success: true}, nil
+}
+
+func setup(t *testing.T) (*fakeClient, *tracetest.SpanRecorder, *sdkmetric.ManualReader, *sdktrace.TracerProvider, *sdkmetric.MeterProvider) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(server.Close)
+
+	recorder := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	return &fakeClient{httpClient: NewHTTPClient(server.Client()), url: server.URL}, recorder, reader, tracerProvider, meterProvider
+}
+
+func attributeMap(attributes []attribute.KeyValue) map[attribute.Key]attribute.Value {
+	values := make(map[attribute.Key]attribute.Value)
+	for _, kv := range attributes {
+		values[kv.Key] = kv.Value
+	}
+	return values
+}
+
+func TestSpans(t *testing.T) {
+	fake, recorder, _, tracerProvider, meterProvider := setup(t)
+	client, err := Wrap(fake, "fake", WithTracerProvider(tracerProvider), WithMeterProvider(meterProvider))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, parent := tracerProvider.Tracer("test").Start(context.Background(), "checkout")
+	_, _ = client.AuthorizeWithContext(ctx, &sleet.AuthorizationRequest{
+		Amount:     sleet.Amount{Amount: 100, Currency: "USD"},
+		CreditCard: &sleet.CreditCard{Number: "4111111111111111", CVV: "737"},
+	})
+	_, _ = client.CaptureWithContext(ctx, &sleet.CaptureRequest{Amount: &sleet.Amount{Amount: 100, Currency: "EUR"}, TransactionReference: "ref"})
+	_, _ = client.VoidWithContext(ctx, &sleet.VoidRequest{TransactionReference: "ref"})
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 4 {
+		t.Fatalf("expected 4 spans, got %d", len(spans))
+	}
+
+	t.Run("Authorize", func(t *testing.T) {
+		span := spans[0]
+		if span.Name() != "sleet.authorize" || span.SpanKind() != trace.SpanKindClient {
+			t.Errorf("unexpected span %s of kind %s", span.Name(), span.SpanKind())
+		}
+		if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Error("expected the span to be a child of the caller's span")
+		}
+		attributes := attributeMap(span.Attributes())
+		expected := map[attribute.Key]attribute.Value{
+			GatewayKey:        attribute.StringValue("fake"),
+			OperationKey:      attribute.StringValue("authorize"),
+			CurrencyKey:       attribute.StringValue("USD"),
+			ResultTypeKey:     attribute.StringValue(string(sleet.ResultTypePaymentError)),
+			SuccessKey:        attribute.BoolValue(false),
+			ErrorCodeKey:      attribute.StringValue("05"),
+			HTTPStatusCodeKey: attribute.IntValue(http.StatusOK),
+		}
+		for key, want := range expected {
+			if got := attributes[key]; got != want {
+				t.Errorf("expected %s to be %v, got %v", key, want.Emit(), got.Emit())
+			}
+		}
+		if span.Status().Code != codes.Error {
+			t.Errorf("expected a declined authorization to have an error status, got %v", span.Status())
+		}
+		for _, kv := range span.Attributes() {
+			if value := kv.Value.Emit(); strings.Contains(value, "4111111111111111") || strings.Contains(value, "737") {
+				t.Errorf("card data traced in %s", kv.Key)
+			}
+		}
+	})
+
+	t.Run("Capture", func(t *testing.T) {
+		attributes := attributeMap(spans[1].Attributes())
+		if attributes[HTTPStatusCodeKey] != attribute.IntValue(http.StatusAccepted) {
+			t.Errorf("expected the status of the http request, got %v", attributes[HTTPStatusCodeKey].Emit())
+		}
+		if attributes[ResultTypeKey] != attribute.StringValue(string(sleet.ResultTypeSuccess)) || spans[1].Status().Code == codes.Error {
+			t.Errorf("expected an approved capture, got %v", attributes[ResultTypeKey].Emit())
+		}
+	})
+
+	t.Run("Void", func(t *testing.T) {
+		span := spans[2]
+		if span.Status().Code != codes.Error || len(span.Events()) != 1 {
+			t.Errorf("expected the error to be recorded, got %v", span.Status())
+		}
+		attributes := attributeMap(span.Attributes())
+		if attributes[ResultTypeKey] != attribute.StringValue(string(sleet.ResultTypeServerError)) {
+			t.Errorf("expected a server error, got %v", attributes[ResultTypeKey].Emit())
+		}
+		if attributes[ErrorKindKey] != attribute.StringValue(string(sleet.ErrorKindNetwork)) {
+			t.Errorf("expected a network error, got %v", attributes[ErrorKindKey].Emit())
+		}
+	})
+}
+
+func TestMetrics(t *testing.T) {
+	fake, _, reader, tracerProvider, meterProvider := setup(t)
+	client, err := Wrap(fake, "fake", WithTracerProvider(tracerProvider), WithMeterProvider(meterProvider))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _ = client.Refund(&sleet.RefundRequest{Amount: &sleet.Amount{Amount: 100, Currency: "USD"}})
+	_, _ = client.Refund(&sleet.RefundRequest{Amount: &sleet.Amount{Amount: 200, Currency: "USD"}})
+	_, _ = client.Void(&sleet.VoidRequest{})
+
+	var data metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &data); err != nil {
+		t.Fatal(err)
+	}
+	if len(data.ScopeMetrics) != 1 || data.ScopeMetrics[0].Scope.Name != ScopeName {
+		t.Fatalf("expected the metrics of %s, got %+v", ScopeName, data.ScopeMetrics)
+	}
+
+	counts := make(map[string]int64)
+	var durations uint64
+	for _, m := range data.ScopeMetrics[0].Metrics {
+		switch m.Name {
+		case RequestsMetric:
+			for _, point := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				operation, _ := point.Attributes.Value(OperationKey)
+				resultType, _ := point.Attributes.Value(ResultTypeKey)
+				counts[operation.AsString()+" "+resultType.AsString()] += point.Value
+			}
+		case DurationMetric:
+			for _, point := range m.Data.(metricdata.Histogram[float64]).DataPoints {
+				durations += point.Count
+			}
+		}
+	}
+
+	if counts["refund Approved"] != 2 || counts["void ServerError"] != 1 {
+		t.Errorf("unexpected request counts %v", counts)
+	}
+	if durations != 3 {
+		t.Errorf("expected 3 durations, got %d", durations)
+	}
+}
@@ -9,13 +9,14 @@ import (
 	"github.com/BoltApp/sleet/common"
 	"github.com/BoltApp/sleet/gateways/adyen"
 	sleet_testing "github.com/BoltApp/sleet/testing"
+	"github.com/BoltApp/sleet/testing/fakes"
 )
 
 // TestAdyenAuthorizeFailed
 //
 // Adyen requires client transaction references, ensure that setting this to empty is an RPC failure
 func TestAdyenAuthorizeFailed(t *testing.T) {
-	client := adyen.NewClient(getEnv("ADYEN_ACCOUNT"), getEnv("ADYEN_KEY"), "", common.Sandbox)
+	client := newAdyenClient(t)
 	failedRequest := adyenBaseAuthRequest()
 	// set ClientTransactionReference to be empty
 	failedRequest.ClientTransactionReference = sPtr("")
@@ -44,12 +45,16 @@ func TestAdyenServerFailed(t *testing.T) {
 	ft := &fakeTransport{}
 	httpClient := http.Client{Transport: ft}
 
-	client := adyen.NewWithHTTPClient(getEnv("ADYEN_ACCOUNT"), getEnv("ADYEN_KEY"), "", common.Sandbox, &httpClient)
+	client := adyen.NewWithHTTPClient(fakes.AdyenMerchantAccount, fakes.AdyenAPIKey, "", common.Sandbox, &httpClient)
 	request := adyenBaseAuthRequest()
-	auth, _ := client.Authorize(request)
+	auth, err := client.Authorize(request)
+	if auth != nil {
+		t.Errorf("Expected no response, got %+v", auth)
+	}
 
-	if auth.ResultType != sleet.ResultTypeServerError {
-		t.Errorf("ResultType should be ServerError, got %s", auth.ResultType)
+	var sleetErr *sleet.Error
+	if !errors.As(err, &sleetErr) || sleetErr.Kind != sleet.ErrorKindNetwork || !sleetErr.Retryable {
+		t.Errorf("Expected a retryable network error, got %v", err)
 	}
 }
 
@@ -65,7 +70,7 @@ func (ft *fakeTransport) RoundTrip(*http.Request) (*http.Response, error) {
 //
 // This should not error but auth should be refused with Expired Card
 func TestAdyenExpiredCard(t *testing.T) {
-	client := adyen.NewClient(getEnv("ADYEN_ACCOUNT"), getEnv("ADYEN_KEY"), "", common.Sandbox)
+	client := newAdyenClient(t)
 	expiredRequest := adyenBaseAuthRequest()
 	expiredRequest.CreditCard.ExpirationYear = 2010
 	auth, err := client.Authorize(expiredRequest)
@@ -94,7 +99,7 @@ func TestAdyenExpiredCard(t *testing.T) {
 //
 // This should fail authorization but also include some AVS, CVV data
 func TestAdyenAuthFailedAVSPresent(t *testing.T) {
-	client := adyen.NewClient(getEnv("ADYEN_ACCOUNT"), getEnv("ADYEN_KEY"), "", common.Sandbox)
+	client := newAdyenClient(t)
 	expiredRequest := adyenBaseAuthRequest()
 	expiredRequest.CreditCard.ExpirationYear = 2010
 	expiredRequest.BillingAddress = &sleet.Address{
@@ -140,7 +145,7 @@ func TestAdyenAuthFailedAVSPresent(t *testing.T) {
 // Test addresses found from here
 // https://docs.adyen.com/development-resources/test-cards/test-card-numbers#test-address-verification-system-avs
 func TestAdyenAVSCode1(t *testing.T) {
-	client := adyen.NewClient(getEnv("ADYEN_ACCOUNT"), getEnv("ADYEN_KEY"), "", common.Sandbox)
+	client := newAdyenClient(t)
 	avsRequest := adyenBaseAuthRequest()
 	avsRequest.CreditCard.Number = "5500000000000004"
 	avsRequest.BillingAddress = &sleet.Address{
@@ -174,7 +179,7 @@ func TestAdyenAVSCode1(t *testing.T) {
 // Test addresses found from here
 // https://docs.adyen.com/development-resources/test-cards/test-card-numbers#test-address-verification-system-avs
 func TestAdyenAVSCode2(t *testing.T) {
-	client := adyen.NewClient(getEnv("ADYEN_ACCOUNT"), getEnv("ADYEN_KEY"), "", common.Sandbox)
+	client := newAdyenClient(t)
 	avsRequest := adyenBaseAuthRequest()
 	avsRequest.CreditCard.Number = "5500000000000004"
 	avsRequest.BillingAddress = &sleet.Address{
@@ -206,7 +211,7 @@ func TestAdyenAVSCode2(t *testing.T) {
 //
 // This should successfully create an authorization on Adyen for a new customer
 func TestAdyenAuth(t *testing.T) {
-	client := adyen.NewClient(getEnv("ADYEN_ACCOUNT"), getEnv("ADYEN_KEY"), "", common.Sandbox)
+	client := newAdyenClient(t)
 	request := adyenBaseAuthRequest()
 	auth, err := client.Authorize(request)
 	if err != nil {
@@ -223,7 +228,7 @@ func TestAdyenAuth(t *testing.T) {
 // This should successfully create an authorization on Adyen for a new customer with IP, email, and shipping address
 // included
 func TestAdyenAuthWithIPEmailShippingAddress(t *testing.T) {
-	client := adyen.NewClient(getEnv("ADYEN_ACCOUNT"), getEnv("ADYEN_KEY"), "", common.Sandbox)
+	client := newAdyenClient(t)
 	request := adyenBaseAuthRequest()
 	adyenEnhanceAuthRequestIPEmailShipping(request)
 	auth, err := client.Authorize(request)
@@ -240,7 +245,7 @@ func TestAdyenAuthWithIPEmailShippingAddress(t *testing.T) {
 //
 // This should successfully create an authorization on Adyen for an existing customer
 func TestAdyenRechargeAuth(t *testing.T) {
-	client := adyen.NewClient(getEnv("ADYEN_ACCOUNT"), getEnv("ADYEN_KEY"), "", common.Sandbox)
+	client := newAdyenClient(t)
 	request := adyenBaseAuthRequest()
 	request.CreditCard.CVV = ""
 	auth, err := client.Authorize(request)
@@ -257,7 +262,7 @@ func TestAdyenRechargeAuth(t *testing.T) {
 //
 // This should successfully create an authorization on Adyen for customer that does not want his/her card saved
 func TestAdyenOneTimeAuth(t *testing.T) {
-	client := adyen.NewClient(getEnv("ADYEN_ACCOUNT"), getEnv("ADYEN_KEY"), "", common.Sandbox)
+	client := newAdyenClient(t)
 	request := adyenBaseAuthRequest()
 	request.CreditCard.Save = false
 	auth, err := client.Authorize(request)
@@ -274,7 +279,7 @@ func TestAdyenOneTimeAuth(t *testing.T) {
 //
 // This should successfully create an authorization on Adyen then Capture for full amount
 func TestAdyenAuthFullCapture(t *testing.T) {
-	client := adyen.NewClient(getEnv("ADYEN_ACCOUNT"), getEnv("ADYEN_KEY"), "", common.Sandbox)
+	client := newAdyenClient(t)
 	authRequest := adyenBaseAuthRequest()
 	auth, err := client.Authorize(authRequest)
 	if err != nil {
@@ -303,7 +308,7 @@ func TestAdyenAuthFullCapture(t *testing.T) {
 //
 // This should successfully create an authorization on Adyen then Capture for partial amount
 func TestAdyenAuthPartialCapture(t *testing.T) {
-	client := adyen.NewClient(getEnv("ADYEN_ACCOUNT"), getEnv("ADYEN_KEY"), "", common.Sandbox)
+	client := newAdyenClient(t)
 	authRequest := adyenBaseAuthRequest()
 	auth, err := client.Authorize(authRequest)
 	if err != nil {
@@ -335,7 +340,7 @@ func TestAdyenAuthPartialCapture(t *testing.T) {
 //
 // This should successfully create an authorization on Adyen then Void/Cancel the Auth
 func TestAdyenAuthVoid(t *testing.T) {
-	client := adyen.NewClient(getEnv("ADYEN_ACCOUNT"), getEnv("ADYEN_KEY"), "", common.Sandbox)
+	client := newAdyenClient(t)
 	authRequest := adyenBaseAuthRequest()
 	auth, err := client.Authorize(authRequest)
 	if err != nil {
@@ -363,7 +368,7 @@ func TestAdyenAuthVoid(t *testing.T) {
 //
 // This should successfully create an authorization on Adyen then Capture for full amount, then refund for full amount
 func TestAdyenAuthCaptureRefund(t *testing.T) {
-	client := adyen.NewClient(getEnv("ADYEN_ACCOUNT"), getEnv("ADYEN_KEY"), "", common.Sandbox)
+	client := newAdyenClient(t)
 	authRequest := adyenBaseAuthRequest()
 	auth, err := client.Authorize(authRequest)
 	if err != nil {
@@ -402,6 +407,16 @@ func TestAdyenAuthCaptureRefund(t *testing.T) {
 	}
 }
 
+// newAdyenClient returns a client for the Adyen sandbox when ADYEN_ACCOUNT and ADYEN_KEY are set, or for a local fake
+func newAdyenClient(t *testing.T) *adyen.AdyenClient {
+	if hasEnv("ADYEN_ACCOUNT", "ADYEN_KEY") {
+		return adyen.NewClient(getEnv("ADYEN_ACCOUNT"), getEnv("ADYEN_KEY"), "", common.Sandbox)
+	}
+	fake := fakes.NewAdyen()
+	t.Cleanup(fake.Close)
+	return adyen.NewWithHTTPClient(fakes.AdyenMerchantAccount, fakes.AdyenAPIKey, "", common.Sandbox, fake.Client())
+}
+
 func adyenBaseAuthRequest() *sleet.AuthorizationRequest {
 	request := sleet_testing.BaseAuthorizationRequest()
 	request.CreditCard.ExpirationMonth = 3
@@ -10,6 +10,7 @@ import (
 	"github.com/BoltApp/sleet/common"
 	"github.com/BoltApp/sleet/gateways/authorizenet"
 	sleet_testing "github.com/BoltApp/sleet/testing"
+	"github.com/BoltApp/sleet/testing/fakes"
 )
 
 // Authorize.net has pretty strict duplicate checking mechanisms, simply change amount in tests
@@ -18,7 +19,7 @@ import (
 //
 // This should successfully create an authorization on Authorize.net
 func TestAuthNetAuth(t *testing.T) {
-	client := authorizenet.NewClient(getEnv("AUTH_NET_LOGIN_ID"), getEnv("AUTH_NET_TXN_KEY"), common.Sandbox)
+	client := newAuthNetClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequestWithEmailPhoneNumber()
 	authRequest.Amount.Amount = int64(randomdata.Number(100))
 	authRequest.MerchantOrderReference = "test-order-ref"
@@ -33,7 +34,7 @@ func TestAuthNetAuth(t *testing.T) {
 }
 
 func TestAuthNetAuthL2L3(t *testing.T) {
-	client := authorizenet.NewClient(getEnv("AUTH_NET_LOGIN_ID"), getEnv("AUTH_NET_TXN_KEY"), common.Sandbox)
+	client := newAuthNetClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequestWithEmailPhoneNumber()
 	authRequest.Amount.Amount = int64(randomdata.Number(100))
 	authRequest.MerchantOrderReference = "test-order-ref"
@@ -50,7 +51,7 @@ func TestAuthNetAuthL2L3(t *testing.T) {
 }
 
 func TestAuthNetAuthL2L3MultipleItem(t *testing.T) {
-	client := authorizenet.NewClient(getEnv("AUTH_NET_LOGIN_ID"), getEnv("AUTH_NET_TXN_KEY"), common.Sandbox)
+	client := newAuthNetClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequestWithEmailPhoneNumber()
 	authRequest.Amount.Amount = int64(randomdata.Number(100))
 	authRequest.MerchantOrderReference = "test-order-ref"
@@ -67,7 +68,7 @@ func TestAuthNetAuthL2L3MultipleItem(t *testing.T) {
 }
 
 func TestAuthNetAuthWithCustomerIP(t *testing.T) {
-	client := authorizenet.NewClient(getEnv("AUTH_NET_LOGIN_ID"), getEnv("AUTH_NET_TXN_KEY"), common.Sandbox)
+	client := newAuthNetClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequestWithEmailPhoneNumber()
 	if authRequest.Options == nil {
 		authRequest.Options = make(map[string]interface{})
@@ -89,7 +90,7 @@ func TestAuthNetAuthWithCustomerIP(t *testing.T) {
 //
 // Recharge requests will not have CVV. This should successfully create an authorization on Authorize.net
 func TestAuthNetRechargeAuth(t *testing.T) {
-	client := authorizenet.NewClient(getEnv("AUTH_NET_LOGIN_ID"), getEnv("AUTH_NET_TXN_KEY"), common.Sandbox)
+	client := newAuthNetClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequestWithEmailPhoneNumber()
 	authRequest.CreditCard.CVV = ""
 	authRequest.Amount.Amount = int64(randomdata.Number(100))
@@ -107,7 +108,7 @@ func TestAuthNetRechargeAuth(t *testing.T) {
 //
 // This should successfully create an authorization on Authorize.net then Capture for full amount
 func TestAuthNetAuthFullCapture(t *testing.T) {
-	client := authorizenet.NewClient(getEnv("AUTH_NET_LOGIN_ID"), getEnv("AUTH_NET_TXN_KEY"), common.Sandbox)
+	client := newAuthNetClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequestWithEmailPhoneNumber()
 	authRequest.Amount.Amount = int64(randomdata.Number(100))
 	auth, err := client.Authorize(authRequest)
@@ -138,7 +139,7 @@ func TestAuthNetAuthFullCapture(t *testing.T) {
 // This should successfully create an authorization on Authorize.net then Capture for a partial amount
 // Since we auth for 1.00USD, we will capture for $0.50
 func TestAuthNetAuthPartialCapture(t *testing.T) {
-	client := authorizenet.NewClient(getEnv("AUTH_NET_LOGIN_ID"), getEnv("AUTH_NET_TXN_KEY"), common.Sandbox)
+	client := newAuthNetClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequestWithEmailPhoneNumber()
 	authRequest.Amount.Amount = int64(randomdata.Number(11, 100))
 	auth, err := client.Authorize(authRequest)
@@ -170,7 +171,7 @@ func TestAuthNetAuthPartialCapture(t *testing.T) {
 //
 // This should successfully create an authorization on Authorize.net then Void/Cancel the Auth
 func TestAuthNetAuthVoid(t *testing.T) {
-	client := authorizenet.NewClient(getEnv("AUTH_NET_LOGIN_ID"), getEnv("AUTH_NET_TXN_KEY"), common.Sandbox)
+	client := newAuthNetClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequestWithEmailPhoneNumber()
 	authRequest.Amount.Amount = int64(randomdata.Number(100))
 	auth, err := client.Authorize(authRequest)
@@ -198,7 +199,7 @@ func TestAuthNetAuthVoid(t *testing.T) {
 // TestAuthNetAuthCaptureRefund
 // TODO: Have this refer to the auth/capture transactionId once automatic settlement is available
 func TestAuthNetAuthCaptureRefund(t *testing.T) {
-	client := authorizenet.NewClient(getEnv("AUTH_NET_LOGIN_ID"), getEnv("AUTH_NET_TXN_KEY"), common.Sandbox)
+	client := newAuthNetClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequestWithEmailPhoneNumber()
 	authRequest.Amount.Amount = int64(randomdata.Number(100))
 	auth, err := client.Authorize(authRequest)
@@ -244,7 +245,7 @@ func TestAuthNetAuthCaptureRefund(t *testing.T) {
 // TestAuthNetGetTransactionDetails
 // This should successfully fetch transaction details from Authorize.net
 func TestAuthNetGetTransactionDetails(t *testing.T) {
-	client := authorizenet.NewClient(getEnv("AUTH_NET_LOGIN_ID"), getEnv("AUTH_NET_TXN_KEY"), common.Sandbox)
+	client := newAuthNetClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequestWithEmailPhoneNumber()
 	authRequest.Amount.Amount = int64(randomdata.Number(100))
 	auth, err := client.Authorize(authRequest)
@@ -272,3 +273,12 @@ func TestAuthNetGetTransactionDetails(t *testing.T) {
 		t.Error("Card Number should not be empty")
 	}
 }
+
+func newAuthNetClient(t *testing.T) *authorizenet.AuthorizeNetClient {
+	if hasEnv("AUTH_NET_LOGIN_ID", "AUTH_NET_TXN_KEY") {
+		return authorizenet.NewClient(getEnv("AUTH_NET_LOGIN_ID"), getEnv("AUTH_NET_TXN_KEY"), common.Sandbox)
+	}
+	fake := fakes.NewAuthorizeNet()
+	t.Cleanup(fake.Close)
+	return authorizenet.NewWithHttpClient(fakes.AuthorizeNetLoginID, fakes.AuthorizeNetTransactionKey, common.Sandbox, fake.Client())
+}
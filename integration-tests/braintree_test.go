@@ -10,6 +10,7 @@ import (
 	"github.com/BoltApp/sleet/common"
 	"github.com/BoltApp/sleet/gateways/braintree"
 	sleet_testing "github.com/BoltApp/sleet/testing"
+	"github.com/BoltApp/sleet/testing/fakes"
 )
 
 // Note: Because we use the same amount for testing we turn off the duplicate checking in Braintree Control Panel
@@ -19,19 +20,15 @@ import (
 //
 // Using amount over 2000 USD will fail it: https://developers.braintreepayments.com/reference/general/testing/php#avs-and-cvv/cid-responses
 func TestBraintreeAuthorizeFailed(t *testing.T) {
-	client := braintree.NewClient(
-		getEnv("BRAINTREE_MERCHANT_ID"),
-		getEnv("BRAINTREE_PUBLIC_KEY"),
-		getEnv("BRAINTREE_PRIVATE_KEY"),
-		common.Sandbox)
+	client, _ := newBraintreeClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequest()
 	authRequest.Amount = sleet.Amount{
 		Amount:   201000,
 		Currency: "USD",
 	}
 	auth, err := client.Authorize(authRequest)
-	if err == nil {
-		t.Errorf("Expected error: received auth: %+v", auth)
+	if err != nil {
+		t.Fatalf("Expected a declined auth: received error: %s", err)
 	}
 	if auth.Success {
 		t.Errorf("Expected not-Success: received: %s", auth.Response)
@@ -42,11 +39,7 @@ func TestBraintreeAuthorizeFailed(t *testing.T) {
 //
 // Tests a successful authorization for Braintree
 func TestBraintreeAuth(t *testing.T) {
-	client := braintree.NewClient(
-		getEnv("BRAINTREE_MERCHANT_ID"),
-		getEnv("BRAINTREE_PUBLIC_KEY"),
-		getEnv("BRAINTREE_PRIVATE_KEY"),
-		common.Sandbox)
+	client, _ := newBraintreeClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequest()
 	resp, err := client.Authorize(authRequest)
 	if err != nil {
@@ -61,11 +54,7 @@ func TestBraintreeAuth(t *testing.T) {
 //
 // Tests a Braintree authorization then full capture
 func TestBraintreeAuthCapture(t *testing.T) {
-	client := braintree.NewClient(
-		getEnv("BRAINTREE_MERCHANT_ID"),
-		getEnv("BRAINTREE_PUBLIC_KEY"),
-		getEnv("BRAINTREE_PRIVATE_KEY"),
-		common.Sandbox)
+	client, _ := newBraintreeClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequest()
 	auth, err := client.Authorize(authRequest)
 	if err != nil {
@@ -93,11 +82,7 @@ func TestBraintreeAuthCapture(t *testing.T) {
 //
 // Tests a Braintree authorization then a partial capture
 func TestBraintreeAuthPartialCapture(t *testing.T) {
-	client := braintree.NewClient(
-		getEnv("BRAINTREE_MERCHANT_ID"),
-		getEnv("BRAINTREE_PUBLIC_KEY"),
-		getEnv("BRAINTREE_PRIVATE_KEY"),
-		common.Sandbox)
+	client, _ := newBraintreeClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequest()
 	auth, err := client.Authorize(authRequest)
 	if err != nil {
@@ -128,11 +113,7 @@ func TestBraintreeAuthPartialCapture(t *testing.T) {
 //
 // This should successfully create an authorization on Braintree then Void/Cancel the Auth
 func TestBraintreeAuthVoid(t *testing.T) {
-	client := braintree.NewClient(
-		getEnv("BRAINTREE_MERCHANT_ID"),
-		getEnv("BRAINTREE_PUBLIC_KEY"),
-		getEnv("BRAINTREE_PRIVATE_KEY"),
-		common.Sandbox)
+	client, _ := newBraintreeClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequest()
 	auth, err := client.Authorize(authRequest)
 	if err != nil {
@@ -162,11 +143,7 @@ func TestBraintreeAuthVoid(t *testing.T) {
 // Note: There is a hack in here to put the transaction in a settled state so the refund can occur because Braintree
 // does not allow refunds for "Submitted for Settlement"
 func TestBraintreeAuthCaptureRefund(t *testing.T) {
-	client := braintree.NewClient(
-		getEnv("BRAINTREE_MERCHANT_ID"),
-		getEnv("BRAINTREE_PUBLIC_KEY"),
-		getEnv("BRAINTREE_PRIVATE_KEY"),
-		common.Sandbox)
+	client, testGateway := newBraintreeClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequest()
 	auth, err := client.Authorize(authRequest)
 	if err != nil {
@@ -191,7 +168,6 @@ func TestBraintreeAuthCaptureRefund(t *testing.T) {
 	}
 
 	// HACK - put the transaction in a settled state
-	testGateway := braintree_go.New(braintree_go.Sandbox, getEnv("BRAINTREE_MERCHANT_ID"), getEnv("BRAINTREE_PUBLIC_KEY"), getEnv("BRAINTREE_PRIVATE_KEY"))
 	testGateway.Testing().Settle(context.TODO(), capture.TransactionReference)
 
 	refundRequest := &sleet.RefundRequest{
@@ -215,11 +191,7 @@ func TestBraintreeAuthCaptureRefund(t *testing.T) {
 // Note: There is a hack in here to put the transaction in a settled state so the refund can occur because Braintree
 // does not allow refunds for "Submitted for Settlement"
 func TestBraintreeAuthCapturePartialRefund(t *testing.T) {
-	client := braintree.NewClient(
-		getEnv("BRAINTREE_MERCHANT_ID"),
-		getEnv("BRAINTREE_PUBLIC_KEY"),
-		getEnv("BRAINTREE_PRIVATE_KEY"),
-		common.Sandbox)
+	client, testGateway := newBraintreeClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequest()
 	auth, err := client.Authorize(authRequest)
 	if err != nil {
@@ -244,7 +216,6 @@ func TestBraintreeAuthCapturePartialRefund(t *testing.T) {
 	}
 
 	// HACK - put the transaction in a settled state
-	testGateway := braintree_go.New(braintree_go.Sandbox, getEnv("BRAINTREE_MERCHANT_ID"), getEnv("BRAINTREE_PUBLIC_KEY"), getEnv("BRAINTREE_PRIVATE_KEY"))
 	testGateway.Testing().Settle(context.TODO(), capture.TransactionReference)
 
 	refundRequest := &sleet.RefundRequest{
@@ -264,3 +235,20 @@ func TestBraintreeAuthCapturePartialRefund(t *testing.T) {
 		t.Error("Resulting refund should have been successful")
 	}
 }
+
+// newBraintreeClient returns a client and the gateway whose testing API settles transactions
+func newBraintreeClient(t *testing.T) (*braintree.BraintreeClient, *braintree_go.Braintree) {
+	if hasEnv("BRAINTREE_MERCHANT_ID", "BRAINTREE_PUBLIC_KEY", "BRAINTREE_PRIVATE_KEY") {
+		client := braintree.NewClient(
+			getEnv("BRAINTREE_MERCHANT_ID"),
+			getEnv("BRAINTREE_PUBLIC_KEY"),
+			getEnv("BRAINTREE_PRIVATE_KEY"),
+			common.Sandbox)
+		return client, braintree_go.New(braintree_go.Sandbox, getEnv("BRAINTREE_MERCHANT_ID"), getEnv("BRAINTREE_PUBLIC_KEY"), getEnv("BRAINTREE_PRIVATE_KEY"))
+	}
+	fake := fakes.NewBraintree()
+	t.Cleanup(fake.Close)
+	client := braintree.NewWithHttpClient(fakes.BraintreeMerchantID, fakes.BraintreePublicKey, fakes.BraintreePrivateKey, common.Sandbox, fake.Client())
+	testGateway := braintree_go.NewWithHttpClient(braintree_go.Sandbox, fakes.BraintreeMerchantID, fakes.BraintreePublicKey, fakes.BraintreePrivateKey, fake.Client())
+	return client, testGateway
+}
@@ -10,17 +10,33 @@ import (
 	"github.com/Pallinder/go-randomdata"
 
 	sleet_testing "github.com/BoltApp/sleet/testing"
+	"github.com/BoltApp/sleet/testing/fakes"
 )
 
-func NewClient() *cardconnect.CardConnectClient {
-	return cardconnect.NewClient(getEnv("CARDCONNECT_USERNAME"), getEnv("CARDCONNECT_PASSWORD"), getEnv("CARDCONNECT_MERCHANTID"), getEnv("CARDCONNECT_URL"), common.Sandbox)
+var cardConnectEnv = []string{"CARDCONNECT_USERNAME", "CARDCONNECT_PASSWORD", "CARDCONNECT_MERCHANTID", "CARDCONNECT_URL"}
+
+func newCardConnectClient(t *testing.T) *cardconnect.CardConnectClient {
+	if hasEnv(cardConnectEnv...) {
+		return newCardConnectClientForMerchant(t, getEnv("CARDCONNECT_MERCHANTID"))
+	}
+	return newCardConnectClientForMerchant(t, fakes.CardConnectMerchantID)
+}
+
+// newCardConnectClientForMerchant returns a client whose requests are made for the merchant
+func newCardConnectClientForMerchant(t *testing.T, merchantID string) *cardconnect.CardConnectClient {
+	if hasEnv(cardConnectEnv...) {
+		return cardconnect.NewClient(getEnv("CARDCONNECT_USERNAME"), getEnv("CARDCONNECT_PASSWORD"), merchantID, getEnv("CARDCONNECT_URL"), common.Sandbox)
+	}
+	fake := fakes.NewCardConnect()
+	t.Cleanup(fake.Close)
+	return cardconnect.NewWithHttpClient(fakes.CardConnectUsername, fakes.CardConnectPassword, merchantID, fakes.CardConnectURL, common.Sandbox, fake.Client())
 }
 
 // TestCardConnectAuth
 //
 // This should successfully create an authorization on CardConnect
 func TestCardConnectAuth(t *testing.T) {
-	client := NewClient()
+	client := newCardConnectClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequestWithEmailPhoneNumber()
 	authRequest.Amount.Amount = int64(randomdata.Number(10000))
 	authRequest.MerchantOrderReference = "test-order-ref"
@@ -38,7 +54,7 @@ func TestCardConnectAuth(t *testing.T) {
 }
 
 func TestCardConnectAuthBadCredentials(t *testing.T) {
-	client := cardconnect.NewClient(getEnv("CARDCONNECT_USERNAME"), getEnv("CARDCONNECT_PASSWORD"), "wrong", getEnv("CARDCONNECT_URL"), common.Sandbox)
+	client := newCardConnectClientForMerchant(t, "wrong")
 	authRequest := sleet_testing.BaseAuthorizationRequestWithEmailPhoneNumber()
 	authRequest.Amount.Amount = int64(randomdata.Number(10000))
 	authRequest.MerchantOrderReference = "test-order-ref"
@@ -61,7 +77,7 @@ func TestCardConnectAuthBadCredentials(t *testing.T) {
 //
 // This should successfully create an authorization on CardConnect then Capture for full amount
 func TestCardConnectAuthFullCapture(t *testing.T) {
-	client := NewClient()
+	client := newCardConnectClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequestWithEmailPhoneNumber()
 	authRequest.Amount.Amount = int64(randomdata.Number(10000))
 	authRequest.MerchantOrderReference = "test-order-ref"
@@ -93,7 +109,7 @@ func TestCardConnectAuthFullCapture(t *testing.T) {
 //
 // This should successfully create an authorization on CardConnect then Void/Cancel the Auth
 func TestCardConnectAuthVoid(t *testing.T) {
-	client := NewClient()
+	client := newCardConnectClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequestWithEmailPhoneNumber()
 	authRequest.MerchantOrderReference = "test-order-ref"
 	authRequest.CreditCard.ExpirationMonth = 3
@@ -126,7 +142,7 @@ func TestCardConnectAuthVoid(t *testing.T) {
 // TODO: Have this refer to the auth/capture transactionId once automatic settlement is available
 func TestCardConnectAuthCaptureRefund(t *testing.T) {
 	transactionID := "test-order-refund"
-	client := NewClient()
+	client := newCardConnectClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequestWithEmailPhoneNumber()
 	authRequest.Amount.Amount = int64(randomdata.Number(100) * 100)
 	authRequest.MerchantOrderReference = transactionID
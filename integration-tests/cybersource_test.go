@@ -7,11 +7,12 @@ import (
 	"github.com/BoltApp/sleet/common"
 	"github.com/BoltApp/sleet/gateways/cybersource"
 	sleet_testing "github.com/BoltApp/sleet/testing"
+	"github.com/BoltApp/sleet/testing/fakes"
 )
 
 func TestAuthorizeAndCaptureAndRefund(t *testing.T) {
 	testCurrency := "USD"
-	client := cybersource.NewClient(common.Sandbox, getEnv("CYBERSOURCE_ACCOUNT"), getEnv("CYBERSOURCE_API_KEY"), getEnv("CYBERSOURCE_SHARED_SECRET"))
+	client := newCybersourceClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequest()
 	authRequest.ClientTransactionReference = sPtr("[auth]-CUSTOMER-REFERENCE-CODE") // This will be overridden by the level 3 CustomerReference
 	authRequest.BillingAddress = &sleet.Address{
@@ -81,7 +82,7 @@ func TestAuthorizeAndCaptureAndRefund(t *testing.T) {
 }
 
 func TestVoid(t *testing.T) {
-	client := cybersource.NewClient(common.Sandbox, getEnv("CYBERSOURCE_ACCOUNT"), getEnv("CYBERSOURCE_API_KEY"), getEnv("CYBERSOURCE_SHARED_SECRET"))
+	client := newCybersourceClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequest()
 	authRequest.ClientTransactionReference = sPtr("[auth]-CUSTOMER-REFERENCE-CODE")
 	authRequest.BillingAddress = &sleet.Address{
@@ -116,7 +117,7 @@ func TestVoid(t *testing.T) {
 }
 
 func TestMissingReference(t *testing.T) {
-	client := cybersource.NewClient(common.Sandbox, getEnv("CYBERSOURCE_ACCOUNT"), getEnv("CYBERSOURCE_API_KEY"), getEnv("CYBERSOURCE_SHARED_SECRET"))
+	client := newCybersourceClient(t)
 	request := sleet_testing.BaseRefundRequest()
 	request.TransactionReference = ""
 	resp, err := client.Refund(request)
@@ -127,3 +128,12 @@ func TestMissingReference(t *testing.T) {
 		t.Errorf("Expected no response, received %v", resp)
 	}
 }
+
+func newCybersourceClient(t *testing.T) *cybersource.CybersourceClient {
+	if hasEnv("CYBERSOURCE_ACCOUNT", "CYBERSOURCE_API_KEY", "CYBERSOURCE_SHARED_SECRET") {
+		return cybersource.NewClient(common.Sandbox, getEnv("CYBERSOURCE_ACCOUNT"), getEnv("CYBERSOURCE_API_KEY"), getEnv("CYBERSOURCE_SHARED_SECRET"))
+	}
+	fake := fakes.NewCybersource()
+	t.Cleanup(fake.Close)
+	return cybersource.NewWithHttpClient(common.Sandbox, fakes.CybersourceMerchantID, fakes.CybersourceKeyID, fakes.CybersourceSharedSecret, fake.Client())
+}
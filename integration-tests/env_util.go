@@ -12,3 +12,14 @@ func getEnv(key string) string {
 	}
 	return v
 }
+
+// hasEnv reports whether all the environment variables are set. A PsP's tests run against its sandbox when its
+// credentials are set, and against a local fake from the fakes package otherwise.
+func hasEnv(keys ...string) bool {
+	for _, key := range keys {
+		if len(os.Getenv(key)) == 0 {
+			return false
+		}
+	}
+	return true
+}
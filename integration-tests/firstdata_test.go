@@ -0,0 +1,200 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/Pallinder/go-randomdata"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+	"github.com/BoltApp/sleet/gateways/firstdata"
+	sleet_testing "github.com/BoltApp/sleet/testing"
+	"github.com/BoltApp/sleet/testing/fakes"
+)
+
+var firstdataEnv = []string{"FIRSTDATA_API_KEY", "FIRSTDATA_API_SECRET"}
+
+func newFirstdataClient(t *testing.T) *firstdata.FirstdataClient {
+	if hasEnv(firstdataEnv...) {
+		return firstdata.NewClient(common.Sandbox, firstdata.Credentials{
+			ApiKey:    getEnv("FIRSTDATA_API_KEY"),
+			ApiSecret: getEnv("FIRSTDATA_API_SECRET"),
+		})
+	}
+	fake := fakes.NewFirstdata()
+	t.Cleanup(fake.Close)
+	return firstdata.NewWithHttpClient(common.Sandbox, firstdata.Credentials{
+		ApiKey:    fakes.FirstdataApiKey,
+		ApiSecret: fakes.FirstdataApiSecret,
+	}, fake.Client())
+}
+
+// firstdataRequestID returns a new Client-Request-Id, which First Data expects to be unique for every request
+func firstdataRequestID() *string {
+	return common.SPtr(randomdata.Alphanumeric(20))
+}
+
+// TestFirstdataAuthorize
+//
+// This should successfully create an authorization on First Data
+func TestFirstdataAuthorize(t *testing.T) {
+	client := newFirstdataClient(t)
+	authRequest := sleet_testing.BaseAuthorizationRequest()
+	auth, err := client.Authorize(authRequest)
+	if err != nil {
+		t.Fatalf("Authorize request should not have failed: %s", err)
+	}
+	if !auth.Success {
+		t.Errorf("Resulting auth should have been successful: %s", auth.ErrorCode)
+	}
+	if auth.TransactionReference == "" {
+		t.Error("Resulting auth should have a transaction reference")
+	}
+}
+
+// TestFirstdataAuthorizeDeclined
+//
+// This should decline an authorization of the fake's declined card
+func TestFirstdataAuthorizeDeclined(t *testing.T) {
+	if hasEnv(firstdataEnv...) {
+		t.Skip("the declined card is specific to the First Data fake")
+	}
+	client := newFirstdataClient(t)
+	authRequest := sleet_testing.BaseAuthorizationRequest()
+	authRequest.CreditCard.Number = fakes.FirstdataDeclinedCard
+	auth, err := client.Authorize(authRequest)
+	if err != nil {
+		t.Fatalf("Authorize request should not have failed: %s", err)
+	}
+	if auth.Success {
+		t.Error("Resulting auth should have been declined")
+	}
+	if auth.DeclineReason != sleet.DeclineReasonDoNotHonor {
+		t.Errorf("Expected decline reason %s: received: %s", sleet.DeclineReasonDoNotHonor, auth.DeclineReason)
+	}
+}
+
+// TestFirstdataAuthCaptureRefund
+//
+// This should successfully create an authorization on First Data, capture it, refund the capture and find the refund
+func TestFirstdataAuthCaptureRefund(t *testing.T) {
+	client := newFirstdataClient(t)
+	authRequest := sleet_testing.BaseAuthorizationRequest()
+	auth, err := client.Authorize(authRequest)
+	if err != nil {
+		t.Fatalf("Authorize request should not have failed: %s", err)
+	}
+	if !auth.Success {
+		t.Fatalf("Resulting auth should have been successful: %s", auth.ErrorCode)
+	}
+
+	capture, err := client.Capture(&sleet.CaptureRequest{
+		Amount:                     &authRequest.Amount,
+		TransactionReference:       auth.TransactionReference,
+		ClientTransactionReference: firstdataRequestID(),
+	})
+	if err != nil {
+		t.Fatalf("Capture request should not have failed: %s", err)
+	}
+	if !capture.Success {
+		t.Fatalf("Resulting capture should have been successful: %s", *capture.ErrorCode)
+	}
+
+	query, err := client.QueryTransaction(&sleet.TransactionQueryRequest{
+		TransactionReference:       auth.TransactionReference,
+		ClientTransactionReference: firstdataRequestID(),
+	})
+	if err != nil {
+		t.Fatalf("Query request should not have failed: %s", err)
+	}
+	if query.State != sleet.TransactionStateCaptured {
+		t.Errorf("Expected the authorization to be captured: received: %s", query.State)
+	}
+
+	refund, err := client.Refund(&sleet.RefundRequest{
+		Amount:                     &authRequest.Amount,
+		TransactionReference:       auth.TransactionReference,
+		ClientTransactionReference: firstdataRequestID(),
+	})
+	if err != nil {
+		t.Fatalf("Refund request should not have failed: %s", err)
+	}
+	if !refund.Success {
+		t.Fatalf("Resulting refund should have been successful: %s", *refund.ErrorCode)
+	}
+
+	query, err = client.QueryTransaction(&sleet.TransactionQueryRequest{
+		TransactionReference:       refund.TransactionReference,
+		ClientTransactionReference: firstdataRequestID(),
+	})
+	if err != nil {
+		t.Fatalf("Query request should not have failed: %s", err)
+	}
+	if query.State != sleet.TransactionStateRefunded {
+		t.Errorf("Expected the transaction to be a refund: received: %s", query.State)
+	}
+}
+
+// TestFirstdataCaptureExceedingAuthorization
+//
+// This should fail to capture more than was authorized
+func TestFirstdataCaptureExceedingAuthorization(t *testing.T) {
+	client := newFirstdataClient(t)
+	authRequest := sleet_testing.BaseAuthorizationRequest()
+	auth, err := client.Authorize(authRequest)
+	if err != nil {
+		t.Fatalf("Authorize request should not have failed: %s", err)
+	}
+	if !auth.Success {
+		t.Fatalf("Resulting auth should have been successful: %s", auth.ErrorCode)
+	}
+
+	capture, err := client.Capture(&sleet.CaptureRequest{
+		Amount:                     &sleet.Amount{Amount: authRequest.Amount.Amount + 100, Currency: authRequest.Amount.Currency},
+		TransactionReference:       auth.TransactionReference,
+		ClientTransactionReference: firstdataRequestID(),
+	})
+	if err != nil {
+		t.Fatalf("Capture request should not have failed: %s", err)
+	}
+	if capture.Success {
+		t.Error("Resulting capture should have failed")
+	}
+}
+
+// TestFirstdataAuthVoid
+//
+// This should successfully create an authorization on First Data then void it
+func TestFirstdataAuthVoid(t *testing.T) {
+	client := newFirstdataClient(t)
+	authRequest := sleet_testing.BaseAuthorizationRequest()
+	auth, err := client.Authorize(authRequest)
+	if err != nil {
+		t.Fatalf("Authorize request should not have failed: %s", err)
+	}
+	if !auth.Success {
+		t.Fatalf("Resulting auth should have been successful: %s", auth.ErrorCode)
+	}
+
+	void, err := client.Void(&sleet.VoidRequest{
+		TransactionReference:       auth.TransactionReference,
+		ClientTransactionReference: firstdataRequestID(),
+	})
+	if err != nil {
+		t.Fatalf("Void request should not have failed: %s", err)
+	}
+	if !void.Success {
+		t.Fatalf("Resulting void should have been successful: %s", *void.ErrorCode)
+	}
+
+	query, err := client.QueryTransaction(&sleet.TransactionQueryRequest{
+		TransactionReference:       auth.TransactionReference,
+		ClientTransactionReference: firstdataRequestID(),
+	})
+	if err != nil {
+		t.Fatalf("Query request should not have failed: %s", err)
+	}
+	if query.State != sleet.TransactionStateVoided {
+		t.Errorf("Expected the authorization to be voided: received: %s", query.State)
+	}
+}
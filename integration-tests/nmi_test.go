@@ -9,10 +9,11 @@ import (
 	"github.com/BoltApp/sleet/common"
 	"github.com/BoltApp/sleet/gateways/nmi"
 	sleet_testing "github.com/BoltApp/sleet/testing"
+	"github.com/BoltApp/sleet/testing/fakes"
 )
 
 func TestNMIAuthorize(t *testing.T) {
-	client := nmi.NewClient(common.Sandbox, getEnv("NMI_SECURITY_KEY"))
+	client := newNMIClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequest()
 	authRequest.MerchantOrderReference = "test_merchant_reference"
 
@@ -32,7 +33,7 @@ func TestNMIAuthorize(t *testing.T) {
 }
 
 func TestNMIAuthorizeDeclined(t *testing.T) {
-	client := nmi.NewClient(common.Sandbox, getEnv("NMI_SECURITY_KEY"))
+	client := newNMIClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequest()
 
 	authRequest.Amount.Amount = int64(99)
@@ -51,7 +52,7 @@ func TestNMIAuthorizeDeclined(t *testing.T) {
 }
 
 func TestNMIAuthorizeAndCapture(t *testing.T) {
-	client := nmi.NewClient(common.Sandbox, getEnv("NMI_SECURITY_KEY"))
+	client := newNMIClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequest()
 	authRequest.MerchantOrderReference = "test_merchant_reference"
 
@@ -89,7 +90,7 @@ func TestNMIAuthorizeAndCapture(t *testing.T) {
 }
 
 func TestNMIAuthorizeAndCaptureFailed(t *testing.T) {
-	client := nmi.NewClient(common.Sandbox, getEnv("NMI_SECURITY_KEY"))
+	client := newNMIClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequest()
 
 	rand.Seed(time.Now().UnixNano())
@@ -130,7 +131,7 @@ func TestNMIAuthorizeAndCaptureFailed(t *testing.T) {
 }
 
 func TestNMIVoid(t *testing.T) {
-	client := nmi.NewClient(common.Sandbox, getEnv("NMI_SECURITY_KEY"))
+	client := newNMIClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequest()
 
 	rand.Seed(time.Now().UnixNano())
@@ -167,7 +168,7 @@ func TestNMIVoid(t *testing.T) {
 }
 
 func TestNMIVoidFailed(t *testing.T) {
-	client := nmi.NewClient(common.Sandbox, getEnv("NMI_SECURITY_KEY"))
+	client := newNMIClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequest()
 
 	rand.Seed(time.Now().UnixNano())
@@ -200,7 +201,7 @@ func TestNMIVoidFailed(t *testing.T) {
 }
 
 func TestNMIRefund(t *testing.T) {
-	client := nmi.NewClient(common.Sandbox, getEnv("NMI_SECURITY_KEY"))
+	client := newNMIClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequest()
 
 	rand.Seed(time.Now().UnixNano())
@@ -250,7 +251,7 @@ func TestNMIRefund(t *testing.T) {
 }
 
 func TestNMIRefundFailed(t *testing.T) {
-	client := nmi.NewClient(common.Sandbox, getEnv("NMI_SECURITY_KEY"))
+	client := newNMIClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequest()
 
 	rand.Seed(time.Now().UnixNano())
@@ -295,3 +296,13 @@ func TestNMIRefundFailed(t *testing.T) {
 		t.Errorf("Expected void error code to be 300: recieved %s", *refundResp.ErrorCode)
 	}
 }
+
+// newNMIClient returns a client for NMI's sandbox when NMI_SECURITY_KEY is set, and for a local fake otherwise
+func newNMIClient(t *testing.T) *nmi.NMIClient {
+	if hasEnv("NMI_SECURITY_KEY") {
+		return nmi.NewClient(common.Sandbox, getEnv("NMI_SECURITY_KEY"))
+	}
+	fake := fakes.NewNMI()
+	t.Cleanup(fake.Close)
+	return nmi.NewWithHttpClient(common.Sandbox, fakes.NMISecurityKey, fake.Client())
+}
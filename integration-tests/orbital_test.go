@@ -0,0 +1,251 @@
+package test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+	"github.com/BoltApp/sleet/gateways/orbital"
+	sleet_testing "github.com/BoltApp/sleet/testing"
+	"github.com/BoltApp/sleet/testing/fakes"
+)
+
+var orbitalEnv = []string{"ORBITAL_USERNAME", "ORBITAL_PASSWORD", "ORBITAL_MERCHANT_ID"}
+
+func newOrbitalClient(t *testing.T) *orbital.OrbitalClient {
+	if hasEnv(orbitalEnv...) {
+		merchantID, err := strconv.Atoi(getEnv("ORBITAL_MERCHANT_ID"))
+		if err != nil {
+			t.Fatalf("ORBITAL_MERCHANT_ID should be a number: %s", err)
+		}
+		return orbital.NewClient(common.Sandbox, orbital.Credentials{
+			Username:   getEnv("ORBITAL_USERNAME"),
+			Password:   getEnv("ORBITAL_PASSWORD"),
+			MerchantID: merchantID,
+		})
+	}
+	fake := fakes.NewOrbital()
+	t.Cleanup(fake.Close)
+	return orbital.NewWithHttpClient(common.Sandbox, orbital.Credentials{
+		Username:   fakes.OrbitalUsername,
+		Password:   fakes.OrbitalPassword,
+		MerchantID: fakes.OrbitalMerchantID,
+	}, fake.Client())
+}
+
+// TestOrbitalAuthorize
+//
+// This should successfully create an authorization on Orbital
+func TestOrbitalAuthorize(t *testing.T) {
+	client := newOrbitalClient(t)
+	authRequest := sleet_testing.BaseAuthorizationRequest()
+	auth, err := client.Authorize(authRequest)
+	if err != nil {
+		t.Fatalf("Authorize request should not have failed: %s", err)
+	}
+	if !auth.Success {
+		t.Errorf("Resulting auth should have been successful: %s", auth.ErrorCode)
+	}
+	if auth.TransactionReference == "" {
+		t.Error("Resulting auth should have a transaction reference")
+	}
+}
+
+// TestOrbitalAuthorizeDeclined
+//
+// This should decline an authorization of the fake's declined card
+func TestOrbitalAuthorizeDeclined(t *testing.T) {
+	if hasEnv(orbitalEnv...) {
+		t.Skip("the declined card is specific to the Orbital fake")
+	}
+	client := newOrbitalClient(t)
+	authRequest := sleet_testing.BaseAuthorizationRequest()
+	authRequest.CreditCard.Number = fakes.OrbitalDeclinedCard
+	auth, err := client.Authorize(authRequest)
+	if err != nil {
+		t.Fatalf("Authorize request should not have failed: %s", err)
+	}
+	if auth.Success {
+		t.Error("Resulting auth should have been declined")
+	}
+	if auth.ErrorCode != "05" {
+		t.Errorf("Expected error code 05: received: %s", auth.ErrorCode)
+	}
+}
+
+// TestOrbitalAuthCaptureRefund
+//
+// This should successfully create an authorization on Orbital, capture it and refund the capture
+func TestOrbitalAuthCaptureRefund(t *testing.T) {
+	client := newOrbitalClient(t)
+	authRequest := sleet_testing.BaseAuthorizationRequest()
+	auth, err := client.Authorize(authRequest)
+	if err != nil {
+		t.Fatalf("Authorize request should not have failed: %s", err)
+	}
+	if !auth.Success {
+		t.Fatalf("Resulting auth should have been successful: %s", auth.ErrorCode)
+	}
+
+	capture, err := client.Capture(&sleet.CaptureRequest{
+		Amount:                     &authRequest.Amount,
+		TransactionReference:       auth.TransactionReference,
+		ClientTransactionReference: authRequest.ClientTransactionReference,
+	})
+	if err != nil {
+		t.Fatalf("Capture request should not have failed: %s", err)
+	}
+	if !capture.Success {
+		t.Fatalf("Resulting capture should have been successful: %s", *capture.ErrorCode)
+	}
+
+	refund, err := client.Refund(&sleet.RefundRequest{
+		Amount:                     &authRequest.Amount,
+		TransactionReference:       capture.TransactionReference,
+		ClientTransactionReference: authRequest.ClientTransactionReference,
+	})
+	if err != nil {
+		t.Fatalf("Refund request should not have failed: %s", err)
+	}
+	if !refund.Success {
+		t.Errorf("Resulting refund should have been successful: %s", *refund.ErrorCode)
+	}
+}
+
+// TestOrbitalCaptureExceedingAuthorization
+//
+// This should fail to capture more than was authorized
+func TestOrbitalCaptureExceedingAuthorization(t *testing.T) {
+	client := newOrbitalClient(t)
+	authRequest := sleet_testing.BaseAuthorizationRequest()
+	auth, err := client.Authorize(authRequest)
+	if err != nil {
+		t.Fatalf("Authorize request should not have failed: %s", err)
+	}
+	if !auth.Success {
+		t.Fatalf("Resulting auth should have been successful: %s", auth.ErrorCode)
+	}
+
+	capture, err := client.Capture(&sleet.CaptureRequest{
+		Amount:                     &sleet.Amount{Amount: authRequest.Amount.Amount + 100, Currency: authRequest.Amount.Currency},
+		TransactionReference:       auth.TransactionReference,
+		ClientTransactionReference: authRequest.ClientTransactionReference,
+	})
+	if err != nil {
+		t.Fatalf("Capture request should not have failed: %s", err)
+	}
+	if capture.Success {
+		t.Error("Resulting capture should have failed")
+	}
+}
+
+// TestOrbitalAuthVoid
+//
+// This should successfully create an authorization on Orbital then void it
+func TestOrbitalAuthVoid(t *testing.T) {
+	client := newOrbitalClient(t)
+	authRequest := sleet_testing.BaseAuthorizationRequest()
+	auth, err := client.Authorize(authRequest)
+	if err != nil {
+		t.Fatalf("Authorize request should not have failed: %s", err)
+	}
+	if !auth.Success {
+		t.Fatalf("Resulting auth should have been successful: %s", auth.ErrorCode)
+	}
+
+	void, err := client.Void(&sleet.VoidRequest{
+		TransactionReference:       auth.TransactionReference,
+		ClientTransactionReference: authRequest.ClientTransactionReference,
+	})
+	if err != nil {
+		t.Fatalf("Void request should not have failed: %s", err)
+	}
+	if !void.Success {
+		t.Errorf("Resulting void should have been successful: %s", *void.ErrorCode)
+	}
+}
+
+// TestOrbitalReverseTimedOutAuthorization
+//
+// This should reverse an authorization by retrying it with its trace number
+func TestOrbitalReverseTimedOutAuthorization(t *testing.T) {
+	client := newOrbitalClient(t)
+	authRequest := sleet_testing.BaseAuthorizationRequest()
+	authRequest.Options = map[string]interface{}{sleet.TimeoutReversalOption: true}
+	auth, err := client.Authorize(authRequest)
+	if err != nil {
+		t.Fatalf("Authorize request should not have failed: %s", err)
+	}
+	if !auth.Success {
+		t.Fatalf("Resulting auth should have been successful: %s", auth.ErrorCode)
+	}
+
+	reversal, err := client.ReverseTimedOutAuthorization(sleet.NewTimeoutReversalRequest(authRequest))
+	if err != nil {
+		t.Fatalf("Reversal request should not have failed: %s", err)
+	}
+	if !reversal.Success {
+		t.Fatalf("Resulting reversal should have been successful: %v", reversal.ErrorCode)
+	}
+	if reversal.TransactionReference != auth.TransactionReference {
+		t.Errorf("Expected the authorization %s to be reversed: received: %s", auth.TransactionReference, reversal.TransactionReference)
+	}
+}
+
+// TestOrbitalReverseTimedOutPartialAuthorization
+//
+// This should reverse a partially approved authorization, which leaves a hold for the approved amount
+func TestOrbitalReverseTimedOutPartialAuthorization(t *testing.T) {
+	if hasEnv(orbitalEnv...) {
+		t.Skip("the partial approval limit is specific to the Orbital fake")
+	}
+	client := newOrbitalClient(t)
+	authRequest := sleet_testing.BaseAuthorizationRequest()
+	// the fake approves $1,000 of larger authorizations which allow partial approval
+	authRequest.Amount.Amount = 150000
+	authRequest.AllowPartialAuth = true
+	authRequest.Options = map[string]interface{}{sleet.TimeoutReversalOption: true}
+	auth, err := client.Authorize(authRequest)
+	if err != nil {
+		t.Fatalf("Authorize request should not have failed: %s", err)
+	}
+	if !auth.Success || auth.ApprovedAmount == nil {
+		t.Fatalf("Resulting auth should have been partially approved: %s", auth.ErrorCode)
+	}
+
+	reversal, err := client.ReverseTimedOutAuthorization(sleet.NewTimeoutReversalRequest(authRequest))
+	if err != nil {
+		t.Fatalf("Reversal request should not have failed: %s", err)
+	}
+	if !reversal.Success || reversal.NotAuthorized {
+		t.Fatalf("Resulting reversal should have reversed the authorization: %+v", reversal)
+	}
+	if reversal.TransactionReference != auth.TransactionReference {
+		t.Errorf("Expected the authorization %s to be reversed: received: %s", auth.TransactionReference, reversal.TransactionReference)
+	}
+}
+
+// TestOrbitalReverseTimedOutDeclinedAuthorization
+//
+// This should reverse nothing for a declined authorization, and report it wasn't authorized
+func TestOrbitalReverseTimedOutDeclinedAuthorization(t *testing.T) {
+	if hasEnv(orbitalEnv...) {
+		t.Skip("the declined card is specific to the Orbital fake")
+	}
+	client := newOrbitalClient(t)
+	authRequest := sleet_testing.BaseAuthorizationRequest()
+	authRequest.CreditCard.Number = fakes.OrbitalDeclinedCard
+	authRequest.Options = map[string]interface{}{sleet.TimeoutReversalOption: true}
+	if _, err := client.Authorize(authRequest); err != nil {
+		t.Fatalf("Authorize request should not have failed: %s", err)
+	}
+
+	reversal, err := client.ReverseTimedOutAuthorization(sleet.NewTimeoutReversalRequest(authRequest))
+	if err != nil {
+		t.Fatalf("Reversal request should not have failed: %s", err)
+	}
+	if !reversal.Success || !reversal.NotAuthorized {
+		t.Errorf("Resulting reversal should report the authorization wasn't approved: %+v", reversal)
+	}
+}
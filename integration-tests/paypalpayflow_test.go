@@ -9,13 +9,16 @@ import (
 	"github.com/BoltApp/sleet/common"
 	"github.com/BoltApp/sleet/gateways/paypalpayflow"
 	sleet_testing "github.com/BoltApp/sleet/testing"
+	"github.com/BoltApp/sleet/testing/fakes"
 )
 
+var paypalPayflowEnv = []string{"PAYPAL_PARTNER", "PAYPAL_PASSWORD", "PAYPAL_VENDOR", "PAYPAL_USER"}
+
 // TestPaypalAuth
 //
 // This should successfully create an authorization on Paypal Payflow
 func TestPaypalAuth(t *testing.T) {
-	client := paypalpayflow.NewClient(getEnv("PAYPAL_PARTNER"), getEnv("PAYPAL_PASSWORD"), getEnv("PAYPAL_VENDOR"), getEnv("PAYPAL_USER"), common.Sandbox)
+	client := newPaypalPayflowClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequestWithEmailPhoneNumber()
 	authRequest.Amount.Amount = int64(randomdata.Number(10000))
 	authRequest.MerchantOrderReference = "test-order-ref"
@@ -33,7 +36,10 @@ func TestPaypalAuth(t *testing.T) {
 }
 
 func TestPaypalAuthBadCredentials(t *testing.T) {
-	client := paypalpayflow.NewClient("PAYPAL_PARTNER", "PAYPAL_PASSWORD", getEnv("PAYPAL_VENDOR"), getEnv("PAYPAL_USER"), common.Sandbox)
+	client := paypalpayflow.NewWithHttpClient("PAYPAL_PARTNER", "PAYPAL_PASSWORD", fakes.PaypalPayflowVendor, fakes.PaypalPayflowUser, common.Sandbox, newPaypalPayflowFake(t).Client())
+	if hasEnv(paypalPayflowEnv...) {
+		client = paypalpayflow.NewClient("PAYPAL_PARTNER", "PAYPAL_PASSWORD", getEnv("PAYPAL_VENDOR"), getEnv("PAYPAL_USER"), common.Sandbox)
+	}
 	authRequest := sleet_testing.BaseAuthorizationRequestWithEmailPhoneNumber()
 	authRequest.Amount.Amount = int64(randomdata.Number(10000))
 	authRequest.MerchantOrderReference = "test-order-ref"
@@ -54,7 +60,7 @@ func TestPaypalAuthBadCredentials(t *testing.T) {
 //
 // This should successfully create an authorization on Paypal Payflow then Capture for full amount
 func TestPaypalAuthFullCapture(t *testing.T) {
-	client := paypalpayflow.NewClient(getEnv("PAYPAL_PARTNER"), getEnv("PAYPAL_PASSWORD"), getEnv("PAYPAL_VENDOR"), getEnv("PAYPAL_USER"), common.Sandbox)
+	client := newPaypalPayflowClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequestWithEmailPhoneNumber()
 	authRequest.Amount.Amount = int64(randomdata.Number(10000))
 	authRequest.MerchantOrderReference = "test-order-ref"
@@ -86,7 +92,7 @@ func TestPaypalAuthFullCapture(t *testing.T) {
 //
 // This should successfully create an authorization on Paypal Payflow then Void/Cancel the Auth
 func TestPaypalAuthVoid(t *testing.T) {
-	client := paypalpayflow.NewClient(getEnv("PAYPAL_PARTNER"), getEnv("PAYPAL_PASSWORD"), getEnv("PAYPAL_VENDOR"), getEnv("PAYPAL_USER"), common.Sandbox)
+	client := newPaypalPayflowClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequestWithEmailPhoneNumber()
 	authRequest.MerchantOrderReference = "test-order-ref"
 	authRequest.CreditCard.ExpirationMonth = 3
@@ -119,7 +125,7 @@ func TestPaypalAuthVoid(t *testing.T) {
 // TODO: Have this refer to the auth/capture transactionId once automatic settlement is available
 func TestPaypalAuthCaptureRefund(t *testing.T) {
 	transactionID := "test-order-refund"
-	client := paypalpayflow.NewClient(getEnv("PAYPAL_PARTNER"), getEnv("PAYPAL_PASSWORD"), getEnv("PAYPAL_VENDOR"), getEnv("PAYPAL_USER"), common.Sandbox)
+	client := newPaypalPayflowClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequestWithEmailPhoneNumber()
 	authRequest.Amount.Amount = int64(randomdata.Number(100) * 100)
 	authRequest.MerchantOrderReference = transactionID
@@ -166,7 +172,7 @@ func TestPaypalAuthCaptureRefund(t *testing.T) {
 
 func TestPaypalAuthCaptureRefundWithNonUSDCurrency(t *testing.T) {
 	transactionID := "test-order-refund"
-	client := paypalpayflow.NewClient(getEnv("PAYPAL_PARTNER"), getEnv("PAYPAL_PASSWORD"), getEnv("PAYPAL_VENDOR"), getEnv("PAYPAL_USER"), common.Sandbox)
+	client := newPaypalPayflowClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequestWithEmailPhoneNumber()
 	authRequest.Amount.Amount = int64(randomdata.Number(100) * 100)
 	authRequest.Amount.Currency = "CAD"
@@ -211,3 +217,17 @@ func TestPaypalAuthCaptureRefundWithNonUSDCurrency(t *testing.T) {
 		t.Error("Resulting refund should have been successful")
 	}
 }
+
+func newPaypalPayflowClient(t *testing.T) *paypalpayflow.PaypalPayflowClient {
+	if hasEnv(paypalPayflowEnv...) {
+		return paypalpayflow.NewClient(getEnv("PAYPAL_PARTNER"), getEnv("PAYPAL_PASSWORD"), getEnv("PAYPAL_VENDOR"), getEnv("PAYPAL_USER"), common.Sandbox)
+	}
+	fake := newPaypalPayflowFake(t)
+	return paypalpayflow.NewWithHttpClient(fakes.PaypalPayflowPartner, fakes.PaypalPayflowPassword, fakes.PaypalPayflowVendor, fakes.PaypalPayflowUser, common.Sandbox, fake.Client())
+}
+
+func newPaypalPayflowFake(t *testing.T) *fakes.Server {
+	fake := fakes.NewPaypalPayflow()
+	t.Cleanup(fake.Close)
+	return fake
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/BoltApp/sleet/common"
 	"github.com/BoltApp/sleet/gateways/rocketgate"
 	sleet_testing "github.com/BoltApp/sleet/testing"
+	"github.com/BoltApp/sleet/testing/fakes"
 )
 
 /*
@@ -20,7 +21,7 @@ import (
  */
 
 func TestRocketGateAuthorize(t *testing.T) {
-	client := rocketgate.NewClient(common.Sandbox, getEnv("ROCKETGATE_MERCHANT_ID"), getEnv("ROCKETGATE_MERCHANT_PASSWORD"), nil)
+	client := newRocketgateClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequest()
 
 	resp, err := client.Authorize(authRequest)
@@ -33,7 +34,7 @@ func TestRocketGateAuthorize(t *testing.T) {
 }
 
 func TestRocketGateAuthorizeFailed(t *testing.T) {
-	client := rocketgate.NewClient(common.Sandbox, getEnv("ROCKETGATE_MERCHANT_ID"), getEnv("ROCKETGATE_MERCHANT_PASSWORD"), nil)
+	client := newRocketgateClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequest()
 
 	// 1 cent will always be declined in RocketGate dev
@@ -53,7 +54,7 @@ func TestRocketGateAuthorizeFailed(t *testing.T) {
 }
 
 func TestRocketGateAuthFullCapture(t *testing.T) {
-	client := rocketgate.NewClient(common.Sandbox, getEnv("ROCKETGATE_MERCHANT_ID"), getEnv("ROCKETGATE_MERCHANT_PASSWORD"), nil)
+	client := newRocketgateClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequest()
 
 	auth, err := client.Authorize(authRequest)
@@ -79,7 +80,7 @@ func TestRocketGateAuthFullCapture(t *testing.T) {
 }
 
 func TestRocketGateAuthVoid(t *testing.T) {
-	client := rocketgate.NewClient(common.Sandbox, getEnv("ROCKETGATE_MERCHANT_ID"), getEnv("ROCKETGATE_MERCHANT_PASSWORD"), nil)
+	client := newRocketgateClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequest()
 	auth, err := client.Authorize(authRequest)
 	if err != nil {
@@ -103,7 +104,7 @@ func TestRocketGateAuthVoid(t *testing.T) {
 }
 
 func TestRocketGateAuthCaptureRefund(t *testing.T) {
-	client := rocketgate.NewClient(common.Sandbox, getEnv("ROCKETGATE_MERCHANT_ID"), getEnv("ROCKETGATE_MERCHANT_PASSWORD"), nil)
+	client := newRocketgateClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequest()
 	auth, err := client.Authorize(authRequest)
 	if err != nil {
@@ -139,3 +140,12 @@ func TestRocketGateAuthCaptureRefund(t *testing.T) {
 		t.Error("Resulting refund should have been successful")
 	}
 }
+
+func newRocketgateClient(t *testing.T) *rocketgate.RocketgateClient {
+	if hasEnv("ROCKETGATE_MERCHANT_ID", "ROCKETGATE_MERCHANT_PASSWORD") {
+		return rocketgate.NewClient(common.Sandbox, getEnv("ROCKETGATE_MERCHANT_ID"), getEnv("ROCKETGATE_MERCHANT_PASSWORD"), nil)
+	}
+	fake := fakes.NewRocketgate()
+	t.Cleanup(fake.Close)
+	return rocketgate.NewWithHttpClient(common.Sandbox, fakes.RocketgateMerchantID, fakes.RocketgateMerchantPassword, nil, fake.Client())
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/BoltApp/sleet"
 	"github.com/BoltApp/sleet/gateways/stripe"
 	sleet_testing "github.com/BoltApp/sleet/testing"
+	"github.com/BoltApp/sleet/testing/fakes"
 )
 
 // Note: For all of these tests, we enabled raw credit card processing to charges API
@@ -18,17 +19,20 @@ import (
 // Stripe has test cards here: https://stripe.com/docs/testing#cards-responses
 // Using a rejected card number
 func TestStripeAuthorizeFailed(t *testing.T) {
-	client := stripe.NewClient(getEnv("STRIPE_TEST_KEY"))
+	client := newStripeClient(t)
 	failedRequest := sleet_testing.BaseAuthorizationRequest()
 	// set ClientTransactionReference to be empty
 	failedRequest.CreditCard.Number = "4000000000009995"
-	_, err := client.Authorize(failedRequest)
-	if err == nil {
+	auth, err := client.Authorize(failedRequest)
+	if err != nil {
+		t.Fatalf("Error thrown after sending request %q", err)
+	}
+	if auth.Success {
 		t.Error("Authorize request should have failed with bad card number")
 	}
 
-	if !strings.Contains(err.Error(), "Your card has insufficient funds.") {
-		t.Errorf("Response should contain insufficient funds- %s", err.Error())
+	if !strings.Contains(auth.Message, "Your card has insufficient funds.") {
+		t.Errorf("Response should contain insufficient funds- %s", auth.Message)
 	}
 }
 
@@ -36,7 +40,7 @@ func TestStripeAuthorizeFailed(t *testing.T) {
 //
 // This should successfully create an authorization on Stripe
 func TestStripeAuth(t *testing.T) {
-	client := stripe.NewClient(getEnv("STRIPE_TEST_KEY"))
+	client := newStripeClient(t)
 	request := sleet_testing.BaseAuthorizationRequest()
 	auth, err := client.Authorize(request)
 	if err != nil {
@@ -52,7 +56,7 @@ func TestStripeAuth(t *testing.T) {
 //
 // This should successfully create an authorization on Stripe then Capture for full amount
 func TestStripeAuthFullCapture(t *testing.T) {
-	client := stripe.NewClient(getEnv("STRIPE_TEST_KEY"))
+	client := newStripeClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequest()
 	auth, err := client.Authorize(authRequest)
 	if err != nil {
@@ -82,7 +86,7 @@ func TestStripeAuthFullCapture(t *testing.T) {
 // This should successfully create an authorization on Stripe then Capture for a partial amount
 // Since we auth for 1.00USD, we will capture for $0.50
 func TestStripeAuthPartialCapture(t *testing.T) {
-	client := stripe.NewClient(getEnv("STRIPE_TEST_KEY"))
+	client := newStripeClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequest()
 	auth, err := client.Authorize(authRequest)
 	if err != nil {
@@ -113,7 +117,7 @@ func TestStripeAuthPartialCapture(t *testing.T) {
 //
 // This should successfully create an authorization on Stripe then Void/Cancel the Auth
 func TestStripeAuthVoid(t *testing.T) {
-	client := stripe.NewClient(getEnv("STRIPE_TEST_KEY"))
+	client := newStripeClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequest()
 	auth, err := client.Authorize(authRequest)
 	if err != nil {
@@ -141,7 +145,7 @@ func TestStripeAuthVoid(t *testing.T) {
 //
 // This should successfully create an authorization on Stripe then Capture for full amount, then refund for full amount
 func TestStripeAuthCaptureRefund(t *testing.T) {
-	client := stripe.NewClient(getEnv("STRIPE_TEST_KEY"))
+	client := newStripeClient(t)
 	authRequest := sleet_testing.BaseAuthorizationRequest()
 	auth, err := client.Authorize(authRequest)
 	if err != nil {
@@ -179,3 +183,12 @@ func TestStripeAuthCaptureRefund(t *testing.T) {
 		t.Error("Resulting refund should have been successful")
 	}
 }
+
+func newStripeClient(t *testing.T) *stripe.StripeClient {
+	if hasEnv("STRIPE_TEST_KEY") {
+		return stripe.NewClient(getEnv("STRIPE_TEST_KEY"))
+	}
+	fake := fakes.NewStripe()
+	t.Cleanup(fake.Close)
+	return stripe.NewWithHTTPClient(fakes.StripeAPIKey, fake.Client())
+}
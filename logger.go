@@ -0,0 +1,75 @@
+package sleet
+
+import (
+	"context"
+)
+
+// Logger receives the events of the gateways. Each message is followed by alternating keys and values, such as
+// "gateway", "cybersource", "http_status", 201. Card data and credentials are never passed to a Logger.
+type Logger interface {
+	Debug(message string, keysAndValues ...interface{})
+	Info(message string, keysAndValues ...interface{})
+	Warn(message string, keysAndValues ...interface{})
+	Error(message string, keysAndValues ...interface{})
+}
+
+// NopLogger discards every event, it is the default logger of the gateways
+type NopLogger struct{}
+
+func (NopLogger) Debug(string, ...interface{}) {}
+func (NopLogger) Info(string, ...interface{})  {}
+func (NopLogger) Warn(string, ...interface{})  {}
+func (NopLogger) Error(string, ...interface{}) {}
+
+type loggerKey struct{}
+
+// ContextWithLogger returns a context whose requests log to logger, instead of the logger the client was built with
+func ContextWithLogger(ctx context.Context, logger Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// LoggerFromContext returns the logger set by ContextWithLogger, or nil
+func LoggerFromContext(ctx context.Context) Logger {
+	if ctx == nil {
+		return nil
+	}
+	logger, _ := ctx.Value(loggerKey{}).(Logger)
+	return logger
+}
+
+// ClientOptions are the settings shared by every gateway client, set with ClientOption functions
+type ClientOptions struct {
+	Logger  Logger
+	BaseURL string // replaces the scheme and host of the PsP's URLs when set, see WithBaseURL
+}
+
+// ClientOption is passed to gateway constructors to change their ClientOptions
+type ClientOption func(options *ClientOptions)
+
+// WithLogger sends the client's events to logger
+func WithLogger(logger Logger) ClientOption {
+	return func(options *ClientOptions) {
+		options.Logger = logger
+	}
+}
+
+// WithBaseURL sends the client's requests to baseURL, such as "https://proxy.example.com", instead of the PsP's host.
+// The PsP's paths are kept and prefixed with the path of baseURL, if any. For gateways built on a PsP's SDK the SDK's
+// URL is overridden the same way.
+func WithBaseURL(baseURL string) ClientOption {
+	return func(options *ClientOptions) {
+		options.BaseURL = baseURL
+	}
+}
+
+// NewClientOptions applies the options to the defaults, for use by gateway constructors
+func NewClientOptions(options ...ClientOption) *ClientOptions {
+	clientOptions := &ClientOptions{Logger: NopLogger{}}
+	for _, option := range options {
+		option(clientOptions)
+	}
+	if clientOptions.Logger == nil {
+		clientOptions.Logger = NopLogger{}
+	}
+	return clientOptions
+}
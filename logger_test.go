@@ -0,0 +1,45 @@
+package sleet
+
+import (
+	"context"
+	"testing"
+)
+
+type recordingLogger struct {
+	NopLogger
+	messages []string
+}
+
+func (l *recordingLogger) Info(message string, _ ...interface{}) {
+	l.messages = append(l.messages, message)
+}
+
+func TestNewClientOptions(t *testing.T) {
+	t.Run("Default Logger", func(t *testing.T) {
+		if _, ok := NewClientOptions().Logger.(NopLogger); !ok {
+			t.Error("expected the default logger to be a NopLogger")
+		}
+		if _, ok := NewClientOptions(WithLogger(nil)).Logger.(NopLogger); !ok {
+			t.Error("expected a nil logger to be replaced by a NopLogger")
+		}
+	})
+
+	t.Run("With Logger", func(t *testing.T) {
+		logger := &recordingLogger{}
+		if NewClientOptions(WithLogger(logger)).Logger != logger {
+			t.Error("expected the logger passed to WithLogger")
+		}
+	})
+}
+
+func TestContextWithLogger(t *testing.T) {
+	if LoggerFromContext(context.Background()) != nil {
+		t.Error("expected no logger on a background context")
+	}
+
+	logger := &recordingLogger{}
+	ctx := ContextWithLogger(context.Background(), logger)
+	if LoggerFromContext(ctx) != logger {
+		t.Error("expected the logger set on the context")
+	}
+}
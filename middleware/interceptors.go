@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/BoltApp/sleet"
+)
+
+// LogEntry is the structured record of an operation passed to the Logging function. It never holds the request, so
+// card data and credentials cannot reach the logs.
+type LogEntry struct {
+	Operation            Operation
+	Duration             time.Duration
+	Success              bool   // the Success field of the response, false if there is no response
+	TransactionReference string // the PsP reference of the response, if any
+	Err                  error
+}
+
+// Logging calls log with a LogEntry once each operation completes
+func Logging(log func(ctx context.Context, entry LogEntry)) Interceptor {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, operation Operation, request interface{}) (interface{}, error) {
+			start := time.Now()
+			response, err := next(ctx, operation, request)
+			success, reference := describe(response)
+			log(ctx, LogEntry{
+				Operation:            operation,
+				Duration:             time.Since(start),
+				Success:              success,
+				TransactionReference: reference,
+				Err:                  err,
+			})
+			return response, err
+		}
+	}
+}
+
+// Latency calls observe with the time taken by each operation, including failed ones
+func Latency(observe func(operation Operation, duration time.Duration, err error)) Interceptor {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, operation Operation, request interface{}) (interface{}, error) {
+			start := time.Now()
+			response, err := next(ctx, operation, request)
+			observe(operation, time.Since(start), err)
+			return response, err
+		}
+	}
+}
+
+// PanicError is returned by operations which panicked inside a Recovery interceptor
+type PanicError struct {
+	Operation Operation
+	Value     interface{} // the value passed to panic
+	Stack     []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("middleware: %s panicked: %v", e.Operation, e.Value)
+}
+
+// Recovery turns panics in the interceptors after it, or in the wrapped client, into a *PanicError
+func Recovery() Interceptor {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, operation Operation, request interface{}) (response interface{}, err error) {
+			defer func() {
+				if value := recover(); value != nil {
+					response = nil
+					err = &PanicError{Operation: operation, Value: value, Stack: debug.Stack()}
+				}
+			}()
+			return next(ctx, operation, request)
+		}
+	}
+}
+
+// Callbacks calls onRequest before each operation is sent and onResponse after it completes, either may be nil.
+// The callbacks receive the request, so they must not log or store its card data.
+func Callbacks(
+	onRequest func(ctx context.Context, operation Operation, request interface{}),
+	onResponse func(ctx context.Context, operation Operation, request interface{}, response interface{}, err error),
+) Interceptor {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, operation Operation, request interface{}) (interface{}, error) {
+			if onRequest != nil {
+				onRequest(ctx, operation, request)
+			}
+			response, err := next(ctx, operation, request)
+			if onResponse != nil {
+				onResponse(ctx, operation, request, response, err)
+			}
+			return response, err
+		}
+	}
+}
+
+// describe returns the Success and TransactionReference fields of a response
+func describe(response interface{}) (bool, string) {
+	switch r := response.(type) {
+	case *sleet.AuthorizationResponse:
+		if r != nil {
+			return r.Success, r.TransactionReference
+		}
+	case *sleet.CaptureResponse:
+		if r != nil {
+			return r.Success, r.TransactionReference
+		}
+	case *sleet.VoidResponse:
+		if r != nil {
+			return r.Success, r.TransactionReference
+		}
+	case *sleet.RefundResponse:
+		if r != nil {
+			return r.Success, r.TransactionReference
+		}
+	case *sleet.SaleResponse:
+		if r != nil {
+			return r.Success, r.TransactionReference
+		}
+	case *sleet.VerificationResponse:
+		if r != nil {
+			return r.Success, r.TransactionReference
+		}
+	case *sleet.IncrementAuthorizationResponse:
+		if r != nil {
+			return r.Success, r.TransactionReference
+		}
+	case *sleet.TransactionQueryResponse:
+		if r != nil {
+			return r.Success, r.TransactionReference
+		}
+	case *sleet.FindByClientReferenceResponse:
+		if r != nil {
+			return r.Success, ""
+		}
+	case *sleet.TimeoutReversalResponse:
+		if r != nil {
+			return r.Success, r.TransactionReference
+		}
+	case *sleet.StorePaymentMethodResponse:
+		if r != nil {
+			return r.Success, ""
+		}
+	case *sleet.GetPaymentMethodResponse:
+		if r != nil {
+			return r.Success, ""
+		}
+	case *sleet.DeletePaymentMethodResponse:
+		if r != nil {
+			return r.Success, ""
+		}
+	}
+	return false, ""
+}
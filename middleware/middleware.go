@@ -0,0 +1,376 @@
+// Package middleware wraps sleet clients with interceptors, so cross-cutting concerns such as logging, metrics and
+// panic recovery are written once instead of in each gateway.
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/BoltApp/sleet"
+)
+
+// ErrOperationNotSupported is returned for optional operations which the wrapped client does not implement
+var ErrOperationNotSupported = errors.New("middleware: operation not supported by the wrapped client")
+
+// ErrUnexpectedRequest is returned when an interceptor replaces the request with one of another type than the
+// operation takes
+var ErrUnexpectedRequest = errors.New("middleware: unexpected request type")
+
+// Operation names the client method passing through the interceptors
+type Operation string
+
+const (
+	OperationAuthorize              Operation = "authorize"
+	OperationCapture                Operation = "capture"
+	OperationVoid                   Operation = "void"
+	OperationRefund                 Operation = "refund"
+	OperationSale                   Operation = "sale"
+	OperationVerify                 Operation = "verify"
+	OperationIncrementAuthorization Operation = "increment_authorization"
+	OperationQueryTransaction       Operation = "query_transaction"
+	OperationFindByClientReference  Operation = "find_by_client_reference"
+	OperationReverseTimedOut        Operation = "reverse_timed_out_authorization"
+	OperationStorePaymentMethod     Operation = "store_payment_method"
+	OperationGetPaymentMethod       Operation = "get_payment_method"
+	OperationDeletePaymentMethod    Operation = "delete_payment_method"
+)
+
+// Handler sends a request for an operation. The request and response are the pointer types of the operation, such as
+// *sleet.CaptureRequest and *sleet.CaptureResponse.
+type Handler func(ctx context.Context, operation Operation, request interface{}) (interface{}, error)
+
+// Interceptor wraps the next Handler, it may inspect or replace the request and response, or not call next at all
+type Interceptor func(next Handler) Handler
+
+// Client runs every operation of the wrapped client through its interceptors. It implements every optional interface,
+// so whether the wrapped client supports an operation is given by Capabilities, not by type assertions on the Client.
+// Optional operations return ErrOperationNotSupported when the wrapped client does not implement them, except Verify
+// which falls back to sleet.VerifyByAuthorization.
+type Client struct {
+	client       sleet.ClientWithContext
+	interceptors []Interceptor
+}
+
+// Wrap returns a client running operations through the interceptors, the first interceptor being the outermost
+func Wrap(client sleet.ClientWithContext, interceptors ...Interceptor) *Client {
+	return &Client{
+		client:       client,
+		interceptors: interceptors,
+	}
+}
+
+// Unwrap returns the wrapped client, for type assertions on optional interfaces
+func (client *Client) Unwrap() sleet.ClientWithContext {
+	return client.client
+}
+
+// Capabilities returns the capabilities of the wrapped client, see sleet.CapabilitiesOf
+func (client *Client) Capabilities() sleet.Capabilities {
+	return sleet.CapabilitiesOf(client.client)
+}
+
+// handle runs the request through the interceptors to the final handler, which calls the wrapped client
+func (client *Client) handle(ctx context.Context, operation Operation, request interface{}, final Handler) (interface{}, error) {
+	handler := final
+	for i := len(client.interceptors) - 1; i >= 0; i-- {
+		handler = client.interceptors[i](handler)
+	}
+	return handler(ctx, operation, request)
+}
+
+// unexpectedRequest is the error of a final handler given a request of another type than its operation takes
+func unexpectedRequest(operation Operation, request interface{}) error {
+	return fmt.Errorf("%w: %s given a %T", ErrUnexpectedRequest, operation, request)
+}
+
+// Authorize wraps AuthorizeWithContext
+func (client *Client) Authorize(request *sleet.AuthorizationRequest) (*sleet.AuthorizationResponse, error) {
+	return client.AuthorizeWithContext(context.TODO(), request)
+}
+
+// AuthorizeWithContext runs the authorization through the interceptors
+func (client *Client) AuthorizeWithContext(ctx context.Context, request *sleet.AuthorizationRequest) (*sleet.AuthorizationResponse, error) {
+	response, err := client.handle(ctx, OperationAuthorize, request, func(ctx context.Context, operation Operation, request interface{}) (interface{}, error) {
+		authRequest, ok := request.(*sleet.AuthorizationRequest)
+		if !ok {
+			return nil, unexpectedRequest(operation, request)
+		}
+		return client.client.AuthorizeWithContext(ctx, authRequest)
+	})
+	authResponse, _ := response.(*sleet.AuthorizationResponse)
+	return authResponse, err
+}
+
+// Capture wraps CaptureWithContext
+func (client *Client) Capture(request *sleet.CaptureRequest) (*sleet.CaptureResponse, error) {
+	return client.CaptureWithContext(context.TODO(), request)
+}
+
+// CaptureWithContext runs the capture through the interceptors
+func (client *Client) CaptureWithContext(ctx context.Context, request *sleet.CaptureRequest) (*sleet.CaptureResponse, error) {
+	response, err := client.handle(ctx, OperationCapture, request, func(ctx context.Context, operation Operation, request interface{}) (interface{}, error) {
+		captureRequest, ok := request.(*sleet.CaptureRequest)
+		if !ok {
+			return nil, unexpectedRequest(operation, request)
+		}
+		return client.client.CaptureWithContext(ctx, captureRequest)
+	})
+	captureResponse, _ := response.(*sleet.CaptureResponse)
+	return captureResponse, err
+}
+
+// Void wraps VoidWithContext
+func (client *Client) Void(request *sleet.VoidRequest) (*sleet.VoidResponse, error) {
+	return client.VoidWithContext(context.TODO(), request)
+}
+
+// VoidWithContext runs the void through the interceptors
+func (client *Client) VoidWithContext(ctx context.Context, request *sleet.VoidRequest) (*sleet.VoidResponse, error) {
+	response, err := client.handle(ctx, OperationVoid, request, func(ctx context.Context, operation Operation, request interface{}) (interface{}, error) {
+		voidRequest, ok := request.(*sleet.VoidRequest)
+		if !ok {
+			return nil, unexpectedRequest(operation, request)
+		}
+		return client.client.VoidWithContext(ctx, voidRequest)
+	})
+	voidResponse, _ := response.(*sleet.VoidResponse)
+	return voidResponse, err
+}
+
+// Refund wraps RefundWithContext
+func (client *Client) Refund(request *sleet.RefundRequest) (*sleet.RefundResponse, error) {
+	return client.RefundWithContext(context.TODO(), request)
+}
+
+// RefundWithContext runs the refund through the interceptors
+func (client *Client) RefundWithContext(ctx context.Context, request *sleet.RefundRequest) (*sleet.RefundResponse, error) {
+	response, err := client.handle(ctx, OperationRefund, request, func(ctx context.Context, operation Operation, request interface{}) (interface{}, error) {
+		refundRequest, ok := request.(*sleet.RefundRequest)
+		if !ok {
+			return nil, unexpectedRequest(operation, request)
+		}
+		return client.client.RefundWithContext(ctx, refundRequest)
+	})
+	refundResponse, _ := response.(*sleet.RefundResponse)
+	return refundResponse, err
+}
+
+// Sale wraps SaleWithContext
+func (client *Client) Sale(request *sleet.SaleRequest) (*sleet.SaleResponse, error) {
+	return client.SaleWithContext(context.TODO(), request)
+}
+
+// SaleWithContext runs the sale through the interceptors
+func (client *Client) SaleWithContext(ctx context.Context, request *sleet.SaleRequest) (*sleet.SaleResponse, error) {
+	response, err := client.handle(ctx, OperationSale, request, func(ctx context.Context, operation Operation, request interface{}) (interface{}, error) {
+		saler, ok := client.client.(sleet.SaleWithContext)
+		if !ok {
+			return nil, ErrOperationNotSupported
+		}
+		saleRequest, ok := request.(*sleet.SaleRequest)
+		if !ok {
+			return nil, unexpectedRequest(operation, request)
+		}
+		return saler.SaleWithContext(ctx, saleRequest)
+	})
+	saleResponse, _ := response.(*sleet.SaleResponse)
+	return saleResponse, err
+}
+
+// Verify wraps VerifyWithContext
+func (client *Client) Verify(request *sleet.VerificationRequest) (*sleet.VerificationResponse, error) {
+	return client.VerifyWithContext(context.TODO(), request)
+}
+
+// VerifyWithContext runs the verification through the interceptors. Clients without a verification endpoint verify
+// by authorizing and voiding, which is seen by the interceptors as a single verify operation.
+func (client *Client) VerifyWithContext(ctx context.Context, request *sleet.VerificationRequest) (*sleet.VerificationResponse, error) {
+	response, err := client.handle(ctx, OperationVerify, request, func(ctx context.Context, operation Operation, request interface{}) (interface{}, error) {
+		verificationRequest, ok := request.(*sleet.VerificationRequest)
+		if !ok {
+			return nil, unexpectedRequest(operation, request)
+		}
+		if verifier, ok := client.client.(sleet.VerifierWithContext); ok {
+			return verifier.VerifyWithContext(ctx, verificationRequest)
+		}
+		return sleet.VerifyByAuthorization(ctx, client.client, verificationRequest)
+	})
+	verifyResponse, _ := response.(*sleet.VerificationResponse)
+	return verifyResponse, err
+}
+
+// IncrementAuthorization wraps IncrementAuthorizationWithContext
+func (client *Client) IncrementAuthorization(request *sleet.IncrementAuthorizationRequest) (*sleet.IncrementAuthorizationResponse, error) {
+	return client.IncrementAuthorizationWithContext(context.TODO(), request)
+}
+
+// IncrementAuthorizationWithContext runs the increment through the interceptors. Check
+// Capabilities().IncrementalAuthorization before calling it, as the wrapped client may not support it.
+func (client *Client) IncrementAuthorizationWithContext(ctx context.Context, request *sleet.IncrementAuthorizationRequest) (*sleet.IncrementAuthorizationResponse, error) {
+	response, err := client.handle(ctx, OperationIncrementAuthorization, request, func(ctx context.Context, operation Operation, request interface{}) (interface{}, error) {
+		incrementer, ok := client.client.(sleet.IncrementAuthorizerWithContext)
+		if !ok {
+			return nil, ErrOperationNotSupported
+		}
+		incrementRequest, ok := request.(*sleet.IncrementAuthorizationRequest)
+		if !ok {
+			return nil, unexpectedRequest(operation, request)
+		}
+		return incrementer.IncrementAuthorizationWithContext(ctx, incrementRequest)
+	})
+	incrementResponse, _ := response.(*sleet.IncrementAuthorizationResponse)
+	return incrementResponse, err
+}
+
+// QueryTransaction wraps QueryTransactionWithContext
+func (client *Client) QueryTransaction(request *sleet.TransactionQueryRequest) (*sleet.TransactionQueryResponse, error) {
+	return client.QueryTransactionWithContext(context.TODO(), request)
+}
+
+// QueryTransactionWithContext runs the query through the interceptors
+func (client *Client) QueryTransactionWithContext(ctx context.Context, request *sleet.TransactionQueryRequest) (*sleet.TransactionQueryResponse, error) {
+	response, err := client.handle(ctx, OperationQueryTransaction, request, func(ctx context.Context, operation Operation, request interface{}) (interface{}, error) {
+		querier, ok := client.client.(sleet.TransactionQuerierWithContext)
+		if !ok {
+			return nil, ErrOperationNotSupported
+		}
+		queryRequest, ok := request.(*sleet.TransactionQueryRequest)
+		if !ok {
+			return nil, unexpectedRequest(operation, request)
+		}
+		return querier.QueryTransactionWithContext(ctx, queryRequest)
+	})
+	queryResponse, _ := response.(*sleet.TransactionQueryResponse)
+	return queryResponse, err
+}
+
+// FindByClientReference wraps FindByClientReferenceWithContext
+func (client *Client) FindByClientReference(request *sleet.FindByClientReferenceRequest) (*sleet.FindByClientReferenceResponse, error) {
+	return client.FindByClientReferenceWithContext(context.TODO(), request)
+}
+
+// FindByClientReferenceWithContext runs the search through the interceptors
+func (client *Client) FindByClientReferenceWithContext(ctx context.Context, request *sleet.FindByClientReferenceRequest) (*sleet.FindByClientReferenceResponse, error) {
+	response, err := client.handle(ctx, OperationFindByClientReference, request, func(ctx context.Context, operation Operation, request interface{}) (interface{}, error) {
+		finder, ok := client.client.(sleet.TransactionFinderWithContext)
+		if !ok {
+			return nil, ErrOperationNotSupported
+		}
+		findRequest, ok := request.(*sleet.FindByClientReferenceRequest)
+		if !ok {
+			return nil, unexpectedRequest(operation, request)
+		}
+		return finder.FindByClientReferenceWithContext(ctx, findRequest)
+	})
+	findResponse, _ := response.(*sleet.FindByClientReferenceResponse)
+	return findResponse, err
+}
+
+// ReverseTimedOutAuthorization wraps ReverseTimedOutAuthorizationWithContext
+func (client *Client) ReverseTimedOutAuthorization(request *sleet.TimeoutReversalRequest) (*sleet.TimeoutReversalResponse, error) {
+	return client.ReverseTimedOutAuthorizationWithContext(context.TODO(), request)
+}
+
+// ReverseTimedOutAuthorizationWithContext runs the reversal through the interceptors
+func (client *Client) ReverseTimedOutAuthorizationWithContext(ctx context.Context, request *sleet.TimeoutReversalRequest) (*sleet.TimeoutReversalResponse, error) {
+	response, err := client.handle(ctx, OperationReverseTimedOut, request, func(ctx context.Context, operation Operation, request interface{}) (interface{}, error) {
+		reverser, ok := client.client.(sleet.TimeoutReverserWithContext)
+		if !ok {
+			return nil, ErrOperationNotSupported
+		}
+		reversalRequest, ok := request.(*sleet.TimeoutReversalRequest)
+		if !ok {
+			return nil, unexpectedRequest(operation, request)
+		}
+		return reverser.ReverseTimedOutAuthorizationWithContext(ctx, reversalRequest)
+	})
+	reversalResponse, _ := response.(*sleet.TimeoutReversalResponse)
+	return reversalResponse, err
+}
+
+// StorePaymentMethod wraps StorePaymentMethodWithContext
+func (client *Client) StorePaymentMethod(request *sleet.StorePaymentMethodRequest) (*sleet.StorePaymentMethodResponse, error) {
+	return client.StorePaymentMethodWithContext(context.TODO(), request)
+}
+
+// StorePaymentMethodWithContext runs the vaulting of a payment method through the interceptors
+func (client *Client) StorePaymentMethodWithContext(ctx context.Context, request *sleet.StorePaymentMethodRequest) (*sleet.StorePaymentMethodResponse, error) {
+	response, err := client.handle(ctx, OperationStorePaymentMethod, request, func(ctx context.Context, operation Operation, request interface{}) (interface{}, error) {
+		vault, ok := client.client.(sleet.VaultWithContext)
+		if !ok {
+			return nil, ErrOperationNotSupported
+		}
+		storeRequest, ok := request.(*sleet.StorePaymentMethodRequest)
+		if !ok {
+			return nil, unexpectedRequest(operation, request)
+		}
+		return vault.StorePaymentMethodWithContext(ctx, storeRequest)
+	})
+	storeResponse, _ := response.(*sleet.StorePaymentMethodResponse)
+	return storeResponse, err
+}
+
+// GetPaymentMethod wraps GetPaymentMethodWithContext
+func (client *Client) GetPaymentMethod(request *sleet.GetPaymentMethodRequest) (*sleet.GetPaymentMethodResponse, error) {
+	return client.GetPaymentMethodWithContext(context.TODO(), request)
+}
+
+// GetPaymentMethodWithContext runs the retrieval of a stored payment method through the interceptors
+func (client *Client) GetPaymentMethodWithContext(ctx context.Context, request *sleet.GetPaymentMethodRequest) (*sleet.GetPaymentMethodResponse, error) {
+	response, err := client.handle(ctx, OperationGetPaymentMethod, request, func(ctx context.Context, operation Operation, request interface{}) (interface{}, error) {
+		vault, ok := client.client.(sleet.VaultWithContext)
+		if !ok {
+			return nil, ErrOperationNotSupported
+		}
+		getRequest, ok := request.(*sleet.GetPaymentMethodRequest)
+		if !ok {
+			return nil, unexpectedRequest(operation, request)
+		}
+		return vault.GetPaymentMethodWithContext(ctx, getRequest)
+	})
+	getResponse, _ := response.(*sleet.GetPaymentMethodResponse)
+	return getResponse, err
+}
+
+// DeletePaymentMethod wraps DeletePaymentMethodWithContext
+func (client *Client) DeletePaymentMethod(request *sleet.DeletePaymentMethodRequest) (*sleet.DeletePaymentMethodResponse, error) {
+	return client.DeletePaymentMethodWithContext(context.TODO(), request)
+}
+
+// DeletePaymentMethodWithContext runs the deletion of a stored payment method through the interceptors
+func (client *Client) DeletePaymentMethodWithContext(ctx context.Context, request *sleet.DeletePaymentMethodRequest) (*sleet.DeletePaymentMethodResponse, error) {
+	response, err := client.handle(ctx, OperationDeletePaymentMethod, request, func(ctx context.Context, operation Operation, request interface{}) (interface{}, error) {
+		vault, ok := client.client.(sleet.VaultWithContext)
+		if !ok {
+			return nil, ErrOperationNotSupported
+		}
+		deleteRequest, ok := request.(*sleet.DeletePaymentMethodRequest)
+		if !ok {
+			return nil, unexpectedRequest(operation, request)
+		}
+		return vault.DeletePaymentMethodWithContext(ctx, deleteRequest)
+	})
+	deleteResponse, _ := response.(*sleet.DeletePaymentMethodResponse)
+	return deleteResponse, err
+}
+
+var (
+	// assert client interface
+	_ sleet.ClientWithContext              = &Client{}
+	_ sleet.CapabilitiesReporter           = &Client{}
+	_ sleet.SaleWithContext                = &Client{}
+	_ sleet.Sale                           = &Client{}
+	_ sleet.VerifierWithContext            = &Client{}
+	_ sleet.Verifier                       = &Client{}
+	_ sleet.IncrementAuthorizerWithContext = &Client{}
+	_ sleet.IncrementAuthorizer            = &Client{}
+	_ sleet.TransactionQuerierWithContext  = &Client{}
+	_ sleet.TransactionQuerier             = &Client{}
+	_ sleet.TransactionFinderWithContext   = &Client{}
+	_ sleet.TransactionFinder              = &Client{}
+	_ sleet.TimeoutReverserWithContext     = &Client{}
+	_ sleet.TimeoutReverser                = &Client{}
+	_ sleet.VaultWithContext               = &Client{}
+	_ sleet.Vault                          = &Client{}
+)
@@ -0,0 +1,271 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/BoltApp/sleet"
+	sleet_t "github.com/BoltApp/sleet/testing"
+)
+
+// fakeClient approves every operation, or panics if panicOn matches the operation
+type fakeClient struct {
+	panicOn Operation
+}
+
+func (c *fakeClient) Authorize(request *sleet.AuthorizationRequest) (*sleet.AuthorizationResponse, error) {
+	return c.AuthorizeWithContext(context.TODO(), request)
+}
+
+func (c *fakeClient) AuthorizeWithContext(_ context.Context, _ *sleet.AuthorizationRequest) (*sleet.AuthorizationResponse, error) {
+	if c.panicOn == OperationAuthorize {
+		panic("authorize failed")
+	}
+	return &sleet.AuthorizationResponse{Success: true, TransactionReference: "auth-ref"}, nil
+}
+
+func (c *fakeClient) Capture(request *sleet.CaptureRequest) (*sleet.CaptureResponse, error) {
+	return c.CaptureWithContext(context.TODO(), request)
+}
+
+func (c *fakeClient) CaptureWithContext(_ context.Context, request *sleet.CaptureRequest) (*sleet.CaptureResponse, error) {
+	return &sleet.CaptureResponse{Success: true, TransactionReference: request.TransactionReference}, nil
+}
+
+func (c *fakeClient) Void(request *sleet.VoidRequest) (*sleet.VoidResponse, error) {
+	return c.VoidWithContext(context.TODO(), request)
+}
+
+func (c *fakeClient) VoidWithContext(_ context.Context, request *sleet.VoidRequest) (*sleet.VoidResponse, error) {
+	return &sleet.VoidResponse{Success: true, TransactionReference: request.TransactionReference}, nil
+}
+
+func (c *fakeClient) Refund(request *sleet.RefundRequest) (*sleet.RefundResponse, error) {
+	return c.RefundWithContext(context.TODO(), request)
+}
+
+func (c *fakeClient) RefundWithContext(_ context.Context, request *sleet.RefundRequest) (*sleet.RefundResponse, error) {
+	return &sleet.RefundResponse{Success: true, TransactionReference: request.TransactionReference}, nil
+}
+
+func TestInterceptorOrder(t *testing.T) {
+	var calls []string
+	record := func(name string) Interceptor {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, operation Operation, request interface{}) (interface{}, error) {
+				calls = append(calls, name+" "+string(operation))
+				response, err := next(ctx, operation, request)
+				calls = append(calls, name+" done")
+				return response, err
+			}
+		}
+	}
+
+	client := Wrap(&fakeClient{}, record("outer"), record("inner"))
+	response, err := client.Capture(&sleet.CaptureRequest{TransactionReference: "ref"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !response.Success || response.TransactionReference != "ref" {
+		t.Errorf("expected the wrapped client's response, got %+v", response)
+	}
+
+	want := []string{"outer capture", "inner capture", "inner done", "outer done"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("expected %v, got %v", want, calls)
+	}
+}
+
+func TestInterceptorReplacesRequest(t *testing.T) {
+	rewrite := func(next Handler) Handler {
+		return func(ctx context.Context, operation Operation, request interface{}) (interface{}, error) {
+			return next(ctx, operation, &sleet.VoidRequest{TransactionReference: "rewritten"})
+		}
+	}
+
+	response, err := Wrap(&fakeClient{}, rewrite).Void(&sleet.VoidRequest{TransactionReference: "ref"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.TransactionReference != "rewritten" {
+		t.Errorf("expected the replaced request to be sent, got %s", response.TransactionReference)
+	}
+}
+
+func TestOptionalOperations(t *testing.T) {
+	client := Wrap(&fakeClient{})
+
+	if _, err := client.Sale(sleet_t.BaseAuthorizationRequest()); err != ErrOperationNotSupported {
+		t.Errorf("expected ErrOperationNotSupported for Sale, got %v", err)
+	}
+	if _, err := client.IncrementAuthorization(&sleet.IncrementAuthorizationRequest{}); err != ErrOperationNotSupported {
+		t.Errorf("expected ErrOperationNotSupported for IncrementAuthorization, got %v", err)
+	}
+	if _, err := client.QueryTransaction(&sleet.TransactionQueryRequest{}); err != ErrOperationNotSupported {
+		t.Errorf("expected ErrOperationNotSupported for QueryTransaction, got %v", err)
+	}
+
+	if _, err := client.FindByClientReference(&sleet.FindByClientReferenceRequest{}); err != ErrOperationNotSupported {
+		t.Errorf("expected ErrOperationNotSupported for FindByClientReference, got %v", err)
+	}
+	if _, err := client.ReverseTimedOutAuthorization(&sleet.TimeoutReversalRequest{}); err != ErrOperationNotSupported {
+		t.Errorf("expected ErrOperationNotSupported for ReverseTimedOutAuthorization, got %v", err)
+	}
+	if _, err := client.StorePaymentMethod(&sleet.StorePaymentMethodRequest{}); err != ErrOperationNotSupported {
+		t.Errorf("expected ErrOperationNotSupported for StorePaymentMethod, got %v", err)
+	}
+	if _, err := client.GetPaymentMethod(&sleet.GetPaymentMethodRequest{}); err != ErrOperationNotSupported {
+		t.Errorf("expected ErrOperationNotSupported for GetPaymentMethod, got %v", err)
+	}
+	if _, err := client.DeletePaymentMethod(&sleet.DeletePaymentMethodRequest{}); err != ErrOperationNotSupported {
+		t.Errorf("expected ErrOperationNotSupported for DeletePaymentMethod, got %v", err)
+	}
+
+	response, err := client.Verify(sleet_t.BaseAuthorizationRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !response.Success {
+		t.Error("expected Verify to fall back to authorizing and voiding")
+	}
+
+	if want := (sleet.Capabilities{ContextSupport: true}); !reflect.DeepEqual(client.Capabilities(), want) {
+		t.Errorf("expected only the operations of the wrapped client, got %+v", client.Capabilities())
+	}
+}
+
+// fakeReverser also reverses timed out authorizations
+type fakeReverser struct {
+	fakeClient
+}
+
+func (c *fakeReverser) ReverseTimedOutAuthorization(request *sleet.TimeoutReversalRequest) (*sleet.TimeoutReversalResponse, error) {
+	return c.ReverseTimedOutAuthorizationWithContext(context.TODO(), request)
+}
+
+func (c *fakeReverser) ReverseTimedOutAuthorizationWithContext(_ context.Context, _ *sleet.TimeoutReversalRequest) (*sleet.TimeoutReversalResponse, error) {
+	return &sleet.TimeoutReversalResponse{Success: true, TransactionReference: "reversal-ref"}, nil
+}
+
+func TestForwardedOperations(t *testing.T) {
+	var operations []Operation
+	record := func(next Handler) Handler {
+		return func(ctx context.Context, operation Operation, request interface{}) (interface{}, error) {
+			operations = append(operations, operation)
+			return next(ctx, operation, request)
+		}
+	}
+	client := Wrap(&fakeReverser{}, record)
+
+	response, err := client.ReverseTimedOutAuthorization(&sleet.TimeoutReversalRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !response.Success || response.TransactionReference != "reversal-ref" {
+		t.Errorf("expected the wrapped client's response, got %+v", response)
+	}
+	if !reflect.DeepEqual(operations, []Operation{OperationReverseTimedOut}) {
+		t.Errorf("expected the reversal to run through the interceptors, got %v", operations)
+	}
+	if !client.Capabilities().TimeoutReversal {
+		t.Error("expected the timeout reversal capability of the wrapped client")
+	}
+}
+
+func TestInterceptorReplacesRequestType(t *testing.T) {
+	rewrite := func(next Handler) Handler {
+		return func(ctx context.Context, operation Operation, request interface{}) (interface{}, error) {
+			return next(ctx, operation, &sleet.CaptureRequest{TransactionReference: "rewritten"})
+		}
+	}
+
+	response, err := Wrap(&fakeClient{}, rewrite).Void(&sleet.VoidRequest{TransactionReference: "ref"})
+	if !errors.Is(err, ErrUnexpectedRequest) {
+		t.Errorf("expected ErrUnexpectedRequest, got %v", err)
+	}
+	if response != nil {
+		t.Errorf("expected no response, got %+v", response)
+	}
+}
+
+func TestLogging(t *testing.T) {
+	var entries []LogEntry
+	client := Wrap(&fakeClient{}, Logging(func(_ context.Context, entry LogEntry) {
+		entries = append(entries, entry)
+	}))
+
+	if _, err := client.Authorize(sleet_t.BaseAuthorizationRequest()); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	entry := entries[0]
+	if entry.Operation != OperationAuthorize || !entry.Success || entry.TransactionReference != "auth-ref" || entry.Err != nil {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+}
+
+func TestLatency(t *testing.T) {
+	observed := map[Operation]time.Duration{}
+	slow := func(next Handler) Handler {
+		return func(ctx context.Context, operation Operation, request interface{}) (interface{}, error) {
+			time.Sleep(time.Millisecond)
+			return next(ctx, operation, request)
+		}
+	}
+	client := Wrap(&fakeClient{}, Latency(func(operation Operation, duration time.Duration, _ error) {
+		observed[operation] = duration
+	}), slow)
+
+	if _, err := client.Refund(&sleet.RefundRequest{TransactionReference: "ref"}); err != nil {
+		t.Fatal(err)
+	}
+	if observed[OperationRefund] < time.Millisecond {
+		t.Errorf("expected the refund latency to be observed, got %v", observed)
+	}
+}
+
+func TestRecovery(t *testing.T) {
+	client := Wrap(&fakeClient{panicOn: OperationAuthorize}, Recovery())
+
+	response, err := client.Authorize(sleet_t.BaseAuthorizationRequest())
+	if response != nil {
+		t.Errorf("expected no response, got %+v", response)
+	}
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) {
+		t.Fatalf("expected a PanicError, got %v", err)
+	}
+	if panicErr.Operation != OperationAuthorize || panicErr.Value != "authorize failed" || len(panicErr.Stack) == 0 {
+		t.Errorf("unexpected PanicError %+v", panicErr)
+	}
+}
+
+func TestCallbacks(t *testing.T) {
+	var requested, responded interface{}
+	client := Wrap(&fakeClient{}, Callbacks(
+		func(_ context.Context, _ Operation, request interface{}) {
+			requested = request
+		},
+		func(_ context.Context, _ Operation, _ interface{}, response interface{}, _ error) {
+			responded = response
+		},
+	))
+
+	request := &sleet.CaptureRequest{TransactionReference: "ref"}
+	response, err := client.Capture(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requested != request || responded != response {
+		t.Error("expected the callbacks to receive the request and response")
+	}
+
+	if _, err := Wrap(&fakeClient{}, Callbacks(nil, nil)).Capture(request); err != nil {
+		t.Errorf("expected nil callbacks to be skipped, got %v", err)
+	}
+}
@@ -0,0 +1,23 @@
+// Package all registers every Sleet gateway of a PsP with the registry when imported:
+//
+//	import _ "github.com/BoltApp/sleet/registry/all"
+//
+// The in-memory simulator is not registered, so a config can't select it in production by mistake. Import
+// gateways/simulator to register it.
+package all
+
+import (
+	// gateways register themselves with the registry when imported
+	_ "github.com/BoltApp/sleet/gateways/adyen"
+	_ "github.com/BoltApp/sleet/gateways/authorizenet"
+	_ "github.com/BoltApp/sleet/gateways/braintree"
+	_ "github.com/BoltApp/sleet/gateways/cardconnect"
+	_ "github.com/BoltApp/sleet/gateways/checkoutcom"
+	_ "github.com/BoltApp/sleet/gateways/cybersource"
+	_ "github.com/BoltApp/sleet/gateways/firstdata"
+	_ "github.com/BoltApp/sleet/gateways/nmi"
+	_ "github.com/BoltApp/sleet/gateways/orbital"
+	_ "github.com/BoltApp/sleet/gateways/paypalpayflow"
+	_ "github.com/BoltApp/sleet/gateways/rocketgate"
+	_ "github.com/BoltApp/sleet/gateways/stripe"
+)
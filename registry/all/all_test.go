@@ -0,0 +1,110 @@
+package all
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+	"github.com/BoltApp/sleet/registry"
+
+	// the simulator isn't registered by all, it is imported here so its capabilities are checked too
+	_ "github.com/BoltApp/sleet/gateways/simulator"
+)
+
+// credentials has every credential each gateway requires
+var credentials = map[string]map[string]string{
+	"adyen":         {"merchant_account": "account", "api_key": "key"},
+	"authorizenet":  {"merchant_name": "name", "transaction_key": "key"},
+	"braintree":     {"merchant_id": "id", "public_key": "public", "private_key": "private"},
+	"cardconnect":   {"username": "user", "password": "password", "merchant_id": "id", "url": "https://example.cardconnect.com"},
+	"checkoutcom":   {"api_key": "key"},
+	"cybersource":   {"merchant_id": "id", "shared_secret_key_id": "key id", "shared_secret_key": "key"},
+	"firstdata":     {"api_key": "key", "api_secret": "secret"},
+	"nmi":           {"security_key": "key"},
+	"orbital":       {"username": "user", "password": "password", "merchant_id": "123456"},
+	"paypalpayflow": {"partner": "partner", "password": "password", "vendor": "vendor", "user": "user"},
+	"rocketgate":    {"merchant_id": "id", "merchant_password": "password"},
+	"simulator":     {},
+	"stripe":        {"api_key": "key"},
+}
+
+func TestEveryGatewayIsRegistered(t *testing.T) {
+	names := registry.Gateways()
+	if len(names) != len(credentials) {
+		t.Errorf("expected %d gateways to be registered, got %v", len(credentials), names)
+	}
+
+	for gateway, creds := range credentials {
+		t.Run(gateway, func(t *testing.T) {
+			client, err := registry.NewClient(&registry.Config{
+				Gateway:     gateway,
+				Environment: common.Sandbox,
+				Credentials: creds,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reflect.ValueOf(client).IsNil() {
+				t.Fatal("expected a client")
+			}
+
+			for key := range creds {
+				missing := make(map[string]string)
+				for k, v := range creds {
+					if k != key {
+						missing[k] = v
+					}
+				}
+				if _, err := registry.NewClient(&registry.Config{Gateway: gateway, Environment: common.Sandbox, Credentials: missing}); err == nil {
+					t.Errorf("expected an error without the %s credential", key)
+				}
+			}
+		})
+	}
+}
+
+// TestCapabilitiesMatchInterfaces checks that no client reports an operation it doesn't implement, or hides one it
+// does, so callers choosing a gateway by its Capabilities don't pick one without the operation
+func TestCapabilitiesMatchInterfaces(t *testing.T) {
+	for gateway, creds := range credentials {
+		t.Run(gateway, func(t *testing.T) {
+			client, err := registry.NewClient(&registry.Config{Gateway: gateway, Environment: common.Sandbox, Credentials: creds})
+			if err != nil {
+				t.Fatal(err)
+			}
+			reporter, ok := client.(sleet.CapabilitiesReporter)
+			if !ok {
+				t.Fatal("expected the client to report its capabilities")
+			}
+			capabilities := reporter.Capabilities()
+			if !capabilities.ContextSupport {
+				t.Error("expected ContextSupport, as every registered client implements ClientWithContext")
+			}
+
+			_, incremental := client.(sleet.IncrementAuthorizerWithContext)
+			_, sale := client.(sleet.SaleWithContext)
+			_, vault := client.(sleet.VaultWithContext)
+			_, query := client.(sleet.TransactionQuerierWithContext)
+			_, finder := client.(sleet.TransactionFinderWithContext)
+			_, reverser := client.(sleet.TimeoutReverserWithContext)
+			checks := []struct {
+				capability  string
+				reported    bool
+				implemented bool
+			}{
+				{"IncrementalAuthorization", capabilities.IncrementalAuthorization, incremental},
+				{"Sale", capabilities.Sale, sale},
+				{"Vault", capabilities.Vault, vault},
+				{"TransactionQuery", capabilities.TransactionQuery, query},
+				{"FindByReference", capabilities.FindByReference, finder},
+				{"TimeoutReversal", capabilities.TimeoutReversal, reverser},
+			}
+			for _, check := range checks {
+				if check.reported != check.implemented {
+					t.Errorf("client implements %s %t, but reports it %t", check.capability, check.implemented, check.reported)
+				}
+			}
+		})
+	}
+}
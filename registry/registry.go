@@ -0,0 +1,186 @@
+// Package registry builds sleet clients from configuration. Each gateway package registers a Factory under its name
+// when it is imported, so a gateway is enabled by importing it, or every gateway by importing registry/all.
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+)
+
+// Factory builds a client from its config, sending requests with the given http client and passing the options to
+// the gateway's constructor
+type Factory func(config *Config, httpClient *http.Client, options ...sleet.ClientOption) (sleet.ClientWithContext, error)
+
+var (
+	mu        sync.RWMutex
+	factories = make(map[string]Factory)
+)
+
+// Register makes a gateway available to NewClient by name. It panics if the name is already registered,
+// as two gateway packages would be claiming the same configuration.
+func Register(name string, factory Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if factory == nil {
+		panic("registry: Register factory is nil for " + name)
+	}
+	if _, ok := factories[name]; ok {
+		panic("registry: Register called twice for " + name)
+	}
+	factories[name] = factory
+}
+
+// Gateways returns the sorted names of the registered gateways
+func Gateways() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Config describes a client: the gateway to use, its environment and credentials, and how it connects.
+// The field names are the same in JSON and YAML, so a Config can be decoded from either.
+type Config struct {
+	Gateway     string             `json:"gateway" yaml:"gateway"`         // the name the gateway is registered under, such as "adyen"
+	Environment common.Environment `json:"environment" yaml:"environment"` // sandbox, production or custom
+	Credentials map[string]string  `json:"credentials" yaml:"credentials"` // the keys are listed in each gateway's registry.go
+	BaseURL     string             `json:"baseURL" yaml:"baseURL"`         // overrides the PsP's URL, required by the custom environment
+	HTTP        HTTPConfig         `json:"http" yaml:"http"`
+}
+
+// HTTPConfig configures the http client of a gateway, zero values keep the defaults
+type HTTPConfig struct {
+	Timeout             string `json:"timeout" yaml:"timeout"` // a time.ParseDuration string such as "30s", common.DefaultTimeout if empty
+	MaxIdleConnsPerHost int    `json:"maxIdleConnsPerHost" yaml:"maxIdleConnsPerHost"`
+}
+
+// Credential returns the required credential, or an error if it is missing
+func (config *Config) Credential(key string) (string, error) {
+	value := config.Credentials[key]
+	if value == "" {
+		return "", fmt.Errorf("registry: %s credential %q is required", config.Gateway, key)
+	}
+	return value, nil
+}
+
+// RequiredCredentials returns the required credentials in the order of their keys, or an error if any is missing
+func (config *Config) RequiredCredentials(keys ...string) ([]string, error) {
+	values := make([]string, len(keys))
+	for i, key := range keys {
+		value, err := config.Credential(key)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// OptionalCredential returns the credential, or nil if it is missing
+func (config *Config) OptionalCredential(key string) *string {
+	value, ok := config.Credentials[key]
+	if !ok || value == "" {
+		return nil
+	}
+	return &value
+}
+
+// IntCredential returns the required credential parsed as an int
+func (config *Config) IntCredential(key string) (int, error) {
+	value, err := config.Credential(key)
+	if err != nil {
+		return 0, err
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("registry: %s credential %q must be an integer", config.Gateway, key)
+	}
+	return parsed, nil
+}
+
+// HTTPClient returns the http client described by the HTTP config
+func (config *Config) HTTPClient() (*http.Client, error) {
+	httpClient := common.DefaultHttpClient()
+	if config.HTTP.Timeout != "" {
+		timeout, err := time.ParseDuration(config.HTTP.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("registry: invalid http timeout %q: %v", config.HTTP.Timeout, err)
+		}
+		httpClient.Timeout = timeout
+	}
+	if config.HTTP.MaxIdleConnsPerHost > 0 {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.MaxIdleConnsPerHost = config.HTTP.MaxIdleConnsPerHost
+		httpClient.Transport = transport
+	}
+	return httpClient, nil
+}
+
+// NewClient builds a client with the factory registered for the configured gateway. Options which can't be
+// configured, such as sleet.WithLogger, are passed to the gateway's constructor, after the configured base URL.
+func NewClient(config *Config, options ...sleet.ClientOption) (sleet.ClientWithContext, error) {
+	switch config.Environment {
+	case common.Sandbox, common.Production:
+	case common.Custom:
+		if config.BaseURL == "" {
+			return nil, fmt.Errorf("registry: the %s environment requires a base URL", common.Custom)
+		}
+	default:
+		return nil, fmt.Errorf("registry: unknown environment %q", config.Environment)
+	}
+	if config.BaseURL != "" {
+		baseURL, err := url.Parse(config.BaseURL)
+		if err != nil || baseURL.Scheme == "" || baseURL.Host == "" {
+			return nil, fmt.Errorf("registry: invalid base URL %q", config.BaseURL)
+		}
+		options = append([]sleet.ClientOption{sleet.WithBaseURL(config.BaseURL)}, options...)
+	}
+
+	mu.RLock()
+	factory, ok := factories[config.Gateway]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("registry: unknown gateway %q, is its package imported?", config.Gateway)
+	}
+
+	httpClient, err := config.HTTPClient()
+	if err != nil {
+		return nil, err
+	}
+	return factory(config, httpClient, options...)
+}
+
+// LoadJSON builds a client from a JSON config block
+func LoadJSON(data []byte, options ...sleet.ClientOption) (sleet.ClientWithContext, error) {
+	config := &Config{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, err
+	}
+	return NewClient(config, options...)
+}
+
+// LoadYAML builds a client from a YAML config block
+func LoadYAML(data []byte, options ...sleet.ClientOption) (sleet.ClientWithContext, error) {
+	config := &Config{}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, err
+	}
+	return NewClient(config, options...)
+}
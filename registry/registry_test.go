@@ -0,0 +1,151 @@
+package registry
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/BoltApp/sleet"
+)
+
+// fakeClient is built by the fake gateway factory, it embeds the interface as no operations are sent
+type fakeClient struct {
+	sleet.ClientWithContext
+	config     *Config
+	httpClient *http.Client
+	options    *sleet.ClientOptions
+}
+
+func init() {
+	Register("fake", func(config *Config, httpClient *http.Client, options ...sleet.ClientOption) (sleet.ClientWithContext, error) {
+		if _, err := config.RequiredCredentials("key", "secret"); err != nil {
+			return nil, err
+		}
+		return &fakeClient{config: config, httpClient: httpClient, options: sleet.NewClientOptions(options...)}, nil
+	})
+}
+
+func TestLoadJSON(t *testing.T) {
+	t.Run("Builds The Registered Gateway", func(t *testing.T) {
+		client, err := LoadJSON([]byte(`{
+			"gateway": "fake",
+			"environment": "sandbox",
+			"credentials": {"key": "k", "secret": "s"},
+			"http": {"timeout": "5s", "maxIdleConnsPerHost": 4}
+		}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		fake := client.(*fakeClient)
+		if fake.config.Credentials["key"] != "k" {
+			t.Errorf("expected the credentials to be passed to the factory, got %v", fake.config.Credentials)
+		}
+		if fake.httpClient.Timeout != 5*time.Second {
+			t.Errorf("expected a 5s timeout, got %s", fake.httpClient.Timeout)
+		}
+		if fake.httpClient.Transport.(*http.Transport).MaxIdleConnsPerHost != 4 {
+			t.Error("expected MaxIdleConnsPerHost to be set on the transport")
+		}
+	})
+
+	t.Run("Passes The Base URL", func(t *testing.T) {
+		client, err := LoadJSON([]byte(`{
+			"gateway": "fake",
+			"environment": "custom",
+			"baseURL": "http://127.0.0.1:8080",
+			"credentials": {"key": "k", "secret": "s"}
+		}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if baseURL := client.(*fakeClient).options.BaseURL; baseURL != "http://127.0.0.1:8080" {
+			t.Errorf("expected the base URL to be passed to the factory, got %q", baseURL)
+		}
+	})
+
+	cases := []struct {
+		label  string
+		config string
+	}{
+		{"Unknown Gateway", `{"gateway": "unknown", "environment": "sandbox"}`},
+		{"Unknown Environment", `{"gateway": "fake", "environment": "staging", "credentials": {"key": "k", "secret": "s"}}`},
+		{"Custom Without Base URL", `{"gateway": "fake", "environment": "custom", "credentials": {"key": "k", "secret": "s"}}`},
+		{"Invalid Base URL", `{"gateway": "fake", "environment": "sandbox", "baseURL": "localhost", "credentials": {"key": "k", "secret": "s"}}`},
+		{"Missing Credential", `{"gateway": "fake", "environment": "sandbox", "credentials": {"key": "k"}}`},
+		{"Invalid Timeout", `{"gateway": "fake", "environment": "sandbox", "credentials": {"key": "k", "secret": "s"}, "http": {"timeout": "5"}}`},
+		{"Invalid JSON", `{"gateway": `},
+	}
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			if _, err := LoadJSON([]byte(c.config)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestLoadYAML(t *testing.T) {
+	t.Run("Builds The Registered Gateway", func(t *testing.T) {
+		client, err := LoadYAML([]byte(`
+gateway: fake
+environment: custom
+baseURL: http://127.0.0.1:8080
+credentials:
+  key: k
+  secret: s
+http:
+  timeout: 5s
+  maxIdleConnsPerHost: 4
+`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		fake := client.(*fakeClient)
+		if fake.config.Credentials["secret"] != "s" {
+			t.Errorf("expected the credentials to be passed to the factory, got %v", fake.config.Credentials)
+		}
+		if fake.httpClient.Timeout != 5*time.Second {
+			t.Errorf("expected a 5s timeout, got %s", fake.httpClient.Timeout)
+		}
+		if fake.httpClient.Transport.(*http.Transport).MaxIdleConnsPerHost != 4 {
+			t.Error("expected MaxIdleConnsPerHost to be set on the transport")
+		}
+		if baseURL := fake.options.BaseURL; baseURL != "http://127.0.0.1:8080" {
+			t.Errorf("expected the base URL to be passed to the factory, got %q", baseURL)
+		}
+	})
+
+	cases := []struct {
+		label  string
+		config string
+	}{
+		{"Missing Credential", "gateway: fake\nenvironment: sandbox\ncredentials:\n  key: k\n"},
+		{"Invalid YAML", "gateway: [fake\n"},
+	}
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			if _, err := LoadYAML([]byte(c.config)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestRegisterTwice(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected registering a name twice to panic")
+		}
+	}()
+	Register("fake", func(*Config, *http.Client, ...sleet.ClientOption) (sleet.ClientWithContext, error) { return nil, nil })
+}
+
+func TestIntCredential(t *testing.T) {
+	config := &Config{Gateway: "fake", Credentials: map[string]string{"id": "42", "name": "abc"}}
+	if id, err := config.IntCredential("id"); err != nil || id != 42 {
+		t.Errorf("expected 42, got %d, %v", id, err)
+	}
+	if _, err := config.IntCredential("name"); err == nil {
+		t.Error("expected an error for a non integer credential")
+	}
+}
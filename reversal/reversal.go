@@ -0,0 +1,209 @@
+package reversal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+)
+
+// ErrReversalNotSupported is returned by NewClient for clients which can neither reverse a timed out authorization
+// nor find it by reference to void it.
+var ErrReversalNotSupported = errors.New("reversal: client does not support reversing authorizations by reference")
+
+// DefaultReversalTimeout bounds the reversal of an ambiguous authorization when no timeout is given to NewClient
+const DefaultReversalTimeout = 30 * time.Second
+
+// Outcome is the resolved state of an authorization whose outcome was unknown
+type Outcome string
+
+const (
+	OutcomeReversed      Outcome = "reversed"       // the PsP accepted the reversal, no hold remains on the card
+	OutcomeNotAuthorized Outcome = "not_authorized" // the PsP has no approved authorization for the references
+	OutcomeUnresolved    Outcome = "unresolved"     // the reversal failed, a hold may remain on the card
+)
+
+// AmbiguousAuthorizationError is returned by Client.AuthorizeWithContext when the outcome of an authorization was
+// unknown. Outcome reports the state after the reversal was attempted.
+type AmbiguousAuthorizationError struct {
+	Err         error // the error returned by the authorization, nil if the PsP responded with a server error
+	Outcome     Outcome
+	ReversalErr error // the error that left the authorization unresolved, if any
+}
+
+func (e *AmbiguousAuthorizationError) Error() string {
+	message := fmt.Sprintf("reversal: authorization outcome unknown, %s", e.Outcome)
+	if e.Err != nil {
+		message += ": " + e.Err.Error()
+	}
+	if e.ReversalErr != nil {
+		message += ", reversal failed: " + e.ReversalErr.Error()
+	}
+	return message
+}
+
+func (e *AmbiguousAuthorizationError) Unwrap() error {
+	return e.Err
+}
+
+// Client wraps a sleet client so that authorizations with an unknown outcome are reversed. Clients implementing
+// sleet.TimeoutReverserWithContext are reversed by reference, otherwise clients implementing
+// sleet.TransactionFinderWithContext have the authorizations found for the references voided.
+// All other operations are passed through to the wrapped client.
+type Client struct {
+	sleet.ClientWithContext
+	reverser        sleet.TimeoutReverserWithContext
+	finder          sleet.TransactionFinderWithContext
+	reversalTimeout time.Duration
+}
+
+// NewClient wraps the client, reversals are bounded by reversalTimeout or DefaultReversalTimeout if it is 0
+func NewClient(client sleet.ClientWithContext, reversalTimeout time.Duration) (*Client, error) {
+	// wrappers such as middleware.Client implement every optional interface, so go by the capabilities
+	capabilities := sleet.CapabilitiesOf(client)
+	var reverser sleet.TimeoutReverserWithContext
+	if capabilities.TimeoutReversal {
+		reverser, _ = client.(sleet.TimeoutReverserWithContext)
+	}
+	var finder sleet.TransactionFinderWithContext
+	if capabilities.FindByReference {
+		finder, _ = client.(sleet.TransactionFinderWithContext)
+	}
+	if reverser == nil && finder == nil {
+		return nil, ErrReversalNotSupported
+	}
+	if reversalTimeout == 0 {
+		reversalTimeout = DefaultReversalTimeout
+	}
+	return &Client{
+		ClientWithContext: client,
+		reverser:          reverser,
+		finder:            finder,
+		reversalTimeout:   reversalTimeout,
+	}, nil
+}
+
+// Authorize wraps AuthorizeWithContext
+func (client *Client) Authorize(request *sleet.AuthorizationRequest) (*sleet.AuthorizationResponse, error) {
+	return client.AuthorizeWithContext(context.TODO(), request)
+}
+
+// AuthorizeWithContext authorizes with the wrapped client. If the outcome is unknown, the authorization is reversed
+// and an unsuccessful response is returned with an *AmbiguousAuthorizationError describing the resolved outcome.
+func (client *Client) AuthorizeWithContext(ctx context.Context, request *sleet.AuthorizationRequest) (*sleet.AuthorizationResponse, error) {
+	request = withReversalOption(request)
+	response, err := client.ClientWithContext.AuthorizeWithContext(ctx, request)
+	if !IsAmbiguous(response, err) {
+		return response, err
+	}
+
+	// the caller's context may be what expired, so the reversal gets its own deadline
+	reversalCtx, cancel := context.WithTimeout(context.Background(), client.reversalTimeout)
+	defer cancel()
+	outcome, reversalErr := client.reverse(reversalCtx, request)
+
+	if response == nil {
+		response = &sleet.AuthorizationResponse{ResultType: sleet.ResultTypeServerError}
+	}
+	response.Success = false
+	return response, &AmbiguousAuthorizationError{
+		Err:         err,
+		Outcome:     outcome,
+		ReversalErr: reversalErr,
+	}
+}
+
+// withReversalOption copies the request with sleet.TimeoutReversalOption set, leaving the caller's options unchanged
+func withReversalOption(request *sleet.AuthorizationRequest) *sleet.AuthorizationRequest {
+	reversible := *request
+	reversible.Options = make(map[string]interface{}, len(request.Options)+1)
+	for key, value := range request.Options {
+		reversible.Options[key] = value
+	}
+	reversible.Options[sleet.TimeoutReversalOption] = true
+	return &reversible
+}
+
+// reverse releases any hold placed by the authorization request
+func (client *Client) reverse(ctx context.Context, request *sleet.AuthorizationRequest) (Outcome, error) {
+	if client.reverser != nil {
+		reversal, err := client.reverser.ReverseTimedOutAuthorizationWithContext(ctx, sleet.NewTimeoutReversalRequest(request))
+		if err != nil {
+			return OutcomeUnresolved, err
+		}
+		if !reversal.Success {
+			return OutcomeUnresolved, fmt.Errorf("reversal: declined with error code %s", common.SafeStr(reversal.ErrorCode))
+		}
+		if reversal.NotAuthorized {
+			return OutcomeNotAuthorized, nil
+		}
+		return OutcomeReversed, nil
+	}
+
+	findRequest := &sleet.FindByClientReferenceRequest{
+		ClientTransactionReference: request.ClientTransactionReference,
+		Options:                    request.Options,
+	}
+	if request.MerchantOrderReference != "" {
+		findRequest.MerchantOrderReference = &request.MerchantOrderReference
+	}
+	found, err := client.finder.FindByClientReferenceWithContext(ctx, findRequest)
+	if err != nil {
+		return OutcomeUnresolved, err
+	}
+	if !found.Success {
+		return OutcomeUnresolved, fmt.Errorf("reversal: search failed with error code %s", common.SafeStr(found.ErrorCode))
+	}
+
+	outcome := OutcomeNotAuthorized
+	for _, transaction := range found.Transactions {
+		if transaction.State != sleet.TransactionStateAuthorized {
+			continue
+		}
+		void, err := client.ClientWithContext.VoidWithContext(ctx, &sleet.VoidRequest{
+			TransactionReference:       transaction.TransactionReference,
+			ClientTransactionReference: request.ClientTransactionReference,
+			MerchantOrderReference:     findRequest.MerchantOrderReference,
+		})
+		if err != nil {
+			return OutcomeUnresolved, err
+		}
+		if !void.Success {
+			return OutcomeUnresolved, fmt.Errorf("reversal: void declined with error code %s", common.SafeStr(void.ErrorCode))
+		}
+		outcome = OutcomeReversed
+	}
+	return outcome, nil
+}
+
+// IsAmbiguous reports whether the outcome of an authorization is unknown: the request timed out, the connection
+// was lost before a response was read, the response could not be decoded, or the PsP responded with a server error.
+func IsAmbiguous(response *sleet.AuthorizationResponse, err error) bool {
+	if err == nil {
+		return response != nil && response.StatusCode >= http.StatusInternalServerError
+	}
+	var sleetErr *sleet.Error
+	if errors.As(err, &sleetErr) {
+		switch sleetErr.Kind {
+		case sleet.ErrorKindTimeout, sleet.ErrorKindNetwork, sleet.ErrorKindDecode:
+			return true
+		case sleet.ErrorKindPSPAPI:
+			return sleetErr.StatusCode >= http.StatusInternalServerError
+		}
+		return false
+	}
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, context.Canceled) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF) ||
+		(errors.As(err, &netErr) && netErr.Timeout())
+}
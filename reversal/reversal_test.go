@@ -0,0 +1,236 @@
+package reversal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/middleware"
+	sleet_t "github.com/BoltApp/sleet/testing"
+)
+
+// fakeClient authorizes with the configured response and records the reversals and voids it receives. Reversals
+// succeed unless a reversal response is configured.
+type fakeClient struct {
+	authResponse *sleet.AuthorizationResponse
+	authErr      error
+	found        []sleet.TransactionQueryResponse
+	reversal     *sleet.TimeoutReversalResponse
+	reversals    int
+	voided       []string
+	authOptions  map[string]interface{}
+}
+
+func (c *fakeClient) Authorize(request *sleet.AuthorizationRequest) (*sleet.AuthorizationResponse, error) {
+	return c.AuthorizeWithContext(context.TODO(), request)
+}
+
+func (c *fakeClient) AuthorizeWithContext(_ context.Context, request *sleet.AuthorizationRequest) (*sleet.AuthorizationResponse, error) {
+	c.authOptions = request.Options
+	return c.authResponse, c.authErr
+}
+
+func (c *fakeClient) Capture(request *sleet.CaptureRequest) (*sleet.CaptureResponse, error) {
+	return c.CaptureWithContext(context.TODO(), request)
+}
+
+func (c *fakeClient) CaptureWithContext(_ context.Context, _ *sleet.CaptureRequest) (*sleet.CaptureResponse, error) {
+	return &sleet.CaptureResponse{Success: true}, nil
+}
+
+func (c *fakeClient) Void(request *sleet.VoidRequest) (*sleet.VoidResponse, error) {
+	return c.VoidWithContext(context.TODO(), request)
+}
+
+func (c *fakeClient) VoidWithContext(_ context.Context, request *sleet.VoidRequest) (*sleet.VoidResponse, error) {
+	c.voided = append(c.voided, request.TransactionReference)
+	return &sleet.VoidResponse{Success: true}, nil
+}
+
+func (c *fakeClient) Refund(request *sleet.RefundRequest) (*sleet.RefundResponse, error) {
+	return c.RefundWithContext(context.TODO(), request)
+}
+
+func (c *fakeClient) RefundWithContext(_ context.Context, _ *sleet.RefundRequest) (*sleet.RefundResponse, error) {
+	return &sleet.RefundResponse{Success: true}, nil
+}
+
+// fakeReverser also supports timeout reversals
+type fakeReverser struct {
+	fakeClient
+}
+
+func (c *fakeReverser) ReverseTimedOutAuthorization(request *sleet.TimeoutReversalRequest) (*sleet.TimeoutReversalResponse, error) {
+	return c.ReverseTimedOutAuthorizationWithContext(context.TODO(), request)
+}
+
+func (c *fakeReverser) ReverseTimedOutAuthorizationWithContext(_ context.Context, _ *sleet.TimeoutReversalRequest) (*sleet.TimeoutReversalResponse, error) {
+	c.reversals++
+	if c.reversal != nil {
+		return c.reversal, nil
+	}
+	return &sleet.TimeoutReversalResponse{Success: true}, nil
+}
+
+// fakeFinder also finds transactions by reference
+type fakeFinder struct {
+	fakeClient
+}
+
+func (c *fakeFinder) FindByClientReference(request *sleet.FindByClientReferenceRequest) (*sleet.FindByClientReferenceResponse, error) {
+	return c.FindByClientReferenceWithContext(context.TODO(), request)
+}
+
+func (c *fakeFinder) FindByClientReferenceWithContext(_ context.Context, _ *sleet.FindByClientReferenceRequest) (*sleet.FindByClientReferenceResponse, error) {
+	return &sleet.FindByClientReferenceResponse{Success: true, Transactions: c.found}, nil
+}
+
+func TestNewClient(t *testing.T) {
+	if _, err := NewClient(&fakeClient{}, 0); err != ErrReversalNotSupported {
+		t.Errorf("expected ErrReversalNotSupported, got %v", err)
+	}
+	if _, err := NewClient(middleware.Wrap(&fakeClient{}), 0); err != ErrReversalNotSupported {
+		t.Errorf("expected ErrReversalNotSupported for a wrapped client without reversals, got %v", err)
+	}
+
+	inner := &fakeReverser{fakeClient{authErr: context.DeadlineExceeded}}
+	client, err := NewClient(middleware.Wrap(inner), 0)
+	if err != nil {
+		t.Fatalf("expected a wrapped reverser to be supported, got %v", err)
+	}
+	if _, err := client.Authorize(sleet_t.BaseAuthorizationRequest()); err == nil {
+		t.Error("expected the ambiguous authorization to be reported")
+	}
+	if inner.reversals != 1 {
+		t.Errorf("expected the reversal to be forwarded to the wrapped client, got %d reversals", inner.reversals)
+	}
+}
+
+func TestAuthorizeWithContext(t *testing.T) {
+	request := sleet_t.BaseAuthorizationRequest()
+
+	t.Run("Approved Authorization", func(t *testing.T) {
+		inner := &fakeReverser{fakeClient{authResponse: &sleet.AuthorizationResponse{Success: true, StatusCode: http.StatusOK}}}
+		client, _ := NewClient(inner, 0)
+
+		got, err := client.Authorize(request)
+		if err != nil || !got.Success {
+			t.Fatalf("expected a successful authorization, got %+v, %v", got, err)
+		}
+		if inner.reversals != 0 {
+			t.Errorf("expected no reversal, got %d", inner.reversals)
+		}
+	})
+
+	t.Run("Authorization Is Sent Reversible", func(t *testing.T) {
+		inner := &fakeReverser{fakeClient{authResponse: &sleet.AuthorizationResponse{Success: true, StatusCode: http.StatusOK}}}
+		client, _ := NewClient(inner, 0)
+
+		optioned := *request
+		optioned.Options = map[string]interface{}{sleet.ResponseHeaderOption: []string{"X-Request-Id"}}
+		if _, err := client.Authorize(&optioned); err != nil {
+			t.Fatal(err)
+		}
+		if reversible, _ := inner.authOptions[sleet.TimeoutReversalOption].(bool); !reversible {
+			t.Errorf("expected the authorization to be sent with %s, got %v", sleet.TimeoutReversalOption, inner.authOptions)
+		}
+		if _, ok := inner.authOptions[sleet.ResponseHeaderOption]; !ok {
+			t.Errorf("expected the caller's options to be kept, got %v", inner.authOptions)
+		}
+		if _, ok := optioned.Options[sleet.TimeoutReversalOption]; ok {
+			t.Error("expected the caller's options to be left unchanged")
+		}
+	})
+
+	t.Run("Timed Out Authorization Is Reversed", func(t *testing.T) {
+		inner := &fakeReverser{fakeClient{authErr: context.DeadlineExceeded}}
+		client, _ := NewClient(inner, 0)
+
+		got, err := client.Authorize(request)
+		var ambiguous *AmbiguousAuthorizationError
+		if !errors.As(err, &ambiguous) {
+			t.Fatalf("expected an AmbiguousAuthorizationError, got %v", err)
+		}
+		if ambiguous.Outcome != OutcomeReversed || got.Success {
+			t.Errorf("expected a reversed unsuccessful authorization, got %s, %+v", ambiguous.Outcome, got)
+		}
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected the authorization error to be wrapped, got %v", err)
+		}
+		if inner.reversals != 1 {
+			t.Errorf("expected one reversal, got %d", inner.reversals)
+		}
+	})
+
+	t.Run("Timed Out Authorization Was Not Approved", func(t *testing.T) {
+		inner := &fakeReverser{fakeClient{
+			authErr:  context.DeadlineExceeded,
+			reversal: &sleet.TimeoutReversalResponse{Success: true, NotAuthorized: true},
+		}}
+		client, _ := NewClient(inner, 0)
+
+		_, err := client.Authorize(request)
+		var ambiguous *AmbiguousAuthorizationError
+		if !errors.As(err, &ambiguous) || ambiguous.Outcome != OutcomeNotAuthorized {
+			t.Fatalf("expected a not authorized AmbiguousAuthorizationError, got %v", err)
+		}
+	})
+
+	t.Run("Undecodable Authorization Is Reversed", func(t *testing.T) {
+		inner := &fakeReverser{fakeClient{authErr: &sleet.Error{Kind: sleet.ErrorKindDecode, StatusCode: http.StatusOK}}}
+		client, _ := NewClient(inner, 0)
+
+		_, err := client.Authorize(request)
+		var ambiguous *AmbiguousAuthorizationError
+		if !errors.As(err, &ambiguous) || ambiguous.Outcome != OutcomeReversed {
+			t.Fatalf("expected a reversed AmbiguousAuthorizationError, got %v", err)
+		}
+	})
+
+	t.Run("Invalid Authorization Is Not Reversed", func(t *testing.T) {
+		inner := &fakeReverser{fakeClient{authErr: &sleet.Error{Kind: sleet.ErrorKindValidation, Err: sleet.ErrClientReferenceRequired}}}
+		client, _ := NewClient(inner, 0)
+
+		_, err := client.Authorize(request)
+		var ambiguous *AmbiguousAuthorizationError
+		if errors.As(err, &ambiguous) || !errors.Is(err, sleet.ErrClientReferenceRequired) {
+			t.Fatalf("expected the validation error, got %v", err)
+		}
+		if inner.reversals != 0 {
+			t.Errorf("expected no reversal, got %d", inner.reversals)
+		}
+	})
+
+	t.Run("Server Error Is Voided By Reference", func(t *testing.T) {
+		inner := &fakeFinder{fakeClient{
+			authResponse: &sleet.AuthorizationResponse{StatusCode: http.StatusBadGateway},
+			found: []sleet.TransactionQueryResponse{
+				{TransactionReference: "declined", State: sleet.TransactionStateDeclined},
+				{TransactionReference: "authorized", State: sleet.TransactionStateAuthorized},
+			},
+		}}
+		client, _ := NewClient(inner, 0)
+
+		_, err := client.Authorize(request)
+		var ambiguous *AmbiguousAuthorizationError
+		if !errors.As(err, &ambiguous) || ambiguous.Outcome != OutcomeReversed {
+			t.Fatalf("expected a reversed AmbiguousAuthorizationError, got %v", err)
+		}
+		if len(inner.voided) != 1 || inner.voided[0] != "authorized" {
+			t.Errorf("expected only the authorized transaction to be voided, got %v", inner.voided)
+		}
+	})
+
+	t.Run("Server Error Without Authorization", func(t *testing.T) {
+		inner := &fakeFinder{fakeClient{authResponse: &sleet.AuthorizationResponse{StatusCode: http.StatusServiceUnavailable}}}
+		client, _ := NewClient(inner, 0)
+
+		_, err := client.Authorize(request)
+		var ambiguous *AmbiguousAuthorizationError
+		if !errors.As(err, &ambiguous) || ambiguous.Outcome != OutcomeNotAuthorized {
+			t.Fatalf("expected a not authorized AmbiguousAuthorizationError, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,45 @@
+package router
+
+import (
+	"sync"
+)
+
+// OwnerStore remembers the gateway which owns each TransactionReference, so follow-on operations are sent back to
+// the PsP which authorized the transaction. A store shared between processes should be persistent.
+type OwnerStore interface {
+	// Put stores the name of the gateway owning the transaction reference
+	Put(transactionReference string, gateway string) error
+	// Get returns the name of the gateway owning the transaction reference, or "" if it is not stored
+	Get(transactionReference string) (string, error)
+}
+
+// MemoryOwnerStore is an OwnerStore kept in memory, suitable for tests and short lived processes. It keeps every
+// transaction reference it is given and never evicts one, as a refund may come months after its authorization, so it
+// grows with every authorization and its owners are lost on restart. Production needs an external OwnerStore, such as
+// a table in the merchant's database.
+type MemoryOwnerStore struct {
+	mu     sync.RWMutex
+	owners map[string]string
+}
+
+// NewMemoryOwnerStore creates an empty in memory OwnerStore
+func NewMemoryOwnerStore() *MemoryOwnerStore {
+	return &MemoryOwnerStore{owners: make(map[string]string)}
+}
+
+// Put stores the name of the gateway owning the transaction reference
+func (store *MemoryOwnerStore) Put(transactionReference string, gateway string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.owners[transactionReference] = gateway
+	return nil
+}
+
+// Get returns the name of the gateway owning the transaction reference
+func (store *MemoryOwnerStore) Get(transactionReference string) (string, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	return store.owners[transactionReference], nil
+}
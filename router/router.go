@@ -0,0 +1,231 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/reversal"
+)
+
+var (
+	// assert client interface
+	_ sleet.ClientWithContext = &Client{}
+)
+
+// ErrNoGateway is returned by Client.AuthorizeWithContext when no gateway's rules match the request
+var ErrNoGateway = errors.New("router: no gateway matches the authorization request")
+
+// ErrUnknownTransaction is returned for a capture, void or refund of a TransactionReference no gateway owns
+var ErrUnknownTransaction = errors.New("router: no gateway owns the transaction reference")
+
+// Gateway is a client authorizations may be routed to
+type Gateway struct {
+	Name   string // identifies the gateway in the OwnerStore, must be unique
+	Client sleet.ClientWithContext
+	Rules  []Rule // every rule must match for a request to be routed to the gateway, no rules match every request
+	Weight int    // relative share of the requests matching several gateways, 0 is treated as 1
+}
+
+// matches returns true if every rule of the gateway matches the request
+func (gateway *Gateway) matches(request *sleet.AuthorizationRequest) bool {
+	for _, rule := range gateway.Rules {
+		if !rule.Match(request) {
+			return false
+		}
+	}
+	return true
+}
+
+func (gateway *Gateway) weight() int {
+	if gateway.Weight <= 0 {
+		return 1
+	}
+	return gateway.Weight
+}
+
+// Client routes authorizations across several gateways and fails over to the next matching gateway when a gateway
+// has a server error. Captures, voids and refunds are sent to the gateway owning the TransactionReference.
+//
+// A server error response may hide an authorization which was approved. Wrap gateways with reversal.Client so such
+// authorizations are reversed, the Client then only fails over once the reversal has released the hold.
+type Client struct {
+	gateways []Gateway
+	byName   map[string]*Gateway
+	owners   OwnerStore
+
+	mu   sync.Mutex
+	intn func(n int) int // returns a random int in [0, n)
+}
+
+// NewClient creates a Client routing to the gateways, which are tried in order of their weighted share. The owner of
+// each authorization is kept in owners, or in a MemoryOwnerStore if owners is nil, which production should not rely on
+// as it is never evicted.
+func NewClient(gateways []Gateway, owners OwnerStore) (*Client, error) {
+	if len(gateways) == 0 {
+		return nil, errors.New("router: no gateways given")
+	}
+	byName := make(map[string]*Gateway, len(gateways))
+	gateways = append([]Gateway{}, gateways...)
+	for i := range gateways {
+		gateway := &gateways[i]
+		if gateway.Name == "" || gateway.Client == nil {
+			return nil, fmt.Errorf("router: gateway %d needs a name and a client", i)
+		}
+		if _, ok := byName[gateway.Name]; ok {
+			return nil, fmt.Errorf("router: duplicate gateway name %s", gateway.Name)
+		}
+		byName[gateway.Name] = gateway
+	}
+	if owners == nil {
+		owners = NewMemoryOwnerStore()
+	}
+	return &Client{
+		gateways: gateways,
+		byName:   byName,
+		owners:   owners,
+		intn:     rand.New(rand.NewSource(time.Now().UnixNano())).Intn,
+	}, nil
+}
+
+// Owner returns the name of the gateway owning the transaction reference, or "" if no gateway owns it
+func (client *Client) Owner(transactionReference string) (string, error) {
+	return client.owners.Get(transactionReference)
+}
+
+// Authorize wraps AuthorizeWithContext
+func (client *Client) Authorize(request *sleet.AuthorizationRequest) (*sleet.AuthorizationResponse, error) {
+	return client.AuthorizeWithContext(context.TODO(), request)
+}
+
+// AuthorizeWithContext authorizes with the gateways matching the request, failing over to the next gateway while the
+// authorization fails with a server error. The response of the last gateway tried is returned.
+func (client *Client) AuthorizeWithContext(ctx context.Context, request *sleet.AuthorizationRequest) (*sleet.AuthorizationResponse, error) {
+	gateways := client.route(request)
+	if len(gateways) == 0 {
+		return nil, ErrNoGateway
+	}
+
+	var response *sleet.AuthorizationResponse
+	var err error
+	for _, gateway := range gateways {
+		response, err = gateway.Client.AuthorizeWithContext(ctx, request)
+		if !canFailover(response, err) || ctx.Err() != nil {
+			if err == nil && response != nil && response.TransactionReference != "" {
+				if ownerErr := client.owners.Put(response.TransactionReference, gateway.Name); ownerErr != nil {
+					return response, ownerErr
+				}
+			}
+			return response, err
+		}
+	}
+	return response, err
+}
+
+// Capture wraps CaptureWithContext
+func (client *Client) Capture(request *sleet.CaptureRequest) (*sleet.CaptureResponse, error) {
+	return client.CaptureWithContext(context.TODO(), request)
+}
+
+// CaptureWithContext captures with the gateway owning the TransactionReference. PsPs which give the capture its own
+// reference have it stored for the same gateway, so it can be refunded through the Client.
+func (client *Client) CaptureWithContext(ctx context.Context, request *sleet.CaptureRequest) (*sleet.CaptureResponse, error) {
+	gateway, err := client.owner(request.TransactionReference)
+	if err != nil {
+		return nil, err
+	}
+	response, err := gateway.Client.CaptureWithContext(ctx, request)
+	if err == nil && response != nil && response.TransactionReference != "" && response.TransactionReference != request.TransactionReference {
+		if ownerErr := client.owners.Put(response.TransactionReference, gateway.Name); ownerErr != nil {
+			return response, ownerErr
+		}
+	}
+	return response, err
+}
+
+// Void wraps VoidWithContext
+func (client *Client) Void(request *sleet.VoidRequest) (*sleet.VoidResponse, error) {
+	return client.VoidWithContext(context.TODO(), request)
+}
+
+// VoidWithContext voids with the gateway owning the TransactionReference
+func (client *Client) VoidWithContext(ctx context.Context, request *sleet.VoidRequest) (*sleet.VoidResponse, error) {
+	gateway, err := client.owner(request.TransactionReference)
+	if err != nil {
+		return nil, err
+	}
+	return gateway.Client.VoidWithContext(ctx, request)
+}
+
+// Refund wraps RefundWithContext
+func (client *Client) Refund(request *sleet.RefundRequest) (*sleet.RefundResponse, error) {
+	return client.RefundWithContext(context.TODO(), request)
+}
+
+// RefundWithContext refunds with the gateway owning the TransactionReference
+func (client *Client) RefundWithContext(ctx context.Context, request *sleet.RefundRequest) (*sleet.RefundResponse, error) {
+	gateway, err := client.owner(request.TransactionReference)
+	if err != nil {
+		return nil, err
+	}
+	return gateway.Client.RefundWithContext(ctx, request)
+}
+
+// owner returns the gateway owning the transaction reference
+func (client *Client) owner(transactionReference string) (*Gateway, error) {
+	name, err := client.owners.Get(transactionReference)
+	if err != nil {
+		return nil, err
+	}
+	gateway, ok := client.byName[name]
+	if !ok {
+		return nil, ErrUnknownTransaction
+	}
+	return gateway, nil
+}
+
+// route returns the gateways matching the request in a weighted random order, so each gateway is tried first for
+// its share of requests
+func (client *Client) route(request *sleet.AuthorizationRequest) []*Gateway {
+	var matching []*Gateway
+	total := 0
+	for i := range client.gateways {
+		if client.gateways[i].matches(request) {
+			matching = append(matching, &client.gateways[i])
+			total += client.gateways[i].weight()
+		}
+	}
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	for i := 0; i < len(matching)-1; i++ {
+		pick := client.intn(total)
+		j := i
+		for ; pick >= matching[j].weight(); j++ {
+			pick -= matching[j].weight()
+		}
+		total -= matching[j].weight()
+		matching[i], matching[j] = matching[j], matching[i]
+	}
+	return matching
+}
+
+// canFailover reports whether an authorization failed without placing a hold on the card, so it can be retried with
+// another gateway: the PsP responded with a server error, the connection could not be established, or the
+// authorization was ambiguous and reversal.Client released the hold.
+func canFailover(response *sleet.AuthorizationResponse, err error) bool {
+	if err == nil {
+		return response != nil && response.ResultType == sleet.ResultTypeServerError
+	}
+	var ambiguous *reversal.AmbiguousAuthorizationError
+	if errors.As(err, &ambiguous) {
+		return ambiguous.Outcome != reversal.OutcomeUnresolved
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
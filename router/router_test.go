@@ -0,0 +1,200 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"testing"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/reversal"
+	sleet_t "github.com/BoltApp/sleet/testing"
+)
+
+// fakeClient authorizes with the configured response and records the operations it receives
+type fakeClient struct {
+	authResponse *sleet.AuthorizationResponse
+	authErr      error
+	captureRef   string
+	authorized   int
+	captured     []string
+	voided       []string
+	refunded     []string
+}
+
+func (c *fakeClient) Authorize(request *sleet.AuthorizationRequest) (*sleet.AuthorizationResponse, error) {
+	return c.AuthorizeWithContext(context.TODO(), request)
+}
+
+func (c *fakeClient) AuthorizeWithContext(_ context.Context, _ *sleet.AuthorizationRequest) (*sleet.AuthorizationResponse, error) {
+	c.authorized++
+	return c.authResponse, c.authErr
+}
+
+func (c *fakeClient) Capture(request *sleet.CaptureRequest) (*sleet.CaptureResponse, error) {
+	return c.CaptureWithContext(context.TODO(), request)
+}
+
+func (c *fakeClient) CaptureWithContext(_ context.Context, request *sleet.CaptureRequest) (*sleet.CaptureResponse, error) {
+	c.captured = append(c.captured, request.TransactionReference)
+	return &sleet.CaptureResponse{Success: true, TransactionReference: c.captureRef}, nil
+}
+
+func (c *fakeClient) Void(request *sleet.VoidRequest) (*sleet.VoidResponse, error) {
+	return c.VoidWithContext(context.TODO(), request)
+}
+
+func (c *fakeClient) VoidWithContext(_ context.Context, request *sleet.VoidRequest) (*sleet.VoidResponse, error) {
+	c.voided = append(c.voided, request.TransactionReference)
+	return &sleet.VoidResponse{Success: true}, nil
+}
+
+func (c *fakeClient) Refund(request *sleet.RefundRequest) (*sleet.RefundResponse, error) {
+	return c.RefundWithContext(context.TODO(), request)
+}
+
+func (c *fakeClient) RefundWithContext(_ context.Context, request *sleet.RefundRequest) (*sleet.RefundResponse, error) {
+	c.refunded = append(c.refunded, request.TransactionReference)
+	return &sleet.RefundResponse{Success: true}, nil
+}
+
+func approved(reference string) *sleet.AuthorizationResponse {
+	return &sleet.AuthorizationResponse{Success: true, TransactionReference: reference, ResultType: sleet.ResultTypeSuccess}
+}
+
+func TestNewClient(t *testing.T) {
+	client := &fakeClient{}
+
+	if _, err := NewClient(nil, nil); err == nil {
+		t.Error("expected an error without gateways")
+	}
+	if _, err := NewClient([]Gateway{{Client: client}}, nil); err == nil {
+		t.Error("expected an error for a gateway without a name")
+	}
+	if _, err := NewClient([]Gateway{{Name: "a", Client: client}, {Name: "a", Client: client}}, nil); err == nil {
+		t.Error("expected an error for duplicate gateway names")
+	}
+}
+
+func TestRouting(t *testing.T) {
+	usd := &fakeClient{authResponse: approved("usd-auth"), captureRef: "usd-capture"}
+	eur := &fakeClient{authResponse: approved("eur-auth")}
+	client, err := NewClient([]Gateway{
+		{Name: "usd", Client: usd, Rules: []Rule{Currencies("USD")}},
+		{Name: "eur", Client: eur, Rules: []Rule{Currencies("EUR")}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := sleet_t.BaseAuthorizationRequest()
+	got, err := client.Authorize(request)
+	if err != nil || got.TransactionReference != "usd-auth" {
+		t.Fatalf("expected the USD gateway to authorize, got %+v, %v", got, err)
+	}
+	if eur.authorized != 0 {
+		t.Error("expected the EUR gateway not to be called")
+	}
+	if owner, _ := client.Owner("usd-auth"); owner != "usd" {
+		t.Errorf("expected the USD gateway to own the authorization, got %q", owner)
+	}
+
+	if _, err := client.Capture(&sleet.CaptureRequest{TransactionReference: "usd-auth"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Refund(&sleet.RefundRequest{TransactionReference: "usd-capture"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Void(&sleet.VoidRequest{TransactionReference: "usd-auth"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(usd.captured) != 1 || len(usd.refunded) != 1 || len(usd.voided) != 1 {
+		t.Errorf("expected follow-on operations to go to the USD gateway, got %+v", usd)
+	}
+
+	t.Run("No Matching Gateway", func(t *testing.T) {
+		request := sleet_t.BaseAuthorizationRequest()
+		request.Amount.Currency = "JPY"
+		if _, err := client.Authorize(request); err != ErrNoGateway {
+			t.Errorf("expected ErrNoGateway, got %v", err)
+		}
+	})
+
+	t.Run("Unknown Transaction", func(t *testing.T) {
+		if _, err := client.Void(&sleet.VoidRequest{TransactionReference: "unknown"}); err != ErrUnknownTransaction {
+			t.Errorf("expected ErrUnknownTransaction, got %v", err)
+		}
+	})
+}
+
+func TestFailover(t *testing.T) {
+	cases := []struct {
+		label    string
+		response *sleet.AuthorizationResponse
+		err      error
+		failover bool
+	}{
+		{"Server Error", &sleet.AuthorizationResponse{ResultType: sleet.ResultTypeServerError, StatusCode: 503}, nil, true},
+		{"Decline", &sleet.AuthorizationResponse{ResultType: sleet.ResultTypePaymentError}, nil, false},
+		{"Connection Refused", nil, &net.OpError{Op: "dial", Err: errors.New("connection refused")}, true},
+		{"Timeout", nil, context.DeadlineExceeded, false},
+		{"Reversed Ambiguous Authorization", &sleet.AuthorizationResponse{ResultType: sleet.ResultTypeServerError}, &reversal.AmbiguousAuthorizationError{Outcome: reversal.OutcomeReversed}, true},
+		{"Unresolved Ambiguous Authorization", &sleet.AuthorizationResponse{ResultType: sleet.ResultTypeServerError}, &reversal.AmbiguousAuthorizationError{Outcome: reversal.OutcomeUnresolved}, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			primary := &fakeClient{authResponse: c.response, authErr: c.err}
+			secondary := &fakeClient{authResponse: approved("secondary-auth")}
+			client, err := NewClient([]Gateway{
+				{Name: "primary", Client: primary},
+				{Name: "secondary", Client: secondary},
+			}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// the gateways are tried in the order given
+			client.intn = func(int) int { return 0 }
+
+			got, err := client.Authorize(sleet_t.BaseAuthorizationRequest())
+			if c.failover {
+				if err != nil || got.TransactionReference != "secondary-auth" {
+					t.Fatalf("expected failover to the secondary gateway, got %+v, %v", got, err)
+				}
+				if owner, _ := client.Owner("secondary-auth"); owner != "secondary" {
+					t.Errorf("expected the secondary gateway to own the authorization, got %q", owner)
+				}
+				return
+			}
+			if secondary.authorized != 0 {
+				t.Error("expected no failover")
+			}
+			if got != c.response || err != c.err {
+				t.Errorf("expected the primary gateway's result, got %+v, %v", got, err)
+			}
+		})
+	}
+}
+
+func TestWeights(t *testing.T) {
+	heavy := &fakeClient{authResponse: approved("heavy")}
+	light := &fakeClient{authResponse: approved("light")}
+	client, err := NewClient([]Gateway{
+		{Name: "heavy", Client: heavy, Weight: 3},
+		{Name: "light", Client: light, Weight: 1},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.intn = rand.New(rand.NewSource(1)).Intn
+
+	for i := 0; i < 1000; i++ {
+		if _, err := client.Authorize(sleet_t.BaseAuthorizationRequest()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if heavy.authorized < 700 || heavy.authorized > 800 {
+		t.Errorf("expected about 750 authorizations on the heavy gateway, got %d", heavy.authorized)
+	}
+}
@@ -0,0 +1,92 @@
+package router
+
+import (
+	"strings"
+
+	"github.com/BoltApp/sleet"
+)
+
+// binLength is the number of leading card number digits passed to a BINCountryLookup
+const binLength = 6
+
+// Rule matches the authorization requests a gateway should receive
+type Rule interface {
+	Match(request *sleet.AuthorizationRequest) bool
+}
+
+// RuleFunc adapts a function to a Rule
+type RuleFunc func(request *sleet.AuthorizationRequest) bool
+
+// Match calls the function with the request
+func (f RuleFunc) Match(request *sleet.AuthorizationRequest) bool {
+	return f(request)
+}
+
+// BINCountryLookup returns the ISO 3166 alpha-2 country code of the issuer of a BIN, or false if it is unknown.
+// Sleet has no BIN database, so the lookup is provided by the caller.
+type BINCountryLookup func(bin string) (string, bool)
+
+// Currencies matches requests with an amount in one of the currencies
+func Currencies(currencies ...string) Rule {
+	return RuleFunc(func(request *sleet.AuthorizationRequest) bool {
+		for _, currency := range currencies {
+			if strings.EqualFold(currency, request.Amount.Currency) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// CardNetworks matches requests paid with a credit card of one of the networks
+func CardNetworks(networks ...sleet.CreditCardNetwork) Rule {
+	return RuleFunc(func(request *sleet.AuthorizationRequest) bool {
+		if request.CreditCard == nil {
+			return false
+		}
+		for _, network := range networks {
+			if network == request.CreditCard.Network {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// BINCountries matches requests paid with a credit card issued in one of the countries. Requests without a card
+// number, such as stored payment methods, or with a BIN unknown to the lookup do not match.
+func BINCountries(lookup BINCountryLookup, countries ...string) Rule {
+	return RuleFunc(func(request *sleet.AuthorizationRequest) bool {
+		if request.CreditCard == nil || len(request.CreditCard.Number) < binLength {
+			return false
+		}
+		country, ok := lookup(request.CreditCard.Number[:binLength])
+		if !ok {
+			return false
+		}
+		for _, c := range countries {
+			if strings.EqualFold(c, country) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// AmountRange matches requests for an amount from min up to and including max, in minor units of the currency.
+// A max of 0 leaves the range unbounded.
+func AmountRange(min int64, max int64) Rule {
+	return RuleFunc(func(request *sleet.AuthorizationRequest) bool {
+		if request.Amount.Amount < min {
+			return false
+		}
+		return max == 0 || request.Amount.Amount <= max
+	})
+}
+
+// Not matches requests which do not match the rule
+func Not(rule Rule) Rule {
+	return RuleFunc(func(request *sleet.AuthorizationRequest) bool {
+		return !rule.Match(request)
+	})
+}
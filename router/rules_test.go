@@ -0,0 +1,46 @@
+package router
+
+import (
+	"testing"
+
+	"github.com/BoltApp/sleet"
+	sleet_t "github.com/BoltApp/sleet/testing"
+)
+
+func TestRules(t *testing.T) {
+	request := sleet_t.BaseAuthorizationRequest()
+	request.CreditCard.Network = sleet.CreditCardNetworkVisa
+
+	lookup := func(bin string) (string, bool) {
+		if bin == request.CreditCard.Number[:binLength] {
+			return "US", true
+		}
+		return "", false
+	}
+
+	cases := []struct {
+		label string
+		rule  Rule
+		want  bool
+	}{
+		{"Currency", Currencies("EUR", "usd"), true},
+		{"Other Currency", Currencies("EUR"), false},
+		{"Card Network", CardNetworks(sleet.CreditCardNetworkMastercard, sleet.CreditCardNetworkVisa), true},
+		{"Other Card Network", CardNetworks(sleet.CreditCardNetworkAmex), false},
+		{"BIN Country", BINCountries(lookup, "US"), true},
+		{"Other BIN Country", BINCountries(lookup, "GB"), false},
+		{"Unknown BIN", BINCountries(func(string) (string, bool) { return "", false }, "US"), false},
+		{"Amount In Range", AmountRange(request.Amount.Amount, request.Amount.Amount), true},
+		{"Amount Unbounded", AmountRange(1, 0), true},
+		{"Amount Below Range", AmountRange(request.Amount.Amount+1, 0), false},
+		{"Not", Not(Currencies("USD")), false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			if got := c.rule.Match(request); got != c.want {
+				t.Errorf("expected %v, got %v", c.want, got)
+			}
+		})
+	}
+}
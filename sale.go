@@ -0,0 +1,25 @@
+package sleet
+
+import "context"
+
+// Sale is implemented by clients of PsPs which can authorize and capture a transaction in a single call, saving a
+// round trip when goods are delivered immediately, such as digital goods.
+type Sale interface {
+	Sale(request *SaleRequest) (*SaleResponse, error)
+}
+
+// SaleWithContext is a superset of `Sale` that includes additional methods that take
+// `context.Context` as parameters.
+type SaleWithContext interface {
+	Sale
+	SaleWithContext(ctx context.Context, request *SaleRequest) (*SaleResponse, error)
+}
+
+// SaleRequest takes the same information as an authorization
+type SaleRequest = AuthorizationRequest
+
+// SaleResponse has the same fields as an AuthorizationResponse. Success is true if the transaction was authorized and
+// captured, and TransactionReference is used to refund the sale.
+type SaleResponse struct {
+	AuthorizationResponse
+}
@@ -184,6 +184,20 @@ func BaseRefundRequest() *sleet.RefundRequest {
 	}
 }
 
+func BaseIncrementAuthorizationRequest() *sleet.IncrementAuthorizationRequest {
+	clientRef := "222222"
+
+	return &sleet.IncrementAuthorizationRequest{
+		Amount: sleet.Amount{
+			Amount:   50,
+			Currency: "USD",
+		},
+		AuthorizedAmount:           100,
+		TransactionReference:       "111111",
+		ClientTransactionReference: &clientRef,
+	}
+}
+
 // Base3DS provides a template with 3DS authorization data. Fields are populated by their names
 // since no valid values will exist without first having run 3DS.
 func Base3DS() *sleet.ThreeDS {
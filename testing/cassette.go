@@ -0,0 +1,461 @@
+package testing
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/BoltApp/sleet/wirelog"
+)
+
+// RecordCassettesEnv is the environment variable which, when set, makes cassettes record instead of replaying
+const RecordCassettesEnv = "SLEET_RECORD_CASSETTES"
+
+// Normalized replaces the values of headers which change on every request, such as dates and request ids
+const Normalized = "[NORMALIZED]"
+
+// CassetteMode is whether a cassette records exchanges with the PsP or replays them
+type CassetteMode string
+
+const (
+	// CassetteReplay answers requests from the golden file, a request matching no recorded interaction fails
+	CassetteReplay CassetteMode = "replay"
+	// CassetteRecord sends requests to the PsP and writes the exchanges to the golden file on Save
+	CassetteRecord CassetteMode = "record"
+)
+
+// normalizedHeaders are headers which differ on every request without being secrets, so they are normalized to keep
+// golden files stable when they are recorded again. Signatures and digests are already redacted by wirelog.
+var normalizedHeaders = []string{"Client-Request-Id", "Date", "Timestamp", "V-C-Date"}
+
+// volatileFields are fragments of the names of body fields which DefaultMatcher ignores
+var volatileFields = []string{"timestamp", "datetime", "nonce"}
+
+// Interaction is a scrubbed exchange with a PsP as it is stored in a golden file
+type Interaction struct {
+	Request  CassetteRequest  `json:"request"`
+	Response CassetteResponse `json:"response"`
+}
+
+// CassetteRequest is a scrubbed request. Its URL, headers and body have volatile values replaced by placeholders.
+type CassetteRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body"`
+}
+
+// CassetteResponse is a scrubbed response
+type CassetteResponse struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header"`
+	Body       string      `json:"body"`
+}
+
+// Matcher reports whether a request matches a recorded one, both scrubbed the same way
+type Matcher func(recorded *CassetteRequest, request *CassetteRequest) bool
+
+// Cassette is an http.RoundTripper recording a gateway's exchanges with its PsP to a golden file, so they can be
+// replayed by later runs without the PsP's sandbox. Exchanges are scrubbed as wirelog scrubs logs: card numbers are
+// masked to BIN and last four, and security codes, credentials, CyberSource's Signature and Digest headers and
+// First Data's Message-Signature header are redacted.
+//
+// Values which differ between runs, such as the random references of BaseAuthorizationRequest, are registered with
+// Volatile. They are stored as placeholders, and a replayed response has the placeholders replaced by the values of
+// the current run, so a gateway finds its own reference in the answer.
+type Cassette struct {
+	Path    string
+	Mode    CassetteMode
+	Next    http.RoundTripper // http.DefaultTransport if nil, only used when recording
+	Matcher Matcher           // DefaultMatcher if nil
+
+	mu           sync.Mutex
+	interactions []*Interaction
+	replayed     []bool
+	volatile     []string
+}
+
+// NewCassette returns a cassette for the golden file at path. It records if RecordCassettesEnv is set, and otherwise
+// loads the golden file to replay it.
+func NewCassette(path string, next http.RoundTripper) (*Cassette, error) {
+	cassette := &Cassette{Path: path, Mode: CassetteReplay, Next: next}
+	if os.Getenv(RecordCassettesEnv) != "" {
+		cassette.Mode = CassetteRecord
+		return cassette, nil
+	}
+	if err := cassette.load(); err != nil {
+		return nil, err
+	}
+	return cassette, nil
+}
+
+// Cassette returns the cassette for the golden file at path, failing the test if it can't be loaded. A recording is
+// saved when the test ends, and a replay fails the test if the gateway sent fewer requests than were recorded.
+func (h TestHelper) Cassette(path string, next http.RoundTripper) *Cassette {
+	h.t.Helper()
+
+	cassette, err := NewCassette(path, next)
+	if err != nil {
+		h.t.Fatalf("Error loading cassette\n %+v", err)
+		return nil
+	}
+	h.t.Cleanup(func() {
+		if err := cassette.Save(); err != nil {
+			h.t.Errorf("Error saving cassette\n %+v", err)
+		}
+		if unplayed := cassette.Unplayed(); unplayed > 0 {
+			h.t.Errorf("%d interactions of cassette %s were not replayed", unplayed, path)
+		}
+	})
+	return cassette
+}
+
+func (c *Cassette) load() error {
+	data, err := ioutil.ReadFile(c.Path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("cassette %s has not been recorded, set %s to record it: %w", c.Path, RecordCassettesEnv, err)
+		}
+		return err
+	}
+	var interactions []*Interaction
+	if err := json.Unmarshal(data, &interactions); err != nil {
+		return fmt.Errorf("cassette %s: %w", c.Path, err)
+	}
+	c.interactions = interactions
+	c.replayed = make([]bool, len(interactions))
+	return nil
+}
+
+// HTTPClient returns a copy of httpClient sending its requests through the cassette, a default client if nil
+func (c *Cassette) HTTPClient(httpClient *http.Client) *http.Client {
+	client := &http.Client{}
+	if httpClient != nil {
+		*client = *httpClient
+	}
+	if c.Next == nil {
+		c.Next = client.Transport
+	}
+	client.Transport = c
+	return client
+}
+
+// Volatile registers values which differ between runs, such as generated references, so requests match whatever
+// their values in the run which recorded them. Values must be registered in the same order in every run.
+func (c *Cassette) Volatile(values ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, value := range values {
+		if value != "" {
+			c.volatile = append(c.volatile, value)
+		}
+	}
+}
+
+// Save writes the recorded interactions to the golden file, it does nothing when replaying
+func (c *Cassette) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.Mode != CassetteRecord {
+		return nil
+	}
+	data, err := json.MarshalIndent(c.interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.Path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.Path, append(data, '\n'), 0644)
+}
+
+// Unplayed returns the number of recorded interactions which were not replayed, meaning the gateway sent fewer
+// requests than when the cassette was recorded
+func (c *Cassette) Unplayed() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	unplayed := 0
+	for _, replayed := range c.replayed {
+		if !replayed {
+			unplayed++
+		}
+	}
+	return unplayed
+}
+
+// RoundTrip records the exchange through Next, or answers the request with the first recorded interaction matching
+// it which has not been replayed yet
+func (c *Cassette) RoundTrip(request *http.Request) (*http.Response, error) {
+	var body []byte
+	if request.Body != nil && request.Body != http.NoBody {
+		var err error
+		body, err = ioutil.ReadAll(request.Body)
+		request.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		request = request.Clone(request.Context())
+		request.Body = ioutil.NopCloser(bytes.NewReader(body))
+		// redirects and retries resend the request through GetBody, which must return the body read here
+		request.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	recorded := CassetteRequest{
+		Method: request.Method,
+		URL:    c.hide(wirelog.RedactURL(request.URL)),
+		Header: c.scrubHeader(request.Header),
+		Body:   c.hide(string(wirelog.Redact(request.Header.Get("Content-Type"), body))),
+	}
+	if c.Mode == CassetteRecord {
+		return c.record(request, recorded)
+	}
+	return c.replay(request, recorded)
+}
+
+func (c *Cassette) record(request *http.Request, recorded CassetteRequest) (*http.Response, error) {
+	next := c.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	response, err := next.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	response.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	c.interactions = append(c.interactions, &Interaction{
+		Request: recorded,
+		Response: CassetteResponse{
+			StatusCode: response.StatusCode,
+			Header:     c.scrubHeader(response.Header),
+			Body:       c.hide(string(wirelog.Redact(response.Header.Get("Content-Type"), body))),
+		},
+	})
+	return response, nil
+}
+
+func (c *Cassette) replay(request *http.Request, recorded CassetteRequest) (*http.Response, error) {
+	matcher := c.Matcher
+	if matcher == nil {
+		matcher = DefaultMatcher
+	}
+	for i, interaction := range c.interactions {
+		if c.replayed[i] || !matcher(&interaction.Request, &recorded) {
+			continue
+		}
+		c.replayed[i] = true
+
+		header := make(http.Header, len(interaction.Response.Header))
+		for name, values := range interaction.Response.Header {
+			for _, value := range values {
+				header.Add(name, c.reveal(value))
+			}
+		}
+		// the length changes when placeholders are replaced, and is set from the body instead
+		header.Del("Content-Length")
+		body := c.reveal(interaction.Response.Body)
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          ioutil.NopCloser(strings.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       request,
+		}, nil
+	}
+	return nil, fmt.Errorf("cassette %s: no recorded interaction matches %s %s", c.Path, recorded.Method, recorded.URL)
+}
+
+// scrubHeader redacts the secrets of a header and normalizes the values which differ on every request
+func (c *Cassette) scrubHeader(header http.Header) http.Header {
+	scrubbed := wirelog.RedactHeader(header)
+	for name, values := range scrubbed {
+		for i, value := range values {
+			values[i] = c.hide(value)
+		}
+		scrubbed[name] = values
+	}
+	for _, name := range normalizedHeaders {
+		if scrubbed.Get(name) != "" {
+			scrubbed.Set(name, Normalized)
+		}
+	}
+	return scrubbed
+}
+
+func placeholder(i int) string {
+	return fmt.Sprintf("[VOLATILE-%d]", i+1)
+}
+
+// hide replaces the volatile values in text with their placeholders
+func (c *Cassette) hide(text string) string {
+	for i, value := range c.volatile {
+		text = strings.ReplaceAll(text, value, placeholder(i))
+	}
+	return text
+}
+
+// reveal replaces the placeholders in text with the volatile values of the current run
+func (c *Cassette) reveal(text string) string {
+	for i, value := range c.volatile {
+		text = strings.ReplaceAll(text, placeholder(i), value)
+	}
+	return text
+}
+
+// DefaultMatcher matches requests by method, path and query, and by the fields of their bodies, whatever their order,
+// other than timestamps and nonces. The host is not matched, so a cassette replays wherever it was recorded, nor are
+// headers, which hold dates, request ids and signatures.
+func DefaultMatcher(recorded *CassetteRequest, request *CassetteRequest) bool {
+	if recorded.Method != request.Method {
+		return false
+	}
+	recordedURL, err := url.Parse(recorded.URL)
+	if err != nil {
+		return recorded.URL == request.URL
+	}
+	requestURL, err := url.Parse(request.URL)
+	if err != nil || recordedURL.Path != requestURL.Path || recordedURL.RawQuery != requestURL.RawQuery {
+		return false
+	}
+
+	recordedFields := bodyFields(recorded.Header.Get("Content-Type"), recorded.Body)
+	requestFields := bodyFields(request.Header.Get("Content-Type"), request.Body)
+	if len(recordedFields) != len(requestFields) {
+		return false
+	}
+	for name, value := range recordedFields {
+		requestValue, ok := requestFields[name]
+		if !ok {
+			return false
+		}
+		if requestValue != value && !isVolatileField(name) {
+			return false
+		}
+	}
+	return true
+}
+
+func isVolatileField(path string) bool {
+	name := strings.ToLower(path)
+	if separator := strings.LastIndexAny(name, ".@/"); separator >= 0 {
+		name = name[separator+1:]
+	}
+	name = strings.NewReplacer("_", "", "-", "").Replace(name)
+	for _, fragment := range volatileFields {
+		if strings.Contains(name, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// bodyFields flattens a JSON, XML or name-value body into its values by path. Other bodies are a single field.
+func bodyFields(contentType string, body string) map[string]string {
+	fields := make(map[string]string)
+	contentType = strings.ToLower(contentType)
+	trimmed := strings.TrimSpace(body)
+	switch {
+	case trimmed == "":
+	case strings.Contains(contentType, "json"), trimmed[0] == '{' || trimmed[0] == '[':
+		decoder := json.NewDecoder(strings.NewReader(body))
+		decoder.UseNumber()
+		var value interface{}
+		if err := decoder.Decode(&value); err != nil {
+			fields[""] = body
+			break
+		}
+		flattenJSON(fields, "", value)
+	case strings.Contains(contentType, "xml"), trimmed[0] == '<':
+		if err := flattenXML(fields, body); err != nil {
+			fields = map[string]string{"": body}
+		}
+	case strings.Contains(contentType, "form-urlencoded"), strings.Contains(contentType, "namevalue"), strings.Contains(body, "="):
+		for _, pair := range strings.Split(body, "&") {
+			name, value := pair, ""
+			if separator := strings.Index(pair, "="); separator >= 0 {
+				name, value = pair[:separator], pair[separator+1:]
+			}
+			addField(fields, name, value)
+		}
+	default:
+		fields[""] = body
+	}
+	return fields
+}
+
+// addField adds a value by path, a path repeated in the body collecting its values in order
+func addField(fields map[string]string, path string, value string) {
+	if existing, ok := fields[path]; ok {
+		value = existing + "\n" + value
+	}
+	fields[path] = value
+}
+
+func flattenJSON(fields map[string]string, path string, value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			flattenJSON(fields, path+"."+key, field)
+		}
+	case []interface{}:
+		for i, element := range v {
+			flattenJSON(fields, fmt.Sprintf("%s.%d", path, i), element)
+		}
+	default:
+		addField(fields, path, fmt.Sprint(v))
+	}
+}
+
+func flattenXML(fields map[string]string, body string) error {
+	decoder := xml.NewDecoder(strings.NewReader(body))
+	var path []string
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			path = append(path, t.Name.Local)
+			for _, attribute := range t.Attr {
+				addField(fields, strings.Join(path, "/")+"@"+attribute.Name.Local, attribute.Value)
+			}
+		case xml.EndElement:
+			path = path[:len(path)-1]
+		case xml.CharData:
+			if text := strings.TrimSpace(string(t)); text != "" {
+				addField(fields, strings.Join(path, "/"), text)
+			}
+		}
+	}
+}
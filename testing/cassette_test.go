@@ -0,0 +1,203 @@
+package testing
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+	"github.com/BoltApp/sleet/gateways/cybersource"
+	"github.com/BoltApp/sleet/gateways/firstdata"
+	"github.com/BoltApp/sleet/testing/fakes"
+)
+
+func TestCassette(t *testing.T) {
+	setenv(t, RecordCassettesEnv, "")
+
+	gateways := []struct {
+		name    string
+		fake    func() *fakes.Server
+		secrets []string
+		client  func(httpClient *http.Client, options ...sleet.ClientOption) sleet.Client
+	}{
+		{
+			name:    "firstdata",
+			fake:    fakes.NewFirstdata,
+			secrets: []string{fakes.FirstdataApiKey},
+			client: func(httpClient *http.Client, options ...sleet.ClientOption) sleet.Client {
+				return firstdata.NewWithHttpClient(common.Custom, firstdata.Credentials{ApiKey: fakes.FirstdataApiKey, ApiSecret: fakes.FirstdataApiSecret}, httpClient, options...)
+			},
+		},
+		{
+			name:    "cybersource",
+			fake:    fakes.NewCybersource,
+			secrets: []string{fakes.CybersourceSharedSecret},
+			client: func(httpClient *http.Client, options ...sleet.ClientOption) sleet.Client {
+				return cybersource.NewWithHttpClient(common.Custom, fakes.CybersourceMerchantID, fakes.CybersourceKeyID, fakes.CybersourceSharedSecret, httpClient, options...)
+			},
+		},
+	}
+
+	for _, gateway := range gateways {
+		t.Run(gateway.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), gateway.name+".json")
+
+			fake := gateway.fake()
+			recorder := &Cassette{Path: path, Mode: CassetteRecord}
+			recordedAuth, recordedCapture := authorizeAndCapture(t, recorder, gateway.client(recorder.HTTPClient(nil), sleet.WithBaseURL(fake.URL)))
+			fake.Close()
+			if err := recorder.Save(); err != nil {
+				t.Fatal(err)
+			}
+
+			golden := string(NewTestHelper(t).ReadFile(path))
+			pan := BaseAuthorizationRequest().CreditCard.Number
+			for _, secret := range append(gateway.secrets, pan) {
+				if strings.Contains(golden, secret) {
+					t.Errorf("expected %q to be scrubbed from the cassette", secret)
+				}
+			}
+			if !strings.Contains(golden, `"Signature"`) && !strings.Contains(golden, `"Message-Signature"`) {
+				t.Error("expected the request signature header to be recorded")
+			}
+
+			// the fake is closed, so the exchanges can only come from the cassette
+			player := NewTestHelper(t).Cassette(path, nil)
+			auth, capture := authorizeAndCapture(t, player, gateway.client(player.HTTPClient(nil), sleet.WithBaseURL("http://127.0.0.1:1")))
+			if auth.TransactionReference != recordedAuth.TransactionReference {
+				t.Errorf("expected the recorded reference %q, got %q", recordedAuth.TransactionReference, auth.TransactionReference)
+			}
+			if capture.TransactionReference != recordedCapture.TransactionReference {
+				t.Errorf("expected the recorded capture reference %q, got %q", recordedCapture.TransactionReference, capture.TransactionReference)
+			}
+
+			if _, err := player.HTTPClient(nil).Get("http://127.0.0.1:1/unrecorded"); err == nil {
+				t.Error("expected a request matching no interaction to fail")
+			}
+		})
+	}
+}
+
+// authorizeAndCapture authorizes a new BaseAuthorizationRequest, whose reference is random, and captures it
+func authorizeAndCapture(t *testing.T, cassette *Cassette, client sleet.Client) (*sleet.AuthorizationResponse, *sleet.CaptureResponse) {
+	t.Helper()
+
+	request := BaseAuthorizationRequest()
+	request.CreditCard.ExpirationYear = 2040
+	cassette.Volatile(*request.ClientTransactionReference)
+	auth, err := client.Authorize(request)
+	if err != nil || !auth.Success {
+		t.Fatalf("expected the authorization to succeed: %+v %v", auth, err)
+	}
+	capture, err := client.Capture(&sleet.CaptureRequest{
+		Amount:                     &request.Amount,
+		TransactionReference:       auth.TransactionReference,
+		ClientTransactionReference: request.ClientTransactionReference,
+	})
+	if err != nil || !capture.Success {
+		t.Fatalf("expected the capture to succeed: %+v %v", capture, err)
+	}
+	return auth, capture
+}
+
+func TestDefaultMatcher(t *testing.T) {
+	recorded := &CassetteRequest{
+		Method: http.MethodPost,
+		URL:    "https://api.example.com/payments?version=2",
+		Header: http.Header{"Content-Type": {"application/json"}},
+		Body:   `{"amount": 100, "reference": "[VOLATILE-1]", "requestTimestamp": 1700000000}`,
+	}
+	cases := []struct {
+		label   string
+		request CassetteRequest
+		want    bool
+	}{
+		{"Same Fields In Another Order", CassetteRequest{Method: http.MethodPost, URL: "http://localhost:8080/payments?version=2", Body: `{"requestTimestamp": 1800000000, "reference": "[VOLATILE-1]", "amount": 100}`}, true},
+		{"Another Amount", CassetteRequest{Method: http.MethodPost, URL: "https://api.example.com/payments?version=2", Body: `{"amount": 200, "reference": "[VOLATILE-1]", "requestTimestamp": 1700000000}`}, false},
+		{"Another Path", CassetteRequest{Method: http.MethodPost, URL: "https://api.example.com/refunds?version=2", Body: recorded.Body}, false},
+		{"Another Method", CassetteRequest{Method: http.MethodGet, URL: recorded.URL, Body: recorded.Body}, false},
+		{"Missing Field", CassetteRequest{Method: http.MethodPost, URL: recorded.URL, Body: `{"amount": 100, "reference": "[VOLATILE-1]"}`}, false},
+	}
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			if got := DefaultMatcher(recorded, &c.request); got != c.want {
+				t.Errorf("Got %t, want %t", got, c.want)
+			}
+		})
+	}
+
+	t.Run("XML And Name-Value Bodies", func(t *testing.T) {
+		xmlRecorded := &CassetteRequest{Method: http.MethodPost, URL: "/", Body: `<Request><Amount>100</Amount><TxDateTime>20240101</TxDateTime></Request>`}
+		xmlRequest := &CassetteRequest{Method: http.MethodPost, URL: "/", Body: `<Request><Amount>100</Amount><TxDateTime>20250101</TxDateTime></Request>`}
+		if !DefaultMatcher(xmlRecorded, xmlRequest) {
+			t.Error("expected XML bodies differing in a timestamp to match")
+		}
+		formRecorded := &CassetteRequest{Method: http.MethodPost, URL: "/", Body: "AMT=1.00&TRXTYPE=A"}
+		formRequest := &CassetteRequest{Method: http.MethodPost, URL: "/", Body: "TRXTYPE=S&AMT=1.00"}
+		if DefaultMatcher(formRecorded, formRequest) {
+			t.Error("expected name-value bodies differing in a field not to match")
+		}
+	})
+}
+
+// roundTripFunc is an http.RoundTripper calling the function
+type roundTripFunc func(request *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
+
+func TestCassetteResendsRequestBody(t *testing.T) {
+	var resent []byte
+	next := roundTripFunc(func(request *http.Request) (*http.Response, error) {
+		ioutil.ReadAll(request.Body)
+		body, err := request.GetBody()
+		if err != nil {
+			t.Fatal(err)
+		}
+		resent, _ = ioutil.ReadAll(body)
+		return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader(`{}`)), Request: request}, nil
+	})
+	cassette := &Cassette{Path: filepath.Join(t.TempDir(), "resend.json"), Mode: CassetteRecord, Next: next}
+
+	// the body can only be read once, so the request has no GetBody of its own
+	request, _ := http.NewRequest(http.MethodPost, "https://example.com/payments", ioutil.NopCloser(strings.NewReader(`{"amount":100}`)))
+	if _, err := cassette.HTTPClient(nil).Do(request); err != nil {
+		t.Fatal(err)
+	}
+	if string(resent) != `{"amount":100}` {
+		t.Errorf("expected GetBody to return the request body, got %s", resent)
+	}
+}
+
+func TestNewCassetteNotRecorded(t *testing.T) {
+	setenv(t, RecordCassettesEnv, "")
+	if _, err := NewCassette(filepath.Join(t.TempDir(), "missing.json"), nil); err == nil || !strings.Contains(err.Error(), RecordCassettesEnv) {
+		t.Errorf("expected an error telling how to record the cassette, got %v", err)
+	}
+
+	setenv(t, RecordCassettesEnv, "1")
+	cassette, err := NewCassette(filepath.Join(t.TempDir(), "missing.json"), nil)
+	if err != nil || cassette.Mode != CassetteRecord {
+		t.Errorf("expected a recording cassette, got %+v %v", cassette, err)
+	}
+}
+
+// setenv sets an environment variable for the duration of the test
+func setenv(t *testing.T, key, value string) {
+	previous, ok := os.LookupEnv(key)
+	if err := os.Setenv(key, value); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if ok {
+			os.Setenv(key, previous)
+		} else {
+			os.Unsetenv(key)
+		}
+	})
+}
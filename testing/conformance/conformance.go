@@ -0,0 +1,458 @@
+// Package conformance runs a standard battery of behaviours against a gateway, so every gateway is held to the same
+// contract whatever its PsP. A gateway's test gives Run a factory building its client on an http client, and a
+// transport answering like the PsP, such as the transport of a fake from testing/fakes:
+//
+//	fake := fakes.NewNMI()
+//	defer fake.Close()
+//	conformance.Run(t, conformance.Suite{
+//		Gateway:   "nmi",
+//		Transport: fake.Client().Transport,
+//		NewClient: func(httpClient *http.Client) sleet.ClientWithContext {
+//			return nmi.NewWithHttpClient(common.Sandbox, fakes.NMISecurityKey, httpClient)
+//		},
+//		Decline: func(request *sleet.AuthorizationRequest) { request.Amount.Amount = 50 },
+//	})
+//
+// Each behaviour runs as a subtest, and Run logs a report of the behaviours the gateway passes. Behaviours the PsP or
+// its SDK can't support are skipped with the reason given in Suite.Skip. Gaps the gateway could close are listed in
+// Suite.Failing instead: they still run and are reported as failed without failing the test, until the gateway passes
+// them and the test fails so the entry is removed.
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BoltApp/sleet"
+	sleet_testing "github.com/BoltApp/sleet/testing"
+)
+
+// Behavior is a behaviour every gateway is expected to have
+type Behavior string
+
+const (
+	BehaviorApprove           Behavior = "Approve"
+	BehaviorDecline           Behavior = "Decline"
+	BehaviorAVSCVV            Behavior = "AVS CVV Mapping"
+	BehaviorCapture           Behavior = "Capture"
+	BehaviorPartialCapture    Behavior = "Partial Capture"
+	BehaviorVoid              Behavior = "Void"
+	BehaviorRefund            Behavior = "Refund"
+	BehaviorResponseHeader    Behavior = "Response Header"
+	BehaviorContextCancel     Behavior = "Context Cancellation"
+	BehaviorMalformedResponse Behavior = "Malformed Response"
+)
+
+// Behaviors lists every behaviour in the order Run checks them
+var Behaviors = []Behavior{
+	BehaviorApprove,
+	BehaviorDecline,
+	BehaviorAVSCVV,
+	BehaviorCapture,
+	BehaviorPartialCapture,
+	BehaviorVoid,
+	BehaviorRefund,
+	BehaviorResponseHeader,
+	BehaviorContextCancel,
+	BehaviorMalformedResponse,
+}
+
+// responseHeader is added to every response by the transport of the response header behaviour
+const responseHeader = "X-Conformance-Header"
+
+// Suite describes the gateway under test and how its PsP is made to answer each behaviour
+type Suite struct {
+	Gateway   string                                                // the name of the gateway in the report
+	NewClient func(httpClient *http.Client) sleet.ClientWithContext // builds a client sending its requests through httpClient
+	Transport http.RoundTripper                                     // answers requests like the PsP, such as a fake's transport
+
+	// Request returns an authorization the PsP approves, BaseAuthorizationRequest with a card which hasn't expired if nil
+	Request func() *sleet.AuthorizationRequest
+	// Decline changes an approved request into one the PsP declines, the decline behaviour is skipped if nil
+	Decline func(request *sleet.AuthorizationRequest)
+	// AVS and CVV are the results the gateway should translate the PsP's checks of the approved request to
+	AVS sleet.AVSResponse
+	CVV sleet.CVVResponse
+
+	// Settle settles a captured transaction before it is refunded, for PsPs only refunding settled transactions
+	Settle func(transactionReference string) error
+
+	// Skip gives the reason a behaviour is not supported by the gateway, such as an SDK ignoring the context
+	Skip map[Behavior]string
+	// Failing gives the reason the gateway is known to fail a behaviour it could support
+	Failing map[Behavior]string
+}
+
+// Outcome is the result of a behaviour
+type Outcome string
+
+const (
+	Passed  Outcome = "passed"
+	Failed  Outcome = "failed"
+	Skipped Outcome = "skipped"
+)
+
+// Result is the outcome of a behaviour, with the reason it failed or was skipped
+type Result struct {
+	Behavior Behavior
+	Outcome  Outcome
+	Message  string
+}
+
+// Report lists the outcome of every behaviour of a gateway
+type Report struct {
+	Gateway string
+	Results []Result
+}
+
+// Passed returns the behaviours the gateway passed
+func (report *Report) Passed() []Behavior {
+	var passed []Behavior
+	for _, result := range report.Results {
+		if result.Outcome == Passed {
+			passed = append(passed, result.Behavior)
+		}
+	}
+	return passed
+}
+
+// String formats the report as a table of behaviours and outcomes
+func (report *Report) String() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "conformance of %s:\n", report.Gateway)
+	for _, result := range report.Results {
+		fmt.Fprintf(&builder, "  %-22s %-8s %s\n", result.Behavior, result.Outcome, result.Message)
+	}
+	return builder.String()
+}
+
+// checks are the functions checking each behaviour, returning why the gateway failed it
+var checks = map[Behavior]func(suite *Suite) error{
+	BehaviorApprove:           checkApprove,
+	BehaviorDecline:           checkDecline,
+	BehaviorAVSCVV:            checkAVSCVV,
+	BehaviorCapture:           checkCapture,
+	BehaviorPartialCapture:    checkPartialCapture,
+	BehaviorVoid:              checkVoid,
+	BehaviorRefund:            checkRefund,
+	BehaviorResponseHeader:    checkResponseHeader,
+	BehaviorContextCancel:     checkContextCancel,
+	BehaviorMalformedResponse: checkMalformedResponse,
+}
+
+// Run checks every behaviour of the gateway in a subtest, logs the report and returns it
+func Run(t *testing.T, suite Suite) *Report {
+	t.Helper()
+
+	report := &Report{Gateway: suite.Gateway}
+	for _, behavior := range Behaviors {
+		result := Result{Behavior: behavior}
+		t.Run(string(behavior), func(t *testing.T) {
+			if reason, ok := suite.Skip[behavior]; ok {
+				result.Outcome, result.Message = Skipped, reason
+				t.Skip(reason)
+			}
+			if behavior == BehaviorDecline && suite.Decline == nil {
+				result.Outcome, result.Message = Skipped, "no declined request given"
+				t.Skip(result.Message)
+			}
+			err := check(&suite, behavior)
+			if reason, ok := suite.Failing[behavior]; ok {
+				if err == nil {
+					result.Outcome = Passed
+					t.Errorf("passed although known to fail (%s), remove it from Suite.Failing", reason)
+					return
+				}
+				result.Outcome, result.Message = Failed, fmt.Sprintf("known: %s: %v", reason, err)
+				t.Log(result.Message)
+				return
+			}
+			if err != nil {
+				result.Outcome, result.Message = Failed, err.Error()
+				t.Error(err)
+				return
+			}
+			result.Outcome = Passed
+		})
+		report.Results = append(report.Results, result)
+	}
+	t.Log(report)
+	return report
+}
+
+// check runs the check of a behaviour, a panic of the gateway failing it
+func check(suite *Suite, behavior Behavior) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panicked: %v", recovered)
+		}
+	}()
+	return checks[behavior](suite)
+}
+
+func (suite *Suite) client(transport http.RoundTripper) sleet.ClientWithContext {
+	return suite.NewClient(&http.Client{Transport: transport})
+}
+
+func (suite *Suite) request() *sleet.AuthorizationRequest {
+	if suite.Request != nil {
+		return suite.Request()
+	}
+	request := sleet_testing.BaseAuthorizationRequest()
+	request.CreditCard.ExpirationYear = time.Now().Year() + 3
+	return request
+}
+
+// authorize authorizes an approved request, failing if it isn't approved
+func (suite *Suite) authorize(client sleet.ClientWithContext, request *sleet.AuthorizationRequest) (*sleet.AuthorizationResponse, error) {
+	auth, err := client.AuthorizeWithContext(context.Background(), request)
+	if err != nil {
+		return nil, fmt.Errorf("authorize failed: %w", err)
+	}
+	if !auth.Success {
+		return nil, fmt.Errorf("authorization was not approved: %s %s", auth.ErrorCode, auth.Response)
+	}
+	if auth.TransactionReference == "" {
+		return nil, errors.New("authorization has no transaction reference")
+	}
+	return auth, nil
+}
+
+func checkApprove(suite *Suite) error {
+	auth, err := suite.authorize(suite.client(suite.Transport), suite.request())
+	if err != nil {
+		return err
+	}
+	if auth.ResultType != sleet.ResultTypeSuccess {
+		return fmt.Errorf("expected result type %s, got %q", sleet.ResultTypeSuccess, auth.ResultType)
+	}
+	return nil
+}
+
+func checkDecline(suite *Suite) error {
+	request := suite.request()
+	suite.Decline(request)
+	auth, err := suite.client(suite.Transport).AuthorizeWithContext(context.Background(), request)
+	if err != nil {
+		return fmt.Errorf("a decline should be a response, not an error: %w", err)
+	}
+	if auth.Success {
+		return errors.New("authorization was approved")
+	}
+	if auth.ResultType != sleet.ResultTypePaymentError {
+		return fmt.Errorf("expected result type %s, got %q", sleet.ResultTypePaymentError, auth.ResultType)
+	}
+	if auth.ErrorCode == "" {
+		return errors.New("decline has no error code")
+	}
+	return nil
+}
+
+func checkAVSCVV(suite *Suite) error {
+	auth, err := suite.authorize(suite.client(suite.Transport), suite.request())
+	if err != nil {
+		return err
+	}
+	if auth.AvsResult != suite.AVS || auth.CvvResult != suite.CVV {
+		return fmt.Errorf("expected AVS %s and CVV %s, got AVS %s (%q) and CVV %s (%q)",
+			suite.AVS, suite.CVV, auth.AvsResult, auth.AvsResultRaw, auth.CvvResult, auth.CvvResultRaw)
+	}
+	return nil
+}
+
+func capture(suite *Suite, amount func(authorized sleet.Amount) sleet.Amount) error {
+	client := suite.client(suite.Transport)
+	request := suite.request()
+	auth, err := suite.authorize(client, request)
+	if err != nil {
+		return err
+	}
+	captured := amount(request.Amount)
+	capture, err := client.CaptureWithContext(context.Background(), &sleet.CaptureRequest{
+		Amount:                     &captured,
+		TransactionReference:       auth.TransactionReference,
+		ClientTransactionReference: request.ClientTransactionReference,
+		MerchantOrderReference:     merchantOrderReference(request),
+	})
+	if err != nil {
+		return fmt.Errorf("capture failed: %w", err)
+	}
+	if !capture.Success {
+		return fmt.Errorf("capture was not successful: %s", optionalString(capture.ErrorCode))
+	}
+	return nil
+}
+
+func checkCapture(suite *Suite) error {
+	return capture(suite, func(authorized sleet.Amount) sleet.Amount { return authorized })
+}
+
+func checkPartialCapture(suite *Suite) error {
+	return capture(suite, func(authorized sleet.Amount) sleet.Amount {
+		return sleet.Amount{Amount: authorized.Amount / 2, Currency: authorized.Currency}
+	})
+}
+
+func checkVoid(suite *Suite) error {
+	client := suite.client(suite.Transport)
+	request := suite.request()
+	auth, err := suite.authorize(client, request)
+	if err != nil {
+		return err
+	}
+	void, err := client.VoidWithContext(context.Background(), &sleet.VoidRequest{
+		TransactionReference:       auth.TransactionReference,
+		ClientTransactionReference: request.ClientTransactionReference,
+		MerchantOrderReference:     merchantOrderReference(request),
+	})
+	if err != nil {
+		return fmt.Errorf("void failed: %w", err)
+	}
+	if !void.Success {
+		return fmt.Errorf("void was not successful: %s", optionalString(void.ErrorCode))
+	}
+	return nil
+}
+
+func checkRefund(suite *Suite) error {
+	client := suite.client(suite.Transport)
+	request := suite.request()
+	auth, err := suite.authorize(client, request)
+	if err != nil {
+		return err
+	}
+	capture, err := client.CaptureWithContext(context.Background(), &sleet.CaptureRequest{
+		Amount:                     &request.Amount,
+		TransactionReference:       auth.TransactionReference,
+		ClientTransactionReference: request.ClientTransactionReference,
+		MerchantOrderReference:     merchantOrderReference(request),
+	})
+	if err != nil || !capture.Success {
+		return fmt.Errorf("capture before the refund failed: %v", err)
+	}
+	// PsPs which keep the authorization's reference after a capture return none for it
+	reference := capture.TransactionReference
+	if reference == "" {
+		reference = auth.TransactionReference
+	}
+	if suite.Settle != nil {
+		if err := suite.Settle(reference); err != nil {
+			return fmt.Errorf("settle before the refund failed: %w", err)
+		}
+	}
+	refund, err := client.RefundWithContext(context.Background(), &sleet.RefundRequest{
+		Amount:                     &request.Amount,
+		TransactionReference:       reference,
+		ClientTransactionReference: request.ClientTransactionReference,
+		MerchantOrderReference:     merchantOrderReference(request),
+		Last4:                      last4(request),
+	})
+	if err != nil {
+		return fmt.Errorf("refund failed: %w", err)
+	}
+	if !refund.Success {
+		return fmt.Errorf("refund was not successful: %s", optionalString(refund.ErrorCode))
+	}
+	return nil
+}
+
+func checkResponseHeader(suite *Suite) error {
+	const value = "conformance"
+	client := suite.client(roundTripFunc(func(request *http.Request) (*http.Response, error) {
+		response, err := suite.Transport.RoundTrip(request)
+		if err == nil {
+			response.Header.Set(responseHeader, value)
+		}
+		return response, err
+	}))
+	request := suite.request()
+	request.Options = map[string]interface{}{sleet.ResponseHeaderOption: []string{responseHeader}}
+	auth, err := suite.authorize(client, request)
+	if err != nil {
+		return err
+	}
+	if got := auth.Header.Get(responseHeader); got != value {
+		return fmt.Errorf("expected the %s response header requested with sleet.ResponseHeaderOption, got %q", responseHeader, got)
+	}
+	return nil
+}
+
+func checkContextCancel(suite *Suite) error {
+	sent := false
+	client := suite.client(roundTripFunc(func(request *http.Request) (*http.Response, error) {
+		if request.Context().Err() == nil {
+			sent = true
+		}
+		return suite.Transport.RoundTrip(request)
+	}))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := client.AuthorizeWithContext(ctx, suite.request())
+	if sent {
+		return errors.New("the request was sent without the canceled context")
+	}
+	if !errors.Is(err, context.Canceled) {
+		return fmt.Errorf("expected an error wrapping context.Canceled, got %v", err)
+	}
+	return nil
+}
+
+// checkMalformedResponse truncates the PsP's response to its first half, as a dropped connection would
+func checkMalformedResponse(suite *Suite) error {
+	client := suite.client(roundTripFunc(func(request *http.Request) (*http.Response, error) {
+		response, err := suite.Transport.RoundTrip(request)
+		if err != nil {
+			return nil, err
+		}
+		body, err := ioutil.ReadAll(response.Body)
+		response.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = body[:len(body)/2]
+		response.Body = ioutil.NopCloser(bytes.NewReader(body))
+		response.ContentLength = int64(len(body))
+		response.Header.Del("Content-Length")
+		return response, nil
+	}))
+	auth, err := client.AuthorizeWithContext(context.Background(), suite.request())
+	if err == nil {
+		return fmt.Errorf("expected an error for a malformed response, got a response approved %t", auth.Success)
+	}
+	return nil
+}
+
+// roundTripFunc adapts a function to an http.RoundTripper
+type roundTripFunc func(request *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
+
+func optionalString(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func merchantOrderReference(request *sleet.AuthorizationRequest) *string {
+	if request.MerchantOrderReference == "" {
+		return nil
+	}
+	return &request.MerchantOrderReference
+}
+
+func last4(request *sleet.AuthorizationRequest) string {
+	if request.CreditCard == nil || len(request.CreditCard.Number) < 4 {
+		return ""
+	}
+	return request.CreditCard.Number[len(request.CreditCard.Number)-4:]
+}
@@ -0,0 +1,56 @@
+package conformance
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/gateways/simulator"
+)
+
+func TestRun(t *testing.T) {
+	report := Run(t, Suite{
+		Gateway: "simulator",
+		NewClient: func(httpClient *http.Client) sleet.ClientWithContext {
+			return simulator.NewClient()
+		},
+		// approved, so the decline check fails and is reported as a known failure
+		Decline: func(request *sleet.AuthorizationRequest) {},
+		AVS:     sleet.AVSResponseMatch,
+		CVV:     sleet.CVVResponseMatch,
+		Skip: map[Behavior]string{
+			BehaviorResponseHeader:    "the simulator sends no HTTP requests",
+			BehaviorMalformedResponse: "the simulator sends no HTTP requests",
+		},
+		Failing: map[Behavior]string{
+			BehaviorDecline: "the request is not changed to be declined",
+		},
+	})
+
+	outcomes := map[Behavior]Outcome{}
+	for _, result := range report.Results {
+		outcomes[result.Behavior] = result.Outcome
+	}
+	if len(outcomes) != len(Behaviors) {
+		t.Fatalf("expected a result for each of the %d behaviours, got %v", len(Behaviors), outcomes)
+	}
+	for _, behavior := range Behaviors {
+		want := Passed
+		switch behavior {
+		case BehaviorResponseHeader, BehaviorMalformedResponse:
+			want = Skipped
+		case BehaviorDecline:
+			want = Failed
+		}
+		if outcomes[behavior] != want {
+			t.Errorf("expected %s to be %s, got %s", behavior, want, outcomes[behavior])
+		}
+	}
+	if passed := report.Passed(); len(passed) != len(Behaviors)-3 {
+		t.Errorf("expected %d behaviours passed, got %v", len(Behaviors)-3, passed)
+	}
+	if !strings.Contains(report.String(), "known: the request is not changed to be declined") {
+		t.Errorf("expected the report to give the reason of the known failure, got\n%s", report)
+	}
+}
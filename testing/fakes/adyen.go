@@ -0,0 +1,261 @@
+package fakes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Credentials accepted by the Adyen fake
+const (
+	AdyenMerchantAccount = "SleetFakeMerchant"
+	AdyenAPIKey          = "fake_adyen_api_key"
+)
+
+// adyenRefusals are the refusal reasons the Adyen test platform returns when given as the card holder's name, by
+// refusal reason code
+var adyenRefusals = map[string]string{
+	"Refused":            "2",
+	"Blocked Card":       "5",
+	"Not enough balance": "12",
+	"CVC Declined":       "24",
+}
+
+// adyenAVSCard has a billing address on file, the other cards have none and match no address. Adyen's test platform
+// checks the house number and postal code.
+const (
+	adyenAVSCard        = "5500000000000004"
+	adyenAVSHouseNumber = "1600"
+	adyenAVSPostalCode  = "20500"
+)
+
+type adyenAmount struct {
+	Value    int64  `json:"value"`
+	Currency string `json:"currency"`
+}
+
+type adyenAddress struct {
+	HouseNumberOrName string `json:"houseNumberOrName"`
+	PostalCode        string `json:"postalCode"`
+}
+
+type adyenPaymentRequest struct {
+	Amount          *adyenAmount      `json:"amount"`
+	MerchantAccount string            `json:"merchantAccount"`
+	Reference       string            `json:"reference"`
+	PaymentMethod   map[string]string `json:"paymentMethod"`
+	BillingAddress  *adyenAddress     `json:"billingAddress"`
+}
+
+type adyenModificationRequest struct {
+	MerchantAccount           string       `json:"merchantAccount"`
+	OriginalReference         string       `json:"originalReference"`
+	OriginalMerchantReference string       `json:"originalMerchantReference"`
+	ModificationAmount        *adyenAmount `json:"modificationAmount"`
+}
+
+// NewAdyen starts a fake of Adyen's Checkout API for payments and its classic Payment API for modifications
+func NewAdyen() *Server {
+	return newServer(func(server *Server) http.Handler {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/checkout/v65/payments", server.adyenPayment)
+		for _, modification := range []string{"capture", "cancel", "refund", "adjustAuthorisation", "technicalCancel"} {
+			mux.HandleFunc("/pal/servlet/Payment/v64/"+modification, server.adyenModification(modification))
+		}
+		return adyenAuthenticated(mux)
+	})
+}
+
+// adyenAuthenticated checks the API key of every request
+func adyenAuthenticated(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-Key") != AdyenAPIKey {
+			adyenError(w, http.StatusUnauthorized, "000", "HTTP Status Response - Unauthorized", "security")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (server *Server) adyenPayment(w http.ResponseWriter, r *http.Request) {
+	var request adyenPaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		adyenError(w, http.StatusBadRequest, "702", "Structure of the request is invalid", "validation")
+		return
+	}
+	switch {
+	case request.MerchantAccount != AdyenMerchantAccount:
+		adyenError(w, http.StatusForbidden, "901", "Invalid Merchant Account", "security")
+		return
+	case request.Reference == "":
+		adyenError(w, http.StatusUnprocessableEntity, "130", "Required field 'reference' is not provided.", "validation")
+		return
+	case request.Amount == nil || request.Amount.Currency == "":
+		adyenError(w, http.StatusUnprocessableEntity, "100", "Required field 'amount' is not provided.", "validation")
+		return
+	case request.PaymentMethod["type"] == "scheme" && request.PaymentMethod["number"] == "":
+		adyenError(w, http.StatusUnprocessableEntity, "101", "Invalid card number", "validation")
+		return
+	}
+
+	month, _ := strconv.Atoi(request.PaymentMethod["expiryMonth"])
+	year, _ := strconv.Atoi(request.PaymentMethod["expiryYear"])
+	if request.PaymentMethod["type"] == "scheme" && (month < 1 || month > 12 || year == 0) {
+		adyenError(w, http.StatusUnprocessableEntity, "129", "Expiry Date Invalid", "validation")
+		return
+	}
+
+	p := &payment{
+		reference:         server.nextReference("88%014d"),
+		merchantReference: request.Reference,
+		state:             stateAuthorized,
+		currency:          request.Amount.Currency,
+		authorized:        request.Amount.Value,
+		last4:             last4(request.PaymentMethod["number"]),
+	}
+	response := map[string]interface{}{
+		"pspReference":      p.reference,
+		"resultCode":        "Authorised",
+		"merchantReference": request.Reference,
+		"additionalData":    adyenChecks(request),
+	}
+	refusalReason, refused := "", false
+	if code, ok := adyenRefusals[request.PaymentMethod["holderName"]]; ok {
+		refusalReason, p.code, refused = request.PaymentMethod["holderName"], code, true
+	} else if request.PaymentMethod["type"] == "scheme" && expired(month, year) {
+		refusalReason, p.code, refused = "Expired Card", "6", true
+	}
+	if refused {
+		p.state, p.authorized = stateDeclined, 0
+		response["resultCode"] = "Refused"
+		response["refusalReason"] = refusalReason
+		response["refusalReasonCode"] = p.code
+	}
+	server.ledger.add(p)
+	writeJSON(w, http.StatusOK, response)
+}
+
+// adyenChecks returns the AVS and CVC results as additional data
+func adyenChecks(request adyenPaymentRequest) map[string]string {
+	avs := "5 No AVS data provided"
+	if address := request.BillingAddress; address != nil {
+		houseMatches := request.PaymentMethod["number"] == adyenAVSCard && address.HouseNumberOrName == adyenAVSHouseNumber
+		postalMatches := request.PaymentMethod["number"] == adyenAVSCard && address.PostalCode == adyenAVSPostalCode
+		switch {
+		case houseMatches && postalMatches:
+			avs = "7 Both postal code and address match"
+		case houseMatches:
+			avs = "1 Address matches, postal code doesn't"
+		case postalMatches:
+			avs = "6 Postal code matches, but the address does not match"
+		default:
+			avs = "2 Neither postal code nor address match"
+		}
+	}
+	cvc, cvcRaw := "6 No CVC/CVV provided", ""
+	if request.PaymentMethod["cvc"] != "" {
+		cvc, cvcRaw = "1 Matches", "M"
+	}
+	return map[string]string{
+		"avsResult":    avs,
+		"avsResultRaw": strings.SplitN(avs, " ", 2)[0],
+		"cvcResult":    cvc,
+		"cvcResultRaw": cvcRaw,
+	}
+}
+
+// adyenModification answers a modification, which Adyen acknowledges with a response naming it
+func (server *Server) adyenModification(modification string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request adyenModificationRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			adyenError(w, http.StatusBadRequest, "702", "Structure of the request is invalid", "validation")
+			return
+		}
+		if request.MerchantAccount != AdyenMerchantAccount {
+			adyenError(w, http.StatusForbidden, "901", "Invalid Merchant Account", "security")
+			return
+		}
+
+		reference := request.OriginalReference
+		if modification == "technicalCancel" {
+			payments := server.ledger.byMerchantReference(request.OriginalMerchantReference)
+			if len(payments) == 0 {
+				adyenError(w, http.StatusUnprocessableEntity, "167", "Original pspReference required for this operation", "validation")
+				return
+			}
+			reference = payments[0].reference
+		}
+		amount, currency := int64(-1), ""
+		if request.ModificationAmount != nil {
+			amount, currency = request.ModificationAmount.Value, request.ModificationAmount.Currency
+		} else if modification != "cancel" && modification != "technicalCancel" {
+			adyenError(w, http.StatusUnprocessableEntity, "137", "Invalid amount specified", "validation")
+			return
+		}
+
+		var err error
+		switch modification {
+		case "capture":
+			_, err = server.ledger.capture(reference, amount, currency)
+		case "cancel", "technicalCancel":
+			_, err = server.ledger.void(reference)
+		case "refund":
+			_, err = server.ledger.refund(reference, amount, currency)
+		case "adjustAuthorisation":
+			// Adyen takes the new total rather than the increment
+			_, err = server.ledger.apply(reference, currency, func(p *payment) error {
+				if p.state != stateAuthorized {
+					return errInvalidState
+				}
+				p.authorized = amount
+				return nil
+			})
+		}
+		switch err {
+		case nil:
+		case errUnknownPayment:
+			adyenError(w, http.StatusUnprocessableEntity, "167", "Original pspReference required for this operation", "validation")
+			return
+		case errCurrencyMismatch:
+			adyenError(w, http.StatusUnprocessableEntity, "138", "Invalid currency specified", "validation")
+			return
+		default:
+			adyenError(w, http.StatusUnprocessableEntity, "137", "Invalid amount specified", "validation")
+			return
+		}
+
+		pspReference := server.nextReference("88%014d")
+		server.ledger.alias(pspReference, reference)
+		writeJSON(w, http.StatusOK, map[string]string{
+			"pspReference": pspReference,
+			"response":     "[" + adyenAcknowledgement(modification) + "-received]",
+		})
+	}
+}
+
+func adyenAcknowledgement(modification string) string {
+	switch modification {
+	case "adjustAuthorisation":
+		return "adjustAuthorisation"
+	case "technicalCancel":
+		return "technical-cancel"
+	}
+	return modification
+}
+
+func adyenError(w http.ResponseWriter, statusCode int, code string, message string, errorType string) {
+	writeJSON(w, statusCode, map[string]interface{}{
+		"status":    statusCode,
+		"errorCode": code,
+		"message":   message,
+		"errorType": errorType,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(body)
+}
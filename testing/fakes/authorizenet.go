@@ -0,0 +1,462 @@
+package fakes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Credentials accepted by the Authorize.Net fake
+const (
+	AuthorizeNetLoginID        = "fake_login_id"
+	AuthorizeNetTransactionKey = "fake_transaction_key"
+)
+
+// Values which trigger an outcome in the Authorize.Net sandbox
+const (
+	authorizeNetDeclineZip    = "46282" // billing ZIP code declining the transaction
+	authorizeNetAVSNoMatchZip = "46204" // billing ZIP code matching neither the address nor the ZIP code
+	authorizeNetCVVNoMatch    = "901"   // card code which does not match
+)
+
+// authorizeNetTransactionFields is the order of the transactionRequest elements in the Authorize.Net schema. The API
+// translates JSON to XML, so elements out of this order are rejected.
+var authorizeNetTransactionFields = []string{
+	"transactionType", "amount", "currencyCode", "payment", "profile", "solution", "callId", "terminalNumber",
+	"authCode", "refTransId", "splitTenderId", "order", "lineItems", "tax", "duty", "shipping", "taxExempt",
+	"poNumber", "customer", "billTo", "shipTo", "customerIP", "cardholderAuthentication", "retail", "employeeId",
+	"transactionSettings", "userFields", "surcharge", "merchantDescriptor", "subMerchant", "tip", "processingOptions",
+	"subsequentAuthInformation", "otherTax", "shipFrom", "authorizationIndicatorType",
+}
+
+type authorizeNetAuthentication struct {
+	Name           string `json:"name"`
+	TransactionKey string `json:"transactionKey"`
+}
+
+type authorizeNetTransactionRequest struct {
+	TransactionType string  `json:"transactionType"`
+	Amount          *string `json:"amount"`
+	Payment         *struct {
+		CreditCard *struct {
+			CardNumber     string `json:"cardNumber"`
+			ExpirationDate string `json:"expirationDate"`
+			CardCode       string `json:"cardCode"`
+		} `json:"creditCard"`
+		OpaqueData *json.RawMessage `json:"opaqueData"`
+	} `json:"payment"`
+	RefTransID *string `json:"refTransId"`
+	Order      *struct {
+		InvoiceNumber string `json:"invoiceNumber"`
+	} `json:"order"`
+	BillTo *struct {
+		Zip *string `json:"zip"`
+	} `json:"billTo"`
+}
+
+type authorizeNetRequest struct {
+	CreateTransactionRequest *struct {
+		MerchantAuthentication authorizeNetAuthentication `json:"merchantAuthentication"`
+		TransactionRequest     json.RawMessage            `json:"transactionRequest"`
+	} `json:"createTransactionRequest"`
+	GetTransactionDetailsRequest *struct {
+		MerchantAuthentication authorizeNetAuthentication `json:"merchantAuthentication"`
+		TransID                string                     `json:"transId"`
+	} `json:"getTransactionDetailsRequest"`
+	GetUnsettledTransactionListRequest *struct {
+		MerchantAuthentication authorizeNetAuthentication `json:"merchantAuthentication"`
+	} `json:"getUnsettledTransactionListRequest"`
+}
+
+// NewAuthorizeNet starts a fake of the Authorize.Net JSON API. Captured transactions can be refunded before they
+// settle, and customer profiles are not supported.
+func NewAuthorizeNet() *Server {
+	return newServer(func(server *Server) http.Handler {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/xml/v1/request.api", server.authorizeNetRequest)
+		return mux
+	})
+}
+
+func (server *Server) authorizeNetRequest(w http.ResponseWriter, r *http.Request) {
+	var request authorizeNetRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		authorizeNetError(w, "E00003", "The request is not valid JSON.")
+		return
+	}
+
+	var authentication authorizeNetAuthentication
+	switch {
+	case request.CreateTransactionRequest != nil:
+		authentication = request.CreateTransactionRequest.MerchantAuthentication
+	case request.GetTransactionDetailsRequest != nil:
+		authentication = request.GetTransactionDetailsRequest.MerchantAuthentication
+	case request.GetUnsettledTransactionListRequest != nil:
+		authentication = request.GetUnsettledTransactionListRequest.MerchantAuthentication
+	default:
+		authorizeNetError(w, "E00044", "Customer Information Manager is not enabled.")
+		return
+	}
+	if authentication.Name != AuthorizeNetLoginID || authentication.TransactionKey != AuthorizeNetTransactionKey {
+		authorizeNetError(w, "E00007", "User authentication failed due to invalid authentication values.")
+		return
+	}
+
+	switch {
+	case request.CreateTransactionRequest != nil:
+		server.authorizeNetTransaction(w, request.CreateTransactionRequest.TransactionRequest)
+	case request.GetTransactionDetailsRequest != nil:
+		server.authorizeNetTransactionDetails(w, request.GetTransactionDetailsRequest.TransID)
+	default:
+		server.authorizeNetUnsettledTransactions(w)
+	}
+}
+
+func (server *Server) authorizeNetTransaction(w http.ResponseWriter, raw json.RawMessage) {
+	if element, ok := outOfOrder(raw, authorizeNetTransactionFields); !ok {
+		authorizeNetError(w, "E00003", fmt.Sprintf("The element 'transactionRequest' in namespace 'AnetApi/xml/v1/schema/AnetApiSchema.xsd' has invalid child element '%s'.", element))
+		return
+	}
+	var request authorizeNetTransactionRequest
+	if err := json.Unmarshal(raw, &request); err != nil {
+		authorizeNetError(w, "E00003", "The transactionRequest element is invalid.")
+		return
+	}
+
+	amount, amountErr := int64(-1), error(nil)
+	if request.Amount != nil {
+		amount, amountErr = decimalToMinorUnits(*request.Amount)
+	}
+	switch request.TransactionType {
+	case "authOnlyTransaction", "authCaptureTransaction":
+		if request.Amount == nil || amountErr != nil {
+			authorizeNetTransactionError(w, "3", "5", "A valid amount is required.")
+			return
+		}
+		server.authorizeNetAuthorize(w, request, amount)
+	case "priorAuthCaptureTransaction":
+		if amountErr != nil {
+			authorizeNetTransactionError(w, "3", "5", "A valid amount is required.")
+			return
+		}
+		p, err := server.ledger.capture(optional(request.RefTransID), amount, "")
+		switch err {
+		case nil:
+			authorizeNetApproved(w, p.reference, p.last4, "This transaction has been approved.")
+		case errUnknownPayment:
+			authorizeNetTransactionError(w, "3", "16", "The transaction cannot be found.")
+		case errAmountExceeded:
+			authorizeNetTransactionError(w, "3", "47", "The amount requested for settlement cannot be greater than the original amount authorized.")
+		default:
+			if p.state == stateCaptured || p.state == stateRefunded {
+				authorizeNetApprovedWithMessage(w, p.reference, p.last4, "311", "This transaction has already been captured.")
+				return
+			}
+			authorizeNetTransactionError(w, "3", "16", "The transaction cannot be found.")
+		}
+	case "voidTransaction":
+		// Auth.net voids captured transactions until they settle
+		p, err := server.ledger.apply(optional(request.RefTransID), "", func(p *payment) error {
+			if p.state != stateAuthorized && p.state != stateCaptured {
+				return errInvalidState
+			}
+			p.state = stateVoided
+			return nil
+		})
+		switch {
+		case err == nil:
+			authorizeNetApproved(w, p.reference, p.last4, "This transaction has been approved.")
+		case err == errInvalidState && p.state == stateVoided:
+			authorizeNetApprovedWithMessage(w, p.reference, p.last4, "310", "This transaction has already been voided.")
+		default:
+			authorizeNetTransactionError(w, "3", "16", "The transaction cannot be found.")
+		}
+	case "refundTransaction":
+		if request.Amount == nil || amountErr != nil {
+			authorizeNetTransactionError(w, "3", "5", "A valid amount is required.")
+			return
+		}
+		server.authorizeNetRefund(w, request, amount)
+	default:
+		authorizeNetError(w, "E00003", fmt.Sprintf("The transactionType '%s' is invalid.", request.TransactionType))
+	}
+}
+
+func (server *Server) authorizeNetAuthorize(w http.ResponseWriter, request authorizeNetTransactionRequest, amount int64) {
+	if request.Payment == nil || (request.Payment.CreditCard == nil && request.Payment.OpaqueData == nil) {
+		authorizeNetTransactionError(w, "3", "33", "Credit card number is required.")
+		return
+	}
+	number, cardCode := "", ""
+	if card := request.Payment.CreditCard; card != nil {
+		if card.CardNumber == "" || card.ExpirationDate == "" {
+			authorizeNetTransactionError(w, "3", "33", "Credit card number and expiration date are required.")
+			return
+		}
+		number, cardCode = card.CardNumber, card.CardCode
+	}
+
+	p := &payment{
+		reference: server.nextReference("6%010d"),
+		state:     stateAuthorized,
+		currency:  "USD",
+		last4:     last4(number),
+	}
+	if request.Order != nil {
+		p.merchantReference = request.Order.InvoiceNumber
+	}
+	avs, cvv := "B", ""
+	zip := ""
+	if request.BillTo != nil && request.BillTo.Zip != nil {
+		zip, avs = *request.BillTo.Zip, "Y"
+	}
+	if zip == authorizeNetAVSNoMatchZip {
+		avs = "N"
+	}
+	if cardCode != "" {
+		cvv = "M"
+		if cardCode == authorizeNetCVVNoMatch {
+			cvv = "N"
+		}
+	}
+
+	if zip == authorizeNetDeclineZip {
+		p.state, p.code = stateDeclined, "2"
+		server.ledger.add(p)
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"transactionResponse": authorizeNetTransactionResponse("2", p.reference, p.last4, avs, cvv, nil,
+				[]map[string]string{{"errorCode": "2", "errorText": "This transaction has been declined."}}),
+			"messages": authorizeNetMessages("Error", "E00027", "The transaction was unsuccessful."),
+		})
+		return
+	}
+	p.authorized = amount
+	if request.TransactionType == "authCaptureTransaction" {
+		p.state, p.captured = stateCaptured, amount
+	}
+	server.ledger.add(p)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"transactionResponse": authorizeNetTransactionResponse("1", p.reference, p.last4, avs, cvv,
+			[]map[string]string{{"code": "1", "description": "This transaction has been approved."}}, nil),
+		"messages": authorizeNetMessages("Ok", "I00001", "Successful."),
+	})
+}
+
+// authorizeNetRefund refunds a transaction by reference, or credits the card number without one
+func (server *Server) authorizeNetRefund(w http.ResponseWriter, request authorizeNetTransactionRequest, amount int64) {
+	if request.Payment == nil || request.Payment.CreditCard == nil || request.Payment.CreditCard.CardNumber == "" {
+		authorizeNetTransactionError(w, "3", "33", "Credit card number is required.")
+		return
+	}
+	card := request.Payment.CreditCard
+	reference := optional(request.RefTransID)
+	if reference == "" {
+		if len(card.CardNumber) < 13 || card.ExpirationDate == "XXXX" {
+			authorizeNetTransactionError(w, "3", "33", "Credit card number and expiration date are required.")
+			return
+		}
+	} else {
+		p, ok := server.ledger.get(reference)
+		if !ok {
+			authorizeNetTransactionError(w, "3", "16", "The transaction cannot be found.")
+			return
+		}
+		if p.last4 != last4(card.CardNumber) {
+			authorizeNetTransactionError(w, "3", "54", "The referenced transaction does not meet the criteria for issuing a credit.")
+			return
+		}
+		if _, err := server.ledger.refund(reference, amount, ""); err != nil {
+			authorizeNetTransactionError(w, "3", "55", "The sum of credits against the referenced transaction would exceed original debit amount.")
+			return
+		}
+	}
+
+	credit := &payment{
+		reference: server.nextReference("6%010d"),
+		state:     stateRefunded,
+		currency:  "USD",
+		refunded:  amount,
+		credit:    true,
+		last4:     last4(card.CardNumber),
+	}
+	if request.Order != nil {
+		credit.merchantReference = request.Order.InvoiceNumber
+	}
+	server.ledger.add(credit)
+	authorizeNetApproved(w, credit.reference, credit.last4, "This transaction has been approved.")
+}
+
+func (server *Server) authorizeNetTransactionDetails(w http.ResponseWriter, reference string) {
+	p, ok := server.ledger.get(reference)
+	if !ok {
+		authorizeNetError(w, "E00040", "The record cannot be found.")
+		return
+	}
+	transaction := map[string]interface{}{
+		"transId":           p.reference,
+		"submitTimeUTC":     p.createdAt.Format("2006-01-02T15:04:05.000Z"),
+		"transactionType":   "authOnlyTransaction",
+		"transactionStatus": authorizeNetStatus(p),
+		"authAmount":        minorUnitsToFloat(p.authorized),
+		"settleAmount":      minorUnitsToFloat(p.captured),
+		"payment": map[string]interface{}{
+			"creditCard": map[string]string{"cardNumber": "XXXX" + p.last4, "expirationDate": "XXXX", "cardType": "Visa"},
+		},
+	}
+	if p.credit {
+		transaction["transactionType"] = "refundTransaction"
+		transaction["settleAmount"] = minorUnitsToFloat(p.refunded)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"transaction": transaction,
+		"messages":    authorizeNetMessages("Ok", "I00001", "Successful."),
+	})
+}
+
+func (server *Server) authorizeNetUnsettledTransactions(w http.ResponseWriter) {
+	server.ledger.mu.Lock()
+	transactions := make([]map[string]interface{}, 0, len(server.ledger.order))
+	for i := len(server.ledger.order) - 1; i >= 0; i-- {
+		p := server.ledger.order[i]
+		transactions = append(transactions, map[string]interface{}{
+			"transId":           p.reference,
+			"submitTimeUTC":     p.createdAt.Format(time.RFC3339),
+			"transactionStatus": authorizeNetStatus(*p),
+			"invoiceNumber":     p.merchantReference,
+			"accountType":       "Visa",
+			"accountNumber":     "XXXX" + p.last4,
+			"settleAmount":      minorUnitsToFloat(p.captured),
+		})
+	}
+	server.ledger.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"transactions": transactions,
+		"messages":     authorizeNetMessages("Ok", "I00001", "Successful."),
+	})
+}
+
+func authorizeNetStatus(p payment) string {
+	switch p.state {
+	case stateAuthorized:
+		return "authorizedPendingCapture"
+	case stateCaptured:
+		return "capturedPendingSettlement"
+	case stateVoided:
+		return "voided"
+	case stateDeclined:
+		return "declined"
+	}
+	if p.credit {
+		return "refundPendingSettlement"
+	}
+	return "capturedPendingSettlement"
+}
+
+func authorizeNetTransactionResponse(responseCode string, reference string, last4 string, avs string, cvv string, messages []map[string]string, errors []map[string]string) map[string]interface{} {
+	response := map[string]interface{}{
+		"responseCode":   responseCode,
+		"authCode":       "",
+		"avsResultCode":  avs,
+		"cvvResultCode":  cvv,
+		"cavvResultCode": "",
+		"transId":        reference,
+		"refTransID":     "",
+		"transHash":      "",
+		"accountNumber":  "XXXX" + last4,
+		"accountType":    "Visa",
+	}
+	if responseCode == "1" {
+		response["authCode"] = fmt.Sprintf("%06s", reference[len(reference)-6:])
+	}
+	if messages != nil {
+		response["messages"] = messages
+	}
+	if errors != nil {
+		response["errors"] = errors
+	}
+	return response
+}
+
+func authorizeNetApproved(w http.ResponseWriter, reference string, last4 string, description string) {
+	authorizeNetApprovedWithMessage(w, reference, last4, "1", description)
+}
+
+func authorizeNetApprovedWithMessage(w http.ResponseWriter, reference string, last4 string, code string, description string) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"transactionResponse": authorizeNetTransactionResponse("1", reference, last4, "P", "", []map[string]string{{"code": code, "description": description}}, nil),
+		"messages":            authorizeNetMessages("Ok", "I00001", "Successful."),
+	})
+}
+
+func authorizeNetTransactionError(w http.ResponseWriter, responseCode string, errorCode string, errorText string) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"transactionResponse": authorizeNetTransactionResponse(responseCode, "0", "", "P", "", nil, []map[string]string{{"errorCode": errorCode, "errorText": errorText}}),
+		"messages":            authorizeNetMessages("Error", "E00027", "The transaction was unsuccessful."),
+	})
+}
+
+func authorizeNetError(w http.ResponseWriter, code string, text string) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"messages": authorizeNetMessages("Error", code, text)})
+}
+
+func authorizeNetMessages(resultCode string, code string, text string) map[string]interface{} {
+	return map[string]interface{}{
+		"resultCode": resultCode,
+		"message":    []map[string]string{{"code": code, "text": text}},
+	}
+}
+
+// outOfOrder checks that the object's keys follow the order, returning the first key out of it
+func outOfOrder(raw json.RawMessage, order []string) (string, bool) {
+	position := make(map[string]int, len(order))
+	for i, key := range order {
+		position[key] = i
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	if _, err := decoder.Token(); err != nil {
+		return "", false
+	}
+	last := -1
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", false
+		}
+		key, _ := token.(string)
+		index, known := position[key]
+		if !known || index < last {
+			return key, false
+		}
+		last = index
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return key, false
+		}
+	}
+	return "", true
+}
+
+// decimalToMinorUnits parses an amount such as "10.50" into cents
+func decimalToMinorUnits(amount string) (int64, error) {
+	value, err := strconv.ParseFloat(amount, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid amount %q", amount)
+	}
+	return int64(math.Round(value * 100)), nil
+}
+
+func minorUnitsToFloat(amount int64) float64 {
+	return float64(amount) / 100
+}
+
+// optional dereferences an optional string field of a request
+func optional(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
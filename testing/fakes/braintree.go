@@ -0,0 +1,417 @@
+package fakes
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Credentials accepted by the Braintree fake
+const (
+	BraintreeMerchantID = "fake_merchant_id"
+	BraintreePublicKey  = "fake_public_key"
+	BraintreePrivateKey = "fake_private_key"
+)
+
+// Amounts which trigger an outcome in the Braintree sandbox, see
+// https://developer.paypal.com/braintree/docs/reference/general/testing#test-amounts
+const (
+	braintreeDeclineFrom   = 200000 // amounts from $2000.00 to $2999.99 are declined with the dollar amount as the code
+	braintreeDeclineUntil  = 300000
+	braintreeFailureAmount = 300000 // $3000.00 fails with the processor network unavailable
+)
+
+// braintreeDeclines are the texts of the processor response codes the sandbox declines with, other codes are
+// "Processor Declined"
+var braintreeDeclines = map[int]string{
+	2000: "Do Not Honor",
+	2001: "Insufficient Funds",
+	2002: "Limit Exceeded",
+	2004: "Expired Card",
+	2005: "Invalid Credit Card Number",
+	2010: "Card Issuer Declined CVV",
+	2046: "Declined",
+}
+
+type braintreeTransactionRequest struct {
+	XMLName            xml.Name `xml:"transaction"`
+	Type               string   `xml:"type"`
+	Amount             string   `xml:"amount"`
+	OrderID            string   `xml:"order-id"`
+	PaymentMethodToken string   `xml:"payment-method-token"`
+	CreditCard         *struct {
+		Number         string `xml:"number"`
+		ExpirationDate string `xml:"expiration-date"`
+		CVV            string `xml:"cvv"`
+	} `xml:"credit-card"`
+	Billing *struct {
+		StreetAddress string `xml:"street-address"`
+		PostalCode    string `xml:"postal-code"`
+	} `xml:"billing"`
+	Options *struct {
+		SubmitForSettlement bool `xml:"submit-for-settlement"`
+	} `xml:"options"`
+}
+
+type braintreeTransaction struct {
+	XMLName                      xml.Name `xml:"transaction"`
+	ID                           string   `xml:"id"`
+	Status                       string   `xml:"status"`
+	Type                         string   `xml:"type"`
+	CurrencyISOCode              string   `xml:"currency-iso-code"`
+	Amount                       string   `xml:"amount"`
+	OrderID                      string   `xml:"order-id"`
+	CreatedAt                    string   `xml:"created-at"`
+	UpdatedAt                    string   `xml:"updated-at"`
+	RefundIDs                    []string `xml:"refund-ids>item"`
+	RefundedTransactionID        string   `xml:"refunded-transaction-id,omitempty"`
+	ProcessorResponseCode        string   `xml:"processor-response-code"`
+	ProcessorResponseText        string   `xml:"processor-response-text"`
+	ProcessorAuthorizationCode   string   `xml:"processor-authorization-code"`
+	AVSErrorResponseCode         string   `xml:"avs-error-response-code"`
+	AVSPostalCodeResponseCode    string   `xml:"avs-postal-code-response-code"`
+	AVSStreetAddressResponseCode string   `xml:"avs-street-address-response-code"`
+	CVVResponseCode              string   `xml:"cvv-response-code"`
+	CreditCard                   struct {
+		CardType string `xml:"card-type"`
+		Last4    string `xml:"last-4"`
+	} `xml:"credit-card"`
+}
+
+type braintreeValidationError struct {
+	Code      string `xml:"code"`
+	Attribute string `xml:"attribute"`
+	Message   string `xml:"message"`
+}
+
+type braintreeErrorResponse struct {
+	XMLName     xml.Name                   `xml:"api-error-response"`
+	Errors      []braintreeValidationError `xml:"errors>transaction>errors>error"`
+	Message     string                     `xml:"message"`
+	Transaction *braintreeTransaction      `xml:"transaction,omitempty"`
+}
+
+// NewBraintree starts a fake of Braintree's XML gateway API. Transactions are refunded once settled, which the sandbox's
+// testing settle endpoint does as it does on Braintree.
+func NewBraintree() *Server {
+	return newServer(func(server *Server) http.Handler {
+		return http.HandlerFunc(server.braintreeRequest)
+	})
+}
+
+func (server *Server) braintreeRequest(w http.ResponseWriter, r *http.Request) {
+	publicKey, privateKey, ok := r.BasicAuth()
+	if !ok || publicKey != BraintreePublicKey || privateKey != BraintreePrivateKey {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	prefix := "/merchants/" + BraintreeMerchantID + "/transactions"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, prefix), "/")
+	id, action := path, ""
+	if i := strings.Index(path, "/"); i >= 0 {
+		id, action = path[:i], path[i+1:]
+	}
+
+	var request braintreeTransactionRequest
+	if r.ContentLength != 0 {
+		if err := xml.NewDecoder(r.Body).Decode(&request); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+	switch {
+	case path == "" && r.Method == http.MethodPost:
+		server.braintreeCreate(w, request)
+	case action == "" && r.Method == http.MethodGet:
+		server.braintreeFind(w, id)
+	case action == "submit_for_settlement" && r.Method == http.MethodPut:
+		server.braintreeSubmitForSettlement(w, id, request)
+	case action == "void" && r.Method == http.MethodPut:
+		server.braintreeVoid(w, id)
+	case action == "refund" && r.Method == http.MethodPost:
+		server.braintreeRefund(w, id, request)
+	case action == "settle" && r.Method == http.MethodPut:
+		server.braintreeSettle(w, id)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (server *Server) braintreeCreate(w http.ResponseWriter, request braintreeTransactionRequest) {
+	amount, err := decimalToMinorUnits(request.Amount)
+	switch {
+	case request.Amount == "":
+		braintreeValidation(w, "81502", "amount", "Amount is required.")
+		return
+	case err != nil:
+		braintreeValidation(w, "81503", "amount", "Amount is an invalid format.")
+		return
+	case request.Type != "sale":
+		braintreeValidation(w, "91523", "type", "Transaction type is invalid.")
+		return
+	case request.PaymentMethodToken != "":
+		braintreeValidation(w, "91518", "payment_method_token", "Payment method token is invalid.")
+		return
+	case request.CreditCard == nil || request.CreditCard.Number == "":
+		braintreeValidation(w, "81714", "number", "Credit card number is required.")
+		return
+	case !validExpirationDate(request.CreditCard.ExpirationDate):
+		braintreeValidation(w, "81712", "expiration_date", "Expiration date is invalid.")
+		return
+	}
+
+	p := &payment{
+		reference:         server.nextReference("%08x"),
+		merchantReference: request.OrderID,
+		state:             stateAuthorized,
+		currency:          "USD",
+		authorized:        amount,
+		last4:             last4(request.CreditCard.Number),
+		code:              "1000",
+	}
+	switch {
+	case amount >= braintreeDeclineFrom && amount < braintreeDeclineUntil:
+		p.state, p.code = stateDeclined, strconv.FormatInt(amount/100, 10)
+	case amount == braintreeFailureAmount:
+		p.state, p.code = stateDeclined, "3000"
+	case request.Options != nil && request.Options.SubmitForSettlement:
+		p.state, p.captured = stateCaptured, amount
+	}
+	server.ledger.add(p)
+
+	transaction := braintreeTransactionElement(*p)
+	transaction.AVSPostalCodeResponseCode, transaction.AVSStreetAddressResponseCode = "I", "I"
+	if billing := request.Billing; billing != nil {
+		transaction.AVSPostalCodeResponseCode = braintreeVerification(billing.PostalCode, "20000", "20001")
+		transaction.AVSStreetAddressResponseCode = braintreeVerification(billing.StreetAddress, "200 ", "201 ")
+	}
+	transaction.CVVResponseCode = "I"
+	if cvv := request.CreditCard.CVV; cvv != "" {
+		transaction.CVVResponseCode = braintreeVerification(cvv, "200", "201")
+	}
+	if p.state == stateDeclined {
+		writeXML(w, http.StatusUnprocessableEntity, braintreeErrorResponse{
+			Message:     transaction.ProcessorResponseText,
+			Transaction: &transaction,
+		})
+		return
+	}
+	writeXML(w, http.StatusCreated, transaction)
+}
+
+// braintreeVerification checks a value the way the sandbox does, not matching or not verifying values with a prefix
+func braintreeVerification(value string, noMatch string, notVerified string) string {
+	switch {
+	case strings.HasPrefix(value, noMatch):
+		return "N"
+	case strings.HasPrefix(value, notVerified):
+		return "U"
+	}
+	return "M"
+}
+
+func (server *Server) braintreeFind(w http.ResponseWriter, id string) {
+	p, ok := server.ledger.get(id)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	writeXML(w, http.StatusOK, braintreeTransactionElement(p))
+}
+
+func (server *Server) braintreeSubmitForSettlement(w http.ResponseWriter, id string, request braintreeTransactionRequest) {
+	amount := int64(-1)
+	if request.Amount != "" {
+		parsed, err := decimalToMinorUnits(request.Amount)
+		if err != nil {
+			braintreeValidation(w, "81503", "amount", "Amount is an invalid format.")
+			return
+		}
+		amount = parsed
+	}
+	p, err := server.ledger.capture(id, amount, "")
+	switch err {
+	case nil:
+		writeXML(w, http.StatusOK, braintreeTransactionElement(p))
+	case errUnknownPayment:
+		w.WriteHeader(http.StatusNotFound)
+	case errAmountExceeded:
+		braintreeValidation(w, "91522", "amount", "Settlement amount is too large.")
+	default:
+		braintreeValidation(w, "91507", "base", "Cannot submit for settlement unless status is authorized.")
+	}
+}
+
+// braintreeVoid voids a transaction until it settles, including one submitted for settlement
+func (server *Server) braintreeVoid(w http.ResponseWriter, id string) {
+	p, err := server.ledger.apply(id, "", func(p *payment) error {
+		if p.credit || p.settled || (p.state != stateAuthorized && p.state != stateCaptured) {
+			return errInvalidState
+		}
+		p.state = stateVoided
+		return nil
+	})
+	switch err {
+	case nil:
+		writeXML(w, http.StatusOK, braintreeTransactionElement(p))
+	case errUnknownPayment:
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		braintreeValidation(w, "91504", "base", "Transaction can only be voided if status is authorized, submitted_for_settlement, or - for PayPal - settlement_pending.")
+	}
+}
+
+// braintreeRefund refunds a settled transaction with a credit transaction of its own
+func (server *Server) braintreeRefund(w http.ResponseWriter, id string, request braintreeTransactionRequest) {
+	amount := int64(-1)
+	if request.Amount != "" {
+		parsed, err := decimalToMinorUnits(request.Amount)
+		if err != nil {
+			braintreeValidation(w, "81503", "amount", "Amount is an invalid format.")
+			return
+		}
+		amount = parsed
+	}
+	p, ok := server.ledger.get(id)
+	switch {
+	case !ok:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	case !p.settled:
+		braintreeValidation(w, "91506", "base", "Cannot refund transaction unless it is settled.")
+		return
+	}
+	before := p.refunded
+	p, err := server.ledger.refund(id, amount, "")
+	if err != nil {
+		braintreeValidation(w, "91521", "amount", "Refund amount is too large.")
+		return
+	}
+	credit := &payment{
+		reference:         server.nextReference("%08x"),
+		merchantReference: p.merchantReference,
+		state:             stateCaptured,
+		currency:          p.currency,
+		authorized:        p.refunded - before,
+		captured:          p.refunded - before,
+		last4:             p.last4,
+		code:              "1000",
+		credit:            true,
+	}
+	server.ledger.add(credit)
+	_, _ = server.ledger.apply(id, "", func(p *payment) error {
+		p.refunds = append(p.refunds, credit.reference)
+		return nil
+	})
+	transaction := braintreeTransactionElement(*credit)
+	transaction.RefundedTransactionID = p.reference
+	writeXML(w, http.StatusCreated, transaction)
+}
+
+// braintreeSettle settles a transaction submitted for settlement, as the sandbox's testing gateway does
+func (server *Server) braintreeSettle(w http.ResponseWriter, id string) {
+	p, err := server.ledger.apply(id, "", func(p *payment) error {
+		if p.state != stateCaptured && p.state != stateRefunded {
+			return errInvalidState
+		}
+		p.settled = true
+		return nil
+	})
+	switch err {
+	case nil:
+		writeXML(w, http.StatusOK, braintreeTransactionElement(p))
+	case errUnknownPayment:
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		braintreeValidation(w, "91568", "base", "Transaction can only be settled if status is submitted_for_settlement.")
+	}
+}
+
+func braintreeTransactionElement(p payment) braintreeTransaction {
+	transaction := braintreeTransaction{
+		ID:                    p.reference,
+		Status:                braintreeStatus(p),
+		Type:                  "sale",
+		CurrencyISOCode:       p.currency,
+		Amount:                minorUnitsToDecimal(p.authorized),
+		OrderID:               p.merchantReference,
+		CreatedAt:             p.createdAt.Format(time.RFC3339),
+		UpdatedAt:             time.Now().UTC().Format(time.RFC3339),
+		RefundIDs:             p.refunds,
+		ProcessorResponseCode: p.code,
+		ProcessorResponseText: "Approved",
+	}
+	if p.credit {
+		transaction.Type = "credit"
+	}
+	if p.captured > 0 {
+		transaction.Amount = minorUnitsToDecimal(p.captured)
+	}
+	code, _ := strconv.Atoi(p.code)
+	switch {
+	case code == 3000:
+		transaction.ProcessorResponseText = "Processor Network Unavailable - Try Again"
+	case p.state == stateDeclined:
+		transaction.ProcessorResponseText = "Processor Declined"
+		if text, ok := braintreeDeclines[code]; ok {
+			transaction.ProcessorResponseText = text
+		}
+	default:
+		transaction.ProcessorAuthorizationCode = strings.ToUpper(p.reference[len(p.reference)-6:])
+	}
+	transaction.CreditCard.CardType = "Visa"
+	transaction.CreditCard.Last4 = p.last4
+	return transaction
+}
+
+func braintreeStatus(p payment) string {
+	switch p.state {
+	case stateAuthorized:
+		return "authorized"
+	case stateVoided:
+		return "voided"
+	case stateDeclined:
+		if p.code == "3000" {
+			return "failed"
+		}
+		return "processor_declined"
+	}
+	if p.settled {
+		return "settled"
+	}
+	return "submitted_for_settlement"
+}
+
+func braintreeValidation(w http.ResponseWriter, code string, attribute string, message string) {
+	writeXML(w, http.StatusUnprocessableEntity, braintreeErrorResponse{
+		Errors:  []braintreeValidationError{{Code: code, Attribute: attribute, Message: message}},
+		Message: message,
+	})
+}
+
+// validExpirationDate checks an expiration date is given as MM/YY or MM/YYYY
+func validExpirationDate(date string) bool {
+	parts := strings.Split(date, "/")
+	if len(parts) != 2 || (len(parts[1]) != 2 && len(parts[1]) != 4) {
+		return false
+	}
+	month, err := strconv.Atoi(parts[0])
+	if err != nil || month < 1 || month > 12 {
+		return false
+	}
+	_, err = strconv.Atoi(parts[1])
+	return err == nil
+}
+
+func writeXML(w http.ResponseWriter, statusCode int, body interface{}) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(statusCode)
+	_, _ = w.Write([]byte(xml.Header))
+	_ = xml.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,295 @@
+package fakes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Credentials accepted by the CardConnect fake, and the site a client is given for it
+const (
+	CardConnectUsername   = "fake_username"
+	CardConnectPassword   = "fake_password"
+	CardConnectMerchantID = "496000000001"
+	CardConnectURL        = "fts-uat.cardconnect.com"
+)
+
+// CardPointe's UAT environment declines amounts from $1000.00 to $1999.99, with the response code given by the
+// dollars over $1000
+const (
+	cardConnectDeclineFrom  = 100000
+	cardConnectDeclineUntil = 200000
+)
+
+// cardConnectDeclines are the texts of the response codes the UAT environment declines with, other codes are "Decline"
+var cardConnectDeclines = map[string]string{
+	"101": "Expired card",
+	"116": "Insufficient funds",
+	"500": "Decline",
+}
+
+type cardConnectRequest struct {
+	MerchantID string  `json:"merchid"`
+	Account    string  `json:"account"`
+	Expiry     string  `json:"expiry"`
+	Amount     *string `json:"amount"`
+	Currency   string  `json:"currency"`
+	CVV2       string  `json:"cvv2"`
+	RetRef     string  `json:"retref"`
+	OrderID    string  `json:"orderid"`
+	Postal     string  `json:"postal"`
+	Profile    string  `json:"profile"`
+	Capture    string  `json:"capture"`
+}
+
+// NewCardConnect starts a fake of the CardPointe gateway's REST API. Refunds are only accepted once a transaction has
+// settled, which the fake never does, as in CardPointe's UAT environment.
+func NewCardConnect() *Server {
+	return newServer(func(server *Server) http.Handler {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/cardconnect/rest/auth", server.cardConnectAuth)
+		mux.HandleFunc("/cardconnect/rest/capture", server.cardConnectCapture)
+		mux.HandleFunc("/cardconnect/rest/void", server.cardConnectVoid)
+		mux.HandleFunc("/cardconnect/rest/refund", server.cardConnectRefund)
+		mux.HandleFunc("/cardconnect/rest/inquire/", server.cardConnectInquire)
+		return mux
+	})
+}
+
+// cardConnectDecode decodes a request once its credentials and merchant are checked. CardPointe answers requests
+// it doesn't authorize with an error page rather than JSON.
+func cardConnectDecode(w http.ResponseWriter, r *http.Request) (cardConnectRequest, bool) {
+	var request cardConnectRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return request, false
+	}
+	return request, cardConnectAuthorized(w, r, request.MerchantID)
+}
+
+func cardConnectAuthorized(w http.ResponseWriter, r *http.Request, merchantID string) bool {
+	username, password, ok := r.BasicAuth()
+	if ok && username == CardConnectUsername && password == CardConnectPassword && merchantID == CardConnectMerchantID {
+		return true
+	}
+	w.Header().Set("Content-Type", "text/html")
+	w.WriteHeader(http.StatusUnauthorized)
+	_, _ = w.Write([]byte("<html><body><h1>401 Unauthorized</h1></body></html>"))
+	return false
+}
+
+func (server *Server) cardConnectAuth(w http.ResponseWriter, r *http.Request) {
+	request, ok := cardConnectDecode(w, r)
+	if !ok {
+		return
+	}
+	switch {
+	case request.Profile != "":
+		cardConnectError(w, "96", "Profile not found", "")
+		return
+	case request.Account == "":
+		cardConnectError(w, "13", "Invalid field", "")
+		return
+	case len(request.Expiry) != 4:
+		cardConnectError(w, "32", "Invalid expiry", "")
+		return
+	case request.Amount == nil:
+		cardConnectError(w, "13", "Invalid field", "")
+		return
+	}
+	amount, err := decimalToMinorUnits(*request.Amount)
+	if err != nil {
+		cardConnectError(w, "37", "Invalid amount", "")
+		return
+	}
+	currency := request.Currency
+	if currency == "" {
+		currency = "USD"
+	}
+
+	p := &payment{
+		reference:         server.nextReference("3%011d"),
+		merchantReference: request.OrderID,
+		state:             stateAuthorized,
+		currency:          currency,
+		authorized:        amount,
+		last4:             last4(request.Account),
+		code:              "00",
+	}
+	avs, cvv := "", ""
+	if request.Postal != "" {
+		avs = "Y"
+	}
+	if request.CVV2 != "" {
+		cvv = "M"
+	}
+	response := map[string]interface{}{
+		"amount":   *request.Amount,
+		"currency": currency,
+		"merchid":  CardConnectMerchantID,
+		"retref":   p.reference,
+		"account":  cardConnectToken(p.last4),
+		"token":    cardConnectToken(p.last4),
+		"expiry":   request.Expiry,
+		"avsresp":  avs,
+		"cvvresp":  cvv,
+		"respproc": "RPCT",
+	}
+	if amount >= cardConnectDeclineFrom && amount < cardConnectDeclineUntil {
+		p.state, p.code = stateDeclined, fmt.Sprintf("%03d", amount/100-1000)
+		text, ok := cardConnectDeclines[p.code]
+		if !ok {
+			text = "Decline"
+		}
+		server.ledger.add(p)
+		response["respstat"], response["respcode"], response["resptext"] = "C", p.code, text
+		writeJSON(w, http.StatusOK, response)
+		return
+	}
+	if request.Capture == "Y" {
+		p.state, p.captured = stateCaptured, amount
+		response["setlstat"] = "Queued for Capture"
+	}
+	server.ledger.add(p)
+	response["respstat"], response["respcode"], response["resptext"] = "A", "00", "Approval"
+	response["authcode"] = "PPS" + p.reference[len(p.reference)-3:]
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (server *Server) cardConnectCapture(w http.ResponseWriter, r *http.Request) {
+	request, ok := cardConnectDecode(w, r)
+	if !ok {
+		return
+	}
+	amount := int64(-1)
+	if request.Amount != nil {
+		parsed, err := decimalToMinorUnits(*request.Amount)
+		if err != nil {
+			cardConnectError(w, "37", "Invalid amount", request.RetRef)
+			return
+		}
+		amount = parsed
+	}
+	p, err := server.ledger.capture(request.RetRef, amount, "")
+	switch err {
+	case nil:
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"respstat": "A",
+			"respcode": "00",
+			"resptext": "Approval",
+			"respproc": "RPCT",
+			"retref":   p.reference,
+			"merchid":  CardConnectMerchantID,
+			"account":  cardConnectToken(p.last4),
+			"amount":   minorUnitsToDecimal(p.captured),
+			"setlstat": "Queued for Capture",
+			"batchid":  "1900940914",
+		})
+	case errAmountExceeded:
+		cardConnectError(w, "37", "Invalid amount", request.RetRef)
+	default:
+		cardConnectError(w, "29", "Txn not found", request.RetRef)
+	}
+}
+
+// cardConnectVoid voids a transaction until it settles, including one queued for capture
+func (server *Server) cardConnectVoid(w http.ResponseWriter, r *http.Request) {
+	request, ok := cardConnectDecode(w, r)
+	if !ok {
+		return
+	}
+	p, err := server.ledger.apply(request.RetRef, "", func(p *payment) error {
+		if p.state != stateAuthorized && p.state != stateCaptured {
+			return errInvalidState
+		}
+		p.state = stateVoided
+		return nil
+	})
+	if err != nil {
+		cardConnectError(w, "29", "Txn not found", request.RetRef)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"respstat": "A",
+		"respcode": "00",
+		"resptext": "Approval",
+		"respproc": "PPS",
+		"retref":   p.reference,
+		"merchid":  CardConnectMerchantID,
+		"amount":   "0.00",
+		"currency": p.currency,
+		"authcode": "REVERS",
+	})
+}
+
+func (server *Server) cardConnectRefund(w http.ResponseWriter, r *http.Request) {
+	request, ok := cardConnectDecode(w, r)
+	if !ok {
+		return
+	}
+	if _, ok := server.ledger.get(request.RetRef); !ok {
+		cardConnectError(w, "29", "Txn not found", request.RetRef)
+		return
+	}
+	cardConnectError(w, "29", "Txn not settled", request.RetRef)
+}
+
+func (server *Server) cardConnectInquire(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/cardconnect/rest/inquire/"), "/")
+	if !cardConnectAuthorized(w, r, parts[len(parts)-1]) {
+		return
+	}
+	p, ok := server.ledger.get(parts[0])
+	if !ok {
+		cardConnectError(w, "29", "Txn not found", parts[0])
+		return
+	}
+	setlstat, capture := "Authorized", "N"
+	switch p.state {
+	case stateCaptured, stateRefunded:
+		setlstat, capture = "Queued for Capture", "Y"
+	case stateVoided:
+		setlstat = "Voided"
+	case stateDeclined:
+		setlstat = "Declined"
+	}
+	amount := p.authorized
+	if p.captured > 0 {
+		amount = p.captured
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"respstat": "A",
+		"respcode": p.code,
+		"resptext": "Approval",
+		"retref":   p.reference,
+		"merchid":  CardConnectMerchantID,
+		"account":  cardConnectToken(p.last4),
+		"amount":   minorUnitsToDecimal(amount),
+		"currency": p.currency,
+		"setlstat": setlstat,
+		"capture":  capture,
+		"accttype": "VISA",
+		"orderId":  p.merchantReference,
+	})
+}
+
+// cardConnectToken returns the CardSecure token standing for a card, which keeps its last four digits
+func cardConnectToken(last4 string) string {
+	return "941859416454" + last4
+}
+
+func cardConnectError(w http.ResponseWriter, code string, text string, reference string) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"respstat": "C",
+		"respcode": code,
+		"resptext": text,
+		"respproc": "PPS",
+		"retref":   reference,
+		"merchid":  CardConnectMerchantID,
+	})
+}
+
+func minorUnitsToDecimal(amount int64) string {
+	return fmt.Sprintf("%d.%02d", amount/100, amount%100)
+}
@@ -0,0 +1,420 @@
+package fakes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Keys accepted by the Checkout.com fake. The secret key is in the format of the unified payments API, which the SDK
+// sends as a bearer token and which requires the processing channel. The legacy key is sent as it is and needs none.
+const (
+	CheckoutComSecretKey           = "sk_sbox_fakefakefakefakefakefakefak"
+	CheckoutComProcessingChannelID = "pc_fakefakefakefakefakefakefa"
+	CheckoutComLegacySecretKey     = "sk_test_fake_legacy"
+)
+
+// checkoutComDecline is the response code and summary a card is declined with
+type checkoutComDecline struct {
+	code    string
+	summary string
+}
+
+// checkoutComTestCards are the card numbers the sandbox declines, see
+// https://www.checkout.com/docs/four/testing/response-code-testing
+var checkoutComTestCards = map[string]checkoutComDecline{
+	"4544249167673670": {"20051", "Insufficient Funds"},
+}
+
+// checkoutComAction is an action taken on a payment, as listed by the get payment actions endpoint
+type checkoutComAction struct {
+	ID           string    `json:"id"`
+	Type         string    `json:"type"`
+	ProcessedOn  time.Time `json:"processed_on"`
+	Amount       int64     `json:"amount"`
+	Approved     bool      `json:"approved"`
+	ResponseCode string    `json:"response_code"`
+	Reference    string    `json:"reference,omitempty"`
+}
+
+type checkoutComSource struct {
+	Type           string `json:"type"`
+	Number         string `json:"number"`
+	ExpiryMonth    int    `json:"expiry_month"`
+	ExpiryYear     int    `json:"expiry_year"`
+	CVV            string `json:"cvv"`
+	BillingAddress *struct {
+		AddressLine1 string `json:"address_line1"`
+		ZIP          string `json:"zip"`
+	} `json:"billing_address"`
+}
+
+type checkoutComPaymentRequest struct {
+	Source              checkoutComSource `json:"source"`
+	Amount              int64             `json:"amount"`
+	Currency            string            `json:"currency"`
+	Capture             *bool             `json:"capture"`
+	Reference           string            `json:"reference"`
+	ProcessingChannelID string            `json:"processing_channel_id"`
+}
+
+type checkoutComActionRequest struct {
+	Amount    int64  `json:"amount"`
+	Reference string `json:"reference"`
+}
+
+// checkoutCom keeps the actions of each payment besides the ledger, as Checkout.com lists them
+type checkoutCom struct {
+	*Server
+	mu      sync.Mutex
+	actions map[string][]checkoutComAction
+	sources map[string]checkoutComSource
+}
+
+// NewCheckoutCom starts a fake of Checkout.com's payments API. Payments can be captured several times until their
+// authorized amount is used up, and refunded several times, as on Checkout.com.
+func NewCheckoutCom() *Server {
+	return newServer(func(server *Server) http.Handler {
+		fake := &checkoutCom{
+			Server:  server,
+			actions: make(map[string][]checkoutComAction),
+			sources: make(map[string]checkoutComSource),
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/payments", fake.payments)
+		mux.HandleFunc("/payments/", fake.payment)
+		return checkoutComAuthenticated(mux)
+	})
+}
+
+// checkoutComAuthenticated checks the secret key, which the unified payments API takes as a bearer token and the
+// legacy API as the whole header. Checkout.com answers unauthorized requests without a body.
+func checkoutComAuthenticated(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if header != "Bearer "+CheckoutComSecretKey && header != CheckoutComLegacySecretKey {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// payments requests a payment, or searches payments by reference
+func (fake *checkoutCom) payments(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		fake.search(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var request checkoutComPaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		checkoutComError(w, http.StatusUnprocessableEntity, "request_invalid")
+		return
+	}
+	unified := strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ")
+	switch {
+	case unified && request.ProcessingChannelID == "":
+		checkoutComError(w, http.StatusUnprocessableEntity, "processing_channel_id_required")
+		return
+	case unified && request.ProcessingChannelID != CheckoutComProcessingChannelID:
+		checkoutComError(w, http.StatusUnprocessableEntity, "processing_channel_id_invalid")
+		return
+	case request.Source.Type != "card":
+		checkoutComError(w, http.StatusUnprocessableEntity, "payment_source_required")
+		return
+	case request.Currency == "":
+		checkoutComError(w, http.StatusUnprocessableEntity, "currency_required")
+		return
+	case request.Amount < 0:
+		checkoutComError(w, http.StatusUnprocessableEntity, "amount_invalid")
+		return
+	case !luhnValid(request.Source.Number):
+		checkoutComError(w, http.StatusUnprocessableEntity, "card_number_invalid")
+		return
+	case request.Source.ExpiryMonth < 1 || request.Source.ExpiryMonth > 12:
+		checkoutComError(w, http.StatusUnprocessableEntity, "card_expiry_month_invalid")
+		return
+	case request.Source.ExpiryYear == 0:
+		checkoutComError(w, http.StatusUnprocessableEntity, "card_expiry_year_required")
+		return
+	}
+
+	p := &payment{
+		reference:         fake.nextReference("pay_%026d"),
+		merchantReference: request.Reference,
+		state:             stateAuthorized,
+		currency:          request.Currency,
+		authorized:        request.Amount,
+		last4:             last4(request.Source.Number),
+		code:              "10000",
+	}
+	summary := "Approved"
+	if decline, ok := checkoutComTestCards[request.Source.Number]; ok {
+		p.state, p.code, summary = stateDeclined, decline.code, decline.summary
+	} else if request.Capture == nil || *request.Capture {
+		p.state, p.captured = stateCaptured, request.Amount
+	}
+	fake.ledger.add(p)
+	fake.mu.Lock()
+	fake.sources[p.reference] = request.Source
+	fake.mu.Unlock()
+	actionID := fake.record(p.reference, "Authorization", request.Amount, p.state != stateDeclined, p.code, request.Reference)
+	if p.state == stateCaptured {
+		fake.record(p.reference, "Capture", request.Amount, true, p.code, request.Reference)
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"id":               p.reference,
+		"action_id":        actionID,
+		"amount":           p.authorized,
+		"currency":         p.currency,
+		"approved":         p.state != stateDeclined,
+		"status":           checkoutComStatus(*p),
+		"auth_code":        "000" + p.reference[len(p.reference)-3:],
+		"response_code":    p.code,
+		"response_summary": summary,
+		"processed_on":     p.createdAt,
+		"reference":        p.merchantReference,
+		"source":           fake.source(*p),
+	})
+}
+
+// payment answers the endpoints of a payment: getting it or its actions, and its captures, refunds, voids and
+// incremental authorizations
+func (fake *checkoutCom) payment(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/payments/")
+	id, action := path, ""
+	if i := strings.Index(path, "/"); i >= 0 {
+		id, action = path[:i], path[i+1:]
+	}
+	p, ok := fake.ledger.get(id)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		switch action {
+		case "":
+			writeJSON(w, http.StatusOK, fake.details(p))
+		case "actions":
+			writeJSON(w, http.StatusOK, fake.list(id))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var request checkoutComActionRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			checkoutComError(w, http.StatusUnprocessableEntity, "request_invalid")
+			return
+		}
+	}
+
+	var err error
+	amount := request.Amount
+	switch action {
+	case "captures":
+		if amount == 0 {
+			amount = -1
+		}
+		before := p.captured
+		p, err = fake.ledger.multiCapture(id, amount, "")
+		amount = p.captured - before
+	case "refunds":
+		before := p.refunded
+		if amount == 0 {
+			amount = -1
+		}
+		p, err = fake.ledger.refund(id, amount, "")
+		amount = p.refunded - before
+	case "voids":
+		p, err = fake.ledger.void(id)
+		amount = p.authorized
+	case "authorizations":
+		p, err = fake.ledger.increment(id, amount, "")
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	switch err {
+	case nil:
+	case errAmountExceeded:
+		checkoutComError(w, http.StatusUnprocessableEntity, "amount_exceeds_balance")
+		return
+	default:
+		// Checkout.com refuses actions the payment's status doesn't allow without a body
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	actionID := fake.record(id, checkoutComActionType(action), amount, true, "10000", request.Reference)
+	if action == "authorizations" {
+		writeJSON(w, http.StatusCreated, map[string]interface{}{
+			"action_id":        actionID,
+			"amount":           amount,
+			"currency":         p.currency,
+			"approved":         true,
+			"status":           checkoutComStatus(p),
+			"response_code":    "10000",
+			"response_summary": "Approved",
+			"processed_on":     time.Now().UTC(),
+			"reference":        request.Reference,
+			"balances": map[string]int64{
+				"total_authorized":     p.authorized,
+				"available_to_capture": p.authorized - p.captured,
+				"available_to_void":    p.authorized,
+			},
+		})
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]string{"action_id": actionID, "reference": request.Reference})
+}
+
+// search lists the payments with a reference, most recent first
+func (fake *checkoutCom) search(w http.ResponseWriter, r *http.Request) {
+	reference := r.URL.Query().Get("reference")
+	if reference == "" {
+		checkoutComError(w, http.StatusUnprocessableEntity, "reference_required")
+		return
+	}
+	found := fake.ledger.byMerchantReference(reference)
+	data := make([]map[string]interface{}, 0, len(found))
+	for _, p := range found {
+		data = append(data, fake.details(p))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"limit":       10,
+		"skip":        0,
+		"total_count": len(data),
+		"data":        data,
+	})
+}
+
+// record adds an action to a payment and returns its ID
+func (fake *checkoutCom) record(paymentID string, actionType string, amount int64, approved bool, code string, reference string) string {
+	action := checkoutComAction{
+		ID:           fake.nextReference("act_%026d"),
+		Type:         actionType,
+		ProcessedOn:  time.Now().UTC(),
+		Amount:       amount,
+		Approved:     approved,
+		ResponseCode: code,
+		Reference:    reference,
+	}
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	fake.actions[paymentID] = append(fake.actions[paymentID], action)
+	return action.ID
+}
+
+// list returns the actions of a payment, most recent first
+func (fake *checkoutCom) list(paymentID string) []checkoutComAction {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	recorded := fake.actions[paymentID]
+	actions := make([]checkoutComAction, 0, len(recorded))
+	for i := len(recorded) - 1; i >= 0; i-- {
+		actions = append(actions, recorded[i])
+	}
+	return actions
+}
+
+func (fake *checkoutCom) details(p payment) map[string]interface{} {
+	return map[string]interface{}{
+		"id":           p.reference,
+		"requested_on": p.createdAt,
+		"amount":       p.authorized,
+		"currency":     p.currency,
+		"payment_type": "Regular",
+		"reference":    p.merchantReference,
+		"approved":     p.state != stateDeclined,
+		"status":       checkoutComStatus(p),
+		"source":       fake.source(p),
+	}
+}
+
+// source describes the card of a payment. The sandbox matches the address and CVV whenever they're given.
+func (fake *checkoutCom) source(p payment) map[string]interface{} {
+	fake.mu.Lock()
+	source := fake.sources[p.reference]
+	fake.mu.Unlock()
+	avs, cvv := "", ""
+	if address := source.BillingAddress; address != nil && address.ZIP != "" {
+		avs = "Z"
+		if address.AddressLine1 != "" {
+			avs = "Y"
+		}
+	}
+	if source.CVV != "" {
+		cvv = "Y"
+	}
+	bin := source.Number
+	if len(bin) > 6 {
+		bin = bin[:6]
+	}
+	return map[string]interface{}{
+		"id":           "src_" + strings.TrimPrefix(p.reference, "pay_"),
+		"type":         "card",
+		"expiry_month": source.ExpiryMonth,
+		"expiry_year":  source.ExpiryYear,
+		"scheme":       "Visa",
+		"last4":        p.last4,
+		"bin":          bin,
+		"card_type":    "CREDIT",
+		"avs_check":    avs,
+		"cvv_check":    cvv,
+	}
+}
+
+// checkoutComStatus returns the status Checkout.com gives a payment
+func checkoutComStatus(p payment) string {
+	switch p.state {
+	case stateDeclined:
+		return "Declined"
+	case stateVoided:
+		return "Voided"
+	case stateCaptured:
+		if p.captured < p.authorized {
+			return "Partially Captured"
+		}
+		return "Captured"
+	case stateRefunded:
+		if p.refunded < p.captured {
+			return "Partially Refunded"
+		}
+		return "Refunded"
+	}
+	return "Authorized"
+}
+
+func checkoutComActionType(action string) string {
+	switch action {
+	case "captures":
+		return "Capture"
+	case "refunds":
+		return "Refund"
+	case "voids":
+		return "Void"
+	}
+	return "Authorization"
+}
+
+func checkoutComError(w http.ResponseWriter, statusCode int, code string) {
+	writeJSON(w, statusCode, map[string]interface{}{
+		"request_id":  "0HL80RJLS76I7",
+		"error_type":  "request_invalid",
+		"error_codes": []string{code},
+	})
+}
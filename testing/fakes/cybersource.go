@@ -0,0 +1,612 @@
+package fakes
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Credentials accepted by the CyberSource fake. The shared secret is base64 encoded, as CyberSource issues it.
+const (
+	CybersourceMerchantID   = "sleet_fake_merchant"
+	CybersourceKeyID        = "5b7ef4d8-7c0a-4c55-9b5e-0f9a4b1a2c3d"
+	CybersourceSharedSecret = "c2xlZXQgZmFrZSBjeWJlcnNvdXJjZSBzZWNyZXQhISE="
+)
+
+// cybersourceDeclinedCard is declined by the fake's processor, every other card with a valid check digit is approved
+const cybersourceDeclinedCard = "4000000000000002"
+
+// CyberSource services run for a transaction, as reported by the Transaction Details API
+const (
+	cybersourceAuth         = "ics_auth"
+	cybersourceAuthReversal = "ics_auth_reversal"
+	cybersourceBill         = "ics_bill"
+	cybersourceCredit       = "ics_credit"
+	cybersourceVoid         = "ics_void"
+)
+
+type cybersourceAmountDetails struct {
+	TotalAmount      string `json:"totalAmount"`
+	AdditionalAmount string `json:"additionalAmount"`
+	Currency         string `json:"currency"`
+}
+
+type cybersourceOrder struct {
+	BillTo struct {
+		Address1   string `json:"address1"`
+		PostalCode string `json:"postalCode"`
+	} `json:"billTo"`
+	AmountDetails cybersourceAmountDetails `json:"amountDetails"`
+}
+
+type cybersourceRequest struct {
+	ClientReferenceInformation *struct {
+		Code          string `json:"code"`
+		TransactionID string `json:"transactionId"`
+	} `json:"clientReferenceInformation"`
+	ProcessingInformation *struct {
+		Capture bool `json:"capture"`
+	} `json:"processingInformation"`
+	OrderInformation   *cybersourceOrder `json:"orderInformation"`
+	PaymentInformation *struct {
+		Card *struct {
+			Number          string `json:"number"`
+			ExpirationMonth string `json:"expirationMonth"`
+			ExpirationYear  string `json:"expirationYear"`
+			SecurityCode    string `json:"securityCode"`
+		} `json:"card"`
+	} `json:"paymentInformation"`
+	ReversalInformation *struct {
+		AmountDetails cybersourceAmountDetails `json:"amountDetails"`
+	} `json:"reversalInformation"`
+}
+
+// cybersourceTransaction is a transaction as the Transaction Details API reports it. Captures, refunds and voids are
+// transactions of their own, related to the authorization they follow.
+type cybersourceTransaction struct {
+	id            string
+	parent        string
+	application   string
+	succeeded     bool
+	amount        int64
+	currency      string
+	code          string // the client reference code
+	transactionID string // the merchant's ID of an authorization, which timeout reversals are made with
+	last4         string
+	cardType      string
+	submitted     time.Time
+}
+
+// cybersource keeps the transactions of the fake besides the ledger, which holds the state of each authorization
+type cybersource struct {
+	*Server
+	mu           sync.Mutex
+	transactions map[string]*cybersourceTransaction
+	related      map[string][]string
+	order        []*cybersourceTransaction
+}
+
+// NewCybersource starts a fake of CyberSource's REST payments, Transaction Details and Transaction Search APIs. Every
+// request must be signed with the HTTP signature of the fake's shared secret, and have a digest of its body.
+// Authorizations can be captured several times, and captures and refunds are voided until they settle, which they
+// never do.
+func NewCybersource() *Server {
+	return newServer(func(server *Server) http.Handler {
+		fake := &cybersource{
+			Server:       server,
+			transactions: make(map[string]*cybersourceTransaction),
+			related:      make(map[string][]string),
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/pts/v2/payments/", fake.payments)
+		mux.HandleFunc("/pts/v2/reversals", fake.timeoutReversal)
+		mux.HandleFunc("/tss/v2/transactions/", fake.details)
+		mux.HandleFunc("/tss/v2/searches", fake.search)
+		return cybersourceSigned(mux)
+	})
+}
+
+// cybersourceSigned checks the signature of every request and the digest of its body. CyberSource answers requests
+// failing authentication with a body unlike its other errors.
+func cybersourceSigned(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil || !cybersourceVerified(r, body) {
+			writeJSON(w, http.StatusUnauthorized, map[string]interface{}{
+				"response": map[string]string{"rmsg": "Authentication Failed"},
+			})
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r)
+	})
+}
+
+func cybersourceVerified(r *http.Request, body []byte) bool {
+	parameters := make(map[string]string)
+	for _, parameter := range strings.Split(r.Header.Get("Signature"), ",") {
+		if pair := strings.SplitN(parameter, "=", 2); len(pair) == 2 {
+			parameters[pair[0]] = strings.Trim(pair[1], `"`)
+		}
+	}
+	if parameters["keyid"] != CybersourceKeyID || parameters["algorithm"] != "HmacSHA256" ||
+		r.Header.Get("v-c-merchant-id") != CybersourceMerchantID || r.Header.Get("Date") == "" {
+		return false
+	}
+
+	var lines []string
+	for _, header := range strings.Fields(parameters["headers"]) {
+		var value string
+		switch header {
+		case "host":
+			value = r.Host
+		case "(request-target)":
+			value = strings.ToLower(r.Method) + " " + r.URL.RequestURI()
+		case "digest":
+			digest := sha256.Sum256(body)
+			value = "SHA-256=" + base64.StdEncoding.EncodeToString(digest[:])
+			if r.Header.Get("Digest") != value {
+				return false
+			}
+		default:
+			value = r.Header.Get(header)
+		}
+		lines = append(lines, header+": "+value)
+	}
+	if len(body) > 0 && !strings.Contains(parameters["headers"], "digest") {
+		return false
+	}
+	secret, _ := base64.StdEncoding.DecodeString(CybersourceSharedSecret)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strings.Join(lines, "\n")))
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(parameters["signature"]), []byte(expected))
+}
+
+// payments authorizes a payment, or acts on one: incrementing its authorization, or capturing, voiding or refunding it
+func (fake *cybersource) payments(w http.ResponseWriter, r *http.Request) {
+	var request cybersourceRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		cybersourceInvalid(w, http.StatusBadRequest, "INVALID_DATA", "")
+		return
+	}
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/pts/v2/payments/"), "/")
+	id, action := path, ""
+	if i := strings.Index(path, "/"); i >= 0 {
+		id, action = path[:i], path[i+1:]
+	}
+
+	switch {
+	case path == "" && r.Method == http.MethodPost:
+		fake.authorize(w, request)
+	case action == "" && r.Method == http.MethodPatch:
+		fake.increment(w, id, request)
+	case r.Method == http.MethodPost && (action == "captures" || action == "refunds" || action == "voids" || action == "reversals"):
+		fake.followOn(w, id, action, request)
+	default:
+		cybersourceInvalid(w, http.StatusNotFound, "NOT_FOUND", "")
+	}
+}
+
+func (fake *cybersource) authorize(w http.ResponseWriter, request cybersourceRequest) {
+	switch {
+	case request.PaymentInformation == nil || request.PaymentInformation.Card == nil || request.PaymentInformation.Card.Number == "":
+		cybersourceInvalid(w, http.StatusBadRequest, "MISSING_FIELD", "paymentInformation.card.number")
+		return
+	case request.OrderInformation == nil || request.OrderInformation.AmountDetails.TotalAmount == "":
+		cybersourceInvalid(w, http.StatusBadRequest, "MISSING_FIELD", "orderInformation.amountDetails.totalAmount")
+		return
+	case request.OrderInformation.AmountDetails.Currency == "":
+		cybersourceInvalid(w, http.StatusBadRequest, "MISSING_FIELD", "orderInformation.amountDetails.currency")
+		return
+	case !luhnValid(request.PaymentInformation.Card.Number):
+		cybersourceInvalid(w, http.StatusBadRequest, "INVALID_DATA", "paymentInformation.card.number")
+		return
+	}
+	card := request.PaymentInformation.Card
+	month, _ := strconv.Atoi(card.ExpirationMonth)
+	year, _ := strconv.Atoi(card.ExpirationYear)
+	if month < 1 || month > 12 || year == 0 {
+		cybersourceInvalid(w, http.StatusBadRequest, "INVALID_DATA", "paymentInformation.card.expirationMonth")
+		return
+	}
+	amount, err := decimalToMinorUnits(request.OrderInformation.AmountDetails.TotalAmount)
+	if err != nil {
+		cybersourceInvalid(w, http.StatusBadRequest, "INVALID_AMOUNT", "orderInformation.amountDetails.totalAmount")
+		return
+	}
+
+	currency := request.OrderInformation.AmountDetails.Currency
+	p := &payment{
+		reference:  fake.nextReference("6%021d"),
+		state:      stateAuthorized,
+		currency:   currency,
+		authorized: amount,
+		last4:      last4(card.Number),
+		code:       "100",
+	}
+	auth := &cybersourceTransaction{
+		id:          p.reference,
+		application: cybersourceAuth,
+		succeeded:   true,
+		amount:      amount,
+		currency:    currency,
+		last4:       p.last4,
+		cardType:    cybersourceCardType(card.Number),
+	}
+	if reference := request.ClientReferenceInformation; reference != nil {
+		p.merchantReference, auth.code, auth.transactionID = reference.Code, reference.Code, reference.TransactionID
+	}
+	if card.Number == cybersourceDeclinedCard {
+		p.state, p.code, auth.succeeded = stateDeclined, "PROCESSOR_DECLINED", false
+		fake.ledger.add(p)
+		fake.record(auth)
+		writeJSON(w, http.StatusCreated, fake.response(auth, "DECLINED", map[string]interface{}{
+			"errorInformation": map[string]string{
+				"reason":  "PROCESSOR_DECLINED",
+				"message": "Decline - General decline of the card. No other information provided by the issuing bank.",
+			},
+			"processorInformation": map[string]interface{}{"responseCode": "005"},
+		}))
+		return
+	}
+	fake.ledger.add(p)
+	fake.record(auth)
+	if request.ProcessingInformation != nil && request.ProcessingInformation.Capture {
+		_, _ = fake.ledger.multiCapture(p.reference, amount, "")
+		fake.record(&cybersourceTransaction{
+			id:          fake.nextReference("6%021d"),
+			parent:      p.reference,
+			application: cybersourceBill,
+			succeeded:   true,
+			amount:      amount,
+			currency:    currency,
+			code:        auth.code,
+			last4:       p.last4,
+		})
+	}
+
+	avs, cvv := "U", ""
+	if request.OrderInformation.BillTo.PostalCode != "" {
+		avs = "Z"
+		if request.OrderInformation.BillTo.Address1 != "" {
+			avs = "Y"
+		}
+	}
+	if card.SecurityCode != "" {
+		cvv = "M"
+	}
+	writeJSON(w, http.StatusCreated, fake.response(auth, "AUTHORIZED", map[string]interface{}{
+		"processorInformation": map[string]interface{}{
+			"approvalCode":     "888888",
+			"responseCode":     "100",
+			"transactionId":    fake.nextReference("%015d"),
+			"avs":              map[string]string{"code": avs, "codeRaw": avs},
+			"cardVerification": map[string]string{"resultCode": cvv},
+		},
+		"orderInformation": map[string]interface{}{
+			"amountDetails": map[string]string{"authorizedAmount": minorUnitsToDecimal(amount), "currency": currency},
+		},
+	}))
+}
+
+// increment raises the authorized amount of a payment by the additional amount
+func (fake *cybersource) increment(w http.ResponseWriter, id string, request cybersourceRequest) {
+	if request.OrderInformation == nil || request.OrderInformation.AmountDetails.AdditionalAmount == "" {
+		cybersourceInvalid(w, http.StatusBadRequest, "MISSING_FIELD", "orderInformation.amountDetails.additionalAmount")
+		return
+	}
+	amount, err := decimalToMinorUnits(request.OrderInformation.AmountDetails.AdditionalAmount)
+	if err != nil {
+		cybersourceInvalid(w, http.StatusBadRequest, "INVALID_AMOUNT", "orderInformation.amountDetails.additionalAmount")
+		return
+	}
+	p, err := fake.ledger.increment(id, amount, request.OrderInformation.AmountDetails.Currency)
+	if err != nil {
+		fake.refused(w, err)
+		return
+	}
+	increment := fake.record(&cybersourceTransaction{
+		id:          fake.nextReference("6%021d"),
+		parent:      p.reference,
+		application: cybersourceAuth,
+		succeeded:   true,
+		amount:      amount,
+		currency:    p.currency,
+		code:        p.merchantReference,
+		last4:       p.last4,
+	})
+	writeJSON(w, http.StatusCreated, fake.response(increment, "AUTHORIZED", map[string]interface{}{
+		"orderInformation": map[string]interface{}{
+			"amountDetails": map[string]string{"authorizedAmount": minorUnitsToDecimal(p.authorized), "currency": p.currency},
+		},
+	}))
+}
+
+// followOn captures, refunds, voids or reverses a payment. The payment can be given by the ID of its authorization
+// or of any of its follow-on transactions.
+func (fake *cybersource) followOn(w http.ResponseWriter, id string, action string, request cybersourceRequest) {
+	amount, currency := int64(-1), ""
+	details := request.OrderInformation
+	if action == "reversals" && request.ReversalInformation != nil {
+		details = &cybersourceOrder{AmountDetails: request.ReversalInformation.AmountDetails}
+	}
+	if details != nil && details.AmountDetails.TotalAmount != "" {
+		parsed, err := decimalToMinorUnits(details.AmountDetails.TotalAmount)
+		if err != nil {
+			cybersourceInvalid(w, http.StatusBadRequest, "INVALID_AMOUNT", "orderInformation.amountDetails.totalAmount")
+			return
+		}
+		amount, currency = parsed, details.AmountDetails.Currency
+	} else if action == "captures" || action == "refunds" {
+		cybersourceInvalid(w, http.StatusBadRequest, "MISSING_FIELD", "orderInformation.amountDetails.totalAmount")
+		return
+	}
+
+	var p payment
+	var err error
+	var application, status string
+	before, _ := fake.ledger.get(id)
+	switch action {
+	case "captures":
+		application, status = cybersourceBill, "PENDING"
+		p, err = fake.ledger.multiCapture(id, amount, currency)
+		amount = p.captured - before.captured
+	case "refunds":
+		application, status = cybersourceCredit, "PENDING"
+		p, err = fake.ledger.refund(id, amount, currency)
+		amount = p.refunded - before.refunded
+	case "voids":
+		// a void cancels a capture or refund until it settles, or the authorization itself
+		application, status = cybersourceVoid, "VOIDED"
+		p, err = fake.ledger.apply(id, "", func(p *payment) error {
+			if p.state != stateAuthorized && p.state != stateCaptured && p.state != stateRefunded {
+				return errInvalidState
+			}
+			p.state = stateVoided
+			return nil
+		})
+		amount = 0
+	case "reversals":
+		application, status = cybersourceAuthReversal, "REVERSED"
+		p, err = fake.ledger.void(id)
+	}
+	if err != nil {
+		fake.refused(w, err)
+		return
+	}
+
+	transaction := fake.record(&cybersourceTransaction{
+		id:          fake.nextReference("6%021d"),
+		parent:      p.reference,
+		application: application,
+		succeeded:   true,
+		amount:      amount,
+		currency:    p.currency,
+		code:        p.merchantReference,
+		last4:       p.last4,
+	})
+	if request.ClientReferenceInformation != nil {
+		transaction.code = request.ClientReferenceInformation.Code
+	}
+	fake.ledger.alias(transaction.id, p.reference)
+
+	extra := map[string]interface{}{"reconciliationId": fake.nextReference("%016d")}
+	if amount > 0 {
+		extra["orderInformation"] = map[string]interface{}{
+			"amountDetails": map[string]string{"totalAmount": minorUnitsToDecimal(amount), "currency": p.currency},
+		}
+	}
+	writeJSON(w, http.StatusCreated, fake.response(transaction, status, extra))
+}
+
+// timeoutReversal reverses the authorization made with the merchant's transaction ID, whose outcome the merchant
+// doesn't know. CyberSource answers MISSING_AUTH when no approved authorization was made with the ID.
+func (fake *cybersource) timeoutReversal(w http.ResponseWriter, r *http.Request) {
+	var request cybersourceRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		cybersourceInvalid(w, http.StatusBadRequest, "INVALID_DATA", "")
+		return
+	}
+	if request.ClientReferenceInformation == nil || request.ClientReferenceInformation.TransactionID == "" {
+		cybersourceInvalid(w, http.StatusBadRequest, "MISSING_FIELD", "clientReferenceInformation.transactionId")
+		return
+	}
+	fake.mu.Lock()
+	var id string
+	for _, transaction := range fake.order {
+		if transaction.application == cybersourceAuth && transaction.succeeded && transaction.transactionID == request.ClientReferenceInformation.TransactionID {
+			id = transaction.id
+		}
+	}
+	fake.mu.Unlock()
+	if id == "" {
+		cybersourceInvalid(w, http.StatusBadRequest, "MISSING_AUTH", "")
+		return
+	}
+	fake.followOn(w, id, "reversals", request)
+}
+
+// details returns a transaction, linking an authorization to its follow-on transactions and those to their
+// authorization
+func (fake *cybersource) details(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/tss/v2/transactions/")
+	fake.mu.Lock()
+	transaction, ok := fake.transactions[id]
+	var related []string
+	if ok && transaction.parent != "" {
+		related = []string{transaction.parent}
+	} else if ok {
+		related = append(related, fake.related[id]...)
+	}
+	fake.mu.Unlock()
+	if !ok {
+		cybersourceInvalid(w, http.StatusNotFound, "NOT_FOUND", "")
+		return
+	}
+	body := fake.summary(transaction)
+	links := map[string]interface{}{"self": cybersourceLink(id)}
+	if len(related) > 0 {
+		relatedLinks := make([]map[string]string, 0, len(related))
+		for _, relatedID := range related {
+			relatedLinks = append(relatedLinks, cybersourceLink(relatedID))
+		}
+		links["relatedTransactions"] = relatedLinks
+	}
+	body["_links"] = links
+	writeJSON(w, http.StatusOK, body)
+}
+
+// search finds the transactions with a client reference code, most recent first. Only queries on the code are
+// supported.
+func (fake *cybersource) search(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Query string `json:"query"`
+		Limit int    `json:"limit"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		cybersourceInvalid(w, http.StatusBadRequest, "INVALID_DATA", "")
+		return
+	}
+	const prefix = "clientReferenceInformation.code:"
+	if !strings.HasPrefix(request.Query, prefix) {
+		cybersourceInvalid(w, http.StatusBadRequest, "INVALID_DATA", "query")
+		return
+	}
+	code := strings.TrimPrefix(request.Query, prefix)
+	fake.mu.Lock()
+	var found []*cybersourceTransaction
+	for _, transaction := range fake.order {
+		if transaction.code == code {
+			found = append(found, transaction)
+		}
+	}
+	fake.mu.Unlock()
+	sort.SliceStable(found, func(i, j int) bool { return found[i].submitted.After(found[j].submitted) })
+	if request.Limit > 0 && len(found) > request.Limit {
+		found = found[:request.Limit]
+	}
+	summaries := make([]map[string]interface{}, 0, len(found))
+	for _, transaction := range found {
+		summaries = append(summaries, fake.summary(transaction))
+	}
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"id":         fake.nextReference("%08d-search"),
+		"totalCount": len(summaries),
+		"_embedded":  map[string]interface{}{"transactionSummaries": summaries},
+	})
+}
+
+// record keeps a transaction, relating a follow-on transaction to its authorization, whose card it was made with
+func (fake *cybersource) record(transaction *cybersourceTransaction) *cybersourceTransaction {
+	transaction.submitted = time.Now().UTC()
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	fake.transactions[transaction.id] = transaction
+	fake.order = append(fake.order, transaction)
+	if parent, ok := fake.transactions[transaction.parent]; ok {
+		transaction.cardType = parent.cardType
+		fake.related[parent.id] = append(fake.related[parent.id], transaction.id)
+	}
+	return transaction
+}
+
+// response is the body CyberSource answers a payment request with, the fields depending on the request are added
+func (fake *cybersource) response(transaction *cybersourceTransaction, status string, fields map[string]interface{}) map[string]interface{} {
+	response := map[string]interface{}{
+		"id":                         transaction.id,
+		"submitTimeUtc":              transaction.submitted.Format(time.RFC3339),
+		"status":                     status,
+		"clientReferenceInformation": map[string]string{"code": transaction.code},
+		"_links":                     map[string]interface{}{"self": cybersourceLink(transaction.id)},
+	}
+	for name, value := range fields {
+		response[name] = value
+	}
+	return response
+}
+
+// summary describes a transaction as the Transaction Details and Search APIs do
+func (fake *cybersource) summary(transaction *cybersourceTransaction) map[string]interface{} {
+	rCode, status := "1", "TRANSMITTED"
+	if !transaction.succeeded {
+		rCode, status = "0", "DECLINED"
+	} else if transaction.application == cybersourceAuth {
+		status = "AUTHORIZED"
+	}
+	return map[string]interface{}{
+		"id":            transaction.id,
+		"submitTimeUTC": transaction.submitted.Format(time.RFC3339),
+		"applicationInformation": map[string]interface{}{
+			"status":       status,
+			"reasonCode":   "100",
+			"applications": []map[string]string{{"name": transaction.application, "rCode": rCode, "rFlag": "SOK"}},
+		},
+		"clientReferenceInformation": map[string]string{"code": transaction.code},
+		"orderInformation": map[string]interface{}{
+			"amountDetails": map[string]string{"totalAmount": minorUnitsToDecimal(transaction.amount), "currency": transaction.currency},
+		},
+		"paymentInformation": map[string]interface{}{
+			"card": map[string]string{"suffix": transaction.last4, "type": transaction.cardType},
+		},
+	}
+}
+
+// refused answers a follow-on transaction the payment doesn't allow
+func (fake *cybersource) refused(w http.ResponseWriter, err error) {
+	switch err {
+	case errUnknownPayment:
+		cybersourceInvalid(w, http.StatusNotFound, "NOT_FOUND", "")
+	case errAmountExceeded:
+		cybersourceInvalid(w, http.StatusBadRequest, "EXCEEDS_AUTH_AMOUNT", "orderInformation.amountDetails.totalAmount")
+	case errCurrencyMismatch:
+		cybersourceInvalid(w, http.StatusBadRequest, "INVALID_DATA", "orderInformation.amountDetails.currency")
+	default:
+		cybersourceInvalid(w, http.StatusBadRequest, "INVALID_REQUEST", "")
+	}
+}
+
+// cybersourceCardType returns CyberSource's code for the card's network
+func cybersourceCardType(number string) string {
+	switch {
+	case strings.HasPrefix(number, "4"):
+		return "001"
+	case strings.HasPrefix(number, "5"), strings.HasPrefix(number, "2"):
+		return "002"
+	case strings.HasPrefix(number, "34"), strings.HasPrefix(number, "37"):
+		return "003"
+	case strings.HasPrefix(number, "6"):
+		return "004"
+	}
+	return ""
+}
+
+func cybersourceLink(id string) map[string]string {
+	return map[string]string{"href": "/tss/v2/transactions/" + id, "method": "GET"}
+}
+
+// cybersourceInvalid answers a request CyberSource rejects, naming the field at fault when there's one
+func cybersourceInvalid(w http.ResponseWriter, statusCode int, reason string, field string) {
+	body := map[string]interface{}{
+		"submitTimeUtc": time.Now().UTC().Format(time.RFC3339),
+		"status":        "INVALID_REQUEST",
+		"reason":        reason,
+		"message":       "Declined - One or more fields in the request contains invalid data",
+	}
+	if statusCode == http.StatusNotFound {
+		body["status"], body["message"] = "NOT_FOUND", "The requested resource does not exist"
+	}
+	if field != "" {
+		body["details"] = []map[string]string{{"field": field, "reason": reason}}
+	}
+	writeJSON(w, statusCode, body)
+}
@@ -0,0 +1,68 @@
+// Package fakes runs local stand-ins for the PsP APIs that sleet's gateways call, so gateways can be tested end to end
+// without credentials or network access. Each fake is an httptest server which speaks its PsP's wire protocol. It checks
+// the credentials or signature and the required fields of every request, and answers the way the PsP's sandbox does,
+// keeping the payments it has seen so that captures, voids and refunds are checked against them.
+//
+// Gateways keep their usual URLs. The http client from Server.Client sends every request to the fake instead:
+//
+//	fake := fakes.NewStripe()
+//	defer fake.Close()
+//	client := stripe.NewWithHTTPClient(fakes.StripeAPIKey, fake.Client())
+//
+// Since the fakes serve their PsP's paths, a client can instead be pointed at a fake's URL with sleet.WithBaseURL
+// and the common.Custom environment.
+//
+// Operations the PsP handles asynchronously, such as Adyen's modifications, are answered at once with the outcome the
+// PsP would report later.
+package fakes
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+)
+
+// Server is a fake PsP listening on a local address
+type Server struct {
+	*httptest.Server
+	mu       sync.Mutex
+	sequence int
+	ledger   *ledger
+}
+
+// newServer starts a fake whose handler is built with the server, so it can use the server's ledger and references
+func newServer(handler func(server *Server) http.Handler) *Server {
+	server := &Server{ledger: newLedger()}
+	server.Server = httptest.NewServer(handler(server))
+	return server
+}
+
+// Client returns an http client which sends every request to the fake, whatever the URL's host, so gateways can be
+// given it without changing their URLs. The request's Host header still names the PsP host the gateway addressed.
+func (server *Server) Client() *http.Client {
+	target, _ := url.Parse(server.URL)
+	return &http.Client{Transport: &redirectTransport{target: target, base: server.Server.Client().Transport}}
+}
+
+// nextReference returns the next reference in the PsP's format, numbered in the order requests arrive
+func (server *Server) nextReference(format string) string {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.sequence++
+	return fmt.Sprintf(format, server.sequence)
+}
+
+// redirectTransport rewrites the scheme and host of every request to the fake's
+type redirectTransport struct {
+	target *url.URL
+	base   http.RoundTripper
+}
+
+func (transport *redirectTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	redirected := request.Clone(request.Context())
+	redirected.URL.Scheme = transport.target.Scheme
+	redirected.URL.Host = transport.target.Host
+	return transport.base.RoundTrip(redirected)
+}
@@ -0,0 +1,462 @@
+package fakes
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Credentials accepted by the First Data fake, and the card it declines as the issuer would with response code 05
+const (
+	FirstdataApiKey       = "fakefirstdataapikey"
+	FirstdataApiSecret    = "fakefirstdataapisecret"
+	FirstdataDeclinedCard = "4000000000000002"
+)
+
+// firstdataSignatureLifetime is how long a signed request stays valid after its timestamp
+const firstdataSignatureLifetime = 5 * time.Minute
+
+// The merchant and terminal of the fake's store, returned on every transaction as First Data does
+const (
+	firstdataMerchantID = "939650001885"
+	firstdataTerminalID = "1588390"
+)
+
+type firstdataRequest struct {
+	RequestType       string `json:"requestType"`
+	TransactionAmount struct {
+		Total    string `json:"total"`
+		Currency string `json:"currency"`
+	} `json:"transactionAmount"`
+	PaymentMethod struct {
+		PaymentCard struct {
+			Number       string `json:"number"`
+			SecurityCode string `json:"securityCode"`
+			ExpiryDate   struct {
+				Month string `json:"month"`
+				Year  string `json:"year"`
+			} `json:"expiryDate"`
+		} `json:"paymentCard"`
+	} `json:"paymentMethod"`
+}
+
+// firstdataCard is the masked card First Data returns with every transaction made with it
+type firstdataCard struct {
+	bin   string
+	last4 string
+	brand string
+	month string
+	year  string
+}
+
+// firstdataTransaction is a transaction as First Data lists it. Captures keep the id of their authorization, while
+// voids and returns are transactions of their own.
+type firstdataTransaction struct {
+	id                string
+	payment           string
+	orderID           string
+	transactionType   string
+	status            string
+	amount            int64
+	currency          string
+	card              firstdataCard
+	authorizationCode string
+	responseCode      string
+	responseMessage   string
+	securityCode      string
+	time              time.Time
+}
+
+// firstdata keeps the transactions by id besides the ledger, as First Data looks them up
+type firstdata struct {
+	*Server
+	mu           sync.Mutex
+	transactions map[string]*firstdataTransaction
+}
+
+// NewFirstdata starts a fake of First Data's payments API. Every request must carry the fake's API key and be signed
+// with its secret. Authorizations are captured once, and captures can be refunded several times until they are used up.
+func NewFirstdata() *Server {
+	return newServer(func(server *Server) http.Handler {
+		fake := &firstdata{Server: server, transactions: make(map[string]*firstdataTransaction)}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/gateway/v2/payments", fake.primary)
+		mux.HandleFunc("/gateway/v2/payments/", fake.secondary)
+		return firstdataSigned(mux)
+	})
+}
+
+// firstdataSigned checks the API key and the message signature of every request, which covers the key, the client
+// request id, the timestamp and the body
+func firstdataSigned(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			firstdataError(w, http.StatusBadRequest, r.Header.Get("Client-Request-Id"), "BadRequest", "400", "The request body could not be read", nil)
+			return
+		}
+		requestID := r.Header.Get("Client-Request-Id")
+		timestamp := r.Header.Get("Timestamp")
+		if r.Header.Get("Api-Key") != FirstdataApiKey {
+			firstdataUnauthenticated(w, requestID, "401", "Invalid ApiKey for given resource")
+			return
+		}
+		if requestID == "" {
+			firstdataUnauthenticated(w, requestID, "401", "Client-Request-Id is required")
+			return
+		}
+		seconds, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			firstdataUnauthenticated(w, requestID, "401", "Timestamp is invalid")
+			return
+		}
+		if age := time.Since(time.Unix(seconds, 0)); age > firstdataSignatureLifetime || age < -firstdataSignatureLifetime {
+			firstdataUnauthenticated(w, requestID, "403", "Message Signature has expired")
+			return
+		}
+		mac := hmac.New(sha256.New, []byte(FirstdataApiSecret))
+		mac.Write([]byte(FirstdataApiKey + requestID + timestamp + string(body)))
+		expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+		if !hmac.Equal([]byte(r.Header.Get("Message-Signature")), []byte(expected)) {
+			firstdataUnauthenticated(w, requestID, "403", "Message Signature is invalid")
+			return
+		}
+		r.Body = ioutil.NopCloser(strings.NewReader(string(body)))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// primary authorizes a payment, capturing it at once for a sale
+func (fake *firstdata) primary(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get("Client-Request-Id")
+	request, ok := firstdataDecode(w, r)
+	if !ok {
+		return
+	}
+	if request.RequestType != "PaymentCardPreAuthTransaction" && request.RequestType != "PaymentCardSaleTransaction" {
+		firstdataInvalid(w, requestID, "requestType", "Unsupported request type for a primary transaction")
+		return
+	}
+	card := request.PaymentMethod.PaymentCard
+	amount, err := decimalToMinorUnits(request.TransactionAmount.Total)
+	month, monthErr := strconv.Atoi(card.ExpiryDate.Month)
+	year, yearErr := strconv.Atoi(card.ExpiryDate.Year)
+	switch {
+	case err != nil:
+		firstdataInvalid(w, requestID, "transactionAmount.total", "must be a positive decimal amount")
+		return
+	case len(request.TransactionAmount.Currency) != 3:
+		firstdataInvalid(w, requestID, "transactionAmount.currency", "must be an ISO 4217 currency code")
+		return
+	case len(card.Number) < 12 || !luhnValid(card.Number):
+		firstdataInvalid(w, requestID, "paymentMethod.paymentCard.number", "Invalid card number")
+		return
+	case monthErr != nil || month < 1 || month > 12:
+		firstdataInvalid(w, requestID, "paymentMethod.paymentCard.expiryDate.month", "must be between 1 and 12")
+		return
+	case yearErr != nil || len(card.ExpiryDate.Year) != 2:
+		firstdataInvalid(w, requestID, "paymentMethod.paymentCard.expiryDate.year", "must be the last 2 digits of the year")
+		return
+	}
+
+	transaction := &firstdataTransaction{
+		id:       fake.nextReference("845%08d"),
+		orderID:  fake.nextReference("R-fake-%08d"),
+		currency: strings.ToUpper(request.TransactionAmount.Currency),
+		amount:   amount,
+		card: firstdataCard{
+			bin:   card.Number[:6],
+			last4: last4(card.Number),
+			brand: firstdataBrand(card.Number),
+			month: card.ExpiryDate.Month,
+			year:  strconv.Itoa(2000 + year),
+		},
+		securityCode: "NOT_PRESENT",
+		time:         time.Now(),
+	}
+	transaction.payment = transaction.id
+	if card.SecurityCode != "" {
+		transaction.securityCode = "MATCHED"
+	}
+	p := &payment{
+		reference:         transaction.id,
+		merchantReference: transaction.orderID,
+		currency:          transaction.currency,
+		authorized:        amount,
+		last4:             transaction.card.last4,
+	}
+	switch {
+	case card.Number == FirstdataDeclinedCard:
+		transaction.status, transaction.responseCode, transaction.responseMessage = "DECLINED", "05", "DO NOT HONOR"
+		p.state = stateDeclined
+	default:
+		transaction.status, transaction.responseCode, transaction.responseMessage = "APPROVED", "00", "APPROVAL"
+		transaction.authorizationCode = fake.nextReference("OK%04d")
+		p.state = stateAuthorized
+	}
+	transaction.transactionType = "PREAUTH"
+	if request.RequestType == "PaymentCardSaleTransaction" {
+		transaction.transactionType = "SALE"
+		if p.state == stateAuthorized {
+			p.state, p.captured = stateCaptured, amount
+		}
+	}
+	p.code = transaction.responseCode
+	fake.ledger.add(p)
+	fake.record(transaction)
+
+	body := fake.response(requestID, *transaction, amount)
+	if transaction.status == "DECLINED" {
+		body["responseType"] = "GatewayDeclined"
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+// secondary captures, voids or refunds a payment by the id of its authorization, or retrieves a transaction
+func (fake *firstdata) secondary(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get("Client-Request-Id")
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/gateway/v2/payments/"), "/")
+	fake.mu.Lock()
+	original, ok := fake.transactions[id]
+	var transaction firstdataTransaction
+	if ok {
+		transaction = *original
+	}
+	fake.mu.Unlock()
+	if !ok {
+		firstdataError(w, http.StatusNotFound, requestID, "NotFound", "404", "Transaction "+id+" not found", nil)
+		return
+	}
+	if r.Method == http.MethodGet {
+		fake.query(w, requestID, transaction)
+		return
+	}
+
+	request, ok := firstdataDecode(w, r)
+	if !ok {
+		return
+	}
+	if transaction.payment != transaction.id {
+		firstdataDeclined(w, requestID, "Secondary transactions can only reference a PREAUTH or SALE transaction")
+		return
+	}
+	amount := int64(-1)
+	if request.TransactionAmount.Total != "" {
+		parsed, err := decimalToMinorUnits(request.TransactionAmount.Total)
+		if err != nil {
+			firstdataInvalid(w, requestID, "transactionAmount.total", "must be a positive decimal amount")
+			return
+		}
+		amount = parsed
+	}
+	currency := request.TransactionAmount.Currency
+
+	var p payment
+	var err error
+	switch request.RequestType {
+	case "PostAuthTransaction":
+		p, err = fake.ledger.capture(id, amount, currency)
+		transaction.transactionType = "POSTAUTH"
+		transaction.amount = p.captured
+	case "VoidTransaction":
+		// First Data voids captures too, as long as they haven't settled
+		p, err = fake.ledger.apply(id, "", func(p *payment) error {
+			if (p.state != stateAuthorized && p.state != stateCaptured) || p.settled {
+				return errInvalidState
+			}
+			p.state = stateVoided
+			return nil
+		})
+		transaction.transactionType = "VOID"
+	case "ReturnTransaction":
+		var before payment
+		before, _ = fake.ledger.get(id)
+		p, err = fake.ledger.refund(id, amount, currency)
+		transaction.transactionType = "RETURN"
+		transaction.amount = p.refunded - before.refunded
+	default:
+		firstdataInvalid(w, requestID, "requestType", "Unsupported request type for a secondary transaction")
+		return
+	}
+	switch err {
+	case nil:
+	case errAmountExceeded:
+		firstdataDeclined(w, requestID, "The amount exceeds the amount available on the transaction")
+		return
+	case errCurrencyMismatch:
+		firstdataInvalid(w, requestID, "transactionAmount.currency", "must match the currency of the transaction")
+		return
+	default:
+		firstdataDeclined(w, requestID, "The transaction is not in a state allowing a "+transaction.transactionType)
+		return
+	}
+
+	if transaction.transactionType != "POSTAUTH" {
+		transaction.id = fake.nextReference("845%08d")
+		transaction.authorizationCode = ""
+		if transaction.transactionType == "VOID" {
+			transaction.authorizationCode = fake.nextReference("OK%04d")
+		}
+		fake.ledger.alias(transaction.id, id)
+		fake.record(&transaction)
+	}
+	transaction.status, transaction.responseCode, transaction.responseMessage = "APPROVED", "00", "APPROVAL"
+	transaction.time = time.Now()
+	writeJSON(w, http.StatusOK, fake.response(requestID, transaction, transaction.amount))
+}
+
+// query answers the transaction with its current state. An authorization's state follows its captures and voids, while
+// a return stays captured.
+func (fake *firstdata) query(w http.ResponseWriter, requestID string, transaction firstdataTransaction) {
+	p, _ := fake.ledger.get(transaction.payment)
+	amount := transaction.amount
+	state := "CAPTURED"
+	if transaction.id == transaction.payment {
+		switch p.state {
+		case stateAuthorized:
+			state = "AUTHORIZED"
+		case stateVoided:
+			state = "VOIDED"
+		case stateDeclined:
+			state = "DECLINED"
+		default:
+			amount = p.captured
+		}
+	} else if transaction.transactionType == "VOID" {
+		state = "VOIDED"
+	}
+	body := fake.response(requestID, transaction, amount)
+	body["transactionState"] = state
+	writeJSON(w, http.StatusOK, body)
+}
+
+func (fake *firstdata) record(transaction *firstdataTransaction) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	fake.transactions[transaction.id] = transaction
+}
+
+// response is the transaction response First Data answers every transaction with
+func (fake *firstdata) response(requestID string, transaction firstdataTransaction, amount int64) map[string]interface{} {
+	processor := map[string]interface{}{
+		"referenceNumber": transaction.id + " ",
+		"responseCode":    transaction.responseCode,
+		"responseMessage": transaction.responseMessage,
+		"network":         transaction.card.brand,
+		"avsResponse": map[string]string{
+			"streetMatch":     "NO_INPUT_DATA",
+			"postalCodeMatch": "NO_INPUT_DATA",
+		},
+	}
+	if transaction.authorizationCode != "" {
+		processor["authorizationCode"] = transaction.authorizationCode
+	}
+	if transaction.transactionType == "PREAUTH" || transaction.transactionType == "SALE" {
+		processor["securityCodeResponse"] = transaction.securityCode
+		processor["associationResponseCode"] = "0" + transaction.responseCode
+	}
+	body := map[string]interface{}{
+		"clientRequestId":   requestID,
+		"apiTraceId":        fake.nextReference("rrt-fake%016x-1"),
+		"ipgTransactionId":  transaction.id,
+		"orderId":           transaction.orderID,
+		"transactionType":   transaction.transactionType,
+		"transactionOrigin": "ECOM",
+		"paymentMethodDetails": map[string]interface{}{
+			"paymentCard": map[string]interface{}{
+				"expiryDate": map[string]string{"month": transaction.card.month, "year": transaction.card.year},
+				"bin":        transaction.card.bin,
+				"last4":      transaction.card.last4,
+				"brand":      transaction.card.brand,
+			},
+			"paymentMethodType": "PAYMENT_CARD",
+		},
+		"terminalId":      firstdataTerminalID,
+		"merchantId":      firstdataMerchantID,
+		"transactionTime": transaction.time.Unix(),
+		"approvedAmount": map[string]interface{}{
+			"total":      minorUnitsToFloat(amount),
+			"currency":   transaction.currency,
+			"components": map[string]float64{"subtotal": minorUnitsToFloat(amount)},
+		},
+		"transactionStatus": transaction.status,
+		"processor":         processor,
+	}
+	if transaction.status == "DECLINED" {
+		body["approvedAmount"] = map[string]interface{}{"total": 0, "currency": transaction.currency}
+	}
+	return body
+}
+
+// firstdataDecode decodes the body of a transaction request
+func firstdataDecode(w http.ResponseWriter, r *http.Request) (firstdataRequest, bool) {
+	var request firstdataRequest
+	if r.Method != http.MethodPost {
+		firstdataError(w, http.StatusMethodNotAllowed, r.Header.Get("Client-Request-Id"), "BadRequest", "405", "Method not allowed", nil)
+		return request, false
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		firstdataInvalid(w, r.Header.Get("Client-Request-Id"), "", "The request body is not valid JSON")
+		return request, false
+	}
+	return request, true
+}
+
+// firstdataBrand returns the brand First Data reports for a card number
+func firstdataBrand(number string) string {
+	switch {
+	case strings.HasPrefix(number, "4"):
+		return "VISA"
+	case strings.HasPrefix(number, "5"), strings.HasPrefix(number, "2"):
+		return "MASTERCARD"
+	case strings.HasPrefix(number, "34"), strings.HasPrefix(number, "37"):
+		return "AMEX"
+	case strings.HasPrefix(number, "35"):
+		return "JCB"
+	case strings.HasPrefix(number, "6"):
+		return "DISCOVER"
+	}
+	return ""
+}
+
+func firstdataUnauthenticated(w http.ResponseWriter, requestID string, code string, message string) {
+	writeJSON(w, http.StatusUnauthorized, map[string]interface{}{
+		"clientRequestId": requestID,
+		"responseType":    "Unauthenticated",
+		"error":           map[string]string{"code": code, "message": message},
+	})
+}
+
+// firstdataInvalid answers a request failing validation, naming the field at fault
+func firstdataInvalid(w http.ResponseWriter, requestID string, field string, message string) {
+	var details []map[string]string
+	if field != "" {
+		details = []map[string]string{{"field": field, "message": message}}
+	}
+	firstdataError(w, http.StatusBadRequest, requestID, "BadRequest", "400", "The request is invalid", details)
+}
+
+// firstdataDeclined answers a secondary transaction the gateway refuses, such as capturing more than was authorized
+func firstdataDeclined(w http.ResponseWriter, requestID string, message string) {
+	writeJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{
+		"clientRequestId":   requestID,
+		"responseType":      "GatewayDeclined",
+		"transactionStatus": "VALIDATION_FAILED",
+		"error":             map[string]string{"code": "422", "message": message},
+	})
+}
+
+func firstdataError(w http.ResponseWriter, statusCode int, requestID string, responseType string, code string, message string, details []map[string]string) {
+	writeJSON(w, statusCode, map[string]interface{}{
+		"clientRequestId": requestID,
+		"responseType":    responseType,
+		"error":           map[string]interface{}{"code": code, "message": message, "details": details},
+	})
+}
@@ -0,0 +1,221 @@
+package fakes
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// States of a payment kept by a fake
+const (
+	stateAuthorized = "authorized"
+	stateCaptured   = "captured"
+	stateVoided     = "voided"
+	stateRefunded   = "refunded"
+	stateDeclined   = "declined"
+)
+
+// Reasons the ledger refuses an operation, which each fake answers in its PsP's format
+var (
+	errUnknownPayment   = errors.New("unknown payment")
+	errInvalidState     = errors.New("payment is not in a state allowing the operation")
+	errAmountExceeded   = errors.New("amount exceeds what is left of the payment")
+	errCurrencyMismatch = errors.New("currency does not match the payment")
+)
+
+// payment is an authorization as a PsP records it
+type payment struct {
+	reference         string
+	merchantReference string
+	state             string
+	currency          string
+	authorized        int64
+	captured          int64
+	refunded          int64
+	last4             string
+	code              string // the PsP's result or decline code
+	settled           bool
+	credit            bool     // a refund the PsP records as a transaction of its own
+	refunds           []string // references of the payment's refunds, for PsPs listing them
+	createdAt         time.Time
+}
+
+// ledger holds the payments of a fake. Operations on a payment, such as a capture, may have their own references, which
+// the ledger resolves to the payment so the PsP can be given either.
+type ledger struct {
+	mu       sync.Mutex
+	payments map[string]*payment
+	aliases  map[string]string
+	order    []*payment
+}
+
+func newLedger() *ledger {
+	return &ledger{payments: make(map[string]*payment), aliases: make(map[string]string)}
+}
+
+// add records a payment, approved or declined
+func (l *ledger) add(p *payment) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if p.createdAt.IsZero() {
+		p.createdAt = time.Now().UTC()
+	}
+	l.payments[p.reference] = p
+	l.order = append(l.order, p)
+}
+
+// alias lets an operation's reference stand for its payment
+func (l *ledger) alias(reference string, paymentReference string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.aliases[reference] = paymentReference
+}
+
+// get returns a copy of the payment with the reference, or of the payment an operation's reference belongs to
+func (l *ledger) get(reference string) (payment, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	p, ok := l.lookup(reference)
+	if !ok {
+		return payment{}, false
+	}
+	return *p, true
+}
+
+// byMerchantReference returns copies of the payments made with the merchant's reference, most recent first
+func (l *ledger) byMerchantReference(merchantReference string) []payment {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var payments []payment
+	for i := len(l.order) - 1; i >= 0; i-- {
+		if l.order[i].merchantReference == merchantReference {
+			payments = append(payments, *l.order[i])
+		}
+	}
+	return payments
+}
+
+// capture captures the amount of an authorized payment, or all of it when the amount is negative. A payment is
+// captured once.
+func (l *ledger) capture(reference string, amount int64, currency string) (payment, error) {
+	return l.apply(reference, currency, func(p *payment) error {
+		if p.state != stateAuthorized {
+			return errInvalidState
+		}
+		if amount < 0 {
+			amount = p.authorized
+		}
+		if amount > p.authorized {
+			return errAmountExceeded
+		}
+		p.captured = amount
+		p.state = stateCaptured
+		return nil
+	})
+}
+
+// multiCapture captures the amount of an authorized payment, or all that is left of it when the amount is negative,
+// for PsPs allowing several captures. The payment stays open to captures until its authorized amount is captured.
+func (l *ledger) multiCapture(reference string, amount int64, currency string) (payment, error) {
+	return l.apply(reference, currency, func(p *payment) error {
+		if p.state != stateAuthorized && (p.state != stateCaptured || p.captured == p.authorized) {
+			return errInvalidState
+		}
+		available := p.authorized - p.captured
+		if amount < 0 {
+			amount = available
+		}
+		if amount > available {
+			return errAmountExceeded
+		}
+		p.captured += amount
+		p.state = stateCaptured
+		return nil
+	})
+}
+
+// void cancels an authorized payment which has not been captured
+func (l *ledger) void(reference string) (payment, error) {
+	return l.apply(reference, "", func(p *payment) error {
+		if p.state != stateAuthorized {
+			return errInvalidState
+		}
+		p.state = stateVoided
+		return nil
+	})
+}
+
+// refund refunds the amount of a captured payment, or all that is left of it when the amount is negative. A payment
+// can be refunded several times.
+func (l *ledger) refund(reference string, amount int64, currency string) (payment, error) {
+	return l.apply(reference, currency, func(p *payment) error {
+		if p.state != stateCaptured && p.state != stateRefunded {
+			return errInvalidState
+		}
+		available := p.captured - p.refunded
+		if amount < 0 {
+			amount = available
+		}
+		if amount > available {
+			return errAmountExceeded
+		}
+		p.refunded += amount
+		p.state = stateRefunded
+		return nil
+	})
+}
+
+// increment raises the amount of an authorized payment
+func (l *ledger) increment(reference string, amount int64, currency string) (payment, error) {
+	return l.apply(reference, currency, func(p *payment) error {
+		if p.state != stateAuthorized {
+			return errInvalidState
+		}
+		p.authorized += amount
+		return nil
+	})
+}
+
+// apply changes the payment with the reference, checking the currency when one is given
+func (l *ledger) apply(reference string, currency string, change func(p *payment) error) (payment, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	p, ok := l.lookup(reference)
+	if !ok {
+		return payment{}, errUnknownPayment
+	}
+	if currency != "" && !strings.EqualFold(currency, p.currency) {
+		return *p, errCurrencyMismatch
+	}
+	if err := change(p); err != nil {
+		return *p, err
+	}
+	return *p, nil
+}
+
+// lookup finds a payment by its reference or an operation's, the caller must hold the lock
+func (l *ledger) lookup(reference string) (*payment, bool) {
+	if paymentReference, ok := l.aliases[reference]; ok {
+		reference = paymentReference
+	}
+	p, ok := l.payments[reference]
+	return p, ok
+}
+
+// last4 returns the last four digits of a card number
+func last4(number string) string {
+	if len(number) < 4 {
+		return number
+	}
+	return number[len(number)-4:]
+}
+
+// expired reports whether a card expiring at the end of the month is expired
+func expired(month int, year int) bool {
+	if year < 100 {
+		year += 2000
+	}
+	now := time.Now()
+	return year < now.Year() || (year == now.Year() && month < int(now.Month()))
+}
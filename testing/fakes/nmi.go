@@ -0,0 +1,414 @@
+package fakes
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NMISecurityKey is the security key accepted by the NMI fake
+const NMISecurityKey = "fake_nmi_security_key"
+
+// NMI's test mode declines authorizations and sales of less than $1.00
+const nmiDeclineUnder = 100
+
+// nmiAction is an action taken on a transaction, as the Query API lists it
+type nmiAction struct {
+	Amount     string `xml:"amount"`
+	ActionType string `xml:"action_type"`
+	Date       string `xml:"date"`
+	Success    string `xml:"success"`
+}
+
+// nmiCustomer is a card stored in the Customer Vault
+type nmiCustomer struct {
+	id         string
+	firstName  string
+	lastName   string
+	number     string
+	expiration string
+}
+
+// nmi keeps the actions taken on each transaction and the Customer Vault besides the ledger
+type nmi struct {
+	*Server
+	mu        sync.Mutex
+	actions   map[string][]nmiAction
+	customers map[string]nmiCustomer
+}
+
+// NewNMI starts a fake of NMI's Direct Post API, which takes form posts and answers with form encoded responses, and
+// its Query API, which answers with XML. Transactions can be refunded before they settle, as in NMI's test mode.
+func NewNMI() *Server {
+	return newServer(func(server *Server) http.Handler {
+		fake := &nmi{
+			Server:    server,
+			actions:   make(map[string][]nmiAction),
+			customers: make(map[string]nmiCustomer),
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/api/transact.php", fake.transact)
+		mux.HandleFunc("/api/query.php", fake.query)
+		return mux
+	})
+}
+
+func (fake *nmi) transact(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.ParseForm() != nil {
+		nmiRespond(w, "3", "300", "Invalid request", nil)
+		return
+	}
+	form := r.PostForm
+	if form.Get("security_key") != NMISecurityKey {
+		nmiRespond(w, "3", "300", "Authentication Failed", nil)
+		return
+	}
+
+	switch form.Get("customer_vault") {
+	case "add_customer":
+		fake.addCustomer(w, form)
+		return
+	case "delete_customer":
+		fake.mu.Lock()
+		_, ok := fake.customers[form.Get("customer_vault_id")]
+		delete(fake.customers, form.Get("customer_vault_id"))
+		fake.mu.Unlock()
+		if !ok {
+			nmiRespond(w, "3", "300", "Invalid Customer Vault Id REFID:"+fake.nextReference("%09d"), nil)
+			return
+		}
+		nmiRespond(w, "1", "100", "Customer Deleted", nil)
+		return
+	}
+
+	switch form.Get("type") {
+	case "auth", "sale", "validate":
+		fake.authorize(w, form)
+	case "capture", "void", "refund":
+		fake.followOn(w, form)
+	default:
+		nmiRespond(w, "3", "300", "Invalid Transaction Type REFID:"+fake.nextReference("%09d"), nil)
+	}
+}
+
+func (fake *nmi) authorize(w http.ResponseWriter, form url.Values) {
+	transactionType := form.Get("type")
+	number, expiration := form.Get("ccnumber"), form.Get("ccexp")
+	if vaultID := form.Get("customer_vault_id"); vaultID != "" {
+		fake.mu.Lock()
+		customer, ok := fake.customers[vaultID]
+		fake.mu.Unlock()
+		if !ok {
+			nmiRespond(w, "3", "300", "Invalid Customer Vault Id REFID:"+fake.nextReference("%09d"), nil)
+			return
+		}
+		number, expiration = customer.number, customer.expiration
+	}
+	if !luhnValid(number) {
+		nmiRespond(w, "3", "300", "Invalid Credit Card Number REFID:"+fake.nextReference("%09d"), nil)
+		return
+	}
+	if !nmiValidExpiration(expiration) {
+		nmiRespond(w, "3", "300", "Invalid Credit Card Expiration REFID:"+fake.nextReference("%09d"), nil)
+		return
+	}
+	amount, err := decimalToMinorUnits(form.Get("amount"))
+	if err != nil || (transactionType == "validate" && amount != 0) {
+		nmiRespond(w, "3", "300", "Invalid amount REFID:"+fake.nextReference("%09d"), nil)
+		return
+	}
+	currency := strings.ToUpper(form.Get("currency"))
+	if currency == "" {
+		currency = "USD"
+	}
+
+	p := &payment{
+		reference:         fake.nextReference("%010d"),
+		merchantReference: form.Get("orderid"),
+		state:             stateAuthorized,
+		currency:          currency,
+		authorized:        amount,
+		last4:             last4(number),
+		code:              "100",
+	}
+	fields := url.Values{
+		"transactionid": {p.reference},
+		"type":          {transactionType},
+		"orderid":       {p.merchantReference},
+	}
+	if transactionType != "validate" && amount < nmiDeclineUnder {
+		p.state, p.code = stateDeclined, "200"
+		fake.ledger.add(p)
+		fake.record(p.reference, transactionType, amount, false)
+		nmiRespond(w, "2", "200", "DECLINE", fields)
+		return
+	}
+	if transactionType == "sale" {
+		p.state, p.captured = stateCaptured, amount
+	}
+	if transactionType == "validate" {
+		p.state = stateVoided
+	}
+	fake.ledger.add(p)
+	fake.record(p.reference, transactionType, amount, true)
+
+	avs := "N"
+	if zip := form.Get("zip"); zip != "" {
+		avs = "Z"
+		if form.Get("address1") != "" {
+			avs = "Y"
+		}
+	}
+	cvv := "P"
+	if form.Get("cvv") != "" {
+		cvv = "M"
+	}
+	fields.Set("authcode", "123456")
+	fields.Set("avsresponse", avs)
+	fields.Set("cvvresponse", cvv)
+	fields.Set("amount_authorized", minorUnitsToDecimal(amount))
+	nmiRespond(w, "1", "100", "SUCCESS", fields)
+}
+
+// followOn captures, voids or refunds a transaction. A capture or void keeps the transaction's ID, a refund is a
+// transaction of its own.
+func (fake *nmi) followOn(w http.ResponseWriter, form url.Values) {
+	transactionType, id := form.Get("type"), form.Get("transactionid")
+	amount := int64(-1)
+	if value := form.Get("amount"); value != "" {
+		parsed, err := decimalToMinorUnits(value)
+		if err != nil {
+			nmiRespond(w, "3", "300", "Invalid amount REFID:"+fake.nextReference("%09d"), nil)
+			return
+		}
+		amount = parsed
+	}
+
+	before, _ := fake.ledger.get(id)
+	var p payment
+	var err error
+	switch transactionType {
+	case "capture":
+		p, err = fake.ledger.capture(id, amount, "")
+		amount = p.captured
+	case "void":
+		// a void cancels a transaction until it settles, captured or not
+		p, err = fake.ledger.apply(id, "", func(p *payment) error {
+			if p.state != stateAuthorized && p.state != stateCaptured {
+				return errInvalidState
+			}
+			p.state = stateVoided
+			return nil
+		})
+		amount = p.authorized
+	case "refund":
+		p, err = fake.ledger.refund(id, amount, "")
+		amount = p.refunded - before.refunded
+	}
+	reference := fake.nextReference("%09d")
+	switch err {
+	case nil:
+	case errUnknownPayment:
+		nmiRespond(w, "3", "300", "Transaction not found REFID:"+reference, url.Values{"type": {transactionType}})
+		return
+	case errAmountExceeded:
+		available := p.authorized
+		if transactionType == "refund" {
+			available = p.captured - p.refunded
+		}
+		nmiRespond(w, "3", "300", fmt.Sprintf("The specified amount of %s exceeds the authorization amount of %s REFID:%s",
+			form.Get("amount"), minorUnitsToDecimal(available), reference), url.Values{"transactionid": {id}, "type": {transactionType}})
+		return
+	default:
+		nmiRespond(w, "3", "300", "A "+transactionType+" requires that the transaction be in a state allowing it REFID:"+reference,
+			url.Values{"transactionid": {id}, "type": {transactionType}})
+		return
+	}
+
+	transactionID := p.reference
+	if transactionType == "refund" {
+		credit := &payment{
+			reference:         fake.nextReference("%010d"),
+			merchantReference: p.merchantReference,
+			state:             stateCaptured,
+			currency:          p.currency,
+			authorized:        amount,
+			captured:          amount,
+			last4:             p.last4,
+			credit:            true,
+		}
+		fake.ledger.add(credit)
+		transactionID = credit.reference
+	}
+	fake.record(transactionID, transactionType, amount, true)
+	nmiRespond(w, "1", "100", "SUCCESS", url.Values{
+		"transactionid": {transactionID},
+		"type":          {transactionType},
+		"orderid":       {p.merchantReference},
+	})
+}
+
+func (fake *nmi) addCustomer(w http.ResponseWriter, form url.Values) {
+	if !luhnValid(form.Get("ccnumber")) {
+		nmiRespond(w, "3", "300", "Invalid Credit Card Number REFID:"+fake.nextReference("%09d"), nil)
+		return
+	}
+	if !nmiValidExpiration(form.Get("ccexp")) {
+		nmiRespond(w, "3", "300", "Invalid Credit Card Expiration REFID:"+fake.nextReference("%09d"), nil)
+		return
+	}
+	customer := nmiCustomer{
+		id:         fake.nextReference("%09d"),
+		firstName:  form.Get("first_name"),
+		lastName:   form.Get("last_name"),
+		number:     form.Get("ccnumber"),
+		expiration: form.Get("ccexp"),
+	}
+	fake.mu.Lock()
+	fake.customers[customer.id] = customer
+	fake.mu.Unlock()
+	nmiRespond(w, "1", "100", "Customer Added", url.Values{"customer_vault_id": {customer.id}})
+}
+
+// query answers the Query API with the transactions of an ID or an order ID, or a customer of the vault
+func (fake *nmi) query(w http.ResponseWriter, r *http.Request) {
+	type transaction struct {
+		TransactionID string      `xml:"transaction_id"`
+		OrderID       string      `xml:"order_id"`
+		Condition     string      `xml:"condition"`
+		CardNumber    string      `xml:"cc_number"`
+		CardType      string      `xml:"cc_type"`
+		Currency      string      `xml:"currency"`
+		Actions       []nmiAction `xml:"action"`
+	}
+	type customer struct {
+		ID              string `xml:"id,attr"`
+		CustomerVaultID string `xml:"customer_vault_id"`
+		FirstName       string `xml:"first_name"`
+		LastName        string `xml:"last_name"`
+		CardNumber      string `xml:"cc_number"`
+		CardExpiration  string `xml:"cc_exp"`
+		CardType        string `xml:"cc_type"`
+	}
+	type response struct {
+		XMLName       xml.Name      `xml:"nm_response"`
+		Customers     *[]customer   `xml:"customer_vault>customer"`
+		Transactions  []transaction `xml:"transaction"`
+		ErrorResponse string        `xml:"error_response,omitempty"`
+	}
+
+	if r.ParseForm() != nil || r.PostForm.Get("security_key") != NMISecurityKey {
+		writeXML(w, http.StatusOK, response{ErrorResponse: "Authentication Failed"})
+		return
+	}
+	form := r.PostForm
+	if form.Get("report_type") == "customer_vault" {
+		customers := []customer{}
+		fake.mu.Lock()
+		if stored, ok := fake.customers[form.Get("customer_vault_id")]; ok {
+			customers = append(customers, customer{
+				ID:              stored.id,
+				CustomerVaultID: stored.id,
+				FirstName:       stored.firstName,
+				LastName:        stored.lastName,
+				CardNumber:      nmiMasked(stored.number),
+				CardExpiration:  stored.expiration,
+				CardType:        "visa",
+			})
+		}
+		fake.mu.Unlock()
+		writeXML(w, http.StatusOK, response{Customers: &customers})
+		return
+	}
+
+	var found []payment
+	if id := form.Get("transaction_id"); id != "" {
+		if p, ok := fake.ledger.get(id); ok {
+			found = append(found, p)
+		}
+	} else if orderID := form.Get("order_id"); orderID != "" {
+		found = fake.ledger.byMerchantReference(orderID)
+	} else {
+		writeXML(w, http.StatusOK, response{ErrorResponse: "Invalid query"})
+		return
+	}
+	transactions := make([]transaction, 0, len(found))
+	fake.mu.Lock()
+	for _, p := range found {
+		transactions = append(transactions, transaction{
+			TransactionID: p.reference,
+			OrderID:       p.merchantReference,
+			Condition:     nmiCondition(p),
+			CardNumber:    "4xxxxxxxxxxx" + p.last4,
+			CardType:      "visa",
+			Currency:      strings.ToLower(p.currency),
+			Actions:       fake.actions[p.reference],
+		})
+	}
+	fake.mu.Unlock()
+	writeXML(w, http.StatusOK, response{Transactions: transactions})
+}
+
+// record adds an action to a transaction
+func (fake *nmi) record(transactionID string, actionType string, amount int64, succeeded bool) {
+	success := "0"
+	if succeeded {
+		success = "1"
+	}
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	fake.actions[transactionID] = append(fake.actions[transactionID], nmiAction{
+		Amount:     minorUnitsToDecimal(amount),
+		ActionType: actionType,
+		Date:       time.Now().UTC().Format("20060102150405"),
+		Success:    success,
+	})
+}
+
+// nmiCondition returns the condition the Query API gives a transaction, transactions never settle in the fake
+func nmiCondition(p payment) string {
+	switch p.state {
+	case stateAuthorized:
+		return "pending"
+	case stateVoided:
+		return "canceled"
+	case stateDeclined:
+		return "failed"
+	}
+	return "pendingsettlement"
+}
+
+// nmiValidExpiration checks an expiration date is formatted as MMYY
+func nmiValidExpiration(expiration string) bool {
+	if len(expiration) != 4 {
+		return false
+	}
+	month := (int(expiration[0]-'0'))*10 + int(expiration[1]-'0')
+	return month >= 1 && month <= 12 && expiration[2] >= '0' && expiration[2] <= '9' && expiration[3] >= '0' && expiration[3] <= '9'
+}
+
+// nmiMasked masks all but the first and last four digits of a card number
+func nmiMasked(number string) string {
+	if len(number) < 8 {
+		return number
+	}
+	return number[:1] + strings.Repeat("x", len(number)-5) + number[len(number)-4:]
+}
+
+// nmiRespond writes a form encoded response, which NMI answers every transaction with a 200 status
+func nmiRespond(w http.ResponseWriter, response string, code string, text string, fields url.Values) {
+	body := url.Values{}
+	for name, values := range fields {
+		body[name] = values
+	}
+	body.Set("response", response)
+	body.Set("response_code", code)
+	body.Set("responsetext", text)
+	w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(body.Encode()))
+}
@@ -0,0 +1,405 @@
+package fakes
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Credentials accepted by the Orbital fake
+const (
+	OrbitalUsername   = "SLEETFAKE"
+	OrbitalPassword   = "fakeorbitalpassword"
+	OrbitalMerchantID = 123456
+)
+
+// OrbitalDeclinedCard is declined by the Orbital fake with RespCode 05, Do Not Honor
+const OrbitalDeclinedCard = "4000000000000002"
+
+// Authorizations for more than $1,000 are declined, or approved for $1,000 when partial authorizations are allowed
+const orbitalApprovalLimit = 100000
+
+// ProcStatus values of requests Orbital rejects before reaching the issuer
+const (
+	orbitalProcStatusSuccess        = "0"
+	orbitalProcStatusInvalidCard    = "841"
+	orbitalProcStatusInvalidExp     = "885"
+	orbitalProcStatusInvalidTx      = "881"
+	orbitalProcStatusInvalidAmount  = "9705"
+	orbitalProcStatusInvalidRequest = "9717"
+	orbitalProcStatusSecurity       = "20412"
+)
+
+var orbitalCurrencies = map[string]string{
+	"840": "USD",
+	"124": "CAD",
+	"826": "GBP",
+	"978": "EUR",
+}
+
+// orbitalRequestBody holds the fields of a NewOrder, MarkForCapture or Reversal request the fake reads
+type orbitalRequestBody struct {
+	XMLName                   xml.Name
+	OrbitalConnectionUsername string
+	OrbitalConnectionPassword string
+	IndustryType              string
+	MessageType               string
+	BIN                       string
+	MerchantID                string
+	TerminalID                string
+	AccountNum                string
+	Exp                       string
+	CurrencyCode              string
+	CardSecVal                string
+	TxRefNum                  string
+	AVSzip                    string
+	AVSaddress1               string
+	OrderID                   string
+	Amount                    string
+	PartialAuthInd            string
+}
+
+// orbitalResponseBody is the body of a response, named after the request it answers or QuickResp for a rejection
+type orbitalResponseBody struct {
+	XMLName             xml.Name
+	IndustryType        string `xml:"IndustryType,omitempty"`
+	MessageType         string `xml:"MessageType,omitempty"`
+	MerchantID          string `xml:"MerchantID,omitempty"`
+	TerminalID          string `xml:"TerminalID,omitempty"`
+	CardBrand           string `xml:"CardBrand,omitempty"`
+	AccountNum          string `xml:"AccountNum,omitempty"`
+	OrderID             string `xml:"OrderID,omitempty"`
+	TxRefNum            string `xml:"TxRefNum,omitempty"`
+	TxRefIdx            string `xml:"TxRefIdx,omitempty"`
+	Amount              string `xml:"Amount,omitempty"`
+	OutstandingAmt      string `xml:"OutstandingAmt,omitempty"`
+	ProcStatus          string `xml:"ProcStatus"`
+	ApprovalStatus      string `xml:"ApprovalStatus,omitempty"`
+	RespCode            string `xml:"RespCode,omitempty"`
+	AVSRespCode         string `xml:"AVSRespCode,omitempty"`
+	CVV2RespCode        string `xml:"CVV2RespCode,omitempty"`
+	AuthCode            string `xml:"AuthCode,omitempty"`
+	StatusMsg           string `xml:"StatusMsg"`
+	HostRespCode        string `xml:"HostRespCode,omitempty"`
+	PartialAuthOccurred string `xml:"PartialAuthOccurred,omitempty"`
+	RequestAmount       string `xml:"RequestAmount,omitempty"`
+	RedeemedAmount      string `xml:"RedeemedAmount,omitempty"`
+	RespTime            string `xml:"RespTime,omitempty"`
+}
+
+// orbital keeps the responses to traced requests and the capture count of each TxRefNum besides the ledger
+type orbital struct {
+	*Server
+	mu       sync.Mutex
+	traced   map[string][]byte
+	captures map[string]int
+}
+
+// NewOrbital starts a fake of Chase Orbital's XML interface. A request sent with a Trace-number header is answered with
+// the response to the first request of that trace number and merchant, as Orbital does for retries.
+func NewOrbital() *Server {
+	return newServer(func(server *Server) http.Handler {
+		fake := &orbital{Server: server, traced: make(map[string][]byte), captures: make(map[string]int)}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/authorize", fake.authorize)
+		return mux
+	})
+}
+
+func (fake *orbital) authorize(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/PTI80" ||
+		r.Header.Get("MIME-Version") != "1.1" || r.Header.Get("Document-type") != "Request" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	trace, merchant := r.Header.Get("Trace-number"), r.Header.Get("Merchant-Id")
+	if trace != "" && merchant == "" {
+		fake.respond(w, "", orbitalQuickResp(orbitalProcStatusInvalidRequest, "Merchant-Id header is required with Trace-number"))
+		return
+	}
+	traceKey := ""
+	if trace != "" {
+		traceKey = merchant + "/" + trace
+		fake.mu.Lock()
+		original, ok := fake.traced[traceKey]
+		fake.mu.Unlock()
+		if ok {
+			w.Header().Set("Content-Type", "application/PTI80")
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write(original)
+			return
+		}
+	}
+
+	var request struct {
+		XMLName xml.Name           `xml:"Request"`
+		Body    orbitalRequestBody `xml:",any"`
+	}
+	if err := xml.NewDecoder(r.Body).Decode(&request); err != nil {
+		fake.respond(w, traceKey, orbitalQuickResp(orbitalProcStatusInvalidRequest, "Error parsing XML request"))
+		return
+	}
+	body := request.Body
+	if body.OrbitalConnectionUsername != OrbitalUsername || body.OrbitalConnectionPassword != OrbitalPassword ||
+		body.MerchantID != strconv.Itoa(OrbitalMerchantID) {
+		fake.respond(w, traceKey, orbitalQuickResp(orbitalProcStatusSecurity, "Precondition Failed: Security Information is missing"))
+		return
+	}
+	if (body.BIN != "000001" && body.BIN != "000002") || body.TerminalID != "001" {
+		fake.respond(w, traceKey, orbitalQuickResp(orbitalProcStatusInvalidRequest, "Invalid BIN or TerminalID"))
+		return
+	}
+
+	switch {
+	case body.XMLName.Local == "NewOrder" && (body.MessageType == "A" || body.MessageType == "AC"):
+		fake.respond(w, traceKey, fake.newOrder(body))
+	case body.XMLName.Local == "NewOrder" && body.MessageType == "R":
+		fake.respond(w, traceKey, fake.refund(body))
+	case body.XMLName.Local == "MarkForCapture":
+		fake.respond(w, traceKey, fake.markForCapture(body))
+	case body.XMLName.Local == "Reversal":
+		fake.respond(w, traceKey, fake.reversal(body))
+	default:
+		fake.respond(w, traceKey, orbitalQuickResp(orbitalProcStatusInvalidRequest, "Unsupported request"))
+	}
+}
+
+// newOrder authorizes, or authorizes and captures, a card
+func (fake *orbital) newOrder(body orbitalRequestBody) orbitalResponseBody {
+	if !luhnValid(body.AccountNum) {
+		return orbitalQuickResp(orbitalProcStatusInvalidCard, "Error validating card/account number range")
+	}
+	if !orbitalValidExp(body.Exp) {
+		return orbitalQuickResp(orbitalProcStatusInvalidExp, "Invalid Expiration Date")
+	}
+	currency, ok := orbitalCurrencies[body.CurrencyCode]
+	if !ok {
+		return orbitalQuickResp(orbitalProcStatusInvalidRequest, "Invalid Currency Code")
+	}
+	amount, err := strconv.ParseInt(body.Amount, 10, 64)
+	if err != nil || amount < 0 || len(body.OrderID) == 0 || len(body.OrderID) > 22 {
+		return orbitalQuickResp(orbitalProcStatusInvalidRequest, "Invalid Amount or OrderID")
+	}
+
+	p := &payment{
+		reference:         fake.nextReference("5F%038X"),
+		merchantReference: body.OrderID,
+		state:             stateAuthorized,
+		currency:          currency,
+		authorized:        amount,
+		last4:             last4(body.AccountNum),
+		code:              "00",
+	}
+	response := orbitalResponseBody{
+		XMLName:        xml.Name{Local: "NewOrderResp"},
+		IndustryType:   body.IndustryType,
+		MessageType:    body.MessageType,
+		MerchantID:     body.MerchantID,
+		TerminalID:     body.TerminalID,
+		CardBrand:      orbitalCardBrand(body.AccountNum),
+		AccountNum:     strings.Repeat("X", len(body.AccountNum)-4) + p.last4,
+		OrderID:        body.OrderID,
+		TxRefNum:       p.reference,
+		TxRefIdx:       "0",
+		ProcStatus:     orbitalProcStatusSuccess,
+		ApprovalStatus: "1",
+		RespCode:       "00",
+		StatusMsg:      "Approved",
+		RespTime:       time.Now().Format("150405"),
+	}
+	switch {
+	case body.AccountNum == OrbitalDeclinedCard:
+		p.code = "05"
+	case amount > orbitalApprovalLimit && body.PartialAuthInd == "Y" && body.MessageType == "A":
+		p.code, p.authorized = "10", orbitalApprovalLimit
+		response.PartialAuthOccurred = "Y"
+		response.RequestAmount = strconv.FormatInt(amount, 10)
+		response.RedeemedAmount = strconv.FormatInt(orbitalApprovalLimit, 10)
+		response.StatusMsg = "Partial Approval"
+	case amount > orbitalApprovalLimit:
+		p.code = "05"
+	}
+	response.RespCode, response.HostRespCode = p.code, p.code
+	if p.code == "05" {
+		p.state = stateDeclined
+		response.ApprovalStatus, response.StatusMsg = "0", "Do Not Honor"
+		fake.ledger.add(p)
+		return response
+	}
+	if body.MessageType == "AC" {
+		p.state, p.captured = stateCaptured, p.authorized
+	}
+	fake.ledger.add(p)
+
+	response.AuthCode = "tst" + p.reference[len(p.reference)-3:]
+	response.AVSRespCode = "3"
+	if body.AVSzip != "" && body.AVSaddress1 != "" {
+		response.AVSRespCode = "H"
+	} else if body.AVSzip != "" {
+		response.AVSRespCode = "A"
+	}
+	if body.CardSecVal != "" {
+		response.CVV2RespCode = "M"
+	}
+	return response
+}
+
+// markForCapture captures an authorization, keeping its TxRefNum and raising its TxRefIdx
+func (fake *orbital) markForCapture(body orbitalRequestBody) orbitalResponseBody {
+	amount, err := strconv.ParseInt(body.Amount, 10, 64)
+	if err != nil {
+		return orbitalQuickResp(orbitalProcStatusInvalidAmount, "Invalid Amount")
+	}
+	p, err := fake.ledger.capture(body.TxRefNum, amount, "")
+	switch err {
+	case nil:
+	case errAmountExceeded:
+		return orbitalQuickResp(orbitalProcStatusInvalidAmount, "The amount to capture exceeds the authorized amount")
+	default:
+		return orbitalQuickResp(orbitalProcStatusInvalidTx, "Unable to process the Mark for Capture: invalid TxRefNum")
+	}
+	fake.mu.Lock()
+	fake.captures[p.reference]++
+	index := fake.captures[p.reference]
+	fake.mu.Unlock()
+	return orbitalResponseBody{
+		XMLName:        xml.Name{Local: "MarkForCaptureResp"},
+		MerchantID:     body.MerchantID,
+		TerminalID:     body.TerminalID,
+		OrderID:        body.OrderID,
+		TxRefNum:       p.reference,
+		TxRefIdx:       strconv.Itoa(index),
+		Amount:         strconv.FormatInt(amount, 10),
+		ProcStatus:     orbitalProcStatusSuccess,
+		ApprovalStatus: "1",
+		RespCode:       "00",
+		StatusMsg:      "Approved",
+		HostRespCode:   "00",
+		RespTime:       time.Now().Format("150405"),
+	}
+}
+
+// reversal voids an authorization, or a capture which has not been refunded
+func (fake *orbital) reversal(body orbitalRequestBody) orbitalResponseBody {
+	p, err := fake.ledger.apply(body.TxRefNum, "", func(p *payment) error {
+		if p.state != stateAuthorized && p.state != stateCaptured {
+			return errInvalidState
+		}
+		p.state = stateVoided
+		return nil
+	})
+	if err != nil {
+		return orbitalQuickResp(orbitalProcStatusInvalidTx, "Unable to process the Reversal: invalid TxRefNum")
+	}
+	return orbitalResponseBody{
+		XMLName:        xml.Name{Local: "ReversalResp"},
+		MerchantID:     body.MerchantID,
+		TerminalID:     body.TerminalID,
+		OrderID:        body.OrderID,
+		TxRefNum:       p.reference,
+		TxRefIdx:       "1",
+		OutstandingAmt: "0",
+		ProcStatus:     orbitalProcStatusSuccess,
+		StatusMsg:      "Approved",
+		RespTime:       time.Now().Format("150405"),
+	}
+}
+
+// refund refunds a captured transaction, as a transaction of its own with a new TxRefNum
+func (fake *orbital) refund(body orbitalRequestBody) orbitalResponseBody {
+	currency, ok := orbitalCurrencies[body.CurrencyCode]
+	if !ok {
+		return orbitalQuickResp(orbitalProcStatusInvalidRequest, "Invalid Currency Code")
+	}
+	amount, err := strconv.ParseInt(body.Amount, 10, 64)
+	if err != nil {
+		return orbitalQuickResp(orbitalProcStatusInvalidAmount, "Invalid Amount")
+	}
+	p, err := fake.ledger.refund(body.TxRefNum, amount, currency)
+	switch err {
+	case nil:
+	case errAmountExceeded:
+		return orbitalQuickResp(orbitalProcStatusInvalidAmount, "The amount to refund exceeds the captured amount")
+	default:
+		return orbitalQuickResp(orbitalProcStatusInvalidTx, "Unable to process the Refund: invalid TxRefNum")
+	}
+	reference := fake.nextReference("5F%038X")
+	fake.ledger.alias(reference, p.reference)
+	return orbitalResponseBody{
+		XMLName:        xml.Name{Local: "NewOrderResp"},
+		IndustryType:   body.IndustryType,
+		MessageType:    body.MessageType,
+		MerchantID:     body.MerchantID,
+		TerminalID:     body.TerminalID,
+		OrderID:        body.OrderID,
+		TxRefNum:       reference,
+		TxRefIdx:       "1",
+		ProcStatus:     orbitalProcStatusSuccess,
+		ApprovalStatus: "1",
+		RespCode:       "00",
+		StatusMsg:      "Approved",
+		HostRespCode:   "00",
+		RespTime:       time.Now().Format("150405"),
+	}
+}
+
+// respond writes the response, keeping it for retries of a traced request
+func (fake *orbital) respond(w http.ResponseWriter, traceKey string, body orbitalResponseBody) {
+	var buffer bytes.Buffer
+	buffer.WriteString(xml.Header)
+	_ = xml.NewEncoder(&buffer).Encode(struct {
+		XMLName xml.Name `xml:"Response"`
+		Body    orbitalResponseBody
+	}{Body: body})
+	if traceKey != "" {
+		fake.mu.Lock()
+		fake.traced[traceKey] = buffer.Bytes()
+		fake.mu.Unlock()
+	}
+	w.Header().Set("Content-Type", "application/PTI80")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buffer.Bytes())
+}
+
+// orbitalQuickResp returns the QuickResp Orbital rejects a request with
+func orbitalQuickResp(procStatus string, message string) orbitalResponseBody {
+	return orbitalResponseBody{XMLName: xml.Name{Local: "QuickResp"}, ProcStatus: procStatus, StatusMsg: message}
+}
+
+// orbitalValidExp checks an expiration date is formatted as MMYY or YYYYMM
+func orbitalValidExp(exp string) bool {
+	if _, err := strconv.Atoi(exp); err != nil {
+		return false
+	}
+	var month int
+	switch len(exp) {
+	case 4:
+		month, _ = strconv.Atoi(exp[:2])
+	case 6:
+		month, _ = strconv.Atoi(exp[4:])
+	default:
+		return false
+	}
+	return month >= 1 && month <= 12
+}
+
+// orbitalCardBrand returns the CardBrand of a card number
+func orbitalCardBrand(number string) string {
+	switch {
+	case strings.HasPrefix(number, "4"):
+		return "VI"
+	case strings.HasPrefix(number, "5"), strings.HasPrefix(number, "2"):
+		return "MC"
+	case strings.HasPrefix(number, "34"), strings.HasPrefix(number, "37"):
+		return "AX"
+	case strings.HasPrefix(number, "6"):
+		return "DI"
+	case strings.HasPrefix(number, "35"):
+		return "JC"
+	}
+	return ""
+}
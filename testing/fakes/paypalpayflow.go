@@ -0,0 +1,380 @@
+package fakes
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Credentials accepted by the PayPal Payflow fake
+const (
+	PaypalPayflowPartner  = "PayPal"
+	PaypalPayflowVendor   = "sleetfake"
+	PaypalPayflowUser     = "sleetfake"
+	PaypalPayflowPassword = "fake-payflow-password"
+)
+
+// Authorizations for more than $1,000 are declined, or approved for $1,000 when partial authorizations are allowed
+const payflowApprovalLimit = 100000
+
+// payflowTransaction is a transaction as an inquiry reports it. Captures, voids and credits are transactions of their
+// own, with their own PNREF, on the payment they belong to.
+type payflowTransaction struct {
+	pnref    string
+	payment  string
+	trxType  string
+	result   string
+	amount   int64
+	cardType string
+	last4    string
+	time     time.Time
+}
+
+// payflow keeps the transactions of every PNREF besides the ledger
+type payflow struct {
+	*Server
+	mu           sync.Mutex
+	transactions map[string]payflowTransaction
+}
+
+// NewPaypalPayflow starts a fake of the Payflow Pro gateway. Requests and responses are name-value pairs joined with
+// '&', where a name may carry the length of its value in brackets (NAME[5]=va&ue) so values can hold any character.
+func NewPaypalPayflow() *Server {
+	return newServer(func(server *Server) http.Handler {
+		fake := &payflow{Server: server, transactions: make(map[string]payflowTransaction)}
+		return http.HandlerFunc(fake.handle)
+	})
+}
+
+func (fake *payflow) handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	params, ok := parsePayflowParams(string(body))
+	if !ok {
+		payflowRespond(w, "7", "Field format error")
+		return
+	}
+	if params["PARTNER"] != PaypalPayflowPartner || params["VENDOR"] != PaypalPayflowVendor ||
+		params["USER"] != PaypalPayflowUser || params["PWD"] != PaypalPayflowPassword {
+		payflowRespond(w, "1", "User authentication failed")
+		return
+	}
+	if params["TRXTYPE"] != "I" && params["TENDER"] != "C" {
+		payflowRespond(w, "2", "Invalid tender")
+		return
+	}
+
+	switch params["TRXTYPE"] {
+	case "A", "S":
+		fake.authorize(w, params)
+	case "D", "V", "C":
+		fake.followOn(w, params)
+	case "I":
+		fake.inquire(w, params)
+	default:
+		payflowRespond(w, "3", "Invalid transaction type")
+	}
+}
+
+func (fake *payflow) authorize(w http.ResponseWriter, params map[string]string) {
+	number, expiration := params["ACCT"], params["EXPDATE"]
+	if !luhnValid(number) {
+		payflowRespond(w, "23", "Invalid account number")
+		return
+	}
+	if month, err := strconv.Atoi(expiration); len(expiration) != 4 || err != nil || month/100 < 1 || month/100 > 12 {
+		payflowRespond(w, "24", "Invalid expiration date")
+		return
+	}
+	amount, err := decimalToMinorUnits(params["AMT"])
+	if err != nil {
+		payflowRespond(w, "4", "Invalid amount")
+		return
+	}
+	currency := params["CURRENCY"]
+	if currency == "" {
+		currency = "USD"
+	}
+
+	transaction := payflowTransaction{
+		pnref:    fake.pnref(),
+		trxType:  params["TRXTYPE"],
+		result:   "0",
+		amount:   amount,
+		cardType: payflowCardType(number),
+		last4:    last4(number),
+		time:     time.Now(),
+	}
+	transaction.payment = transaction.pnref
+	message := "Approved"
+	if amount > payflowApprovalLimit {
+		if params["PARTIALAUTH"] != "Y" || transaction.trxType != "A" {
+			transaction.result = "12"
+		} else {
+			transaction.amount, message = payflowApprovalLimit, "Partial Approval"
+		}
+	}
+	p := &payment{
+		reference:  transaction.pnref,
+		state:      stateAuthorized,
+		currency:   currency,
+		authorized: transaction.amount,
+		last4:      transaction.last4,
+		code:       transaction.result,
+	}
+	switch {
+	case transaction.result != "0":
+		p.state = stateDeclined
+	case transaction.trxType == "S":
+		p.state, p.captured = stateCaptured, transaction.amount
+	}
+	fake.ledger.add(p)
+	fake.record(transaction)
+
+	if transaction.result != "0" {
+		payflowRespond(w, transaction.result, "Declined", "PNREF", transaction.pnref)
+		return
+	}
+	avs := "N"
+	if params["BILLTOSTREET"] != "" {
+		avs = "Y"
+	}
+	avsZip := "N"
+	if params["BILLTOZIP"] != "" {
+		avsZip = "Y"
+	}
+	payflowRespond(w, "0", message,
+		"PNREF", transaction.pnref,
+		"AUTHCODE", "010010",
+		"AVSADDR", avs,
+		"AVSZIP", avsZip,
+		"IAVS", "N",
+		"HOSTCODE", "A",
+		"AMT", minorUnitsToDecimal(transaction.amount),
+		"ACCT", transaction.last4,
+		"EXPDATE", expiration,
+		"CARDTYPE", transaction.cardType,
+		"TRANSTIME", payflowTransTime(transaction.time),
+	)
+}
+
+// followOn runs a delayed capture, a void or a referenced credit against the transaction named by ORIGID
+func (fake *payflow) followOn(w http.ResponseWriter, params map[string]string) {
+	trxType := params["TRXTYPE"]
+	original, ok := fake.transaction(params["ORIGID"])
+	if !ok {
+		payflowRespond(w, "19", "Original transaction ID not found: "+params["ORIGID"])
+		return
+	}
+	amount := int64(-1)
+	if params["AMT"] != "" {
+		parsed, err := decimalToMinorUnits(params["AMT"])
+		if err != nil {
+			payflowRespond(w, "4", "Invalid amount")
+			return
+		}
+		amount = parsed
+	}
+
+	var (
+		p   payment
+		err error
+	)
+	before, _ := fake.ledger.get(original.payment)
+	switch trxType {
+	case "D":
+		if original.trxType != "A" {
+			payflowRespond(w, "111", "Capture error: Only authorization transactions can be captured")
+			return
+		}
+		p, err = fake.ledger.capture(original.payment, amount, params["CURRENCY"])
+		amount = p.captured
+	case "V":
+		// an authorization is voided until it is captured, a capture or sale until it settles
+		p, err = fake.ledger.apply(original.payment, "", func(p *payment) error {
+			if original.trxType == "A" && p.state != stateAuthorized {
+				return errInvalidState
+			}
+			if original.trxType != "A" && (p.state != stateCaptured || p.settled) {
+				return errInvalidState
+			}
+			p.state = stateVoided
+			return nil
+		})
+		amount = original.amount
+	case "C":
+		if original.trxType != "D" && original.trxType != "S" {
+			payflowRespond(w, "105", "Credit error: Can not credit an authorization")
+			return
+		}
+		p, err = fake.ledger.refund(original.payment, amount, params["CURRENCY"])
+		amount = p.refunded - before.refunded
+	}
+	if err != nil {
+		result, operation := payflowFollowOnError(trxType)
+		reason := "Transaction is not in a state allowing it"
+		switch err {
+		case errAmountExceeded:
+			reason = "Amount exceeds original transaction amount"
+		case errCurrencyMismatch:
+			reason = "Currency does not match original transaction"
+		}
+		payflowRespond(w, result, operation+": "+reason)
+		return
+	}
+
+	transaction := payflowTransaction{
+		pnref:    fake.pnref(),
+		payment:  original.payment,
+		trxType:  trxType,
+		result:   "0",
+		amount:   amount,
+		cardType: original.cardType,
+		last4:    original.last4,
+		time:     time.Now(),
+	}
+	fake.ledger.alias(transaction.pnref, original.payment)
+	fake.record(transaction)
+	payflowRespond(w, "0", "Approved",
+		"PNREF", transaction.pnref,
+		"TRANSTIME", payflowTransTime(transaction.time),
+	)
+}
+
+// inquire reports the state of the transaction named by ORIGID. Payflow has no state for voided transactions, the
+// fake leaves TRANSSTATE out of their inquiries.
+func (fake *payflow) inquire(w http.ResponseWriter, params map[string]string) {
+	original, ok := fake.transaction(params["ORIGID"])
+	if !ok {
+		payflowRespond(w, "19", "Original transaction ID not found: "+params["ORIGID"])
+		return
+	}
+	p, _ := fake.ledger.get(original.payment)
+	pairs := []string{
+		"PNREF", fake.pnref(),
+		"ORIGPNREF", original.pnref,
+		"ORIGRESULT", original.result,
+		"AMT", minorUnitsToDecimal(original.amount),
+		"ACCT", original.last4,
+		"CARDTYPE", original.cardType,
+		"TRANSTIME", payflowTransTime(original.time),
+	}
+	switch {
+	case original.result != "0":
+		pairs = append(pairs, "TRANSSTATE", "12")
+	case p.state == stateVoided:
+	case original.trxType == "A" && p.state == stateAuthorized:
+		pairs = append(pairs, "TRANSSTATE", "3")
+	case original.trxType == "A":
+		pairs = append(pairs, "TRANSSTATE", "9")
+	default:
+		pairs = append(pairs, "TRANSSTATE", "6")
+	}
+	payflowRespond(w, "0", "Approved", pairs...)
+}
+
+func (fake *payflow) record(transaction payflowTransaction) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	fake.transactions[transaction.pnref] = transaction
+}
+
+func (fake *payflow) transaction(pnref string) (payflowTransaction, bool) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	transaction, ok := fake.transactions[pnref]
+	return transaction, ok
+}
+
+// pnref returns a PNREF, which Payflow makes 12 alphanumeric characters
+func (fake *payflow) pnref() string {
+	return fake.nextReference("A7%010X")
+}
+
+// payflowFollowOnError returns the RESULT and the message prefix of a failed capture, void or credit
+func payflowFollowOnError(trxType string) (string, string) {
+	switch trxType {
+	case "D":
+		return "111", "Capture error"
+	case "V":
+		return "108", "Void error"
+	}
+	return "105", "Credit error"
+}
+
+// payflowCardType returns the CARDTYPE of a card number
+func payflowCardType(number string) string {
+	switch {
+	case strings.HasPrefix(number, "4"):
+		return "0"
+	case strings.HasPrefix(number, "5"), strings.HasPrefix(number, "2"):
+		return "1"
+	case strings.HasPrefix(number, "6"):
+		return "2"
+	case strings.HasPrefix(number, "34"), strings.HasPrefix(number, "37"):
+		return "3"
+	case strings.HasPrefix(number, "35"):
+		return "5"
+	}
+	return "0"
+}
+
+// payflowTransTime formats a time as TRANSTIME, which Payflow gives in Pacific time
+func payflowTransTime(t time.Time) string {
+	if location, err := time.LoadLocation("America/Los_Angeles"); err == nil {
+		t = t.In(location)
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
+
+// parsePayflowParams parses name-value pairs, honoring the length tags of names. Names are case insensitive.
+func parsePayflowParams(body string) (map[string]string, bool) {
+	params := make(map[string]string)
+	for body != "" {
+		separator := strings.IndexByte(body, '=')
+		if separator < 1 {
+			return nil, false
+		}
+		name, rest := body[:separator], body[separator+1:]
+		var value string
+		if open := strings.IndexByte(name, '['); open > 0 && strings.HasSuffix(name, "]") {
+			length, err := strconv.Atoi(name[open+1 : len(name)-1])
+			if err != nil || length < 0 || length > len(rest) {
+				return nil, false
+			}
+			name, value, rest = name[:open], rest[:length], rest[length:]
+			if rest != "" && rest[0] != '&' {
+				return nil, false
+			}
+		} else if end := strings.IndexByte(rest, '&'); end >= 0 {
+			value, rest = rest[:end], rest[end:]
+		} else {
+			value, rest = rest, ""
+		}
+		params[strings.ToUpper(name)] = value
+		body = strings.TrimPrefix(rest, "&")
+	}
+	return params, true
+}
+
+// payflowRespond writes a response with its RESULT and RESPMSG followed by the given names and values
+func payflowRespond(w http.ResponseWriter, result string, message string, pairs ...string) {
+	fields := []string{"RESULT=" + result, "RESPMSG=" + message}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		fields = append(fields, pairs[i]+"="+pairs[i+1])
+	}
+	w.Header().Set("Content-Type", "text/namevalue")
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.WriteString(w, strings.Join(fields, "&"))
+}
@@ -0,0 +1,391 @@
+package fakes
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Credentials accepted by the RocketGate fake
+const (
+	RocketgateMerchantID       = "1"
+	RocketgateMerchantPassword = "fakerocketgatepassword"
+)
+
+// RocketGate's response codes
+const (
+	rocketgateSuccess      = "0"
+	rocketgateBankFail     = "1"
+	rocketgateRequestError = "4"
+)
+
+// RocketGate's reason codes
+const (
+	rocketgateReasonSuccess         = "0"
+	rocketgateReasonNoMatchingXact  = "100"
+	rocketgateReasonCannotVoid      = "101"
+	rocketgateReasonCannotCredit    = "102"
+	rocketgateReasonCannotTicket    = "103"
+	rocketgateReasonDeclined        = "104"
+	rocketgateReasonXMLError        = "400"
+	rocketgateReasonInvalidCardNo   = "403"
+	rocketgateReasonInvalidExpiry   = "404"
+	rocketgateReasonInvalidAmount   = "405"
+	rocketgateReasonInvalidMerchant = "406"
+	rocketgateReasonInvalidRefGUID  = "410"
+	rocketgateReasonInvalidAccess   = "411"
+)
+
+// RocketGate's dev gateway declines every transaction of one cent
+const rocketgateDeclinedAmount = 1
+
+// rocketgateTransaction is a transaction as a lookup reports it. Tickets, voids and credits are transactions of their
+// own, with their own GUID, on the payment they belong to.
+type rocketgateTransaction struct {
+	guid            string
+	payment         string
+	transactionType string
+	responseCode    string
+	reasonCode      string
+	amount          int64
+	currency        string
+	last4           string
+	cardType        string
+	expiration      string
+	confirmed       bool
+	time            time.Time
+}
+
+// rocketgate keeps the transactions of every GUID besides the ledger
+type rocketgate struct {
+	*Server
+	mu           sync.Mutex
+	transactions map[string]*rocketgateTransaction
+}
+
+// NewRocketgate starts a fake of the RocketGate gateway servlet, which the RocketGate SDK posts gatewayRequest XML
+// documents to. Authorizations and purchases are only final once the SDK confirms them with a CC_CONFIRM.
+func NewRocketgate() *Server {
+	return newServer(func(server *Server) http.Handler {
+		fake := &rocketgate{Server: server, transactions: make(map[string]*rocketgateTransaction)}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/gateway/servlet/ServiceDispatcherAccess", fake.dispatch)
+		return mux
+	})
+}
+
+func (fake *rocketgate) dispatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	fields, err := parseRocketgateRequest(r.Body)
+	if err != nil {
+		rocketgateRespond(w, rocketgateRequestError, rocketgateReasonXMLError, nil)
+		return
+	}
+	if fields["merchantID"] != RocketgateMerchantID {
+		rocketgateRespond(w, rocketgateRequestError, rocketgateReasonInvalidMerchant, nil)
+		return
+	}
+	if fields["merchantPassword"] != RocketgateMerchantPassword {
+		rocketgateRespond(w, rocketgateRequestError, rocketgateReasonInvalidAccess, nil)
+		return
+	}
+
+	switch transactionType := fields["transactionType"]; transactionType {
+	case "CC_AUTH", "CC_PURCHASE":
+		fake.authorize(w, transactionType, fields)
+	case "CC_CONFIRM":
+		fake.confirm(w, fields)
+	case "CC_TICKET", "CC_VOID", "CC_CREDIT":
+		fake.followOn(w, transactionType, fields)
+	case "LOOKUP":
+		fake.lookup(w, fields)
+	default:
+		rocketgateRespond(w, rocketgateRequestError, rocketgateReasonXMLError, nil)
+	}
+}
+
+func (fake *rocketgate) authorize(w http.ResponseWriter, transactionType string, fields map[string]string) {
+	number := fields["cardNo"]
+	if !luhnValid(number) {
+		rocketgateRespond(w, rocketgateRequestError, rocketgateReasonInvalidCardNo, nil)
+		return
+	}
+	month, monthErr := strconv.Atoi(fields["expireMonth"])
+	year, yearErr := strconv.Atoi(fields["expireYear"])
+	if monthErr != nil || yearErr != nil || month < 1 || month > 12 {
+		rocketgateRespond(w, rocketgateRequestError, rocketgateReasonInvalidExpiry, nil)
+		return
+	}
+	amount, err := decimalToMinorUnits(fields["amount"])
+	if err != nil || amount == 0 {
+		rocketgateRespond(w, rocketgateRequestError, rocketgateReasonInvalidAmount, nil)
+		return
+	}
+	currency := strings.ToUpper(fields["currency"])
+	if currency == "" {
+		currency = "USD"
+	}
+
+	transaction := &rocketgateTransaction{
+		guid:            fake.guid(),
+		transactionType: transactionType,
+		responseCode:    rocketgateSuccess,
+		reasonCode:      rocketgateReasonSuccess,
+		amount:          amount,
+		currency:        currency,
+		last4:           last4(number),
+		cardType:        rocketgateCardType(number),
+		expiration:      fmt.Sprintf("%02d%02d", month, year%100),
+		time:            time.Now(),
+	}
+	transaction.payment = transaction.guid
+	if amount == rocketgateDeclinedAmount {
+		transaction.responseCode, transaction.reasonCode = rocketgateBankFail, rocketgateReasonDeclined
+	}
+	p := &payment{
+		reference:         transaction.guid,
+		merchantReference: fields["merchantInvoiceID"],
+		state:             stateAuthorized,
+		currency:          currency,
+		authorized:        amount,
+		last4:             transaction.last4,
+		code:              transaction.reasonCode,
+	}
+	switch {
+	case transaction.responseCode != rocketgateSuccess:
+		p.state = stateDeclined
+	case transactionType == "CC_PURCHASE":
+		p.state, p.captured = stateCaptured, amount
+	}
+	fake.ledger.add(p)
+	fake.record(transaction)
+
+	if transaction.responseCode != rocketgateSuccess {
+		rocketgateRespond(w, transaction.responseCode, transaction.reasonCode, []string{"guidNo", transaction.guid})
+		return
+	}
+	cvv := ""
+	if fields["cvv2"] != "" {
+		cvv = "M"
+	}
+	avs := ""
+	if fields["billingZipCode"] != "" {
+		avs = "Z"
+		if fields["billingAddress"] != "" {
+			avs = "Y"
+		}
+	}
+	rocketgateRespond(w, rocketgateSuccess, rocketgateReasonSuccess, append(transaction.details(),
+		"authNo", "123456",
+		"avsResponse", avs,
+		"cvv2Code", cvv,
+		"merchantAccount", "1",
+	))
+}
+
+// confirm marks an approved authorization or purchase as confirmed by the SDK
+func (fake *rocketgate) confirm(w http.ResponseWriter, fields map[string]string) {
+	fake.mu.Lock()
+	transaction, ok := fake.transactions[fields["referenceGUID"]]
+	if ok && transaction.responseCode == rocketgateSuccess {
+		transaction.confirmed = true
+	}
+	fake.mu.Unlock()
+	if !ok || transaction.responseCode != rocketgateSuccess {
+		rocketgateRespond(w, rocketgateRequestError, rocketgateReasonNoMatchingXact, nil)
+		return
+	}
+	rocketgateRespond(w, rocketgateSuccess, rocketgateReasonSuccess, []string{"guidNo", transaction.guid})
+}
+
+// followOn tickets, voids or credits the transaction named by referenceGUID
+func (fake *rocketgate) followOn(w http.ResponseWriter, transactionType string, fields map[string]string) {
+	original, ok := fake.transaction(fields["referenceGUID"])
+	if !ok {
+		reason := rocketgateReasonNoMatchingXact
+		if _, err := strconv.ParseInt(fields["referenceGUID"], 16, 64); err != nil {
+			reason = rocketgateReasonInvalidRefGUID
+		}
+		rocketgateRespond(w, rocketgateRequestError, reason, nil)
+		return
+	}
+	amount := int64(-1)
+	if fields["amount"] != "" {
+		parsed, err := decimalToMinorUnits(fields["amount"])
+		if err != nil {
+			rocketgateRespond(w, rocketgateRequestError, rocketgateReasonInvalidAmount, nil)
+			return
+		}
+		amount = parsed
+	}
+
+	var (
+		p      payment
+		err    error
+		reason string
+	)
+	before, _ := fake.ledger.get(original.payment)
+	switch transactionType {
+	case "CC_TICKET":
+		reason = rocketgateReasonCannotTicket
+		if original.transactionType != "CC_AUTH" || !original.confirmed {
+			err = errInvalidState
+			break
+		}
+		p, err = fake.ledger.capture(original.payment, amount, fields["currency"])
+		amount = p.captured
+	case "CC_VOID":
+		// an authorization is voided until it is ticketed, a ticket or purchase until it is credited
+		reason = rocketgateReasonCannotVoid
+		p, err = fake.ledger.apply(original.payment, "", func(p *payment) error {
+			if original.transactionType == "CC_AUTH" && p.state != stateAuthorized {
+				return errInvalidState
+			}
+			if original.transactionType != "CC_AUTH" && p.state != stateCaptured {
+				return errInvalidState
+			}
+			p.state = stateVoided
+			return nil
+		})
+		amount = original.amount
+	case "CC_CREDIT":
+		reason = rocketgateReasonCannotCredit
+		p, err = fake.ledger.refund(original.payment, amount, fields["currency"])
+		amount = p.refunded - before.refunded
+	}
+	if err == errAmountExceeded {
+		reason = rocketgateReasonInvalidAmount
+	}
+	if err != nil {
+		rocketgateRespond(w, rocketgateRequestError, reason, nil)
+		return
+	}
+
+	transaction := &rocketgateTransaction{
+		guid:            fake.guid(),
+		payment:         original.payment,
+		transactionType: transactionType,
+		responseCode:    rocketgateSuccess,
+		reasonCode:      rocketgateReasonSuccess,
+		amount:          amount,
+		currency:        p.currency,
+		last4:           original.last4,
+		cardType:        original.cardType,
+		expiration:      original.expiration,
+		confirmed:       true,
+		time:            time.Now(),
+	}
+	fake.ledger.alias(transaction.guid, original.payment)
+	fake.record(transaction)
+	rocketgateRespond(w, rocketgateSuccess, rocketgateReasonSuccess, transaction.details())
+}
+
+// lookup reports the outcome of the transaction named by referenceGUID, with the codes it was answered with
+func (fake *rocketgate) lookup(w http.ResponseWriter, fields map[string]string) {
+	transaction, ok := fake.transaction(fields["referenceGUID"])
+	if !ok {
+		rocketgateRespond(w, rocketgateRequestError, rocketgateReasonNoMatchingXact, nil)
+		return
+	}
+	rocketgateRespond(w, transaction.responseCode, transaction.reasonCode, transaction.details())
+}
+
+// details returns the fields describing a transaction in a response
+func (transaction rocketgateTransaction) details() []string {
+	return []string{
+		"guidNo", transaction.guid,
+		"approvedAmount", minorUnitsToDecimal(transaction.amount),
+		"approvedCurrency", transaction.currency,
+		"cardType", transaction.cardType,
+		"cardLastFour", transaction.last4,
+		"cardExpiration", transaction.expiration,
+		"transactionTime", transaction.time.Format("2006-01-02 15:04:05"),
+	}
+}
+
+func (fake *rocketgate) record(transaction *rocketgateTransaction) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	fake.transactions[transaction.guid] = transaction
+}
+
+func (fake *rocketgate) transaction(guid string) (rocketgateTransaction, bool) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	transaction, ok := fake.transactions[guid]
+	if !ok {
+		return rocketgateTransaction{}, false
+	}
+	return *transaction, true
+}
+
+// guid returns a transaction GUID. Its top byte is the number of the site which processed it, which the SDK sends
+// follow-on transactions to, so every GUID names site 16.
+func (fake *rocketgate) guid() string {
+	return fake.nextReference("10%014X")
+}
+
+// rocketgateCardType returns the cardType of a card number
+func rocketgateCardType(number string) string {
+	switch {
+	case strings.HasPrefix(number, "4"):
+		return "VISA"
+	case strings.HasPrefix(number, "5"), strings.HasPrefix(number, "2"):
+		return "MC"
+	case strings.HasPrefix(number, "34"), strings.HasPrefix(number, "37"):
+		return "AMEX"
+	case strings.HasPrefix(number, "6"):
+		return "DISC"
+	case strings.HasPrefix(number, "35"):
+		return "JCB"
+	}
+	return ""
+}
+
+// parseRocketgateRequest reads the fields of a gatewayRequest document, which holds one element per field
+func parseRocketgateRequest(body io.Reader) (map[string]string, error) {
+	var document struct {
+		XMLName xml.Name `xml:"gatewayRequest"`
+		Fields  []struct {
+			XMLName xml.Name
+			Value   string `xml:",chardata"`
+		} `xml:",any"`
+	}
+	if err := xml.NewDecoder(body).Decode(&document); err != nil {
+		return nil, err
+	}
+	fields := make(map[string]string, len(document.Fields))
+	for _, field := range document.Fields {
+		fields[field.XMLName.Local] = strings.TrimSpace(field.Value)
+	}
+	return fields, nil
+}
+
+// rocketgateRespond writes a gatewayResponse document with the response and reason codes followed by the given
+// names and values, leaving out empty values as RocketGate does
+func rocketgateRespond(w http.ResponseWriter, responseCode string, reasonCode string, pairs []string) {
+	var body strings.Builder
+	body.WriteString(xml.Header)
+	body.WriteString("<gatewayResponse>\n")
+	pairs = append([]string{"version", "1.0", "responseCode", responseCode, "reasonCode", reasonCode}, pairs...)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] == "" {
+			continue
+		}
+		body.WriteString("<" + pairs[i] + ">")
+		_ = xml.EscapeText(&body, []byte(pairs[i+1]))
+		body.WriteString("</" + pairs[i] + ">\n")
+	}
+	body.WriteString("</gatewayResponse>")
+	w.Header().Set("Content-Type", "text/xml")
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.WriteString(w, body.String())
+}
@@ -0,0 +1,303 @@
+package fakes
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// StripeAPIKey is the secret key accepted by the Stripe fake
+const StripeAPIKey = "sk_test_fake"
+
+// stripeDecline is the error Stripe's test cards are declined with
+type stripeDecline struct {
+	code        string
+	declineCode string
+	message     string
+}
+
+// stripeTestCards are the card numbers Stripe's test mode declines, see https://stripe.com/docs/testing#cards-responses
+var stripeTestCards = map[string]stripeDecline{
+	"4000000000000002": {"card_declined", "generic_decline", "Your card was declined."},
+	"4000000000009995": {"card_declined", "insufficient_funds", "Your card has insufficient funds."},
+	"4000000000009987": {"card_declined", "lost_card", "Your card was declined."},
+	"4000000000009979": {"card_declined", "stolen_card", "Your card was declined."},
+	"4000000000000069": {"expired_card", "", "Your card has expired."},
+	"4000000000000127": {"incorrect_cvc", "", "Your card's security code is incorrect."},
+	"4000000000000119": {"processing_error", "", "An error occurred while processing your card. Try again in a little bit."},
+}
+
+// NewStripe starts a fake of Stripe's charges and refunds API, with raw card numbers allowed on charges. Partially
+// captured charges have the uncaptured remainder refunded, as on Stripe.
+func NewStripe() *Server {
+	return newServer(func(server *Server) http.Handler {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/v1/charges", server.stripeCreateCharge)
+		mux.HandleFunc("/v1/charges/", server.stripeCharge)
+		mux.HandleFunc("/v1/refunds", server.stripeRefund)
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			stripeError(w, http.StatusNotFound, "invalid_request_error", "", fmt.Sprintf("Unrecognized request URL (%s: %s).", r.Method, r.URL.Path), "")
+		})
+		return stripeAuthenticated(mux)
+	})
+}
+
+// stripeAuthenticated checks the secret key, which Stripe takes as a bearer token or the basic auth user
+func stripeAuthenticated(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, _, ok := r.BasicAuth()
+		if !ok {
+			key = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		}
+		if key != StripeAPIKey {
+			stripeError(w, http.StatusUnauthorized, "invalid_request_error", "", "Invalid API Key provided: "+maskKey(key), "")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (server *Server) stripeCreateCharge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.ParseForm() != nil {
+		stripeError(w, http.StatusBadRequest, "invalid_request_error", "", "Invalid request.", "")
+		return
+	}
+	amount, err := strconv.ParseInt(r.PostForm.Get("amount"), 10, 64)
+	currency := strings.ToLower(r.PostForm.Get("currency"))
+	switch {
+	case err != nil:
+		stripeError(w, http.StatusBadRequest, "invalid_request_error", "parameter_missing", "Missing required param: amount.", "")
+		return
+	case currency == "":
+		stripeError(w, http.StatusBadRequest, "invalid_request_error", "parameter_missing", "Missing required param: currency.", "")
+		return
+	case currency == "usd" && amount < 50:
+		stripeError(w, http.StatusBadRequest, "invalid_request_error", "amount_too_small", "Amount must be at least $0.50 usd", "")
+		return
+	}
+	if customer := r.PostForm.Get("customer"); customer != "" {
+		stripeError(w, http.StatusBadRequest, "invalid_request_error", "resource_missing", fmt.Sprintf("No such customer: '%s'", customer), "")
+		return
+	}
+	number := r.PostForm.Get("source[number]")
+	if number == "" {
+		stripeError(w, http.StatusBadRequest, "invalid_request_error", "parameter_missing", "Must provide source or customer.", "")
+		return
+	}
+	if !luhnValid(number) {
+		stripeError(w, http.StatusPaymentRequired, "card_error", "incorrect_number", "Your card number is incorrect.", "")
+		return
+	}
+
+	p := &payment{
+		reference:  server.nextReference("ch_%024d"),
+		state:      stateAuthorized,
+		currency:   currency,
+		authorized: amount,
+		last4:      last4(number),
+	}
+	if decline, ok := stripeTestCards[number]; ok {
+		p.state, p.code = stateDeclined, decline.code
+		server.ledger.add(p)
+		writeJSON(w, http.StatusPaymentRequired, map[string]interface{}{"error": map[string]string{
+			"charge":       p.reference,
+			"code":         decline.code,
+			"decline_code": decline.declineCode,
+			"message":      decline.message,
+			"type":         "card_error",
+		}})
+		return
+	}
+	if r.PostForm.Get("capture") != "false" {
+		p.state, p.captured = stateCaptured, amount
+	}
+	server.ledger.add(p)
+	writeJSON(w, http.StatusOK, stripeChargeObject(*p, r.PostForm.Get("source[cvc]") != ""))
+}
+
+// stripeCharge retrieves a charge, or captures it when posted to its capture path
+func (server *Server) stripeCharge(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/v1/charges/")
+	id, action := path, ""
+	if i := strings.Index(path, "/"); i >= 0 {
+		id, action = path[:i], path[i+1:]
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		p, ok := server.ledger.get(id)
+		if !ok {
+			stripeError(w, http.StatusNotFound, "invalid_request_error", "resource_missing", fmt.Sprintf("No such charge: '%s'", id), "id")
+			return
+		}
+		writeJSON(w, http.StatusOK, stripeChargeObject(p, true))
+	case action == "capture" && r.Method == http.MethodPost:
+		_ = r.ParseForm()
+		amount := int64(-1)
+		if value := r.PostForm.Get("amount"); value != "" {
+			amount, _ = strconv.ParseInt(value, 10, 64)
+		}
+		p, err := server.ledger.capture(id, amount, "")
+		switch err {
+		case nil:
+			writeJSON(w, http.StatusOK, stripeChargeObject(p, true))
+		case errUnknownPayment:
+			stripeError(w, http.StatusNotFound, "invalid_request_error", "resource_missing", fmt.Sprintf("No such charge: '%s'", id), "charge")
+		case errAmountExceeded:
+			stripeError(w, http.StatusBadRequest, "invalid_request_error", "amount_too_large", "The amount to capture must be less than or equal to the original amount.", "amount")
+		default:
+			if p.state == stateCaptured || p.state == stateRefunded {
+				stripeError(w, http.StatusBadRequest, "invalid_request_error", "charge_already_captured", fmt.Sprintf("Charge %s has already been captured.", id), "")
+				return
+			}
+			stripeError(w, http.StatusBadRequest, "invalid_request_error", "charge_already_refunded", fmt.Sprintf("Charge %s has already been refunded.", id), "")
+		}
+	default:
+		stripeError(w, http.StatusNotFound, "invalid_request_error", "", fmt.Sprintf("Unrecognized request URL (%s: %s).", r.Method, r.URL.Path), "")
+	}
+}
+
+// stripeRefund refunds a charge. Refunding an uncaptured charge in full releases it.
+func (server *Server) stripeRefund(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.ParseForm() != nil {
+		stripeError(w, http.StatusBadRequest, "invalid_request_error", "", "Invalid request.", "")
+		return
+	}
+	id := r.PostForm.Get("charge")
+	amount := int64(-1)
+	if value := r.PostForm.Get("amount"); value != "" {
+		amount, _ = strconv.ParseInt(value, 10, 64)
+	}
+
+	p, ok := server.ledger.get(id)
+	if !ok {
+		stripeError(w, http.StatusNotFound, "invalid_request_error", "resource_missing", fmt.Sprintf("No such charge: '%s'", id), "charge")
+		return
+	}
+	var err error
+	refunded := amount
+	switch p.state {
+	case stateAuthorized:
+		if amount >= 0 && amount != p.authorized {
+			stripeError(w, http.StatusBadRequest, "invalid_request_error", "", "You cannot partially refund an uncaptured charge. Instead, capture the charge for an amount less than the original amount.", "amount")
+			return
+		}
+		p, err = server.ledger.void(id)
+		refunded = p.authorized
+	case stateVoided:
+		err = errInvalidState
+	default:
+		before := p.refunded
+		p, err = server.ledger.refund(id, amount, "")
+		refunded = p.refunded - before
+	}
+	switch err {
+	case nil:
+	case errAmountExceeded:
+		stripeError(w, http.StatusBadRequest, "invalid_request_error", "amount_too_large",
+			fmt.Sprintf("Refund amount is greater than unrefunded amount on charge (%d)", p.captured-p.refunded), "amount")
+		return
+	default:
+		stripeError(w, http.StatusBadRequest, "invalid_request_error", "charge_already_refunded", fmt.Sprintf("Charge %s has already been refunded.", id), "")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"id":       server.nextReference("re_%024d"),
+		"object":   "refund",
+		"amount":   refunded,
+		"charge":   p.reference,
+		"currency": p.currency,
+		"status":   "succeeded",
+	})
+}
+
+// stripeChargeObject renders a payment as a charge. The cvc check passes whenever a CVC was given.
+func stripeChargeObject(p payment, cvc bool) map[string]interface{} {
+	status, captured := "succeeded", p.state == stateCaptured || p.state == stateRefunded
+	amountRefunded := p.refunded
+	switch p.state {
+	case stateDeclined:
+		status = "failed"
+	case stateVoided:
+		amountRefunded = p.authorized
+	case stateCaptured, stateRefunded:
+		// the uncaptured remainder of a partial capture is refunded
+		amountRefunded += p.authorized - p.captured
+	}
+	var cvcCheck interface{}
+	if cvc {
+		cvcCheck = "pass"
+	}
+	card := map[string]interface{}{
+		"id":                  "card_" + strings.TrimPrefix(p.reference, "ch_"),
+		"object":              "card",
+		"brand":               "Visa",
+		"last4":               p.last4,
+		"cvc_check":           cvcCheck,
+		"address_line1_check": nil,
+		"address_zip_check":   nil,
+	}
+	return map[string]interface{}{
+		"id":              p.reference,
+		"object":          "charge",
+		"amount":          p.authorized,
+		"amount_captured": p.captured,
+		"amount_refunded": amountRefunded,
+		"captured":        captured,
+		"created":         p.createdAt.Unix(),
+		"currency":        p.currency,
+		"paid":            p.state != stateDeclined,
+		"refunded":        p.state == stateVoided || (captured && amountRefunded == p.authorized),
+		"status":          status,
+		"failure_code":    p.code,
+		"source":          card,
+		"payment_method_details": map[string]interface{}{
+			"type": "card",
+			"card": map[string]interface{}{
+				"brand":  "visa",
+				"last4":  p.last4,
+				"checks": map[string]interface{}{"cvc_check": cvcCheck, "address_line1_check": nil, "address_postal_code_check": nil},
+			},
+		},
+	}
+}
+
+func stripeError(w http.ResponseWriter, statusCode int, errorType string, code string, message string, param string) {
+	body := map[string]string{"type": errorType, "message": message}
+	if code != "" {
+		body["code"] = code
+	}
+	if param != "" {
+		body["param"] = param
+	}
+	writeJSON(w, statusCode, map[string]interface{}{"error": body})
+}
+
+// maskKey hides all but the last four characters of a key, as Stripe does when rejecting it
+func maskKey(key string) string {
+	if len(key) <= 4 {
+		return key
+	}
+	return strings.Repeat("*", len(key)-4) + key[len(key)-4:]
+}
+
+// luhnValid checks a card number's check digit
+func luhnValid(number string) bool {
+	sum, double := 0, false
+	for i := len(number) - 1; i >= 0; i-- {
+		digit := int(number[i] - '0')
+		if digit < 0 || digit > 9 {
+			return false
+		}
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return len(number) >= 12 && sum%10 == 0
+}
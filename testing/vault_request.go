@@ -0,0 +1,33 @@
+package testing
+
+import (
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+)
+
+// BaseStorePaymentMethodRequest is used as a testing helper method to standardize vault calls, a new customer is created
+func BaseStorePaymentMethodRequest() *sleet.StorePaymentMethodRequest {
+	base := BaseAuthorizationRequest()
+	base.BillingAddress.Email = common.SPtr("test@bolt.com")
+	return &sleet.StorePaymentMethodRequest{
+		CreditCard:       base.CreditCard,
+		BillingAddress:   base.BillingAddress,
+		ShopperReference: base.ShopperReference,
+	}
+}
+
+// BaseStoredPaymentMethod references a stored payment method with PsP agnostic values
+func BaseStoredPaymentMethod() *sleet.StoredPaymentMethod {
+	return &sleet.StoredPaymentMethod{
+		CustomerReference: "333333",
+		Token:             "444444",
+	}
+}
+
+// BaseStoredPaymentMethodAuthorizationRequest authorizes BaseStoredPaymentMethod instead of a credit card
+func BaseStoredPaymentMethodAuthorizationRequest() *sleet.AuthorizationRequest {
+	base := BaseAuthorizationRequest()
+	base.CreditCard = nil
+	base.StoredPaymentMethod = BaseStoredPaymentMethod()
+	return base
+}
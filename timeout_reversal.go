@@ -0,0 +1,52 @@
+package sleet
+
+import "context"
+
+// TimeoutReverser is implemented by clients of PsPs which can reverse an authorization without its
+// TransactionReference, using only the references it was sent with. It is used when the outcome of an
+// authorization is unknown, so that a hold which may have been placed on the card is released.
+type TimeoutReverser interface {
+	ReverseTimedOutAuthorization(request *TimeoutReversalRequest) (*TimeoutReversalResponse, error)
+}
+
+// TimeoutReverserWithContext is a superset of `TimeoutReverser` that includes additional methods that take
+// `context.Context` as parameters.
+type TimeoutReverserWithContext interface {
+	TimeoutReverser
+	ReverseTimedOutAuthorizationWithContext(ctx context.Context, request *TimeoutReversalRequest) (*TimeoutReversalResponse, error)
+}
+
+// TimeoutReversalRequest identifies an authorization by the references and amount of its AuthorizationRequest.
+// AuthorizationRequest is only needed by PsPs which resolve the authorization by retrying it, such as Orbital.
+type TimeoutReversalRequest struct {
+	Amount                     Amount
+	ClientTransactionReference *string
+	MerchantOrderReference     *string
+	AuthorizationRequest       *AuthorizationRequest
+	Options                    map[string]interface{}
+}
+
+// NewTimeoutReversalRequest creates a TimeoutReversalRequest for the authorization request whose outcome is unknown
+func NewTimeoutReversalRequest(authRequest *AuthorizationRequest) *TimeoutReversalRequest {
+	request := &TimeoutReversalRequest{
+		Amount:                     authRequest.Amount,
+		ClientTransactionReference: authRequest.ClientTransactionReference,
+		AuthorizationRequest:       authRequest,
+		Options:                    authRequest.Options,
+	}
+	if authRequest.MerchantOrderReference != "" {
+		merchantOrderReference := authRequest.MerchantOrderReference
+		request.MerchantOrderReference = &merchantOrderReference
+	}
+	return request
+}
+
+// TimeoutReversalResponse indicates if the reversal was accepted by the PsP. PsPs accept timeout reversals for
+// authorizations they never received, so Success does not mean an authorization existed. NotAuthorized is set with
+// Success when the PsP resolved that the authorization was not approved, so there was nothing to reverse.
+type TimeoutReversalResponse struct {
+	Success              bool
+	NotAuthorized        bool
+	TransactionReference string
+	ErrorCode            *string
+}
@@ -0,0 +1,39 @@
+package sleet
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrClientReferenceRequired is returned by TransactionFinder implementations when the request is missing the
+// reference the PsP is searched by.
+var ErrClientReferenceRequired = errors.New("sleet: the reference sent to this PsP on authorization is required")
+
+// TransactionFinder is implemented by clients of PsPs which can search transactions by a merchant supplied reference.
+// It is meant for recovering from timeouts, when the PsP TransactionReference of an authorization is unknown.
+type TransactionFinder interface {
+	FindByClientReference(request *FindByClientReferenceRequest) (*FindByClientReferenceResponse, error)
+}
+
+// TransactionFinderWithContext is a superset of `TransactionFinder` that includes additional methods that take
+// `context.Context` as parameters.
+type TransactionFinderWithContext interface {
+	TransactionFinder
+	FindByClientReferenceWithContext(ctx context.Context, request *FindByClientReferenceRequest) (*FindByClientReferenceResponse, error)
+}
+
+// FindByClientReferenceRequest searches for transactions by the references given in the AuthorizationRequest.
+// PsPs are searched by whichever reference they were sent, see the documentation of each client.
+type FindByClientReferenceRequest struct {
+	ClientTransactionReference *string
+	MerchantOrderReference     *string
+	Options                    map[string]interface{}
+}
+
+// FindByClientReferenceResponse contains every transaction matching the reference, most recent first where the PsP
+// orders results. Success is true if the search succeeded, even if no transactions matched.
+type FindByClientReferenceResponse struct {
+	Success      bool
+	Transactions []TransactionQueryResponse
+	ErrorCode    *string
+}
@@ -0,0 +1,65 @@
+package sleet
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrTransactionQueryNotSupported is returned by TransactionQuerier implementations of PsPs which do not expose a
+// lookup API for a single transaction. Webhooks are the only source of transaction state for these PsPs.
+var ErrTransactionQueryNotSupported = errors.New("sleet: transaction query is not supported by this PsP")
+
+// TransactionQuerier is implemented by clients of PsPs which can look up a transaction by its reference, and returns its
+// current state at the PsP, mainly to reconcile transactions whose outcome is unknown to the caller.
+type TransactionQuerier interface {
+	QueryTransaction(request *TransactionQueryRequest) (*TransactionQueryResponse, error)
+}
+
+// TransactionQuerierWithContext is a superset of `TransactionQuerier` that includes additional methods that take
+// `context.Context` as parameters.
+type TransactionQuerierWithContext interface {
+	TransactionQuerier
+	QueryTransactionWithContext(ctx context.Context, request *TransactionQueryRequest) (*TransactionQueryResponse, error)
+}
+
+// TransactionState is the normalized state of a transaction at the PsP
+type TransactionState string
+
+// Transaction states, a transaction moves forward through authorized, captured and settled.
+// Refunded means some or all of the captured amount was refunded, RefundedAmount has the total.
+const (
+	TransactionStateUnknown    TransactionState = "unknown"
+	TransactionStateAuthorized TransactionState = "authorized"
+	TransactionStateCaptured   TransactionState = "captured"
+	TransactionStateSettled    TransactionState = "settled"
+	TransactionStateVoided     TransactionState = "voided"
+	TransactionStateRefunded   TransactionState = "refunded"
+	TransactionStateDeclined   TransactionState = "declined"
+)
+
+// TransactionQueryRequest looks up a transaction by the TransactionReference returned from Authorize
+type TransactionQueryRequest struct {
+	TransactionReference       string
+	ClientTransactionReference *string // Custom transaction reference metadata that will be associated with this request
+	Options                    map[string]interface{}
+}
+
+// TransactionQueryResponse contains the normalized state of a transaction.
+// Success is true if the lookup succeeded, regardless of the state of the transaction.
+// Amounts are in minor units of Currency and are 0 if not returned by the PsP.
+type TransactionQueryResponse struct {
+	Success              bool
+	TransactionReference string
+	State                TransactionState
+	StateRaw             string // PsP specific status the State was translated from
+	Currency             string
+	AuthorizedAmount     int64
+	CapturedAmount       int64
+	RefundedAmount       int64
+	Last4                string
+	Network              CreditCardNetwork
+	CreatedAt            *time.Time
+	UpdatedAt            *time.Time
+	ErrorCode            *string
+}
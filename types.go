@@ -91,12 +91,16 @@ const (
 	ResponseHeaderOption string = "ResponseHeader"
 	GooglePayTokenOption string = "GooglePayToken"
 	ApplePayTokenOption  string = "ApplePayToken"
+	// TimeoutReversalOption is set to true by reversal.Client on the authorizations it may reverse, for PsPs which
+	// need the authorization sent differently to reverse it later
+	TimeoutReversalOption string = "TimeoutReversal"
 )
 
 // AuthorizationRequest specifies needed information for request to authorize by PsPs
-// Note: Only credit cards are supported
+// Note: Only credit cards, or credit cards stored with a Vault, are supported
 // Note: Options is a generic key-value pair that can be used to provide additional information to PsP
 type AuthorizationRequest struct {
+	AllowPartialAuth              bool // opt in to approvals for less than Amount, such as on prepaid or gift cards
 	Amount                        Amount
 	BillingAddress                *Address
 	Channel                       string  // for PSPs that track the sales channel
@@ -109,7 +113,8 @@ type AuthorizationRequest struct {
 	PreviousExternalTransactionID *string                  // If we are in a recurring situation, then we can use the PreviousExternalTransactionID as part of the auth request
 	ProcessingInitiator           *ProcessingInitiatorType // For Card on File transactions we want to store the various different types (initial cof, initial recurring, etc)
 	ShippingAddress               *Address
-	ShopperReference              string // ShopperReference Unique reference to a shopper (shopperId, etc.)
+	ShopperReference              string               // ShopperReference Unique reference to a shopper (shopperId, etc.)
+	StoredPaymentMethod           *StoredPaymentMethod // For PsPs implementing Vault, authorize a stored payment method instead of CreditCard
 	ThreeDS                       *ThreeDS
 
 	Options map[string]interface{}
@@ -130,12 +135,14 @@ type AuthorizationResponse struct {
 	Success               bool
 	TransactionReference  string
 	ExternalTransactionID string
+	ApprovedAmount        *Amount // the amount approved when reported by the PsP, less than the requested amount on a partial approval
 	AvsResult             AVSResponse
 	CvvResult             CVVResponse
 	Response              string
 	ErrorCode             string
 	Message               string // message from the gateway describing the reason for a failed auth
 	ResultType            ResultType
+	DeclineReason         DeclineReason // the reason for a declined auth, translated from ErrorCode
 	AvsResultRaw          string
 	CvvResultRaw          string
 	RTAUResult            *RTAUResponse
@@ -159,13 +166,20 @@ type CaptureResponse struct {
 	Success              bool
 	TransactionReference string
 	ErrorCode            *string
+	Message              string // message from the gateway describing the reason for a failed capture
+	ResultType           ResultType
+	Amount               *Amount           // the amount captured, as reported by the PsP or else as requested
+	Metadata             map[string]string // store additional data that might be unique to PSP
+	StatusCode           int               // the status code from raw PSP http response.
+	Header               http.Header       // the http response header
 }
 
 // VoidRequest cancels an authorized transaction
 type VoidRequest struct {
 	TransactionReference       string
-	ClientTransactionReference *string // Custom transaction reference metadata that will be associated with this request
-	MerchantOrderReference     *string // Custom merchant order reference that will be associated with this request
+	ClientTransactionReference *string                // Custom transaction reference metadata that will be associated with this request
+	MerchantOrderReference     *string                // Custom merchant order reference that will be associated with this request
+	Options                    map[string]interface{} // For additional options that need to be passed in
 }
 
 // VoidResponse also specifies a transaction reference if PsP uses different transaction references for different states
@@ -173,6 +187,11 @@ type VoidResponse struct {
 	Success              bool
 	TransactionReference string
 	ErrorCode            *string
+	Message              string // message from the gateway describing the reason for a failed void
+	ResultType           ResultType
+	Metadata             map[string]string // store additional data that might be unique to PSP
+	StatusCode           int               // the status code from raw PSP http response.
+	Header               http.Header       // the http response header
 }
 
 // RefundRequest for refunding a captured transaction with generic Options and amount to be refunded
@@ -190,6 +209,12 @@ type RefundResponse struct {
 	Success              bool
 	TransactionReference string
 	ErrorCode            *string
+	Message              string // message from the gateway describing the reason for a failed refund
+	ResultType           ResultType
+	Amount               *Amount           // the amount refunded, as reported by the PsP or else as requested
+	Metadata             map[string]string // store additional data that might be unique to PSP
+	StatusCode           int               // the status code from raw PSP http response.
+	Header               http.Header       // the http response header
 }
 
 // TransactionDetailsRequest for fetching a transaction's details
@@ -255,6 +280,8 @@ type ThreeDS struct {
 	XID              string // Transaction ID from authentication processing (for 3DS1)
 }
 
+// ResultType classifies the outcome of an operation the same way across PsPs, so callers can tell a declined card from
+// a request to fix or a failure to retry
 type ResultType string
 
 const (
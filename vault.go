@@ -0,0 +1,71 @@
+package sleet
+
+import "context"
+
+// Vault is an optional interface implemented by clients of PsPs which can store payment methods for later use.
+// A stored payment method can be authorized by setting AuthorizationRequest.StoredPaymentMethod instead of CreditCard.
+type Vault interface {
+	StorePaymentMethod(request *StorePaymentMethodRequest) (*StorePaymentMethodResponse, error)
+	GetPaymentMethod(request *GetPaymentMethodRequest) (*GetPaymentMethodResponse, error)
+	DeletePaymentMethod(request *DeletePaymentMethodRequest) (*DeletePaymentMethodResponse, error)
+}
+
+// VaultWithContext is a superset of `Vault` that includes additional methods that take
+// `context.Context` as parameters.
+type VaultWithContext interface {
+	Vault
+	StorePaymentMethodWithContext(ctx context.Context, request *StorePaymentMethodRequest) (*StorePaymentMethodResponse, error)
+	GetPaymentMethodWithContext(ctx context.Context, request *GetPaymentMethodRequest) (*GetPaymentMethodResponse, error)
+	DeletePaymentMethodWithContext(ctx context.Context, request *DeletePaymentMethodRequest) (*DeletePaymentMethodResponse, error)
+}
+
+// StoredPaymentMethod references a payment method stored in a PsP vault.
+// Both fields are PsP references and should be persisted as returned by StorePaymentMethod.
+type StoredPaymentMethod struct {
+	CustomerReference string // PsP reference of the customer (profile) owning the payment method, empty if the PsP has none
+	Token             string // PsP reference of the payment method itself
+}
+
+// StorePaymentMethodRequest stores a credit card in the PsP vault.
+// If CustomerReference is empty a new customer is created for PsPs which attach payment methods to customers.
+type StorePaymentMethodRequest struct {
+	CreditCard        *CreditCard
+	BillingAddress    *Address
+	CustomerReference string // PsP reference of an existing customer to add the payment method to
+	ShopperReference  string // merchant's reference to the shopper, stored on the new customer where supported
+	Options           map[string]interface{}
+}
+
+// StorePaymentMethodResponse has Success be true if the payment method was stored and a reference to use it
+type StorePaymentMethodResponse struct {
+	Success             bool
+	StoredPaymentMethod *StoredPaymentMethod
+	ErrorCode           *string
+}
+
+// GetPaymentMethodRequest retrieves a stored payment method
+type GetPaymentMethodRequest struct {
+	StoredPaymentMethod StoredPaymentMethod
+}
+
+// GetPaymentMethodResponse contains the non sensitive details of a stored payment method
+type GetPaymentMethodResponse struct {
+	Success             bool
+	StoredPaymentMethod *StoredPaymentMethod
+	Last4               string
+	ExpirationMonth     int // 0 if not returned by the PsP
+	ExpirationYear      int // 0 if not returned by the PsP
+	Network             CreditCardNetwork
+	ErrorCode           *string
+}
+
+// DeletePaymentMethodRequest removes a stored payment method from the PsP vault
+type DeletePaymentMethodRequest struct {
+	StoredPaymentMethod StoredPaymentMethod
+}
+
+// DeletePaymentMethodResponse has Success be true if the payment method was deleted
+type DeletePaymentMethodResponse struct {
+	Success   bool
+	ErrorCode *string
+}
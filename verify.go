@@ -0,0 +1,89 @@
+package sleet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// VerificationFallbackAmount is the amount, in minor units, authorized by VerifyByAuthorization when the request
+// has no amount
+const VerificationFallbackAmount int64 = 100
+
+// Verifier is implemented by clients which can validate a card, including its AVS and CVV results, without holding
+// funds. It is meant for checking cards when they are saved.
+type Verifier interface {
+	Verify(request *VerificationRequest) (*VerificationResponse, error)
+}
+
+// VerifierWithContext is a superset of `Verifier` that includes additional methods that take
+// `context.Context` as parameters.
+type VerifierWithContext interface {
+	Verifier
+	VerifyWithContext(ctx context.Context, request *VerificationRequest) (*VerificationResponse, error)
+}
+
+// VerificationRequest takes the same information as an authorization. Native verifications are for a zero amount,
+// so only Amount.Currency is used by them.
+type VerificationRequest = AuthorizationRequest
+
+// VerificationResponse has the same fields as an AuthorizationResponse. Success is true if the card was verified,
+// AvsResult and CvvResult hold the results of the checks.
+type VerificationResponse struct {
+	AuthorizationResponse
+}
+
+// VerifyByAuthorization verifies a card for PsPs without a native verification by authorizing the request amount,
+// or VerificationFallbackAmount if it is zero, and voiding the authorization once approved. The response is returned
+// with a verify *Error if the authorization could not be voided, its Gateway is left to the caller when the void was
+// declined.
+func VerifyByAuthorization(ctx context.Context, client ClientWithContext, request *VerificationRequest) (*VerificationResponse, error) {
+	authRequest := *request
+	if authRequest.Amount.Amount == 0 {
+		authRequest.Amount.Amount = VerificationFallbackAmount
+	}
+
+	authResponse, err := client.AuthorizeWithContext(ctx, &authRequest)
+	if authResponse == nil {
+		return nil, err
+	}
+	response := &VerificationResponse{AuthorizationResponse: *authResponse}
+	if err != nil || !authResponse.Success {
+		return response, err
+	}
+
+	merchantOrderReference := request.MerchantOrderReference
+	voidResponse, err := client.VoidWithContext(ctx, &VoidRequest{
+		TransactionReference:       authResponse.TransactionReference,
+		ClientTransactionReference: request.ClientTransactionReference,
+		MerchantOrderReference:     &merchantOrderReference,
+	})
+	if err != nil {
+		verifyErr := &Error{
+			Kind:      ErrorKindPSPAPI,
+			Operation: OperationVerify,
+			Err:       fmt.Errorf("verification authorization %s was not voided: %w", authResponse.TransactionReference, err),
+		}
+		// keep the classification of the void's error
+		var voidErr *Error
+		if errors.As(err, &voidErr) {
+			verifyErr.Kind = voidErr.Kind
+			verifyErr.Gateway = voidErr.Gateway
+			verifyErr.Retryable = voidErr.Retryable
+			verifyErr.StatusCode = voidErr.StatusCode
+		}
+		return response, verifyErr
+	}
+	if !voidResponse.Success {
+		var errorCode string
+		if voidResponse.ErrorCode != nil {
+			errorCode = *voidResponse.ErrorCode
+		}
+		return response, &Error{
+			Kind:      ErrorKindPSPAPI,
+			Operation: OperationVerify,
+			Err:       fmt.Errorf("verification authorization %s was not voided, error code %s", authResponse.TransactionReference, errorCode),
+		}
+	}
+	return response, nil
+}
@@ -0,0 +1,111 @@
+package sleet
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// verifyTestClient approves authorizations when approve is set and records the voided transactions
+type verifyTestClient struct {
+	approve     bool
+	voidSuccess bool
+	voidErr     error
+	authorized  []int64
+	voided      []string
+}
+
+func (c *verifyTestClient) Authorize(request *AuthorizationRequest) (*AuthorizationResponse, error) {
+	return c.AuthorizeWithContext(context.TODO(), request)
+}
+
+func (c *verifyTestClient) AuthorizeWithContext(_ context.Context, request *AuthorizationRequest) (*AuthorizationResponse, error) {
+	c.authorized = append(c.authorized, request.Amount.Amount)
+	return &AuthorizationResponse{Success: c.approve, TransactionReference: "auth", CvvResult: CVVResponseMatch}, nil
+}
+
+func (c *verifyTestClient) Capture(request *CaptureRequest) (*CaptureResponse, error) {
+	return c.CaptureWithContext(context.TODO(), request)
+}
+
+func (c *verifyTestClient) CaptureWithContext(_ context.Context, _ *CaptureRequest) (*CaptureResponse, error) {
+	return &CaptureResponse{Success: true}, nil
+}
+
+func (c *verifyTestClient) Void(request *VoidRequest) (*VoidResponse, error) {
+	return c.VoidWithContext(context.TODO(), request)
+}
+
+func (c *verifyTestClient) VoidWithContext(_ context.Context, request *VoidRequest) (*VoidResponse, error) {
+	c.voided = append(c.voided, request.TransactionReference)
+	if c.voidErr != nil {
+		return nil, c.voidErr
+	}
+	return &VoidResponse{Success: c.voidSuccess}, nil
+}
+
+func (c *verifyTestClient) Refund(request *RefundRequest) (*RefundResponse, error) {
+	return c.RefundWithContext(context.TODO(), request)
+}
+
+func (c *verifyTestClient) RefundWithContext(_ context.Context, _ *RefundRequest) (*RefundResponse, error) {
+	return &RefundResponse{Success: true}, nil
+}
+
+func TestVerifyByAuthorization(t *testing.T) {
+	request := &VerificationRequest{Amount: Amount{Currency: "USD"}, CreditCard: &CreditCard{Number: "4111111111111111"}}
+
+	t.Run("Approved Authorization Is Voided", func(t *testing.T) {
+		client := &verifyTestClient{approve: true, voidSuccess: true}
+		got, err := VerifyByAuthorization(context.TODO(), client, request)
+		if err != nil || !got.Success || got.CvvResult != CVVResponseMatch {
+			t.Fatalf("expected a successful verification, got %+v, %v", got, err)
+		}
+		if len(client.authorized) != 1 || client.authorized[0] != VerificationFallbackAmount {
+			t.Errorf("expected an authorization of the fallback amount, got %v", client.authorized)
+		}
+		if len(client.voided) != 1 || client.voided[0] != "auth" {
+			t.Errorf("expected the authorization to be voided, got %v", client.voided)
+		}
+	})
+
+	t.Run("Declined Authorization Is Not Voided", func(t *testing.T) {
+		client := &verifyTestClient{}
+		got, err := VerifyByAuthorization(context.TODO(), client, request)
+		if err != nil || got.Success {
+			t.Fatalf("expected an unsuccessful verification, got %+v, %v", got, err)
+		}
+		if len(client.voided) != 0 {
+			t.Errorf("expected no void, got %v", client.voided)
+		}
+	})
+
+	t.Run("Failed Void", func(t *testing.T) {
+		client := &verifyTestClient{approve: true}
+		got, err := VerifyByAuthorization(context.TODO(), client, request)
+		if err == nil || got == nil || !got.Success {
+			t.Fatalf("expected the verification to be returned with an error, got %+v, %v", got, err)
+		}
+		var sleetErr *Error
+		if !errors.As(err, &sleetErr) || sleetErr.Operation != OperationVerify || sleetErr.Kind != ErrorKindPSPAPI {
+			t.Errorf("expected a verify psp_api *Error, got %v", err)
+		}
+	})
+
+	t.Run("Void Error", func(t *testing.T) {
+		voidErr := &Error{Kind: ErrorKindTimeout, Gateway: "test", Operation: OperationVoid, Retryable: true, Err: context.DeadlineExceeded}
+		client := &verifyTestClient{approve: true, voidErr: voidErr}
+		got, err := VerifyByAuthorization(context.TODO(), client, request)
+		if err == nil || got == nil || !got.Success {
+			t.Fatalf("expected the verification to be returned with an error, got %+v, %v", got, err)
+		}
+		var sleetErr *Error
+		if !errors.As(err, &sleetErr) || sleetErr.Operation != OperationVerify || sleetErr.Kind != ErrorKindTimeout ||
+			sleetErr.Gateway != "test" || !sleetErr.Retryable {
+			t.Errorf("expected a verify *Error classified as the void's, got %+v", sleetErr)
+		}
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected the cause of the void's error to be kept, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,65 @@
+package webhooks
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/BoltApp/sleet"
+)
+
+// ErrInvalidSignature is returned by a Parser when a notification's signature does not match the payload.
+// Notifications failing verification must not be trusted and should be rejected by the caller.
+var ErrInvalidSignature = errors.New("webhooks: invalid signature")
+
+// ErrMissingSignature is returned by a Parser when a notification carries no signature to verify.
+var ErrMissingSignature = errors.New("webhooks: missing signature")
+
+// maxBodySize caps the number of bytes read from an incoming webhook request
+const maxBodySize = 1 << 20
+
+// EventKind is the normalized kind of a PsP notification
+type EventKind string
+
+const (
+	EventKindAuthorization EventKind = "authorization"
+	EventKindCapture       EventKind = "capture"
+	EventKindSale          EventKind = "sale" // authorization and capture in one step
+	EventKindVoid          EventKind = "void"
+	EventKindRefund        EventKind = "refund"
+	EventKindSettlement    EventKind = "settlement"
+	EventKindChargeback    EventKind = "chargeback"
+	EventKindUnknown       EventKind = "unknown" // the PsP sent an event sleet does not map, see PsPEventType
+)
+
+// WebhookEvent is a generic notification translated from a PsP specific webhook payload
+// TransactionReference matches the TransactionReference returned by the sleet client for the same PsP.
+// For events about follow-up operations (capture, refund, etc.), OriginalTransactionReference holds the
+// reference of the authorization when the PsP sends it.
+type WebhookEvent struct {
+	Kind                         EventKind
+	PsPEventType                 string // the untranslated event type/code sent by the PsP
+	EventID                      string // unique id of the notification if provided by the PsP, useful for de-duplication
+	TransactionReference         string
+	OriginalTransactionReference string
+	MerchantReference            string        // merchant provided reference (order id, client transaction reference) if sent back by the PsP
+	Amount                       *sleet.Amount // nil if the PsP does not include an amount in the notification
+	Success                      bool
+	RawPayload                   []byte
+}
+
+// Parser verifies the signature of a PsP notification and translates it into generic webhook events.
+// Some PsPs batch several notifications in a single request, so more than one event can be returned.
+// ErrInvalidSignature or ErrMissingSignature is returned if the notification cannot be verified.
+type Parser interface {
+	Parse(header http.Header, body []byte) ([]WebhookEvent, error)
+}
+
+// ParseRequest reads the body of an incoming webhook request and parses it with the given parser
+func ParseRequest(parser Parser, request *http.Request) ([]WebhookEvent, error) {
+	body, err := ioutil.ReadAll(http.MaxBytesReader(nil, request.Body, maxBodySize))
+	if err != nil {
+		return nil, err
+	}
+	return parser.Parse(request.Header, body)
+}
@@ -0,0 +1,142 @@
+package wirelog_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/BoltApp/sleet"
+	"github.com/BoltApp/sleet/common"
+	"github.com/BoltApp/sleet/gateways/adyen"
+	"github.com/BoltApp/sleet/gateways/authorizenet"
+	"github.com/BoltApp/sleet/gateways/braintree"
+	"github.com/BoltApp/sleet/gateways/cardconnect"
+	"github.com/BoltApp/sleet/gateways/checkoutcom"
+	"github.com/BoltApp/sleet/gateways/cybersource"
+	"github.com/BoltApp/sleet/gateways/firstdata"
+	"github.com/BoltApp/sleet/gateways/nmi"
+	"github.com/BoltApp/sleet/gateways/orbital"
+	"github.com/BoltApp/sleet/gateways/paypalpayflow"
+	"github.com/BoltApp/sleet/gateways/rocketgate"
+	sleet_stripe "github.com/BoltApp/sleet/gateways/stripe"
+	sleet_t "github.com/BoltApp/sleet/testing"
+	"github.com/BoltApp/sleet/wirelog"
+	"github.com/stripe/stripe-go"
+)
+
+const (
+	testCVV        = "987"
+	testCryptogram = "AgAAAAAAAIR8CQrXcIhbQAAAAAA="
+	testSecret     = "s3cr3t-credential"
+)
+
+// echoTransport answers every request with its own body, so the response carries the card data back to the logger
+type echoTransport struct{}
+
+func (echoTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	body := []byte{}
+	if request.Body != nil {
+		body, _ = ioutil.ReadAll(request.Body)
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": request.Header["Content-Type"]},
+		Body:       ioutil.NopCloser(strings.NewReader(string(body))),
+		Request:    request,
+	}, nil
+}
+
+// cvvValue matches the test CVV as a JSON, XML or name-value field value
+var cvvValue = regexp.MustCompile(`[">=:]\s*` + testCVV + `\s*(?:[<"&,}]|$)`)
+
+func TestNoCardDataLeaksFromGateways(t *testing.T) {
+	var entries []*wirelog.Entry
+	httpClient := wirelog.NewHTTPClient(&http.Client{Transport: echoTransport{}}, func(_ context.Context, entry *wirelog.Entry) {
+		entries = append(entries, entry)
+	})
+
+	// stripe-go sends requests through its global backend
+	stripe.SetBackend(stripe.APIBackend, stripe.GetBackendWithConfig(stripe.APIBackend, &stripe.BackendConfig{HTTPClient: httpClient}))
+	defer stripe.SetBackend(stripe.APIBackend, nil)
+
+	gateways := map[string]sleet.ClientWithContext{
+		"adyen":         adyen.NewWithHTTPClient("account", testSecret, "", common.Sandbox, httpClient),
+		"authorizenet":  authorizenet.NewWithHttpClient("name", testSecret, common.Sandbox, httpClient),
+		"braintree":     braintree.NewWithHttpClient("merchant", "public", testSecret, common.Sandbox, httpClient),
+		"cardconnect":   cardconnect.NewWithHttpClient("user", testSecret, "merchant", "https://example.cardconnect.com", common.Sandbox, httpClient),
+		"checkoutcom":   checkoutcom.NewWithHTTPClient(common.Sandbox, "sk_sbox_"+testSecret, nil, httpClient),
+		"cybersource":   cybersource.NewWithHttpClient(common.Sandbox, "merchant", "key id", "czNjcjN0LWNyZWRlbnRpYWw=", httpClient),
+		"firstdata":     firstdata.NewWithHttpClient(common.Sandbox, firstdata.Credentials{ApiKey: testSecret, ApiSecret: testSecret}, httpClient),
+		"nmi":           nmi.NewWithHttpClient(common.Sandbox, testSecret, httpClient),
+		"orbital":       orbital.NewWithHttpClient(common.Sandbox, orbital.Credentials{Username: "user", Password: testSecret, MerchantID: 123456}, httpClient),
+		"paypalpayflow": paypalpayflow.NewWithHttpClient("partner", testSecret, "vendor", "user", common.Sandbox, httpClient),
+		"rocketgate":    rocketgate.NewWithHttpClient(common.Sandbox, "merchant", testSecret, nil, httpClient),
+		"stripe":        sleet_stripe.NewWithHTTPClient(testSecret, httpClient),
+	}
+
+	requests := map[string]func() *sleet.AuthorizationRequest{
+		"Card": func() *sleet.AuthorizationRequest {
+			request := sleet_t.BaseAuthorizationRequest()
+			request.CreditCard.CVV = testCVV
+			return request
+		},
+		"Network Token": func() *sleet.AuthorizationRequest {
+			request := sleet_t.BaseAuthorizationRequest()
+			request.CreditCard.CVV = ""
+			request.Cryptogram = testCryptogram
+			request.ECI = "05"
+			request.CreditCard.Network = sleet.CreditCardNetworkVisa
+			return request
+		},
+	}
+
+	for name, client := range gateways {
+		for label, request := range requests {
+			t.Run(name+" "+label, func(t *testing.T) {
+				entries = nil
+				func() {
+					// the echoed responses aren't valid PsP responses, only the logged exchange is checked
+					defer func() { recover() }()
+					client.AuthorizeWithContext(context.Background(), request())
+				}()
+
+				if len(entries) == 0 {
+					t.Fatal("expected the request to be sent through the logged http client")
+				}
+				for _, entry := range entries {
+					assertRedacted(t, entry)
+				}
+			})
+		}
+	}
+}
+
+func assertRedacted(t *testing.T, entry *wirelog.Entry) {
+	t.Helper()
+	logged := entry.URL + string(entry.RequestBody) + string(entry.ResponseBody)
+	for _, header := range []http.Header{entry.RequestHeader, entry.ResponseHeader} {
+		for name, values := range header {
+			logged += name + ": " + strings.Join(values, ",") + "\n"
+		}
+	}
+
+	pan := sleet_t.BaseAuthorizationRequest().CreditCard.Number
+	if strings.Contains(logged, pan) {
+		t.Errorf("card number leaked: %s", logged)
+	}
+	if cvvValue.MatchString(logged) {
+		t.Errorf("CVV leaked: %s", logged)
+	}
+	if strings.Contains(logged, testCryptogram) {
+		t.Errorf("cryptogram leaked: %s", logged)
+	}
+	if strings.Contains(logged, testSecret) {
+		t.Errorf("credential leaked: %s", logged)
+	}
+	if len(entry.RequestBody) > 0 && !strings.Contains(string(entry.RequestBody), wirelog.MaskPAN(pan)) {
+		t.Errorf("expected the request body to keep a masked card number: %s", entry.RequestBody)
+	}
+}
@@ -0,0 +1,302 @@
+package wirelog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces the values of secrets, and of bodies which could not be parsed to find them
+const Redacted = "[REDACTED]"
+
+// panFields are the names, normalized by normalizeName, under which the gateways send card numbers
+var panFields = map[string]bool{
+	"account":              true, // CardConnect
+	"accountnum":           true, // Orbital
+	"accountnumber":        true,
+	"acct":                 true, // Payflow
+	"cardno":               true, // RocketGate
+	"cardnumber":           true,
+	"ccnumber":             true, // NMI, Braintree
+	"encryptedcardnumber":  true,
+	"number":               true,
+	"pan":                  true,
+	"primaryaccountnumber": true,
+}
+
+// secretFields are the names of card security codes and other values which are removed entirely
+var secretFields = map[string]bool{
+	"cardcode":               true, // Authorize.net
+	"cardsecval":             true, // Orbital
+	"cardverificationvalue":  true,
+	"cavv":                   true,
+	"cvc":                    true,
+	"cvc2":                   true,
+	"cvd":                    true,
+	"cvn":                    true,
+	"cvv":                    true,
+	"cvv2":                   true,
+	"encryptedsecuritycode":  true,
+	"pwd":                    true, // Payflow
+	"securitycode":           true,
+	"tavv":                   true,
+	"ucafauthenticationdata": true,
+	"xid":                    true, // CyberSource sends Apple Pay cryptograms as the XID
+}
+
+// secretFragments redact any field whose name contains them, such as transactionKey or merchantPassword
+var secretFragments = []string{"apikey", "cryptogram", "password", "privatekey", "secret", "securitykey", "signature", "transactionkey"}
+
+// secretHeaderFragments redact any header whose name contains them, covering credentials and request signatures
+var secretHeaderFragments = []string{"auth", "cookie", "digest", "key", "password", "secret", "signature", "token"}
+
+var (
+	digitRuns  = regexp.MustCompile(`[0-9]+`)
+	xmlElement = regexp.MustCompile(`<(?:[\w.-]+:)?([\w.-]+)(?:\s[^<>]*)?>([^<]*)`)
+)
+
+// normalizeName lowercases a field name and drops separators, so card_number, cardNumber and card-number match
+func normalizeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		}
+		return -1
+	}, name)
+}
+
+func isSecret(name string) bool {
+	name = normalizeName(name)
+	if secretFields[name] {
+		return true
+	}
+	for _, fragment := range secretFragments {
+		if strings.Contains(name, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+func isPAN(name string) bool {
+	return panFields[normalizeName(name)]
+}
+
+// redactField returns the value to log for a field, or the value unchanged if the field holds no card data
+func redactField(name string, value string) string {
+	switch {
+	case isSecret(name):
+		if value == "" {
+			return value
+		}
+		return Redacted
+	case isPAN(name):
+		return MaskPAN(value)
+	}
+	return MaskPANs(value)
+}
+
+// MaskPAN masks a card number to its BIN and last four digits. Values which are not a plain card number are
+// redacted unless they hold at most four digits, as PsPs echo already masked numbers such as XXXX1111.
+func MaskPAN(pan string) string {
+	if len(pan) >= 13 && len(pan) <= 19 && digitRuns.FindString(pan) == pan {
+		return pan[:6] + strings.Repeat("*", len(pan)-10) + pan[len(pan)-4:]
+	}
+	digits := 0
+	for _, r := range pan {
+		if r >= '0' && r <= '9' {
+			digits++
+		}
+	}
+	if digits <= 4 {
+		return pan
+	}
+	return Redacted
+}
+
+// MaskPANs masks every run of 13 to 19 digits passing the Luhn check, wherever it appears in the text. Other
+// numbers of that length, such as millisecond timestamps, are masked when they happen to pass the check.
+func MaskPANs(text string) string {
+	return digitRuns.ReplaceAllStringFunc(text, func(digits string) string {
+		if len(digits) < 13 || len(digits) > 19 || !luhn(digits) {
+			return digits
+		}
+		return MaskPAN(digits)
+	})
+}
+
+func luhn(digits string) bool {
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		digit := int(digits[i] - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// RedactHeader returns a copy of the header with credentials, signatures and cookies redacted
+func RedactHeader(header http.Header) http.Header {
+	redacted := make(http.Header, len(header))
+	for name, values := range header {
+		normalized := normalizeName(name)
+		secret := false
+		for _, fragment := range secretHeaderFragments {
+			if strings.Contains(normalized, fragment) {
+				secret = true
+				break
+			}
+		}
+		redacted[name] = make([]string, len(values))
+		for i, value := range values {
+			if secret {
+				redacted[name][i] = Redacted
+			} else {
+				redacted[name][i] = MaskPANs(value)
+			}
+		}
+	}
+	return redacted
+}
+
+// RedactURL returns the URL with the values of its query redacted as name-value pairs
+func RedactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	redacted := *u
+	redacted.User = nil
+	redacted.RawQuery = string(redactNameValue([]byte(u.RawQuery)))
+	return redacted.String()
+}
+
+// Redact returns the body with card numbers masked to BIN and last four, and security codes, cryptograms and
+// credentials removed. JSON, XML and name-value bodies are recognized by their content type, or by their first
+// character when the content type is not specific. Other bodies have only their card numbers masked.
+func Redact(contentType string, body []byte) []byte {
+	if len(body) == 0 {
+		return body
+	}
+	contentType = strings.ToLower(contentType)
+	trimmed := bytes.TrimSpace(body)
+	switch {
+	case strings.Contains(contentType, "json"), len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '['):
+		return redactJSON(body)
+	case strings.Contains(contentType, "xml"), strings.Contains(contentType, "html"), len(trimmed) > 0 && trimmed[0] == '<':
+		return redactXML(body)
+	case strings.Contains(contentType, "form-urlencoded"), strings.Contains(contentType, "namevalue"), bytes.Contains(body, []byte("=")):
+		return redactNameValue(body)
+	}
+	return []byte(MaskPANs(string(body)))
+}
+
+// redactJSON redacts the fields of a JSON body. A body which can't be parsed is redacted entirely, as a truncated
+// payload could still hold a security code.
+func redactJSON(body []byte) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return []byte(Redacted)
+	}
+
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(redactJSONValue("", value)); err != nil {
+		return []byte(Redacted)
+	}
+	return bytes.TrimSuffix(buffer.Bytes(), []byte("\n"))
+}
+
+func redactJSONValue(name string, value interface{}) interface{} {
+	if value != nil && value != "" && isSecret(name) {
+		return Redacted
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			v[key] = redactJSONValue(key, field)
+		}
+		return v
+	case []interface{}:
+		for i, element := range v {
+			v[i] = redactJSONValue(name, element)
+		}
+		return v
+	case string:
+		return redactField(name, v)
+	case json.Number:
+		if masked := redactField(name, v.String()); masked != v.String() {
+			return masked
+		}
+	}
+	return value
+}
+
+// redactXML redacts the text of the elements named as card data, then masks card numbers anywhere in the body
+func redactXML(body []byte) []byte {
+	text := string(body)
+	var builder strings.Builder
+	last := 0
+	for _, match := range xmlElement.FindAllStringSubmatchIndex(text, -1) {
+		name := text[match[2]:match[3]]
+		builder.WriteString(text[last:match[4]])
+		builder.WriteString(redactField(name, text[match[4]:match[5]]))
+		last = match[5]
+	}
+	builder.WriteString(text[last:])
+	return []byte(MaskPANs(builder.String()))
+}
+
+// redactNameValue redacts URL encoded forms and Payflow's name-value pairs. Payflow length tags such as ACCT[16]
+// and nested form names such as card[number] are matched on the innermost name.
+func redactNameValue(body []byte) []byte {
+	pairs := strings.Split(string(body), "&")
+	for i, pair := range pairs {
+		separator := strings.Index(pair, "=")
+		if separator < 0 {
+			pairs[i] = MaskPANs(pair)
+			continue
+		}
+		name, value := pair[:separator], pair[separator+1:]
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		field := fieldName(name)
+		if unescaped, err := url.QueryUnescape(value); err == nil && (isSecret(field) || isPAN(field)) {
+			value = unescaped
+		}
+		pairs[i] = pair[:separator+1] + redactField(field, value)
+	}
+	return []byte(strings.Join(pairs, "&"))
+}
+
+// fieldName returns the innermost non numeric name of a form field, so both ACCT[16] and card[number] match
+func fieldName(name string) string {
+	for strings.HasSuffix(name, "]") {
+		open := strings.LastIndex(name, "[")
+		if open < 0 {
+			break
+		}
+		inner := name[open+1 : len(name)-1]
+		if inner != "" && digitRuns.FindString(inner) != inner {
+			return inner
+		}
+		name = name[:open]
+	}
+	return name
+}
@@ -0,0 +1,103 @@
+// Package wirelog logs the HTTP requests and responses exchanged with PsPs. Every gateway accepts an *http.Client,
+// so wire logging is enabled by passing the client returned by NewHTTPClient to the gateway's NewWithHttpClient.
+// Bodies, headers and URLs are redacted before they are logged, so card numbers are masked to BIN and last four,
+// and security codes, cryptograms, credentials and signatures never reach the logs.
+package wirelog
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// Entry is a redacted HTTP exchange with a PsP
+type Entry struct {
+	Method         string
+	URL            string
+	RequestHeader  http.Header
+	RequestBody    []byte
+	StatusCode     int // 0 if no response was received
+	ResponseHeader http.Header
+	ResponseBody   []byte
+	Duration       time.Duration
+	Err            error // the transport error, if no response was received
+}
+
+// Transport is an http.RoundTripper logging each exchange through Next once the response body has been read
+type Transport struct {
+	Next http.RoundTripper                       // http.DefaultTransport if nil
+	Log  func(ctx context.Context, entry *Entry) // nothing is logged if nil
+}
+
+// NewHTTPClient returns a copy of httpClient whose requests and responses are logged, a default client if nil. A nil
+// log logs nothing.
+func NewHTTPClient(httpClient *http.Client, log func(ctx context.Context, entry *Entry)) *http.Client {
+	logged := &http.Client{}
+	if httpClient != nil {
+		*logged = *httpClient
+	}
+	logged.Transport = &Transport{Next: logged.Transport, Log: log}
+	return logged
+}
+
+// RoundTrip sends the request through Next and logs the redacted exchange. The response body is read so it can be
+// logged, and replaced so the gateway reads it unchanged.
+func (t *Transport) RoundTrip(request *http.Request) (*http.Response, error) {
+	next := t.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	entry := &Entry{
+		Method:        request.Method,
+		URL:           RedactURL(request.URL),
+		RequestHeader: RedactHeader(request.Header),
+	}
+	if request.Body != nil && request.Body != http.NoBody {
+		body, err := ioutil.ReadAll(request.Body)
+		request.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		request = request.Clone(request.Context())
+		request.Body = ioutil.NopCloser(bytes.NewReader(body))
+		// redirects and retries resend the request through GetBody, which must return the body read here
+		request.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(body)), nil
+		}
+		entry.RequestBody = Redact(request.Header.Get("Content-Type"), body)
+	}
+
+	start := time.Now()
+	response, err := next.RoundTrip(request)
+	entry.Duration = time.Since(start)
+	if err != nil {
+		entry.Err = err
+		t.log(request.Context(), entry)
+		return nil, err
+	}
+
+	entry.StatusCode = response.StatusCode
+	entry.ResponseHeader = RedactHeader(response.Header)
+	body, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		entry.Err = err
+		t.log(request.Context(), entry)
+		return nil, err
+	}
+	response.Body = ioutil.NopCloser(bytes.NewReader(body))
+	entry.ResponseBody = Redact(response.Header.Get("Content-Type"), body)
+	t.log(request.Context(), entry)
+	return response, nil
+}
+
+// log passes the entry to Log, unless it is nil
+func (t *Transport) log(ctx context.Context, entry *Entry) {
+	if t.Log != nil {
+		t.Log(ctx, entry)
+	}
+}